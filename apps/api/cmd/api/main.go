@@ -87,14 +87,28 @@ func main() {
 	emailService := core.NewEmailService(database)
 	regService := core.NewRegistrationService(database, redisClient)
 	facilitiesService := core.NewFacilitiesService(database, redisClient)
+	googleCalendar := core.NewGoogleCalendarService(database)
+	syncClient := core.NewSyncClient(database)
 
 	// Initialize job manager
-	jobManager := jobs.NewJobManager(database, emailService)
+	jobManager := jobs.NewJobManager(database, emailService, googleCalendar)
 	jobManager.Start()
 	defer jobManager.Stop()
 
+	// Central platform sync runs only when SYNC_ENABLED is set
+	if syncClient.Enabled() {
+		syncWorker := jobs.NewSyncWorker(database, syncClient)
+		syncWorker.Start()
+		defer syncWorker.Stop()
+	}
+
+	// Check-in codes are signed, so event check-in stays off without a secret
+	if !core.CheckInEnabled() {
+		log.Println("CHECKIN_TOKEN_SECRET and JWT_SECRET are unset; event check-in is disabled")
+	}
+
 	// Initialize HTTP handler
-	handler := http.NewHandler(database, regService, facilitiesService)
+	handler := http.NewHandler(database, regService, facilitiesService, googleCalendar, syncClient, emailService)
 
 	// Setup Gin
 	if os.Getenv("GIN_MODE") == "" {
@@ -140,18 +154,30 @@ func main() {
 		// Facilities (public)
 		api.GET("/facilities", handler.GetFacilities)
 		api.GET("/facilities/:slug", handler.GetFacilityBySlug)
-		api.GET("/facilities/:slug/availability", handler.GetAvailability)
+		api.GET("/facilities/:slug/availability", http.OptionalAuthMiddleware(), handler.GetAvailability)
+		api.GET("/facilities/:slug/next-available", http.OptionalAuthMiddleware(), handler.GetNextAvailable)
+		api.GET("/facilities/:slug/hours", http.OptionalAuthMiddleware(), handler.GetFacilityHours)
+		api.POST("/facilities/:slug/quote", http.OptionalAuthMiddleware(), handler.GetBookingQuote)
 
 		// Waivers (public)
 		api.GET("/waivers/program/:program_id", handler.GetProgramWaivers)
 
 		// Form templates (public)
 		api.GET("/form-templates", handler.GetFormTemplates)
+
+		// Google OAuth redirect target; the user is identified by the signed state
+		api.GET("/me/integrations/google/callback", handler.GoogleCalendarCallback)
+
+		// Email provider delivery webhook; authenticated by the provider's signature
+		api.POST("/public/email/webhook", handler.EmailWebhook)
+
+		// Leaving an impersonation works even once its session has expired
+		api.POST("/impersonation/end", handler.EndImpersonation)
 	}
 
 	// Protected routes (auth required)
 	protected := router.Group("/api")
-	protected.Use(http.AuthMiddleware())
+	protected.Use(http.AuthMiddleware(database))
 	{
 		protected.POST("/logout", handler.Logout)
 		protected.GET("/me", handler.GetMe)
@@ -159,6 +185,13 @@ func main() {
 		// Family/Household management
 		protected.GET("/household", handler.GetHousehold)
 		protected.PUT("/household", handler.UpdateHousehold)
+		protected.GET("/me/family-schedule", handler.GetFamilySchedule)
+		protected.GET("/me/notifications/history", handler.GetMyNotificationHistory)
+
+		// Google Calendar integration
+		protected.GET("/me/integrations/google", handler.GetGoogleCalendarIntegration)
+		protected.GET("/me/integrations/google/connect", handler.ConnectGoogleCalendar)
+		protected.DELETE("/me/integrations/google", handler.DisconnectGoogleCalendar)
 
 		// Participant management
 		protected.GET("/participants", handler.GetParticipants)
@@ -166,6 +199,7 @@ func main() {
 		protected.PUT("/participants/:id", handler.UpdateParticipantEnhanced)
 		protected.DELETE("/participants/:id", handler.DeleteParticipantEnhanced)
 		protected.GET("/participants/:id/eligibility", handler.GetParticipantEligibility)
+		protected.GET("/participants/:id/eligible-programs", handler.GetParticipantEligiblePrograms)
 
 		// Participant waivers and forms
 		protected.POST("/participants/:id/waivers/:waiver_id/accept", handler.AcceptParticipantWaiver)
@@ -174,77 +208,174 @@ func main() {
 		protected.GET("/participants/:id/forms", handler.GetParticipantForms)
 
 		// Registration
+		protected.POST("/programs/:id/interest", handler.AddProgramInterest)
+		protected.GET("/registrations", handler.GetMyRegistrations)
 		protected.POST("/registrations", handler.CreateRegistration)
+		protected.POST("/registrations/batch", handler.CreateRegistrationBatch)
+		protected.POST("/registrations/cart/validate", handler.ValidateRegistrationCart)
 		protected.POST("/registrations/cancel", handler.CancelRegistration)
+		protected.GET("/registrations/:id/waitlist", handler.GetRegistrationWaitlist)
+		protected.GET("/registrations/:id/waitlist-position", handler.GetRegistrationWaitlistPosition)
+		protected.PUT("/registrations/:id/waitlist-preferences", handler.UpdateWaitlistPreferences)
+		protected.POST("/registrations/:id/accept-offer", handler.AcceptWaitlistOffer)
+		protected.POST("/registrations/:id/decline-offer", handler.DeclineWaitlistOffer)
+		protected.POST("/registrations/:id/transfer", handler.TransferRegistration)
+		protected.POST("/registrations/:id/pause", handler.PauseRegistration)
+		protected.POST("/registrations/:id/resume", handler.ResumeRegistration)
 
 		// Facility bookings (authenticated)
 		protected.POST("/bookings", handler.CreateBooking)
+		protected.POST("/bookings/recurring", handler.CreateRecurringBooking)
+		protected.POST("/bookings/reserve", handler.ReserveBooking)
+		protected.POST("/bookings/:id/confirm", handler.ConfirmBooking)
 		protected.GET("/bookings", handler.GetMyBookings)
 		protected.POST("/bookings/:id/cancel", handler.CancelBooking)
+		protected.POST("/bookings/series/:series_id/cancel", handler.CancelBookingSeries)
 	}
 
-	// Admin routes (auth + admin required)
+	// Admin routes (auth + admin required). Each route names the scope an API key needs.
 	admin := router.Group("/api/admin")
-	admin.Use(http.AuthMiddleware())
+	admin.Use(http.AdminAuthMiddleware(database))
 	admin.Use(handler.AdminOnly())
 	{
 		// Dashboard
-		admin.GET("/dashboard/summary", handler.GetDashboardSummary)
-		admin.GET("/dashboard/upcoming-events", handler.GetDashboardUpcomingEvents)
-		admin.GET("/dashboard/recent-bookings", handler.GetRecentBookings)
-		admin.GET("/dashboard/utilization-series", handler.GetUtilizationSeries)
-		admin.GET("/onboarding", handler.GetOnboarding)
+		admin.GET("/dashboard/summary", http.RequireScope(db.ScopeDashboardRead), handler.GetDashboardSummary)
+		admin.GET("/dashboard/upcoming-events", http.RequireScope(db.ScopeDashboardRead), handler.GetDashboardUpcomingEvents)
+		admin.GET("/dashboard/recent-bookings", http.RequireScope(db.ScopeDashboardRead), handler.GetRecentBookings)
+		admin.GET("/dashboard/utilization-series", http.RequireScope(db.ScopeDashboardRead), handler.GetUtilizationSeries)
+		admin.GET("/onboarding", http.RequireScope(db.ScopeDashboardRead), handler.GetOnboarding)
+
+		// Analytics
+		admin.GET("/analytics/cancellations", http.RequireScope(db.ScopeDashboardRead), handler.AdminGetCancellationAnalytics)
+		admin.GET("/reports/participation", http.RequireScope(db.ScopeDashboardRead), handler.AdminGetParticipationReport)
 
 		// Programs
-		admin.POST("/programs", handler.AdminCreateProgram)
-		admin.PUT("/programs/:id", handler.AdminUpdateProgram)
-		admin.DELETE("/programs/:id", handler.AdminDeleteProgram)
+		admin.GET("/programs", http.RequireScope(db.ScopeProgramsRead), handler.AdminGetPrograms)
+		admin.GET("/programs/:id", http.RequireScope(db.ScopeProgramsRead), handler.AdminGetProgram)
+		admin.POST("/programs", http.RequireScope(db.ScopeProgramsWrite), handler.AdminCreateProgram)
+		admin.PUT("/programs/:id", http.RequireScope(db.ScopeProgramsWrite), handler.AdminUpdateProgram)
+		admin.DELETE("/programs/:id", http.RequireScope(db.ScopeProgramsWrite), handler.AdminDeleteProgram)
+		admin.GET("/programs/:id/reconcile", http.RequireScope(db.ScopeProgramsRead), handler.AdminGetProgramReconciliation)
+		admin.POST("/programs/:id/reconcile", http.RequireScope(db.ScopeProgramsWrite), handler.AdminFixProgramReconciliation)
+		admin.GET("/programs/:id/needs", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetProgramNeeds)
+		admin.GET("/programs/:id/interest", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetProgramInterest)
+		admin.GET("/programs/:id/compliance", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetProgramCompliance)
+		admin.POST("/programs/:id/send-schedule", http.RequireScope(db.ScopeRegistrationsWrite), handler.AdminSendProgramSchedule)
+		admin.POST("/programs/:id/sessions", http.RequireScope(db.ScopeProgramsWrite), handler.AdminCreateProgramSession)
 
 		// Events
-		admin.POST("/events", handler.AdminCreateEvent)
-		admin.PUT("/events/:id", handler.AdminUpdateEvent)
-		admin.DELETE("/events/:id", handler.AdminDeleteEvent)
+		admin.GET("/events/:id", http.RequireScope(db.ScopeEventsRead), handler.AdminGetEvent)
+		admin.POST("/events", http.RequireScope(db.ScopeEventsWrite), handler.AdminCreateEvent)
+		admin.PUT("/events/:id", http.RequireScope(db.ScopeEventsWrite), handler.AdminUpdateEvent)
+		admin.DELETE("/events/:id", http.RequireScope(db.ScopeEventsWrite), handler.AdminDeleteEvent)
+		admin.POST("/events/:id/check-in", http.RequireScope(db.ScopeRegistrationsWrite), handler.AdminEventCheckIn)
+		admin.POST("/events/:id/sessions", http.RequireScope(db.ScopeEventsWrite), handler.AdminCreateEventSession)
+		admin.PUT("/events/:id/sessions/:session_id", http.RequireScope(db.ScopeEventsWrite), handler.AdminUpdateEventSession)
+		admin.DELETE("/events/:id/sessions/:session_id", http.RequireScope(db.ScopeEventsWrite), handler.AdminDeleteEventSession)
+
+		// Households
+		admin.POST("/households/merge", http.RequireScope(db.ScopeHouseholdsWrite), handler.AdminMergeHouseholds)
+
+		// Participants
+		admin.GET("/participants/search", http.RequireScope(db.ScopeUsersRead), handler.AdminSearchParticipants)
+		admin.GET("/participants/:id/profile", http.RequireScope(db.ScopeUsersRead), handler.AdminGetParticipantProfile)
+		admin.GET("/participants/:id/registrations", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetParticipantRegistrations)
+		admin.PUT("/participants/:id/legal-hold", http.RequireScope(db.ScopeUsersWrite), handler.AdminSetParticipantLegalHold)
 
 		// Registrations
-		admin.GET("/registrations", handler.AdminGetRegistrations)
-		admin.GET("/program-registrations", handler.AdminGetProgramRegistrations)
-		admin.PUT("/program-registrations/:id/status", handler.AdminUpdateRegistrationStatus)
+		admin.GET("/registrations", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetRegistrations)
+		admin.GET("/registrations/:id", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetRegistration)
+		admin.POST("/registrations/:id/approve", http.RequireScope(db.ScopeRegistrationsWrite), handler.AdminApproveRegistration)
+		admin.POST("/registrations/:id/reject", http.RequireScope(db.ScopeRegistrationsWrite), handler.AdminRejectRegistration)
+		admin.GET("/program-registrations", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetProgramRegistrations)
+		admin.GET("/program-registrations/export", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminExportProgramRegistrations)
+		admin.PUT("/program-registrations/:id/status", http.RequireScope(db.ScopeRegistrationsWrite), handler.AdminUpdateRegistrationStatus)
+		admin.PUT("/sessions/:id", http.RequireScope(db.ScopeProgramsWrite), handler.AdminUpdateSession)
+		admin.DELETE("/sessions/:id", http.RequireScope(db.ScopeProgramsWrite), handler.AdminDeleteSession)
+		admin.GET("/sessions/:id/waitlist", http.RequireScope(db.ScopeRegistrationsRead), handler.AdminGetSessionWaitlist)
 
 		// Facilities (admin)
-		admin.GET("/facilities", handler.AdminGetAllFacilities)
-		admin.POST("/facilities", handler.AdminCreateFacility)
-		admin.PUT("/facilities/:id", handler.AdminUpdateFacility)
-		admin.DELETE("/facilities/:id", handler.AdminDeleteFacility)
+		admin.GET("/facilities", http.RequireScope(db.ScopeFacilitiesRead), handler.AdminGetAllFacilities)
+		admin.POST("/facilities", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminCreateFacility)
+		admin.PUT("/facilities/:id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminUpdateFacility)
+		admin.DELETE("/facilities/:id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminDeleteFacility)
+		admin.GET("/facilities/:id/export", http.RequireScope(db.ScopeFacilitiesRead), handler.AdminExportFacilityConfig)
+		admin.POST("/facilities/import", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminImportFacilityConfig)
 
 		// Availability windows
-		admin.POST("/facilities/:id/availability", handler.AdminCreateAvailabilityWindow)
-		admin.DELETE("/facilities/:id/availability/:window_id", handler.AdminDeleteAvailabilityWindow)
+		admin.POST("/facilities/:id/availability", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminCreateAvailabilityWindow)
+		admin.PUT("/facilities/:id/availability", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminReplaceAvailabilityWindows)
+		admin.DELETE("/facilities/:id/availability/:window_id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminDeleteAvailabilityWindow)
+
+		// Pricing rules
+		admin.GET("/facilities/:id/pricing-rules", http.RequireScope(db.ScopeFacilitiesRead), handler.AdminGetPricingRules)
+		admin.PUT("/facilities/:id/pricing-rules", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminReplacePricingRules)
+
+		// Units (courts, lanes)
+		admin.GET("/facilities/:id/units", http.RequireScope(db.ScopeFacilitiesRead), handler.AdminGetFacilityUnits)
+		admin.POST("/facilities/:id/units", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminCreateFacilityUnit)
+		admin.PUT("/facilities/:id/units/:unit_id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminUpdateFacilityUnit)
+		admin.DELETE("/facilities/:id/units/:unit_id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminDeleteFacilityUnit)
 
 		// Closures
-		admin.GET("/facilities/:id/closures", handler.AdminGetClosures)
-		admin.POST("/facilities/:id/closures", handler.AdminCreateClosure)
-		admin.DELETE("/facilities/:id/closures/:closure_id", handler.AdminDeleteClosure)
+		admin.GET("/facilities/:id/closures", http.RequireScope(db.ScopeFacilitiesRead), handler.AdminGetClosures)
+		admin.POST("/facilities/:id/closures", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminCreateClosure)
+		admin.DELETE("/facilities/:id/closures/:closure_id", http.RequireScope(db.ScopeFacilitiesWrite), handler.AdminDeleteClosure)
+		admin.POST("/facilities/:id/closures/:closure_id/reschedule-bookings", http.RequireScope(db.ScopeBookingsWrite), handler.AdminProposeClosureReschedule)
+		admin.POST("/facilities/:id/closures/:closure_id/reschedule-bookings/confirm", http.RequireScope(db.ScopeBookingsWrite), handler.AdminConfirmClosureReschedule)
 
 		// Bookings (admin)
-		admin.GET("/facilities/:id/bookings", handler.AdminGetFacilityBookings)
-		admin.GET("/bookings/export", handler.AdminExportBookings)
+		admin.GET("/facilities/:id/bookings", http.RequireScope(db.ScopeBookingsRead), handler.AdminGetFacilityBookings)
+		admin.POST("/facilities/:id/program-reservations", http.RequireScope(db.ScopeBookingsWrite), handler.AdminReserveFacilityForProgram)
+		admin.GET("/bookings/export", http.RequireScope(db.ScopeBookingsExport), handler.AdminExportBookings)
+		admin.POST("/bookings/:id/no-show", http.RequireScope(db.ScopeBookingsWrite), handler.AdminMarkBookingNoShow)
+		admin.POST("/bookings/:id/approve", http.RequireScope(db.ScopeBookingsWrite), handler.AdminApproveBooking)
+		admin.POST("/bookings/:id/reject", http.RequireScope(db.ScopeBookingsWrite), handler.AdminRejectBooking)
+		admin.GET("/users/:id/booking-stats", http.RequireScope(db.ScopeUsersRead), handler.AdminGetUserBookingStats)
+		admin.GET("/users/:id/notifications/history", http.RequireScope(db.ScopeUsersRead), handler.AdminGetUserNotificationHistory)
+		admin.PUT("/users/:id/membership", http.RequireScope(db.ScopeUsersWrite), handler.AdminSetUserMembership)
+		admin.PUT("/users/:id/advance-booking-exempt", http.RequireScope(db.ScopeUsersWrite), handler.AdminSetUserAdvanceBookingExempt)
+
+		// Support impersonation (admin)
+		admin.POST("/users/:id/impersonate", http.SessionOnly(), handler.AdminImpersonateUser)
+		admin.GET("/impersonation-sessions", http.RequireScope(db.ScopeUsersRead), handler.AdminGetImpersonationSessions)
+		admin.GET("/impersonation-sessions/:id", http.RequireScope(db.ScopeUsersRead), handler.AdminGetImpersonationSession)
+
+		// Email deliverability (admin)
+		admin.GET("/email-suppressions", http.RequireScope(db.ScopeUsersRead), handler.AdminGetEmailSuppressions)
+		admin.DELETE("/email-suppressions/:email", http.RequireScope(db.ScopeUsersWrite), handler.AdminDeleteEmailSuppression)
+		admin.GET("/notifications/failed", http.RequireScope(db.ScopeUsersRead), handler.AdminGetFailedNotifications)
+		admin.POST("/notifications/:id/retry", http.RequireScope(db.ScopeUsersWrite), handler.AdminRetryNotification)
+		admin.POST("/email-templates/:key/render", http.RequireScope(db.ScopeUsersRead), handler.AdminRenderEmailTemplate)
+
+		// Central platform sync (admin)
+		admin.GET("/sync/status", http.RequireScope(db.ScopeAdminRead), handler.AdminGetSyncStatus)
 
 		// Waivers (admin)
-		admin.GET("/waivers", handler.AdminGetAllWaivers)
-		admin.POST("/waivers", handler.AdminCreateWaiver)
-		admin.GET("/waivers/:id", handler.AdminGetWaiver)
-		admin.PUT("/waivers/:id", handler.AdminUpdateWaiver)
-		admin.DELETE("/waivers/:id", handler.AdminDeleteWaiver)
+		admin.GET("/waivers", http.RequireScope(db.ScopeWaiversRead), handler.AdminGetAllWaivers)
+		admin.POST("/waivers", http.RequireScope(db.ScopeWaiversWrite), handler.AdminCreateWaiver)
+		admin.GET("/waivers/:id", http.RequireScope(db.ScopeWaiversRead), handler.AdminGetWaiver)
+		admin.PUT("/waivers/:id", http.RequireScope(db.ScopeWaiversWrite), handler.AdminUpdateWaiver)
+		admin.DELETE("/waivers/:id", http.RequireScope(db.ScopeWaiversWrite), handler.AdminDeleteWaiver)
 
 		// Program waivers (admin)
-		admin.POST("/program-waivers", handler.AdminAssignWaiverToProgram)
-		admin.DELETE("/program-waivers", handler.AdminRemoveWaiverFromProgram)
+		admin.POST("/program-waivers", http.RequireScope(db.ScopeWaiversWrite), handler.AdminAssignWaiverToProgram)
+		admin.DELETE("/program-waivers", http.RequireScope(db.ScopeWaiversWrite), handler.AdminRemoveWaiverFromProgram)
+
+		// Program forms (admin)
+		admin.POST("/program-forms", http.RequireScope(db.ScopeWaiversWrite), handler.AdminAssignFormToProgram)
+		admin.DELETE("/program-forms", http.RequireScope(db.ScopeWaiversWrite), handler.AdminRemoveFormFromProgram)
 
 		// Form templates (admin)
-		admin.GET("/form-templates", handler.AdminGetAllFormTemplates)
-		admin.POST("/form-templates", handler.AdminCreateFormTemplate)
-		admin.PUT("/form-templates/:id", handler.AdminUpdateFormTemplate)
-		admin.DELETE("/form-templates/:id", handler.AdminDeleteFormTemplate)
+		admin.GET("/form-templates", http.RequireScope(db.ScopeWaiversRead), handler.AdminGetAllFormTemplates)
+		admin.POST("/form-templates", http.RequireScope(db.ScopeWaiversWrite), handler.AdminCreateFormTemplate)
+		admin.PUT("/form-templates/:id", http.RequireScope(db.ScopeWaiversWrite), handler.AdminUpdateFormTemplate)
+		admin.DELETE("/form-templates/:id", http.RequireScope(db.ScopeWaiversWrite), handler.AdminDeleteFormTemplate)
+
+		// API keys (admin)
+		admin.GET("/api-keys", http.SessionOnly(), handler.AdminGetAPIKeys)
+		admin.POST("/api-keys", http.SessionOnly(), handler.AdminCreateAPIKey)
+		admin.DELETE("/api-keys/:id", http.SessionOnly(), handler.AdminRevokeAPIKey)
 	}
 
 	// Start server
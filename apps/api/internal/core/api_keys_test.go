@@ -0,0 +1,28 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestGenerateAPIKey checks keys are random, carry their prefix and hash consistently
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if !strings.HasPrefix(key, "srk_") || !strings.HasPrefix(key, prefix) || len(prefix) != 12 {
+		t.Errorf("key %q, prefix %q: want srk_ key starting with a 12 character prefix", key, prefix)
+	}
+	if hash != HashAPIKey(key) || hash == key {
+		t.Errorf("hash %q does not match HashAPIKey(key)", hash)
+	}
+
+	other, _, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("GenerateAPIKey: %v", err)
+	}
+	if other == key || otherHash == hash {
+		t.Error("two generated keys are identical")
+	}
+}
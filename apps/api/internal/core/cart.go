@@ -0,0 +1,317 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+// CartItem is one registration a family intends to make at checkout
+type CartItem struct {
+	ParentType    string     `json:"parent_type"`
+	ParentID      uuid.UUID  `json:"parent_id"`
+	SessionID     *uuid.UUID `json:"session_id,omitempty"`
+	ParticipantID uuid.UUID  `json:"participant_id"`
+}
+
+// Cart issue codes say what would stop a cart item from being registered as intended
+const (
+	CartIssueNotFound                = "not_found"
+	CartIssueNotOpen                 = "registration_not_open"
+	CartIssueIneligibleAge           = "ineligible_age"
+	CartIssueMissingWaivers          = "missing_waivers"
+	CartIssueMissingForms            = "missing_forms"
+	CartIssueMissingEmergencyContact = "missing_emergency_contact"
+	CartIssueAlreadyRegistered       = "already_registered"
+	CartIssueDuplicate               = "duplicate"
+	CartIssueWouldWaitlist           = "would_waitlist"
+	CartIssueTimeConflict            = "time_conflict"
+)
+
+// CartIssue is one blocking problem with a cart item
+type CartIssue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	Age     *int                       `json:"age,omitempty"`
+	Waivers []MissingWaiver            `json:"waivers,omitempty"`
+	Forms   []db.ComplianceRequirement `json:"forms,omitempty"`
+	// ConflictsWith lists the indexes of the other cart items this one clashes with
+	ConflictsWith []int `json:"conflicts_with,omitempty"`
+}
+
+// CartItemValidation is the outcome of validating one cart item
+type CartItemValidation struct {
+	Index int `json:"index"`
+	CartItem
+	Title string `json:"title,omitempty"`
+	// RequiresApproval is not blocking: the registration is made pending an admin's approval
+	RequiresApproval bool        `json:"requires_approval"`
+	Issues           []CartIssue `json:"issues"`
+	Valid            bool        `json:"valid"`
+}
+
+// CartValidation is the outcome of validating a whole cart
+type CartValidation struct {
+	Items        []CartItemValidation `json:"items"`
+	Valid        bool                 `json:"valid"`
+	ItemCount    int                  `json:"item_count"`
+	BlockedCount int                  `json:"blocked_count"`
+	// IssueCounts counts the issues of each code across the cart
+	IssueCounts map[string]int `json:"issue_counts"`
+}
+
+// timeWindow is when a cart item's participant is taken up
+type timeWindow struct {
+	start, end time.Time
+}
+
+// windowsOverlap reports whether any window of a overlaps any window of b. Windows that
+// only touch, one ending as the next starts, do not overlap.
+func windowsOverlap(a, b []timeWindow) bool {
+	for _, wa := range a {
+		for _, wb := range b {
+			if wa.start.Before(wb.end) && wb.start.Before(wa.end) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// cartSlot is the parent and session a cart item takes a seat in
+type cartSlot struct {
+	parentType string
+	parentID   uuid.UUID
+	sessionID  uuid.UUID // uuid.Nil for the parent itself
+}
+
+// cartEntry is a participant's registration for a slot, which a cart should list once
+type cartEntry struct {
+	slot          cartSlot
+	participantID uuid.UUID
+}
+
+// ValidateCart checks each item of a cart the way registering it would, without writing
+// anything: the program or event and session exist and are open, the participant's age,
+// required waivers, forms and emergency contact, whether it is already registered or
+// listed twice, whether it would be waitlisted once the items before it in the cart have
+// taken their seats, and whether its sessions overlap another item's for the same
+// participant. Items must name participants the caller may register.
+func (rs *RegistrationService) ValidateCart(items []CartItem) (*CartValidation, error) {
+	result := &CartValidation{
+		Items:       make([]CartItemValidation, len(items)),
+		IssueCounts: map[string]int{},
+	}
+
+	windows := make([][]timeWindow, len(items))
+	firstIndex := map[cartEntry]int{}
+	seatsLeft := map[cartSlot]int{}
+
+	for i, item := range items {
+		v := &result.Items[i]
+		v.Index = i
+		v.CartItem = item
+		v.Issues = []CartIssue{}
+
+		found, err := rs.validateCartItem(v, &windows[i])
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+
+		slot := cartSlot{parentType: item.ParentType, parentID: item.ParentID}
+		if item.SessionID != nil {
+			slot.sessionID = *item.SessionID
+		}
+		key := cartEntry{slot, item.ParticipantID}
+		if first, ok := firstIndex[key]; ok {
+			v.Issues = append(v.Issues, CartIssue{
+				Code:          CartIssueDuplicate,
+				Message:       "This registration is already in the cart",
+				ConflictsWith: []int{first},
+			})
+			// Its sessions are the first item's, which would otherwise be reported as a clash
+			windows[i] = nil
+			continue
+		}
+		firstIndex[key] = i
+
+		registered, err := rs.db.HasActiveRegistration(item.ParentType, item.ParentID, item.SessionID, item.ParticipantID)
+		if err != nil {
+			return nil, err
+		}
+		if registered {
+			v.Issues = append(v.Issues, CartIssue{Code: CartIssueAlreadyRegistered, Message: "Participant is already registered"})
+			continue
+		}
+
+		// Registrations awaiting approval hold no seat until approved
+		if v.RequiresApproval {
+			continue
+		}
+		left, ok := seatsLeft[slot]
+		if !ok {
+			if left, err = rs.db.SeatsLeft(item.ParentType, item.ParentID, item.SessionID); err != nil {
+				return nil, err
+			}
+		}
+		if left <= 0 {
+			v.Issues = append(v.Issues, CartIssue{Code: CartIssueWouldWaitlist, Message: "No spots are left; this registration would be waitlisted"})
+		}
+		seatsLeft[slot] = left - 1
+	}
+
+	// Sessions of two items for the same participant must not overlap
+	for i := range items {
+		var conflicts []int
+		for j := range items {
+			if i != j && items[i].ParticipantID == items[j].ParticipantID && windowsOverlap(windows[i], windows[j]) {
+				conflicts = append(conflicts, j)
+			}
+		}
+		if len(conflicts) > 0 {
+			result.Items[i].Issues = append(result.Items[i].Issues, CartIssue{
+				Code:          CartIssueTimeConflict,
+				Message:       "Overlaps another registration in the cart for the same participant",
+				ConflictsWith: conflicts,
+			})
+		}
+	}
+
+	result.ItemCount = len(items)
+	for i := range result.Items {
+		v := &result.Items[i]
+		v.Valid = len(v.Issues) == 0
+		if !v.Valid {
+			result.BlockedCount++
+		}
+		for _, issue := range v.Issues {
+			result.IssueCounts[issue.Code]++
+		}
+	}
+	result.Valid = result.BlockedCount == 0
+
+	return result, nil
+}
+
+// validateCartItem checks a cart item's program or event, session and participant, and
+// fills in when its participant would be taken up. It returns false when the item names
+// nothing that can be registered for, which is its only issue.
+func (rs *RegistrationService) validateCartItem(v *CartItemValidation, windows *[]timeWindow) (bool, error) {
+	notFound := func(message string) (bool, error) {
+		v.Issues = append(v.Issues, CartIssue{Code: CartIssueNotFound, Message: message})
+		return false, nil
+	}
+
+	participant, err := rs.db.GetParticipantByID(v.ParticipantID)
+	if err != nil {
+		return false, err
+	}
+	if participant == nil {
+		return notFound("Participant not found")
+	}
+
+	var session *db.Session
+	if v.SessionID != nil {
+		if session, err = rs.db.GetSessionByID(*v.SessionID); err != nil {
+			return false, err
+		}
+		if session == nil || !session.IsActive || session.ParentType != v.ParentType || session.ParentID != v.ParentID {
+			return notFound("Session not found")
+		}
+		if session.StartsAt != nil && session.EndsAt != nil {
+			*windows = []timeWindow{{*session.StartsAt, *session.EndsAt}}
+		}
+	}
+
+	if v.ParentType == "event" {
+		event, err := rs.db.GetEventByID(v.ParentID)
+		if err != nil {
+			return false, err
+		}
+		if event == nil || !event.IsActive {
+			return notFound("Event not found")
+		}
+		v.Title = event.Title
+		if session == nil && event.StartsAt != nil && event.EndsAt != nil {
+			*windows = []timeWindow{{*event.StartsAt, *event.EndsAt}}
+		}
+		return true, nil
+	}
+
+	// Programs outside their publish window are hidden from the public
+	program, err := rs.db.GetProgramByID(v.ParentID)
+	if err != nil {
+		return false, err
+	}
+	if program == nil || !db.IsPublished(program.PublishedAt, program.UnpublishedAt, time.Now()) {
+		return notFound("Program not found")
+	}
+	v.Title = program.Title
+	v.RequiresApproval = program.RequiresApproval
+
+	// A registration for the whole program takes up every one of its sessions
+	if session == nil {
+		sessions, err := rs.db.GetProgramScheduleSessions(program.ID, nil)
+		if err != nil {
+			return false, err
+		}
+		for _, s := range sessions {
+			if s.EndsAt != nil {
+				*windows = append(*windows, timeWindow{*s.StartsAt, *s.EndsAt})
+			}
+		}
+	}
+
+	if program.RegistrationOpensAt != nil && time.Now().Before(*program.RegistrationOpensAt) {
+		v.Issues = append(v.Issues, CartIssue{
+			Code:    CartIssueNotOpen,
+			Message: fmt.Sprintf("Registration opens %s", program.RegistrationOpensAt.Format("January 2, 2006 at 3:04 PM")),
+		})
+	}
+
+	// Participants without a DOB can't be checked and are let through
+	if participant.DOB != nil {
+		age := db.AgeOn(*participant.DOB, db.ProgramAgeReferenceDate(program))
+		if reason := db.AgeRequirementReason(program, age); reason != "" {
+			v.Issues = append(v.Issues, CartIssue{Code: CartIssueIneligibleAge, Message: reason, Age: &age})
+		}
+	}
+
+	if err := rs.checkRequiredWaivers(program.ID, participant.ID); err != nil {
+		var waiversErr *MissingWaiversError
+		if !errors.As(err, &waiversErr) {
+			return false, err
+		}
+		v.Issues = append(v.Issues, CartIssue{Code: CartIssueMissingWaivers, Message: waiversErr.Error(), Waivers: waiversErr.Waivers})
+	}
+
+	forms, err := rs.db.GetMissingProgramForms(program.ID, participant.ID)
+	if err != nil {
+		return false, err
+	}
+	if len(forms) > 0 {
+		v.Issues = append(v.Issues, CartIssue{
+			Code:    CartIssueMissingForms,
+			Message: fmt.Sprintf("%d required form(s) must be completed", len(forms)),
+			Forms:   forms,
+		})
+	}
+
+	if db.NeedsEmergencyContact(program, participant) {
+		contactErr := &MissingEmergencyContactError{
+			Age:       db.AgeOn(*participant.DOB, db.ProgramAgeReferenceDate(program)),
+			Threshold: program.MinorAgeThreshold,
+		}
+		v.Issues = append(v.Issues, CartIssue{Code: CartIssueMissingEmergencyContact, Message: contactErr.Error(), Age: &contactErr.Age})
+	}
+
+	return true, nil
+}
@@ -0,0 +1,34 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowsOverlap(t *testing.T) {
+	base := time.Date(2026, 6, 2, 9, 0, 0, 0, time.UTC)
+	window := func(startHour, endHour int) timeWindow {
+		return timeWindow{base.Add(time.Duration(startHour) * time.Hour), base.Add(time.Duration(endHour) * time.Hour)}
+	}
+
+	tests := []struct {
+		name string
+		a, b []timeWindow
+		want bool
+	}{
+		{"overlapping", []timeWindow{window(0, 2)}, []timeWindow{window(1, 3)}, true},
+		{"contained", []timeWindow{window(0, 4)}, []timeWindow{window(1, 2)}, true},
+		{"back to back", []timeWindow{window(0, 1)}, []timeWindow{window(1, 2)}, false},
+		{"apart", []timeWindow{window(0, 1)}, []timeWindow{window(3, 4)}, false},
+		{"one of several sessions", []timeWindow{window(0, 1), window(24, 25)}, []timeWindow{window(24, 26)}, true},
+		{"no times", nil, []timeWindow{window(0, 1)}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowsOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("windowsOverlap() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
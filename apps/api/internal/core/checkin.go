@@ -0,0 +1,72 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrCheckInDisabled is returned when neither CHECKIN_TOKEN_SECRET nor JWT_SECRET is set,
+// since tokens signed with an empty key could be forged by anyone
+var ErrCheckInDisabled = errors.New("event check-in is not configured")
+
+// checkInSecret signs event check-in tokens. CHECKIN_TOKEN_SECRET falls back to
+// JWT_SECRET so existing deployments work without new configuration.
+func checkInSecret() []byte {
+	if secret := os.Getenv("CHECKIN_TOKEN_SECRET"); secret != "" {
+		return []byte(secret)
+	}
+	return []byte(os.Getenv("JWT_SECRET"))
+}
+
+// CheckInEnabled reports whether a secret is configured to sign check-in tokens
+func CheckInEnabled() bool {
+	return len(checkInSecret()) > 0
+}
+
+// GenerateCheckInToken returns a signed token identifying a registration, compact
+// enough to encode as a QR code. It returns ErrCheckInDisabled when no secret is set.
+func GenerateCheckInToken(registrationID uuid.UUID) (string, error) {
+	if !CheckInEnabled() {
+		return "", ErrCheckInDisabled
+	}
+	id := registrationID[:]
+	return base64.RawURLEncoding.EncodeToString(id) + "." + base64.RawURLEncoding.EncodeToString(signCheckIn(id)), nil
+}
+
+// ParseCheckInToken verifies a check-in token and returns its registration ID. It returns
+// ErrCheckInDisabled when no secret is set.
+func ParseCheckInToken(token string) (uuid.UUID, error) {
+	if !CheckInEnabled() {
+		return uuid.Nil, ErrCheckInDisabled
+	}
+
+	idPart, sigPart, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok {
+		return uuid.Nil, fmt.Errorf("malformed check-in token")
+	}
+
+	id, err := base64.RawURLEncoding.DecodeString(idPart)
+	if err != nil || len(id) != 16 {
+		return uuid.Nil, fmt.Errorf("malformed check-in token")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, signCheckIn(id)) {
+		return uuid.Nil, fmt.Errorf("invalid check-in token signature")
+	}
+
+	return uuid.FromBytes(id)
+}
+
+func signCheckIn(id []byte) []byte {
+	mac := hmac.New(sha256.New, checkInSecret())
+	mac.Write([]byte("checkin:"))
+	mac.Write(id)
+	return mac.Sum(nil)
+}
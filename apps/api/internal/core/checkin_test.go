@@ -0,0 +1,63 @@
+package core
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestCheckInToken(t *testing.T) {
+	t.Setenv("CHECKIN_TOKEN_SECRET", "test-secret")
+	registrationID := uuid.New()
+	token, err := GenerateCheckInToken(registrationID)
+	if err != nil {
+		t.Fatalf("GenerateCheckInToken() error = %v", err)
+	}
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := ParseCheckInToken(token)
+		if err != nil {
+			t.Fatalf("ParseCheckInToken() error = %v", err)
+		}
+		if got != registrationID {
+			t.Errorf("ParseCheckInToken() = %s, want %s", got, registrationID)
+		}
+	})
+
+	t.Run("rejects token for another registration", func(t *testing.T) {
+		// Keep this registration's ID but use another registration's signature
+		other, _ := GenerateCheckInToken(uuid.New())
+		forged := token[:strings.Index(token, ".")] + other[strings.Index(other, "."):]
+		if _, err := ParseCheckInToken(forged); err == nil {
+			t.Error("expected error for mismatched signature")
+		}
+	})
+
+	t.Run("rejects token signed with another secret", func(t *testing.T) {
+		t.Setenv("CHECKIN_TOKEN_SECRET", "other-secret")
+		if _, err := ParseCheckInToken(token); err == nil {
+			t.Error("expected error for token signed with another secret")
+		}
+	})
+
+	t.Run("rejects malformed tokens", func(t *testing.T) {
+		for _, bad := range []string{"", "abc", "abc.def", registrationID.String()} {
+			if _, err := ParseCheckInToken(bad); err == nil {
+				t.Errorf("ParseCheckInToken(%q) expected error", bad)
+			}
+		}
+	})
+
+	t.Run("disabled without a secret", func(t *testing.T) {
+		t.Setenv("CHECKIN_TOKEN_SECRET", "")
+		t.Setenv("JWT_SECRET", "")
+		if _, err := GenerateCheckInToken(registrationID); !errors.Is(err, ErrCheckInDisabled) {
+			t.Errorf("GenerateCheckInToken() error = %v, want ErrCheckInDisabled", err)
+		}
+		if _, err := ParseCheckInToken(token); !errors.Is(err, ErrCheckInDisabled) {
+			t.Errorf("ParseCheckInToken() error = %v, want ErrCheckInDisabled", err)
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package core
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Circuit breaker states
+const (
+	CircuitClosed   = "closed"    // requests go through
+	CircuitOpen     = "open"      // requests fail fast until the cooldown passes
+	CircuitHalfOpen = "half_open" // one probe request decides whether to close again
+)
+
+// ErrCircuitOpen is returned instead of making a request while the breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker open: central platform unavailable")
+
+// CircuitBreaker stops calls to a failing dependency. After threshold consecutive
+// failures it opens and fails every call until cooldown has passed, then lets a single
+// probe through: success closes it again, failure reopens it for another cooldown.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	lastError string
+	now       func() time.Time
+}
+
+// CircuitBreakerStatus is a snapshot of a breaker for status endpoints
+type CircuitBreakerStatus struct {
+	State               string     `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	FailureThreshold    int        `json:"failure_threshold"`
+	CooldownSeconds     int        `json:"cooldown_seconds"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	ProbeAt             *time.Time `json:"probe_at,omitempty"` // when an open breaker next lets a probe through
+	LastError           *string    `json:"last_error,omitempty"`
+}
+
+func NewCircuitBreaker(threshold int, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{threshold: threshold, cooldown: cooldown, now: time.Now}
+}
+
+// state returns the breaker's state; the caller holds the lock
+func (cb *CircuitBreaker) state() string {
+	if cb.failures < cb.threshold {
+		return CircuitClosed
+	}
+	if cb.now().Before(cb.openedAt.Add(cb.cooldown)) {
+		return CircuitOpen
+	}
+	return CircuitHalfOpen
+}
+
+// Allow returns ErrCircuitOpen when a call may not be made. A call that is allowed must
+// report its outcome with Record.
+func (cb *CircuitBreaker) Allow() error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	switch cb.state() {
+	case CircuitClosed:
+		return nil
+	case CircuitHalfOpen:
+		if !cb.probing {
+			cb.probing = true
+			return nil
+		}
+	}
+	return ErrCircuitOpen
+}
+
+// Record reports the outcome of an allowed call; a nil err is a success
+func (cb *CircuitBreaker) Record(err error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.probing = false
+	if err == nil {
+		cb.failures = 0
+		cb.lastError = ""
+		return
+	}
+
+	cb.failures++
+	cb.lastError = err.Error()
+	if cb.failures >= cb.threshold {
+		cb.openedAt = cb.now()
+	}
+}
+
+// Status returns a snapshot of the breaker
+func (cb *CircuitBreaker) Status() CircuitBreakerStatus {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	status := CircuitBreakerStatus{
+		State:               cb.state(),
+		ConsecutiveFailures: cb.failures,
+		FailureThreshold:    cb.threshold,
+		CooldownSeconds:     int(cb.cooldown / time.Second),
+	}
+	if status.State != CircuitClosed {
+		openedAt, probeAt := cb.openedAt, cb.openedAt.Add(cb.cooldown)
+		status.OpenedAt, status.ProbeAt = &openedAt, &probeAt
+	}
+	if cb.lastError != "" {
+		lastError := cb.lastError
+		status.LastError = &lastError
+	}
+	return status
+}
@@ -0,0 +1,111 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+	cb := NewCircuitBreaker(3, time.Minute)
+	cb.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	call := func(err error) error {
+		t.Helper()
+		if allowErr := cb.Allow(); allowErr != nil {
+			return allowErr
+		}
+		cb.Record(err)
+		return nil
+	}
+
+	// Failures below the threshold, or broken up by a success, keep it closed
+	call(failure)
+	call(failure)
+	call(nil)
+	call(failure)
+	call(failure)
+	if s := cb.Status(); s.State != CircuitClosed || s.ConsecutiveFailures != 2 {
+		t.Fatalf("status = %+v, want closed after 2 consecutive failures", s)
+	}
+
+	call(failure)
+	s := cb.Status()
+	if s.State != CircuitOpen || s.ProbeAt == nil || !s.ProbeAt.Equal(now.Add(time.Minute)) || s.LastError == nil {
+		t.Fatalf("status = %+v, want open until a minute from now with the last error", s)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("open breaker Allow = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the cooldown one probe goes through; a failed probe reopens it
+	now = now.Add(time.Minute)
+	if s := cb.Status(); s.State != CircuitHalfOpen {
+		t.Errorf("state after cooldown = %q, want half_open", s.State)
+	}
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second call while probing = %v, want ErrCircuitOpen", err)
+	}
+	cb.Record(failure)
+	if s := cb.Status(); s.State != CircuitOpen {
+		t.Errorf("state after failed probe = %q, want open", s.State)
+	}
+
+	// A successful probe closes it
+	now = now.Add(time.Minute)
+	if err := call(nil); err != nil {
+		t.Fatalf("probe refused: %v", err)
+	}
+	if s := cb.Status(); s.State != CircuitClosed || s.ConsecutiveFailures != 0 || s.OpenedAt != nil || s.LastError != nil {
+		t.Errorf("status after successful probe = %+v, want closed and reset", s)
+	}
+}
+
+// TestSyncClientCircuitBreaker tests server errors open the breaker and further requests
+// fail without reaching the server, while client errors do not count
+func TestSyncClientCircuitBreaker(t *testing.T) {
+	requests := 0
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+
+	sc := &SyncClient{baseURL: server.URL, httpClient: server.Client(), breaker: NewCircuitBreaker(2, time.Hour)}
+	payload := map[string]interface{}{"registration_id": "x"}
+
+	status = http.StatusBadRequest
+	for i := 0; i < 3; i++ {
+		sc.SyncRegistrationCreated(context.Background(), payload)
+	}
+	if !sc.Available() {
+		t.Fatal("client errors opened the breaker")
+	}
+
+	status = http.StatusServiceUnavailable
+	for i := 0; i < 2; i++ {
+		if err := sc.SyncRegistrationCreated(context.Background(), payload); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("request %d error = %v, want the server error", i, err)
+		}
+	}
+	if sc.Available() {
+		t.Fatal("breaker not open after 2 server errors")
+	}
+
+	before := requests
+	if err := sc.SyncRegistrationCreated(context.Background(), payload); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("request with the breaker open = %v, want ErrCircuitOpen", err)
+	}
+	if requests != before {
+		t.Error("request with the breaker open reached the server")
+	}
+}
@@ -2,14 +2,22 @@ package core
 
 import (
 	"bytes"
+	"database/sql"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
 	"net/smtp"
 	"os"
+	"strconv"
+	"strings"
 	textTemplate "text/template"
 	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
 
 	"sterling-rec/api/internal/db"
 )
@@ -21,6 +29,9 @@ type EmailService struct {
 	password string
 	from     string
 	db       *db.DB
+
+	// notification is the queued notification being sent, recorded against each email
+	notification *db.NotificationQueue
 }
 
 func NewEmailService(database *db.DB) *EmailService {
@@ -34,16 +45,88 @@ func NewEmailService(database *db.DB) *EmailService {
 	}
 }
 
-func (es *EmailService) SendEmail(to, subject, bodyHTML, bodyText string) error {
+// withNotification returns a copy of the service that links the emails it sends to notif
+func (es *EmailService) withNotification(notif *db.NotificationQueue) *EmailService {
+	sender := *es
+	sender.notification = notif
+	return &sender
+}
+
+// messageIDDomain returns the domain of the sender address, used to build Message-IDs
+func (es *EmailService) messageIDDomain() string {
+	from := strings.TrimSuffix(strings.TrimSpace(es.from), ">")
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		return from[at+1:]
+	}
+	return "sterling-rec.local"
+}
+
+// EmailAttachment is a file sent alongside an email's bodies
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// SendEmail sends an email unless the address has hard-bounced or complained. Each email
+// gets a Message-ID, recorded so the provider's delivery webhook can be matched to it.
+func (es *EmailService) SendEmail(to, subject, bodyHTML, bodyText string, attachments ...EmailAttachment) error {
+	suppressed, err := es.db.IsEmailSuppressed(to)
+	if err != nil {
+		return err
+	}
+	if suppressed {
+		log.Printf("Email to %s suppressed after a bounce or complaint: %s", to, subject)
+		return nil
+	}
+
 	addr := fmt.Sprintf("%s:%s", es.host, es.port)
+	messageID := fmt.Sprintf("<%s@%s>", uuid.New(), es.messageIDDomain())
+
+	msg := buildMessage(es.from, to, subject, messageID, bodyHTML, bodyText, attachments)
+
+	var auth smtp.Auth
+	if es.username != "" && es.password != "" {
+		auth = smtp.PlainAuth("", es.username, es.password, es.host)
+	}
+
+	err = smtp.SendMail(addr, auth, es.from, []string{to}, msg)
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	log.Printf("Email sent to %s: %s", to, subject)
+
+	// The email is already out, so a failure to record it must not trigger a resend
+	sent := &db.EmailMessage{MessageID: messageID, Recipient: to, Subject: subject}
+	if es.notification != nil {
+		sent.NotificationID = &es.notification.ID
+		sent.NotificationType = &es.notification.Type
+		sent.NotificationPayload = json.RawMessage(es.notification.Payload)
+	}
+	if err := es.db.RecordEmailSent(sent); err != nil {
+		log.Printf("Failed to record email %s: %v", messageID, err)
+	}
+	return nil
+}
 
-	// Construct email
-	msg := []byte(
-		"From: " + es.from + "\r\n" +
+// buildMessage constructs a MIME email. The plain text and HTML bodies are alternatives;
+// attachments wrap them in a multipart/mixed message.
+func buildMessage(from, to, subject, messageID, bodyHTML, bodyText string, attachments []EmailAttachment) []byte {
+	var msg bytes.Buffer
+	msg.WriteString("From: " + from + "\r\n" +
 		"To: " + to + "\r\n" +
 		"Subject: " + subject + "\r\n" +
-		"MIME-Version: 1.0\r\n" +
-		"Content-Type: multipart/alternative; boundary=boundary\r\n" +
+		"Message-ID: " + messageID + "\r\n" +
+		"MIME-Version: 1.0\r\n")
+
+	if len(attachments) > 0 {
+		msg.WriteString("Content-Type: multipart/mixed; boundary=mixed\r\n" +
+			"\r\n" +
+			"--mixed\r\n")
+	}
+
+	msg.WriteString("Content-Type: multipart/alternative; boundary=boundary\r\n" +
 		"\r\n" +
 		"--boundary\r\n" +
 		"Content-Type: text/plain; charset=UTF-8\r\n" +
@@ -53,24 +136,185 @@ func (es *EmailService) SendEmail(to, subject, bodyHTML, bodyText string) error
 		"Content-Type: text/html; charset=UTF-8\r\n" +
 		"\r\n" +
 		bodyHTML + "\r\n" +
-		"--boundary--\r\n",
-	)
+		"--boundary--\r\n")
 
-	var auth smtp.Auth
-	if es.username != "" && es.password != "" {
-		auth = smtp.PlainAuth("", es.username, es.password, es.host)
+	if len(attachments) == 0 {
+		return msg.Bytes()
+	}
+
+	for _, a := range attachments {
+		msg.WriteString("--mixed\r\n" +
+			"Content-Type: " + a.ContentType + "; name=\"" + a.Filename + "\"\r\n" +
+			"Content-Disposition: attachment; filename=\"" + a.Filename + "\"\r\n" +
+			"Content-Transfer-Encoding: base64\r\n" +
+			"\r\n")
+		// Base64 lines are kept to 76 characters as RFC 2045 requires
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			msg.WriteString(encoded[:76] + "\r\n")
+			encoded = encoded[76:]
+		}
+		msg.WriteString(encoded + "\r\n")
+	}
+	msg.WriteString("--mixed--\r\n")
+
+	return msg.Bytes()
+}
+
+// BookingCalendarEvent builds an iCalendar (RFC 5545) file holding a single event for a
+// facility booking. The booking ID makes up the UID, so calendars treat a resent invite
+// for the same booking as an update rather than a second event.
+func (es *EmailService) BookingCalendarEvent(bookingID uuid.UUID, facilityName, location string, start, end time.Time) []byte {
+	return es.bookingCalendar("PUBLISH", "CONFIRMED", 0, bookingID, facilityName, location, start, end)
+}
+
+// BookingCalendarCancellation builds an iCalendar file that withdraws the event sent by
+// BookingCalendarEvent for the same booking, so calendars remove it
+func (es *EmailService) BookingCalendarCancellation(bookingID uuid.UUID, facilityName, location string, start, end time.Time) []byte {
+	return es.bookingCalendar("CANCEL", "CANCELLED", 1, bookingID, facilityName, location, start, end)
+}
+
+// bookingCalendar builds the iCalendar file for a booking's event. A later version of the
+// event, such as its cancellation, must have a higher sequence to replace the original.
+func (es *EmailService) bookingCalendar(method, status string, sequence int, bookingID uuid.UUID, facilityName, location string, start, end time.Time) []byte {
+	return es.calendarFile("Facility Bookings", method, []calendarEvent{{
+		UID:      "booking-" + bookingID.String(),
+		Sequence: sequence,
+		Start:    start,
+		End:      end,
+		Summary:  facilityName + " booking",
+		Location: location,
+		Status:   status,
+	}})
+}
+
+// ProgramScheduleCalendar builds an iCalendar file holding an event for each of a
+// program's sessions. Session IDs make up the UIDs, so a resent schedule updates the
+// events already on a calendar.
+func (es *EmailService) ProgramScheduleCalendar(programTitle, location string, sessions []db.Session) []byte {
+	var events []calendarEvent
+	for _, s := range sessions {
+		if s.StartsAt == nil {
+			continue
+		}
+		event := calendarEvent{
+			UID:      "session-" + s.ID.String(),
+			Start:    *s.StartsAt,
+			Summary:  programTitle,
+			Location: location,
+			Status:   "CONFIRMED",
+		}
+		if s.EndsAt != nil {
+			event.End = *s.EndsAt
+		}
+		events = append(events, event)
+	}
+	return es.calendarFile("Programs", "PUBLISH", events)
+}
+
+// calendarEvent is one VEVENT of an iCalendar file. A zero End leaves out DTEND.
+type calendarEvent struct {
+	UID      string
+	Sequence int
+	Start    time.Time
+	End      time.Time
+	Summary  string
+	Location string
+	Status   string
+}
+
+// calendarFile builds an iCalendar (RFC 5545) file of events under the given product
+func (es *EmailService) calendarFile(product, method string, events []calendarEvent) []byte {
+	const stamp = "20060102T150405Z"
+
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//Sterling Recreation//" + product + "//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:" + method,
 	}
+	now := time.Now().UTC().Format(stamp)
+	for _, e := range events {
+		lines = append(lines,
+			"BEGIN:VEVENT",
+			"UID:"+e.UID+"@"+es.messageIDDomain(),
+			"SEQUENCE:"+strconv.Itoa(e.Sequence),
+			"DTSTAMP:"+now,
+			"DTSTART:"+e.Start.UTC().Format(stamp),
+		)
+		if !e.End.IsZero() {
+			lines = append(lines, "DTEND:"+e.End.UTC().Format(stamp))
+		}
+		lines = append(lines, "SUMMARY:"+icsEscape(e.Summary))
+		if e.Location != "" {
+			lines = append(lines, "LOCATION:"+icsEscape(e.Location))
+		}
+		lines = append(lines, "STATUS:"+e.Status, "END:VEVENT")
+	}
+	lines = append(lines, "END:VCALENDAR")
+
+	var ics bytes.Buffer
+	for _, line := range lines {
+		ics.WriteString(icsFold(line))
+	}
+	return ics.Bytes()
+}
+
+// icsEscape escapes a TEXT property value
+func icsEscape(value string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		";", "\\;",
+		",", "\\,",
+		"\r\n", "\\n",
+		"\n", "\\n",
+	).Replace(value)
+}
 
-	err := smtp.SendMail(addr, auth, es.from, []string{to}, msg)
+// icsFold ends a content line with CRLF, folding it onto continuation lines so none is
+// longer than 75 octets. Folds never split a UTF-8 character.
+func icsFold(line string) string {
+	var folded strings.Builder
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		folded.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		// The leading space of a continuation line counts towards its length
+		limit = 74
+	}
+	folded.WriteString(line + "\r\n")
+	return folded.String()
+}
+
+// SendTemplatedEmail renders the template under templateKey with data and sends it along
+// with any attachments
+func (es *EmailService) SendTemplatedEmail(to, templateKey string, data map[string]interface{}, attachments ...EmailAttachment) error {
+	email, err := es.RenderTemplate(templateKey, data)
 	if err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+		return err
 	}
 
-	log.Printf("Email sent to %s: %s", to, subject)
-	return nil
+	return es.SendEmail(to, email.Subject, email.HTML, email.Text, attachments...)
 }
 
-func (es *EmailService) SendTemplatedEmail(to, templateKey string, data map[string]interface{}) error {
+// ErrTemplateNotFound is returned for an email template key that does not exist
+var ErrTemplateNotFound = errors.New("email template not found")
+
+// RenderedEmail is an email template rendered with its data
+type RenderedEmail struct {
+	To      string `json:"to,omitempty"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// RenderTemplate renders an email template's subject and bodies with the data
+func (es *EmailService) RenderTemplate(templateKey string, data map[string]interface{}) (*RenderedEmail, error) {
 	// Get template from database
 	var tmpl db.EmailTemplate
 	err := es.db.QueryRow(`
@@ -78,41 +322,44 @@ func (es *EmailService) SendTemplatedEmail(to, templateKey string, data map[stri
 		FROM email_templates
 		WHERE template_key = $1
 	`, templateKey).Scan(&tmpl.TemplateKey, &tmpl.Subject, &tmpl.BodyHTML, &tmpl.BodyText)
+	if err == sql.ErrNoRows {
+		return nil, ErrTemplateNotFound
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get email template: %w", err)
+		return nil, fmt.Errorf("failed to get email template: %w", err)
 	}
 
 	// Parse and execute subject template
 	subjectTmpl, err := textTemplate.New("subject").Parse(tmpl.Subject)
 	if err != nil {
-		return fmt.Errorf("failed to parse subject template: %w", err)
+		return nil, fmt.Errorf("failed to parse subject template: %w", err)
 	}
 	var subjectBuf bytes.Buffer
 	if err := subjectTmpl.Execute(&subjectBuf, data); err != nil {
-		return fmt.Errorf("failed to execute subject template: %w", err)
+		return nil, fmt.Errorf("failed to execute subject template: %w", err)
 	}
 
 	// Parse and execute HTML template
 	htmlTmpl, err := template.New("html").Parse(tmpl.BodyHTML)
 	if err != nil {
-		return fmt.Errorf("failed to parse HTML template: %w", err)
+		return nil, fmt.Errorf("failed to parse HTML template: %w", err)
 	}
 	var htmlBuf bytes.Buffer
 	if err := htmlTmpl.Execute(&htmlBuf, data); err != nil {
-		return fmt.Errorf("failed to execute HTML template: %w", err)
+		return nil, fmt.Errorf("failed to execute HTML template: %w", err)
 	}
 
 	// Parse and execute text template
 	textTmpl, err := textTemplate.New("text").Parse(tmpl.BodyText)
 	if err != nil {
-		return fmt.Errorf("failed to parse text template: %w", err)
+		return nil, fmt.Errorf("failed to parse text template: %w", err)
 	}
 	var textBuf bytes.Buffer
 	if err := textTmpl.Execute(&textBuf, data); err != nil {
-		return fmt.Errorf("failed to execute text template: %w", err)
+		return nil, fmt.Errorf("failed to execute text template: %w", err)
 	}
 
-	return es.SendEmail(to, subjectBuf.String(), htmlBuf.String(), textBuf.String())
+	return &RenderedEmail{Subject: subjectBuf.String(), HTML: htmlBuf.String(), Text: textBuf.String()}, nil
 }
 
 // ProcessNotificationQueue processes pending notifications
@@ -120,7 +367,8 @@ func (es *EmailService) ProcessNotificationQueue() error {
 	rows, err := es.db.Query(`
 		SELECT id, type, payload, attempts, max_attempts
 		FROM notification_queue
-		WHERE attempts < max_attempts
+		WHERE failed_at IS NULL
+			AND attempts < max_attempts
 			AND (not_before_ts IS NULL OR not_before_ts <= $1)
 		ORDER BY created_at ASC
 		LIMIT 100
@@ -131,7 +379,7 @@ func (es *EmailService) ProcessNotificationQueue() error {
 	}
 	defer rows.Close()
 
-	var processed int
+	var processed, skipped int
 	for rows.Next() {
 		var notif db.NotificationQueue
 		err := rows.Scan(&notif.ID, &notif.Type, &notif.Payload, &notif.Attempts, &notif.MaxAttempts)
@@ -140,29 +388,63 @@ func (es *EmailService) ProcessNotificationQueue() error {
 			continue
 		}
 
-		err = es.processNotification(&notif)
-		if err != nil {
-			log.Printf("Failed to process notification %d: %v", notif.ID, err)
-			// Update with error
-			es.db.Exec(`
-				UPDATE notification_queue
-				SET attempts = attempts + 1, last_error = $1
-				WHERE id = $2
-			`, err.Error(), notif.ID)
-		} else {
-			// Delete successful notification
-			es.db.Exec(`DELETE FROM notification_queue WHERE id = $1`, notif.ID)
+		err = es.handleNotification(&notif)
+		switch {
+		case errors.Is(err, ErrNotificationSkipped):
+			// Archived with the reason, so it is not taken for a notification that was sent
+			if err := es.db.ArchiveSkippedNotification(notif.ID, err.Error()); err != nil {
+				log.Printf("Failed to archive skipped notification %d: %v", notif.ID, err)
+			}
+			skipped++
+		case err != nil:
+			deadLettered, err := es.db.RecordNotificationFailure(notif.ID, err.Error())
+			if err != nil {
+				log.Printf("Failed to record failure of notification %d: %v", notif.ID, err)
+			} else if deadLettered {
+				log.Printf("Notification %d dead-lettered after %d attempts", notif.ID, notif.MaxAttempts)
+			}
+		default:
+			// Keep a record of the sent notification for the user's history
+			if err := es.db.ArchiveNotification(notif.ID); err != nil {
+				log.Printf("Failed to archive notification %d: %v", notif.ID, err)
+			}
 			processed++
 		}
 	}
 
-	if processed > 0 {
-		log.Printf("Processed %d notifications", processed)
+	if processed > 0 || skipped > 0 {
+		log.Printf("Processed %d notifications, skipped %d", processed, skipped)
 	}
 
 	return nil
 }
 
+// ErrNotificationSkipped is returned for a notification that was dropped without sending
+// because what it was about changed while it waited in the queue
+var ErrNotificationSkipped = errors.New("notification skipped")
+
+// skipNotification returns ErrNotificationSkipped with the reason the notification was
+// dropped
+func skipNotification(reason string) error {
+	return fmt.Errorf("%w: %s", ErrNotificationSkipped, reason)
+}
+
+// handleNotification processes a queued notification and logs any failure. A panic is
+// recovered as an error, so one bad row counts as a failed attempt instead of stopping
+// the worker.
+func (es *EmailService) handleNotification(notif *db.NotificationQueue) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+		if err != nil && !errors.Is(err, ErrNotificationSkipped) {
+			log.Printf("Failed to process notification %d: %v", notif.ID, err)
+		}
+	}()
+
+	return es.withNotification(notif).processNotification(notif)
+}
+
 func (es *EmailService) processNotification(notif *db.NotificationQueue) error {
 	// Parse payload
 	var payload map[string]interface{}
@@ -170,8 +452,92 @@ func (es *EmailService) processNotification(notif *db.NotificationQueue) error {
 		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
+	// Facility booking notifications carry a booking rather than a registration
+	if _, ok := payload["booking_id"]; ok {
+		return es.processBookingNotification(notif.Type, payload)
+	}
+
+	// Interest list notifications go to a user rather than a participant
+	if _, ok := payload["interest_id"]; ok {
+		return es.processInterestNotification(notif.Type, payload)
+	}
+
+	// Promotion emails can be held back by a lead time; one whose spot was given up in the
+	// meantime is dropped, as is an offer that was accepted, declined or expired
+	if id, ok := payload["registration_id"].(string); ok && (notif.Type == "WAITLIST_PROMOTED" || notif.Type == "WAITLIST_OFFER") {
+		var status string
+		if err := es.db.QueryRow(`SELECT status FROM registrations WHERE id = $1`, id).Scan(&status); err != nil {
+			return fmt.Errorf("failed to get promoted registration: %w", err)
+		}
+		if notif.Type == "WAITLIST_OFFER" && status != "offered" {
+			return skipNotification("offer is no longer open")
+		}
+		if notif.Type == "WAITLIST_PROMOTED" && status != "confirmed" && status != "paused" {
+			return skipNotification("promoted registration no longer holds its spot")
+		}
+	}
+
+	// Season schedules list the program's sessions and attach them as calendar events
+	if notif.Type == "PROGRAM_SCHEDULE" {
+		return es.processScheduleNotification(notif.Type, payload)
+	}
+
+	// Reminders are queued hours ahead of their send time; one whose registration was
+	// cancelled in the meantime is dropped
+	if strings.HasPrefix(notif.Type, "REMINDER_") {
+		confirmed, err := es.reminderStillConfirmed(payload)
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			return skipNotification("registration is no longer confirmed")
+		}
+	}
+
+	userEmail, templateData, err := es.registrationTemplateData(notif.Type, payload)
+	if err != nil {
+		return err
+	}
+
+	// Each notification type has a template of the same name
+	templateKey := notif.Type
+
+	// Registrations awaiting approval go to staff rather than the family
+	if notif.Type == "REGISTRATION_PENDING_REVIEW" {
+		return es.sendToAdmins(templateKey, templateData)
+	}
+
+	return es.SendTemplatedEmail(userEmail, templateKey, templateData)
+}
+
+// reminderStillConfirmed reports whether the participant of a reminder is still
+// confirmed for what it reminds them of: the payload's session, or the program or event
+// as a whole when it names none
+func (es *EmailService) reminderStillConfirmed(payload map[string]interface{}) (bool, error) {
+	var confirmed bool
+	err := es.db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM registrations
+			WHERE parent_type = $1 AND parent_id = $2 AND participant_id = $3
+				AND session_id IS NOT DISTINCT FROM $4::uuid AND status = 'confirmed'
+		)
+	`, payload["parent_type"], payload["parent_id"], payload["participant_id"], payload["session_id"]).Scan(&confirmed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check registration for reminder: %w", err)
+	}
+	return confirmed, nil
+}
+
+// registrationTemplateData assembles the recipient and template data of a notification
+// about a registration: the household owner's email, the participant, the program or
+// event and, where the notification calls for them, the session date, waitlist position,
+// reason, offer deadline and event check-in code
+func (es *EmailService) registrationTemplateData(notifType string, payload map[string]interface{}) (string, map[string]interface{}, error) {
 	// Get participant and user email
-	participantID := payload["participant_id"].(string)
+	participantID, ok := payload["participant_id"].(string)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid participant_id in payload")
+	}
 	var userEmail, participantName string
 	err := es.db.QueryRow(`
 		SELECT u.email, p.first_name || ' ' || p.last_name
@@ -181,12 +547,18 @@ func (es *EmailService) processNotification(notif *db.NotificationQueue) error {
 		WHERE p.id = $1
 	`, participantID).Scan(&userEmail, &participantName)
 	if err != nil {
-		return fmt.Errorf("failed to get user email: %w", err)
+		return "", nil, fmt.Errorf("failed to get user email: %w", err)
 	}
 
 	// Get program/event info
-	parentType := payload["parent_type"].(string)
-	parentID := payload["parent_id"].(string)
+	parentType, ok := payload["parent_type"].(string)
+	if !ok || (parentType != "program" && parentType != "event") {
+		return "", nil, fmt.Errorf("invalid parent_type in payload")
+	}
+	parentID, ok := payload["parent_id"].(string)
+	if !ok {
+		return "", nil, fmt.Errorf("invalid parent_id in payload")
+	}
 
 	var programTitle, location string
 	var sessionDate *time.Time
@@ -205,16 +577,20 @@ func (es *EmailService) processNotification(notif *db.NotificationQueue) error {
 		`, parentID).Scan(&programTitle, &location, &sessionDate)
 	}
 	if err != nil {
-		return fmt.Errorf("failed to get program/event info: %w", err)
+		return "", nil, fmt.Errorf("failed to get program/event info: %w", err)
 	}
 
-	// Check for session date
+	// A program notification about one session, such as its reminder, gives that
+	// session's date
 	if sessionIDStr, ok := payload["session_id"].(string); ok && sessionIDStr != "" {
-		es.db.QueryRow(`
+		err = es.db.QueryRow(`
 			SELECT starts_at
 			FROM sessions
 			WHERE id = $1
 		`, sessionIDStr).Scan(&sessionDate)
+		if err != nil && err != sql.ErrNoRows {
+			return "", nil, fmt.Errorf("failed to get session date: %w", err)
+		}
 	}
 
 	// Build template data
@@ -229,13 +605,308 @@ func (es *EmailService) processNotification(notif *db.NotificationQueue) error {
 	if position, ok := payload["position"]; ok {
 		templateData["Position"] = position
 	}
+	if reason, ok := payload["reason"]; ok {
+		templateData["Reason"] = reason
+	}
+	if expiresAt, ok := payload["offer_expires_at"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, expiresAt); err == nil {
+			templateData["OfferExpiresAt"] = t.Format("Monday, January 2, 2006 at 3:04 PM")
+		}
+	}
 
-	// Determine template key
-	templateKey := notif.Type
-	if notif.Type == "REMINDER" {
-		// Could be 72h or 24h
-		templateKey = "REMINDER_24H" // Default
+	// A demoted family moving back up is offered the spot when offers are on, rather than
+	// confirmed automatically
+	if notifType == "WAITLIST_DEMOTED" {
+		if window := es.db.WaitlistOfferWindow(); window > 0 {
+			templateData["OfferHours"] = int(window.Hours())
+		}
 	}
 
-	return es.SendTemplatedEmail(userEmail, templateKey, templateData)
+	// Event confirmations carry a check-in code for the door, when check-in is configured
+	if parentType == "event" && CheckInEnabled() && (notifType == "CONFIRMATION" || notifType == "WAITLIST_PROMOTED") {
+		// Promotion payloads name the registration; other notifications look it up
+		var registrationID uuid.UUID
+		if id, ok := payload["registration_id"].(string); ok {
+			registrationID, err = uuid.Parse(id)
+			if err != nil {
+				return "", nil, fmt.Errorf("invalid registration_id: %w", err)
+			}
+		} else {
+			err = es.db.QueryRow(`
+				SELECT id
+				FROM registrations
+				WHERE parent_type = 'event' AND parent_id = $1 AND participant_id = $2 AND status = 'confirmed'
+			`, parentID, participantID).Scan(&registrationID)
+			if err != nil {
+				return "", nil, fmt.Errorf("failed to get registration for check-in code: %w", err)
+			}
+		}
+		token, err := GenerateCheckInToken(registrationID)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to generate check-in code: %w", err)
+		}
+		templateData["CheckInToken"] = token
+	}
+
+	return userEmail, templateData, nil
+}
+
+// PreviewRegistrationEmail renders the template under templateKey with the data the
+// notification queue would assemble for a registration, without sending it. It returns
+// nil if the registration does not exist.
+func (es *EmailService) PreviewRegistrationEmail(templateKey string, registrationID uuid.UUID) (*RenderedEmail, error) {
+	var parentType string
+	var parentID, participantID uuid.UUID
+	var sessionID *uuid.UUID
+	err := es.db.QueryRow(`
+		SELECT parent_type, parent_id, session_id, participant_id
+		FROM registrations
+		WHERE id = $1
+	`, registrationID).Scan(&parentType, &parentID, &sessionID, &participantID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration: %w", err)
+	}
+
+	// Build the payload as it is queued, round-tripped through JSON as the worker reads it
+	payload := map[string]interface{}{
+		"parent_type":     parentType,
+		"parent_id":       parentID,
+		"participant_id":  participantID,
+		"registration_id": registrationID,
+	}
+	if sessionID != nil {
+		payload["session_id"] = sessionID
+	}
+	standing, err := es.db.GetWaitlistStanding(registrationID)
+	if err != nil {
+		return nil, err
+	}
+	if standing != nil {
+		payload["position"] = standing.Position
+	}
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payload: %w", err)
+	}
+	payload = nil
+	if err := json.Unmarshal(payloadJSON, &payload); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	userEmail, templateData, err := es.registrationTemplateData(templateKey, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	email, err := es.RenderTemplate(templateKey, templateData)
+	if err != nil {
+		return nil, err
+	}
+	email.To = userEmail
+	return email, nil
+}
+
+// processScheduleNotification emails a program registrant the sessions they are
+// registered for, with a calendar file of them attached. A registration no longer
+// confirmed, or whose sessions have all gone, is dropped.
+func (es *EmailService) processScheduleNotification(templateKey string, payload map[string]interface{}) error {
+	registrationID, ok := payload["registration_id"].(string)
+	if !ok {
+		return fmt.Errorf("invalid registration_id in payload")
+	}
+	var status string
+	if err := es.db.QueryRow(`SELECT status FROM registrations WHERE id = $1`, registrationID).Scan(&status); err != nil {
+		return fmt.Errorf("failed to get registration for schedule: %w", err)
+	}
+	if status != "confirmed" {
+		return skipNotification("registration is no longer confirmed")
+	}
+
+	userEmail, templateData, err := es.registrationTemplateData(templateKey, payload)
+	if err != nil {
+		return err
+	}
+
+	programID, err := uuid.Parse(payload["parent_id"].(string))
+	if err != nil {
+		return fmt.Errorf("invalid parent_id in payload: %w", err)
+	}
+	var sessionID *uuid.UUID
+	if id, ok := payload["session_id"].(string); ok {
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return fmt.Errorf("invalid session_id in payload: %w", err)
+		}
+		sessionID = &parsed
+	}
+	sessions, err := es.db.GetProgramScheduleSessions(programID, sessionID)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return skipNotification("program has no dated sessions")
+	}
+
+	var schedule []map[string]string
+	for _, s := range sessions {
+		entry := map[string]string{
+			"Date":      s.StartsAt.Format("Monday, January 2, 2006"),
+			"StartTime": s.StartsAt.Format("3:04 PM"),
+		}
+		if s.EndsAt != nil {
+			entry["EndTime"] = s.EndsAt.Format("3:04 PM")
+		}
+		schedule = append(schedule, entry)
+	}
+	templateData["Sessions"] = schedule
+
+	title, _ := templateData["ProgramTitle"].(string)
+	location, _ := templateData["Location"].(string)
+	return es.SendTemplatedEmail(userEmail, templateKey, templateData, EmailAttachment{
+		Filename:    "schedule.ics",
+		ContentType: "text/calendar; charset=UTF-8; method=PUBLISH",
+		Data:        es.ProgramScheduleCalendar(title, location, sessions),
+	})
+}
+
+// sendToAdmins sends a templated email to every admin user. A retried notification skips
+// the admins its earlier attempts already reached, so a failure part way through does
+// not email the rest twice. With no admin to send to, the notification fails rather
+// than counting as sent.
+func (es *EmailService) sendToAdmins(templateKey string, data map[string]interface{}) error {
+	rows, err := es.db.Query(`SELECT email FROM users WHERE role = 'admin' AND NOT is_service ORDER BY email`)
+	if err != nil {
+		return fmt.Errorf("failed to get admin emails: %w", err)
+	}
+	defer rows.Close()
+
+	var emails []string
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return fmt.Errorf("failed to scan admin email: %w", err)
+		}
+		emails = append(emails, email)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to get admin emails: %w", err)
+	}
+	if len(emails) == 0 {
+		return fmt.Errorf("no admin users to send %s to", templateKey)
+	}
+
+	alreadySent := map[string]bool{}
+	if es.notification != nil {
+		alreadySent, err = es.db.GetNotificationRecipients(es.notification.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, email := range emails {
+		if alreadySent[strings.ToLower(strings.TrimSpace(email))] {
+			continue
+		}
+		if err := es.SendTemplatedEmail(email, templateKey, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (es *EmailService) processBookingNotification(templateKey string, payload map[string]interface{}) error {
+	bookingID, ok := payload["booking_id"].(string)
+	if !ok {
+		return fmt.Errorf("invalid booking_id in payload")
+	}
+
+	var userEmail, firstName, facilityName, location string
+	var startTime, endTime time.Time
+	err := es.db.QueryRow(`
+		SELECT u.email, u.first_name, f.name, COALESCE(f.location, ''), b.start_time, b.end_time
+		FROM facility_bookings b
+		JOIN facilities f ON f.id = b.facility_id
+		JOIN users u ON u.id = b.user_id
+		WHERE b.id = $1
+	`, bookingID).Scan(&userEmail, &firstName, &facilityName, &location, &startTime, &endTime)
+	if err != nil {
+		return fmt.Errorf("failed to get booking info: %w", err)
+	}
+
+	templateData := map[string]interface{}{
+		"UserFirstName": firstName,
+		"FacilityName":  facilityName,
+		"Location":      location,
+		"BookingDate":   startTime.Format("Monday, January 2, 2006"),
+		"StartTime":     startTime.Format("3:04 PM"),
+		"EndTime":       endTime.Format("3:04 PM"),
+	}
+
+	if reason, ok := payload["reason"].(string); ok {
+		templateData["Reason"] = reason
+	}
+	if previousStart, ok := payload["previous_start_time"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, previousStart); err == nil {
+			templateData["PreviousDate"] = t.Format("Monday, January 2, 2006")
+			templateData["PreviousStartTime"] = t.Format("3:04 PM")
+		}
+	}
+	if previousEnd, ok := payload["previous_end_time"].(string); ok {
+		if t, err := time.Parse(time.RFC3339, previousEnd); err == nil {
+			templateData["PreviousEndTime"] = t.Format("3:04 PM")
+		}
+	}
+
+	if cancelledBy, ok := payload["cancelled_by"].(string); ok {
+		templateData["CancelledByAdmin"] = cancelledBy == "admin"
+	}
+
+	// Confirmations carry the booking as a calendar invite, and cancellations withdraw it
+	var attachments []EmailAttachment
+	switch templateKey {
+	case "BOOKING_CONFIRMED", "BOOKING_APPROVED", "BOOKING_CANCELLED":
+		id, err := uuid.Parse(bookingID)
+		if err != nil {
+			return fmt.Errorf("invalid booking_id in payload: %w", err)
+		}
+		invite := EmailAttachment{
+			Filename:    "booking.ics",
+			ContentType: "text/calendar; charset=UTF-8; method=PUBLISH",
+			Data:        es.BookingCalendarEvent(id, facilityName, location, startTime, endTime),
+		}
+		if templateKey == "BOOKING_CANCELLED" {
+			invite.ContentType = "text/calendar; charset=UTF-8; method=CANCEL"
+			invite.Data = es.BookingCalendarCancellation(id, facilityName, location, startTime, endTime)
+		}
+		attachments = append(attachments, invite)
+	}
+
+	return es.SendTemplatedEmail(userEmail, templateKey, templateData, attachments...)
+}
+
+func (es *EmailService) processInterestNotification(templateKey string, payload map[string]interface{}) error {
+	interestID, ok := payload["interest_id"].(string)
+	if !ok {
+		return fmt.Errorf("invalid interest_id in payload")
+	}
+
+	var userEmail, firstName, programTitle string
+	err := es.db.QueryRow(`
+		SELECT u.email, u.first_name, p.title
+		FROM interest_list i
+		JOIN users u ON u.id = i.user_id
+		JOIN programs p ON p.id = i.program_id
+		WHERE i.id = $1
+	`, interestID).Scan(&userEmail, &firstName, &programTitle)
+	if err != nil {
+		return fmt.Errorf("failed to get interest info: %w", err)
+	}
+
+	return es.SendTemplatedEmail(userEmail, templateKey, map[string]interface{}{
+		"UserFirstName": firstName,
+		"ProgramTitle":  programTitle,
+	})
 }
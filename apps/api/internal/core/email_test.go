@@ -0,0 +1,316 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+// TestBookingCalendarEvent tests the invite is a single RFC 5545 event for the booking
+func TestBookingCalendarEvent(t *testing.T) {
+	es := &EmailService{from: "Sterling Recreation <bookings@sterling.example>"}
+	bookingID := uuid.New()
+	ny := time.FixedZone("EST", -5*3600)
+	start := time.Date(2030, time.March, 4, 18, 0, 0, 0, ny)
+
+	ics := string(es.BookingCalendarEvent(bookingID, "Main Gym", "100 Main St, Sterling; Court A", start, start.Add(90*time.Minute)))
+
+	for _, want := range []string{
+		"BEGIN:VCALENDAR\r\nVERSION:2.0\r\n",
+		"BEGIN:VEVENT\r\n",
+		"UID:booking-" + bookingID.String() + "@sterling.example\r\n",
+		"DTSTART:20300304T230000Z\r\n",
+		"DTEND:20300305T003000Z\r\n",
+		"SUMMARY:Main Gym booking\r\n",
+		`LOCATION:100 Main St\, Sterling\; Court A` + "\r\n",
+		"END:VEVENT\r\nEND:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar event missing %q:\n%s", want, ics)
+		}
+	}
+	if strings.Contains(strings.ReplaceAll(ics, "\r\n", ""), "\n") {
+		t.Error("calendar event has a line not ended by CRLF")
+	}
+	if strings.Contains(ics, "LOCATION:\r\n") {
+		t.Error("empty location written")
+	}
+}
+
+func TestICSFold(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("é", 100)
+	folded := icsFold(line)
+
+	if !strings.HasSuffix(folded, "\r\n") {
+		t.Fatalf("folded line %q does not end with CRLF", folded)
+	}
+	lines := strings.Split(strings.TrimSuffix(folded, "\r\n"), "\r\n")
+	if len(lines) < 2 {
+		t.Fatalf("line of %d octets not folded", len(line))
+	}
+	var unfolded string
+	for i, l := range lines {
+		if len(l) > 75 {
+			t.Errorf("line %d is %d octets, want at most 75", i, len(l))
+		}
+		if i > 0 {
+			if !strings.HasPrefix(l, " ") {
+				t.Errorf("continuation line %d does not start with a space", i)
+			}
+			l = l[1:]
+		}
+		unfolded += l
+	}
+	if unfolded != line {
+		t.Errorf("unfolded line = %q, want %q", unfolded, line)
+	}
+
+	if got := icsFold("VERSION:2.0"); got != "VERSION:2.0\r\n" {
+		t.Errorf("short line folded as %q", got)
+	}
+}
+
+// TestBuildMessage tests attachments move the alternative bodies into a multipart/mixed
+// message, and emails without them are unchanged
+func TestBuildMessage(t *testing.T) {
+	plain := string(buildMessage("from@example.com", "to@example.com", "Hi", "<id@example.com>", "<p>Hi</p>", "Hi", nil))
+	if !strings.Contains(plain, "Content-Type: multipart/alternative; boundary=boundary\r\n") || strings.Contains(plain, "mixed") {
+		t.Errorf("email without attachments is not multipart/alternative:\n%s", plain)
+	}
+
+	data := []byte(strings.Repeat("BEGIN:VCALENDAR\r\n", 10))
+	msg := string(buildMessage("from@example.com", "to@example.com", "Hi", "<id@example.com>", "<p>Hi</p>", "Hi", []EmailAttachment{
+		{Filename: "booking.ics", ContentType: "text/calendar; charset=UTF-8; method=PUBLISH", Data: data},
+	}))
+
+	for _, want := range []string{
+		"MIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=mixed\r\n",
+		"--mixed\r\nContent-Type: multipart/alternative; boundary=boundary\r\n",
+		"--boundary--\r\n--mixed\r\n",
+		"Content-Type: text/calendar; charset=UTF-8; method=PUBLISH; name=\"booking.ics\"\r\n",
+		"Content-Disposition: attachment; filename=\"booking.ics\"\r\n",
+		"--mixed--\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message missing %q:\n%s", want, msg)
+		}
+	}
+
+	body := msg[strings.Index(msg, "base64\r\n\r\n")+len("base64\r\n\r\n") : strings.Index(msg, "--mixed--")]
+	var encoded string
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\r\n"), "\r\n") {
+		if len(line) > 76 {
+			t.Errorf("base64 line is %d characters, want at most 76", len(line))
+		}
+		encoded += line
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || string(decoded) != string(data) {
+		t.Errorf("attachment decoded to %q (%v), want %q", decoded, err, data)
+	}
+}
+
+// TestBookingCalendarCancellation tests the cancellation replaces the original invite
+func TestBookingCalendarCancellation(t *testing.T) {
+	es := &EmailService{from: "bookings@sterling.example"}
+	bookingID := uuid.New()
+	start := time.Date(2030, time.March, 4, 18, 0, 0, 0, time.UTC)
+
+	invite := string(es.BookingCalendarEvent(bookingID, "Main Gym", "", start, start.Add(time.Hour)))
+	cancel := string(es.BookingCalendarCancellation(bookingID, "Main Gym", "", start, start.Add(time.Hour)))
+
+	uid := "UID:booking-" + bookingID.String() + "@sterling.example\r\n"
+	for _, want := range []string{uid, "METHOD:CANCEL\r\n", "SEQUENCE:1\r\n", "STATUS:CANCELLED\r\n", "DTSTART:20300304T180000Z\r\n"} {
+		if !strings.Contains(cancel, want) {
+			t.Errorf("cancellation missing %q:\n%s", want, cancel)
+		}
+	}
+	if !strings.Contains(invite, uid) || !strings.Contains(invite, "SEQUENCE:0\r\n") {
+		t.Errorf("invite does not share the UID at a lower sequence:\n%s", invite)
+	}
+}
+
+// TestHandleNotificationMalformed tests a bad queue row is logged as a failed attempt
+// rather than stopping the email worker
+func TestHandleNotificationMalformed(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	// Without a database, reaching a query panics; the malformed payloads must fail first
+	es := &EmailService{}
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{"missing participant_id", `{"parent_type": "program", "parent_id": "x"}`, "participant_id"},
+		{"non-string participant_id", `{"participant_id": 42}`, "participant_id"},
+		{"not an object", `[1, 2]`, "unmarshal"},
+		{"panics", `{"booking_id": "` + uuid.New().String() + `"}`, "panic"},
+	}
+
+	for i, tt := range tests {
+		logs.Reset()
+		notif := &db.NotificationQueue{ID: int64(i + 1), Type: "CONFIRMATION", Payload: []byte(tt.payload)}
+		err := es.handleNotification(notif)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+		if !strings.Contains(logs.String(), fmt.Sprintf("Failed to process notification %d", notif.ID)) {
+			t.Errorf("%s: failure not logged, got %q", tt.name, logs.String())
+		}
+	}
+}
+
+// TestPreviewRegistrationEmail tests a preview renders the template with the
+// registration's data, including a waitlisted registration's position
+func TestPreviewRegistrationEmail(t *testing.T) {
+	rs, database := setupTestRegistrationService(t)
+	es := NewEmailService(database)
+	programID := createTestProgram(t, database, 1)
+
+	register := func() *db.Registration {
+		t.Helper()
+		result, err := rs.Register(context.Background(), db.RegistrationRequest{
+			ParentType:    "program",
+			ParentID:      programID,
+			ParticipantID: createTestParticipant(t, database),
+		})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		return result.Registration
+	}
+	confirmed, waitlisted := register(), register()
+
+	email, err := es.PreviewRegistrationEmail("CONFIRMATION", confirmed.ID)
+	if err != nil {
+		t.Fatalf("PreviewRegistrationEmail: %v", err)
+	}
+	if email.Subject != "Registration Confirmed - Test Program" || email.To == "" {
+		t.Errorf("preview = %+v, want the confirmation for Test Program with a recipient", email)
+	}
+	if !strings.Contains(email.Text, "Hi Test Participant") {
+		t.Errorf("preview text = %q, want the participant's name", email.Text)
+	}
+
+	email, err = es.PreviewRegistrationEmail("WAITLIST_SPOT", waitlisted.ID)
+	if err != nil {
+		t.Fatalf("PreviewRegistrationEmail: %v", err)
+	}
+	if !strings.Contains(email.Text, "Your position: #1") {
+		t.Errorf("waitlist preview text = %q, want position 1", email.Text)
+	}
+
+	if email, err := es.PreviewRegistrationEmail("CONFIRMATION", uuid.New()); email != nil || err != nil {
+		t.Errorf("unknown registration = %+v, %v; want nil, nil", email, err)
+	}
+	if _, err := es.PreviewRegistrationEmail("NO_SUCH_TEMPLATE", confirmed.ID); !errors.Is(err, ErrTemplateNotFound) {
+		t.Errorf("unknown template error = %v, want ErrTemplateNotFound", err)
+	}
+}
+
+// TestProgramScheduleCalendar tests each dated session becomes its own event
+func TestProgramScheduleCalendar(t *testing.T) {
+	es := &EmailService{from: "programs@sterling.example"}
+	start := time.Date(2030, time.June, 3, 9, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	later := start.AddDate(0, 0, 7)
+	sessions := []db.Session{
+		{ID: uuid.New(), StartsAt: &start, EndsAt: &end},
+		{ID: uuid.New(), StartsAt: &later},
+		{ID: uuid.New()},
+	}
+
+	ics := string(es.ProgramScheduleCalendar("Summer Swim", "Town Pool", sessions))
+
+	if n := strings.Count(ics, "BEGIN:VEVENT\r\n"); n != 2 {
+		t.Errorf("calendar has %d events, want one per dated session:\n%s", n, ics)
+	}
+	for _, want := range []string{
+		"UID:session-" + sessions[0].ID.String() + "@sterling.example\r\n",
+		"UID:session-" + sessions[1].ID.String() + "@sterling.example\r\n",
+		"DTSTART:20300603T090000Z\r\nDTEND:20300603T110000Z\r\n",
+		"DTSTART:20300610T090000Z\r\nSUMMARY:Summer Swim\r\n",
+		"LOCATION:Town Pool\r\n",
+	} {
+		if !strings.Contains(ics, want) {
+			t.Errorf("calendar missing %q:\n%s", want, ics)
+		}
+	}
+}
+
+// TestReminderStillConfirmed tests a session reminder is only due while the participant
+// is confirmed in that session, not just in another session of the program, and is
+// skipped otherwise
+func TestReminderStillConfirmed(t *testing.T) {
+	rs, database := setupTestRegistrationService(t)
+	es := NewEmailService(database)
+	programID := createTestProgram(t, database, 5)
+	participantID := createTestParticipant(t, database)
+	t.Cleanup(func() {
+		database.Exec(`DELETE FROM sessions WHERE parent_id = $1`, programID)
+	})
+
+	var sessionIDs []uuid.UUID
+	var registrationIDs []uuid.UUID
+	for i := 1; i <= 2; i++ {
+		startsAt := time.Now().Add(time.Duration(i) * 24 * time.Hour)
+		endsAt := startsAt.Add(time.Hour)
+		session, err := database.CreateSession(&db.Session{ParentType: "program", ParentID: programID, StartsAt: &startsAt, EndsAt: &endsAt, IsActive: true})
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		result, err := rs.Register(context.Background(), db.RegistrationRequest{
+			ParentType:    "program",
+			ParentID:      programID,
+			SessionID:     &session.ID,
+			ParticipantID: participantID,
+		})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		sessionIDs = append(sessionIDs, session.ID)
+		registrationIDs = append(registrationIDs, result.Registration.ID)
+	}
+
+	if err := rs.CancelRegistration(context.Background(), registrationIDs[1], participantID, nil, nil, nil); err != nil {
+		t.Fatalf("CancelRegistration: %v", err)
+	}
+
+	for i, want := range []bool{true, false} {
+		payload := map[string]interface{}{
+			"parent_type":    "program",
+			"parent_id":      programID.String(),
+			"session_id":     sessionIDs[i].String(),
+			"participant_id": participantID.String(),
+		}
+		confirmed, err := es.reminderStillConfirmed(payload)
+		if err != nil {
+			t.Fatalf("reminderStillConfirmed: %v", err)
+		}
+		if confirmed != want {
+			t.Errorf("session %d reminder still confirmed = %v, want %v", i+1, confirmed, want)
+		}
+	}
+
+	// The cancelled session's reminder is skipped rather than treated as sent
+	payload := fmt.Sprintf(`{"parent_type": "program", "parent_id": %q, "session_id": %q, "participant_id": %q}`,
+		programID, sessionIDs[1], participantID)
+	notif := &db.NotificationQueue{ID: 1, Type: "REMINDER_24H", Payload: []byte(payload)}
+	if err := es.handleNotification(notif); !errors.Is(err, ErrNotificationSkipped) {
+		t.Errorf("cancelled session reminder error = %v, want ErrNotificationSkipped", err)
+	}
+}
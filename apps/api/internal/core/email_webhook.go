@@ -0,0 +1,41 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// emailWebhookSecret is shared with the email provider, which signs each delivery webhook
+// with it. There is no fallback: without EMAIL_WEBHOOK_SECRET the webhook is disabled.
+func emailWebhookSecret() []byte {
+	return []byte(os.Getenv("EMAIL_WEBHOOK_SECRET"))
+}
+
+// SignEmailWebhook returns the hex HMAC-SHA256 of a webhook body, as the provider sends it
+func SignEmailWebhook(body []byte) string {
+	mac := hmac.New(sha256.New, emailWebhookSecret())
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyEmailWebhookSignature checks a webhook body against its signature header, which
+// may carry a "sha256=" prefix
+func VerifyEmailWebhookSignature(body []byte, signature string) error {
+	if len(emailWebhookSecret()) == 0 {
+		return fmt.Errorf("email webhook secret is not configured")
+	}
+
+	sig, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signature), "sha256="))
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("malformed email webhook signature")
+	}
+	expected, _ := hex.DecodeString(SignEmailWebhook(body))
+	if !hmac.Equal(sig, expected) {
+		return fmt.Errorf("invalid email webhook signature")
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package core
+
+import "testing"
+
+func TestVerifyEmailWebhookSignature(t *testing.T) {
+	body := []byte(`{"events":[{"type":"bounce","message_id":"<a@example.com>"}]}`)
+
+	t.Setenv("EMAIL_WEBHOOK_SECRET", "")
+	if err := VerifyEmailWebhookSignature(body, SignEmailWebhook(body)); err == nil {
+		t.Error("accepted a webhook with no secret configured")
+	}
+
+	t.Setenv("EMAIL_WEBHOOK_SECRET", "test-secret")
+	sig := SignEmailWebhook(body)
+	if err := VerifyEmailWebhookSignature(body, sig); err != nil {
+		t.Errorf("valid signature rejected: %v", err)
+	}
+	if err := VerifyEmailWebhookSignature(body, "sha256="+sig); err != nil {
+		t.Errorf("valid prefixed signature rejected: %v", err)
+	}
+	if err := VerifyEmailWebhookSignature(append(body, ' '), sig); err == nil {
+		t.Error("accepted a signature for a different body")
+	}
+	if err := VerifyEmailWebhookSignature(body, "not-hex"); err == nil {
+		t.Error("accepted a malformed signature")
+	}
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -33,20 +34,43 @@ type BookingRequest struct {
 	EndTime        time.Time
 	Notes          *string
 	IdempotencyKey *string
+	SeriesID       *uuid.UUID // set on the bookings of a recurring series
+	UnitID         *uuid.UUID // unit asked for; any free unit when nil
 }
 
-// CreateBooking creates a new facility booking with distributed locking
+// ErrConfirmationRequired refuses a one-step booking at a facility whose bookings must be
+// reserved and then confirmed
+var ErrConfirmationRequired = errors.New("this facility requires reserving the booking and then confirming it")
+
+// ErrBookingNotFound is returned when a user confirms or cancels a booking that does not
+// exist
+var ErrBookingNotFound = errors.New("booking not found")
+
+// ErrBookingForbidden is returned when a user confirms or cancels someone else's booking
+var ErrBookingForbidden = errors.New("you do not have permission to change this booking")
+
+// CreateBooking creates a new facility booking with distributed locking. At a facility
+// that requires confirmation only the occurrences of a recurring series, which is
+// requested as a whole, can be booked in one step.
 func (fs *FacilitiesService) CreateBooking(ctx context.Context, req BookingRequest) (*db.FacilityBooking, error) {
+	return fs.createBooking(ctx, req, false)
+}
+
+// ReserveBooking holds a slot at a facility that requires confirmation for
+// db.BookingHoldDuration, until ConfirmBooking finalizes the booking. Holds are not
+// idempotent; an abandoned one simply expires.
+func (fs *FacilitiesService) ReserveBooking(ctx context.Context, req BookingRequest) (*db.FacilityBooking, error) {
+	req.IdempotencyKey = nil
+	req.SeriesID = nil
+	return fs.createBooking(ctx, req, true)
+}
+
+// createBooking books a slot, as a hold to be confirmed when hold is set
+func (fs *FacilitiesService) createBooking(ctx context.Context, req BookingRequest, hold bool) (*db.FacilityBooking, error) {
 	// Check for idempotency key first (before acquiring lock)
-	if req.IdempotencyKey != nil && *req.IdempotencyKey != "" {
-		existing, err := fs.db.GetBookingByIdempotencyKey(*req.IdempotencyKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check idempotency key: %w", err)
-		}
-		if existing != nil {
-			// Return existing booking (idempotent response)
-			return existing, nil
-		}
+	if existing, err := fs.getIdempotentBooking(req.IdempotencyKey); err != nil || existing != nil {
+		// Return existing booking (idempotent response)
+		return existing, err
 	}
 
 	// Build lock key for this facility and time range
@@ -60,18 +84,22 @@ func (fs *FacilitiesService) CreateBooking(ctx context.Context, req BookingReque
 	defer fs.releaseLock(ctx, lockKey, lock)
 
 	// Double-check idempotency key after acquiring lock
-	if req.IdempotencyKey != nil && *req.IdempotencyKey != "" {
-		existing, err := fs.db.GetBookingByIdempotencyKey(*req.IdempotencyKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to check idempotency key: %w", err)
-		}
-		if existing != nil {
-			return existing, nil
-		}
+	if existing, err := fs.getIdempotentBooking(req.IdempotencyKey); err != nil || existing != nil {
+		return existing, err
+	}
+
+	// Windows and the advance booking limit depend on who is booking
+	user, err := fs.db.GetUserByID(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
 	}
+	if user == nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	exempt := user.ExemptFromAdvanceLimit()
 
 	// Check availability (includes all validation)
-	if err := fs.db.CheckAvailability(req.FacilityID, req.StartTime, req.EndTime); err != nil {
+	if err := fs.db.CheckAvailability(req.FacilityID, req.StartTime, req.EndTime, user.Audience(), exempt); err != nil {
 		return nil, fmt.Errorf("slot not available: %w", err)
 	}
 
@@ -83,6 +111,36 @@ func (fs *FacilitiesService) CreateBooking(ctx context.Context, req BookingReque
 	if facility == nil {
 		return nil, fmt.Errorf("facility not found")
 	}
+	if hold && !facility.RequiresConfirmation {
+		return nil, fmt.Errorf("this facility does not take booking holds; book it directly")
+	}
+	if !hold && facility.RequiresConfirmation && req.SeriesID == nil {
+		return nil, ErrConfirmationRequired
+	}
+
+	// Facilities split into units book one unit, the one asked for or the first free
+	unitID, err := fs.db.AllocateUnit(facility, req.UnitID, req.StartTime, req.EndTime, nil)
+	if err != nil {
+		return nil, fmt.Errorf("slot not available: %w", err)
+	}
+
+	price, err := fs.db.PriceBooking(facility, req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price booking: %w", err)
+	}
+
+	// Facilities that require approval take the booking as a pending request, and a hold
+	// becomes one or the other once confirmed
+	status := "confirmed"
+	if facility.RequiresApproval {
+		status = "pending"
+	}
+	var holdExpiresAt *time.Time
+	if hold {
+		status = "held"
+		expires := time.Now().Add(db.BookingHoldDuration)
+		holdExpiresAt = &expires
+	}
 
 	// Create the booking
 	booking := &db.FacilityBooking{
@@ -92,9 +150,21 @@ func (fs *FacilitiesService) CreateBooking(ctx context.Context, req BookingReque
 		ParticipantIDs: req.ParticipantIDs,
 		StartTime:      req.StartTime,
 		EndTime:        req.EndTime,
-		Status:         "confirmed",
+		Status:         status,
 		Notes:          req.Notes,
 		IdempotencyKey: req.IdempotencyKey,
+		SeriesID:       req.SeriesID,
+		UnitID:         unitID,
+		HoldExpiresAt:  holdExpiresAt,
+	}
+
+	if price != nil {
+		booking.PriceCents = &price.TotalCents
+	}
+
+	// Record when the booking only went through because of an exemption
+	if exempt && req.StartTime.After(time.Now().AddDate(0, 0, facility.AdvanceBookingDays)) {
+		booking.AdvanceLimitWaived = true
 	}
 
 	createdBooking, err := fs.db.CreateBooking(booking)
@@ -105,41 +175,229 @@ func (fs *FacilitiesService) CreateBooking(ctx context.Context, req BookingReque
 	return createdBooking, nil
 }
 
-// CancelBooking cancels a booking with validation
-func (fs *FacilitiesService) CancelBooking(ctx context.Context, bookingID, userID uuid.UUID, reason *string) error {
+// MaxSeriesOccurrences caps how many bookings one recurring series may make
+const MaxSeriesOccurrences = 52
+
+// Outcomes of a recurring series occurrence
+const (
+	OccurrenceBooked   = "booked"
+	OccurrenceConflict = "conflict" // the slot could not be booked, e.g. it is already taken
+	OccurrenceClosure  = "closure"  // the facility is closed; skipped
+)
+
+// RecurringBookingRequest repeats a booking every IntervalWeeks weeks up to and including
+// the date of Until
+type RecurringBookingRequest struct {
+	BookingRequest
+	IntervalWeeks int
+	Until         time.Time
+}
+
+// SeriesOccurrence is the outcome of one occurrence of a recurring series
+type SeriesOccurrence struct {
+	StartTime time.Time           `json:"start_time"`
+	EndTime   time.Time           `json:"end_time"`
+	Status    string              `json:"status"`
+	Booking   *db.FacilityBooking `json:"booking,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// BookingSeriesResult is the outcome of a recurring booking series
+type BookingSeriesResult struct {
+	SeriesID    uuid.UUID          `json:"series_id"`
+	Booked      int                `json:"booked"`
+	Occurrences []SeriesOccurrence `json:"occurrences"`
+}
+
+// ExpandWeeklySeries returns the start times of a series repeating start every
+// intervalWeeks weeks, up to and including until's calendar date, taken in start's
+// location. Occurrences keep their wall-clock time across daylight saving changes.
+func ExpandWeeklySeries(start time.Time, intervalWeeks int, until time.Time) ([]time.Time, error) {
+	if intervalWeeks < 1 {
+		return nil, fmt.Errorf("interval_weeks must be at least 1")
+	}
+	end := time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, start.Location())
+	if !start.Before(end) {
+		return nil, fmt.Errorf("until must not be before the first occurrence")
+	}
+
+	var starts []time.Time
+	for t := start; t.Before(end); t = t.AddDate(0, 0, 7*intervalWeeks) {
+		if len(starts) == MaxSeriesOccurrences {
+			return nil, fmt.Errorf("series cannot have more than %d occurrences", MaxSeriesOccurrences)
+		}
+		starts = append(starts, t)
+	}
+	return starts, nil
+}
+
+// seriesWindows expands a recurring request into the start and end of each occurrence,
+// repeated in loc so that each keeps the facility's local time across daylight saving
+// changes
+func seriesWindows(req RecurringBookingRequest, loc *time.Location) ([]timeWindow, error) {
+	starts, err := ExpandWeeklySeries(req.StartTime.In(loc), req.IntervalWeeks, req.Until)
+	if err != nil {
+		return nil, err
+	}
+
+	end := req.EndTime.In(loc)
+	windows := make([]timeWindow, len(starts))
+	for i, start := range starts {
+		windows[i] = timeWindow{start, end.AddDate(0, 0, 7*req.IntervalWeeks*i)}
+	}
+	return windows, nil
+}
+
+// CreateBookingSeries books each occurrence of a weekly series through CreateBooking, so
+// every occurrence gets the same checks and locking as a single booking. Occurrences are
+// repeated in the facility's time zone. Occurrences that cannot be booked are reported
+// rather than failing the series; those landing on a closure are skipped as closures.
+// Booked occurrences share the returned series ID.
+func (fs *FacilitiesService) CreateBookingSeries(ctx context.Context, req RecurringBookingRequest) (*BookingSeriesResult, error) {
+	facility, err := fs.db.GetFacilityByID(req.FacilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility: %w", err)
+	}
+	if facility == nil {
+		return nil, fmt.Errorf("facility not found")
+	}
+
+	windows, err := seriesWindows(req, facility.TimeLocation())
+	if err != nil {
+		return nil, err
+	}
+
+	seriesID := uuid.New()
+	result := &BookingSeriesResult{SeriesID: seriesID, Occurrences: make([]SeriesOccurrence, 0, len(windows))}
+	for _, window := range windows {
+		occurrence := SeriesOccurrence{StartTime: window.start, EndTime: window.end}
+
+		bookingReq := req.BookingRequest
+		bookingReq.StartTime, bookingReq.EndTime = occurrence.StartTime, occurrence.EndTime
+		bookingReq.SeriesID = &seriesID
+		bookingReq.IdempotencyKey = nil
+
+		booking, err := fs.CreateBooking(ctx, bookingReq)
+		var unavailable *db.AvailabilityError
+		switch {
+		case err == nil:
+			occurrence.Status = OccurrenceBooked
+			occurrence.Booking = booking
+			result.Booked++
+		case errors.As(err, &unavailable) && unavailable.Code == db.AvailabilityClosure:
+			occurrence.Status = OccurrenceClosure
+			occurrence.Error = err.Error()
+		default:
+			occurrence.Status = OccurrenceConflict
+			occurrence.Error = err.Error()
+		}
+		result.Occurrences = append(result.Occurrences, occurrence)
+	}
+
+	return result, nil
+}
+
+// getIdempotentBooking returns the booking stored for an idempotency key, or nil when
+// there is no key or it is unknown or older than db.IdempotencyKeyTTL
+func (fs *FacilitiesService) getIdempotentBooking(key *string) (*db.FacilityBooking, error) {
+	if key == nil || *key == "" {
+		return nil, nil
+	}
+
+	var booking db.FacilityBooking
+	found, err := fs.db.GetIdempotentResponse(db.IdempotencyScopeBooking, *key, &booking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &booking, nil
+}
+
+// BookingCancellation is the outcome of cancelling a booking
+type BookingCancellation struct {
+	Late     bool `json:"late_cancellation"`
+	FeeCents *int `json:"late_cancellation_fee_cents,omitempty"`
+}
+
+// CancelBooking cancels a booking with validation. Past the facility's cancellation
+// cutoff the booking is refused, unless the facility allows late cancellations, in which
+// case it is cancelled and flagged as late with the fee it forfeits
+func (fs *FacilitiesService) CancelBooking(ctx context.Context, bookingID, userID uuid.UUID, reasonCode, reason *string) (*BookingCancellation, error) {
 	// Get the booking
 	booking, err := fs.db.GetBooking(bookingID)
 	if err != nil {
-		return fmt.Errorf("failed to get booking: %w", err)
+		return nil, fmt.Errorf("failed to get booking: %w", err)
 	}
 	if booking == nil {
-		return fmt.Errorf("booking not found")
+		return nil, ErrBookingNotFound
 	}
 
 	// Verify user owns this booking
 	if booking.UserID != userID {
-		return fmt.Errorf("you do not have permission to cancel this booking")
+		return nil, ErrBookingForbidden
 	}
 
-	// Check if already cancelled
+	// Check if already cancelled. A hold can be released before it is confirmed.
 	if booking.Status == "cancelled" {
-		return fmt.Errorf("booking is already cancelled")
+		return nil, fmt.Errorf("booking is already cancelled")
+	}
+	if booking.Status != "confirmed" && booking.Status != "pending" && booking.Status != "held" {
+		return nil, fmt.Errorf("booking cannot be cancelled")
+	}
+
+	return fs.cancelBooking(ctx, booking, userID, reasonCode, reason)
+}
+
+// ConfirmBooking finalizes the user's hold on a slot before it expires
+func (fs *FacilitiesService) ConfirmBooking(ctx context.Context, bookingID, userID uuid.UUID) (*db.FacilityBooking, error) {
+	booking, err := fs.db.GetBooking(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking == nil {
+		return nil, ErrBookingNotFound
+	}
+	if booking.UserID != userID {
+		return nil, ErrBookingForbidden
+	}
+	if booking.Status != "held" && booking.Status != "expired" {
+		return nil, fmt.Errorf("booking is not awaiting confirmation")
+	}
+	if !booking.HoldActive(time.Now()) {
+		return nil, fmt.Errorf("booking hold has expired; reserve the slot again")
 	}
 
+	return fs.db.ConfirmBookingHold(bookingID)
+}
+
+// cancelBooking checks a booking against its facility's cancellation cutoff and cancels
+// it under the facility/time lock
+func (fs *FacilitiesService) cancelBooking(ctx context.Context, booking *db.FacilityBooking, cancelledBy uuid.UUID, reasonCode, reason *string) (*BookingCancellation, error) {
 	// Get facility to check cancellation cutoff
 	facility, err := fs.db.GetFacilityByID(booking.FacilityID)
 	if err != nil {
-		return fmt.Errorf("failed to get facility: %w", err)
+		return nil, fmt.Errorf("failed to get facility: %w", err)
 	}
 	if facility == nil {
-		return fmt.Errorf("facility not found")
+		return nil, fmt.Errorf("facility not found")
 	}
 
-	// Check cancellation cutoff
+	// Check cancellation cutoff. A pending booking holds no slot, so withdrawing it is
+	// never late.
+	result := &BookingCancellation{}
 	cutoffTime := booking.StartTime.Add(-time.Duration(facility.CancellationCutoffHours) * time.Hour)
-	if time.Now().After(cutoffTime) {
-		return fmt.Errorf("cancellation deadline has passed (must cancel at least %d hours before booking)",
-			facility.CancellationCutoffHours)
+	if booking.Status == "confirmed" && time.Now().After(cutoffTime) {
+		if !facility.AllowLateCancellation {
+			return nil, fmt.Errorf("cancellation deadline has passed (must cancel at least %d hours before booking)",
+				facility.CancellationCutoffHours)
+		}
+		if !time.Now().Before(booking.StartTime) {
+			return nil, fmt.Errorf("booking has already started")
+		}
+		result.Late = true
+		result.FeeCents = facility.LateCancellationFee(booking.PriceCents)
 	}
 
 	// Build lock key for this facility and time range
@@ -148,25 +406,94 @@ func (fs *FacilitiesService) CancelBooking(ctx context.Context, bookingID, userI
 	// Acquire distributed lock
 	lock, err := fs.acquireLock(ctx, lockKey, 10*time.Second)
 	if err != nil {
-		return fmt.Errorf("failed to acquire lock: %w", err)
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
 	}
 	defer fs.releaseLock(ctx, lockKey, lock)
 
 	// Cancel the booking
-	return fs.db.CancelBooking(bookingID, userID, reason)
+	if err := fs.db.CancelBooking(booking.ID, cancelledBy, reasonCode, reason, result.Late, result.FeeCents); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
-// GetUserBookings retrieves all bookings for a user
-func (fs *FacilitiesService) GetUserBookings(ctx context.Context, userID uuid.UUID, includeHistory bool) ([]db.FacilityBooking, error) {
-	status := ""
-	if !includeHistory {
-		status = "confirmed"
+// Outcomes of cancelling a recurring series occurrence
+const (
+	OccurrenceCancelled = "cancelled"
+	OccurrenceSkipped   = "skipped" // already started or past its cancellation cutoff
+)
+
+// SeriesCancellationOccurrence is the outcome of cancelling one booking of a series
+type SeriesCancellationOccurrence struct {
+	BookingID uuid.UUID `json:"booking_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Status    string    `json:"status"`
+	BookingCancellation
+	Reason string `json:"reason,omitempty"`
+}
+
+// SeriesCancellationResult is the outcome of cancelling a recurring booking series
+type SeriesCancellationResult struct {
+	SeriesID    uuid.UUID                      `json:"series_id"`
+	Cancelled   int                            `json:"cancelled"`
+	Skipped     int                            `json:"skipped"`
+	Occurrences []SeriesCancellationOccurrence `json:"occurrences"`
+}
+
+// CancelBookingSeries cancels the confirmed bookings of a recurring series one by one
+// through the single-booking path, so each respects its facility's cutoff and lock.
+// Bookings that have started or can no longer be cancelled are skipped with the reason.
+// Only the series' owner may cancel it unless asAdmin is set.
+func (fs *FacilitiesService) CancelBookingSeries(ctx context.Context, seriesID, userID uuid.UUID, asAdmin bool, reasonCode, reason *string) (*SeriesCancellationResult, error) {
+	bookings, err := fs.db.GetSeriesBookings(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if len(bookings) == 0 {
+		return nil, fmt.Errorf("booking series not found or already cancelled")
+	}
+	if !asAdmin && bookings[0].UserID != userID {
+		return nil, fmt.Errorf("you do not have permission to cancel this booking series")
+	}
+
+	result := &SeriesCancellationResult{SeriesID: seriesID, Occurrences: make([]SeriesCancellationOccurrence, 0, len(bookings))}
+	for i := range bookings {
+		booking := &bookings[i]
+		occurrence := SeriesCancellationOccurrence{BookingID: booking.ID, StartTime: booking.StartTime, EndTime: booking.EndTime}
+
+		cancellation, err := fs.cancelBooking(ctx, booking, userID, reasonCode, reason)
+		if err != nil {
+			occurrence.Status = OccurrenceSkipped
+			occurrence.Reason = err.Error()
+			result.Skipped++
+		} else {
+			occurrence.Status = OccurrenceCancelled
+			occurrence.BookingCancellation = *cancellation
+			result.Cancelled++
+		}
+		result.Occurrences = append(result.Occurrences, occurrence)
 	}
 
-	bookings, err := fs.db.GetBookings(nil, &userID, nil, nil, status)
+	return result, nil
+}
+
+// GetUserBookings retrieves a user's confirmed and pending bookings and unexpired holds, or
+// all of them with includeHistory
+func (fs *FacilitiesService) GetUserBookings(ctx context.Context, userID uuid.UUID, includeHistory bool) ([]db.FacilityBooking, error) {
+	bookings, err := fs.db.GetBookings(nil, &userID, nil, nil, "", nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookings: %w", err)
 	}
+	if !includeHistory {
+		current := bookings[:0]
+		for _, b := range bookings {
+			if b.Status == "confirmed" || b.Status == "pending" || b.HoldActive(time.Now()) {
+				current = append(current, b)
+			}
+		}
+		bookings = current
+	}
 
 	// Load facility details for each booking
 	for i := range bookings {
@@ -180,10 +507,10 @@ func (fs *FacilitiesService) GetUserBookings(ctx context.Context, userID uuid.UU
 	return bookings, nil
 }
 
-// GetFacilityBookings retrieves all bookings for a facility (admin)
-func (fs *FacilitiesService) GetFacilityBookings(ctx context.Context, facilityID uuid.UUID, startTime, endTime *time.Time) ([]db.FacilityBooking, error) {
-	status := "confirmed"
-	bookings, err := fs.db.GetBookings(&facilityID, nil, startTime, endTime, status)
+// GetFacilityBookings retrieves a facility's bookings with the given status (admin),
+// optionally only those created between createdFrom and createdTo
+func (fs *FacilitiesService) GetFacilityBookings(ctx context.Context, facilityID uuid.UUID, status string, startTime, endTime, createdFrom, createdTo *time.Time) ([]db.FacilityBooking, error) {
+	bookings, err := fs.db.GetBookings(&facilityID, nil, startTime, endTime, status, createdFrom, createdTo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookings: %w", err)
 	}
@@ -206,18 +533,325 @@ func (fs *FacilitiesService) GetFacilityBookings(ctx context.Context, facilityID
 	return bookings, nil
 }
 
-// GetAvailableSlots returns available time slots for a facility
-func (fs *FacilitiesService) GetAvailableSlots(ctx context.Context, facilityID uuid.UUID, startDate, endDate time.Time, duration int) ([]db.AvailabilitySlot, error) {
+// GetAvailableSlots returns available time slots for a facility that the audience may book
+func (fs *FacilitiesService) GetAvailableSlots(ctx context.Context, facilityID uuid.UUID, startDate, endDate time.Time, duration int, audience string, participantID *uuid.UUID) ([]db.AvailabilitySlot, error) {
 	query := db.AvailabilityQuery{
-		FacilityID: facilityID,
-		StartDate:  startDate,
-		EndDate:    endDate,
-		Duration:   duration,
+		FacilityID:    facilityID,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		Duration:      duration,
+		Audience:      audience,
+		ParticipantID: participantID,
 	}
 
 	return fs.db.GetAvailableSlots(query)
 }
 
+// GetNextAvailableSlot returns the earliest slot of a duration at or after a time that the audience may book
+func (fs *FacilitiesService) GetNextAvailableSlot(ctx context.Context, facilityID uuid.UUID, after time.Time, duration int, audience string) (*db.AvailabilitySlot, error) {
+	return fs.db.GetNextAvailableSlot(facilityID, after, duration, audience)
+}
+
+// BookingQuote summarizes whether a proposed booking can be made and on what terms
+type BookingQuote struct {
+	StartTime            time.Time        `json:"start_time"`
+	EndTime              time.Time        `json:"end_time"`
+	ParticipantCount     int              `json:"participant_count"`
+	Available            bool             `json:"available"`
+	Reason               string           `json:"reason,omitempty"`      // why the slot is unavailable
+	ReasonCode           string           `json:"reason_code,omitempty"` // db.AvailabilityError code for Reason, when it has one
+	CancellationDeadline time.Time        `json:"cancellation_deadline"`
+	Price                *db.BookingPrice `json:"price,omitempty"` // nil when the facility is not priced
+}
+
+// QuoteBooking runs the same checks as CreateBooking for a proposed slot without booking
+// it, and reports its price and the latest time the booking could be cancelled. user is
+// nil for anonymous requests.
+func (fs *FacilitiesService) QuoteBooking(ctx context.Context, facility *db.Facility, startTime, endTime time.Time, participantCount int, user *db.User) (*BookingQuote, error) {
+	quote := &BookingQuote{
+		StartTime:            startTime,
+		EndTime:              endTime,
+		ParticipantCount:     participantCount,
+		CancellationDeadline: startTime.Add(-time.Duration(facility.CancellationCutoffHours) * time.Hour),
+	}
+
+	price, err := fs.db.PriceBooking(facility, startTime, endTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to price booking: %w", err)
+	}
+	quote.Price = price
+
+	if facility.Capacity != nil && participantCount > *facility.Capacity {
+		quote.Reason = fmt.Sprintf("participant count %d exceeds facility capacity %d", participantCount, *facility.Capacity)
+		return quote, nil
+	}
+
+	audience, exempt := db.AudiencePublic, false
+	if user != nil {
+		audience, exempt = user.Audience(), user.ExemptFromAdvanceLimit()
+	}
+
+	if err := fs.db.CheckAvailability(facility.ID, startTime, endTime, audience, exempt); err != nil {
+		quote.Reason = err.Error()
+		var unavailable *db.AvailabilityError
+		if errors.As(err, &unavailable) {
+			quote.ReasonCode = unavailable.Code
+		}
+		return quote, nil
+	}
+
+	quote.Available = true
+	return quote, nil
+}
+
+// RescheduleProposal pairs a booking affected by a closure with a suggested new slot
+type RescheduleProposal struct {
+	Booking      db.FacilityBooking   `json:"booking"`
+	ProposedSlot *db.AvailabilitySlot `json:"proposed_slot"` // nil when no alternative was found
+}
+
+// RescheduleMove moves a booking to a new time slot
+type RescheduleMove struct {
+	BookingID uuid.UUID
+	StartTime time.Time
+	EndTime   time.Time
+}
+
+// RescheduleResult reports the outcome of a single RescheduleMove
+type RescheduleResult struct {
+	BookingID uuid.UUID `json:"booking_id"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// rescheduleSearchDays is how far past the original booking date alternatives are searched
+const rescheduleSearchDays = 14
+
+// ProposeClosureReschedule finds confirmed bookings overlapping a closure and suggests
+// the nearest available slot of the same length for each. Nothing is changed.
+func (fs *FacilitiesService) ProposeClosureReschedule(ctx context.Context, facilityID, closureID uuid.UUID) ([]RescheduleProposal, error) {
+	closure, err := fs.getFacilityClosure(facilityID, closureID)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := fs.db.GetBookings(&facilityID, nil, &closure.StartTime, &closure.EndTime, "confirmed", nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+
+	facility, err := fs.db.GetFacilityByID(facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility: %w", err)
+	}
+	if facility == nil {
+		return nil, fmt.Errorf("facility not found")
+	}
+	buffer := time.Duration(facility.BufferMinutes) * time.Minute
+
+	// Slots already proposed to an earlier booking are not offered twice
+	var taken []db.AvailabilitySlot
+
+	proposals := make([]RescheduleProposal, 0, len(bookings))
+	for _, booking := range bookings {
+		now := time.Now()
+		searchStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, booking.StartTime.Location())
+		searchEnd := time.Date(
+			booking.StartTime.Year(), booking.StartTime.Month(), booking.StartTime.Day(),
+			0, 0, 0, 0, booking.StartTime.Location(),
+		).AddDate(0, 0, rescheduleSearchDays+1)
+
+		// Only propose windows the booking's owner could have booked themselves
+		audience, err := fs.db.GetUserAudience(booking.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get booking audience: %w", err)
+		}
+
+		duration := int(booking.EndTime.Sub(booking.StartTime).Minutes())
+		slots, err := fs.GetAvailableSlots(ctx, facilityID, searchStart, searchEnd, duration, audience, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get available slots: %w", err)
+		}
+
+		var best *db.AvailabilitySlot
+		var bestDistance time.Duration
+		for i := range slots {
+			if slotOverlapsAny(slots[i], taken, buffer) {
+				continue
+			}
+			distance := slots[i].StartTime.Sub(booking.StartTime)
+			if distance < 0 {
+				distance = -distance
+			}
+			if best == nil || distance < bestDistance {
+				best = &slots[i]
+				bestDistance = distance
+			}
+		}
+
+		if best != nil {
+			taken = append(taken, *best)
+		}
+		proposals = append(proposals, RescheduleProposal{Booking: booking, ProposedSlot: best})
+	}
+
+	return proposals, nil
+}
+
+// ApplyClosureReschedule moves bookings affected by a closure to new slots. Each move
+// is validated and applied independently; the owner of every moved booking is notified.
+func (fs *FacilitiesService) ApplyClosureReschedule(ctx context.Context, facilityID, closureID uuid.UUID, moves []RescheduleMove) ([]RescheduleResult, error) {
+	closure, err := fs.getFacilityClosure(facilityID, closureID)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]RescheduleResult, 0, len(moves))
+	for _, move := range moves {
+		result := RescheduleResult{
+			BookingID: move.BookingID,
+			StartTime: move.StartTime,
+			EndTime:   move.EndTime,
+		}
+		if err := fs.applyRescheduleMove(ctx, closure, move); err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Success = true
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// applyRescheduleMove validates and applies a single move out of a closure
+func (fs *FacilitiesService) applyRescheduleMove(ctx context.Context, closure *db.FacilityClosure, move RescheduleMove) error {
+	booking, err := fs.db.GetBooking(move.BookingID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking == nil || booking.FacilityID != closure.FacilityID {
+		return fmt.Errorf("booking not found")
+	}
+	if booking.Status != "confirmed" {
+		return fmt.Errorf("booking is not confirmed")
+	}
+	if !booking.StartTime.Before(closure.EndTime) || !booking.EndTime.After(closure.StartTime) {
+		return fmt.Errorf("booking does not overlap the closure")
+	}
+
+	lockKey := fs.buildBookingLockKey(booking.FacilityID, move.StartTime, move.EndTime)
+	lock, err := fs.acquireLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock (another booking may be in progress): %w", err)
+	}
+	defer fs.releaseLock(ctx, lockKey, lock)
+
+	audience, err := fs.db.GetUserAudience(booking.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get booking audience: %w", err)
+	}
+
+	if err := fs.db.CheckRescheduleAvailability(booking.ID, booking.FacilityID, move.StartTime, move.EndTime, audience); err != nil {
+		return fmt.Errorf("slot not available: %w", err)
+	}
+
+	// Keep the booking's unit when it is free at the new time, otherwise move to a free one
+	unitID := booking.UnitID
+	if unitID != nil {
+		facility, err := fs.db.GetFacilityByID(booking.FacilityID)
+		if err != nil {
+			return fmt.Errorf("failed to get facility: %w", err)
+		}
+		unitID, err = fs.db.AllocateUnit(facility, booking.UnitID, move.StartTime, move.EndTime, &booking.ID)
+		if err != nil {
+			unitID, err = fs.db.AllocateUnit(facility, nil, move.StartTime, move.EndTime, &booking.ID)
+		}
+		if err != nil {
+			return fmt.Errorf("slot not available: %w", err)
+		}
+	}
+
+	return fs.db.RescheduleBooking(booking.ID, move.StartTime, move.EndTime, unitID)
+}
+
+// ApproveBooking confirms a pending booking under the facility/time lock, once the slot is
+// checked to still be free. Its unit is kept when free, otherwise another free unit is
+// assigned.
+func (fs *FacilitiesService) ApproveBooking(ctx context.Context, bookingID uuid.UUID) (*db.FacilityBooking, error) {
+	booking, err := fs.db.GetBooking(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking: %w", err)
+	}
+	if booking == nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if booking.Status != "pending" {
+		return nil, db.ErrBookingNotPending
+	}
+
+	lockKey := fs.buildBookingLockKey(booking.FacilityID, booking.StartTime, booking.EndTime)
+	lock, err := fs.acquireLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock (another booking may be in progress): %w", err)
+	}
+	defer fs.releaseLock(ctx, lockKey, lock)
+
+	audience, err := fs.db.GetUserAudience(booking.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking audience: %w", err)
+	}
+	if err := fs.db.CheckPendingBookingAvailability(booking, audience); err != nil {
+		return nil, fmt.Errorf("slot not available: %w", err)
+	}
+
+	unitID := booking.UnitID
+	if unitID != nil {
+		facility, err := fs.db.GetFacilityByID(booking.FacilityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get facility: %w", err)
+		}
+		unitID, err = fs.db.AllocateUnit(facility, booking.UnitID, booking.StartTime, booking.EndTime, &booking.ID)
+		if err != nil {
+			unitID, err = fs.db.AllocateUnit(facility, nil, booking.StartTime, booking.EndTime, &booking.ID)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("slot not available: %w", err)
+		}
+	}
+
+	if err := fs.db.ApproveBooking(booking.ID, unitID); err != nil {
+		return nil, err
+	}
+	booking.Status = "confirmed"
+	booking.UnitID = unitID
+	return booking, nil
+}
+
+// getFacilityClosure loads a closure and verifies it belongs to the facility
+func (fs *FacilitiesService) getFacilityClosure(facilityID, closureID uuid.UUID) (*db.FacilityClosure, error) {
+	closure, err := fs.db.GetClosureByID(closureID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closure: %w", err)
+	}
+	if closure == nil || closure.FacilityID != facilityID {
+		return nil, fmt.Errorf("closure not found")
+	}
+	return closure, nil
+}
+
+// slotOverlapsAny reports whether slot overlaps any of the given slots, including buffer
+func slotOverlapsAny(slot db.AvailabilitySlot, others []db.AvailabilitySlot, buffer time.Duration) bool {
+	for _, other := range others {
+		if slot.StartTime.Before(other.EndTime.Add(buffer)) && slot.EndTime.After(other.StartTime.Add(-buffer)) {
+			return true
+		}
+	}
+	return false
+}
+
 // buildBookingLockKey creates a lock key for a facility booking
 func (fs *FacilitiesService) buildBookingLockKey(facilityID uuid.UUID, startTime, endTime time.Time) string {
 	// Use facility ID and time range for lock key
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExpandWeeklySeries(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Tuesday 6pm, the week before daylight saving ends
+	start := time.Date(2025, time.October, 28, 18, 0, 0, 0, loc)
+
+	t.Run("weekly until a date, inclusive", func(t *testing.T) {
+		starts, err := ExpandWeeklySeries(start, 1, time.Date(2025, time.November, 18, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("ExpandWeeklySeries() error = %v", err)
+		}
+		if len(starts) != 4 {
+			t.Fatalf("got %d occurrences, want 4: %v", len(starts), starts)
+		}
+		for i, s := range starts {
+			if s.Weekday() != time.Tuesday || s.Hour() != 18 {
+				t.Errorf("occurrence %d = %v, want Tuesday at 6pm", i, s)
+			}
+		}
+	})
+
+	t.Run("every other week", func(t *testing.T) {
+		starts, err := ExpandWeeklySeries(start, 2, time.Date(2025, time.November, 24, 0, 0, 0, 0, time.UTC))
+		if err != nil {
+			t.Fatalf("ExpandWeeklySeries() error = %v", err)
+		}
+		if len(starts) != 2 || starts[1].Day() != 11 {
+			t.Errorf("occurrences = %v, want Oct 28 and Nov 11", starts)
+		}
+	})
+
+	t.Run("rejects bad series", func(t *testing.T) {
+		if _, err := ExpandWeeklySeries(start, 0, start.AddDate(0, 1, 0)); err == nil {
+			t.Error("expected error for zero interval")
+		}
+		if _, err := ExpandWeeklySeries(start, 1, start.AddDate(0, 0, -1)); err == nil {
+			t.Error("expected error for until before the start")
+		}
+		if _, err := ExpandWeeklySeries(start, 1, start.AddDate(2, 0, 0)); err == nil {
+			t.Errorf("expected error for more than %d occurrences", MaxSeriesOccurrences)
+		}
+	})
+}
+
+func TestSeriesWindowsAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	// Tuesday 6-8pm the week before daylight saving ends, parsed from RFC3339 as the
+	// handler does, so it carries a fixed -04:00 offset rather than the facility's zone
+	start, _ := time.Parse(time.RFC3339, "2025-10-28T18:00:00-04:00")
+	end, _ := time.Parse(time.RFC3339, "2025-10-28T20:00:00-04:00")
+
+	windows, err := seriesWindows(RecurringBookingRequest{
+		BookingRequest: BookingRequest{StartTime: start, EndTime: end},
+		IntervalWeeks:  1,
+		Until:          time.Date(2025, time.November, 11, 0, 0, 0, 0, time.UTC),
+	}, loc)
+	if err != nil {
+		t.Fatalf("seriesWindows() error = %v", err)
+	}
+	if len(windows) != 3 {
+		t.Fatalf("got %d occurrences, want 3", len(windows))
+	}
+	for i, w := range windows {
+		s, e := w.start.In(loc), w.end.In(loc)
+		if s.Weekday() != time.Tuesday || s.Hour() != 18 || e.Hour() != 20 {
+			t.Errorf("occurrence %d = %v to %v, want Tuesday 6-8pm local time", i, s, e)
+		}
+	}
+	// After daylight saving ends, 6pm local is 11pm UTC rather than 10pm
+	if got := windows[1].start.UTC().Hour(); got != 23 {
+		t.Errorf("occurrence after the change starts at %d:00 UTC, want 23:00", got)
+	}
+}
@@ -0,0 +1,437 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+const (
+	googleAuthURL     = "https://accounts.google.com/o/oauth2/v2/auth"
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleRevokeURL   = "https://oauth2.googleapis.com/revoke"
+	googleCalendarAPI = "https://www.googleapis.com/calendar/v3"
+	googleEventsScope = "https://www.googleapis.com/auth/calendar.events"
+
+	// OAuthStateTTL bounds how long a user has to finish the Google consent screen
+	OAuthStateTTL = 10 * time.Minute
+)
+
+// errGoogleGrantRevoked means the user revoked access from their Google account
+var errGoogleGrantRevoked = fmt.Errorf("google access has been revoked")
+
+// GoogleCalendarService connects user accounts to Google Calendar and pushes their
+// registrations and bookings there
+type GoogleCalendarService struct {
+	db           *db.DB
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	httpClient   *http.Client
+}
+
+func NewGoogleCalendarService(database *db.DB) *GoogleCalendarService {
+	return &GoogleCalendarService{
+		db:           database,
+		clientID:     os.Getenv("GOOGLE_CLIENT_ID"),
+		clientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
+		redirectURL:  os.Getenv("GOOGLE_REDIRECT_URL"),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// Configured reports whether Google OAuth credentials are set
+func (gs *GoogleCalendarService) Configured() bool {
+	return gs.clientID != "" && gs.clientSecret != "" && gs.redirectURL != ""
+}
+
+// AuthURL returns the Google consent URL for the user and the state it carries, which the
+// caller keeps in the browser to check on the callback. Offline access with a forced
+// consent prompt makes Google return a refresh token every time.
+func (gs *GoogleCalendarService) AuthURL(userID uuid.UUID) (string, string) {
+	state := GenerateOAuthState(userID, time.Now().Add(OAuthStateTTL))
+	params := url.Values{
+		"client_id":     {gs.clientID},
+		"redirect_uri":  {gs.redirectURL},
+		"response_type": {"code"},
+		"scope":         {googleEventsScope},
+		"access_type":   {"offline"},
+		"prompt":        {"consent"},
+		"state":         {state},
+	}
+	return googleAuthURL + "?" + params.Encode(), state
+}
+
+// Connect exchanges an authorization code for tokens and stores the user's refresh token
+func (gs *GoogleCalendarService) Connect(ctx context.Context, userID uuid.UUID, code string) error {
+	var token struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err := gs.postForm(ctx, googleTokenURL, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {gs.redirectURL},
+		"client_id":     {gs.clientID},
+		"client_secret": {gs.clientSecret},
+	}, &token)
+	if err != nil {
+		return fmt.Errorf("failed to exchange authorization code: %w", err)
+	}
+	if token.RefreshToken == "" {
+		return fmt.Errorf("google did not return a refresh token")
+	}
+
+	encrypted, err := encryptToken(token.RefreshToken)
+	if err != nil {
+		return err
+	}
+	return gs.db.SaveGoogleCalendarConnection(userID, encrypted)
+}
+
+// Disconnect revokes the user's Google grant (best effort) and removes the connection
+func (gs *GoogleCalendarService) Disconnect(ctx context.Context, userID uuid.UUID) error {
+	conn, err := gs.db.GetGoogleCalendarConnection(userID)
+	if err != nil {
+		return err
+	}
+	if conn == nil || conn.RevokedAt != nil {
+		return fmt.Errorf("google calendar connection not found")
+	}
+
+	if conn.RefreshTokenEncrypted != nil {
+		if refreshToken, err := decryptToken(*conn.RefreshTokenEncrypted); err == nil {
+			if err := gs.postForm(ctx, googleRevokeURL, url.Values{"token": {refreshToken}}, nil); err != nil {
+				log.Printf("Failed to revoke google token for user %s: %v", userID, err)
+			}
+		}
+	}
+
+	return gs.db.RevokeGoogleCalendarConnection(userID)
+}
+
+// ProcessSyncQueue pushes queued registrations and bookings to connected calendars
+func (gs *GoogleCalendarService) ProcessSyncQueue() error {
+	if !gs.Configured() {
+		return nil
+	}
+
+	syncs, err := gs.db.GetPendingCalendarSyncs(100)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	accessTokens := map[uuid.UUID]string{}
+	for _, s := range syncs {
+		err := gs.syncEntity(ctx, s, accessTokens)
+		if err == errGoogleGrantRevoked {
+			log.Printf("Google access revoked by user %s, disconnecting calendar", s.UserID)
+			if err := gs.db.RevokeGoogleCalendarConnection(s.UserID); err != nil {
+				log.Printf("Failed to disconnect calendar for user %s: %v", s.UserID, err)
+			}
+			continue
+		}
+		if err != nil {
+			retryAt := time.Now().Add(time.Duration(1<<s.Attempts) * time.Minute)
+			if err := gs.db.FailCalendarSync(s, err.Error(), retryAt); err != nil {
+				log.Printf("Failed to record calendar sync failure %d: %v", s.ID, err)
+			}
+			continue
+		}
+		if err := gs.db.CompleteCalendarSync(s); err != nil {
+			log.Printf("Failed to complete calendar sync %d: %v", s.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// syncEntity brings the user's Google events for one registration or booking in line
+// with its current occurrences: missing events are created, existing ones updated and
+// events for occurrences that no longer apply are deleted
+func (gs *GoogleCalendarService) syncEntity(ctx context.Context, s db.CalendarSync, accessTokens map[uuid.UUID]string) error {
+	conn, err := gs.db.GetGoogleCalendarConnection(s.UserID)
+	if err != nil {
+		return err
+	}
+	if conn == nil || conn.RevokedAt != nil || conn.RefreshTokenEncrypted == nil {
+		return nil // Disconnected since the push was queued
+	}
+
+	accessToken, ok := accessTokens[s.UserID]
+	if !ok {
+		accessToken, err = gs.accessToken(ctx, *conn.RefreshTokenEncrypted)
+		if err != nil {
+			return err
+		}
+		accessTokens[s.UserID] = accessToken
+	}
+
+	occurrences, err := gs.db.GetCalendarOccurrences(s.EntityType, s.EntityID)
+	if err != nil {
+		return err
+	}
+	existing, err := gs.db.GetGoogleCalendarEvents(s.UserID, s.EntityType, s.EntityID)
+	if err != nil {
+		return err
+	}
+
+	eventsURL := fmt.Sprintf("%s/calendars/%s/events", googleCalendarAPI, url.PathEscape(conn.CalendarID))
+	for _, o := range occurrences {
+		event := googleEventBody(o)
+		if eventID, ok := existing[o.Key]; ok {
+			delete(existing, o.Key)
+			status, err := gs.calendarRequest(ctx, accessToken, http.MethodPut, eventsURL+"/"+url.PathEscape(eventID), event, nil)
+			if err == nil {
+				continue
+			}
+			if status != http.StatusNotFound && status != http.StatusGone {
+				return err
+			}
+			// Deleted from the calendar by the user; create it again
+		}
+
+		var created struct {
+			ID string `json:"id"`
+		}
+		if _, err := gs.calendarRequest(ctx, accessToken, http.MethodPost, eventsURL, event, &created); err != nil {
+			return err
+		}
+		if err := gs.db.SaveGoogleCalendarEvent(s.UserID, s.EntityType, s.EntityID, o.Key, created.ID); err != nil {
+			return err
+		}
+	}
+
+	for key, eventID := range existing {
+		status, err := gs.calendarRequest(ctx, accessToken, http.MethodDelete, eventsURL+"/"+url.PathEscape(eventID), nil, nil)
+		if err != nil && status != http.StatusNotFound && status != http.StatusGone {
+			return err
+		}
+		if err := gs.db.DeleteGoogleCalendarEvent(s.UserID, s.EntityType, s.EntityID, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// googleEventBody builds a Google Calendar event resource. Occurrences without an end
+// are shown as one hour long.
+func googleEventBody(o db.CalendarOccurrence) map[string]interface{} {
+	end := o.StartsAt.Add(time.Hour)
+	if o.EndsAt != nil {
+		end = *o.EndsAt
+	}
+	event := map[string]interface{}{
+		"summary":     o.Summary,
+		"description": o.Description,
+		"start":       map[string]string{"dateTime": o.StartsAt.Format(time.RFC3339)},
+		"end":         map[string]string{"dateTime": end.Format(time.RFC3339)},
+	}
+	if o.Location != nil {
+		event["location"] = *o.Location
+	}
+	return event
+}
+
+// accessToken trades the stored refresh token for a short-lived access token
+func (gs *GoogleCalendarService) accessToken(ctx context.Context, refreshTokenEncrypted string) (string, error) {
+	refreshToken, err := decryptToken(refreshTokenEncrypted)
+	if err != nil {
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = gs.postForm(ctx, googleTokenURL, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+		"client_id":     {gs.clientID},
+		"client_secret": {gs.clientSecret},
+	}, &token)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid_grant") {
+			return "", errGoogleGrantRevoked
+		}
+		return "", fmt.Errorf("failed to refresh google access token: %w", err)
+	}
+	return token.AccessToken, nil
+}
+
+// postForm posts a form to a Google OAuth endpoint and decodes the JSON response into out
+func (gs *GoogleCalendarService) postForm(ctx context.Context, endpoint string, form url.Values, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := gs.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("google request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// calendarRequest calls the Google Calendar API, returning the response status so
+// callers can tell a missing event apart from other failures
+func (gs *GoogleCalendarService) calendarRequest(ctx context.Context, accessToken, method, endpoint string, payload, out interface{}) (int, error) {
+	var body io.Reader
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := gs.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("google calendar request failed with status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return resp.StatusCode, nil
+}
+
+// integrationSecret signs OAuth state and encrypts stored tokens.
+// GOOGLE_TOKEN_ENCRYPTION_KEY falls back to JWT_SECRET.
+func integrationSecret() []byte {
+	secret := os.Getenv("GOOGLE_TOKEN_ENCRYPTION_KEY")
+	if secret == "" {
+		secret = os.Getenv("JWT_SECRET")
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return sum[:]
+}
+
+// GenerateOAuthState returns a signed OAuth state naming the user who started the
+// connection, valid until expiresAt
+func GenerateOAuthState(userID uuid.UUID, expiresAt time.Time) string {
+	payload := make([]byte, 24)
+	copy(payload, userID[:])
+	binary.BigEndian.PutUint64(payload[16:], uint64(expiresAt.Unix()))
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(signOAuthState(payload))
+}
+
+// ParseOAuthState verifies an OAuth state and returns the user it was issued to
+func ParseOAuthState(state string) (uuid.UUID, error) {
+	payloadPart, sigPart, ok := strings.Cut(state, ".")
+	if !ok {
+		return uuid.Nil, fmt.Errorf("malformed oauth state")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(payloadPart)
+	if err != nil || len(payload) != 24 {
+		return uuid.Nil, fmt.Errorf("malformed oauth state")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(sigPart)
+	if err != nil || !hmac.Equal(sig, signOAuthState(payload)) {
+		return uuid.Nil, fmt.Errorf("invalid oauth state signature")
+	}
+	if time.Now().Unix() > int64(binary.BigEndian.Uint64(payload[16:])) {
+		return uuid.Nil, fmt.Errorf("oauth state has expired")
+	}
+
+	return uuid.FromBytes(payload[:16])
+}
+
+func signOAuthState(payload []byte) []byte {
+	mac := hmac.New(sha256.New, integrationSecret())
+	mac.Write([]byte("google-oauth:"))
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// encryptToken seals a token with AES-GCM for storage
+func encryptToken(token string) (string, error) {
+	gcm, err := tokenCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(token), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decryptToken opens a token sealed by encryptToken
+func decryptToken(encrypted string) (string, error) {
+	gcm, err := tokenCipher()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("malformed encrypted token")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt token: %w", err)
+	}
+	return string(plain), nil
+}
+
+func tokenCipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(integrationSecret())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestOAuthState(t *testing.T) {
+	t.Setenv("GOOGLE_TOKEN_ENCRYPTION_KEY", "test-secret")
+	userID := uuid.New()
+
+	t.Run("round trip", func(t *testing.T) {
+		got, err := ParseOAuthState(GenerateOAuthState(userID, time.Now().Add(time.Minute)))
+		if err != nil {
+			t.Fatalf("ParseOAuthState() error = %v", err)
+		}
+		if got != userID {
+			t.Errorf("ParseOAuthState() = %s, want %s", got, userID)
+		}
+	})
+
+	t.Run("rejects expired state", func(t *testing.T) {
+		if _, err := ParseOAuthState(GenerateOAuthState(userID, time.Now().Add(-time.Minute))); err == nil {
+			t.Error("expected error for expired state")
+		}
+	})
+
+	t.Run("rejects state signed with another secret", func(t *testing.T) {
+		state := GenerateOAuthState(userID, time.Now().Add(time.Minute))
+		t.Setenv("GOOGLE_TOKEN_ENCRYPTION_KEY", "other-secret")
+		if _, err := ParseOAuthState(state); err == nil {
+			t.Error("expected error for state signed with another secret")
+		}
+	})
+
+	t.Run("rejects malformed state", func(t *testing.T) {
+		for _, bad := range []string{"", "abc", "abc.def", userID.String()} {
+			if _, err := ParseOAuthState(bad); err == nil {
+				t.Errorf("ParseOAuthState(%q) expected error", bad)
+			}
+		}
+	})
+}
+
+func TestTokenEncryption(t *testing.T) {
+	t.Setenv("GOOGLE_TOKEN_ENCRYPTION_KEY", "test-secret")
+
+	encrypted, err := encryptToken("refresh-token")
+	if err != nil {
+		t.Fatalf("encryptToken() error = %v", err)
+	}
+	if encrypted == "refresh-token" {
+		t.Fatal("encryptToken() returned the plaintext")
+	}
+
+	got, err := decryptToken(encrypted)
+	if err != nil || got != "refresh-token" {
+		t.Errorf("decryptToken() = %q, %v; want refresh-token", got, err)
+	}
+
+	t.Setenv("GOOGLE_TOKEN_ENCRYPTION_KEY", "other-secret")
+	if _, err := decryptToken(encrypted); err == nil {
+		t.Error("expected error decrypting with another key")
+	}
+}
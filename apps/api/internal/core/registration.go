@@ -3,6 +3,7 @@ package core
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -23,8 +24,48 @@ func NewRegistrationService(database *db.DB, redisClient *redis.Client) *Registr
 	}
 }
 
-// Register creates a registration with distributed locking
+// MissingWaiver is a required program waiver the participant has not accepted at its
+// current version
+type MissingWaiver struct {
+	WaiverID uuid.UUID `json:"waiver_id"`
+	Title    string    `json:"title"`
+	Version  int       `json:"version"`
+}
+
+// MissingWaiversError is returned when a registration is blocked by unaccepted waivers
+type MissingWaiversError struct {
+	Waivers []MissingWaiver
+}
+
+func (e *MissingWaiversError) Error() string {
+	return fmt.Sprintf("%d required waiver(s) must be accepted before registering", len(e.Waivers))
+}
+
+// MissingEmergencyContactError is returned when a minor has no emergency contact phone on
+// file and the program requires one
+type MissingEmergencyContactError struct {
+	Age       int
+	Threshold int
+}
+
+func (e *MissingEmergencyContactError) Error() string {
+	return fmt.Sprintf("an emergency contact phone is required for participants under %d", e.Threshold)
+}
+
+// Register creates a registration with distributed locking. A request repeating a recent
+// idempotency key gets the original result back instead of registering again.
 func (rs *RegistrationService) Register(ctx context.Context, req db.RegistrationRequest) (*db.RegistrationResult, error) {
+	// Check for idempotency key first (before acquiring lock)
+	if existing, err := rs.getIdempotentResult(req.IdempotencyKey); err != nil || existing != nil {
+		return existing, err
+	}
+
+	if req.ParentType == "program" {
+		if err := rs.checkProgramRequirements(req.ParentID, req.ParticipantID); err != nil {
+			return nil, err
+		}
+	}
+
 	// Build lock key
 	lockKey := rs.buildLockKey(req.ParentType, req.ParentID, req.SessionID)
 
@@ -35,6 +76,11 @@ func (rs *RegistrationService) Register(ctx context.Context, req db.Registration
 	}
 	defer rs.releaseLock(ctx, lockKey, lock)
 
+	// Double-check idempotency key after acquiring lock
+	if existing, err := rs.getIdempotentResult(req.IdempotencyKey); err != nil || existing != nil {
+		return existing, err
+	}
+
 	// Create registration with capacity check
 	result, err := rs.db.CreateRegistration(req)
 	if err != nil {
@@ -44,8 +90,133 @@ func (rs *RegistrationService) Register(ctx context.Context, req db.Registration
 	return result, nil
 }
 
+// RegisterBatch registers several participants for the same parent and session under a
+// single capacity lock, in order. Participants missing a required waiver or emergency
+// contact are reported as errors without being attempted. When atomic is set, nothing is kept unless every
+// participant is confirmed. Returns whether the registrations were kept.
+func (rs *RegistrationService) RegisterBatch(ctx context.Context, reqs []db.RegistrationRequest, atomic bool) ([]db.BatchRegistrationItem, bool, error) {
+	items := make([]db.BatchRegistrationItem, len(reqs))
+	var attempt []db.RegistrationRequest
+	var attemptIdx []int
+	for i, req := range reqs {
+		items[i] = db.BatchRegistrationItem{ParticipantID: req.ParticipantID}
+		if req.ParentType == "program" {
+			if err := rs.checkProgramRequirements(req.ParentID, req.ParticipantID); err != nil {
+				items[i].Status = "error"
+				items[i].Err = err
+				continue
+			}
+		}
+		attempt = append(attempt, req)
+		attemptIdx = append(attemptIdx, i)
+	}
+
+	// An atomic batch cannot succeed once a participant has failed
+	if len(attempt) == 0 || (atomic && len(attempt) < len(reqs)) {
+		return items, false, nil
+	}
+
+	lockKey := rs.buildLockKey(attempt[0].ParentType, attempt[0].ParentID, attempt[0].SessionID)
+	lock, err := rs.acquireLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer rs.releaseLock(ctx, lockKey, lock)
+
+	placed, committed, err := rs.db.CreateRegistrations(attempt, atomic)
+	if err != nil {
+		return nil, false, err
+	}
+	for j, item := range placed {
+		items[attemptIdx[j]] = item
+	}
+	return items, committed, nil
+}
+
+// checkProgramRequirements checks what a participant must have on file before registering
+// for a program: its required waivers and, for minors, an emergency contact phone
+func (rs *RegistrationService) checkProgramRequirements(programID, participantID uuid.UUID) error {
+	if err := rs.checkRequiredWaivers(programID, participantID); err != nil {
+		return err
+	}
+	return rs.checkEmergencyContact(programID, participantID)
+}
+
+// checkEmergencyContact returns a MissingEmergencyContactError when the program requires an
+// emergency contact phone for minors and the participant is a minor without one. Unknown
+// programs and participants are left to the registration itself to reject.
+func (rs *RegistrationService) checkEmergencyContact(programID, participantID uuid.UUID) error {
+	program, err := rs.db.GetProgramByID(programID)
+	if err != nil || program == nil {
+		return err
+	}
+	participant, err := rs.db.GetParticipantByID(participantID)
+	if err != nil || participant == nil {
+		return err
+	}
+
+	if db.NeedsEmergencyContact(program, participant) {
+		return &MissingEmergencyContactError{
+			Age:       db.AgeOn(*participant.DOB, db.ProgramAgeReferenceDate(program)),
+			Threshold: program.MinorAgeThreshold,
+		}
+	}
+	return nil
+}
+
+// checkRequiredWaivers returns a MissingWaiversError listing the program's required waivers
+// the participant has not accepted at their current version. Per-season waivers only count
+// when accepted for this program.
+func (rs *RegistrationService) checkRequiredWaivers(programID, participantID uuid.UUID) error {
+	waivers, err := rs.db.GetProgramWaivers(programID)
+	if err != nil {
+		return err
+	}
+
+	var missing []MissingWaiver
+	for _, pw := range waivers {
+		if !pw.IsRequired {
+			continue
+		}
+		var forProgram *uuid.UUID
+		if pw.IsPerSeason {
+			forProgram = &programID
+		}
+		accepted, err := rs.db.CheckParticipantWaiverStatus(participantID, pw.WaiverID, pw.Waiver.Version, forProgram)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			missing = append(missing, MissingWaiver{WaiverID: pw.WaiverID, Title: pw.Waiver.Title, Version: pw.Waiver.Version})
+		}
+	}
+
+	if len(missing) > 0 {
+		return &MissingWaiversError{Waivers: missing}
+	}
+	return nil
+}
+
+// getIdempotentResult returns the stored result for an idempotency key, or nil when there
+// is no key or it is unknown or expired
+func (rs *RegistrationService) getIdempotentResult(key *string) (*db.RegistrationResult, error) {
+	if key == nil || *key == "" {
+		return nil, nil
+	}
+
+	var result db.RegistrationResult
+	found, err := rs.db.GetIdempotentResponse(db.IdempotencyScopeRegistration, *key, &result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check idempotency key: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &result, nil
+}
+
 // CancelRegistration cancels a registration and promotes from waitlist
-func (rs *RegistrationService) CancelRegistration(ctx context.Context, registrationID, participantID uuid.UUID) error {
+func (rs *RegistrationService) CancelRegistration(ctx context.Context, registrationID, participantID uuid.UUID, cancelledBy *uuid.UUID, reasonCode, reason *string) error {
 	// Get registration to build lock key
 	var parentType string
 	var parentID uuid.UUID
@@ -71,7 +242,117 @@ func (rs *RegistrationService) CancelRegistration(ctx context.Context, registrat
 	defer rs.releaseLock(ctx, lockKey, lock)
 
 	// Cancel registration (this also promotes from waitlist)
-	return rs.db.CancelRegistration(registrationID, participantID)
+	return rs.db.CancelRegistration(registrationID, participantID, cancelledBy, reasonCode, reason)
+}
+
+// TransferRegistration moves a registration to another session of the same program,
+// holding both sessions' capacity locks. The locks are taken in key order so two
+// transfers in opposite directions cannot deadlock.
+func (rs *RegistrationService) TransferRegistration(ctx context.Context, registrationID, participantID, targetSessionID uuid.UUID, actor *uuid.UUID, confirmWaitlist bool) (*db.TransferResult, error) {
+	var parentType string
+	var parentID uuid.UUID
+	var sessionID *uuid.UUID
+
+	err := rs.db.QueryRow(`
+		SELECT parent_type, parent_id, session_id
+		FROM registrations
+		WHERE id = $1 AND participant_id = $2
+	`, registrationID, participantID).Scan(&parentType, &parentID, &sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("registration not found: %w", err)
+	}
+
+	lockKeys := []string{
+		rs.buildLockKey(parentType, parentID, sessionID),
+		rs.buildLockKey(parentType, parentID, &targetSessionID),
+	}
+	sort.Strings(lockKeys)
+
+	for _, lockKey := range lockKeys {
+		lock, err := rs.acquireLock(ctx, lockKey, 10*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		defer rs.releaseLock(ctx, lockKey, lock)
+	}
+
+	return rs.db.TransferRegistration(registrationID, participantID, targetSessionID, actor, confirmWaitlist)
+}
+
+// ApproveRegistration approves a pending registration under the capacity lock so the
+// approval cannot overfill the program
+func (rs *RegistrationService) ApproveRegistration(ctx context.Context, registrationID, approvedBy uuid.UUID) (*db.RegistrationResult, error) {
+	var parentType string
+	var parentID uuid.UUID
+	var sessionID *uuid.UUID
+
+	err := rs.db.QueryRow(`
+		SELECT parent_type, parent_id, session_id
+		FROM registrations
+		WHERE id = $1
+	`, registrationID).Scan(&parentType, &parentID, &sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("registration not found: %w", err)
+	}
+
+	lockKey := rs.buildLockKey(parentType, parentID, sessionID)
+	lock, err := rs.acquireLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer rs.releaseLock(ctx, lockKey, lock)
+
+	return rs.db.ApproveRegistration(registrationID, approvedBy)
+}
+
+// UpdateRegistrationStatus sets a registration's status as an admin override under the
+// capacity lock, so a promotion into a seat it gives up cannot race a registration
+func (rs *RegistrationService) UpdateRegistrationStatus(ctx context.Context, registrationID uuid.UUID, status string, changedBy uuid.UUID, reason *string) error {
+	var parentType string
+	var parentID uuid.UUID
+	var sessionID *uuid.UUID
+
+	err := rs.db.QueryRow(`
+		SELECT parent_type, parent_id, session_id
+		FROM registrations
+		WHERE id = $1
+	`, registrationID).Scan(&parentType, &parentID, &sessionID)
+	if err != nil {
+		return fmt.Errorf("registration not found: %w", err)
+	}
+
+	lockKey := rs.buildLockKey(parentType, parentID, sessionID)
+	lock, err := rs.acquireLock(ctx, lockKey, 10*time.Second)
+	if err != nil {
+		return fmt.Errorf("failed to acquire lock: %w", err)
+	}
+	defer rs.releaseLock(ctx, lockKey, lock)
+
+	return rs.db.UpdateRegistrationStatus(registrationID, status, changedBy, reason)
+}
+
+// FixProgramReconciliation repairs waitlist positions for a program and its sessions,
+// holding every capacity lock so no registration or promotion runs during the fix
+func (rs *RegistrationService) FixProgramReconciliation(ctx context.Context, programID uuid.UUID) (*db.ProgramReconciliation, error) {
+	sessions, err := rs.db.GetProgramSessions(programID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	lockKeys := []string{rs.buildLockKey("program", programID, nil)}
+	for _, session := range sessions {
+		lockKeys = append(lockKeys, rs.buildLockKey("program", programID, &session.ID))
+	}
+
+	for _, lockKey := range lockKeys {
+		lock, err := rs.acquireLock(ctx, lockKey, 30*time.Second)
+		if err != nil {
+			return nil, fmt.Errorf("failed to acquire lock: %w", err)
+		}
+		defer rs.releaseLock(ctx, lockKey, lock)
+	}
+
+	return rs.db.FixProgramReconciliation(programID)
 }
 
 func (rs *RegistrationService) buildLockKey(parentType string, parentID uuid.UUID, sessionID *uuid.UUID) string {
@@ -0,0 +1,279 @@
+package core
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"sterling-rec/api/internal/db"
+)
+
+// TestConcurrentRegistrations tests race condition handling. It needs both
+// TEST_DATABASE_URL and TEST_REDIS_ADDR and is skipped otherwise.
+func TestConcurrentRegistrations(t *testing.T) {
+	t.Run("should handle concurrent registrations at capacity limit", func(t *testing.T) {
+		rs, database := setupTestRegistrationService(t)
+		programID := createTestProgram(t, database, 1)
+
+		const attempts = 10
+		participants := make([]uuid.UUID, attempts)
+		for i := range participants {
+			participants[i] = createTestParticipant(t, database)
+		}
+
+		var wg sync.WaitGroup
+		errs := make(chan error, attempts)
+		for _, participantID := range participants {
+			wg.Add(1)
+			go func(participantID uuid.UUID) {
+				defer wg.Done()
+				errs <- registerWithRetry(rs, db.RegistrationRequest{
+					ParentType:    "program",
+					ParentID:      programID,
+					ParticipantID: participantID,
+				})
+			}(participantID)
+		}
+		wg.Wait()
+		close(errs)
+
+		for err := range errs {
+			if err != nil {
+				t.Fatalf("registration failed: %v", err)
+			}
+		}
+
+		var confirmed, waitlisted int
+		err := database.QueryRow(`
+			SELECT
+				COUNT(*) FILTER (WHERE status = 'confirmed'),
+				COUNT(*) FILTER (WHERE status = 'waitlisted')
+			FROM registrations
+			WHERE parent_type = 'program' AND parent_id = $1
+		`, programID).Scan(&confirmed, &waitlisted)
+		if err != nil {
+			t.Fatalf("failed to count registrations: %v", err)
+		}
+		if confirmed != 1 || waitlisted != attempts-1 {
+			t.Errorf("confirmed = %d, waitlisted = %d; want 1 and %d", confirmed, waitlisted, attempts-1)
+		}
+
+		// Waitlist positions must be 1..n with no gaps or duplicates
+		var positions, distinct, maxPosition int
+		err = database.QueryRow(`
+			SELECT COUNT(*), COUNT(DISTINCT position), COALESCE(MAX(position), 0)
+			FROM waitlist_positions
+			WHERE parent_type = 'program' AND parent_id = $1
+		`, programID).Scan(&positions, &distinct, &maxPosition)
+		if err != nil {
+			t.Fatalf("failed to check waitlist positions: %v", err)
+		}
+		if positions != attempts-1 || distinct != positions || maxPosition != positions {
+			t.Errorf("waitlist positions: count=%d distinct=%d max=%d; want %d contiguous positions",
+				positions, distinct, maxPosition, attempts-1)
+		}
+	})
+}
+
+// registerWithRetry retries a registration while another request holds the capacity
+// lock, the way a client would retry a busy response
+func registerWithRetry(rs *RegistrationService, req db.RegistrationRequest) error {
+	var err error
+	for i := 0; i < 200; i++ {
+		_, err = rs.Register(context.Background(), req)
+		if err == nil || !strings.Contains(err.Error(), "lock already held") {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return err
+}
+
+// setupTestRegistrationService connects to the test database and Redis, skipping the
+// test when either is not configured
+func setupTestRegistrationService(t *testing.T) (*RegistrationService, *db.DB) {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	redisAddr := os.Getenv("TEST_REDIS_ADDR")
+	if dbURL == "" || redisAddr == "" {
+		t.Skip("TEST_DATABASE_URL and TEST_REDIS_ADDR must be set; skipping concurrency test")
+	}
+
+	database, err := db.Open(dbURL)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { database.Close() })
+
+	if err := database.RunMigrations("../../migrations"); err != nil {
+		t.Fatalf("failed to run migrations: %v", err)
+	}
+
+	redisClient := redis.NewClient(&redis.Options{Addr: redisAddr})
+	if err := redisClient.Ping(context.Background()).Err(); err != nil {
+		t.Fatalf("failed to connect to test redis: %v", err)
+	}
+	t.Cleanup(func() { redisClient.Close() })
+
+	return NewRegistrationService(database, redisClient), database
+}
+
+// createTestProgram creates an active program with the given capacity and removes it
+// and its registrations when the test ends
+func createTestProgram(t *testing.T, database *db.DB, capacity int) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	err := database.QueryRow(`
+		INSERT INTO programs (slug, title, capacity)
+		VALUES ($1, 'Test Program', $2)
+		RETURNING id
+	`, "test-program-"+uuid.New().String(), capacity).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create test program: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.Exec(`DELETE FROM notification_queue WHERE payload->>'parent_id' = $1`, id.String())
+		database.Exec(`DELETE FROM waitlist_positions WHERE parent_id = $1`, id)
+		database.Exec(`DELETE FROM registrations WHERE parent_id = $1`, id)
+		database.Exec(`DELETE FROM programs WHERE id = $1`, id)
+	})
+
+	return id
+}
+
+// createTestParticipant creates a user, household and participant, removing them
+// when the test ends
+func createTestParticipant(t *testing.T, database *db.DB) uuid.UUID {
+	t.Helper()
+
+	var participantID uuid.UUID
+	err := database.QueryRow(`
+		WITH u AS (
+			INSERT INTO users (email, password_hash, first_name, last_name)
+			VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+			RETURNING id
+		), h AS (
+			INSERT INTO households (owner_user_id) SELECT id FROM u RETURNING id
+		)
+		INSERT INTO participants (household_id, first_name, last_name)
+		SELECT id, 'Test', 'Participant' FROM h
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&participantID)
+	if err != nil {
+		t.Fatalf("failed to create test participant: %v", err)
+	}
+
+	t.Cleanup(func() {
+		database.Exec(`
+			DELETE FROM users WHERE id = (
+				SELECT h.owner_user_id FROM participants p
+				JOIN households h ON h.id = p.household_id
+				WHERE p.id = $1
+			)
+		`, participantID)
+	})
+
+	return participantID
+}
+
+// TestRegisterRequiresWaivers tests registrations are blocked until every required waiver
+// is accepted at its current version, for this program when it is per season
+func TestRegisterRequiresWaivers(t *testing.T) {
+	rs, database := setupTestRegistrationService(t)
+	programID := createTestProgram(t, database, 10)
+	otherProgramID := createTestProgram(t, database, 10)
+
+	createWaiver := func(perSeason bool) uuid.UUID {
+		var id uuid.UUID
+		err := database.QueryRow(`
+			INSERT INTO waivers (title, body_html) VALUES ('Test Waiver', '<p>Test</p>') RETURNING id
+		`).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test waiver: %v", err)
+		}
+		t.Cleanup(func() {
+			database.Exec(`DELETE FROM waivers WHERE id = $1`, id)
+		})
+		_, err = database.Exec(`
+			INSERT INTO program_waivers (program_id, waiver_id, is_required, is_per_season) VALUES ($1, $2, true, $3)
+		`, programID, id, perSeason)
+		if err != nil {
+			t.Fatalf("failed to assign test waiver: %v", err)
+		}
+		return id
+	}
+	accept := func(participantID, waiverID uuid.UUID, version int, forProgram *uuid.UUID) {
+		_, err := database.Exec(`
+			INSERT INTO participant_waiver_acceptances (participant_id, waiver_id, waiver_version, program_id, accepted_by_user_id)
+			SELECT p.id, $2, $3, $4, h.owner_user_id
+			FROM participants p JOIN households h ON h.id = p.household_id
+			WHERE p.id = $1
+		`, participantID, waiverID, version, forProgram)
+		if err != nil {
+			t.Fatalf("failed to accept test waiver: %v", err)
+		}
+		// Acceptances block deleting the accepting user, so remove them first
+		t.Cleanup(func() {
+			database.Exec(`DELETE FROM participant_waiver_acceptances WHERE participant_id = $1`, participantID)
+		})
+	}
+	missing := func(participantID uuid.UUID) []uuid.UUID {
+		_, err := rs.Register(context.Background(), db.RegistrationRequest{
+			ParentType:    "program",
+			ParentID:      programID,
+			ParticipantID: participantID,
+		})
+		if err == nil {
+			return nil
+		}
+		waiversErr, ok := err.(*MissingWaiversError)
+		if !ok {
+			t.Fatalf("Register: %v", err)
+		}
+		var ids []uuid.UUID
+		for _, w := range waiversErr.Waivers {
+			ids = append(ids, w.WaiverID)
+		}
+		return ids
+	}
+
+	annual := createWaiver(false)
+	seasonal := createWaiver(true)
+
+	participantID := createTestParticipant(t, database)
+	if ids := missing(participantID); len(ids) != 2 {
+		t.Fatalf("missing waivers = %v, want both", ids)
+	}
+
+	// A per-season acceptance for another program does not count
+	accept(participantID, annual, 1, nil)
+	accept(participantID, seasonal, 1, &otherProgramID)
+	if ids := missing(participantID); len(ids) != 1 || ids[0] != seasonal {
+		t.Fatalf("missing waivers = %v, want only the per-season waiver %s", ids, seasonal)
+	}
+
+	accept(participantID, seasonal, 1, &programID)
+	if ids := missing(participantID); ids != nil {
+		t.Fatalf("missing waivers = %v after accepting both, want none", ids)
+	}
+
+	// Bumping the version requires accepting it again
+	if _, err := database.Exec(`UPDATE waivers SET version = 2 WHERE id = $1`, annual); err != nil {
+		t.Fatalf("failed to bump waiver version: %v", err)
+	}
+	again := createTestParticipant(t, database)
+	accept(again, annual, 1, nil)
+	accept(again, seasonal, 1, &programID)
+	if ids := missing(again); len(ids) != 1 || ids[0] != annual {
+		t.Errorf("missing waivers = %v, want the updated waiver %s", ids, annual)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,25 +21,122 @@ type SyncClient struct {
 	apiKey     string
 	tenantSlug string
 	httpClient *http.Client
+	breaker    *CircuitBreaker
 	db         *db.DB
 	enabled    bool
 }
 
+// SyncClientConfig tunes requests to the central platform
+type SyncClientConfig struct {
+	Timeout          time.Duration // per request
+	FailureThreshold int           // consecutive failures that open the circuit breaker
+	Cooldown         time.Duration // how long the open breaker fails requests before probing
+}
+
+var DefaultSyncClientConfig = SyncClientConfig{Timeout: 30 * time.Second, FailureThreshold: 5, Cooldown: time.Minute}
+
+// syncClientConfigFromEnv reads SYNC_TIMEOUT_SECONDS, SYNC_BREAKER_FAILURE_THRESHOLD and
+// SYNC_BREAKER_COOLDOWN_SECONDS. Unset or invalid values keep the defaults.
+func syncClientConfigFromEnv() SyncClientConfig {
+	cfg := DefaultSyncClientConfig
+	if n, err := strconv.Atoi(os.Getenv("SYNC_TIMEOUT_SECONDS")); err == nil && n > 0 {
+		cfg.Timeout = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("SYNC_BREAKER_FAILURE_THRESHOLD")); err == nil && n > 0 {
+		cfg.FailureThreshold = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("SYNC_BREAKER_COOLDOWN_SECONDS")); err == nil && n > 0 {
+		cfg.Cooldown = time.Duration(n) * time.Second
+	}
+	return cfg
+}
+
 func NewSyncClient(database *db.DB) *SyncClient {
 	enabled := os.Getenv("SYNC_ENABLED") == "true"
+	cfg := syncClientConfigFromEnv()
 
 	return &SyncClient{
 		baseURL:    os.Getenv("CENTRAL_PLATFORM_URL"),
 		apiKey:     os.Getenv("CENTRAL_PLATFORM_API_KEY"),
 		tenantSlug: os.Getenv("TENANT_SLUG"),
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout: cfg.Timeout,
 		},
+		breaker: NewCircuitBreaker(cfg.FailureThreshold, cfg.Cooldown),
 		db:      database,
 		enabled: enabled,
 	}
 }
 
+// Enabled reports whether sync to the central platform is turned on (SYNC_ENABLED)
+func (sc *SyncClient) Enabled() bool {
+	return sc.enabled
+}
+
+// Available reports whether the circuit breaker lets requests through, so a worker can
+// skip a run rather than fail every event
+func (sc *SyncClient) Available() bool {
+	return sc.breaker.Status().State != CircuitOpen
+}
+
+// SyncStatus reports the health of sync to the central platform
+type SyncStatus struct {
+	Enabled        bool                 `json:"enabled"`
+	TimeoutSeconds int                  `json:"timeout_seconds"`
+	CircuitBreaker CircuitBreakerStatus `json:"circuit_breaker"`
+	Queue          map[string]int       `json:"queue"` // sync_events by status
+}
+
+// Status returns the circuit breaker state and how many sync events are in each status
+func (sc *SyncClient) Status(ctx context.Context) (*SyncStatus, error) {
+	rows, err := sc.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM sync_events GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count sync events: %w", err)
+	}
+	defer rows.Close()
+
+	queue := map[string]int{}
+	for rows.Next() {
+		var status string
+		var count int
+		if err := rows.Scan(&status, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan sync event count: %w", err)
+		}
+		queue[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to count sync events: %w", err)
+	}
+
+	return &SyncStatus{
+		Enabled:        sc.enabled,
+		TimeoutSeconds: int(sc.httpClient.Timeout / time.Second),
+		CircuitBreaker: sc.breaker.Status(),
+		Queue:          queue,
+	}, nil
+}
+
+// do sends a request to the central platform through the circuit breaker. Network
+// errors, timeouts, 5xx and 429 responses count as failures; any other response shows
+// the platform is up.
+func (sc *SyncClient) do(req *http.Request) (*http.Response, error) {
+	if err := sc.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := sc.httpClient.Do(req)
+	if err != nil {
+		sc.breaker.Record(err)
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		sc.breaker.Record(fmt.Errorf("%s %s returned status %d", req.Method, req.URL.Path, resp.StatusCode))
+	} else {
+		sc.breaker.Record(nil)
+	}
+	return resp, nil
+}
+
 // SyncEvent represents an event to be synced to the central platform
 type SyncEvent struct {
 	ID         int64
@@ -160,9 +258,9 @@ func (sc *SyncClient) makeRequest(ctx context.Context, method, url string, paylo
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", sc.apiKey))
 	req.Header.Set("X-Tenant-Slug", sc.tenantSlug)
 
-	resp, err := sc.httpClient.Do(req)
+	resp, err := sc.do(req)
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -202,9 +300,9 @@ func (sc *SyncClient) FetchPrograms(ctx context.Context) ([]map[string]interface
 
 	req.Header.Set("X-Tenant-Slug", sc.tenantSlug)
 
-	resp, err := sc.httpClient.Do(req)
+	resp, err := sc.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -257,9 +355,9 @@ func (sc *SyncClient) FetchEvents(ctx context.Context) ([]map[string]interface{}
 
 	req.Header.Set("X-Tenant-Slug", sc.tenantSlug)
 
-	resp, err := sc.httpClient.Do(req)
+	resp, err := sc.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
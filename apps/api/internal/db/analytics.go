@@ -0,0 +1,310 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CancellationReasonCodes lists the structured reasons a cancellation may be given
+var CancellationReasonCodes = []string{
+	"schedule_conflict", "illness", "cost", "dissatisfied", "moved", "other",
+}
+
+// CancellationReasonCount is the number of cancellations given a reason code.
+// ReasonCode "unspecified" counts cancellations without a code.
+type CancellationReasonCount struct {
+	ReasonCode string `json:"reason_code"`
+	Count      int    `json:"count"`
+}
+
+// CancellationReport aggregates registration and booking cancellations by reason
+type CancellationReport struct {
+	From               time.Time                 `json:"from"`
+	To                 time.Time                 `json:"to"`
+	Registrations      []CancellationReasonCount `json:"registrations"`
+	Bookings           []CancellationReasonCount `json:"bookings"`
+	TotalRegistrations int                       `json:"total_registrations"`
+	TotalBookings      int                       `json:"total_bookings"`
+}
+
+// GetCancellationReport counts registration and booking cancellations made within
+// [from, to) grouped by reason code
+func (db *DB) GetCancellationReport(from, to time.Time) (*CancellationReport, error) {
+	report := &CancellationReport{From: from, To: to}
+
+	registrations, err := db.countCancellationsByReason(`
+		SELECT COALESCE(reason_code, 'unspecified'), COUNT(*)
+		FROM registration_status_history
+		WHERE new_status = 'cancelled' AND created_at >= $1 AND created_at < $2
+		GROUP BY 1
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count registration cancellations: %w", err)
+	}
+
+	bookings, err := db.countCancellationsByReason(`
+		SELECT COALESCE(cancellation_reason_code, 'unspecified'), COUNT(*)
+		FROM facility_bookings
+		WHERE status = 'cancelled' AND cancelled_at >= $1 AND cancelled_at < $2
+		GROUP BY 1
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count booking cancellations: %w", err)
+	}
+
+	report.Registrations, report.TotalRegistrations = cancellationCounts(registrations)
+	report.Bookings, report.TotalBookings = cancellationCounts(bookings)
+
+	return report, nil
+}
+
+// countCancellationsByReason runs a (reason_code, count) query and returns the counts by code
+func (db *DB) countCancellationsByReason(query string, from, to time.Time) (map[string]int, error) {
+	rows, err := db.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var code string
+		var count int
+		if err := rows.Scan(&code, &count); err != nil {
+			return nil, err
+		}
+		counts[code] = count
+	}
+	return counts, rows.Err()
+}
+
+// cancellationCounts lists every reason code (including zero counts) followed by
+// "unspecified", and returns the total
+func cancellationCounts(counts map[string]int) ([]CancellationReasonCount, int) {
+	codes := append(append([]string{}, CancellationReasonCodes...), "unspecified")
+
+	result := make([]CancellationReasonCount, 0, len(codes))
+	total := 0
+	for _, code := range codes {
+		result = append(result, CancellationReasonCount{ReasonCode: code, Count: counts[code]})
+		total += counts[code]
+	}
+	return result, total
+}
+
+// participationAgeBands are the age bands of the participation report, by lowest age
+var participationAgeBands = []struct {
+	Label  string
+	MinAge int
+}{
+	{"0-5", 0}, {"6-12", 6}, {"13-17", 13}, {"18-54", 18}, {"55+", 55},
+}
+
+// Residency groups of the participation report
+const (
+	ResidencyResident    = "resident"
+	ResidencyNonResident = "non_resident"
+	ResidencyUnknown     = "unknown"
+)
+
+// ParticipationCount is the number of registrations, and of distinct participants behind
+// them, in one group of a participation report
+type ParticipationCount struct {
+	Group         string `json:"group"`
+	Registrations int    `json:"registrations"`
+	Participants  int    `json:"participants"`
+}
+
+// ParticipationReport summarizes who took part in programs and events during a year.
+// Participants are counted once however many programs they joined.
+type ParticipationReport struct {
+	Year               int                  `json:"year"`
+	UniqueParticipants int                  `json:"unique_participants"`
+	TotalRegistrations int                  `json:"total_registrations"`
+	ByCategory         []ParticipationCount `json:"by_category"`
+	ByAgeBand          []ParticipationCount `json:"by_age_band"`
+	ByResidency        []ParticipationCount `json:"by_residency"`
+}
+
+// participationRow is one confirmed registration counted in a participation report
+type participationRow struct {
+	ParticipantID uuid.UUID
+	Category      string
+	DOB           *time.Time
+	Zip           *string
+	ActivityDate  time.Time
+}
+
+// GetParticipationReport builds the participation report for a calendar year from
+// confirmed registrations whose activity (the session, program start or event) falls in
+// that year. Program registrations are grouped by program category and event
+// registrations under "Events". A participant's age band is their age at their first
+// activity of the year; their residency comes from their household's zip code, compared
+// against residentZips. Without resident zips every participant's residency is unknown.
+func (db *DB) GetParticipationReport(year int, residentZips []string) (*ParticipationReport, error) {
+	from := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	rows, err := db.Query(`
+		SELECT participant_id, category, dob, zip, activity_date
+		FROM (
+			SELECT
+				r.participant_id,
+				CASE WHEN r.parent_type = 'event' THEN 'Events'
+					ELSE COALESCE(NULLIF(TRIM(p.category), ''), 'Uncategorized') END AS category,
+				pa.dob,
+				h.zip,
+				COALESCE(s.starts_at, p.start_date::timestamptz, e.starts_at, r.created_at) AS activity_date
+			FROM registrations r
+			JOIN participants pa ON pa.id = r.participant_id
+			LEFT JOIN households h ON h.id = pa.household_id
+			LEFT JOIN programs p ON r.parent_type = 'program' AND p.id = r.parent_id
+			LEFT JOIN events e ON r.parent_type = 'event' AND e.id = r.parent_id
+			LEFT JOIN sessions s ON s.id = r.session_id
+			WHERE r.status = 'confirmed'
+		) counted
+		WHERE activity_date >= $1 AND activity_date < $2
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participation: %w", err)
+	}
+	defer rows.Close()
+
+	var registrations []participationRow
+	for rows.Next() {
+		var r participationRow
+		if err := rows.Scan(&r.ParticipantID, &r.Category, &r.DOB, &r.Zip, &r.ActivityDate); err != nil {
+			return nil, fmt.Errorf("failed to scan participation: %w", err)
+		}
+		registrations = append(registrations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get participation: %w", err)
+	}
+
+	return buildParticipationReport(year, registrations, residentZips), nil
+}
+
+// buildParticipationReport aggregates counted registrations into a participation report
+func buildParticipationReport(year int, registrations []participationRow, residentZips []string) *ParticipationReport {
+	residents := make(map[string]bool)
+	for _, zip := range residentZips {
+		if zip = normalizeZip(zip); zip != "" {
+			residents[zip] = true
+		}
+	}
+
+	// Each participant is placed in one age band and residency group, taken from their
+	// first activity of the year
+	first := make(map[uuid.UUID]participationRow)
+	for _, r := range registrations {
+		if f, ok := first[r.ParticipantID]; !ok || r.ActivityDate.Before(f.ActivityDate) {
+			first[r.ParticipantID] = r
+		}
+	}
+	ageBand := func(r participationRow) string {
+		if r.DOB == nil {
+			return "unknown"
+		}
+		age := AgeOn(*r.DOB, r.ActivityDate)
+		band := participationAgeBands[0].Label
+		for _, b := range participationAgeBands {
+			if age >= b.MinAge {
+				band = b.Label
+			}
+		}
+		return band
+	}
+	residency := func(r participationRow) string {
+		if len(residents) == 0 || r.Zip == nil || normalizeZip(*r.Zip) == "" {
+			return ResidencyUnknown
+		}
+		if residents[normalizeZip(*r.Zip)] {
+			return ResidencyResident
+		}
+		return ResidencyNonResident
+	}
+
+	byCategory := newParticipationTally()
+	byAgeBand := newParticipationTally()
+	byResidency := newParticipationTally()
+	for _, r := range registrations {
+		f := first[r.ParticipantID]
+		byCategory.add(r.Category, r.ParticipantID)
+		byAgeBand.add(ageBand(f), r.ParticipantID)
+		byResidency.add(residency(f), r.ParticipantID)
+	}
+
+	ageBands := make([]string, 0, len(participationAgeBands)+1)
+	for _, b := range participationAgeBands {
+		ageBands = append(ageBands, b.Label)
+	}
+
+	return &ParticipationReport{
+		Year:               year,
+		UniqueParticipants: len(first),
+		TotalRegistrations: len(registrations),
+		ByCategory:         byCategory.counts(byCategory.groupsByRegistrations()),
+		ByAgeBand:          byAgeBand.counts(append(ageBands, "unknown")),
+		ByResidency:        byResidency.counts([]string{ResidencyResident, ResidencyNonResident, ResidencyUnknown}),
+	}
+}
+
+// participationTally counts registrations and distinct participants per group
+type participationTally struct {
+	registrations map[string]int
+	participants  map[string]map[uuid.UUID]bool
+}
+
+func newParticipationTally() *participationTally {
+	return &participationTally{registrations: map[string]int{}, participants: map[string]map[uuid.UUID]bool{}}
+}
+
+func (t *participationTally) add(group string, participantID uuid.UUID) {
+	t.registrations[group]++
+	if t.participants[group] == nil {
+		t.participants[group] = map[uuid.UUID]bool{}
+	}
+	t.participants[group][participantID] = true
+}
+
+// groupsByRegistrations returns the groups seen, most registrations first
+func (t *participationTally) groupsByRegistrations() []string {
+	groups := make([]string, 0, len(t.registrations))
+	for group := range t.registrations {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if t.registrations[groups[i]] != t.registrations[groups[j]] {
+			return t.registrations[groups[i]] > t.registrations[groups[j]]
+		}
+		return groups[i] < groups[j]
+	})
+	return groups
+}
+
+// counts lists the given groups in order, including those with no registrations
+func (t *participationTally) counts(groups []string) []ParticipationCount {
+	result := make([]ParticipationCount, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, ParticipationCount{
+			Group:         group,
+			Registrations: t.registrations[group],
+			Participants:  len(t.participants[group]),
+		})
+	}
+	return result
+}
+
+// normalizeZip reduces a US zip code to its five-digit form
+func normalizeZip(zip string) string {
+	zip = strings.TrimSpace(zip)
+	if len(zip) > 5 {
+		zip = zip[:5]
+	}
+	return zip
+}
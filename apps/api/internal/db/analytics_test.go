@@ -0,0 +1,77 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestBuildParticipationReport checks participants are counted once across programs and
+// placed in an age band and residency group by their first activity of the year
+func TestBuildParticipationReport(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 10, 0, 0, 0, time.UTC)
+	}
+	ptr := func(s string) *string { return &s }
+	dob, adultDOB := date(2019, time.March, 15), date(1980, time.January, 1)
+
+	child, adult, unknown := uuid.New(), uuid.New(), uuid.New()
+	rows := []participationRow{
+		// The child turns 6 in March; their first activity is in February, at 5
+		{ParticipantID: child, Category: "Aquatics", DOB: &dob, Zip: ptr("02134-1234"), ActivityDate: date(2025, time.June, 1)},
+		{ParticipantID: child, Category: "Sports", DOB: &dob, Zip: ptr("02134-1234"), ActivityDate: date(2025, time.February, 1)},
+		{ParticipantID: adult, Category: "Aquatics", DOB: &adultDOB, Zip: ptr("90210"), ActivityDate: date(2025, time.May, 1)},
+		{ParticipantID: unknown, Category: "Events", ActivityDate: date(2025, time.July, 4)},
+	}
+
+	report := buildParticipationReport(2025, rows, []string{" 02134 ", "02135"})
+
+	if report.UniqueParticipants != 3 || report.TotalRegistrations != 4 {
+		t.Errorf("totals = %d participants, %d registrations, want 3 and 4", report.UniqueParticipants, report.TotalRegistrations)
+	}
+
+	wantCategories := []ParticipationCount{
+		{Group: "Aquatics", Registrations: 2, Participants: 2},
+		{Group: "Events", Registrations: 1, Participants: 1},
+		{Group: "Sports", Registrations: 1, Participants: 1},
+	}
+	checkParticipationCounts(t, "by category", report.ByCategory, wantCategories)
+
+	wantAgeBands := []ParticipationCount{
+		{Group: "0-5", Registrations: 2, Participants: 1},
+		{Group: "6-12"},
+		{Group: "13-17"},
+		{Group: "18-54", Registrations: 1, Participants: 1},
+		{Group: "55+"},
+		{Group: "unknown", Registrations: 1, Participants: 1},
+	}
+	checkParticipationCounts(t, "by age band", report.ByAgeBand, wantAgeBands)
+
+	wantResidency := []ParticipationCount{
+		{Group: ResidencyResident, Registrations: 2, Participants: 1},
+		{Group: ResidencyNonResident, Registrations: 1, Participants: 1},
+		{Group: ResidencyUnknown, Registrations: 1, Participants: 1},
+	}
+	checkParticipationCounts(t, "by residency", report.ByResidency, wantResidency)
+
+	// Without resident zip codes residency cannot be told
+	report = buildParticipationReport(2025, rows, nil)
+	checkParticipationCounts(t, "by residency without zips", report.ByResidency, []ParticipationCount{
+		{Group: ResidencyResident},
+		{Group: ResidencyNonResident},
+		{Group: ResidencyUnknown, Registrations: 4, Participants: 3},
+	})
+}
+
+func checkParticipationCounts(t *testing.T, name string, got, want []ParticipationCount) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %+v, want %+v", name, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("%s[%d] = %+v, want %+v", name, i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// API key scopes. Every admin route requires one; admin:read and admin:write are broad
+// scopes that cover the others (see APIKey.HasScope).
+const (
+	ScopeAdminRead  = "admin:read"
+	ScopeAdminWrite = "admin:write"
+
+	ScopeDashboardRead      = "dashboard:read"
+	ScopeProgramsRead       = "programs:read"
+	ScopeProgramsWrite      = "programs:write"
+	ScopeEventsRead         = "events:read"
+	ScopeEventsWrite        = "events:write"
+	ScopeHouseholdsWrite    = "households:write"
+	ScopeRegistrationsRead  = "registrations:read"
+	ScopeRegistrationsWrite = "registrations:write"
+	ScopeFacilitiesRead     = "facilities:read"
+	ScopeFacilitiesWrite    = "facilities:write"
+	ScopeBookingsRead       = "bookings:read"
+	ScopeBookingsWrite      = "bookings:write"
+	ScopeBookingsExport     = "bookings:export"
+	ScopeUsersRead          = "users:read"
+	ScopeUsersWrite         = "users:write"
+	ScopeWaiversRead        = "waivers:read"
+	ScopeWaiversWrite       = "waivers:write"
+)
+
+// APIKeyScopes are the scopes a key may be granted
+var APIKeyScopes = []string{
+	ScopeAdminRead, ScopeAdminWrite,
+	ScopeDashboardRead,
+	ScopeProgramsRead, ScopeProgramsWrite,
+	ScopeEventsRead, ScopeEventsWrite,
+	ScopeHouseholdsWrite,
+	ScopeRegistrationsRead, ScopeRegistrationsWrite,
+	ScopeFacilitiesRead, ScopeFacilitiesWrite,
+	ScopeBookingsRead, ScopeBookingsWrite, ScopeBookingsExport,
+	ScopeUsersRead, ScopeUsersWrite,
+	ScopeWaiversRead, ScopeWaiversWrite,
+}
+
+// APIKey is a credential for server-to-server access. Requests made with it act as
+// the key's service user, which carries the role.
+type APIKey struct {
+	ID            uuid.UUID  `json:"id"`
+	Name          string     `json:"name"`
+	KeyPrefix     string     `json:"key_prefix"`
+	Role          string     `json:"role"`
+	Scopes        []string   `json:"scopes"`
+	ServiceUserID uuid.UUID  `json:"service_user_id"`
+	ServiceEmail  string     `json:"-"`
+	CreatedBy     *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports whether the key was granted the scope, either directly or through
+// admin:write (every scope) or admin:read (every read and export scope)
+func (k *APIKey) HasScope(scope string) bool {
+	if containsString(k.Scopes, scope) || containsString(k.Scopes, ScopeAdminWrite) {
+		return true
+	}
+	return containsString(k.Scopes, ScopeAdminRead) &&
+		(strings.HasSuffix(scope, ":read") || strings.HasSuffix(scope, ":export"))
+}
+
+// ValidateAPIKeyScopes checks scopes are known, not repeated and not empty
+func ValidateAPIKeyScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("scopes: at least one scope is required")
+	}
+	return validateCodes("scopes", scopes, APIKeyScopes)
+}
+
+const apiKeyColumns = `
+	k.id, k.name, k.key_prefix, u.role, k.scopes, k.service_user_id, u.email,
+	k.created_by, k.created_at, k.last_used_at, k.revoked_at
+`
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (*APIKey, error) {
+	var key APIKey
+	err := row.Scan(
+		&key.ID, &key.Name, &key.KeyPrefix, &key.Role, pq.Array(&key.Scopes), &key.ServiceUserID, &key.ServiceEmail,
+		&key.CreatedBy, &key.CreatedAt, &key.LastUsedAt, &key.RevokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// CreateAPIKey stores a new key with its service user. Only the hash of the key is
+// kept; the caller shows the plaintext to the admin once.
+func (db *DB) CreateAPIKey(name, role string, scopes []string, keyPrefix, keyHash string, createdBy uuid.UUID) (*APIKey, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The service user can never log in: "!" is not a valid bcrypt hash
+	var serviceUserID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role, is_service)
+		VALUES ($1, '!', $2, 'API key', $3, true)
+		RETURNING id
+	`, "api-key-"+uuid.New().String()+"@service.invalid", name, role).Scan(&serviceUserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service user: %w", err)
+	}
+
+	var keyID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO api_keys (name, key_prefix, key_hash, service_user_id, scopes, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, name, keyPrefix, keyHash, serviceUserID, pq.Array(scopes), createdBy).Scan(&keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	key, err := scanAPIKey(tx.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys k JOIN users u ON u.id = k.service_user_id
+		WHERE k.id = $1
+	`, keyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return key, nil
+}
+
+// GetAPIKey retrieves a key by ID, revoked or not
+func (db *DB) GetAPIKey(id uuid.UUID) (*APIKey, error) {
+	key, err := scanAPIKey(db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys k JOIN users u ON u.id = k.service_user_id
+		WHERE k.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// GetActiveAPIKeyByHash finds the unrevoked key with the given hash
+func (db *DB) GetActiveAPIKeyByHash(keyHash string) (*APIKey, error) {
+	key, err := scanAPIKey(db.QueryRow(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys k JOIN users u ON u.id = k.service_user_id
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL
+	`, keyHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns all keys, newest first
+func (db *DB) ListAPIKeys() ([]APIKey, error) {
+	rows, err := db.Query(`
+		SELECT ` + apiKeyColumns + `
+		FROM api_keys k JOIN users u ON u.id = k.service_user_id
+		ORDER BY k.created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan api key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// TouchAPIKey records that a key was used. Writes are limited to one a minute per key
+// so busy integrations don't update the row on every request.
+func (db *DB) TouchAPIKey(id uuid.UUID) error {
+	_, err := db.Exec(`
+		UPDATE api_keys SET last_used_at = now()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute')
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to update api key last used: %w", err)
+	}
+	return nil
+}
+
+// RevokeAPIKey stops a key from authenticating. Revoked keys are kept for the record.
+func (db *DB) RevokeAPIKey(id uuid.UUID) error {
+	result, err := db.Exec(`UPDATE api_keys SET revoked_at = now() WHERE id = $1 AND revoked_at IS NULL`, id)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("api key not found")
+	}
+	return nil
+}
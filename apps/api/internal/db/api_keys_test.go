@@ -0,0 +1,96 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestAPIKeys tests issuing, looking up and revoking API keys
+func TestAPIKeys(t *testing.T) {
+	db := setupTestDB(t)
+
+	var adminID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Admin', 'admin')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&adminID)
+	if err != nil {
+		t.Fatalf("failed to create test admin: %v", err)
+	}
+
+	hash := "test-hash-" + uuid.New().String()
+	key, err := db.CreateAPIKey("Test sync", "admin", []string{ScopeAdminRead}, "srk_testtest", hash, adminID)
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM users WHERE id IN ($1, $2)`, key.ServiceUserID, adminID)
+	})
+
+	if key.Role != "admin" || !key.HasScope(ScopeAdminRead) || key.HasScope(ScopeAdminWrite) {
+		t.Errorf("key role %q scopes %v, want admin with admin:read only", key.Role, key.Scopes)
+	}
+
+	found, err := db.GetActiveAPIKeyByHash(hash)
+	if err != nil {
+		t.Fatalf("GetActiveAPIKeyByHash: %v", err)
+	}
+	if found == nil || found.ID != key.ID {
+		t.Fatalf("GetActiveAPIKeyByHash = %v, want key %s", found, key.ID)
+	}
+
+	if err := db.TouchAPIKey(key.ID); err != nil {
+		t.Fatalf("TouchAPIKey: %v", err)
+	}
+	if touched, _ := db.GetAPIKey(key.ID); touched == nil || touched.LastUsedAt == nil {
+		t.Error("last_used_at not recorded")
+	}
+
+	if err := db.RevokeAPIKey(key.ID); err != nil {
+		t.Fatalf("RevokeAPIKey: %v", err)
+	}
+	if found, _ := db.GetActiveAPIKeyByHash(hash); found != nil {
+		t.Error("revoked key still authenticates")
+	}
+	if err := db.RevokeAPIKey(key.ID); err == nil {
+		t.Error("revoking twice should fail")
+	}
+}
+
+// TestValidateAPIKeyScopes checks scopes must be known and non-empty
+func TestValidateAPIKeyScopes(t *testing.T) {
+	if err := ValidateAPIKeyScopes([]string{ScopeAdminRead, ScopeAdminWrite}); err != nil {
+		t.Errorf("valid scopes rejected: %v", err)
+	}
+	for _, scopes := range [][]string{nil, {"admin"}, {ScopeAdminRead, ScopeAdminRead}} {
+		if err := ValidateAPIKeyScopes(scopes); err == nil {
+			t.Errorf("scopes %v accepted", scopes)
+		}
+	}
+}
+
+// TestAPIKeyHasScope checks the broad admin scopes cover the narrower ones
+func TestAPIKeyHasScope(t *testing.T) {
+	tests := []struct {
+		scopes []string
+		scope  string
+		want   bool
+	}{
+		{[]string{ScopeRegistrationsRead}, ScopeRegistrationsRead, true},
+		{[]string{ScopeRegistrationsRead}, ScopeRegistrationsWrite, false},
+		{[]string{ScopeRegistrationsRead}, ScopeProgramsWrite, false},
+		{[]string{ScopeAdminRead}, ScopeBookingsRead, true},
+		{[]string{ScopeAdminRead}, ScopeBookingsExport, true},
+		{[]string{ScopeAdminRead}, ScopeBookingsWrite, false},
+		{[]string{ScopeAdminWrite}, ScopeProgramsWrite, true},
+	}
+
+	for _, tt := range tests {
+		key := &APIKey{Scopes: tt.scopes}
+		if got := key.HasScope(tt.scope); got != tt.want {
+			t.Errorf("key with %v: HasScope(%q) = %v, want %v", tt.scopes, tt.scope, got, tt.want)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,14 +11,65 @@ import (
 // AvailabilityQuery represents a query for available time slots
 type AvailabilityQuery struct {
 	FacilityID uuid.UUID
-	StartDate  time.Time
+	StartDate  time.Time // calendar dates, taken in the facility's time zone; EndDate is exclusive
 	EndDate    time.Time
-	Duration   int // duration in minutes
+	Duration   int    // duration in minutes
+	Audience   string // requester's audience; windows reserved for others are skipped
+	// ParticipantID, when set, also skips slots overlapping the participant's confirmed
+	// bookings at any facility
+	ParticipantID *uuid.UUID
 }
 
-// CheckAvailability checks if a specific time slot is available for booking
-// Returns error if slot is not available with reason
-func (db *DB) CheckAvailability(facilityID uuid.UUID, startTime, endTime time.Time) error {
+// Reasons a slot cannot be booked, reported as AvailabilityError.Code
+const (
+	AvailabilityFacilityUnavailable = "FACILITY_UNAVAILABLE" // inactive, not bookable or unpublished
+	AvailabilityDurationTooShort    = "DURATION_TOO_SHORT"
+	AvailabilityDurationTooLong     = "DURATION_TOO_LONG"
+	AvailabilityTooFarInAdvance     = "TOO_FAR_IN_ADVANCE"
+	AvailabilityInPast              = "IN_PAST"
+	AvailabilityPastCutoff          = "PAST_BOOKING_CUTOFF" // the day closed at the facility's cutoff the day before
+	AvailabilityOutsideWindow       = "OUTSIDE_WINDOW"
+	AvailabilityClosure             = "CLOSURE"
+	AvailabilityConflict            = "CONFLICT" // taken by other bookings, or every unit is
+)
+
+// AvailabilityError is returned when a slot cannot be booked. Code says which rule the
+// slot broke so clients can point at it; Message is the human-readable reason.
+type AvailabilityError struct {
+	Code    string
+	Message string
+	Closure *FacilityClosure // the overlapping closure, for AvailabilityClosure
+}
+
+func (e *AvailabilityError) Error() string {
+	return e.Message
+}
+
+func availabilityErrorf(code, format string, args ...interface{}) *AvailabilityError {
+	return &AvailabilityError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// CheckAvailability checks if a specific time slot is available for booking by the given audience.
+// waiveAdvanceLimit skips the advance booking limit for exempt users; every other check still applies.
+// Returns an *AvailabilityError with the reason if the slot is not available
+func (db *DB) CheckAvailability(facilityID uuid.UUID, startTime, endTime time.Time, audience string, waiveAdvanceLimit bool) error {
+	return db.checkAvailability(facilityID, startTime, endTime, nil, audience, waiveAdvanceLimit)
+}
+
+// CheckRescheduleAvailability checks if an existing booking can be moved to a new
+// time slot, ignoring the booking's own current time when looking for conflicts
+func (db *DB) CheckRescheduleAvailability(bookingID, facilityID uuid.UUID, startTime, endTime time.Time, audience string) error {
+	return db.checkAvailability(facilityID, startTime, endTime, &bookingID, audience, false)
+}
+
+// CheckPendingBookingAvailability checks a pending booking's slot is still free to confirm.
+// Pending bookings do not hold their slot, so another booking may have taken it since.
+// The advance booking limit is waived if it was when the booking was made.
+func (db *DB) CheckPendingBookingAvailability(b *FacilityBooking, audience string) error {
+	return db.checkAvailability(b.FacilityID, b.StartTime, b.EndTime, &b.ID, audience, b.AdvanceLimitWaived)
+}
+
+func (db *DB) checkAvailability(facilityID uuid.UUID, startTime, endTime time.Time, excludeBookingID *uuid.UUID, audience string, waiveAdvanceLimit bool) error {
 	facility, err := db.GetFacilityByID(facilityID)
 	if err != nil {
 		return fmt.Errorf("failed to get facility: %w", err)
@@ -28,34 +80,23 @@ func (db *DB) CheckAvailability(facilityID uuid.UUID, startTime, endTime time.Ti
 
 	// Check 1: Facility must be active
 	if !facility.IsActive {
-		return fmt.Errorf("facility is not active")
+		return availabilityErrorf(AvailabilityFacilityUnavailable, "facility is not active")
 	}
-
-	// Check 2: Duration constraints
-	duration := int(endTime.Sub(startTime).Minutes())
-	if duration < facility.MinBookingDurationMinutes {
-		return fmt.Errorf("booking duration %d minutes is less than minimum %d minutes",
-			duration, facility.MinBookingDurationMinutes)
+	if !facility.Bookable {
+		return availabilityErrorf(AvailabilityFacilityUnavailable, "facility is currently unavailable for new bookings")
 	}
-	if duration > facility.MaxBookingDurationMinutes {
-		return fmt.Errorf("booking duration %d minutes exceeds maximum %d minutes",
-			duration, facility.MaxBookingDurationMinutes)
-	}
-
-	// Check 3: Advance booking constraint
-	now := time.Now()
-	maxAdvanceDate := now.AddDate(0, 0, facility.AdvanceBookingDays)
-	if startTime.After(maxAdvanceDate) {
-		return fmt.Errorf("cannot book more than %d days in advance", facility.AdvanceBookingDays)
+	// Existing bookings can still be moved while the facility is unpublished
+	if excludeBookingID == nil && !IsPublished(facility.PublishedAt, facility.UnpublishedAt, time.Now()) {
+		return availabilityErrorf(AvailabilityFacilityUnavailable, "facility is not published")
 	}
 
-	// Check 4: Cannot book in the past
-	if startTime.Before(now) {
-		return fmt.Errorf("cannot book in the past")
+	// Checks 2-4: Duration, advance booking limit, not in the past and before the cutoff
+	if err := checkBookingTimes(facility, startTime, endTime, time.Now(), waiveAdvanceLimit); err != nil {
+		return err
 	}
 
 	// Check 5: Within facility availability windows
-	if err := db.checkWithinAvailabilityWindows(facilityID, startTime, endTime); err != nil {
+	if err := db.checkWithinAvailabilityWindows(facility, startTime, endTime, audience); err != nil {
 		return err
 	}
 
@@ -64,53 +105,87 @@ func (db *DB) CheckAvailability(facilityID uuid.UUID, startTime, endTime time.Ti
 		return err
 	}
 
-	// Check 7: No conflicting bookings (includes buffer time)
-	if err := db.checkNoConflictingBookings(facilityID, startTime, endTime, facility.BufferMinutes); err != nil {
+	// Check 7: No conflicting bookings (includes buffer time). A facility split into units
+	// is free while any of its units is; the unit is chosen when the booking is made.
+	unitID, err := db.AllocateUnit(facility, nil, startTime, endTime, excludeBookingID)
+	if err != nil {
 		return err
 	}
+	if unitID == nil {
+		if err := db.checkNoConflictingBookings(facilityID, startTime, endTime, facility.BufferMinutes, facility.ConcurrentBookingLimit(), excludeBookingID); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
 
+// checkBookingTimes checks the booking's duration against the facility's limits, that it
+// starts no more than the facility's advance booking days after now, unless the limit is
+// waived, that it does not start in the past and that its day has not passed the
+// facility's booking cutoff
+func checkBookingTimes(facility *Facility, startTime, endTime, now time.Time, waiveAdvanceLimit bool) error {
+	duration := int(endTime.Sub(startTime).Minutes())
+	if duration < facility.MinBookingDurationMinutes {
+		return availabilityErrorf(AvailabilityDurationTooShort, "booking duration %d minutes is less than minimum %d minutes",
+			duration, facility.MinBookingDurationMinutes)
+	}
+	if duration > facility.MaxBookingDurationMinutes {
+		return availabilityErrorf(AvailabilityDurationTooLong, "booking duration %d minutes exceeds maximum %d minutes",
+			duration, facility.MaxBookingDurationMinutes)
+	}
+
+	maxAdvanceDate := now.AddDate(0, 0, facility.AdvanceBookingDays)
+	if !waiveAdvanceLimit && startTime.After(maxAdvanceDate) {
+		return availabilityErrorf(AvailabilityTooFarInAdvance, "cannot book more than %d days in advance", facility.AdvanceBookingDays)
+	}
+
+	if startTime.Before(now) {
+		return availabilityErrorf(AvailabilityInPast, "cannot book in the past")
+	}
+
+	if cutoff, ok := facility.BookingCutoff(startTime); ok && !now.Before(cutoff) {
+		return availabilityErrorf(AvailabilityPastCutoff, "bookings for %s closed at %s the day before",
+			startTime.In(cutoff.Location()).Format("Monday, January 2"), cutoff.Format("3:04 PM"))
+	}
+	return nil
+}
+
 // checkWithinAvailabilityWindows checks if the time slot falls within availability windows
-func (db *DB) checkWithinAvailabilityWindows(facilityID uuid.UUID, startTime, endTime time.Time) error {
+// open to the audience
+func (db *DB) checkWithinAvailabilityWindows(facility *Facility, startTime, endTime time.Time, audience string) error {
 	// Get all availability windows for the facility
-	windows, err := db.GetAvailabilityWindows(facilityID)
+	windows, err := db.GetAvailabilityWindows(facility.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get availability windows: %w", err)
 	}
 
 	if len(windows) == 0 {
-		return fmt.Errorf("facility has no availability windows configured")
+		return availabilityErrorf(AvailabilityOutsideWindow, "facility has no availability windows configured")
 	}
+	return withinAvailabilityWindows(windowsOpenTo(windows, audience), startTime, endTime, facility.TimeLocation())
+}
 
+// withinAvailabilityWindows checks that every day of the time range, as seen in loc, is
+// covered by one of the windows; window times are wall-clock times in loc
+func withinAvailabilityWindows(windows []AvailabilityWindow, startTime, endTime time.Time, loc *time.Location) error {
 	// Check each day in the booking range
-	currentDate := startTime
+	currentDate := startTime.In(loc)
 	for currentDate.Before(endTime) {
 		dayOfWeek := int(currentDate.Weekday())
-		dayStart := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+		dayStart := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, loc)
 		dayEnd := dayStart.AddDate(0, 0, 1)
 
 		// Find applicable windows for this day
 		var applicableWindows []AvailabilityWindow
 		for _, window := range windows {
-			if window.DayOfWeek != dayOfWeek {
-				continue
-			}
-
-			// Check effective date range
-			if window.EffectiveFrom != nil && currentDate.Before(*window.EffectiveFrom) {
-				continue
-			}
-			if window.EffectiveUntil != nil && currentDate.After(*window.EffectiveUntil) {
-				continue
+			if window.DayOfWeek == dayOfWeek && windowInEffect(window, dayStart) {
+				applicableWindows = append(applicableWindows, window)
 			}
-
-			applicableWindows = append(applicableWindows, window)
 		}
 
 		if len(applicableWindows) == 0 {
-			return fmt.Errorf("facility is not available on %s", currentDate.Weekday())
+			return availabilityErrorf(AvailabilityOutsideWindow, "facility is not available on %s", currentDate.Weekday())
 		}
 
 		// Check if the booking time on this day falls within any window
@@ -125,28 +200,11 @@ func (db *DB) checkWithinAvailabilityWindows(facilityID uuid.UUID, startTime, en
 
 		withinWindow := false
 		for _, window := range applicableWindows {
-			// Parse window times
-			windowStart, err := time.Parse("15:04:05", window.StartTime)
-			if err != nil {
-				return fmt.Errorf("invalid window start time: %w", err)
-			}
-			windowEnd, err := time.Parse("15:04:05", window.EndTime)
+			windowStartTime, windowEndTime, err := window.Bounds(dayStart)
 			if err != nil {
-				return fmt.Errorf("invalid window end time: %w", err)
+				continue
 			}
 
-			// Convert to actual timestamps for this day
-			windowStartTime := time.Date(
-				currentDate.Year(), currentDate.Month(), currentDate.Day(),
-				windowStart.Hour(), windowStart.Minute(), windowStart.Second(),
-				0, currentDate.Location(),
-			)
-			windowEndTime := time.Date(
-				currentDate.Year(), currentDate.Month(), currentDate.Day(),
-				windowEnd.Hour(), windowEnd.Minute(), windowEnd.Second(),
-				0, currentDate.Location(),
-			)
-
 			// Check if booking falls within this window
 			if !bookingStart.Before(windowStartTime) && !bookingEnd.After(windowEndTime) {
 				withinWindow = true
@@ -155,7 +213,7 @@ func (db *DB) checkWithinAvailabilityWindows(facilityID uuid.UUID, startTime, en
 		}
 
 		if !withinWindow {
-			return fmt.Errorf("booking time is outside facility availability hours on %s",
+			return availabilityErrorf(AvailabilityOutsideWindow, "booking time is outside facility availability hours on %s",
 				currentDate.Format("Monday, January 2"))
 		}
 
@@ -172,50 +230,130 @@ func (db *DB) checkNotDuringClosure(facilityID uuid.UUID, startTime, endTime tim
 	if err != nil {
 		return fmt.Errorf("failed to get closures: %w", err)
 	}
+	return closureConflict(closures, startTime, endTime)
+}
 
-	for _, closure := range closures {
+// closureConflict returns an AvailabilityClosure error for the first closure overlapping
+// the time range
+func closureConflict(closures []FacilityClosure, startTime, endTime time.Time) error {
+	for i, closure := range closures {
 		// Check if there's any overlap
 		if startTime.Before(closure.EndTime) && endTime.After(closure.StartTime) {
 			reason := "scheduled closure"
 			if closure.Reason != nil {
 				reason = *closure.Reason
 			}
-			return fmt.Errorf("facility is closed during this time: %s", reason)
+			e := availabilityErrorf(AvailabilityClosure, "facility is closed during this time: %s", reason)
+			e.Closure = &closures[i]
+			return e
 		}
 	}
 
 	return nil
 }
 
-// checkNoConflictingBookings checks for overlapping confirmed bookings
-func (db *DB) checkNoConflictingBookings(facilityID uuid.UUID, startTime, endTime time.Time, bufferMinutes int) error {
+// checkNoConflictingBookings checks for overlapping bookings that hold their slot,
+// optionally ignoring one booking. The slot is taken once limit bookings are in progress at the same time.
+func (db *DB) checkNoConflictingBookings(facilityID uuid.UUID, startTime, endTime time.Time, bufferMinutes, limit int, excludeBookingID *uuid.UUID) error {
 	// Add buffer time to the check
 	checkStart := startTime.Add(-time.Duration(bufferMinutes) * time.Minute)
 	checkEnd := endTime.Add(time.Duration(bufferMinutes) * time.Minute)
 
 	query := `
-		SELECT COUNT(*)
+		SELECT start_time, end_time
 		FROM facility_bookings
 		WHERE facility_id = $1
-			AND status = 'confirmed'
+			AND ` + holdingSlotSQL + `
 			AND start_time < $3
 			AND end_time > $2
+			AND ($4::uuid IS NULL OR id <> $4)
 	`
 
-	var count int
-	err := db.QueryRow(query, facilityID, checkStart, checkEnd).Scan(&count)
+	rows, err := db.Query(query, facilityID, checkStart, checkEnd, excludeBookingID)
 	if err != nil {
 		return fmt.Errorf("failed to check for conflicts: %w", err)
 	}
+	defer rows.Close()
 
-	if count > 0 {
-		if bufferMinutes > 0 {
-			return fmt.Errorf("time slot conflicts with existing booking (including %d minute buffer)", bufferMinutes)
+	var bookings []FacilityBooking
+	for rows.Next() {
+		var b FacilityBooking
+		if err := rows.Scan(&b.StartTime, &b.EndTime); err != nil {
+			return fmt.Errorf("failed to scan booking: %w", err)
 		}
-		return fmt.Errorf("time slot conflicts with existing booking")
+		bookings = append(bookings, b)
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to check for conflicts: %w", err)
 	}
 
-	return nil
+	return bookingConflict(bookings, startTime, endTime, bufferMinutes, limit)
+}
+
+// bookingConflict returns an AvailabilityConflict error when limit of the bookings, widened
+// by the buffer, are in progress at once during the time range
+func bookingConflict(bookings []FacilityBooking, startTime, endTime time.Time, bufferMinutes, limit int) error {
+	if peakConcurrentBookings(bookings, startTime, endTime, bufferMinutes) < limit {
+		return nil
+	}
+	if limit > 1 {
+		return availabilityErrorf(AvailabilityConflict, "time slot is fully booked (%d concurrent bookings allowed)", limit)
+	}
+	if bufferMinutes > 0 {
+		return availabilityErrorf(AvailabilityConflict, "time slot conflicts with existing booking (including %d minute buffer)", bufferMinutes)
+	}
+	return availabilityErrorf(AvailabilityConflict, "time slot conflicts with existing booking")
+}
+
+// ConcurrentBookingLimit returns how many bookings the facility takes at the same time:
+// its max_concurrent_bookings, more than one for a shared facility such as a pavilion.
+// Capacity is a headcount and does not limit bookings.
+func (f *Facility) ConcurrentBookingLimit() int {
+	if f.MaxConcurrentBookings > 1 {
+		return f.MaxConcurrentBookings
+	}
+	return 1
+}
+
+// peakConcurrentBookings returns the most bookings, each widened by the buffer, that are
+// in progress at the same moment between start and end
+func peakConcurrentBookings(bookings []FacilityBooking, start, end time.Time, bufferMinutes int) int {
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	buffer := time.Duration(bufferMinutes) * time.Minute
+	var edges []edge
+	for _, b := range bookings {
+		from, to := b.StartTime.Add(-buffer), b.EndTime.Add(buffer)
+		if !from.Before(end) || !to.After(start) {
+			continue
+		}
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		edges = append(edges, edge{from, 1}, edge{to, -1})
+	}
+
+	// A booking ending as another starts does not overlap it, so ends sort first
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+
+	current, peak := 0, 0
+	for _, e := range edges {
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
+	return peak
 }
 
 // GetAvailableSlots returns all available time slots for a facility within a date range
@@ -232,6 +370,11 @@ func (db *DB) GetAvailableSlots(query AvailabilityQuery) ([]AvailabilitySlot, er
 		return nil, fmt.Errorf("facility is not active")
 	}
 
+	// A non-bookable facility has no open slots, but is not an error
+	if !facility.Bookable {
+		return []AvailabilitySlot{}, nil
+	}
+
 	// Get availability windows
 	windows, err := db.GetAvailabilityWindows(query.FacilityID)
 	if err != nil {
@@ -241,122 +384,238 @@ func (db *DB) GetAvailableSlots(query AvailabilityQuery) ([]AvailabilitySlot, er
 	if len(windows) == 0 {
 		return []AvailabilitySlot{}, nil
 	}
+	windows = windowsOpenTo(windows, query.Audience)
+
+	// The range covers whole calendar days in the facility's time zone
+	loc := facility.TimeLocation()
+	rangeStart := facilityDay(query.StartDate, loc)
+	rangeEnd := facilityDay(query.EndDate, loc)
 
 	// Get all closures in range
-	closures, err := db.GetClosures(query.FacilityID, query.StartDate, query.EndDate)
+	closures, err := db.GetClosures(query.FacilityID, rangeStart, rangeEnd)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get closures: %w", err)
 	}
 
-	// Get all confirmed bookings in range
-	bookings, err := db.GetBookings(&query.FacilityID, nil, &query.StartDate, &query.EndDate, "confirmed")
+	// Get all bookings holding their slot in range
+	bookings, err := db.GetBookings(&query.FacilityID, nil, &rangeStart, &rangeEnd, BookingStatusHoldingSlot, nil, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get bookings: %w", err)
 	}
 
+	units, err := db.GetFacilityUnits(query.FacilityID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var participantBusy *busyIndex
+	if query.ParticipantID != nil {
+		participantBusy, err = db.participantBookingPeriods(*query.ParticipantID, rangeStart, rangeEnd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Generate all potential slots based on availability windows
 	var allSlots []AvailabilitySlot
-	currentDate := query.StartDate
-	for currentDate.Before(query.EndDate) {
-		dayOfWeek := int(currentDate.Weekday())
+	currentDate := rangeStart
+	for currentDate.Before(rangeEnd) {
+		allSlots = append(allSlots, windowSlotsForDay(facility, windows, currentDate, query.Duration)...)
 
-		// Find applicable windows for this day
-		for _, window := range windows {
-			if window.DayOfWeek != dayOfWeek {
-				continue
-			}
+		// Move to next day
+		currentDate = currentDate.AddDate(0, 0, 1)
+	}
 
-			// Check effective date range
-			if window.EffectiveFrom != nil && currentDate.Before(*window.EffectiveFrom) {
-				continue
-			}
-			if window.EffectiveUntil != nil && currentDate.After(*window.EffectiveUntil) {
-				continue
+	// Filter out slots that conflict with closures or bookings, or with the participant's
+	// own bookings
+	checker := newSlotChecker(facility, closures, bookings, units)
+	var availableSlots []AvailabilitySlot
+	for _, slot := range allSlots {
+		if participantBusy != nil && participantBusy.overlaps(slot.StartTime, slot.EndTime) {
+			continue
+		}
+		if checker.open(&slot) {
+			availableSlots = append(availableSlots, slot)
+		}
+	}
+
+	return availableSlots, nil
+}
+
+// participantBookingPeriods indexes the participant's confirmed bookings at any facility
+// that overlap the time range. The participant cannot be in two places at once, so other
+// facilities' buffers do not apply.
+func (db *DB) participantBookingPeriods(participantID uuid.UUID, from, to time.Time) (*busyIndex, error) {
+	rows, err := db.Query(`
+		SELECT start_time, end_time
+		FROM facility_bookings
+		WHERE $1 = ANY(participant_ids)
+			AND status = 'confirmed'
+			AND start_time < $3
+			AND end_time > $2
+	`, participantID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participant bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []busyPeriod
+	for rows.Next() {
+		var p busyPeriod
+		if err := rows.Scan(&p.start, &p.end); err != nil {
+			return nil, fmt.Errorf("failed to scan participant booking: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get participant bookings: %w", err)
+	}
+
+	return newBusyIndex(periods), nil
+}
+
+// GetNextAvailableSlot returns the earliest free slot of the given duration starting at or
+// after the given time in windows open to the audience, scanning one day at a time up to
+// the advance booking limit. Returns nil if no slot is available.
+func (db *DB) GetNextAvailableSlot(facilityID uuid.UUID, after time.Time, duration int, audience string) (*AvailabilitySlot, error) {
+	facility, err := db.GetFacilityByID(facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility: %w", err)
+	}
+	if facility == nil {
+		return nil, fmt.Errorf("facility not found")
+	}
+
+	if !facility.IsActive {
+		return nil, fmt.Errorf("facility is not active")
+	}
+	if !facility.Bookable {
+		return nil, nil
+	}
+
+	windows, err := db.GetAvailabilityWindows(facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get availability windows: %w", err)
+	}
+	windows = windowsOpenTo(windows, audience)
+	if len(windows) == 0 {
+		return nil, nil
+	}
+
+	units, err := db.GetFacilityUnits(facilityID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAdvanceDate := time.Now().AddDate(0, 0, facility.AdvanceBookingDays)
+	loc := facility.TimeLocation()
+	day := facilityDay(after.In(loc), loc)
+	for !day.After(maxAdvanceDate) {
+		dayEnd := day.AddDate(0, 0, 1)
+
+		var candidates []AvailabilitySlot
+		for _, slot := range windowSlotsForDay(facility, windows, day, duration) {
+			if !slot.StartTime.Before(after) {
+				candidates = append(candidates, slot)
 			}
+		}
 
-			// Parse window times
-			windowStart, err := time.Parse("15:04:05", window.StartTime)
+		if len(candidates) > 0 {
+			closures, err := db.GetClosures(facilityID, day, dayEnd)
 			if err != nil {
-				continue
+				return nil, fmt.Errorf("failed to get closures: %w", err)
 			}
-			windowEnd, err := time.Parse("15:04:05", window.EndTime)
+
+			// Bookings ending shortly after midnight can still block early slots through the buffer
+			buffer := time.Duration(facility.BufferMinutes) * time.Minute
+			rangeStart := day.Add(-buffer)
+			rangeEnd := dayEnd.Add(buffer)
+			bookings, err := db.GetBookings(&facilityID, nil, &rangeStart, &rangeEnd, BookingStatusHoldingSlot, nil, nil)
 			if err != nil {
-				continue
+				return nil, fmt.Errorf("failed to get bookings: %w", err)
 			}
 
-			// Convert to actual timestamps for this day
-			windowStartTime := time.Date(
-				currentDate.Year(), currentDate.Month(), currentDate.Day(),
-				windowStart.Hour(), windowStart.Minute(), windowStart.Second(),
-				0, currentDate.Location(),
-			)
-			windowEndTime := time.Date(
-				currentDate.Year(), currentDate.Month(), currentDate.Day(),
-				windowEnd.Hour(), windowEnd.Minute(), windowEnd.Second(),
-				0, currentDate.Location(),
-			)
-
-			// Generate slots within this window
-			slotStart := windowStartTime
-			for slotStart.Add(time.Duration(query.Duration) * time.Minute).Before(windowEndTime) ||
-				slotStart.Add(time.Duration(query.Duration)*time.Minute).Equal(windowEndTime) {
-
-				slotEnd := slotStart.Add(time.Duration(query.Duration) * time.Minute)
-
-				// Check if slot is in the future
-				if slotStart.After(time.Now()) {
-					// Check if slot is within advance booking limit
-					maxAdvanceDate := time.Now().AddDate(0, 0, facility.AdvanceBookingDays)
-					if slotStart.Before(maxAdvanceDate) || slotStart.Equal(maxAdvanceDate) {
-						allSlots = append(allSlots, AvailabilitySlot{
-							StartTime: slotStart,
-							EndTime:   slotEnd,
-						})
-					}
+			sort.Slice(candidates, func(i, j int) bool {
+				return candidates[i].StartTime.Before(candidates[j].StartTime)
+			})
+			checker := newSlotChecker(facility, closures, bookings, units)
+			for _, slot := range candidates {
+				if checker.open(&slot) {
+					return &slot, nil
 				}
-
-				// Move to next potential slot (using minimum booking duration as increment)
-				slotStart = slotStart.Add(time.Duration(facility.MinBookingDurationMinutes) * time.Minute)
 			}
 		}
 
-		// Move to next day
-		currentDate = currentDate.AddDate(0, 0, 1)
+		day = dayEnd
 	}
 
-	// Filter out slots that conflict with closures or bookings
-	var availableSlots []AvailabilitySlot
-	for _, slot := range allSlots {
-		available := true
+	return nil, nil
+}
 
-		// Check closures
-		for _, closure := range closures {
-			if slot.StartTime.Before(closure.EndTime) && slot.EndTime.After(closure.StartTime) {
-				available = false
-				break
-			}
+// windowsOpenTo returns the windows the audience may book
+func windowsOpenTo(windows []AvailabilityWindow, audience string) []AvailabilityWindow {
+	var open []AvailabilityWindow
+	for _, window := range windows {
+		if window.OpenTo(audience) {
+			open = append(open, window)
 		}
+	}
+	return open
+}
+
+// facilityDay returns midnight in loc on the calendar date of t, so dates parsed without
+// a zone are taken as the facility's own dates
+func facilityDay(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
 
-		if !available {
+// windowSlotsForDay generates the bookable slots of the given duration on a single day
+// from the facility's availability windows. currentDate is midnight of the day in the
+// facility's time zone. Slots in the past or beyond the advance booking limit are
+// skipped, as is the whole day once its booking cutoff has passed; closures and bookings
+// are not considered.
+func windowSlotsForDay(facility *Facility, windows []AvailabilityWindow, currentDate time.Time, duration int) []AvailabilitySlot {
+	var slots []AvailabilitySlot
+	dayOfWeek := int(currentDate.Weekday())
+	now := time.Now()
+	maxAdvanceDate := now.AddDate(0, 0, facility.AdvanceBookingDays)
+	if cutoff, ok := facility.BookingCutoff(currentDate); ok && !now.Before(cutoff) {
+		return nil
+	}
+
+	// Find applicable windows for this day
+	for _, window := range windows {
+		if window.DayOfWeek != dayOfWeek || !windowInEffect(window, currentDate) {
 			continue
 		}
 
-		// Check bookings (with buffer)
-		bufferDuration := time.Duration(facility.BufferMinutes) * time.Minute
-		for _, booking := range bookings {
-			bookingStart := booking.StartTime.Add(-bufferDuration)
-			bookingEnd := booking.EndTime.Add(bufferDuration)
+		windowStartTime, windowEndTime, err := window.Bounds(currentDate)
+		if err != nil {
+			continue
+		}
 
-			if slot.StartTime.Before(bookingEnd) && slot.EndTime.After(bookingStart) {
-				available = false
-				break
+		// Generate slots within this window
+		slotStart := windowStartTime
+		for slotStart.Add(time.Duration(duration) * time.Minute).Before(windowEndTime) ||
+			slotStart.Add(time.Duration(duration)*time.Minute).Equal(windowEndTime) {
+
+			slotEnd := slotStart.Add(time.Duration(duration) * time.Minute)
+
+			// Check if slot is in the future
+			if slotStart.After(now) {
+				// Check if slot is within advance booking limit
+				if slotStart.Before(maxAdvanceDate) || slotStart.Equal(maxAdvanceDate) {
+					slots = append(slots, AvailabilitySlot{
+						StartTime: slotStart,
+						EndTime:   slotEnd,
+					})
+				}
 			}
-		}
 
-		if available {
-			availableSlots = append(availableSlots, slot)
+			// Move to next potential slot (using minimum booking duration as increment)
+			slotStart = slotStart.Add(time.Duration(facility.MinBookingDurationMinutes) * time.Minute)
 		}
 	}
 
-	return availableSlots, nil
+	return slots
 }
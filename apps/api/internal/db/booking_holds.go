@@ -0,0 +1,85 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BookingHoldDuration is how long a reserved booking holds its slot while the user
+// confirms it, at facilities that require confirmation
+const BookingHoldDuration = 5 * time.Minute
+
+// BookingStatusHoldingSlot is a GetBookings status filter matching the bookings that hold
+// their slot against others: confirmed bookings and holds that have not expired
+const BookingStatusHoldingSlot = "holding_slot"
+
+// holdingSlotSQL matches facility_bookings rows that hold their slot
+const holdingSlotSQL = `(status = 'confirmed' OR (status = 'held' AND hold_expires_at > NOW()))`
+
+// HoldActive reports whether a booking is a hold that still has its slot
+func (b *FacilityBooking) HoldActive(now time.Time) bool {
+	return b.Status == "held" && b.HoldExpiresAt != nil && now.Before(*b.HoldExpiresAt)
+}
+
+// ConfirmBookingHold turns an unexpired hold into a booking: confirmed, or pending at a
+// facility that requires approval. Like a booking made in one step, a confirmed one-off
+// booking queues a BOOKING_CONFIRMED email and its calendar sync.
+func (db *DB) ConfirmBookingHold(id uuid.UUID) (*FacilityBooking, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	var seriesID, programID *uuid.UUID
+	err = tx.QueryRow(`
+		UPDATE facility_bookings b SET
+			status = CASE WHEN f.requires_approval THEN 'pending' ELSE 'confirmed' END,
+			hold_expires_at = NULL,
+			updated_at = NOW()
+		FROM facilities f
+		WHERE b.id = $1 AND f.id = b.facility_id
+			AND b.status = 'held' AND b.hold_expires_at > NOW()
+		RETURNING b.status, b.series_id, b.program_id
+	`, id).Scan(&status, &seriesID, &programID)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("booking hold not found or expired")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm booking hold: %w", err)
+	}
+
+	if err := queueCalendarSync(tx, "booking", id); err != nil {
+		return nil, err
+	}
+	if status == "confirmed" && seriesID == nil && programID == nil {
+		if err := queueBookingNotificationInTx(tx, "BOOKING_CONFIRMED", map[string]interface{}{"booking_id": id.String()}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return db.GetBooking(id)
+}
+
+// ExpireBookingHolds marks holds whose time ran out as expired and returns how many.
+// Expired holds stop holding their slot as soon as their time is up; this only tidies
+// their status.
+func (db *DB) ExpireBookingHolds() (int64, error) {
+	result, err := db.Exec(`
+		UPDATE facility_bookings
+		SET status = 'expired', updated_at = NOW()
+		WHERE status = 'held' AND hold_expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to expire booking holds: %w", err)
+	}
+	return result.RowsAffected()
+}
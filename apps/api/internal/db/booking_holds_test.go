@@ -0,0 +1,126 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestHoldActive(t *testing.T) {
+	now := time.Now()
+	later, earlier := now.Add(time.Minute), now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		booking FacilityBooking
+		active  bool
+	}{
+		{"unexpired hold", FacilityBooking{Status: "held", HoldExpiresAt: &later}, true},
+		{"lapsed hold", FacilityBooking{Status: "held", HoldExpiresAt: &earlier}, false},
+		{"hold without expiry", FacilityBooking{Status: "held"}, false},
+		{"confirmed booking", FacilityBooking{Status: "confirmed", HoldExpiresAt: &later}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.booking.HoldActive(now); got != tt.active {
+			t.Errorf("%s: HoldActive = %v, want %v", tt.name, got, tt.active)
+		}
+	}
+}
+
+// TestBookingHold tests a hold blocks its slot until it expires, and confirming it makes
+// a confirmed booking with its confirmation email
+func TestBookingHold(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	var facilityID uuid.UUID
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, capacity, requires_confirmation)
+		VALUES ($1, 'Test Court', 'court', 1, true)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'booking_id' IN (SELECT id::text FROM facility_bookings WHERE facility_id = $1)`, facilityID)
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	start := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	hold := func(start time.Time, expires time.Time) *FacilityBooking {
+		t.Helper()
+		b, err := db.CreateBooking(&FacilityBooking{FacilityID: facilityID, UserID: userID, StartTime: start, EndTime: start.Add(time.Hour), Status: "held", HoldExpiresAt: &expires})
+		if err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+		return b
+	}
+	confirmations := func(bookingID uuid.UUID) int {
+		return countRows(t, db, `SELECT COUNT(*) FROM notification_queue WHERE type = 'BOOKING_CONFIRMED' AND payload->>'booking_id' = $1`, bookingID.String())
+	}
+
+	active := hold(start, time.Now().Add(BookingHoldDuration))
+	if err := db.checkNoConflictingBookings(facilityID, start, start.Add(time.Hour), 0, 1, nil); err == nil {
+		t.Error("slot with an unexpired hold is available, want conflict")
+	}
+	if n := confirmations(active.ID); n != 0 {
+		t.Errorf("hold queued %d confirmation emails, want none until confirmed", n)
+	}
+
+	lapsedStart := start.Add(2 * time.Hour)
+	lapsed := hold(lapsedStart, time.Now().Add(-time.Minute))
+	if err := db.checkNoConflictingBookings(facilityID, lapsedStart, lapsedStart.Add(time.Hour), 0, 1, nil); err != nil {
+		t.Errorf("slot with a lapsed hold unavailable: %v", err)
+	}
+	if _, err := db.ConfirmBookingHold(lapsed.ID); err == nil {
+		t.Error("confirmed a lapsed hold, want error")
+	}
+
+	confirmed, err := db.ConfirmBookingHold(active.ID)
+	if err != nil {
+		t.Fatalf("ConfirmBookingHold: %v", err)
+	}
+	if confirmed.Status != "confirmed" || confirmed.HoldExpiresAt != nil {
+		t.Errorf("confirmed hold has status %q and expiry %v, want confirmed without expiry", confirmed.Status, confirmed.HoldExpiresAt)
+	}
+	if n := confirmations(active.ID); n != 1 {
+		t.Errorf("confirming the hold queued %d confirmation emails, want 1", n)
+	}
+	if _, err := db.ConfirmBookingHold(active.ID); err == nil {
+		t.Error("confirmed a hold twice, want error")
+	}
+
+	if _, err := db.ExpireBookingHolds(); err != nil {
+		t.Fatalf("ExpireBookingHolds: %v", err)
+	}
+	if b, _ := db.GetBooking(lapsed.ID); b == nil || b.Status != "expired" {
+		t.Errorf("lapsed hold = %+v, want expired", b)
+	}
+	if b, _ := db.GetBooking(active.ID); b == nil || b.Status != "confirmed" {
+		t.Errorf("confirmed booking = %+v, want it left confirmed", b)
+	}
+
+	// Cancelling a hold releases its slot at once instead of waiting for it to lapse
+	releasedStart := start.Add(4 * time.Hour)
+	released := hold(releasedStart, time.Now().Add(BookingHoldDuration))
+	if err := db.CancelBooking(released.ID, userID, nil, nil, false, nil); err != nil {
+		t.Fatalf("CancelBooking of a hold: %v", err)
+	}
+	if err := db.checkNoConflictingBookings(facilityID, releasedStart, releasedStart.Add(time.Hour), 0, 1, nil); err != nil {
+		t.Errorf("slot of a cancelled hold unavailable: %v", err)
+	}
+}
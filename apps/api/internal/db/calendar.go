@@ -0,0 +1,350 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GoogleCalendarConnection is a user's opt-in link to their Google Calendar
+type GoogleCalendarConnection struct {
+	UserID                uuid.UUID  `json:"user_id"`
+	RefreshTokenEncrypted *string    `json:"-"`
+	CalendarID            string     `json:"calendar_id"`
+	ConnectedAt           time.Time  `json:"connected_at"`
+	RevokedAt             *time.Time `json:"revoked_at,omitempty"`
+}
+
+// CalendarSync is a pending push of one registration or booking to a user's calendar
+type CalendarSync struct {
+	ID         int64
+	UserID     uuid.UUID
+	EntityType string // 'registration' or 'booking'
+	EntityID   uuid.UUID
+	Attempts   int
+	QueuedAt   time.Time
+}
+
+// CalendarOccurrence is one calendar event a registration or booking should appear as.
+// Key is stable across syncs: the session ID for session-based registrations, or ”.
+type CalendarOccurrence struct {
+	Key         string
+	Summary     string
+	Location    *string
+	Description string
+	StartsAt    time.Time
+	EndsAt      *time.Time
+}
+
+// execer is satisfied by both *DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// calendarSyncUsers selects the users who see an entity on their calendar: everyone in
+// the participant's household for a registration, the booker for a booking
+var calendarSyncUsers = map[string]string{
+	"registration": `
+		SELECT h.owner_user_id FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		JOIN households h ON h.id = p.household_id
+		WHERE r.id = $2
+		UNION
+		SELECT hm.user_id FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		JOIN household_members hm ON hm.household_id = p.household_id
+		WHERE r.id = $2
+	`,
+	"booking": `SELECT user_id FROM facility_bookings WHERE id = $2`,
+}
+
+// queueCalendarSync queues a registration or booking to be pushed to the calendars of
+// connected users who can see it. Users without a connection are skipped, so this is a
+// no-op for most changes.
+func queueCalendarSync(e execer, entityType string, entityID uuid.UUID) error {
+	_, err := e.Exec(`
+		INSERT INTO calendar_sync_queue (user_id, entity_type, entity_id)
+		SELECT gc.user_id, $1::text, $2::uuid
+		FROM google_calendar_connections gc
+		WHERE gc.revoked_at IS NULL AND gc.user_id IN (`+calendarSyncUsers[entityType]+`)
+		ON CONFLICT (user_id, entity_type, entity_id) DO UPDATE
+		SET attempts = 0, last_error = NULL, not_before_ts = NULL, created_at = now()
+	`, entityType, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to queue calendar sync: %w", err)
+	}
+	return nil
+}
+
+// SaveGoogleCalendarConnection stores a user's encrypted refresh token, reconnecting a
+// previously revoked connection, and queues their upcoming registrations and bookings so
+// the calendar starts out complete
+func (db *DB) SaveGoogleCalendarConnection(userID uuid.UUID, refreshTokenEncrypted string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO google_calendar_connections (user_id, refresh_token_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET refresh_token_encrypted = EXCLUDED.refresh_token_encrypted,
+			connected_at = now(),
+			revoked_at = NULL
+	`, userID, refreshTokenEncrypted)
+	if err != nil {
+		return fmt.Errorf("failed to save google calendar connection: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO calendar_sync_queue (user_id, entity_type, entity_id)
+		SELECT $1::uuid, 'registration', r.id
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		JOIN households h ON h.id = p.household_id
+		WHERE r.status = 'confirmed' AND h.deleted_at IS NULL
+			AND (h.owner_user_id = $1 OR h.id IN (SELECT household_id FROM household_members WHERE user_id = $1))
+			AND (
+				EXISTS (
+					SELECT 1 FROM sessions s
+					WHERE s.parent_type = r.parent_type AND s.parent_id = r.parent_id AND s.starts_at > now()
+				)
+				OR EXISTS (SELECT 1 FROM events e WHERE r.parent_type = 'event' AND e.id = r.parent_id AND e.starts_at > now())
+			)
+		UNION ALL
+		SELECT $1, 'booking', b.id
+		FROM facility_bookings b
+		WHERE b.user_id = $1 AND b.status = 'confirmed' AND b.start_time > now()
+		ON CONFLICT (user_id, entity_type, entity_id) DO NOTHING
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to queue calendar sync: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetGoogleCalendarConnection retrieves a user's Google Calendar connection, including a
+// revoked one
+func (db *DB) GetGoogleCalendarConnection(userID uuid.UUID) (*GoogleCalendarConnection, error) {
+	var gc GoogleCalendarConnection
+	err := db.QueryRow(`
+		SELECT user_id, refresh_token_encrypted, calendar_id, connected_at, revoked_at
+		FROM google_calendar_connections
+		WHERE user_id = $1
+	`, userID).Scan(&gc.UserID, &gc.RefreshTokenEncrypted, &gc.CalendarID, &gc.ConnectedAt, &gc.RevokedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get google calendar connection: %w", err)
+	}
+	return &gc, nil
+}
+
+// RevokeGoogleCalendarConnection disconnects a user's Google Calendar: the refresh token
+// is discarded and pending pushes and event mappings are dropped. Events already in the
+// user's calendar are left there.
+func (db *DB) RevokeGoogleCalendarConnection(userID uuid.UUID) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE google_calendar_connections
+		SET revoked_at = now(), refresh_token_encrypted = NULL
+		WHERE user_id = $1 AND revoked_at IS NULL
+	`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke google calendar connection: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("google calendar connection not found")
+	}
+
+	if _, err := tx.Exec(`DELETE FROM calendar_sync_queue WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear calendar sync queue: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM google_calendar_events WHERE user_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear google calendar events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPendingCalendarSyncs returns queued calendar pushes that are due, oldest first
+func (db *DB) GetPendingCalendarSyncs(limit int) ([]CalendarSync, error) {
+	rows, err := db.Query(`
+		SELECT id, user_id, entity_type, entity_id, attempts, created_at
+		FROM calendar_sync_queue
+		WHERE attempts < max_attempts
+			AND (not_before_ts IS NULL OR not_before_ts <= now())
+		ORDER BY created_at ASC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calendar sync queue: %w", err)
+	}
+	defer rows.Close()
+
+	syncs := []CalendarSync{}
+	for rows.Next() {
+		var s CalendarSync
+		if err := rows.Scan(&s.ID, &s.UserID, &s.EntityType, &s.EntityID, &s.Attempts, &s.QueuedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar sync: %w", err)
+		}
+		syncs = append(syncs, s)
+	}
+
+	return syncs, rows.Err()
+}
+
+// CompleteCalendarSync removes a processed push from the queue. A push that was queued
+// again while it was being processed is kept so the newer change is not lost.
+func (db *DB) CompleteCalendarSync(s CalendarSync) error {
+	_, err := db.Exec(`DELETE FROM calendar_sync_queue WHERE id = $1 AND created_at = $2`, s.ID, s.QueuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to complete calendar sync: %w", err)
+	}
+	return nil
+}
+
+// FailCalendarSync records a failed push attempt and when to retry it
+func (db *DB) FailCalendarSync(s CalendarSync, lastError string, retryAt time.Time) error {
+	_, err := db.Exec(`
+		UPDATE calendar_sync_queue
+		SET attempts = attempts + 1, last_error = $3, not_before_ts = $4
+		WHERE id = $1 AND created_at = $2
+	`, s.ID, s.QueuedAt, lastError, retryAt)
+	if err != nil {
+		return fmt.Errorf("failed to record calendar sync failure: %w", err)
+	}
+	return nil
+}
+
+// GetCalendarOccurrences returns the calendar events a registration or booking should
+// appear as. Registrations that are not confirmed and bookings that are not confirmed
+// have none, so their existing events get removed.
+func (db *DB) GetCalendarOccurrences(entityType string, entityID uuid.UUID) ([]CalendarOccurrence, error) {
+	var query string
+	switch entityType {
+	case "registration":
+		// Same occurrences as the family schedule: the registered session, every active
+		// session of the parent, or the event's own times when it has no sessions
+		query = `
+			SELECT COALESCE(s.id::text, ''),
+				COALESCE(prog.title, ev.title) || ' - ' || p.first_name || ' ' || p.last_name,
+				COALESCE(prog.location, ev.location),
+				'Registration for ' || p.first_name || ' ' || p.last_name,
+				COALESCE(s.starts_at, ev.starts_at), COALESCE(s.ends_at, ev.ends_at)
+			FROM registrations r
+			JOIN participants p ON p.id = r.participant_id
+			LEFT JOIN programs prog ON r.parent_type = 'program' AND prog.id = r.parent_id
+			LEFT JOIN events ev ON r.parent_type = 'event' AND ev.id = r.parent_id
+			LEFT JOIN sessions s ON (r.session_id IS NOT NULL AND s.id = r.session_id)
+				OR (r.session_id IS NULL AND s.parent_type = r.parent_type AND s.parent_id = r.parent_id AND s.is_active = true)
+			WHERE r.id = $1 AND r.status = 'confirmed'
+				AND COALESCE(s.starts_at, ev.starts_at) IS NOT NULL
+			ORDER BY 5
+		`
+	case "booking":
+		query = `
+			SELECT '', f.name || ' booking', f.location,
+				'Facility booking' || COALESCE(': ' || b.notes, ''),
+				b.start_time, b.end_time
+			FROM facility_bookings b
+			JOIN facilities f ON f.id = b.facility_id
+			WHERE b.id = $1 AND b.status = 'confirmed'
+		`
+	default:
+		return nil, fmt.Errorf("unknown calendar entity type: %s", entityType)
+	}
+
+	rows, err := db.Query(query, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar occurrences: %w", err)
+	}
+	defer rows.Close()
+
+	occurrences := []CalendarOccurrence{}
+	for rows.Next() {
+		var o CalendarOccurrence
+		if err := rows.Scan(&o.Key, &o.Summary, &o.Location, &o.Description, &o.StartsAt, &o.EndsAt); err != nil {
+			return nil, fmt.Errorf("failed to scan calendar occurrence: %w", err)
+		}
+		occurrences = append(occurrences, o)
+	}
+
+	return occurrences, rows.Err()
+}
+
+// GetGoogleCalendarEvents returns the Google event IDs already created for an entity in a
+// user's calendar, keyed by occurrence key
+func (db *DB) GetGoogleCalendarEvents(userID uuid.UUID, entityType string, entityID uuid.UUID) (map[string]string, error) {
+	rows, err := db.Query(`
+		SELECT occurrence_key, google_event_id
+		FROM google_calendar_events
+		WHERE user_id = $1 AND entity_type = $2 AND entity_id = $3
+	`, userID, entityType, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get google calendar events: %w", err)
+	}
+	defer rows.Close()
+
+	events := map[string]string{}
+	for rows.Next() {
+		var key, eventID string
+		if err := rows.Scan(&key, &eventID); err != nil {
+			return nil, fmt.Errorf("failed to scan google calendar event: %w", err)
+		}
+		events[key] = eventID
+	}
+
+	return events, rows.Err()
+}
+
+// SaveGoogleCalendarEvent records the Google event created for an occurrence
+func (db *DB) SaveGoogleCalendarEvent(userID uuid.UUID, entityType string, entityID uuid.UUID, occurrenceKey, googleEventID string) error {
+	_, err := db.Exec(`
+		INSERT INTO google_calendar_events (user_id, entity_type, entity_id, occurrence_key, google_event_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, entity_type, entity_id, occurrence_key) DO UPDATE
+		SET google_event_id = EXCLUDED.google_event_id, updated_at = now()
+	`, userID, entityType, entityID, occurrenceKey, googleEventID)
+	if err != nil {
+		return fmt.Errorf("failed to save google calendar event: %w", err)
+	}
+	return nil
+}
+
+// DeleteGoogleCalendarEvent forgets the Google event for an occurrence
+func (db *DB) DeleteGoogleCalendarEvent(userID uuid.UUID, entityType string, entityID uuid.UUID, occurrenceKey string) error {
+	_, err := db.Exec(`
+		DELETE FROM google_calendar_events
+		WHERE user_id = $1 AND entity_type = $2 AND entity_id = $3 AND occurrence_key = $4
+	`, userID, entityType, entityID, occurrenceKey)
+	if err != nil {
+		return fmt.Errorf("failed to delete google calendar event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProgramForm represents the assignment of a form template to a program
+type ProgramForm struct {
+	ID             uuid.UUID `json:"id"`
+	ProgramID      uuid.UUID `json:"program_id"`
+	FormTemplateID uuid.UUID `json:"form_template_id"`
+	IsRequired     bool      `json:"is_required"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	// Joined fields
+	FormTemplate *FormTemplate `json:"form_template,omitempty"`
+}
+
+// AssignFormToProgram assigns a form template to a program
+func (db *DB) AssignFormToProgram(pf *ProgramForm) (*ProgramForm, error) {
+	err := db.QueryRow(`
+		INSERT INTO program_forms (program_id, form_template_id, is_required)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (program_id, form_template_id) DO UPDATE
+		SET is_required = EXCLUDED.is_required
+		RETURNING id, created_at
+	`, pf.ProgramID, pf.FormTemplateID, pf.IsRequired).Scan(&pf.ID, &pf.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign form to program: %w", err)
+	}
+
+	return pf, nil
+}
+
+// GetProgramForms retrieves the active form templates assigned to a program
+func (db *DB) GetProgramForms(programID uuid.UUID) ([]ProgramForm, error) {
+	rows, err := db.Query(`
+		SELECT pf.id, pf.program_id, pf.form_template_id, pf.is_required, pf.created_at,
+		       ft.id, ft.type, ft.title, ft.description, ft.schema_json, ft.version, ft.is_active, ft.created_at, ft.updated_at
+		FROM program_forms pf
+		JOIN form_templates ft ON pf.form_template_id = ft.id
+		WHERE pf.program_id = $1 AND ft.is_active = true
+		ORDER BY pf.is_required DESC, ft.title ASC
+	`, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query program forms: %w", err)
+	}
+	defer rows.Close()
+
+	var programForms []ProgramForm
+	for rows.Next() {
+		var pf ProgramForm
+		var ft FormTemplate
+		err := rows.Scan(
+			&pf.ID, &pf.ProgramID, &pf.FormTemplateID, &pf.IsRequired, &pf.CreatedAt,
+			&ft.ID, &ft.Type, &ft.Title, &ft.Description, &ft.SchemaJSON, &ft.Version, &ft.IsActive, &ft.CreatedAt, &ft.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan program form: %w", err)
+		}
+		pf.FormTemplate = &ft
+		programForms = append(programForms, pf)
+	}
+
+	return programForms, nil
+}
+
+// RemoveFormFromProgram removes a form template assignment from a program
+func (db *DB) RemoveFormFromProgram(programID, formTemplateID uuid.UUID) error {
+	result, err := db.Exec(`DELETE FROM program_forms WHERE program_id = $1 AND form_template_id = $2`, programID, formTemplateID)
+	if err != nil {
+		return fmt.Errorf("failed to remove form from program: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("program form assignment not found")
+	}
+
+	return nil
+}
+
+// ComplianceRequirement is a required waiver or form in a compliance report
+type ComplianceRequirement struct {
+	ID      uuid.UUID `json:"id"`
+	Title   string    `json:"title"`
+	Version int       `json:"version"`
+}
+
+// RequirementStatus is whether a participant has completed one requirement
+type RequirementStatus struct {
+	ID       uuid.UUID `json:"id"`
+	Complete bool      `json:"complete"`
+}
+
+// ParticipantCompliance is one participant's row in a compliance report
+type ParticipantCompliance struct {
+	ParticipantID uuid.UUID           `json:"participant_id"`
+	Name          string              `json:"name"`
+	Waivers       []RequirementStatus `json:"waivers"`
+	Forms         []RequirementStatus `json:"forms"`
+	Compliant     bool                `json:"compliant"`
+}
+
+// ProgramCompliance lists which confirmed participants have accepted the current version
+// of each required waiver and submitted the current version of each required form
+type ProgramCompliance struct {
+	ProgramID    uuid.UUID               `json:"program_id"`
+	Waivers      []ComplianceRequirement `json:"waivers"`
+	Forms        []ComplianceRequirement `json:"forms"`
+	Participants []ParticipantCompliance `json:"participants"`
+}
+
+// GetProgramCompliance builds the compliance report for participants with a confirmed
+// registration for the program or any of its sessions, ordered by name. Per-season
+// waivers only count when accepted for this program.
+func (db *DB) GetProgramCompliance(programID uuid.UUID) (*ProgramCompliance, error) {
+	report := &ProgramCompliance{
+		ProgramID:    programID,
+		Waivers:      []ComplianceRequirement{},
+		Forms:        []ComplianceRequirement{},
+		Participants: []ParticipantCompliance{},
+	}
+
+	programWaivers, err := db.GetProgramWaivers(programID)
+	if err != nil {
+		return nil, err
+	}
+	var waivers []ProgramWaiver
+	for _, pw := range programWaivers {
+		if pw.IsRequired {
+			waivers = append(waivers, pw)
+			report.Waivers = append(report.Waivers, ComplianceRequirement{pw.WaiverID, pw.Waiver.Title, pw.Waiver.Version})
+		}
+	}
+
+	programForms, err := db.GetProgramForms(programID)
+	if err != nil {
+		return nil, err
+	}
+	var forms []ProgramForm
+	for _, pf := range programForms {
+		if pf.IsRequired {
+			forms = append(forms, pf)
+			report.Forms = append(report.Forms, ComplianceRequirement{pf.FormTemplateID, pf.FormTemplate.Title, pf.FormTemplate.Version})
+		}
+	}
+
+	rows, err := db.Query(`
+		SELECT DISTINCT p.id, p.first_name || ' ' || p.last_name AS name
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		LEFT JOIN sessions s ON s.id = r.session_id
+		WHERE r.status = 'confirmed'
+			AND ((r.parent_type = 'program' AND r.parent_id = $1)
+				OR (s.parent_type = 'program' AND s.parent_id = $1))
+		ORDER BY name
+	`, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program participants: %w", err)
+	}
+	for rows.Next() {
+		var pc ParticipantCompliance
+		if err := rows.Scan(&pc.ParticipantID, &pc.Name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan participant: %w", err)
+		}
+		report.Participants = append(report.Participants, pc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get program participants: %w", err)
+	}
+
+	for i := range report.Participants {
+		pc := &report.Participants[i]
+		pc.Waivers = []RequirementStatus{}
+		pc.Forms = []RequirementStatus{}
+		pc.Compliant = true
+
+		for _, pw := range waivers {
+			accepted, err := db.waiverComplete(pc.ParticipantID, programID, pw)
+			if err != nil {
+				return nil, err
+			}
+			pc.Waivers = append(pc.Waivers, RequirementStatus{pw.WaiverID, accepted})
+			pc.Compliant = pc.Compliant && accepted
+		}
+
+		for _, pf := range forms {
+			current, err := db.formComplete(pc.ParticipantID, pf)
+			if err != nil {
+				return nil, err
+			}
+			pc.Forms = append(pc.Forms, RequirementStatus{pf.FormTemplateID, current})
+			pc.Compliant = pc.Compliant && current
+		}
+	}
+
+	return report, nil
+}
+
+// waiverComplete reports whether the participant has accepted the current version of a
+// program waiver; per-season waivers only count when accepted for this program
+func (db *DB) waiverComplete(participantID, programID uuid.UUID, pw ProgramWaiver) (bool, error) {
+	var forProgram *uuid.UUID
+	if pw.IsPerSeason {
+		forProgram = &programID
+	}
+	return db.CheckParticipantWaiverStatus(participantID, pw.WaiverID, pw.Waiver.Version, forProgram)
+}
+
+// GetMissingProgramForms returns the program's required forms the participant has not
+// submitted at their current version
+func (db *DB) GetMissingProgramForms(programID, participantID uuid.UUID) ([]ComplianceRequirement, error) {
+	programForms, err := db.GetProgramForms(programID)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []ComplianceRequirement
+	for _, pf := range programForms {
+		if !pf.IsRequired {
+			continue
+		}
+		current, err := db.formComplete(participantID, pf)
+		if err != nil {
+			return nil, err
+		}
+		if !current {
+			missing = append(missing, ComplianceRequirement{pf.FormTemplateID, pf.FormTemplate.Title, pf.FormTemplate.Version})
+		}
+	}
+	return missing, nil
+}
+
+// formComplete reports whether the participant has submitted the current version of a
+// program form
+func (db *DB) formComplete(participantID uuid.UUID, pf ProgramForm) (bool, error) {
+	submission, err := db.GetParticipantFormByTemplate(participantID, pf.FormTemplateID)
+	if err != nil {
+		return false, err
+	}
+	return submission != nil && submission.FormVersion == pf.FormTemplate.Version, nil
+}
+
+// Registration completion states tell the family what, if anything, they still have to
+// do for a registration
+const (
+	CompletionComplete           = "complete"
+	CompletionAwaitingPayment    = "awaiting_payment" // not produced until payments are collected
+	CompletionAwaitingWaivers    = "awaiting_waivers"
+	CompletionAwaitingForms      = "awaiting_forms"
+	CompletionWaitlisted         = "waitlisted"
+	CompletionAwaitingAcceptance = "awaiting_acceptance" // offered a spot from the waitlist
+	CompletionPendingApproval    = "pending_approval"
+	CompletionCancelled          = "cancelled"
+)
+
+// RegistrationCompletionState derives a registration's completion state from its status
+// and whether the participant has completed the program's required waivers and forms.
+// Waitlisted, pending and cancelled registrations report their status, as there is
+// nothing for the family to do yet, and an offered spot has to be accepted before
+// anything else; waivers are asked for before forms.
+func RegistrationCompletionState(status string, waiversDone, formsDone bool) string {
+	switch {
+	case status == "waitlisted":
+		return CompletionWaitlisted
+	case status == "offered":
+		return CompletionAwaitingAcceptance
+	case status == "pending":
+		return CompletionPendingApproval
+	case status == "cancelled":
+		return CompletionCancelled
+	case !waiversDone:
+		return CompletionAwaitingWaivers
+	case !formsDone:
+		return CompletionAwaitingForms
+	}
+	return CompletionComplete
+}
+
+// SetCompletionStates fills in each registration's CompletionState, loading each
+// program's required waivers and forms once. Events have no requirements.
+func (db *DB) SetCompletionStates(regs ...*Registration) error {
+	type requirements struct {
+		waivers []ProgramWaiver
+		forms   []ProgramForm
+	}
+	programs := map[uuid.UUID]*requirements{}
+
+	for _, r := range regs {
+		waiversDone, formsDone := true, true
+		if r.ParentType == "program" && (r.Status == "confirmed" || r.Status == "paused") {
+			req, ok := programs[r.ParentID]
+			if !ok {
+				req = &requirements{}
+				programWaivers, err := db.GetProgramWaivers(r.ParentID)
+				if err != nil {
+					return err
+				}
+				for _, pw := range programWaivers {
+					if pw.IsRequired {
+						req.waivers = append(req.waivers, pw)
+					}
+				}
+				programForms, err := db.GetProgramForms(r.ParentID)
+				if err != nil {
+					return err
+				}
+				for _, pf := range programForms {
+					if pf.IsRequired {
+						req.forms = append(req.forms, pf)
+					}
+				}
+				programs[r.ParentID] = req
+			}
+
+			var err error
+			for _, pw := range req.waivers {
+				if !waiversDone {
+					break
+				}
+				if waiversDone, err = db.waiverComplete(r.ParticipantID, r.ParentID, pw); err != nil {
+					return err
+				}
+			}
+			// Forms only decide the state once the waivers are done
+			for _, pf := range req.forms {
+				if !waiversDone || !formsDone {
+					break
+				}
+				if formsDone, err = db.formComplete(r.ParticipantID, pf); err != nil {
+					return err
+				}
+			}
+		}
+		r.CompletionState = RegistrationCompletionState(r.Status, waiversDone, formsDone)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestProgramCompliance tests the waiver and form completion report for a program roster
+func TestProgramCompliance(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+	result := registerTestParticipant(t, db, programID, nil)
+	participantID := result.Registration.ParticipantID
+
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id WHERE p.id = $1
+	`, participantID).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to get test parent: %v", err)
+	}
+
+	waiver, err := db.CreateWaiver(&Waiver{Title: "Test Waiver " + uuid.New().String(), BodyHTML: "<p>Test</p>", Version: 2, IsActive: true})
+	if err != nil {
+		t.Fatalf("CreateWaiver: %v", err)
+	}
+	form, err := db.CreateFormTemplate(&FormTemplate{Type: "medical", Title: "Test Form", SchemaJSON: json.RawMessage(`{"fields":[]}`), Version: 3, IsActive: true})
+	if err != nil {
+		t.Fatalf("CreateFormTemplate: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM participant_waiver_acceptances WHERE waiver_id = $1`, waiver.ID)
+		db.Exec(`DELETE FROM participant_form_submissions WHERE form_template_id = $1`, form.ID)
+		db.Exec(`DELETE FROM program_waivers WHERE waiver_id = $1`, waiver.ID)
+		db.Exec(`DELETE FROM waivers WHERE id = $1`, waiver.ID)
+		db.Exec(`DELETE FROM form_templates WHERE id = $1`, form.ID)
+	})
+
+	if _, err := db.AssignWaiverToProgram(&ProgramWaiver{ProgramID: programID, WaiverID: waiver.ID, IsRequired: true}); err != nil {
+		t.Fatalf("AssignWaiverToProgram: %v", err)
+	}
+	if _, err := db.AssignFormToProgram(&ProgramForm{ProgramID: programID, FormTemplateID: form.ID, IsRequired: true}); err != nil {
+		t.Fatalf("AssignFormToProgram: %v", err)
+	}
+
+	check := func(wantWaiver, wantForm bool, wantState string) {
+		t.Helper()
+		report, err := db.GetProgramCompliance(programID)
+		if err != nil {
+			t.Fatalf("GetProgramCompliance: %v", err)
+		}
+		if len(report.Waivers) != 1 || len(report.Forms) != 1 || len(report.Participants) != 1 {
+			t.Fatalf("report has %d waivers, %d forms, %d participants; want 1 each",
+				len(report.Waivers), len(report.Forms), len(report.Participants))
+		}
+		p := report.Participants[0]
+		if p.Waivers[0].Complete != wantWaiver || p.Forms[0].Complete != wantForm || p.Compliant != (wantWaiver && wantForm) {
+			t.Errorf("participant = %+v, want waiver %v, form %v", p, wantWaiver, wantForm)
+		}
+
+		reg := *result.Registration
+		if err := db.SetCompletionStates(&reg); err != nil {
+			t.Fatalf("SetCompletionStates: %v", err)
+		}
+		if reg.CompletionState != wantState {
+			t.Errorf("completion state = %q, want %q", reg.CompletionState, wantState)
+		}
+	}
+
+	check(false, false, CompletionAwaitingWaivers)
+
+	// Accepting an old waiver version or submitting an old form version does not count
+	db.AcceptWaiver(&ParticipantWaiverAcceptance{ParticipantID: participantID, WaiverID: waiver.ID, WaiverVersion: 1, AcceptedByUserID: userID})
+	db.SaveParticipantForm(&ParticipantFormSubmission{ParticipantID: participantID, FormTemplateID: form.ID, FormVersion: 2, DataJSON: json.RawMessage(`{}`), SubmittedByUserID: userID})
+	check(false, false, CompletionAwaitingWaivers)
+
+	db.AcceptWaiver(&ParticipantWaiverAcceptance{ParticipantID: participantID, WaiverID: waiver.ID, WaiverVersion: 2, AcceptedByUserID: userID})
+	check(true, false, CompletionAwaitingForms)
+
+	db.SaveParticipantForm(&ParticipantFormSubmission{ParticipantID: participantID, FormTemplateID: form.ID, FormVersion: 3, DataJSON: json.RawMessage(`{}`), SubmittedByUserID: userID})
+	check(true, true, CompletionComplete)
+
+	// Participants registered for one of the program's sessions are on the report too
+	sessionID := createTestSession(t, db, programID, nil)
+	registerTestParticipant(t, db, programID, &sessionID)
+	report, err := db.GetProgramCompliance(programID)
+	if err != nil {
+		t.Fatalf("GetProgramCompliance: %v", err)
+	}
+	if len(report.Participants) != 2 {
+		t.Errorf("report has %d participants, want 2 including the session registration", len(report.Participants))
+	}
+}
+
+// TestRegistrationCompletionState tests status takes precedence over requirements, and
+// waivers over forms
+func TestRegistrationCompletionState(t *testing.T) {
+	tests := []struct {
+		status  string
+		waivers bool
+		forms   bool
+		want    string
+	}{
+		{"confirmed", true, true, CompletionComplete},
+		{"paused", true, true, CompletionComplete},
+		{"confirmed", false, false, CompletionAwaitingWaivers},
+		{"confirmed", true, false, CompletionAwaitingForms},
+		{"waitlisted", false, false, CompletionWaitlisted},
+		{"offered", true, true, CompletionAwaitingAcceptance},
+		{"pending", true, true, CompletionPendingApproval},
+		{"cancelled", false, true, CompletionCancelled},
+	}
+
+	for _, tt := range tests {
+		if got := RegistrationCompletionState(tt.status, tt.waivers, tt.forms); got != tt.want {
+			t.Errorf("RegistrationCompletionState(%q, %v, %v) = %q, want %q", tt.status, tt.waivers, tt.forms, got, tt.want)
+		}
+	}
+}
@@ -8,12 +8,28 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	_ "github.com/lib/pq"
 )
 
 type DB struct {
 	*sql.DB
+
+	// slowQueryThreshold is the duration above which queries are logged; 0 disables logging
+	slowQueryThreshold time.Duration
+
+	// promotionNotify schedules the emails sent when waitlisted registrations are promoted
+	promotionNotify PromotionNotifyConfig
+
+	// removeOptedOut cancels waitlisted registrations that opted out of promotion when
+	// their turn comes, instead of passing over them and keeping their place
+	removeOptedOut bool
+
+	// offerWindow is how long a waitlisted registration promoted into an open spot has to
+	// accept it; 0 confirms promotions straight away
+	offerWindow time.Duration
 }
 
 func NewDB() (*DB, error) {
@@ -27,6 +43,11 @@ func NewDB() (*DB, error) {
 	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		host, port, user, password, dbname, sslmode)
 
+	return Open(connStr)
+}
+
+// Open connects to the database described by a lib/pq connection string or URL
+func Open(connStr string) (*DB, error) {
 	sqlDB, err := sql.Open("postgres", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
@@ -42,7 +63,18 @@ func NewDB() (*DB, error) {
 
 	log.Println("Database connection established")
 
-	return &DB{sqlDB}, nil
+	threshold := slowQueryThresholdFromEnv()
+	if threshold > 0 {
+		log.Printf("Logging queries slower than %v", threshold)
+	}
+
+	return &DB{
+		DB:                 sqlDB,
+		slowQueryThreshold: threshold,
+		promotionNotify:    promotionNotifyConfigFromEnv(),
+		removeOptedOut:     os.Getenv("WAITLIST_REMOVE_OPTED_OUT") == "true",
+		offerWindow:        waitlistOfferWindowFromEnv(),
+	}, nil
 }
 
 func (db *DB) RunMigrations(migrationsPath string) error {
@@ -122,11 +154,29 @@ func (db *DB) Seed() error {
 	}
 
 	if count > 0 {
-		log.Println("Database already seeded, skipping")
-		return nil
+		log.Println("Programs and events already seeded, skipping")
+	} else {
+		log.Println("Seeding database with sample data...")
+		if err := db.seedProgramsAndEvents(); err != nil {
+			return err
+		}
 	}
 
-	log.Println("Seeding database with sample data...")
+	adminID, err := db.seedAdminUser()
+	if err != nil {
+		return err
+	}
+
+	if err := db.seedFacilities(adminID); err != nil {
+		return err
+	}
+
+	log.Println("Database seeded successfully")
+	return nil
+}
+
+// seedProgramsAndEvents inserts the sample programs and events
+func (db *DB) seedProgramsAndEvents() error {
 
 	// Create sample programs
 	programs := []struct {
@@ -215,10 +265,179 @@ func (db *DB) Seed() error {
 		}
 	}
 
-	log.Println("Database seeded successfully")
 	return nil
 }
 
+// seedAdminUser creates the sample admin account if it does not already exist
+func (db *DB) seedAdminUser() (uuid.UUID, error) {
+	const email = "admin@sterling.local"
+
+	existing, err := db.GetUserByEmail(email)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if existing != nil {
+		log.Printf("Admin user %s already exists, skipping", email)
+		return existing.ID, nil
+	}
+
+	user, err := db.CreateUser(email, "admin123", "Sterling", "Admin", nil)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to seed admin user: %w", err)
+	}
+
+	_, err = db.Exec("UPDATE users SET role = 'admin' WHERE id = $1", user.ID)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to grant admin role: %w", err)
+	}
+
+	log.Printf("Seeded admin user %s (password: admin123)", email)
+	return user.ID, nil
+}
+
+// seedFacilities creates sample facilities with weekly availability windows
+// and a single confirmed booking. Facilities are matched by slug so the
+// routine can be re-run safely.
+func (db *DB) seedFacilities(bookingUserID uuid.UUID) error {
+	facilities := []struct {
+		facility Facility
+		windows  []AvailabilityWindow
+	}{
+		{
+			facility: Facility{
+				Slug:                      "community-gym",
+				Name:                      "Community Center Gym",
+				Description:               strPtr("Full-size indoor basketball/volleyball court."),
+				FacilityType:              "court",
+				Location:                  strPtr("Sterling Community Center"),
+				Capacity:                  intPtr(60),
+				MaxConcurrentBookings:     1,
+				MinBookingDurationMinutes: 60,
+				MaxBookingDurationMinutes: 180,
+				BufferMinutes:             15,
+				AdvanceBookingDays:        30,
+				CancellationCutoffHours:   24,
+				IsActive:                  true,
+				Bookable:                  true,
+			},
+			// Weekdays 4pm-9pm, Saturday 8am-6pm
+			windows: append(weeklyWindows([]int{1, 2, 3, 4, 5}, "16:00:00", "21:00:00"),
+				weeklyWindows([]int{6}, "08:00:00", "18:00:00")...),
+		},
+		{
+			facility: Facility{
+				Slug:                      "tennis-court-1",
+				Name:                      "Tennis Court 1",
+				Description:               strPtr("Outdoor hard court with lights."),
+				FacilityType:              "court",
+				Location:                  strPtr("Sterling Town Park"),
+				Capacity:                  intPtr(4),
+				MaxConcurrentBookings:     1,
+				MinBookingDurationMinutes: 30,
+				MaxBookingDurationMinutes: 120,
+				BufferMinutes:             0,
+				AdvanceBookingDays:        14,
+				CancellationCutoffHours:   12,
+				IsActive:                  true,
+				Bookable:                  true,
+			},
+			// Every day 7am-9pm
+			windows: weeklyWindows([]int{0, 1, 2, 3, 4, 5, 6}, "07:00:00", "21:00:00"),
+		},
+	}
+
+	for _, sf := range facilities {
+		existing, err := db.GetFacilityBySlug(sf.facility.Slug)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			log.Printf("Facility %s already exists, skipping", sf.facility.Slug)
+			continue
+		}
+
+		f := sf.facility
+		created, err := db.CreateFacility(&f)
+		if err != nil {
+			return fmt.Errorf("failed to seed facility %s: %w", f.Slug, err)
+		}
+
+		for _, w := range sf.windows {
+			w.FacilityID = created.ID
+			if _, err := db.CreateAvailabilityWindow(&w); err != nil {
+				return fmt.Errorf("failed to seed availability window for %s: %w", f.Slug, err)
+			}
+		}
+	}
+
+	// Sample booking: next weekday the gym is open, 6pm-7pm local server time
+	var bookingCount int
+	err := db.QueryRow("SELECT COUNT(*) FROM facility_bookings").Scan(&bookingCount)
+	if err != nil {
+		return fmt.Errorf("failed to check existing bookings: %w", err)
+	}
+	if bookingCount > 0 {
+		log.Println("Facility bookings already seeded, skipping")
+		return nil
+	}
+
+	gym, err := db.GetFacilityBySlug("community-gym")
+	if err != nil {
+		return err
+	}
+	if gym == nil {
+		log.Println("Sample gym facility not found, skipping sample booking")
+		return nil
+	}
+
+	day := time.Now().AddDate(0, 0, 1)
+	for day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+		day = day.AddDate(0, 0, 1)
+	}
+	start := time.Date(day.Year(), day.Month(), day.Day(), 18, 0, 0, 0, time.Local)
+
+	household, err := db.GetUserHousehold(bookingUserID)
+	if err != nil {
+		return err
+	}
+	var householdID *uuid.UUID
+	if household != nil {
+		householdID = &household.ID
+	}
+
+	_, err = db.CreateBooking(&FacilityBooking{
+		FacilityID:  gym.ID,
+		UserID:      bookingUserID,
+		HouseholdID: householdID,
+		StartTime:   start.UTC(),
+		EndTime:     start.Add(time.Hour).UTC(),
+		Status:      "confirmed",
+		Notes:       strPtr("Sample booking created by seed"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to seed booking: %w", err)
+	}
+
+	return nil
+}
+
+// weeklyWindows builds one availability window per given day of week
+func weeklyWindows(days []int, startTime, endTime string) []AvailabilityWindow {
+	windows := make([]AvailabilityWindow, 0, len(days))
+	for _, d := range days {
+		windows = append(windows, AvailabilityWindow{
+			DayOfWeek: d,
+			StartTime: startTime,
+			EndTime:   endTime,
+		})
+	}
+	return windows
+}
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
+
 // Helper to build WHERE clauses safely
 func BuildWhereClause(conditions map[string]interface{}) (string, []interface{}) {
 	if len(conditions) == 0 {
@@ -0,0 +1,146 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+// DuplicateTitleSimilarity is how similar two normalized titles must be, from 0 to 1, for
+// a new program or event to be flagged as a possible duplicate of an existing one
+const DuplicateTitleSimilarity = 0.8
+
+// PossibleDuplicate is an active program or event whose title and dates are close enough
+// to a new one's that it may be the same catalog entry created twice
+type PossibleDuplicate struct {
+	ID         uuid.UUID  `json:"id"`
+	Slug       string     `json:"slug"`
+	Title      string     `json:"title"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	Similarity float64    `json:"similarity"`
+}
+
+// FindSimilarPrograms returns active programs with a title similar to title whose dates
+// overlap start to end. A program or new title without dates is compared on title alone.
+func (db *DB) FindSimilarPrograms(title string, start, end *time.Time) ([]PossibleDuplicate, error) {
+	return db.findSimilar(`
+		SELECT id, slug, title, start_date, end_date
+		FROM programs
+		WHERE is_active
+			AND ($1::date IS NULL OR COALESCE(end_date, start_date) IS NULL OR COALESCE(end_date, start_date) >= $1)
+			AND ($2::date IS NULL OR start_date IS NULL OR start_date <= $2)
+	`, title, start, end)
+}
+
+// FindSimilarEvents returns active events with a title similar to title held on any of
+// the days from start to end. An event or new title without dates is compared on title
+// alone.
+func (db *DB) FindSimilarEvents(title string, start, end *time.Time) ([]PossibleDuplicate, error) {
+	return db.findSimilar(`
+		SELECT id, slug, title, starts_at, ends_at
+		FROM events
+		WHERE is_active
+			AND ($1::timestamptz IS NULL OR starts_at IS NULL OR COALESCE(ends_at, starts_at)::date >= $1::date)
+			AND ($2::timestamptz IS NULL OR starts_at IS NULL OR starts_at::date <= $2::date)
+	`, title, start, end)
+}
+
+// findSimilar runs a query for candidates overlapping start to end, which default to each
+// other when only one is given, and keeps those with a similar title
+func (db *DB) findSimilar(query, title string, start, end *time.Time) ([]PossibleDuplicate, error) {
+	if end == nil {
+		end = start
+	}
+	if start == nil {
+		start = end
+	}
+
+	rows, err := db.Query(query, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query possible duplicates: %w", err)
+	}
+	defer rows.Close()
+
+	duplicates := []PossibleDuplicate{}
+	for rows.Next() {
+		var d PossibleDuplicate
+		if err := rows.Scan(&d.ID, &d.Slug, &d.Title, &d.StartsAt, &d.EndsAt); err != nil {
+			return nil, fmt.Errorf("failed to scan possible duplicate: %w", err)
+		}
+		d.Similarity = TitleSimilarity(title, d.Title)
+		if d.Similarity >= DuplicateTitleSimilarity {
+			duplicates = append(duplicates, d)
+		}
+	}
+	return duplicates, rows.Err()
+}
+
+// normalizeTitle lowercases a title and reduces punctuation and runs of spaces to single
+// spaces, so "Youth Soccer - Spring" and "youth soccer (spring)" read the same
+func normalizeTitle(title string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(title), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// TitleSimilarity scores how alike two titles are from 0 to 1, as the Dice coefficient of
+// the character pairs of their normalized forms. Titles whose numbers differ, such as
+// "Swim Level 1" and "Swim Level 2", are different offerings and score 0.
+func TitleSimilarity(a, b string) float64 {
+	a, b = normalizeTitle(a), normalizeTitle(b)
+	if a == b {
+		return 1
+	}
+	if titleNumbers(a) != titleNumbers(b) {
+		return 0
+	}
+
+	pairs := map[string]int{}
+	aPairs := titleBigrams(a)
+	for _, p := range aPairs {
+		pairs[p]++
+	}
+	bPairs := titleBigrams(b)
+	if len(aPairs)+len(bPairs) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for _, p := range bPairs {
+		if pairs[p] > 0 {
+			pairs[p]--
+			shared++
+		}
+	}
+	return float64(2*shared) / float64(len(aPairs)+len(bPairs))
+}
+
+// titleBigrams returns the adjacent character pairs of a normalized title
+func titleBigrams(title string) []string {
+	runes := []rune(title)
+	pairs := make([]string, 0, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		pairs = append(pairs, string(runes[i:i+2]))
+	}
+	return pairs
+}
+
+// titleNumbers returns the numbers in a normalized title, in order
+func titleNumbers(title string) string {
+	var numbers []string
+	for _, word := range strings.Fields(title) {
+		if strings.IndexFunc(word, unicode.IsDigit) >= 0 {
+			numbers = append(numbers, strings.Map(func(r rune) rune {
+				if unicode.IsDigit(r) {
+					return r
+				}
+				return -1
+			}, word))
+		}
+	}
+	return strings.Join(numbers, " ")
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTitleSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		similar bool
+	}{
+		{"Youth Soccer League", "Youth Soccer League", true},
+		{"Youth Soccer - Spring", "youth soccer (spring)", true},
+		{"Youth Soccer League", "Youth Socer League", true},
+		{"Summer Day Camp", "Summer Day Camps", true},
+		{"Youth Soccer League", "Adult Basketball League", false},
+		{"Swim Lessons Level 1", "Swim Lessons Level 2", false},
+		{"Tennis 101", "Tennis 101", true},
+		{"", "Yoga", false},
+	}
+
+	for _, tt := range tests {
+		got := TitleSimilarity(tt.a, tt.b)
+		if (got >= DuplicateTitleSimilarity) != tt.similar {
+			t.Errorf("TitleSimilarity(%q, %q) = %.2f, want similar = %v", tt.a, tt.b, got, tt.similar)
+		}
+		if back := TitleSimilarity(tt.b, tt.a); back != got {
+			t.Errorf("TitleSimilarity(%q, %q) = %.2f, not symmetric with %.2f", tt.b, tt.a, back, got)
+		}
+	}
+}
+
+// TestFindSimilarPrograms tests a similar title is only flagged when the dates overlap
+func TestFindSimilarPrograms(t *testing.T) {
+	db := setupTestDB(t)
+
+	title := "Duplicate Check " + uuid.New().String()[:8]
+	start := time.Date(2031, time.June, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2031, time.August, 31, 0, 0, 0, 0, time.UTC)
+
+	var id uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO programs (slug, title, capacity, start_date, end_date)
+		VALUES ($1, $2, 10, $3, $4)
+		RETURNING id
+	`, "test-program-"+uuid.New().String(), title, start, end).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create test program: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM programs WHERE id = $1`, id)
+	})
+
+	found := func(title string, start, end *time.Time) bool {
+		t.Helper()
+		duplicates, err := db.FindSimilarPrograms(title, start, end)
+		if err != nil {
+			t.Fatalf("FindSimilarPrograms: %v", err)
+		}
+		for _, d := range duplicates {
+			if d.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+
+	july := time.Date(2031, time.July, 1, 0, 0, 0, 0, time.UTC)
+	nextYear := start.AddDate(1, 0, 0)
+	if !found(title+"!", &july, nil) {
+		t.Error("similar program with overlapping dates not flagged")
+	}
+	if !found(title, nil, nil) {
+		t.Error("similar program not flagged for a new program without dates")
+	}
+	if found(title, &nextYear, nil) {
+		t.Error("similar program flagged for dates a year later")
+	}
+	if found("Something Else Entirely", &july, nil) {
+		t.Error("program with a different title flagged")
+	}
+}
@@ -0,0 +1,252 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Email delivery event types reported by the email provider
+const (
+	EmailEventDelivered = "delivered"
+	EmailEventBounce    = "bounce"
+	EmailEventComplaint = "complaint"
+)
+
+// EmailMessage is a sent email and its latest delivery status
+type EmailMessage struct {
+	ID                  uuid.UUID       `json:"id"`
+	MessageID           string          `json:"message_id"`
+	NotificationID      *int64          `json:"notification_id,omitempty"`
+	NotificationType    *string         `json:"notification_type,omitempty"`
+	NotificationPayload json.RawMessage `json:"notification_payload,omitempty"`
+	Recipient           string          `json:"recipient"`
+	UserID              *uuid.UUID      `json:"user_id,omitempty"` // account the recipient address belonged to when sent
+	Subject             string          `json:"subject"`
+	Status              string          `json:"status"` // sent, delivered, bounced or complained
+	SentAt              time.Time       `json:"sent_at"`
+	StatusUpdatedAt     *time.Time      `json:"status_updated_at,omitempty"`
+}
+
+// EmailEvent is a delivery, bounce or complaint reported for a sent email
+type EmailEvent struct {
+	MessageID  string
+	EventType  string
+	BounceType *string // hard or soft, for bounces
+	Recipient  *string
+	Detail     *string
+	OccurredAt *time.Time
+}
+
+// EmailSuppression is an address that is no longer emailed
+type EmailSuppression struct {
+	Email          string     `json:"email"`
+	Reason         string     `json:"reason"` // hard_bounce or complaint
+	Detail         *string    `json:"detail,omitempty"`
+	EmailMessageID *uuid.UUID `json:"email_message_id,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+
+	// Joined fields
+	UserID   *uuid.UUID `json:"user_id,omitempty"`
+	UserName *string    `json:"user_name,omitempty"`
+}
+
+// normalizeEmail lower-cases an address for suppression lookups
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// RecordEmailSent records a sent email under its Message-ID, against the user whose
+// address it was sent to at the time
+func (db *DB) RecordEmailSent(m *EmailMessage) error {
+	err := db.QueryRow(`
+		INSERT INTO email_messages (message_id, notification_id, notification_type, notification_payload, recipient, subject, user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, (SELECT id FROM users WHERE lower(email) = lower($5)))
+		RETURNING id, status, sent_at, user_id
+	`, m.MessageID, m.NotificationID, m.NotificationType, nullableJSON(m.NotificationPayload), m.Recipient, m.Subject).Scan(
+		&m.ID, &m.Status, &m.SentAt, &m.UserID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record sent email: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationRecipients returns the normalized addresses a notification's emails
+// have already been sent to, so a retried notification can skip them
+func (db *DB) GetNotificationRecipients(notificationID int64) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT recipient FROM email_messages WHERE notification_id = $1`, notificationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification recipients: %w", err)
+	}
+	defer rows.Close()
+
+	recipients := map[string]bool{}
+	for rows.Next() {
+		var recipient string
+		if err := rows.Scan(&recipient); err != nil {
+			return nil, fmt.Errorf("failed to scan notification recipient: %w", err)
+		}
+		recipients[normalizeEmail(recipient)] = true
+	}
+	return recipients, rows.Err()
+}
+
+// GetEmailMessage retrieves a sent email by its Message-ID
+func (db *DB) GetEmailMessage(messageID string) (*EmailMessage, error) {
+	var m EmailMessage
+	err := db.QueryRow(`
+		SELECT id, message_id, notification_id, notification_type, notification_payload,
+			recipient, user_id, subject, status, sent_at, status_updated_at
+		FROM email_messages
+		WHERE message_id = $1
+	`, messageID).Scan(
+		&m.ID, &m.MessageID, &m.NotificationID, &m.NotificationType, (*[]byte)(&m.NotificationPayload),
+		&m.Recipient, &m.UserID, &m.Subject, &m.Status, &m.SentAt, &m.StatusUpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email message: %w", err)
+	}
+	return &m, nil
+}
+
+// RecordEmailEvent stores a delivery event, updates the status of the email it refers to
+// and suppresses the recipient on a hard bounce or complaint. It reports whether the
+// Message-ID matched an email we sent; unmatched events are still stored and still
+// suppress the recipient they name.
+func (db *DB) RecordEmailEvent(e EmailEvent) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var emailMessageID *uuid.UUID
+	var recipient string
+	err = tx.QueryRow(`
+		SELECT id, recipient FROM email_messages WHERE message_id = $1 FOR UPDATE
+	`, e.MessageID).Scan(&emailMessageID, &recipient)
+	if err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to get email message: %w", err)
+	}
+	if e.Recipient != nil && *e.Recipient != "" {
+		recipient = *e.Recipient
+	}
+
+	occurredAt := time.Now()
+	if e.OccurredAt != nil {
+		occurredAt = *e.OccurredAt
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO email_events (email_message_id, message_id, event_type, bounce_type, recipient, detail, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, emailMessageID, e.MessageID, e.EventType, e.BounceType, sql.NullString{String: recipient, Valid: recipient != ""}, e.Detail, occurredAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record email event: %w", err)
+	}
+
+	status := map[string]string{
+		EmailEventDelivered: "delivered",
+		EmailEventBounce:    "bounced",
+		EmailEventComplaint: "complained",
+	}[e.EventType]
+	if emailMessageID != nil {
+		// A late delivery report never hides an earlier bounce or complaint
+		_, err = tx.Exec(`
+			UPDATE email_messages SET status = $2, status_updated_at = $3
+			WHERE id = $1 AND NOT (status IN ('bounced', 'complained') AND $2 = 'delivered')
+		`, emailMessageID, status, occurredAt)
+		if err != nil {
+			return false, fmt.Errorf("failed to update email status: %w", err)
+		}
+	}
+
+	reason := ""
+	switch {
+	case e.EventType == EmailEventComplaint:
+		reason = "complaint"
+	case e.EventType == EmailEventBounce && e.BounceType != nil && *e.BounceType == "hard":
+		reason = "hard_bounce"
+	}
+	if reason != "" && recipient != "" {
+		_, err = tx.Exec(`
+			INSERT INTO email_suppressions (email, reason, detail, email_message_id)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (email) DO NOTHING
+		`, normalizeEmail(recipient), reason, e.Detail, emailMessageID)
+		if err != nil {
+			return false, fmt.Errorf("failed to suppress email address: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return emailMessageID != nil, nil
+}
+
+// IsEmailSuppressed reports whether an address has hard-bounced or complained
+func (db *DB) IsEmailSuppressed(email string) (bool, error) {
+	var suppressed bool
+	err := db.QueryRow(`
+		SELECT EXISTS(SELECT 1 FROM email_suppressions WHERE email = $1)
+	`, normalizeEmail(email)).Scan(&suppressed)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email suppression: %w", err)
+	}
+	return suppressed, nil
+}
+
+// GetEmailSuppressions lists suppressed addresses, newest first, with the user they belong to
+func (db *DB) GetEmailSuppressions() ([]EmailSuppression, error) {
+	rows, err := db.Query(`
+		SELECT s.email, s.reason, s.detail, s.email_message_id, s.created_at,
+			u.id, u.first_name || ' ' || u.last_name
+		FROM email_suppressions s
+		LEFT JOIN users u ON lower(u.email) = s.email
+		ORDER BY s.created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email suppressions: %w", err)
+	}
+	defer rows.Close()
+
+	suppressions := []EmailSuppression{}
+	for rows.Next() {
+		var s EmailSuppression
+		err := rows.Scan(&s.Email, &s.Reason, &s.Detail, &s.EmailMessageID, &s.CreatedAt, &s.UserID, &s.UserName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan email suppression: %w", err)
+		}
+		suppressions = append(suppressions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get email suppressions: %w", err)
+	}
+	return suppressions, nil
+}
+
+// DeleteEmailSuppression lets an address be emailed again, e.g. once the family has fixed it
+func (db *DB) DeleteEmailSuppression(email string) error {
+	result, err := db.Exec(`DELETE FROM email_suppressions WHERE email = $1`, normalizeEmail(email))
+	if err != nil {
+		return fmt.Errorf("failed to delete email suppression: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("email suppression not found")
+	}
+	return nil
+}
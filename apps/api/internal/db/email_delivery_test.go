@@ -0,0 +1,120 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestEmailDeliveryEvents tests webhook events update the sent email and suppress
+// hard-bounced and complaining addresses
+func TestEmailDeliveryEvents(t *testing.T) {
+	db := setupTestDB(t)
+
+	recipient := "Test-" + uuid.New().String() + "@Example.com"
+	sent := &EmailMessage{MessageID: "<" + uuid.New().String() + "@example.com>", Recipient: recipient, Subject: "Test"}
+	if err := db.RecordEmailSent(sent); err != nil {
+		t.Fatalf("RecordEmailSent: %v", err)
+	}
+	unknownRecipient := "test-" + uuid.New().String() + "@example.com"
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM email_suppressions WHERE email IN ($1, $2)`, normalizeEmail(recipient), unknownRecipient)
+		db.Exec(`DELETE FROM email_events WHERE message_id = $1 OR recipient = $2`, sent.MessageID, unknownRecipient)
+		db.Exec(`DELETE FROM email_messages WHERE id = $1`, sent.ID)
+	})
+
+	status := func() string {
+		t.Helper()
+		m, err := db.GetEmailMessage(sent.MessageID)
+		if err != nil || m == nil {
+			t.Fatalf("GetEmailMessage = %v, %v", m, err)
+		}
+		return m.Status
+	}
+	suppressed := func(email string) bool {
+		t.Helper()
+		s, err := db.IsEmailSuppressed(email)
+		if err != nil {
+			t.Fatalf("IsEmailSuppressed: %v", err)
+		}
+		return s
+	}
+	record := func(e EmailEvent) bool {
+		t.Helper()
+		matched, err := db.RecordEmailEvent(e)
+		if err != nil {
+			t.Fatalf("RecordEmailEvent: %v", err)
+		}
+		return matched
+	}
+
+	soft, hard := "soft", "hard"
+	if !record(EmailEvent{MessageID: sent.MessageID, EventType: EmailEventBounce, BounceType: &soft}) {
+		t.Error("event for a sent email was not matched")
+	}
+	if status() != "bounced" || suppressed(recipient) {
+		t.Errorf("after a soft bounce: status %q, suppressed %v; want bounced and not suppressed", status(), suppressed(recipient))
+	}
+
+	record(EmailEvent{MessageID: sent.MessageID, EventType: EmailEventBounce, BounceType: &hard})
+	if !suppressed(recipient) || !suppressed(normalizeEmail(recipient)) {
+		t.Error("hard-bounced address was not suppressed")
+	}
+
+	record(EmailEvent{MessageID: sent.MessageID, EventType: EmailEventDelivered})
+	if status() != "bounced" {
+		t.Errorf("late delivery report changed status to %q, want bounced", status())
+	}
+
+	if record(EmailEvent{MessageID: "<unknown@example.com>", EventType: EmailEventComplaint, Recipient: &unknownRecipient}) {
+		t.Error("event for an unknown Message-ID was matched")
+	}
+	if !suppressed(unknownRecipient) {
+		t.Error("complaining address was not suppressed")
+	}
+
+	if err := db.DeleteEmailSuppression(recipient); err != nil {
+		t.Fatalf("DeleteEmailSuppression: %v", err)
+	}
+	if suppressed(recipient) {
+		t.Error("address still suppressed after removing the suppression")
+	}
+}
+
+// TestGetNotificationRecipients tests the addresses a notification was already sent to
+// are listed normalized, and only for that notification
+func TestGetNotificationRecipients(t *testing.T) {
+	db := setupTestDB(t)
+
+	var notifID int64
+	err := db.QueryRow(`
+		INSERT INTO notification_queue (type, payload)
+		VALUES ('REGISTRATION_PENDING_REVIEW', '{}')
+		RETURNING id
+	`).Scan(&notifID)
+	if err != nil {
+		t.Fatalf("failed to queue test notification: %v", err)
+	}
+
+	recipient := "Admin-" + uuid.New().String() + "@Example.com"
+	sent := &EmailMessage{MessageID: "<" + uuid.New().String() + "@example.com>", NotificationID: &notifID, Recipient: recipient, Subject: "Test"}
+	if err := db.RecordEmailSent(sent); err != nil {
+		t.Fatalf("RecordEmailSent: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM email_messages WHERE id = $1`, sent.ID)
+		db.Exec(`DELETE FROM notification_queue WHERE id = $1`, notifID)
+	})
+
+	recipients, err := db.GetNotificationRecipients(notifID)
+	if err != nil {
+		t.Fatalf("GetNotificationRecipients: %v", err)
+	}
+	if len(recipients) != 1 || !recipients[normalizeEmail(recipient)] {
+		t.Errorf("recipients = %v, want only %s", recipients, normalizeEmail(recipient))
+	}
+
+	if other, err := db.GetNotificationRecipients(notifID + 1_000_000); err != nil || len(other) != 0 {
+		t.Errorf("recipients of another notification = %v, %v; want none", other, err)
+	}
+}
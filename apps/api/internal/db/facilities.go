@@ -2,7 +2,13 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,18 +24,63 @@ type Facility struct {
 	FacilityType               string     `json:"facility_type"`
 	Location                   *string    `json:"location,omitempty"`
 	Capacity                   *int       `json:"capacity,omitempty"`
+	MaxConcurrentBookings      int        `json:"max_concurrent_bookings"` // bookings without a unit allowed at the same time
 	MinBookingDurationMinutes  int        `json:"min_booking_duration_minutes"`
 	MaxBookingDurationMinutes  int        `json:"max_booking_duration_minutes"`
 	BufferMinutes              int        `json:"buffer_minutes"`
 	AdvanceBookingDays         int        `json:"advance_booking_days"`
 	CancellationCutoffHours    int        `json:"cancellation_cutoff_hours"`
 	IsActive                   bool       `json:"is_active"`
+	Bookable                   bool       `json:"bookable"` // false = visible but closed to new bookings
 	RequiresApproval           bool       `json:"requires_approval"`
+	PublishedAt                *time.Time `json:"published_at,omitempty"`   // hidden from the public before this
+	UnpublishedAt              *time.Time `json:"unpublished_at,omitempty"` // hidden from the public from this
+	HourlyRateCents            *int       `json:"hourly_rate_cents,omitempty"` // base rate outside pricing rules; nil = free
+	AllowLateCancellation      bool       `json:"allow_late_cancellation"`     // inside the cutoff, cancel as late instead of refusing
+	LateCancellationFeePct     int        `json:"late_cancellation_fee_pct"`   // share of the price a late cancellation forfeits
+	Timezone                   string     `json:"timezone"`                    // IANA name windows are read in; empty = UTC
+	RequiresConfirmation       bool       `json:"requires_confirmation"`       // bookings start as a short hold the user must confirm
+	SameDayCutoffTime          *string    `json:"same_day_cutoff_time,omitempty"` // HH:MM:SS the day before after which a day can no longer be booked; nil = none
 	CreatedAt                  time.Time  `json:"created_at"`
 	UpdatedAt                  time.Time  `json:"updated_at"`
 
 	// Computed/joined fields
 	AvailabilityWindows []AvailabilityWindow `json:"availability_windows,omitempty"`
+	PricingRules        []PricingRule        `json:"pricing_rules,omitempty"`
+	Units               []FacilityUnit       `json:"units,omitempty"`
+}
+
+// TimeLocation returns the time zone the facility's windows, pricing rules and days are
+// read in. A facility without a time zone, or with one that cannot be loaded, uses UTC.
+func (f *Facility) TimeLocation() *time.Location {
+	if f.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(f.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// BookingCutoff returns when booking closes for slots on the facility's calendar date of
+// start: the same-day cutoff time on the day before, so staff such as lifeguards can be
+// scheduled. ok is false when the facility has no cutoff.
+func (f *Facility) BookingCutoff(start time.Time) (cutoff time.Time, ok bool) {
+	if f.SameDayCutoffTime == nil {
+		return time.Time{}, false
+	}
+	offset, err := ParseWindowTime(*f.SameDayCutoffTime)
+	if err != nil {
+		return time.Time{}, false
+	}
+	loc := f.TimeLocation()
+	return timeOnDate(facilityDay(start.In(loc), loc).AddDate(0, 0, -1), offset), true
+}
+
+// IsPublic reports whether the facility is shown to the public at the given time
+func (f *Facility) IsPublic(now time.Time) bool {
+	return f.IsActive && IsPublished(f.PublishedAt, f.UnpublishedAt, now)
 }
 
 // AvailabilityWindow represents a recurring weekly availability pattern
@@ -41,9 +92,119 @@ type AvailabilityWindow struct {
 	EndTime         string     `json:"end_time"`    // HH:MM:SS format
 	EffectiveFrom   *time.Time `json:"effective_from,omitempty"`
 	EffectiveUntil  *time.Time `json:"effective_until,omitempty"`
+	Audience        string     `json:"audience"` // public, members or staff
 	CreatedAt       time.Time  `json:"created_at"`
 }
 
+// Booking audiences, from least to most privileged. A window tagged with an audience
+// is open to that audience and every more privileged one.
+const (
+	AudiencePublic  = "public"
+	AudienceMembers = "members"
+	AudienceStaff   = "staff"
+)
+
+var audienceRank = map[string]int{
+	AudiencePublic:  0,
+	AudienceMembers: 1,
+	AudienceStaff:   2,
+}
+
+// OpenTo reports whether the window may be booked by the given audience. Untagged
+// windows are public.
+func (aw AvailabilityWindow) OpenTo(audience string) bool {
+	return audienceRank[audience] >= audienceRank[aw.Audience]
+}
+
+// Bounds returns the window's start and end as timestamps on the given date. An end
+// time of 24:00:00 is midnight at the end of the day.
+func (aw AvailabilityWindow) Bounds(date time.Time) (time.Time, time.Time, error) {
+	start, err := ParseWindowTime(aw.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid window start time: %w", err)
+	}
+	end, err := ParseWindowTime(aw.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid window end time: %w", err)
+	}
+	return timeOnDate(date, start), timeOnDate(date, end), nil
+}
+
+// ParseWindowTime parses a time of day in HH:MM or HH:MM:SS form, as written by admins
+// or read back from a TIME column, into its offset from midnight. Fractional seconds
+// are dropped. 24:00:00 is accepted as the end of the day.
+func ParseWindowTime(s string) (time.Duration, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 && len(parts) != 3 {
+		return 0, fmt.Errorf("time %q is not HH:MM or HH:MM:SS", s)
+	}
+
+	seconds, fraction := "00", ""
+	if len(parts) == 3 {
+		seconds = parts[2]
+		if i := strings.IndexByte(seconds, '.'); i >= 0 {
+			seconds, fraction = seconds[:i], seconds[i+1:]
+			if fraction == "" || !isDigits(fraction) {
+				return 0, fmt.Errorf("time %q has invalid fractional seconds", s)
+			}
+		}
+	}
+
+	h, hOK := timeField(parts[0], 1, 24)
+	m, mOK := timeField(parts[1], 2, 59)
+	sec, sOK := timeField(seconds, 2, 59)
+	if !hOK || !mOK || !sOK {
+		return 0, fmt.Errorf("time %q is not HH:MM or HH:MM:SS", s)
+	}
+	if h == 24 && (m != 0 || sec != 0 || strings.Trim(fraction, "0") != "") {
+		return 0, fmt.Errorf("time %q is past the end of the day", s)
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second, nil
+}
+
+// NormalizeWindowTime parses a time of day and returns it in canonical HH:MM:SS form
+func NormalizeWindowTime(s string) (string, error) {
+	d, err := ParseWindowTime(s)
+	if err != nil {
+		return "", err
+	}
+	return formatWindowTime(d), nil
+}
+
+func formatWindowTime(d time.Duration) string {
+	total := int(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// timeField parses a one- or two-digit time field (minDigits sets whether a single
+// digit is allowed) and checks it does not exceed max
+func timeField(s string, minDigits, max int) (int, bool) {
+	if len(s) < minDigits || len(s) > 2 || !isDigits(s) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n > max {
+		return 0, false
+	}
+	return n, true
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// timeOnDate returns the timestamp offset from midnight on the given date
+func timeOnDate(date time.Time, offset time.Duration) time.Time {
+	total := int(offset / time.Second)
+	return time.Date(date.Year(), date.Month(), date.Day(), total/3600, total/60%60, total%60, 0, date.Location())
+}
+
 // FacilityClosure represents an ad-hoc closure
 type FacilityClosure struct {
 	ID          uuid.UUID  `json:"id"`
@@ -64,12 +225,22 @@ type FacilityBooking struct {
 	ParticipantIDs      []uuid.UUID `json:"participant_ids,omitempty"`
 	StartTime           time.Time   `json:"start_time"`
 	EndTime             time.Time   `json:"end_time"`
-	Status              string      `json:"status"` // 'confirmed', 'cancelled'
+	Status              string      `json:"status"` // 'held', 'pending', 'confirmed', 'rejected', 'cancelled', 'no_show', 'expired'
 	Notes               *string     `json:"notes,omitempty"`
 	CancelledAt         *time.Time  `json:"cancelled_at,omitempty"`
 	CancelledBy         *uuid.UUID  `json:"cancelled_by,omitempty"`
-	CancellationReason  *string     `json:"cancellation_reason,omitempty"`
-	IdempotencyKey      *string     `json:"idempotency_key,omitempty"`
+	CancellationReason  *string     `json:"cancellation_reason,omitempty"` // or why a pending booking was rejected
+	CancellationReasonCode *string  `json:"cancellation_reason_code,omitempty"`
+	AdvanceLimitWaived  bool        `json:"advance_limit_waived"`
+	LateCancellation    bool        `json:"late_cancellation"` // cancelled inside the cancellation cutoff
+	LateCancellationFeeCents *int   `json:"late_cancellation_fee_cents,omitempty"`
+	IdempotencyKey      *string     `json:"-"` // request only; see idempotency_keys
+	ProgramID           *uuid.UUID  `json:"program_id,omitempty"` // set on program session reservations
+	SessionID           *uuid.UUID  `json:"session_id,omitempty"`
+	PriceCents          *int        `json:"price_cents,omitempty"` // computed when booked; nil = unpriced
+	SeriesID            *uuid.UUID  `json:"series_id,omitempty"` // shared by the bookings of a recurring series
+	UnitID              *uuid.UUID  `json:"unit_id,omitempty"`   // unit held; nil holds the whole facility
+	HoldExpiresAt       *time.Time  `json:"hold_expires_at,omitempty"` // when an unconfirmed hold gives up its slot
 	CreatedAt           time.Time   `json:"created_at"`
 	UpdatedAt           time.Time   `json:"updated_at"`
 
@@ -79,10 +250,64 @@ type FacilityBooking struct {
 	Participants []Participant  `json:"participants,omitempty"`
 }
 
+// UserBookingStats aggregates a user's bookings over a time range
+type UserBookingStats struct {
+	UserID            uuid.UUID              `json:"user_id"`
+	TotalBookings     int                    `json:"total_bookings"`
+	TotalBookedHours  float64                `json:"total_booked_hours"`
+	NoShowCount       int                    `json:"no_show_count"`
+	CancellationCount int                    `json:"cancellation_count"`
+	LateCancellationCount int                `json:"late_cancellation_count"`
+	Facilities        []FacilityBookingStats `json:"facilities"`
+}
+
+// FacilityBookingStats is the per-facility breakdown within UserBookingStats
+type FacilityBookingStats struct {
+	FacilityID        uuid.UUID `json:"facility_id"`
+	FacilityName      string    `json:"facility_name"`
+	TotalBookings     int       `json:"total_bookings"`
+	TotalBookedHours  float64   `json:"total_booked_hours"`
+	NoShowCount       int       `json:"no_show_count"`
+	CancellationCount int       `json:"cancellation_count"`
+	LateCancellationCount int   `json:"late_cancellation_count"`
+}
+
 // AvailabilitySlot represents an available time slot
 type AvailabilitySlot struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
+	FreeUnits *int      `json:"free_units,omitempty"` // for facilities split into units
+}
+
+// facilityColumns is the column list scanned by scanFacility. same_day_cutoff_time is read
+// as text, as availability window times are, since the driver returns a TIME as a date.
+const facilityColumns = `id, slug, name, description, facility_type, location, capacity, max_concurrent_bookings,
+			min_booking_duration_minutes, max_booking_duration_minutes,
+			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
+			is_active, bookable, requires_approval, published_at, unpublished_at, hourly_rate_cents,
+			allow_late_cancellation, late_cancellation_fee_pct, timezone, requires_confirmation, same_day_cutoff_time::text,
+			created_at, updated_at`
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanFacility scans a row selected with facilityColumns
+func scanFacility(row rowScanner) (*Facility, error) {
+	var f Facility
+	err := row.Scan(
+		&f.ID, &f.Slug, &f.Name, &f.Description, &f.FacilityType, &f.Location, &f.Capacity, &f.MaxConcurrentBookings,
+		&f.MinBookingDurationMinutes, &f.MaxBookingDurationMinutes,
+		&f.BufferMinutes, &f.AdvanceBookingDays, &f.CancellationCutoffHours,
+		&f.IsActive, &f.Bookable, &f.RequiresApproval, &f.PublishedAt, &f.UnpublishedAt, &f.HourlyRateCents,
+		&f.AllowLateCancellation, &f.LateCancellationFeePct, &f.Timezone, &f.RequiresConfirmation, &f.SameDayCutoffTime,
+		&f.CreatedAt, &f.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
 }
 
 // CreateFacility creates a new facility
@@ -92,8 +317,10 @@ func (db *DB) CreateFacility(f *Facility) (*Facility, error) {
 			slug, name, description, facility_type, location, capacity,
 			min_booking_duration_minutes, max_booking_duration_minutes,
 			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
-			is_active, requires_approval
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			is_active, requires_approval, bookable, published_at, unpublished_at, hourly_rate_cents,
+			allow_late_cancellation, late_cancellation_fee_pct, timezone, requires_confirmation, same_day_cutoff_time,
+			max_concurrent_bookings
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -102,7 +329,9 @@ func (db *DB) CreateFacility(f *Facility) (*Facility, error) {
 		f.Slug, f.Name, f.Description, f.FacilityType, f.Location, f.Capacity,
 		f.MinBookingDurationMinutes, f.MaxBookingDurationMinutes,
 		f.BufferMinutes, f.AdvanceBookingDays, f.CancellationCutoffHours,
-		f.IsActive, f.RequiresApproval,
+		f.IsActive, f.RequiresApproval, f.Bookable, f.PublishedAt, f.UnpublishedAt, f.HourlyRateCents,
+		f.AllowLateCancellation, f.LateCancellationFeePct, f.Timezone, f.RequiresConfirmation, f.SameDayCutoffTime,
+		f.MaxConcurrentBookings,
 	).Scan(&f.ID, &f.CreatedAt, &f.UpdatedAt)
 
 	if err != nil {
@@ -129,6 +358,16 @@ func (db *DB) UpdateFacility(id uuid.UUID, f *Facility) error {
 			cancellation_cutoff_hours = $12,
 			is_active = $13,
 			requires_approval = $14,
+			bookable = $15,
+			published_at = $16,
+			unpublished_at = $17,
+			hourly_rate_cents = $18,
+			allow_late_cancellation = $19,
+			late_cancellation_fee_pct = $20,
+			timezone = $21,
+			requires_confirmation = $22,
+			same_day_cutoff_time = $23,
+			max_concurrent_bookings = $24,
 			updated_at = NOW()
 		WHERE id = $1
 	`
@@ -138,7 +377,9 @@ func (db *DB) UpdateFacility(id uuid.UUID, f *Facility) error {
 		id, f.Slug, f.Name, f.Description, f.FacilityType, f.Location, f.Capacity,
 		f.MinBookingDurationMinutes, f.MaxBookingDurationMinutes,
 		f.BufferMinutes, f.AdvanceBookingDays, f.CancellationCutoffHours,
-		f.IsActive, f.RequiresApproval,
+		f.IsActive, f.RequiresApproval, f.Bookable, f.PublishedAt, f.UnpublishedAt, f.HourlyRateCents,
+		f.AllowLateCancellation, f.LateCancellationFeePct, f.Timezone, f.RequiresConfirmation, f.SameDayCutoffTime,
+		f.MaxConcurrentBookings,
 	)
 
 	if err != nil {
@@ -159,22 +400,13 @@ func (db *DB) UpdateFacility(id uuid.UUID, f *Facility) error {
 
 // GetFacilityByID retrieves a facility by ID
 func (db *DB) GetFacilityByID(id uuid.UUID) (*Facility, error) {
-	var f Facility
 	query := `
-		SELECT id, slug, name, description, facility_type, location, capacity,
-			min_booking_duration_minutes, max_booking_duration_minutes,
-			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
-			is_active, requires_approval, created_at, updated_at
+		SELECT `+facilityColumns+`
 		FROM facilities
 		WHERE id = $1
 	`
 
-	err := db.QueryRow(query, id).Scan(
-		&f.ID, &f.Slug, &f.Name, &f.Description, &f.FacilityType, &f.Location, &f.Capacity,
-		&f.MinBookingDurationMinutes, &f.MaxBookingDurationMinutes,
-		&f.BufferMinutes, &f.AdvanceBookingDays, &f.CancellationCutoffHours,
-		&f.IsActive, &f.RequiresApproval, &f.CreatedAt, &f.UpdatedAt,
-	)
+	f, err := scanFacility(db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -183,27 +415,18 @@ func (db *DB) GetFacilityByID(id uuid.UUID) (*Facility, error) {
 		return nil, fmt.Errorf("failed to get facility: %w", err)
 	}
 
-	return &f, nil
+	return f, nil
 }
 
 // GetFacilityBySlug retrieves a facility by slug
 func (db *DB) GetFacilityBySlug(slug string) (*Facility, error) {
-	var f Facility
 	query := `
-		SELECT id, slug, name, description, facility_type, location, capacity,
-			min_booking_duration_minutes, max_booking_duration_minutes,
-			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
-			is_active, requires_approval, created_at, updated_at
+		SELECT `+facilityColumns+`
 		FROM facilities
 		WHERE slug = $1
 	`
 
-	err := db.QueryRow(query, slug).Scan(
-		&f.ID, &f.Slug, &f.Name, &f.Description, &f.FacilityType, &f.Location, &f.Capacity,
-		&f.MinBookingDurationMinutes, &f.MaxBookingDurationMinutes,
-		&f.BufferMinutes, &f.AdvanceBookingDays, &f.CancellationCutoffHours,
-		&f.IsActive, &f.RequiresApproval, &f.CreatedAt, &f.UpdatedAt,
-	)
+	f, err := scanFacility(db.QueryRow(query, slug))
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -212,22 +435,32 @@ func (db *DB) GetFacilityBySlug(slug string) (*Facility, error) {
 		return nil, fmt.Errorf("failed to get facility: %w", err)
 	}
 
-	return &f, nil
+	return f, nil
 }
 
 // GetAllFacilities retrieves all facilities
 func (db *DB) GetAllFacilities(activeOnly bool) ([]Facility, error) {
+	return db.listFacilities(`($1 = false OR is_active = true)`, activeOnly)
+}
+
+// GetPublishedFacilities retrieves active facilities inside their publish window, for
+// public listings
+func (db *DB) GetPublishedFacilities() ([]Facility, error) {
+	return db.listFacilities(`is_active = true
+			AND (published_at IS NULL OR published_at <= NOW())
+			AND (unpublished_at IS NULL OR unpublished_at > NOW())`)
+}
+
+// listFacilities retrieves facilities matching a WHERE condition, ordered by name
+func (db *DB) listFacilities(condition string, args ...interface{}) ([]Facility, error) {
 	query := `
-		SELECT id, slug, name, description, facility_type, location, capacity,
-			min_booking_duration_minutes, max_booking_duration_minutes,
-			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
-			is_active, requires_approval, created_at, updated_at
+		SELECT `+facilityColumns+`
 		FROM facilities
-		WHERE ($1 = false OR is_active = true)
+		WHERE `+condition+`
 		ORDER BY name ASC
 	`
 
-	rows, err := db.Query(query, activeOnly)
+	rows, err := db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query facilities: %w", err)
 	}
@@ -235,17 +468,11 @@ func (db *DB) GetAllFacilities(activeOnly bool) ([]Facility, error) {
 
 	var facilities []Facility
 	for rows.Next() {
-		var f Facility
-		err := rows.Scan(
-			&f.ID, &f.Slug, &f.Name, &f.Description, &f.FacilityType, &f.Location, &f.Capacity,
-			&f.MinBookingDurationMinutes, &f.MaxBookingDurationMinutes,
-			&f.BufferMinutes, &f.AdvanceBookingDays, &f.CancellationCutoffHours,
-			&f.IsActive, &f.RequiresApproval, &f.CreatedAt, &f.UpdatedAt,
-		)
+		f, err := scanFacility(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan facility: %w", err)
 		}
-		facilities = append(facilities, f)
+		facilities = append(facilities, *f)
 	}
 
 	return facilities, nil
@@ -273,19 +500,33 @@ func (db *DB) DeleteFacility(id uuid.UUID) error {
 
 // CreateAvailabilityWindow creates a new availability window
 func (db *DB) CreateAvailabilityWindow(aw *AvailabilityWindow) (*AvailabilityWindow, error) {
+	start, err := ParseWindowTime(aw.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start_time: %w", err)
+	}
+	end, err := ParseWindowTime(aw.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end_time: %w", err)
+	}
+	if end <= start {
+		return nil, fmt.Errorf("end_time must be after start_time")
+	}
+	// Always store canonical HH:MM:SS so the availability math can rely on the format
+	aw.StartTime, aw.EndTime = formatWindowTime(start), formatWindowTime(end)
+
 	query := `
 		INSERT INTO availability_windows (
 			facility_id, day_of_week, start_time, end_time,
-			effective_from, effective_until
-		) VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, created_at
+			effective_from, effective_until, audience
+		) VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'public'))
+		RETURNING id, audience, created_at
 	`
 
-	err := db.QueryRow(
+	err = db.QueryRow(
 		query,
 		aw.FacilityID, aw.DayOfWeek, aw.StartTime, aw.EndTime,
-		aw.EffectiveFrom, aw.EffectiveUntil,
-	).Scan(&aw.ID, &aw.CreatedAt)
+		aw.EffectiveFrom, aw.EffectiveUntil, aw.Audience,
+	).Scan(&aw.ID, &aw.Audience, &aw.CreatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create availability window: %w", err)
@@ -298,7 +539,7 @@ func (db *DB) CreateAvailabilityWindow(aw *AvailabilityWindow) (*AvailabilityWin
 func (db *DB) GetAvailabilityWindows(facilityID uuid.UUID) ([]AvailabilityWindow, error) {
 	query := `
 		SELECT id, facility_id, day_of_week, start_time::text, end_time::text,
-			effective_from, effective_until, created_at
+			effective_from, effective_until, audience, created_at
 		FROM availability_windows
 		WHERE facility_id = $1
 		ORDER BY day_of_week, start_time
@@ -315,11 +556,23 @@ func (db *DB) GetAvailabilityWindows(facilityID uuid.UUID) ([]AvailabilityWindow
 		var aw AvailabilityWindow
 		err := rows.Scan(
 			&aw.ID, &aw.FacilityID, &aw.DayOfWeek, &aw.StartTime, &aw.EndTime,
-			&aw.EffectiveFrom, &aw.EffectiveUntil, &aw.CreatedAt,
+			&aw.EffectiveFrom, &aw.EffectiveUntil, &aw.Audience, &aw.CreatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan availability window: %w", err)
 		}
+
+		// A malformed window is skipped rather than failing availability for the whole facility
+		start, startErr := NormalizeWindowTime(aw.StartTime)
+		end, endErr := NormalizeWindowTime(aw.EndTime)
+		if startErr != nil || endErr != nil {
+			slog.Warn("skipping availability window with malformed times",
+				slog.String("window_id", aw.ID.String()),
+				slog.String("start_time", aw.StartTime),
+				slog.String("end_time", aw.EndTime))
+			continue
+		}
+		aw.StartTime, aw.EndTime = start, end
 		windows = append(windows, aw)
 	}
 
@@ -346,6 +599,204 @@ func (db *DB) DeleteAvailabilityWindow(id uuid.UUID) error {
 	return nil
 }
 
+// AvailabilityWindowReplacement is the outcome of replacing a facility's weekly schedule
+type AvailabilityWindowReplacement struct {
+	Windows   []AvailabilityWindow `json:"windows"`
+	Added     int                  `json:"added"`
+	Removed   int                  `json:"removed"`
+	Unchanged int                  `json:"unchanged"`
+}
+
+// ValidateAvailabilityWindows normalizes the times of a complete set of windows to
+// HH:MM:SS and checks each window is well formed and that no two windows overlap. Windows
+// overlap when they share a weekday and their times and effective date ranges intersect;
+// windows that only touch (one ends when the next starts) do not.
+func ValidateAvailabilityWindows(windows []AvailabilityWindow) error {
+	for i := range windows {
+		w := &windows[i]
+		if w.DayOfWeek < 0 || w.DayOfWeek > 6 {
+			return fmt.Errorf("window %d: day_of_week must be between 0 and 6", i)
+		}
+		start, err := NormalizeWindowTime(w.StartTime)
+		if err != nil {
+			return fmt.Errorf("window %d: invalid start_time: %w", i, err)
+		}
+		end, err := NormalizeWindowTime(w.EndTime)
+		if err != nil {
+			return fmt.Errorf("window %d: invalid end_time: %w", i, err)
+		}
+		if end <= start {
+			return fmt.Errorf("window %d: end_time must be after start_time", i)
+		}
+		if w.EffectiveFrom != nil && w.EffectiveUntil != nil && w.EffectiveUntil.Before(*w.EffectiveFrom) {
+			return fmt.Errorf("window %d: effective_until must not be before effective_from", i)
+		}
+		if w.Audience != "" {
+			if _, ok := audienceRank[w.Audience]; !ok {
+				return fmt.Errorf("window %d: invalid audience %q", i, w.Audience)
+			}
+		}
+		w.StartTime, w.EndTime = start, end
+	}
+
+	for i := range windows {
+		for j := i + 1; j < len(windows); j++ {
+			if windowsOverlap(windows[i], windows[j]) {
+				return fmt.Errorf("window %d overlaps window %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// windowsOverlap reports whether two windows with normalized times can apply at the same
+// moment
+func windowsOverlap(a, b AvailabilityWindow) bool {
+	if a.DayOfWeek != b.DayOfWeek {
+		return false
+	}
+	// Canonical HH:MM:SS strings compare in time order
+	if a.StartTime >= b.EndTime || b.StartTime >= a.EndTime {
+		return false
+	}
+	if a.EffectiveUntil != nil && b.EffectiveFrom != nil && a.EffectiveUntil.Before(*b.EffectiveFrom) {
+		return false
+	}
+	if b.EffectiveUntil != nil && a.EffectiveFrom != nil && b.EffectiveUntil.Before(*a.EffectiveFrom) {
+		return false
+	}
+	return true
+}
+
+// windowKey identifies a window by everything except its ID, so an unchanged window in a
+// replacement set can be matched to the existing row
+func windowKey(w AvailabilityWindow) string {
+	date := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02")
+	}
+	audience := w.Audience
+	if audience == "" {
+		audience = AudiencePublic
+	}
+	return fmt.Sprintf("%d|%s|%s|%s|%s|%s", w.DayOfWeek, w.StartTime, w.EndTime,
+		date(w.EffectiveFrom), date(w.EffectiveUntil), audience)
+}
+
+// ReplaceAvailabilityWindows makes the facility's availability windows exactly the given
+// set in one transaction. Windows identical to an existing one keep their row; the rest
+// of the existing windows are deleted and the new ones inserted. The set must already
+// have passed ValidateAvailabilityWindows.
+func (db *DB) ReplaceAvailabilityWindows(facilityID uuid.UUID, windows []AvailabilityWindow) (*AvailabilityWindowReplacement, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the facility so concurrent replacements apply one after the other
+	var locked uuid.UUID
+	err = tx.QueryRow("SELECT id FROM facilities WHERE id = $1 FOR UPDATE", facilityID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("facility not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock facility: %w", err)
+	}
+
+	rows, err := tx.Query(`
+		SELECT id, facility_id, day_of_week, start_time::text, end_time::text,
+			effective_from, effective_until, audience, created_at
+		FROM availability_windows
+		WHERE facility_id = $1
+	`, facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query availability windows: %w", err)
+	}
+	existing := map[string][]AvailabilityWindow{}
+	for rows.Next() {
+		var aw AvailabilityWindow
+		err := rows.Scan(
+			&aw.ID, &aw.FacilityID, &aw.DayOfWeek, &aw.StartTime, &aw.EndTime,
+			&aw.EffectiveFrom, &aw.EffectiveUntil, &aw.Audience, &aw.CreatedAt,
+		)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan availability window: %w", err)
+		}
+		// Malformed rows never match and are removed
+		if start, err := NormalizeWindowTime(aw.StartTime); err == nil {
+			aw.StartTime = start
+		}
+		if end, err := NormalizeWindowTime(aw.EndTime); err == nil {
+			aw.EndTime = end
+		}
+		key := windowKey(aw)
+		existing[key] = append(existing[key], aw)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query availability windows: %w", err)
+	}
+
+	result := &AvailabilityWindowReplacement{Windows: []AvailabilityWindow{}}
+	var toInsert []AvailabilityWindow
+	for _, w := range windows {
+		key := windowKey(w)
+		if matches := existing[key]; len(matches) > 0 {
+			result.Windows = append(result.Windows, matches[0])
+			existing[key] = matches[1:]
+			result.Unchanged++
+			continue
+		}
+		toInsert = append(toInsert, w)
+	}
+
+	for _, matches := range existing {
+		for _, aw := range matches {
+			if _, err := tx.Exec("DELETE FROM availability_windows WHERE id = $1", aw.ID); err != nil {
+				return nil, fmt.Errorf("failed to delete availability window: %w", err)
+			}
+			result.Removed++
+		}
+	}
+
+	for _, aw := range toInsert {
+		aw.FacilityID = facilityID
+		err := tx.QueryRow(`
+			INSERT INTO availability_windows (
+				facility_id, day_of_week, start_time, end_time,
+				effective_from, effective_until, audience
+			) VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'public'))
+			RETURNING id, audience, created_at
+		`,
+			aw.FacilityID, aw.DayOfWeek, aw.StartTime, aw.EndTime,
+			aw.EffectiveFrom, aw.EffectiveUntil, aw.Audience,
+		).Scan(&aw.ID, &aw.Audience, &aw.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create availability window: %w", err)
+		}
+		result.Windows = append(result.Windows, aw)
+		result.Added++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	sort.Slice(result.Windows, func(i, j int) bool {
+		a, b := result.Windows[i], result.Windows[j]
+		if a.DayOfWeek != b.DayOfWeek {
+			return a.DayOfWeek < b.DayOfWeek
+		}
+		return a.StartTime < b.StartTime
+	})
+
+	return result, nil
+}
+
 // CreateClosure creates a new closure
 func (db *DB) CreateClosure(c *FacilityClosure) (*FacilityClosure, error) {
 	query := `
@@ -399,6 +850,28 @@ func (db *DB) GetClosures(facilityID uuid.UUID, startTime, endTime time.Time) ([
 	return closures, nil
 }
 
+// GetClosureByID retrieves a closure by ID
+func (db *DB) GetClosureByID(id uuid.UUID) (*FacilityClosure, error) {
+	var c FacilityClosure
+	query := `
+		SELECT id, facility_id, start_time, end_time, reason, created_at, created_by
+		FROM facility_closures
+		WHERE id = $1
+	`
+
+	err := db.QueryRow(query, id).Scan(
+		&c.ID, &c.FacilityID, &c.StartTime, &c.EndTime, &c.Reason, &c.CreatedAt, &c.CreatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get closure: %w", err)
+	}
+
+	return &c, nil
+}
+
 // DeleteClosure deletes a closure
 func (db *DB) DeleteClosure(id uuid.UUID) error {
 	query := `DELETE FROM facility_closures WHERE id = $1`
@@ -419,26 +892,57 @@ func (db *DB) DeleteClosure(id uuid.UUID) error {
 	return nil
 }
 
-// CreateBooking creates a new facility booking
+// CreateBooking creates a new facility booking and, for a confirmed one-off booking, queues
+// a BOOKING_CONFIRMED email with a calendar invite for the booking owner
 func (db *DB) CreateBooking(b *FacilityBooking) (*FacilityBooking, error) {
 	query := `
 		INSERT INTO facility_bookings (
 			facility_id, user_id, household_id, participant_ids,
-			start_time, end_time, status, notes, idempotency_key
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+			start_time, end_time, status, notes, advance_limit_waived,
+			program_id, session_id, price_cents, series_id, unit_id, hold_expires_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := db.QueryRow(
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(
 		query,
 		b.FacilityID, b.UserID, b.HouseholdID, pq.Array(b.ParticipantIDs),
-		b.StartTime, b.EndTime, b.Status, b.Notes, b.IdempotencyKey,
+		b.StartTime, b.EndTime, b.Status, b.Notes, b.AdvanceLimitWaived,
+		b.ProgramID, b.SessionID, b.PriceCents, b.SeriesID, b.UnitID, b.HoldExpiresAt,
 	).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to create booking: %w", err)
 	}
 
+	if err := queueCalendarSync(tx, "booking", b.ID); err != nil {
+		return nil, err
+	}
+
+	// Series occurrences and program reservations are booked many at a time, and pending
+	// requests are confirmed by BOOKING_APPROVED, so only one-off bookings are emailed here
+	if b.Status == "confirmed" && b.SeriesID == nil && b.ProgramID == nil {
+		if err := queueBookingNotificationInTx(tx, "BOOKING_CONFIRMED", map[string]interface{}{"booking_id": b.ID.String()}); err != nil {
+			return nil, err
+		}
+	}
+
+	if b.IdempotencyKey != nil && *b.IdempotencyKey != "" {
+		if err := saveIdempotentResponseInTx(tx, IdempotencyScopeBooking, *b.IdempotencyKey, b); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return b, nil
 }
 
@@ -448,8 +952,8 @@ func (db *DB) GetBooking(id uuid.UUID) (*FacilityBooking, error) {
 	query := `
 		SELECT id, facility_id, user_id, household_id, participant_ids,
 			start_time, end_time, status, notes,
-			cancelled_at, cancelled_by, cancellation_reason,
-			idempotency_key, created_at, updated_at
+			cancelled_at, cancelled_by, cancellation_reason, cancellation_reason_code,
+			late_cancellation, late_cancellation_fee_cents, advance_limit_waived, program_id, session_id, price_cents, series_id, unit_id, hold_expires_at, created_at, updated_at
 		FROM facility_bookings
 		WHERE id = $1
 	`
@@ -457,8 +961,9 @@ func (db *DB) GetBooking(id uuid.UUID) (*FacilityBooking, error) {
 	err := db.QueryRow(query, id).Scan(
 		&b.ID, &b.FacilityID, &b.UserID, &b.HouseholdID, pq.Array(&b.ParticipantIDs),
 		&b.StartTime, &b.EndTime, &b.Status, &b.Notes,
-		&b.CancelledAt, &b.CancelledBy, &b.CancellationReason,
-		&b.IdempotencyKey, &b.CreatedAt, &b.UpdatedAt,
+		&b.CancelledAt, &b.CancelledBy, &b.CancellationReason, &b.CancellationReasonCode,
+		&b.LateCancellation, &b.LateCancellationFeeCents,
+		&b.AdvanceLimitWaived, &b.ProgramID, &b.SessionID, &b.PriceCents, &b.SeriesID, &b.UnitID, &b.HoldExpiresAt, &b.CreatedAt, &b.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -471,23 +976,28 @@ func (db *DB) GetBooking(id uuid.UUID) (*FacilityBooking, error) {
 	return &b, nil
 }
 
-// GetBookings retrieves bookings with optional filters
-func (db *DB) GetBookings(facilityID *uuid.UUID, userID *uuid.UUID, startTime, endTime *time.Time, status string) ([]FacilityBooking, error) {
+// GetBookings retrieves bookings with optional filters. startTime and endTime match bookings
+// overlapping that range; createdFrom (inclusive) and createdTo (exclusive) match bookings
+// made in that window. A status of BookingStatusHoldingSlot matches the bookings that
+// hold their slot.
+func (db *DB) GetBookings(facilityID *uuid.UUID, userID *uuid.UUID, startTime, endTime *time.Time, status string, createdFrom, createdTo *time.Time) ([]FacilityBooking, error) {
 	query := `
 		SELECT id, facility_id, user_id, household_id, participant_ids,
 			start_time, end_time, status, notes,
-			cancelled_at, cancelled_by, cancellation_reason,
-			idempotency_key, created_at, updated_at
+			cancelled_at, cancelled_by, cancellation_reason, cancellation_reason_code,
+			late_cancellation, late_cancellation_fee_cents, advance_limit_waived, program_id, session_id, price_cents, series_id, unit_id, hold_expires_at, created_at, updated_at
 		FROM facility_bookings
 		WHERE ($1::uuid IS NULL OR facility_id = $1)
 			AND ($2::uuid IS NULL OR user_id = $2)
 			AND ($3::timestamptz IS NULL OR end_time > $3)
 			AND ($4::timestamptz IS NULL OR start_time < $4)
-			AND ($5 = '' OR status = $5)
+			AND ($5 = '' OR status = $5 OR ($5 = '`+BookingStatusHoldingSlot+`' AND `+holdingSlotSQL+`))
+			AND ($6::timestamptz IS NULL OR created_at >= $6)
+			AND ($7::timestamptz IS NULL OR created_at < $7)
 		ORDER BY start_time ASC
 	`
 
-	rows, err := db.Query(query, facilityID, userID, startTime, endTime, status)
+	rows, err := db.Query(query, facilityID, userID, startTime, endTime, status, createdFrom, createdTo)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query bookings: %w", err)
 	}
@@ -499,8 +1009,9 @@ func (db *DB) GetBookings(facilityID *uuid.UUID, userID *uuid.UUID, startTime, e
 		err := rows.Scan(
 			&b.ID, &b.FacilityID, &b.UserID, &b.HouseholdID, pq.Array(&b.ParticipantIDs),
 			&b.StartTime, &b.EndTime, &b.Status, &b.Notes,
-			&b.CancelledAt, &b.CancelledBy, &b.CancellationReason,
-			&b.IdempotencyKey, &b.CreatedAt, &b.UpdatedAt,
+			&b.CancelledAt, &b.CancelledBy, &b.CancellationReason, &b.CancellationReasonCode,
+			&b.LateCancellation, &b.LateCancellationFeeCents,
+			&b.AdvanceLimitWaived, &b.ProgramID, &b.SessionID, &b.PriceCents, &b.SeriesID, &b.UnitID, &b.HoldExpiresAt, &b.CreatedAt, &b.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan booking: %w", err)
@@ -511,60 +1022,365 @@ func (db *DB) GetBookings(facilityID *uuid.UUID, userID *uuid.UUID, startTime, e
 	return bookings, nil
 }
 
-// CancelBooking cancels a booking
-func (db *DB) CancelBooking(id uuid.UUID, cancelledBy uuid.UUID, reason *string) error {
+// GetSeriesBookings retrieves the confirmed bookings of a recurring series, earliest first
+func (db *DB) GetSeriesBookings(seriesID uuid.UUID) ([]FacilityBooking, error) {
 	query := `
-		UPDATE facility_bookings SET
+		SELECT id, facility_id, user_id, household_id, participant_ids,
+			start_time, end_time, status, notes,
+			cancelled_at, cancelled_by, cancellation_reason, cancellation_reason_code,
+			late_cancellation, late_cancellation_fee_cents, advance_limit_waived, program_id, session_id, price_cents, series_id, unit_id, created_at, updated_at
+		FROM facility_bookings
+		WHERE series_id = $1 AND status = 'confirmed'
+		ORDER BY start_time ASC
+	`
+
+	rows, err := db.Query(query, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query series bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []FacilityBooking
+	for rows.Next() {
+		var b FacilityBooking
+		err := rows.Scan(
+			&b.ID, &b.FacilityID, &b.UserID, &b.HouseholdID, pq.Array(&b.ParticipantIDs),
+			&b.StartTime, &b.EndTime, &b.Status, &b.Notes,
+			&b.CancelledAt, &b.CancelledBy, &b.CancellationReason, &b.CancellationReasonCode,
+			&b.LateCancellation, &b.LateCancellationFeeCents,
+			&b.AdvanceLimitWaived, &b.ProgramID, &b.SessionID, &b.PriceCents, &b.SeriesID, &b.UnitID, &b.CreatedAt, &b.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		bookings = append(bookings, b)
+	}
+
+	return bookings, nil
+}
+
+// LateCancellationFee returns the share of a booking's price forfeited by cancelling it
+// late, rounded to the nearest cent; nil when there is nothing to forfeit
+func (f *Facility) LateCancellationFee(priceCents *int) *int {
+	if priceCents == nil || *priceCents <= 0 || f.LateCancellationFeePct <= 0 {
+		return nil
+	}
+	fee := (*priceCents*f.LateCancellationFeePct + 50) / 100
+	return &fee
+}
+
+// CancelBooking cancels a confirmed, pending or held booking with an optional reason code and
+// free-text reason. A late cancellation is flagged along with the fee it forfeits. The
+// owner of a confirmed one-off booking is sent a BOOKING_CANCELLED email saying whether
+// they or an administrator cancelled it; as with BOOKING_CONFIRMED, series occurrences,
+// program reservations and bookings that were never confirmed are not emailed.
+func (db *DB) CancelBooking(id uuid.UUID, cancelledBy uuid.UUID, reasonCode, reason *string, late bool, feeCents *int) error {
+	query := `
+		WITH previous AS (
+			SELECT id, status FROM facility_bookings WHERE id = $1 FOR UPDATE
+		)
+		UPDATE facility_bookings b SET
 			status = 'cancelled',
 			cancelled_at = NOW(),
 			cancelled_by = $2,
 			cancellation_reason = $3,
+			cancellation_reason_code = $4,
+			late_cancellation = $5,
+			late_cancellation_fee_cents = $6,
 			updated_at = NOW()
-		WHERE id = $1 AND status = 'confirmed'
+		FROM previous
+		WHERE b.id = previous.id AND b.status IN ('confirmed', 'pending', 'held')
+		RETURNING b.user_id, b.start_time, b.end_time, previous.status, b.series_id, b.program_id
 	`
 
-	result, err := db.Exec(query, id, cancelledBy, reason)
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var ownerID uuid.UUID
+	var startTime, endTime time.Time
+	var previousStatus string
+	var seriesID, programID *uuid.UUID
+	err = tx.QueryRow(query, id, cancelledBy, reason, reasonCode, late, feeCents).Scan(
+		&ownerID, &startTime, &endTime, &previousStatus, &seriesID, &programID,
+	)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("booking not found or already cancelled")
+	}
 	if err != nil {
 		return fmt.Errorf("failed to cancel booking: %w", err)
 	}
 
-	rowsAffected, err := result.RowsAffected()
+	if err := queueCalendarSync(tx, "booking", id); err != nil {
+		return err
+	}
+
+	if previousStatus == "confirmed" && seriesID == nil && programID == nil {
+		payload := map[string]interface{}{
+			"booking_id":   id.String(),
+			"start_time":   startTime.Format(time.RFC3339),
+			"end_time":     endTime.Format(time.RFC3339),
+			"cancelled_by": "self",
+		}
+		if cancelledBy != ownerID {
+			payload["cancelled_by"] = "admin"
+		}
+		if reason != nil && *reason != "" {
+			payload["reason"] = *reason
+		}
+		if err := queueBookingNotificationInTx(tx, "BOOKING_CANCELLED", payload); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ErrBookingNotPending is returned when approving or rejecting a booking that is no longer
+// awaiting approval, such as one another admin approved or rejected first
+var ErrBookingNotPending = errors.New("booking not found or not pending")
+
+// ApproveBooking confirms a pending booking, holding unitID, and queues a
+// BOOKING_APPROVED notification for the booking owner. The caller checks the slot is
+// still free under the facility/time lock (see core/facilities.go).
+func (db *DB) ApproveBooking(id uuid.UUID, unitID *uuid.UUID) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE facility_bookings SET
+			status = 'confirmed',
+			unit_id = $2,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, unitID)
 	if err != nil {
+		return fmt.Errorf("failed to approve booking: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return ErrBookingNotPending
 	}
 
-	if rowsAffected == 0 {
-		return fmt.Errorf("booking not found or already cancelled")
+	if err := queueBookingNotificationInTx(tx, "BOOKING_APPROVED", map[string]interface{}{"booking_id": id.String()}); err != nil {
+		return err
+	}
+	if err := queueCalendarSync(tx, "booking", id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
 	return nil
 }
 
-// GetBookingByIdempotencyKey retrieves a booking by idempotency key
-func (db *DB) GetBookingByIdempotencyKey(key string) (*FacilityBooking, error) {
-	var b FacilityBooking
+// RejectBooking rejects a pending booking with an optional reason and queues a
+// BOOKING_REJECTED notification for the booking owner. Pending bookings hold no slot, so
+// nothing else changes.
+func (db *DB) RejectBooking(id, rejectedBy uuid.UUID, reason *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE facility_bookings SET
+			status = 'rejected',
+			cancelled_at = NOW(),
+			cancelled_by = $2,
+			cancellation_reason = $3,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`, id, rejectedBy, reason)
+	if err != nil {
+		return fmt.Errorf("failed to reject booking: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if n == 0 {
+		return ErrBookingNotPending
+	}
+
+	payload := map[string]interface{}{"booking_id": id.String()}
+	if reason != nil {
+		payload["reason"] = *reason
+	}
+	if err := queueBookingNotificationInTx(tx, "BOOKING_REJECTED", payload); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// queueBookingNotificationInTx queues an email to a booking's owner
+func queueBookingNotificationInTx(tx *sql.Tx, notifType string, payload map[string]interface{}) error {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO notification_queue (type, payload)
+		VALUES ($1, $2)
+	`, notifType, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// MarkBookingNoShow marks a confirmed booking that has already started as a no-show
+func (db *DB) MarkBookingNoShow(id uuid.UUID) error {
 	query := `
-		SELECT id, facility_id, user_id, household_id, participant_ids,
-			start_time, end_time, status, notes,
-			cancelled_at, cancelled_by, cancellation_reason,
-			idempotency_key, created_at, updated_at
-		FROM facility_bookings
-		WHERE idempotency_key = $1
+		UPDATE facility_bookings SET
+			status = 'no_show',
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'confirmed' AND start_time <= NOW()
 	`
 
-	err := db.QueryRow(query, key).Scan(
-		&b.ID, &b.FacilityID, &b.UserID, &b.HouseholdID, pq.Array(&b.ParticipantIDs),
-		&b.StartTime, &b.EndTime, &b.Status, &b.Notes,
-		&b.CancelledAt, &b.CancelledBy, &b.CancellationReason,
-		&b.IdempotencyKey, &b.CreatedAt, &b.UpdatedAt,
-	)
+	result, err := db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark booking as no-show: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("booking not found, not confirmed, or not yet started")
+	}
+
+	return nil
+}
+
+// RescheduleBooking moves a confirmed booking to a new time range, holding unitID there,
+// and queues a BOOKING_RESCHEDULED notification for the booking owner
+func (db *DB) RescheduleBooking(id uuid.UUID, startTime, endTime time.Time, unitID *uuid.UUID) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
 
+	var previousStart, previousEnd time.Time
+	err = tx.QueryRow(`
+		SELECT start_time, end_time
+		FROM facility_bookings
+		WHERE id = $1 AND status = 'confirmed'
+		FOR UPDATE
+	`, id).Scan(&previousStart, &previousEnd)
 	if err == sql.ErrNoRows {
-		return nil, nil
+		return fmt.Errorf("booking not found or not confirmed")
 	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get booking by idempotency key: %w", err)
+		return fmt.Errorf("failed to get booking: %w", err)
 	}
 
-	return &b, nil
+	_, err = tx.Exec(`
+		UPDATE facility_bookings SET
+			start_time = $2,
+			end_time = $3,
+			unit_id = $4,
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, startTime, endTime, unitID)
+	if err != nil {
+		return fmt.Errorf("failed to reschedule booking: %w", err)
+	}
+
+	payloadJSON, err := json.Marshal(map[string]interface{}{
+		"booking_id":          id.String(),
+		"previous_start_time": previousStart,
+		"previous_end_time":   previousEnd,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO notification_queue (type, payload)
+		VALUES ('BOOKING_RESCHEDULED', $1)
+	`, payloadJSON)
+	if err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+
+	if err := queueCalendarSync(tx, "booking", id); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserBookingStats aggregates a user's bookings that start within the optional range.
+// Booked hours count confirmed bookings and no-shows.
+func (db *DB) GetUserBookingStats(userID uuid.UUID, from, to *time.Time) (*UserBookingStats, error) {
+	query := `
+		SELECT b.facility_id, f.name,
+			COUNT(*) AS total_bookings,
+			COALESCE(SUM(EXTRACT(EPOCH FROM (b.end_time - b.start_time)) / 3600)
+				FILTER (WHERE b.status IN ('confirmed', 'no_show')), 0) AS booked_hours,
+			COUNT(*) FILTER (WHERE b.status = 'no_show') AS no_shows,
+			COUNT(*) FILTER (WHERE b.status = 'cancelled') AS cancellations,
+			COUNT(*) FILTER (WHERE b.status = 'cancelled' AND b.late_cancellation) AS late_cancellations
+		FROM facility_bookings b
+		JOIN facilities f ON f.id = b.facility_id
+		WHERE b.user_id = $1
+			AND ($2::timestamptz IS NULL OR b.start_time >= $2)
+			AND ($3::timestamptz IS NULL OR b.start_time < $3)
+		GROUP BY b.facility_id, f.name
+		ORDER BY total_bookings DESC, f.name ASC
+	`
+
+	rows, err := db.Query(query, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query booking stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := &UserBookingStats{
+		UserID:     userID,
+		Facilities: []FacilityBookingStats{},
+	}
+	for rows.Next() {
+		var fs FacilityBookingStats
+		err := rows.Scan(
+			&fs.FacilityID, &fs.FacilityName, &fs.TotalBookings, &fs.TotalBookedHours,
+			&fs.NoShowCount, &fs.CancellationCount, &fs.LateCancellationCount,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking stats: %w", err)
+		}
+		stats.TotalBookings += fs.TotalBookings
+		stats.TotalBookedHours += fs.TotalBookedHours
+		stats.NoShowCount += fs.NoShowCount
+		stats.CancellationCount += fs.CancellationCount
+		stats.LateCancellationCount += fs.LateCancellationCount
+		stats.Facilities = append(stats.Facilities, fs)
+	}
+
+	return stats, nil
 }
@@ -0,0 +1,916 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestNormalizeWindowTime checks availability window times are stored as HH:MM:SS
+func TestNormalizeWindowTime(t *testing.T) {
+	valid := map[string]string{
+		"09:00":           "09:00:00",
+		"9:30":            "09:30:00",
+		"17:45:30":        "17:45:30",
+		"08:15:00.250000": "08:15:00",
+		" 07:00 ":         "07:00:00",
+		"24:00":           "24:00:00",
+		"24:00:00":        "24:00:00",
+	}
+	for in, want := range valid {
+		got, err := NormalizeWindowTime(in)
+		if err != nil || got != want {
+			t.Errorf("NormalizeWindowTime(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+
+	invalid := []string{"", "9", "09:0", "09:60", "25:00", "24:00:01", "09:00:00:00", "9am", "09:00:", "09:00:00.", "-1:00"}
+	for _, in := range invalid {
+		if got, err := NormalizeWindowTime(in); err == nil {
+			t.Errorf("NormalizeWindowTime(%q) = %q, want error", in, got)
+		}
+	}
+}
+
+// TestAvailabilityWindowBounds checks window times resolve to timestamps on a date
+func TestAvailabilityWindowBounds(t *testing.T) {
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	window := AvailabilityWindow{StartTime: "18:30:00", EndTime: "24:00:00"}
+
+	start, end, err := window.Bounds(date)
+	if err != nil {
+		t.Fatalf("Bounds failed: %v", err)
+	}
+	if want := time.Date(2024, 6, 3, 18, 30, 0, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("start = %v, want %v", start, want)
+	}
+	if want := time.Date(2024, 6, 4, 0, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("end = %v, want %v", end, want)
+	}
+
+	if _, _, err := (AvailabilityWindow{StartTime: "bad", EndTime: "10:00:00"}).Bounds(date); err == nil {
+		t.Error("Bounds with a malformed start time succeeded, want error")
+	}
+}
+
+// TestValidateAvailabilityWindows checks a replacement schedule is normalized and
+// rejected when windows overlap
+func TestValidateAvailabilityWindows(t *testing.T) {
+	date := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+
+	windows := []AvailabilityWindow{
+		{DayOfWeek: 1, StartTime: "9:00", EndTime: "12:00"},
+		{DayOfWeek: 1, StartTime: "12:00", EndTime: "17:00"},
+		{DayOfWeek: 2, StartTime: "09:00", EndTime: "17:00"},
+		{DayOfWeek: 6, StartTime: "09:00", EndTime: "12:00", EffectiveUntil: date("2024-05-31")},
+		{DayOfWeek: 6, StartTime: "10:00", EndTime: "14:00", EffectiveFrom: date("2024-06-01")},
+	}
+	if err := ValidateAvailabilityWindows(windows); err != nil {
+		t.Fatalf("ValidateAvailabilityWindows failed: %v", err)
+	}
+	if windows[0].StartTime != "09:00:00" || windows[0].EndTime != "12:00:00" {
+		t.Errorf("times not normalized: %s-%s", windows[0].StartTime, windows[0].EndTime)
+	}
+
+	invalid := map[string][]AvailabilityWindow{
+		"overlapping times": {
+			{DayOfWeek: 1, StartTime: "09:00", EndTime: "12:00"},
+			{DayOfWeek: 1, StartTime: "11:00", EndTime: "13:00"},
+		},
+		"overlapping date ranges": {
+			{DayOfWeek: 6, StartTime: "09:00", EndTime: "12:00", EffectiveUntil: date("2024-06-01")},
+			{DayOfWeek: 6, StartTime: "10:00", EndTime: "14:00", EffectiveFrom: date("2024-06-01")},
+		},
+		"end before start": {
+			{DayOfWeek: 1, StartTime: "12:00", EndTime: "09:00"},
+		},
+		"bad time": {
+			{DayOfWeek: 1, StartTime: "9am", EndTime: "12:00"},
+		},
+		"bad day": {
+			{DayOfWeek: 7, StartTime: "09:00", EndTime: "12:00"},
+		},
+		"until before from": {
+			{DayOfWeek: 1, StartTime: "09:00", EndTime: "12:00", EffectiveFrom: date("2024-06-01"), EffectiveUntil: date("2024-05-01")},
+		},
+	}
+	for name, set := range invalid {
+		if err := ValidateAvailabilityWindows(set); err == nil {
+			t.Errorf("%s: ValidateAvailabilityWindows succeeded, want error", name)
+		}
+	}
+}
+
+// TestGetBookingsCreatedWindow tests filtering bookings by when they were made rather than
+// when they take place
+func TestGetBookingsCreatedWindow(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type)
+		VALUES ($1, 'Test Court', 'court')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	// Both bookings take place next month; one was made last week, the other today
+	weekStart, weekEnd := time.Now().AddDate(0, 0, -8), time.Now().AddDate(0, 0, -1)
+	slot := time.Now().AddDate(0, 1, 0).Truncate(time.Hour)
+	createBooking := func(startTime, createdAt time.Time) uuid.UUID {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time, created_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id
+		`, facilityID, userID, startTime, startTime.Add(time.Hour), createdAt).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test booking: %v", err)
+		}
+		return id
+	}
+	lastWeek := createBooking(slot, time.Now().AddDate(0, 0, -5))
+	createBooking(slot.Add(2*time.Hour), time.Now())
+
+	bookings, err := db.GetBookings(&facilityID, nil, nil, nil, "", &weekStart, &weekEnd)
+	if err != nil {
+		t.Fatalf("GetBookings: %v", err)
+	}
+	if len(bookings) != 1 || bookings[0].ID != lastWeek {
+		t.Errorf("bookings created last week = %+v, want only %s", bookings, lastWeek)
+	}
+
+	all, err := db.GetBookings(&facilityID, nil, nil, nil, "", nil, nil)
+	if err != nil || len(all) != 2 {
+		t.Errorf("unfiltered bookings = %d, %v; want 2", len(all), err)
+	}
+}
+
+// TestGetPublishedFacilities tests that facilities outside their publish window are left
+// out of the public listing and closed to new bookings
+func TestGetPublishedFacilities(t *testing.T) {
+	db := setupTestDB(t)
+
+	var scheduled, live uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, published_at)
+		VALUES ($1, 'Test Court', 'court', NOW() + INTERVAL '1 day')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&scheduled)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, unpublished_at)
+		VALUES ($1, 'Test Field', 'field', NOW() + INTERVAL '1 day')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&live)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facilities WHERE id IN ($1, $2)`, scheduled, live)
+	})
+
+	facilities, err := db.GetPublishedFacilities()
+	if err != nil {
+		t.Fatalf("GetPublishedFacilities: %v", err)
+	}
+	listed := map[uuid.UUID]bool{}
+	for _, f := range facilities {
+		listed[f.ID] = true
+	}
+	if listed[scheduled] || !listed[live] {
+		t.Errorf("public listing: scheduled=%v live=%v; want only live", listed[scheduled], listed[live])
+	}
+
+	all, err := db.GetAllFacilities(true)
+	if err != nil {
+		t.Fatalf("GetAllFacilities: %v", err)
+	}
+	found := false
+	for _, f := range all {
+		found = found || f.ID == scheduled
+	}
+	if !found {
+		t.Error("admin listing is missing the scheduled facility")
+	}
+
+	start := time.Now().AddDate(0, 0, 2).Truncate(time.Hour)
+	if err := db.CheckAvailability(scheduled, start, start.Add(time.Hour), AudiencePublic, false); err == nil {
+		t.Error("CheckAvailability on an unpublished facility succeeded, want error")
+	}
+}
+
+// TestLateCancellationFee checks the forfeited share of a booking's price is rounded to
+// the cent and only charged when there is one
+func TestLateCancellationFee(t *testing.T) {
+	price := func(cents int) *int { return &cents }
+	tests := []struct {
+		name  string
+		pct   int
+		price *int
+		want  *int
+	}{
+		{"half", 50, price(3000), price(1500)},
+		{"rounded", 33, price(1250), price(413)},
+		{"whole price", 100, price(999), price(999)},
+		{"no fee", 0, price(3000), nil},
+		{"free booking", 50, nil, nil},
+		{"zero price", 50, price(0), nil},
+	}
+	for _, tt := range tests {
+		f := &Facility{LateCancellationFeePct: tt.pct}
+		got := f.LateCancellationFee(tt.price)
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: LateCancellationFee = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestGetSeriesBookings tests a series lists only its confirmed bookings, earliest first
+func TestGetSeriesBookings(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type)
+		VALUES ($1, 'Test Court', 'court')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	seriesID := uuid.New()
+	slot := time.Now().AddDate(0, 1, 0).Truncate(time.Hour)
+	createBooking := func(startTime time.Time, series *uuid.UUID, status string) uuid.UUID {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time, series_id, status)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id
+		`, facilityID, userID, startTime, startTime.Add(time.Hour), series, status).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test booking: %v", err)
+		}
+		return id
+	}
+	second := createBooking(slot.AddDate(0, 0, 7), &seriesID, "confirmed")
+	first := createBooking(slot, &seriesID, "confirmed")
+	createBooking(slot.AddDate(0, 0, 14), &seriesID, "cancelled")
+	createBooking(slot.Add(2*time.Hour), nil, "confirmed")
+
+	bookings, err := db.GetSeriesBookings(seriesID)
+	if err != nil {
+		t.Fatalf("GetSeriesBookings: %v", err)
+	}
+	if len(bookings) != 2 || bookings[0].ID != first || bookings[1].ID != second {
+		t.Errorf("series bookings = %+v, want %s then %s", bookings, first, second)
+	}
+}
+
+// TestPeakConcurrentBookings checks bookings are counted only while they overlap each
+// other within the slot, including buffer time
+func TestPeakConcurrentBookings(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 3, hour, minute, 0, 0, time.UTC)
+	}
+	booking := func(start, end time.Time) FacilityBooking {
+		return FacilityBooking{StartTime: start, EndTime: end}
+	}
+
+	tests := []struct {
+		name     string
+		bookings []FacilityBooking
+		buffer   int
+		want     int
+	}{
+		{"none", nil, 0, 0},
+		{"all overlapping", []FacilityBooking{
+			booking(at(9, 0), at(11, 0)), booking(at(9, 30), at(10, 30)), booking(at(8, 0), at(12, 0)),
+		}, 0, 3},
+		{"one after another", []FacilityBooking{
+			booking(at(9, 0), at(10, 0)), booking(at(10, 0), at(11, 0)), booking(at(11, 0), at(12, 0)),
+		}, 0, 1},
+		{"buffer joins them", []FacilityBooking{
+			booking(at(9, 0), at(10, 0)), booking(at(10, 0), at(11, 0)),
+		}, 10, 2},
+		{"outside the slot", []FacilityBooking{
+			booking(at(7, 0), at(9, 0)), booking(at(12, 0), at(13, 0)),
+		}, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := peakConcurrentBookings(tt.bookings, at(9, 0), at(12, 0), tt.buffer); got != tt.want {
+			t.Errorf("%s: peak = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestSharedFacilityCapacity tests a facility allowing three concurrent bookings takes three
+// overlapping bookings and refuses a fourth, while a facility allowing one refuses any
+// overlap whatever its capacity
+func TestSharedFacilityCapacity(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, pavilionID, courtID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, capacity, max_concurrent_bookings)
+		VALUES ($1, 'Test Pavilion', 'pavilion', 50, 3)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&pavilionID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, capacity)
+		VALUES ($1, 'Test Court', 'court', 4)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&courtID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id IN ($1, $2)`, pavilionID, courtID)
+		db.Exec(`DELETE FROM facilities WHERE id IN ($1, $2)`, pavilionID, courtID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	slot := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	book := func(facilityID uuid.UUID, offset time.Duration) error {
+		facility, err := db.GetFacilityByID(facilityID)
+		if err != nil {
+			t.Fatalf("GetFacilityByID: %v", err)
+		}
+		start := slot.Add(offset)
+		err = db.checkNoConflictingBookings(facilityID, start, start.Add(time.Hour), facility.BufferMinutes, facility.ConcurrentBookingLimit(), nil)
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec(`
+			INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time)
+			VALUES ($1, $2, $3, $4)
+		`, facilityID, userID, start, start.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("failed to create test booking: %v", err)
+		}
+		return nil
+	}
+
+	// Shared bookings may take the exact same slot
+	for i, offset := range []time.Duration{0, 0, 30 * time.Minute} {
+		if err := book(pavilionID, offset); err != nil {
+			t.Fatalf("pavilion booking %d: %v", i+1, err)
+		}
+	}
+	if err := book(pavilionID, 45*time.Minute); err == nil {
+		t.Error("fourth overlapping pavilion booking succeeded, want it refused")
+	}
+	// Only the third booking is still in progress after the first two end
+	if err := book(pavilionID, time.Hour); err != nil {
+		t.Errorf("pavilion booking once the first ended: %v", err)
+	}
+
+	if err := book(courtID, 0); err != nil {
+		t.Fatalf("court booking: %v", err)
+	}
+	// A court's capacity is a headcount and does not let bookings overlap
+	if err := book(courtID, 30*time.Minute); err == nil {
+		t.Error("overlapping court booking succeeded, want it refused")
+	}
+}
+
+// TestBookingApproval tests pending bookings do not hold their slot until approved, and
+// that approving or rejecting one notifies the booking owner
+func TestBookingApproval(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, requires_approval)
+		VALUES ($1, 'Test Gym', 'gym', true)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'booking_id' IN (SELECT id::text FROM facility_bookings WHERE facility_id = $1)`, facilityID)
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	start := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	request := func() *FacilityBooking {
+		b, err := db.CreateBooking(&FacilityBooking{FacilityID: facilityID, UserID: userID, StartTime: start, EndTime: start.Add(time.Hour), Status: "pending"})
+		if err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+		return b
+	}
+	conflict := func() error {
+		return db.checkNoConflictingBookings(facilityID, start, start.Add(time.Hour), 0, 1, nil)
+	}
+	notifications := func(notifType string, bookingID uuid.UUID) (n int, reason string) {
+		db.QueryRow(`
+			SELECT COUNT(*), COALESCE(MAX(payload->>'reason'), '')
+			FROM notification_queue WHERE type = $1 AND payload->>'booking_id' = $2
+		`, notifType, bookingID.String()).Scan(&n, &reason)
+		return n, reason
+	}
+
+	rejected, approved := request(), request()
+	if err := conflict(); err != nil {
+		t.Fatalf("pending bookings hold the slot: %v", err)
+	}
+
+	reason := "Gym is reserved for a tournament"
+	if err := db.RejectBooking(rejected.ID, userID, &reason); err != nil {
+		t.Fatalf("RejectBooking: %v", err)
+	}
+	if b, _ := db.GetBooking(rejected.ID); b == nil || b.Status != "rejected" || b.CancellationReason == nil || *b.CancellationReason != reason {
+		t.Errorf("rejected booking = %+v, want rejected with the reason", b)
+	}
+	if n, got := notifications("BOOKING_REJECTED", rejected.ID); n != 1 || got != reason {
+		t.Errorf("BOOKING_REJECTED notifications = %d with reason %q, want 1 with %q", n, got, reason)
+	}
+
+	if err := db.ApproveBooking(approved.ID, nil); err != nil {
+		t.Fatalf("ApproveBooking: %v", err)
+	}
+	if b, _ := db.GetBooking(approved.ID); b == nil || b.Status != "confirmed" {
+		t.Errorf("approved booking = %+v, want confirmed", b)
+	}
+	if n, _ := notifications("BOOKING_APPROVED", approved.ID); n != 1 {
+		t.Errorf("BOOKING_APPROVED notifications = %d, want 1", n)
+	}
+	if err := conflict(); err == nil {
+		t.Error("approved booking does not hold the slot")
+	}
+
+	// Only pending bookings can be approved or rejected, so the second of two admins
+	// deciding the same booking gets ErrBookingNotPending
+	if err := db.ApproveBooking(rejected.ID, nil); !errors.Is(err, ErrBookingNotPending) {
+		t.Errorf("approving a rejected booking = %v, want ErrBookingNotPending", err)
+	}
+	if err := db.RejectBooking(approved.ID, userID, nil); !errors.Is(err, ErrBookingNotPending) {
+		t.Errorf("rejecting a confirmed booking = %v, want ErrBookingNotPending", err)
+	}
+	if err := db.ApproveBooking(approved.ID, nil); !errors.Is(err, ErrBookingNotPending) {
+		t.Errorf("approving a booking twice = %v, want ErrBookingNotPending", err)
+	}
+}
+
+// TestBookingCancellationNotification tests cancelling a booking emails its owner, saying
+// who cancelled it
+func TestBookingCancellationNotification(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userIDs []uuid.UUID
+	for i := 0; i < 2; i++ {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO users (email, password_hash, first_name, last_name)
+			VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+			RETURNING id
+		`, "test-"+uuid.New().String()+"@example.com").Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, id)
+	}
+	ownerID, adminID := userIDs[0], userIDs[1]
+
+	var facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, capacity)
+		VALUES ($1, 'Test Pavilion', 'pavilion', 2)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'booking_id' IN (SELECT id::text FROM facility_bookings WHERE facility_id = $1)`, facilityID)
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		for _, id := range userIDs {
+			db.Exec(`DELETE FROM users WHERE id = $1`, id)
+		}
+	})
+
+	start := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	book := func() *FacilityBooking {
+		b, err := db.CreateBooking(&FacilityBooking{FacilityID: facilityID, UserID: ownerID, StartTime: start, EndTime: start.Add(time.Hour), Status: "confirmed"})
+		if err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+		return b
+	}
+	cancellation := func(bookingID uuid.UUID) (n int, cancelledBy, reason string) {
+		db.QueryRow(`
+			SELECT COUNT(*), COALESCE(MAX(payload->>'cancelled_by'), ''), COALESCE(MAX(payload->>'reason'), '')
+			FROM notification_queue WHERE type = 'BOOKING_CANCELLED' AND payload->>'booking_id' = $1
+		`, bookingID.String()).Scan(&n, &cancelledBy, &reason)
+		return n, cancelledBy, reason
+	}
+
+	mine, theirs := book(), book()
+
+	if err := db.CancelBooking(mine.ID, ownerID, nil, nil, false, nil); err != nil {
+		t.Fatalf("CancelBooking by owner: %v", err)
+	}
+	if n, by, _ := cancellation(mine.ID); n != 1 || by != "self" {
+		t.Errorf("owner cancellation queued %d notifications cancelled by %q, want 1 by self", n, by)
+	}
+
+	reason := "Pavilion closed for repairs"
+	if err := db.CancelBooking(theirs.ID, adminID, nil, &reason, false, nil); err != nil {
+		t.Fatalf("CancelBooking by admin: %v", err)
+	}
+	if n, by, got := cancellation(theirs.ID); n != 1 || by != "admin" || got != reason {
+		t.Errorf("admin cancellation queued %d notifications cancelled by %q with reason %q, want 1 by admin with %q", n, by, got, reason)
+	}
+
+	// A booking that is already cancelled is not emailed again
+	if err := db.CancelBooking(mine.ID, ownerID, nil, nil, false, nil); err == nil {
+		t.Error("cancelling a cancelled booking succeeded, want error")
+	}
+	if n, _, _ := cancellation(mine.ID); n != 1 {
+		t.Errorf("repeat cancellation left %d notifications, want 1", n)
+	}
+
+	// Pending requests, series occurrences and program reservations were never emailed a
+	// confirmation, so their cancellations are not emailed either
+	seriesID, programID := uuid.New(), createTestProgram(t, db, 1)
+	for name, b := range map[string]*FacilityBooking{
+		"pending": {Status: "pending"},
+		"series":  {Status: "confirmed", SeriesID: &seriesID},
+		"program": {Status: "confirmed", ProgramID: &programID},
+	} {
+		start = start.Add(2 * time.Hour)
+		b.FacilityID, b.UserID, b.StartTime, b.EndTime = facilityID, ownerID, start, start.Add(time.Hour)
+		if _, err := db.CreateBooking(b); err != nil {
+			t.Fatalf("CreateBooking %s: %v", name, err)
+		}
+		if err := db.CancelBooking(b.ID, ownerID, nil, nil, false, nil); err != nil {
+			t.Fatalf("CancelBooking %s: %v", name, err)
+		}
+		if n, _, _ := cancellation(b.ID); n != 0 {
+			t.Errorf("%s cancellation queued %d notifications, want 0", name, n)
+		}
+	}
+}
+
+// TestFacilityTimeLocation checks facilities without a loadable time zone use UTC
+func TestFacilityTimeLocation(t *testing.T) {
+	tests := map[string]string{
+		"":                 "UTC",
+		"America/New_York": "America/New_York",
+		"Not/AZone":        "UTC",
+	}
+	for tz, want := range tests {
+		f := Facility{Timezone: tz}
+		if got := f.TimeLocation().String(); got != want {
+			t.Errorf("TimeLocation() for %q = %s, want %s", tz, got, want)
+		}
+	}
+}
+
+// TestAvailabilityAcrossSpringForward checks windows are read in the facility's time zone
+// on the day clocks go forward, so a 1am-4am window holds two real hours of slots
+func TestAvailabilityAcrossSpringForward(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	// US clocks go forward at 2am on the second Sunday of March
+	year := time.Now().Year() + 1
+	day := time.Date(year, time.March, 1, 0, 0, 0, 0, loc)
+	for day.Weekday() != time.Sunday {
+		day = day.AddDate(0, 0, 1)
+	}
+	day = day.AddDate(0, 0, 7)
+	at := func(hour, minute int) time.Time {
+		return time.Date(year, time.March, day.Day(), hour, minute, 0, 0, loc)
+	}
+
+	facility := &Facility{Timezone: "America/New_York", MinBookingDurationMinutes: 60, AdvanceBookingDays: 800}
+	windows := []AvailabilityWindow{{DayOfWeek: 0, StartTime: "01:00:00", EndTime: "04:00:00", Audience: AudiencePublic}}
+
+	// The slot starting at 1am ends at 3am, an hour later
+	slots := windowSlotsForDay(facility, windows, facilityDay(day, loc), 60)
+	if len(slots) != 2 || !slots[0].StartTime.Equal(at(1, 0)) || !slots[1].StartTime.Equal(at(3, 0)) {
+		t.Fatalf("slots = %+v, want 1am and 3am", slots)
+	}
+	for _, slot := range slots {
+		if d := slot.EndTime.Sub(slot.StartTime); d != time.Hour {
+			t.Errorf("slot %v lasts %v, want 1h", slot.StartTime, d)
+		}
+	}
+
+	// Without a time zone the same window is read in UTC
+	utc := &Facility{MinBookingDurationMinutes: 60, AdvanceBookingDays: 800}
+	if slots := windowSlotsForDay(utc, windows, facilityDay(day, time.UTC), 60); len(slots) != 3 || slots[0].StartTime.Location() != time.UTC {
+		t.Errorf("UTC slots = %+v, want three from 1am UTC", slots)
+	}
+
+	// Bookings are checked against the window in the facility's zone, whatever their offset
+	if err := withinAvailabilityWindows(windows, at(1, 30).UTC(), at(3, 30).UTC(), loc); err != nil {
+		t.Errorf("booking across the gap: %v", err)
+	}
+	if err := withinAvailabilityWindows(windows, at(3, 30), at(4, 30), loc); err == nil {
+		t.Error("booking past the window's end succeeded, want error")
+	}
+}
+
+// TestBookingCutoff checks a day closes at the facility's cutoff time the day before, read
+// in the facility's time zone
+func TestBookingCutoff(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	cutoffTime := "17:00:00"
+	facility := &Facility{Timezone: "America/New_York", MinBookingDurationMinutes: 30, MaxBookingDurationMinutes: 120, AdvanceBookingDays: 14, SameDayCutoffTime: &cutoffTime}
+	start := time.Date(2024, 6, 5, 9, 0, 0, 0, loc) // Wednesday morning
+
+	cutoff, ok := facility.BookingCutoff(start.UTC())
+	if !ok || !cutoff.Equal(time.Date(2024, 6, 4, 17, 0, 0, 0, loc)) {
+		t.Fatalf("cutoff = %v, %v; want Tuesday at 5pm in New York", cutoff, ok)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		code string
+	}{
+		{"day before, ahead of the cutoff", time.Date(2024, 6, 4, 16, 59, 0, 0, loc), ""},
+		{"day before, at the cutoff", time.Date(2024, 6, 4, 17, 0, 0, 0, loc), AvailabilityPastCutoff},
+		{"same day", time.Date(2024, 6, 5, 7, 0, 0, 0, loc), AvailabilityPastCutoff},
+		{"days ahead", time.Date(2024, 6, 1, 12, 0, 0, 0, loc), ""},
+	}
+	for _, tt := range tests {
+		err := checkBookingTimes(facility, start, start.Add(time.Hour), tt.now, false)
+		if tt.code == "" {
+			if err != nil {
+				t.Errorf("%s: %v, want bookable", tt.name, err)
+			}
+			continue
+		}
+		if e, ok := err.(*AvailabilityError); !ok || e.Code != tt.code {
+			t.Errorf("%s: err = %v, want code %s", tt.name, err, tt.code)
+		}
+	}
+
+	facility.SameDayCutoffTime = nil
+	if _, ok := facility.BookingCutoff(start); ok {
+		t.Error("facility without a cutoff has one")
+	}
+	if err := checkBookingTimes(facility, start, start.Add(time.Hour), time.Date(2024, 6, 5, 7, 0, 0, 0, loc), false); err != nil {
+		t.Errorf("same-day booking without a cutoff: %v", err)
+	}
+}
+
+// TestSameDayCutoffStored tests a facility's cutoff time reads back from the database in
+// the form BookingCutoff and the facility config expect
+func TestSameDayCutoffStored(t *testing.T) {
+	db := setupTestDB(t)
+
+	cutoffTime := "17:00"
+	facility, err := db.CreateFacility(&Facility{
+		Slug: "test-facility-" + uuid.New().String(), Name: "Test Pool", FacilityType: "pool",
+		MaxConcurrentBookings: 1, MinBookingDurationMinutes: 30, MaxBookingDurationMinutes: 120,
+		AdvanceBookingDays: 14, IsActive: true, Bookable: true, Timezone: "America/New_York",
+		SameDayCutoffTime: &cutoffTime,
+	})
+	if err != nil {
+		t.Fatalf("CreateFacility: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM facilities WHERE id = $1`, facility.ID) })
+
+	stored, err := db.GetFacilityByID(facility.ID)
+	if err != nil || stored == nil {
+		t.Fatalf("GetFacilityByID = %v, %v", stored, err)
+	}
+	if stored.SameDayCutoffTime == nil || *stored.SameDayCutoffTime != "17:00:00" {
+		t.Fatalf("stored cutoff = %v, want 17:00:00", stored.SameDayCutoffTime)
+	}
+	if _, ok := stored.BookingCutoff(time.Now()); !ok {
+		t.Error("stored facility has no booking cutoff")
+	}
+
+	cfg, err := db.ExportFacilityConfig(facility.ID)
+	if err != nil {
+		t.Fatalf("ExportFacilityConfig: %v", err)
+	}
+	if err := ValidateFacilityConfig(cfg); err != nil {
+		t.Errorf("exported config with a cutoff does not validate: %v", err)
+	}
+}
+
+// TestAvailabilityAcrossMidnightUTC checks an evening window west of UTC accepts a booking
+// whose UTC time falls on the next day
+func TestAvailabilityAcrossMidnightUTC(t *testing.T) {
+	loc, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+
+	windows := []AvailabilityWindow{{DayOfWeek: 1, StartTime: "20:00:00", EndTime: "23:00:00", Audience: AudiencePublic}}
+	start := time.Date(2024, 6, 3, 21, 0, 0, 0, loc).UTC() // Monday evening, Tuesday 4am UTC
+	end := start.Add(time.Hour)
+
+	if err := withinAvailabilityWindows(windows, start, end, loc); err != nil {
+		t.Errorf("Monday evening booking in Los Angeles: %v", err)
+	}
+	if err := withinAvailabilityWindows(windows, start, end, time.UTC); err == nil {
+		t.Error("booking read in UTC succeeded, want it on Tuesday and refused")
+	}
+}
+
+// TestAvailabilityErrorCodes checks each rule a slot can break is reported with its code
+func TestAvailabilityErrorCodes(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC) // a Monday
+	facility := &Facility{MinBookingDurationMinutes: 30, MaxBookingDurationMinutes: 120, AdvanceBookingDays: 14}
+	windows := []AvailabilityWindow{{DayOfWeek: 1, StartTime: "08:00:00", EndTime: "20:00:00", Audience: AudiencePublic}}
+	start := now.AddDate(0, 0, 7).Add(2 * time.Hour) // next Monday at 2pm
+	reason := "Resurfacing"
+	closures := []FacilityClosure{{StartTime: start, EndTime: start.Add(time.Hour), Reason: &reason}}
+	bookings := []FacilityBooking{{StartTime: start.Add(3 * time.Hour), EndTime: start.Add(4 * time.Hour)}}
+
+	tests := []struct {
+		name string
+		err  error
+		code string
+	}{
+		{"too short", checkBookingTimes(facility, start, start.Add(15*time.Minute), now, false), AvailabilityDurationTooShort},
+		{"too long", checkBookingTimes(facility, start, start.Add(3*time.Hour), now, false), AvailabilityDurationTooLong},
+		{"too far ahead", checkBookingTimes(facility, now.AddDate(0, 0, 15), now.AddDate(0, 0, 15).Add(time.Hour), now, false), AvailabilityTooFarInAdvance},
+		{"in the past", checkBookingTimes(facility, now.Add(-time.Hour), now, now, false), AvailabilityInPast},
+		{"outside hours", withinAvailabilityWindows(windows, start.Add(5*time.Hour), start.Add(7*time.Hour), time.UTC), AvailabilityOutsideWindow},
+		{"closed day", withinAvailabilityWindows(windows, start.AddDate(0, 0, 1), start.AddDate(0, 0, 1).Add(time.Hour), time.UTC), AvailabilityOutsideWindow},
+		{"closure", closureConflict(closures, start.Add(30*time.Minute), start.Add(90*time.Minute)), AvailabilityClosure},
+		{"conflict", bookingConflict(bookings, start.Add(150*time.Minute), start.Add(210*time.Minute), 0, 1), AvailabilityConflict},
+		{"fully booked", bookingConflict(append(bookings, bookings[0]), start.Add(3*time.Hour), start.Add(4*time.Hour), 0, 2), AvailabilityConflict},
+	}
+	for _, tt := range tests {
+		e, ok := tt.err.(*AvailabilityError)
+		if !ok {
+			t.Errorf("%s: err = %v, want an AvailabilityError", tt.name, tt.err)
+			continue
+		}
+		if e.Code != tt.code || e.Message == "" || e.Error() != e.Message {
+			t.Errorf("%s: code %q message %q, want code %q with a message", tt.name, e.Code, e.Message, tt.code)
+		}
+	}
+
+	if e, _ := closureConflict(closures, start, start.Add(time.Hour)).(*AvailabilityError); e == nil || e.Closure == nil || *e.Closure.Reason != reason {
+		t.Errorf("closure error = %+v, want it to name the closure", e)
+	}
+
+	// A slot breaking no rule has no error
+	if err := checkBookingTimes(facility, start, start.Add(time.Hour), now, false); err != nil {
+		t.Errorf("valid times: %v", err)
+	}
+	if err := checkBookingTimes(facility, now.AddDate(0, 0, 15), now.AddDate(0, 0, 15).Add(time.Hour), now, true); err != nil {
+		t.Errorf("waived advance limit: %v", err)
+	}
+	if err := closureConflict(closures, start.Add(time.Hour), start.Add(2*time.Hour)); err != nil {
+		t.Errorf("after the closure: %v", err)
+	}
+	if err := bookingConflict(bookings, start.Add(time.Hour), start.Add(3*time.Hour), 0, 1); err != nil {
+		t.Errorf("before the booking: %v", err)
+	}
+}
+
+// TestAvailabilityForParticipant tests slots clashing with a participant's booking at
+// another facility are left out only when asking for that participant
+func TestAvailabilityForParticipant(t *testing.T) {
+	db := setupTestDB(t)
+
+	participantID := createTestParticipant(t, db)
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id WHERE p.id = $1
+	`, participantID).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to get participant's user: %v", err)
+	}
+
+	var facilityIDs []uuid.UUID
+	for i := 0; i < 2; i++ {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO facilities (slug, name, facility_type, min_booking_duration_minutes)
+			VALUES ($1, 'Test Court', 'court', 60)
+			RETURNING id
+		`, "test-facility-"+uuid.New().String()).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test facility: %v", err)
+		}
+		facilityIDs = append(facilityIDs, id)
+	}
+	court, elsewhere := facilityIDs[0], facilityIDs[1]
+	t.Cleanup(func() {
+		for _, id := range facilityIDs {
+			db.Exec(`DELETE FROM notification_queue WHERE payload->>'booking_id' IN (SELECT id::text FROM facility_bookings WHERE facility_id = $1)`, id)
+			db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, id)
+			db.Exec(`DELETE FROM availability_windows WHERE facility_id = $1`, id)
+			db.Exec(`DELETE FROM facilities WHERE id = $1`, id)
+		}
+	})
+
+	day := time.Now().UTC().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+	_, err = db.Exec(`
+		INSERT INTO availability_windows (facility_id, day_of_week, start_time, end_time)
+		VALUES ($1, $2, '09:00', '13:00')
+	`, court, int(day.Weekday()))
+	if err != nil {
+		t.Fatalf("failed to create availability window: %v", err)
+	}
+
+	book := func(status string, participants ...uuid.UUID) {
+		t.Helper()
+		start := day.Add(10 * time.Hour)
+		_, err := db.CreateBooking(&FacilityBooking{FacilityID: elsewhere, UserID: userID, ParticipantIDs: participants, StartTime: start, EndTime: start.Add(time.Hour), Status: status})
+		if err != nil {
+			t.Fatalf("CreateBooking: %v", err)
+		}
+	}
+	book("confirmed", participantID)
+	book("cancelled", participantID)
+	book("confirmed", createTestParticipant(t, db))
+
+	starts := func(participantID *uuid.UUID) []int {
+		t.Helper()
+		slots, err := db.GetAvailableSlots(AvailabilityQuery{FacilityID: court, StartDate: day, EndDate: day.AddDate(0, 0, 1), Duration: 60, Audience: AudiencePublic, ParticipantID: participantID})
+		if err != nil {
+			t.Fatalf("GetAvailableSlots: %v", err)
+		}
+		var hours []int
+		for _, s := range slots {
+			hours = append(hours, s.StartTime.UTC().Hour())
+		}
+		return hours
+	}
+
+	if got := starts(nil); len(got) != 4 {
+		t.Errorf("slots without a participant start at %v, want 9, 10, 11 and 12", got)
+	}
+	if got := starts(&participantID); len(got) != 3 || got[0] != 9 || got[1] != 11 || got[2] != 12 {
+		t.Errorf("slots for the participant start at %v, want 9, 11 and 12", got)
+	}
+}
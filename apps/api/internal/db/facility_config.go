@@ -0,0 +1,381 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// FacilityConfigVersion is the version of the facility config format written by
+// ExportFacilityConfig; imports of any other version are refused
+const FacilityConfigVersion = 1
+
+// ErrFacilitySlugTaken is returned when importing a facility under a slug another facility
+// already has, including one created while the import was running
+var ErrFacilitySlugTaken = errors.New("a facility with this slug already exists")
+
+// FacilityConfig is a facility's complete configuration without any IDs, so it can be
+// recreated as a new facility in this or another environment. Only upcoming closures are
+// carried over.
+type FacilityConfig struct {
+	Version             int                     `json:"version"`
+	Facility            FacilitySettings        `json:"facility"`
+	AvailabilityWindows []FacilityConfigWindow  `json:"availability_windows"`
+	PricingRules        []FacilityConfigRule    `json:"pricing_rules"`
+	Closures            []FacilityConfigClosure `json:"closures"`
+	Units               []FacilityConfigUnit    `json:"units"`
+}
+
+// FacilitySettings are a facility's own settings, without its slug
+type FacilitySettings struct {
+	Name                      string     `json:"name"`
+	Description               *string    `json:"description,omitempty"`
+	FacilityType              string     `json:"facility_type"`
+	Location                  *string    `json:"location,omitempty"`
+	Capacity                  *int       `json:"capacity,omitempty"`
+	MaxConcurrentBookings     int        `json:"max_concurrent_bookings,omitempty"` // omitted = 1
+	MinBookingDurationMinutes int        `json:"min_booking_duration_minutes"`
+	MaxBookingDurationMinutes int        `json:"max_booking_duration_minutes"`
+	BufferMinutes             int        `json:"buffer_minutes"`
+	AdvanceBookingDays        int        `json:"advance_booking_days"`
+	CancellationCutoffHours   int        `json:"cancellation_cutoff_hours"`
+	IsActive                  bool       `json:"is_active"`
+	Bookable                  bool       `json:"bookable"`
+	RequiresApproval          bool       `json:"requires_approval"`
+	PublishedAt               *time.Time `json:"published_at,omitempty"`
+	UnpublishedAt             *time.Time `json:"unpublished_at,omitempty"`
+	HourlyRateCents           *int       `json:"hourly_rate_cents,omitempty"`
+	AllowLateCancellation     bool       `json:"allow_late_cancellation"`
+	LateCancellationFeePct    int        `json:"late_cancellation_fee_pct"`
+	Timezone                  string     `json:"timezone"`
+	RequiresConfirmation      bool       `json:"requires_confirmation"`
+	SameDayCutoffTime         *string    `json:"same_day_cutoff_time,omitempty"`
+}
+
+// FacilityConfigWindow is an availability window in a facility config
+type FacilityConfigWindow struct {
+	DayOfWeek      int        `json:"day_of_week"`
+	StartTime      string     `json:"start_time"`
+	EndTime        string     `json:"end_time"`
+	EffectiveFrom  *time.Time `json:"effective_from,omitempty"`
+	EffectiveUntil *time.Time `json:"effective_until,omitempty"`
+	Audience       string     `json:"audience,omitempty"`
+}
+
+// FacilityConfigRule is a pricing rule in a facility config
+type FacilityConfigRule struct {
+	DayOfWeek       int     `json:"day_of_week"`
+	StartTime       string  `json:"start_time"`
+	EndTime         string  `json:"end_time"`
+	HourlyRateCents int     `json:"hourly_rate_cents"`
+	Label           *string `json:"label,omitempty"`
+}
+
+// FacilityConfigClosure is a closure in a facility config
+type FacilityConfigClosure struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Reason    *string   `json:"reason,omitempty"`
+}
+
+// FacilityConfigUnit is a unit in a facility config
+type FacilityConfigUnit struct {
+	Name      string `json:"name"`
+	SortOrder int    `json:"sort_order"`
+	IsActive  bool   `json:"is_active"`
+}
+
+// ExportFacilityConfig gathers a facility's configuration. Returns nil when the facility
+// does not exist.
+func (db *DB) ExportFacilityConfig(facilityID uuid.UUID) (*FacilityConfig, error) {
+	f, err := db.GetFacilityByID(facilityID)
+	if err != nil || f == nil {
+		return nil, err
+	}
+
+	cfg := &FacilityConfig{
+		Version: FacilityConfigVersion,
+		Facility: FacilitySettings{
+			Name:                      f.Name,
+			Description:               f.Description,
+			FacilityType:              f.FacilityType,
+			Location:                  f.Location,
+			Capacity:                  f.Capacity,
+			MaxConcurrentBookings:     f.MaxConcurrentBookings,
+			MinBookingDurationMinutes: f.MinBookingDurationMinutes,
+			MaxBookingDurationMinutes: f.MaxBookingDurationMinutes,
+			BufferMinutes:             f.BufferMinutes,
+			AdvanceBookingDays:        f.AdvanceBookingDays,
+			CancellationCutoffHours:   f.CancellationCutoffHours,
+			IsActive:                  f.IsActive,
+			Bookable:                  f.Bookable,
+			RequiresApproval:          f.RequiresApproval,
+			PublishedAt:               f.PublishedAt,
+			UnpublishedAt:             f.UnpublishedAt,
+			HourlyRateCents:           f.HourlyRateCents,
+			AllowLateCancellation:     f.AllowLateCancellation,
+			LateCancellationFeePct:    f.LateCancellationFeePct,
+			Timezone:                  f.Timezone,
+			RequiresConfirmation:      f.RequiresConfirmation,
+			SameDayCutoffTime:         f.SameDayCutoffTime,
+		},
+		AvailabilityWindows: []FacilityConfigWindow{},
+		PricingRules:        []FacilityConfigRule{},
+		Closures:            []FacilityConfigClosure{},
+		Units:               []FacilityConfigUnit{},
+	}
+
+	windows, err := db.GetAvailabilityWindows(facilityID)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range windows {
+		cfg.AvailabilityWindows = append(cfg.AvailabilityWindows, FacilityConfigWindow{
+			DayOfWeek:      w.DayOfWeek,
+			StartTime:      w.StartTime,
+			EndTime:        w.EndTime,
+			EffectiveFrom:  w.EffectiveFrom,
+			EffectiveUntil: w.EffectiveUntil,
+			Audience:       w.Audience,
+		})
+	}
+
+	rules, err := db.GetPricingRules(facilityID)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range rules {
+		cfg.PricingRules = append(cfg.PricingRules, FacilityConfigRule{
+			DayOfWeek:       r.DayOfWeek,
+			StartTime:       r.StartTime,
+			EndTime:         r.EndTime,
+			HourlyRateCents: r.HourlyRateCents,
+			Label:           r.Label,
+		})
+	}
+
+	closures, err := db.GetClosures(facilityID, time.Now(), time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range closures {
+		cfg.Closures = append(cfg.Closures, FacilityConfigClosure{StartTime: c.StartTime, EndTime: c.EndTime, Reason: c.Reason})
+	}
+
+	units, err := db.GetFacilityUnits(facilityID, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range units {
+		cfg.Units = append(cfg.Units, FacilityConfigUnit{Name: u.Name, SortOrder: u.SortOrder, IsActive: u.IsActive})
+	}
+
+	return cfg, nil
+}
+
+// ValidateFacilityConfig checks an imported config is complete and consistent, applying
+// the same rules as creating the facility and its schedule one piece at a time. Window
+// and rule times and the same-day cutoff are normalized to HH:MM:SS, and a missing
+// max_concurrent_bookings to 1.
+func ValidateFacilityConfig(cfg *FacilityConfig) error {
+	if cfg.Version != FacilityConfigVersion {
+		return fmt.Errorf("unsupported config version %d (expected %d)", cfg.Version, FacilityConfigVersion)
+	}
+
+	f := cfg.Facility
+	switch {
+	case f.Name == "":
+		return fmt.Errorf("facility: name is required")
+	case f.FacilityType == "":
+		return fmt.Errorf("facility: facility_type is required")
+	case f.MinBookingDurationMinutes <= 0:
+		return fmt.Errorf("facility: min_booking_duration_minutes must be positive")
+	case f.MaxBookingDurationMinutes < f.MinBookingDurationMinutes:
+		return fmt.Errorf("facility: max_booking_duration_minutes must be >= min_booking_duration_minutes")
+	case f.MaxConcurrentBookings < 0:
+		return fmt.Errorf("facility: max_concurrent_bookings must be positive")
+	case f.BufferMinutes < 0:
+		return fmt.Errorf("facility: buffer_minutes cannot be negative")
+	case f.AdvanceBookingDays <= 0:
+		return fmt.Errorf("facility: advance_booking_days must be positive")
+	case f.CancellationCutoffHours < 0:
+		return fmt.Errorf("facility: cancellation_cutoff_hours cannot be negative")
+	case f.HourlyRateCents != nil && *f.HourlyRateCents < 0:
+		return fmt.Errorf("facility: hourly_rate_cents cannot be negative")
+	case f.LateCancellationFeePct < 0 || f.LateCancellationFeePct > 100:
+		return fmt.Errorf("facility: late_cancellation_fee_pct must be between 0 and 100")
+	case f.PublishedAt != nil && f.UnpublishedAt != nil && !f.UnpublishedAt.After(*f.PublishedAt):
+		return fmt.Errorf("facility: unpublished_at must be after published_at")
+	}
+	if f.MaxConcurrentBookings == 0 {
+		cfg.Facility.MaxConcurrentBookings = 1
+	}
+	if f.Timezone != "" {
+		if _, err := time.LoadLocation(f.Timezone); err != nil || f.Timezone == "Local" {
+			return fmt.Errorf("facility: invalid timezone %q", f.Timezone)
+		}
+	}
+	if f.SameDayCutoffTime != nil {
+		cutoff, err := NormalizeWindowTime(*f.SameDayCutoffTime)
+		if err != nil {
+			return fmt.Errorf("facility: same_day_cutoff_time: %w", err)
+		}
+		cfg.Facility.SameDayCutoffTime = &cutoff
+	}
+
+	windows := cfg.windows()
+	if err := ValidateAvailabilityWindows(windows); err != nil {
+		return fmt.Errorf("availability_windows: %w", err)
+	}
+	for i := range windows {
+		cfg.AvailabilityWindows[i].StartTime, cfg.AvailabilityWindows[i].EndTime = windows[i].StartTime, windows[i].EndTime
+	}
+
+	rules := cfg.rules()
+	if err := ValidatePricingRules(rules); err != nil {
+		return fmt.Errorf("pricing_rules: %w", err)
+	}
+	for i := range rules {
+		cfg.PricingRules[i].StartTime, cfg.PricingRules[i].EndTime = rules[i].StartTime, rules[i].EndTime
+	}
+
+	for i, c := range cfg.Closures {
+		if !c.EndTime.After(c.StartTime) {
+			return fmt.Errorf("closures: closure %d: end_time must be after start_time", i)
+		}
+	}
+
+	names := map[string]bool{}
+	for i, u := range cfg.Units {
+		if u.Name == "" {
+			return fmt.Errorf("units: unit %d: name is required", i)
+		}
+		if names[u.Name] {
+			return fmt.Errorf("units: unit %d: duplicate name %q", i, u.Name)
+		}
+		names[u.Name] = true
+	}
+
+	return nil
+}
+
+// windows returns the config's availability windows as AvailabilityWindows
+func (cfg *FacilityConfig) windows() []AvailabilityWindow {
+	windows := make([]AvailabilityWindow, len(cfg.AvailabilityWindows))
+	for i, w := range cfg.AvailabilityWindows {
+		windows[i] = AvailabilityWindow{
+			DayOfWeek:      w.DayOfWeek,
+			StartTime:      w.StartTime,
+			EndTime:        w.EndTime,
+			EffectiveFrom:  w.EffectiveFrom,
+			EffectiveUntil: w.EffectiveUntil,
+			Audience:       w.Audience,
+		}
+	}
+	return windows
+}
+
+// rules returns the config's pricing rules as PricingRules
+func (cfg *FacilityConfig) rules() []PricingRule {
+	rules := make([]PricingRule, len(cfg.PricingRules))
+	for i, r := range cfg.PricingRules {
+		rules[i] = PricingRule{
+			DayOfWeek:       r.DayOfWeek,
+			StartTime:       r.StartTime,
+			EndTime:         r.EndTime,
+			HourlyRateCents: r.HourlyRateCents,
+			Label:           r.Label,
+		}
+	}
+	return rules
+}
+
+// ImportFacilityConfig creates a new facility with the given slug from a config, with its
+// windows, pricing rules, closures and units, in one transaction. The config must already
+// have passed ValidateFacilityConfig. Imported closures are credited to createdBy.
+func (db *DB) ImportFacilityConfig(cfg *FacilityConfig, slug string, createdBy *uuid.UUID) (*Facility, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	s := cfg.Facility
+	var facilityID uuid.UUID
+	err = tx.QueryRow(`
+		INSERT INTO facilities (
+			slug, name, description, facility_type, location, capacity,
+			min_booking_duration_minutes, max_booking_duration_minutes,
+			buffer_minutes, advance_booking_days, cancellation_cutoff_hours,
+			is_active, requires_approval, bookable, published_at, unpublished_at, hourly_rate_cents,
+			allow_late_cancellation, late_cancellation_fee_pct, timezone, requires_confirmation, same_day_cutoff_time,
+			max_concurrent_bookings
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23)
+		RETURNING id
+	`,
+		slug, s.Name, s.Description, s.FacilityType, s.Location, s.Capacity,
+		s.MinBookingDurationMinutes, s.MaxBookingDurationMinutes,
+		s.BufferMinutes, s.AdvanceBookingDays, s.CancellationCutoffHours,
+		s.IsActive, s.RequiresApproval, s.Bookable, s.PublishedAt, s.UnpublishedAt, s.HourlyRateCents,
+		s.AllowLateCancellation, s.LateCancellationFeePct, s.Timezone, s.RequiresConfirmation, s.SameDayCutoffTime,
+		s.MaxConcurrentBookings,
+	).Scan(&facilityID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == "facilities_slug_key" {
+		return nil, ErrFacilitySlugTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create facility: %w", err)
+	}
+
+	for _, w := range cfg.AvailabilityWindows {
+		_, err := tx.Exec(`
+			INSERT INTO availability_windows (
+				facility_id, day_of_week, start_time, end_time,
+				effective_from, effective_until, audience
+			) VALUES ($1, $2, $3, $4, $5, $6, COALESCE(NULLIF($7, ''), 'public'))
+		`, facilityID, w.DayOfWeek, w.StartTime, w.EndTime, w.EffectiveFrom, w.EffectiveUntil, w.Audience)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create availability window: %w", err)
+		}
+	}
+
+	for _, r := range cfg.PricingRules {
+		_, err := tx.Exec(`
+			INSERT INTO facility_pricing_rules (facility_id, day_of_week, start_time, end_time, hourly_rate_cents, label)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, facilityID, r.DayOfWeek, r.StartTime, r.EndTime, r.HourlyRateCents, r.Label)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing rule: %w", err)
+		}
+	}
+
+	for _, c := range cfg.Closures {
+		_, err := tx.Exec(`
+			INSERT INTO facility_closures (facility_id, start_time, end_time, reason, created_by)
+			VALUES ($1, $2, $3, $4, $5)
+		`, facilityID, c.StartTime, c.EndTime, c.Reason, createdBy)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create closure: %w", err)
+		}
+	}
+
+	for _, u := range cfg.Units {
+		_, err := tx.Exec(`
+			INSERT INTO facility_units (facility_id, name, sort_order, is_active)
+			VALUES ($1, $2, $3, $4)
+		`, facilityID, u.Name, u.SortOrder, u.IsActive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create facility unit: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return db.GetFacilityByID(facilityID)
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// validFacilityConfig returns a config that passes ValidateFacilityConfig
+func validFacilityConfig() *FacilityConfig {
+	return &FacilityConfig{
+		Version: FacilityConfigVersion,
+		Facility: FacilitySettings{
+			Name:                      "Tennis Center",
+			FacilityType:              "court",
+			MinBookingDurationMinutes: 30,
+			MaxBookingDurationMinutes: 120,
+			AdvanceBookingDays:        14,
+			CancellationCutoffHours:   24,
+			IsActive:                  true,
+			Bookable:                  true,
+			Timezone:                  "UTC",
+		},
+		AvailabilityWindows: []FacilityConfigWindow{{DayOfWeek: 1, StartTime: "9:00", EndTime: "17:00"}},
+		PricingRules:        []FacilityConfigRule{{DayOfWeek: 1, StartTime: "17:00", EndTime: "21:00", HourlyRateCents: 4000}},
+		Units:               []FacilityConfigUnit{{Name: "Court 1", IsActive: true}, {Name: "Court 2", SortOrder: 1, IsActive: true}},
+	}
+}
+
+// TestValidateFacilityConfig tests imported configs are checked the way their pieces are
+// when created one at a time
+func TestValidateFacilityConfig(t *testing.T) {
+	cfg := validFacilityConfig()
+	if err := ValidateFacilityConfig(cfg); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	if w := cfg.AvailabilityWindows[0]; w.StartTime != "09:00:00" || w.EndTime != "17:00:00" {
+		t.Errorf("window times = %s-%s, want normalized 09:00:00-17:00:00", w.StartTime, w.EndTime)
+	}
+	if cfg.Facility.MaxConcurrentBookings != 1 {
+		t.Errorf("max_concurrent_bookings = %d, want a missing limit to default to 1", cfg.Facility.MaxConcurrentBookings)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*FacilityConfig)
+		want   string
+	}{
+		{"unknown version", func(c *FacilityConfig) { c.Version = 2 }, "version"},
+		{"missing name", func(c *FacilityConfig) { c.Facility.Name = "" }, "name"},
+		{"max below min", func(c *FacilityConfig) { c.Facility.MaxBookingDurationMinutes = 15 }, "max_booking_duration_minutes"},
+		{"bad time zone", func(c *FacilityConfig) { c.Facility.Timezone = "Mars/Olympus" }, "timezone"},
+		{"negative concurrent limit", func(c *FacilityConfig) { c.Facility.MaxConcurrentBookings = -1 }, "max_concurrent_bookings"},
+		{"fee over 100", func(c *FacilityConfig) { c.Facility.LateCancellationFeePct = 150 }, "late_cancellation_fee_pct"},
+		{"overlapping windows", func(c *FacilityConfig) {
+			c.AvailabilityWindows = append(c.AvailabilityWindows, FacilityConfigWindow{DayOfWeek: 1, StartTime: "16:00", EndTime: "18:00"})
+		}, "availability_windows"},
+		{"bad rule time", func(c *FacilityConfig) { c.PricingRules[0].EndTime = "25:00" }, "pricing_rules"},
+		{"backwards closure", func(c *FacilityConfig) {
+			now := time.Now()
+			c.Closures = []FacilityConfigClosure{{StartTime: now, EndTime: now.Add(-time.Hour)}}
+		}, "closures"},
+		{"duplicate unit", func(c *FacilityConfig) { c.Units[1].Name = "Court 1" }, "duplicate"},
+	}
+
+	for _, tt := range tests {
+		cfg := validFacilityConfig()
+		tt.modify(cfg)
+		err := ValidateFacilityConfig(cfg)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want one mentioning %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+// TestFacilityConfigRoundTrip tests an exported facility imports as an identical copy
+// under a new slug
+func TestFacilityConfigRoundTrip(t *testing.T) {
+	db := setupTestDB(t)
+
+	cfg := validFacilityConfig()
+	reason := "Resurfacing"
+	start := time.Now().AddDate(0, 1, 0).Truncate(time.Hour)
+	cfg.Closures = []FacilityConfigClosure{{StartTime: start, EndTime: start.Add(48 * time.Hour), Reason: &reason}}
+	if err := ValidateFacilityConfig(cfg); err != nil {
+		t.Fatalf("ValidateFacilityConfig: %v", err)
+	}
+
+	var ids []uuid.UUID
+	t.Cleanup(func() {
+		for _, id := range ids {
+			db.Exec(`DELETE FROM facilities WHERE id = $1`, id)
+		}
+	})
+
+	original, err := db.ImportFacilityConfig(cfg, "test-facility-"+uuid.New().String(), nil)
+	if err != nil {
+		t.Fatalf("ImportFacilityConfig: %v", err)
+	}
+	ids = append(ids, original.ID)
+
+	exported, err := db.ExportFacilityConfig(original.ID)
+	if err != nil {
+		t.Fatalf("ExportFacilityConfig: %v", err)
+	}
+	// Serialize through JSON as the endpoints do
+	body, err := json.Marshal(exported)
+	if err != nil {
+		t.Fatalf("failed to marshal config: %v", err)
+	}
+	var imported FacilityConfig
+	if err := json.Unmarshal(body, &imported); err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+	if err := ValidateFacilityConfig(&imported); err != nil {
+		t.Fatalf("exported config does not validate: %v", err)
+	}
+
+	copySlug := "test-facility-" + uuid.New().String()
+	copied, err := db.ImportFacilityConfig(&imported, copySlug, nil)
+	if err != nil {
+		t.Fatalf("ImportFacilityConfig copy: %v", err)
+	}
+	ids = append(ids, copied.ID)
+	if copied.Slug != copySlug || copied.ID == original.ID {
+		t.Errorf("copy slug = %q id = %s, want new facility %q", copied.Slug, copied.ID, copySlug)
+	}
+
+	reexported, err := db.ExportFacilityConfig(copied.ID)
+	if err != nil {
+		t.Fatalf("ExportFacilityConfig copy: %v", err)
+	}
+	want, _ := json.Marshal(exported)
+	got, _ := json.Marshal(reexported)
+	if string(got) != string(want) {
+		t.Errorf("copy config = %s\nwant %s", got, want)
+	}
+	if len(reexported.Units) != 2 || len(reexported.Closures) != 1 || len(reexported.PricingRules) != 1 {
+		t.Errorf("copy has %d units, %d closures, %d pricing rules; want 2, 1, 1",
+			len(reexported.Units), len(reexported.Closures), len(reexported.PricingRules))
+	}
+
+	// Importing again under a slug now taken is refused as a conflict
+	if _, err := db.ImportFacilityConfig(&imported, copySlug, nil); !errors.Is(err, ErrFacilitySlugTaken) {
+		t.Errorf("import under a taken slug = %v, want ErrFacilitySlugTaken", err)
+	}
+}
@@ -0,0 +1,204 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FacilityUnit is one separately bookable space within a facility, such as a court or a
+// lane. A facility with active units takes one booking per unit at a time.
+type FacilityUnit struct {
+	ID         uuid.UUID `json:"id"`
+	FacilityID uuid.UUID `json:"facility_id"`
+	Name       string    `json:"name"`
+	SortOrder  int       `json:"sort_order"`
+	IsActive   bool      `json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// GetFacilityUnits retrieves a facility's units in display order, optionally only active ones
+func (db *DB) GetFacilityUnits(facilityID uuid.UUID, activeOnly bool) ([]FacilityUnit, error) {
+	rows, err := db.Query(`
+		SELECT id, facility_id, name, sort_order, is_active, created_at
+		FROM facility_units
+		WHERE facility_id = $1 AND (NOT $2 OR is_active)
+		ORDER BY sort_order, name
+	`, facilityID, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facility units: %w", err)
+	}
+	defer rows.Close()
+
+	units := []FacilityUnit{}
+	for rows.Next() {
+		var u FacilityUnit
+		if err := rows.Scan(&u.ID, &u.FacilityID, &u.Name, &u.SortOrder, &u.IsActive, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan facility unit: %w", err)
+		}
+		units = append(units, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query facility units: %w", err)
+	}
+
+	return units, nil
+}
+
+// GetFacilityUnit retrieves a unit of a facility, or nil if the facility has no such unit
+func (db *DB) GetFacilityUnit(facilityID, unitID uuid.UUID) (*FacilityUnit, error) {
+	var u FacilityUnit
+	err := db.QueryRow(`
+		SELECT id, facility_id, name, sort_order, is_active, created_at
+		FROM facility_units
+		WHERE id = $1 AND facility_id = $2
+	`, unitID, facilityID).Scan(&u.ID, &u.FacilityID, &u.Name, &u.SortOrder, &u.IsActive, &u.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get facility unit: %w", err)
+	}
+	return &u, nil
+}
+
+// CreateFacilityUnit adds a unit to a facility
+func (db *DB) CreateFacilityUnit(u *FacilityUnit) (*FacilityUnit, error) {
+	err := db.QueryRow(`
+		INSERT INTO facility_units (facility_id, name, sort_order, is_active)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, created_at
+	`, u.FacilityID, u.Name, u.SortOrder, u.IsActive).Scan(&u.ID, &u.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create facility unit: %w", err)
+	}
+	return u, nil
+}
+
+// UpdateFacilityUnit renames, reorders or (de)activates a unit
+func (db *DB) UpdateFacilityUnit(u *FacilityUnit) error {
+	result, err := db.Exec(`
+		UPDATE facility_units SET name = $3, sort_order = $4, is_active = $5
+		WHERE id = $1 AND facility_id = $2
+	`, u.ID, u.FacilityID, u.Name, u.SortOrder, u.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update facility unit: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("unit not found")
+	}
+	return nil
+}
+
+// FacilityUnitHasBookings reports whether any booking, in any status, has held the unit
+func (db *DB) FacilityUnitHasBookings(unitID uuid.UUID) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`SELECT EXISTS (SELECT 1 FROM facility_bookings WHERE unit_id = $1)`, unitID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check unit bookings: %w", err)
+	}
+	return exists, nil
+}
+
+// DeleteFacilityUnit removes a unit. Units that have been booked cannot be deleted, to
+// keep their bookings' history, and should be deactivated instead.
+func (db *DB) DeleteFacilityUnit(facilityID, unitID uuid.UUID) error {
+	result, err := db.Exec(`DELETE FROM facility_units WHERE id = $1 AND facility_id = $2`, unitID, facilityID)
+	if err != nil {
+		return fmt.Errorf("failed to delete facility unit: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return fmt.Errorf("unit not found")
+	}
+	return nil
+}
+
+// AllocateUnit picks the unit a booking of the facility holds: the requested unit when it
+// is free, otherwise the first free active unit in display order. Returns nil, and no
+// error, for a facility without active units, whose bookings hold the whole facility.
+// excludeBookingID ignores one booking, so a booking being moved does not block itself.
+func (db *DB) AllocateUnit(facility *Facility, requested *uuid.UUID, startTime, endTime time.Time, excludeBookingID *uuid.UUID) (*uuid.UUID, error) {
+	units, err := db.GetFacilityUnits(facility.ID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(units) == 0 {
+		if requested != nil {
+			return nil, fmt.Errorf("facility has no units to choose from")
+		}
+		return nil, nil
+	}
+
+	bookings, err := db.bookingsAround(facility, startTime, endTime, excludeBookingID)
+	if err != nil {
+		return nil, err
+	}
+	free := FreeUnits(units, bookings, startTime, endTime, facility.BufferMinutes)
+
+	if requested != nil {
+		for _, u := range units {
+			if u.ID != *requested {
+				continue
+			}
+			for _, f := range free {
+				if f.ID == u.ID {
+					return &u.ID, nil
+				}
+			}
+			return nil, availabilityErrorf(AvailabilityConflict, "%s is already booked at this time", u.Name)
+		}
+		return nil, fmt.Errorf("unit not found")
+	}
+
+	if len(free) == 0 {
+		return nil, availabilityErrorf(AvailabilityConflict, "time slot conflicts with existing booking on every unit")
+	}
+	return &free[0].ID, nil
+}
+
+// bookingsAround retrieves the facility's bookings holding their slot that overlap a time
+// range widened by the facility's buffer, optionally ignoring one booking
+func (db *DB) bookingsAround(facility *Facility, startTime, endTime time.Time, excludeBookingID *uuid.UUID) ([]FacilityBooking, error) {
+	buffer := time.Duration(facility.BufferMinutes) * time.Minute
+	rangeStart, rangeEnd := startTime.Add(-buffer), endTime.Add(buffer)
+	bookings, err := db.GetBookings(&facility.ID, nil, &rangeStart, &rangeEnd, BookingStatusHoldingSlot, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bookings: %w", err)
+	}
+	if excludeBookingID == nil {
+		return bookings, nil
+	}
+	kept := bookings[:0]
+	for _, b := range bookings {
+		if b.ID != *excludeBookingID {
+			kept = append(kept, b)
+		}
+	}
+	return kept, nil
+}
+
+// FreeUnits returns the units, in the given order, that no booking overlaps during the
+// time range, including buffer time. A booking without a unit holds every unit.
+func FreeUnits(units []FacilityUnit, bookings []FacilityBooking, startTime, endTime time.Time, bufferMinutes int) []FacilityUnit {
+	buffer := time.Duration(bufferMinutes) * time.Minute
+	taken := make(map[uuid.UUID]bool)
+	for _, b := range bookings {
+		if !startTime.Before(b.EndTime.Add(buffer)) || !endTime.After(b.StartTime.Add(-buffer)) {
+			continue
+		}
+		if b.UnitID == nil {
+			return []FacilityUnit{}
+		}
+		taken[*b.UnitID] = true
+	}
+
+	free := []FacilityUnit{}
+	for _, u := range units {
+		if !taken[u.ID] {
+			free = append(free, u)
+		}
+	}
+	return free
+}
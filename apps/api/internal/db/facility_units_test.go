@@ -0,0 +1,116 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestFreeUnits checks bookings only take their own unit, including buffer time, and
+// bookings without a unit take the whole facility
+func TestFreeUnits(t *testing.T) {
+	courts := []FacilityUnit{{ID: uuid.New(), Name: "Court 1"}, {ID: uuid.New(), Name: "Court 2"}}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 3, hour, minute, 0, 0, time.UTC)
+	}
+	booking := func(unit *FacilityUnit, start, end time.Time) FacilityBooking {
+		b := FacilityBooking{StartTime: start, EndTime: end}
+		if unit != nil {
+			b.UnitID = &unit.ID
+		}
+		return b
+	}
+
+	tests := []struct {
+		name     string
+		bookings []FacilityBooking
+		buffer   int
+		want     []string
+	}{
+		{"no bookings", nil, 0, []string{"Court 1", "Court 2"}},
+		{"one court taken", []FacilityBooking{booking(&courts[0], at(9, 0), at(10, 0))}, 0, []string{"Court 2"}},
+		{"back to back", []FacilityBooking{booking(&courts[0], at(8, 0), at(9, 0))}, 0, []string{"Court 1", "Court 2"}},
+		{"within buffer", []FacilityBooking{booking(&courts[0], at(8, 0), at(9, 0))}, 15, []string{"Court 2"}},
+		{"whole facility", []FacilityBooking{booking(nil, at(9, 30), at(11, 0))}, 0, []string{}},
+	}
+	for _, tt := range tests {
+		free := FreeUnits(courts, tt.bookings, at(9, 0), at(10, 0), tt.buffer)
+		var names []string
+		for _, u := range free {
+			names = append(names, u.Name)
+		}
+		if len(names) != len(tt.want) {
+			t.Errorf("%s: free = %v, want %v", tt.name, names, tt.want)
+			continue
+		}
+		for i := range names {
+			if names[i] != tt.want[i] {
+				t.Errorf("%s: free = %v, want %v", tt.name, names, tt.want)
+				break
+			}
+		}
+	}
+}
+
+// TestUnitBookingIndex checks the double-booking index lets different units of a facility
+// be booked for the same slot, but not the same unit twice
+func TestUnitBookingIndex(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type)
+		VALUES ($1, 'Test Courts', 'court')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facility_units WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	units := make([]uuid.UUID, 2)
+	for i := range units {
+		err := db.QueryRow(`
+			INSERT INTO facility_units (facility_id, name) VALUES ($1, $2) RETURNING id
+		`, facilityID, "Court "+string(rune('1'+i))).Scan(&units[i])
+		if err != nil {
+			t.Fatalf("failed to create test unit: %v", err)
+		}
+	}
+
+	slot := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	book := func(unitID *uuid.UUID) error {
+		_, err := db.Exec(`
+			INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time, unit_id)
+			VALUES ($1, $2, $3, $4, $5)
+		`, facilityID, userID, slot, slot.Add(time.Hour), unitID)
+		return err
+	}
+
+	if err := book(&units[0]); err != nil {
+		t.Fatalf("first court booking: %v", err)
+	}
+	if err := book(&units[1]); err != nil {
+		t.Errorf("other court booking for the same slot: %v", err)
+	}
+	if err := book(&units[0]); err == nil {
+		t.Error("second booking of the same court succeeded, want it refused")
+	}
+	if err := book(nil); err != nil {
+		t.Errorf("whole facility booking: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -285,3 +286,118 @@ func (db *DB) DeleteParticipantForm(participantID, templateID uuid.UUID) error {
 
 	return nil
 }
+
+// FormField is one field of a form schema, as rendered by the web forms
+type FormField struct {
+	ID       string   `json:"id"`
+	Type     string   `json:"type"` // text, textarea, select or checkbox
+	Label    string   `json:"label"`
+	Required bool     `json:"required,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// FormSchema is the {"fields": [...]} format used by form templates and program
+// registration questions
+type FormSchema struct {
+	Fields []FormField `json:"fields"`
+}
+
+// ParseFormSchema parses a form schema and checks that every field is well formed
+func ParseFormSchema(raw json.RawMessage) (*FormSchema, error) {
+	var schema FormSchema
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, fmt.Errorf("invalid form schema: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for _, field := range schema.Fields {
+		if field.ID == "" {
+			return nil, fmt.Errorf("form field is missing an id")
+		}
+		if seen[field.ID] {
+			return nil, fmt.Errorf("duplicate form field id %q", field.ID)
+		}
+		seen[field.ID] = true
+
+		switch field.Type {
+		case "text", "textarea", "checkbox":
+		case "select":
+			if len(field.Options) == 0 {
+				return nil, fmt.Errorf("select field %q has no options", field.ID)
+			}
+		default:
+			return nil, fmt.Errorf("form field %q has unsupported type %q", field.ID, field.Type)
+		}
+	}
+
+	return &schema, nil
+}
+
+// ValidateAnswers checks answers keyed by field id against the schema. Required text and
+// select fields must be non-empty and required checkboxes must be checked.
+func (s *FormSchema) ValidateAnswers(raw json.RawMessage) error {
+	answers := map[string]interface{}{}
+	if len(raw) > 0 && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &answers); err != nil {
+			return fmt.Errorf("answers must be an object keyed by question id")
+		}
+	}
+
+	fields := map[string]FormField{}
+	for _, field := range s.Fields {
+		fields[field.ID] = field
+	}
+	for id := range answers {
+		if _, ok := fields[id]; !ok {
+			return fmt.Errorf("unknown question %q", id)
+		}
+	}
+
+	for _, field := range s.Fields {
+		value, answered := answers[field.ID]
+		if value == nil {
+			answered = false
+		}
+
+		switch field.Type {
+		case "checkbox":
+			checked, ok := value.(bool)
+			if answered && !ok {
+				return fmt.Errorf("answer to %q must be true or false", field.Label)
+			}
+			if field.Required && !checked {
+				return fmt.Errorf("%q must be checked", field.Label)
+			}
+		default:
+			text, ok := value.(string)
+			if answered && !ok {
+				return fmt.Errorf("answer to %q must be text", field.Label)
+			}
+			if field.Required && strings.TrimSpace(text) == "" {
+				return fmt.Errorf("%q is required", field.Label)
+			}
+			if field.Type == "select" && text != "" && !containsString(field.Options, text) {
+				return fmt.Errorf("%q is not an option for %q", text, field.Label)
+			}
+		}
+	}
+
+	return nil
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// nullableJSON passes an empty json.RawMessage to the database as NULL
+func nullableJSON(raw json.RawMessage) interface{} {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestValidateAnswers checks registration answers against a question schema
+func TestValidateAnswers(t *testing.T) {
+	schema, err := ParseFormSchema(json.RawMessage(`{"fields": [
+		{"id": "shirt", "type": "select", "label": "T-shirt size", "required": true, "options": ["S", "M", "L"]},
+		{"id": "carpool", "type": "checkbox", "label": "Interested in carpooling"},
+		{"id": "photo", "type": "checkbox", "label": "Photo consent", "required": true},
+		{"id": "notes", "type": "textarea", "label": "Notes"}
+	]}`))
+	if err != nil {
+		t.Fatalf("failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		answers string
+		wantErr bool
+	}{
+		{"all answered", `{"shirt": "M", "carpool": true, "photo": true, "notes": "none"}`, false},
+		{"optional fields omitted", `{"shirt": "L", "photo": true}`, false},
+		{"missing required select", `{"photo": true}`, true},
+		{"select value not an option", `{"shirt": "XL", "photo": true}`, true},
+		{"required checkbox unchecked", `{"shirt": "S", "photo": false}`, true},
+		{"checkbox answered with text", `{"shirt": "S", "photo": "yes"}`, true},
+		{"unknown question", `{"shirt": "S", "photo": true, "extra": "x"}`, true},
+		{"no answers", ``, true},
+		{"not an object", `["S"]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateAnswers(json.RawMessage(tt.answers))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateAnswers(%s) error = %v, wantErr %v", tt.answers, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestParseFormSchema rejects malformed question schemas
+func TestParseFormSchema(t *testing.T) {
+	invalid := []string{
+		`{"fields": [{"id": "", "type": "text", "label": "Name"}]}`,
+		`{"fields": [{"id": "a", "type": "text"}, {"id": "a", "type": "text"}]}`,
+		`{"fields": [{"id": "size", "type": "select", "label": "Size"}]}`,
+		`{"fields": [{"id": "file", "type": "upload", "label": "File"}]}`,
+		`not json`,
+	}
+	for _, raw := range invalid {
+		if _, err := ParseFormSchema(json.RawMessage(raw)); err == nil {
+			t.Errorf("ParseFormSchema(%s) succeeded, want error", raw)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package db
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// HoursInterval is one continuous stretch of opening hours on a day, as HH:MM:SS
+type HoursInterval struct {
+	Open  string `json:"open"`
+	Close string `json:"close"`
+}
+
+// FacilityDayHours is a facility's opening hours on one weekday, worked out for the
+// next occurrence of that weekday
+type FacilityDayHours struct {
+	DayOfWeek int               `json:"day_of_week"` // 0=Sunday, 1=Monday, ..., 6=Saturday
+	Day       string            `json:"day"`
+	Date      string            `json:"date"` // YYYY-MM-DD the hours apply to
+	Intervals []HoursInterval   `json:"intervals"`
+	Summary   string            `json:"summary"` // e.g. "9:00 AM – 5:00 PM" or "Closed"
+	Closures  []FacilityClosure `json:"closures,omitempty"`
+}
+
+// WeeklyHours summarizes the windows open to the audience as opening hours for the seven
+// days starting on from, returned Sunday first. Each weekday uses the windows in effect
+// on its date; overlapping and back-to-back windows are merged into one interval.
+// Windows with malformed times are left out.
+func WeeklyHours(windows []AvailabilityWindow, audience string, from time.Time) []FacilityDayHours {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+
+	week := make([]FacilityDayHours, 7)
+	for offset := 0; offset < 7; offset++ {
+		date := from.AddDate(0, 0, offset)
+		weekday := int(date.Weekday())
+
+		type span struct{ start, end time.Duration }
+		var spans []span
+		for _, window := range windows {
+			if window.DayOfWeek != weekday || !window.OpenTo(audience) || !windowInEffect(window, date) {
+				continue
+			}
+			start, err := ParseWindowTime(window.StartTime)
+			if err != nil {
+				continue
+			}
+			end, err := ParseWindowTime(window.EndTime)
+			if err != nil || end <= start {
+				continue
+			}
+			spans = append(spans, span{start, end})
+		}
+		sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+
+		var merged []span
+		for _, s := range spans {
+			if n := len(merged); n > 0 && s.start <= merged[n-1].end {
+				if s.end > merged[n-1].end {
+					merged[n-1].end = s.end
+				}
+				continue
+			}
+			merged = append(merged, s)
+		}
+
+		day := FacilityDayHours{
+			DayOfWeek: weekday,
+			Day:       date.Weekday().String(),
+			Date:      date.Format("2006-01-02"),
+			Intervals: []HoursInterval{},
+		}
+		var parts []string
+		for _, s := range merged {
+			day.Intervals = append(day.Intervals, HoursInterval{Open: formatWindowTime(s.start), Close: formatWindowTime(s.end)})
+			parts = append(parts, timeOnDate(date, s.start).Format("3:04 PM")+" – "+timeOnDate(date, s.end).Format("3:04 PM"))
+		}
+		day.Summary = "Closed"
+		if len(parts) > 0 {
+			day.Summary = strings.Join(parts, ", ")
+		}
+		week[weekday] = day
+	}
+
+	return week
+}
+
+// OverlayClosures attaches each closure to the days of the week it overlaps
+func OverlayClosures(week []FacilityDayHours, closures []FacilityClosure, loc *time.Location) {
+	for i := range week {
+		dayStart, err := time.ParseInLocation("2006-01-02", week[i].Date, loc)
+		if err != nil {
+			continue
+		}
+		dayEnd := dayStart.AddDate(0, 0, 1)
+		for _, closure := range closures {
+			if closure.StartTime.Before(dayEnd) && closure.EndTime.After(dayStart) {
+				week[i].Closures = append(week[i].Closures, closure)
+			}
+		}
+	}
+}
+
+// windowInEffect reports whether the window's effective date range covers the date
+func windowInEffect(window AvailabilityWindow, date time.Time) bool {
+	day := date.Format("2006-01-02")
+	if window.EffectiveFrom != nil && day < window.EffectiveFrom.Format("2006-01-02") {
+		return false
+	}
+	if window.EffectiveUntil != nil && day > window.EffectiveUntil.Format("2006-01-02") {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWeeklyHours checks windows are merged into per-weekday opening hours
+func TestWeeklyHours(t *testing.T) {
+	date := func(s string) *time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return &d
+	}
+
+	windows := []AvailabilityWindow{
+		{DayOfWeek: 1, StartTime: "09:00:00", EndTime: "12:00:00", Audience: AudiencePublic},
+		{DayOfWeek: 1, StartTime: "12:00:00", EndTime: "17:00:00", Audience: AudiencePublic},
+		{DayOfWeek: 1, StartTime: "18:00:00", EndTime: "21:00:00", Audience: AudiencePublic},
+		{DayOfWeek: 2, StartTime: "09:00:00", EndTime: "13:00:00", Audience: AudiencePublic},
+		{DayOfWeek: 2, StartTime: "10:00:00", EndTime: "12:00:00", Audience: AudiencePublic},
+		{DayOfWeek: 3, StartTime: "06:00:00", EndTime: "08:00:00", Audience: AudienceMembers},
+		{DayOfWeek: 6, StartTime: "09:00:00", EndTime: "12:00:00", Audience: AudiencePublic, EffectiveUntil: date("2024-06-07")},
+		{DayOfWeek: 6, StartTime: "10:00:00", EndTime: "14:00:00", Audience: AudiencePublic, EffectiveFrom: date("2024-06-08")},
+	}
+
+	// Wednesday 5 June 2024; the coming Saturday is 8 June
+	from := time.Date(2024, 6, 5, 15, 30, 0, 0, time.UTC)
+	week := WeeklyHours(windows, AudiencePublic, from)
+
+	if len(week) != 7 {
+		t.Fatalf("got %d days, want 7", len(week))
+	}
+	for i, day := range week {
+		if day.DayOfWeek != i {
+			t.Errorf("week[%d].DayOfWeek = %d", i, day.DayOfWeek)
+		}
+	}
+
+	monday := week[1]
+	if monday.Date != "2024-06-10" {
+		t.Errorf("Monday date = %s, want 2024-06-10", monday.Date)
+	}
+	if len(monday.Intervals) != 2 || monday.Intervals[0] != (HoursInterval{"09:00:00", "17:00:00"}) {
+		t.Errorf("Monday intervals = %v, want 09:00-17:00 and 18:00-21:00", monday.Intervals)
+	}
+	if want := "9:00 AM – 5:00 PM, 6:00 PM – 9:00 PM"; monday.Summary != want {
+		t.Errorf("Monday summary = %q, want %q", monday.Summary, want)
+	}
+
+	if tuesday := week[2]; len(tuesday.Intervals) != 1 || tuesday.Intervals[0] != (HoursInterval{"09:00:00", "13:00:00"}) {
+		t.Errorf("Tuesday intervals = %v, want 09:00-13:00", tuesday.Intervals)
+	}
+
+	if wednesday := week[3]; wednesday.Date != "2024-06-05" || wednesday.Summary != "Closed" {
+		t.Errorf("Wednesday = %s %q, want members-only window hidden from the public", wednesday.Date, wednesday.Summary)
+	}
+	if members := WeeklyHours(windows, AudienceMembers, from)[3]; len(members.Intervals) != 1 {
+		t.Errorf("Wednesday intervals for members = %v, want the members window", members.Intervals)
+	}
+
+	if saturday := week[6]; len(saturday.Intervals) != 1 || saturday.Intervals[0] != (HoursInterval{"10:00:00", "14:00:00"}) {
+		t.Errorf("Saturday intervals = %v, want the window effective from 8 June", saturday.Intervals)
+	}
+}
+
+// TestOverlayClosures checks closures are attached to the days they overlap
+func TestOverlayClosures(t *testing.T) {
+	week := WeeklyHours(nil, AudiencePublic, time.Date(2024, 6, 5, 0, 0, 0, 0, time.UTC))
+	closure := FacilityClosure{
+		StartTime: time.Date(2024, 6, 7, 18, 0, 0, 0, time.UTC),
+		EndTime:   time.Date(2024, 6, 8, 12, 0, 0, 0, time.UTC),
+	}
+	OverlayClosures(week, []FacilityClosure{closure}, time.UTC)
+
+	for _, day := range week {
+		want := day.Date == "2024-06-07" || day.Date == "2024-06-08"
+		if got := len(day.Closures) == 1; got != want {
+			t.Errorf("%s has %d closures, want closure: %v", day.Date, len(day.Closures), want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// HouseholdMergeResult summarizes what a household merge moved
+type HouseholdMergeResult struct {
+	SourceHouseholdID    uuid.UUID   `json:"source_household_id"`
+	TargetHouseholdID    uuid.UUID   `json:"target_household_id"`
+	MovedParticipantIDs  []uuid.UUID `json:"moved_participant_ids"`
+	MergedParticipantIDs []uuid.UUID `json:"merged_participant_ids"` // source duplicates folded into a target participant
+	MovedBookings        int         `json:"moved_bookings"`
+	MovedMemberUserIDs   []uuid.UUID `json:"moved_member_user_ids"`
+}
+
+// duplicateParticipant pairs a source participant with the target participant it duplicates
+type duplicateParticipant struct {
+	sourceID uuid.UUID
+	targetID uuid.UUID
+	name     string
+}
+
+// MergeHouseholds moves everything from the source household into the target in one
+// transaction and soft-deletes the source. Participants with the same name and date of
+// birth in both households are treated as duplicates and folded into the target's
+// participant. The merge fails if a duplicate holds an active registration for something
+// the target participant is also actively registered for.
+func (db *DB) MergeHouseholds(sourceID, targetID uuid.UUID) (*HouseholdMergeResult, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a household into itself")
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock both households in a fixed order so concurrent merges cannot deadlock
+	rows, err := tx.Query(`
+		SELECT id, owner_user_id FROM households
+		WHERE id IN ($1, $2) AND deleted_at IS NULL
+		ORDER BY id
+		FOR UPDATE
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock households: %w", err)
+	}
+	owners := map[uuid.UUID]*uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		var ownerID *uuid.UUID
+		if err := rows.Scan(&id, &ownerID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan household: %w", err)
+		}
+		owners[id] = ownerID
+	}
+	rows.Close()
+	if _, ok := owners[sourceID]; !ok {
+		return nil, fmt.Errorf("source household not found")
+	}
+	if _, ok := owners[targetID]; !ok {
+		return nil, fmt.Errorf("target household not found")
+	}
+
+	result := &HouseholdMergeResult{
+		SourceHouseholdID:    sourceID,
+		TargetHouseholdID:    targetID,
+		MovedParticipantIDs:  []uuid.UUID{},
+		MergedParticipantIDs: []uuid.UUID{},
+		MovedMemberUserIDs:   []uuid.UUID{},
+	}
+
+	duplicates, err := findDuplicateParticipants(tx, sourceID, targetID)
+	if err != nil {
+		return nil, err
+	}
+	for _, dup := range duplicates {
+		if err := mergeDuplicateParticipant(tx, dup); err != nil {
+			return nil, err
+		}
+		result.MergedParticipantIDs = append(result.MergedParticipantIDs, dup.sourceID)
+	}
+
+	rows, err = tx.Query(`
+		UPDATE participants SET household_id = $2
+		WHERE household_id = $1
+		RETURNING id
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move participants: %w", err)
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan participant: %w", err)
+		}
+		result.MovedParticipantIDs = append(result.MovedParticipantIDs, id)
+	}
+	rows.Close()
+
+	res, err := tx.Exec(`UPDATE facility_bookings SET household_id = $2 WHERE household_id = $1`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move bookings: %w", err)
+	}
+	moved, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	result.MovedBookings = int(moved)
+
+	// The source owner and members join the target household
+	rows, err = tx.Query(`
+		DELETE FROM household_members WHERE household_id = $1
+		RETURNING user_id
+	`, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to remove source members: %w", err)
+	}
+	var memberIDs []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan member: %w", err)
+		}
+		memberIDs = append(memberIDs, id)
+	}
+	rows.Close()
+	if owner := owners[sourceID]; owner != nil {
+		memberIDs = append(memberIDs, *owner)
+	}
+	for _, userID := range memberIDs {
+		if owner := owners[targetID]; owner != nil && *owner == userID {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO household_members (household_id, user_id)
+			VALUES ($1, $2)
+			ON CONFLICT (household_id, user_id) DO NOTHING
+		`, targetID, userID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add household member: %w", err)
+		}
+		result.MovedMemberUserIDs = append(result.MovedMemberUserIDs, userID)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE households SET deleted_at = now(), merged_into_id = $2
+		WHERE id = $1
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete source household: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// findDuplicateParticipants pairs source participants with target participants of the
+// same name (case-insensitive) and date of birth
+func findDuplicateParticipants(tx *sql.Tx, sourceID, targetID uuid.UUID) ([]duplicateParticipant, error) {
+	rows, err := tx.Query(`
+		SELECT DISTINCT ON (s.id) s.id, t.id, s.first_name || ' ' || s.last_name
+		FROM participants s
+		JOIN participants t ON t.household_id = $2
+			AND lower(t.first_name) = lower(s.first_name)
+			AND lower(t.last_name) = lower(s.last_name)
+			AND t.dob IS NOT DISTINCT FROM s.dob
+		WHERE s.household_id = $1
+		ORDER BY s.id, t.created_at
+	`, sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find duplicate participants: %w", err)
+	}
+	defer rows.Close()
+
+	var duplicates []duplicateParticipant
+	for rows.Next() {
+		var d duplicateParticipant
+		if err := rows.Scan(&d.sourceID, &d.targetID, &d.name); err != nil {
+			return nil, fmt.Errorf("failed to scan duplicate participant: %w", err)
+		}
+		duplicates = append(duplicates, d)
+	}
+	return duplicates, rows.Err()
+}
+
+// mergeDuplicateParticipant moves a duplicate's registrations, waitlist positions, waivers,
+// forms and booking references to the target participant, then deletes the duplicate.
+// Records the target already has an equivalent of are dropped.
+func mergeDuplicateParticipant(tx *sql.Tx, dup duplicateParticipant) error {
+	// Both participants actively registered for the same thing cannot be merged safely
+	var conflicts int
+	err := tx.QueryRow(`
+		SELECT COUNT(*)
+		FROM registrations s
+		JOIN registrations t ON t.participant_id = $2
+			AND t.parent_type = s.parent_type AND t.parent_id = s.parent_id
+			AND t.session_id IS NOT DISTINCT FROM s.session_id
+		WHERE s.participant_id = $1 AND s.status != 'cancelled' AND t.status != 'cancelled'
+	`, dup.sourceID, dup.targetID).Scan(&conflicts)
+	if err != nil {
+		return fmt.Errorf("failed to check registration conflicts: %w", err)
+	}
+	if conflicts > 0 {
+		return fmt.Errorf("participant %s is registered in both households for the same program or event; cancel one registration before merging", dup.name)
+	}
+
+	// An active source registration replaces the target's cancelled one
+	_, err = tx.Exec(`
+		DELETE FROM registrations t
+		USING registrations s
+		WHERE t.participant_id = $2 AND s.participant_id = $1
+			AND t.parent_type = s.parent_type AND t.parent_id = s.parent_id
+			AND t.session_id IS NOT DISTINCT FROM s.session_id
+			AND t.status = 'cancelled' AND s.status != 'cancelled'
+	`, dup.sourceID, dup.targetID)
+	if err != nil {
+		return fmt.Errorf("failed to remove superseded registrations: %w", err)
+	}
+
+	statements := []struct {
+		query string
+		what  string
+	}{
+		{`
+			UPDATE registrations s SET participant_id = $2
+			WHERE s.participant_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM registrations t
+				WHERE t.participant_id = $2 AND t.parent_type = s.parent_type
+					AND t.parent_id = s.parent_id AND t.session_id IS NOT DISTINCT FROM s.session_id
+			)
+		`, "registrations"},
+		{`
+			UPDATE waitlist_positions s SET participant_id = $2
+			WHERE s.participant_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM waitlist_positions t
+				WHERE t.participant_id = $2 AND t.parent_type = s.parent_type
+					AND t.parent_id = s.parent_id AND t.session_id IS NOT DISTINCT FROM s.session_id
+			)
+		`, "waitlist positions"},
+		{`
+			UPDATE participant_waivers s SET participant_id = $2
+			WHERE s.participant_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM participant_waivers t
+				WHERE t.participant_id = $2 AND t.waiver_key = s.waiver_key
+			)
+		`, "waivers"},
+		{`
+			UPDATE participant_waiver_acceptances s SET participant_id = $2
+			WHERE s.participant_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM participant_waiver_acceptances t
+				WHERE t.participant_id = $2 AND t.waiver_id = s.waiver_id
+					AND t.waiver_version = s.waiver_version AND t.program_id IS NOT DISTINCT FROM s.program_id
+			)
+		`, "waiver acceptances"},
+		{`
+			UPDATE participant_form_submissions s SET participant_id = $2
+			WHERE s.participant_id = $1 AND NOT EXISTS (
+				SELECT 1 FROM participant_form_submissions t
+				WHERE t.participant_id = $2 AND t.form_template_id = s.form_template_id
+			)
+		`, "form submissions"},
+		{`
+			UPDATE facility_bookings
+			SET participant_ids = CASE
+				WHEN $2 = ANY(participant_ids) THEN array_remove(participant_ids, $1)
+				ELSE array_replace(participant_ids, $1, $2)
+			END
+			WHERE $1 = ANY(participant_ids)
+		`, "booking participants"},
+		{`DELETE FROM participants WHERE id = $1`, "duplicate participant"},
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt.query, dup.sourceID, dup.targetID); err != nil {
+			return fmt.Errorf("failed to merge %s: %w", stmt.what, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// IdempotencyKeyTTL is how long an idempotency key replays its original response; after
+// that the key is treated as new and the maintenance job removes it
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// Idempotency key scopes
+const (
+	IdempotencyScopeBooking      = "booking"
+	IdempotencyScopeRegistration = "registration"
+)
+
+// GetIdempotentResponse loads the response snapshot stored for a key into out. It reports
+// false when the key is unknown or older than IdempotencyKeyTTL.
+func (db *DB) GetIdempotentResponse(scope, key string, out interface{}) (bool, error) {
+	var response []byte
+	err := db.QueryRow(`
+		SELECT response FROM idempotency_keys
+		WHERE scope = $1 AND key = $2 AND created_at > $3
+	`, scope, key, time.Now().Add(-IdempotencyKeyTTL)).Scan(&response)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if err := json.Unmarshal(response, out); err != nil {
+		return false, fmt.Errorf("failed to decode idempotent response: %w", err)
+	}
+	return true, nil
+}
+
+// saveIdempotentResponseInTx stores the response for a key, replacing an expired entry.
+// It fails if the key is still live, so a reused key rolls the transaction back.
+func saveIdempotentResponseInTx(tx *sql.Tx, scope, key string, response interface{}) error {
+	snapshot, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotent response: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (scope, key, response)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (scope, key) DO UPDATE SET response = EXCLUDED.response, created_at = NOW()
+		WHERE idempotency_keys.created_at <= $4
+	`, scope, key, snapshot, time.Now().Add(-IdempotencyKeyTTL))
+	if err != nil {
+		return fmt.Errorf("failed to save idempotency key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("idempotency key already used")
+	}
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys removes keys older than IdempotencyKeyTTL and returns how
+// many were removed
+func (db *DB) DeleteExpiredIdempotencyKeys() (int64, error) {
+	result, err := db.Exec(`DELETE FROM idempotency_keys WHERE created_at <= $1`, time.Now().Add(-IdempotencyKeyTTL))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestIdempotencyKeys tests a key replays its registration until it expires, after which
+// it is treated as new and purged
+func TestIdempotencyKeys(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+
+	key := "test-" + uuid.New().String()
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM idempotency_keys WHERE key = $1`, key)
+	})
+
+	register := func() (*RegistrationResult, error) {
+		return db.CreateRegistration(RegistrationRequest{
+			ParentType:     "program",
+			ParentID:       programID,
+			ParticipantID:  createTestParticipant(t, db),
+			IdempotencyKey: &key,
+		})
+	}
+
+	first, err := register()
+	if err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	var stored RegistrationResult
+	found, err := db.GetIdempotentResponse(IdempotencyScopeRegistration, key, &stored)
+	if err != nil || !found || stored.Registration == nil || stored.Registration.ID != first.Registration.ID {
+		t.Fatalf("GetIdempotentResponse = %+v, %v, %v; want the first registration", stored, found, err)
+	}
+	if found, _ := db.GetIdempotentResponse(IdempotencyScopeBooking, key, &stored); found {
+		t.Errorf("key found under the booking scope, want scopes kept apart")
+	}
+
+	if _, err := register(); err == nil {
+		t.Errorf("registering again with a live key succeeded, want an error")
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM registrations WHERE parent_id = $1`, programID); n != 1 {
+		t.Errorf("registrations = %d, want 1", n)
+	}
+
+	_, err = db.Exec(`UPDATE idempotency_keys SET created_at = $2 WHERE key = $1`, key, time.Now().Add(-IdempotencyKeyTTL-time.Minute))
+	if err != nil {
+		t.Fatalf("failed to age idempotency key: %v", err)
+	}
+	if found, err := db.GetIdempotentResponse(IdempotencyScopeRegistration, key, &stored); err != nil || found {
+		t.Errorf("expired key found = %v, %v; want it treated as new", found, err)
+	}
+
+	second, err := register()
+	if err != nil {
+		t.Fatalf("registering with an expired key: %v", err)
+	}
+	found, err = db.GetIdempotentResponse(IdempotencyScopeRegistration, key, &stored)
+	if err != nil || !found || stored.Registration.ID != second.Registration.ID {
+		t.Errorf("reused key replays %+v, %v, %v; want the new registration", stored, found, err)
+	}
+
+	_, err = db.Exec(`UPDATE idempotency_keys SET created_at = $2 WHERE key = $1`, key, time.Now().Add(-IdempotencyKeyTTL-time.Minute))
+	if err != nil {
+		t.Fatalf("failed to age idempotency key: %v", err)
+	}
+	if count, err := db.DeleteExpiredIdempotencyKeys(); err != nil || count < 1 {
+		t.Errorf("DeleteExpiredIdempotencyKeys = %d, %v; want the expired key removed", count, err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM idempotency_keys WHERE key = $1`, key); n != 0 {
+		t.Errorf("expired key rows = %d, want 0", n)
+	}
+}
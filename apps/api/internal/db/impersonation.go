@@ -0,0 +1,176 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ImpersonationTTL is how long an impersonation session lasts unless it is ended sooner
+const ImpersonationTTL = 30 * time.Minute
+
+// ImpersonationSession is an admin support session acting as another user
+type ImpersonationSession struct {
+	ID          uuid.UUID  `json:"id"`
+	AdminUserID uuid.UUID  `json:"admin_user_id"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Reason      string     `json:"reason"`
+	CreatedAt   time.Time  `json:"created_at"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	EndedAt     *time.Time `json:"ended_at,omitempty"`
+
+	// Joined fields
+	AdminName   string `json:"admin_name,omitempty"`
+	UserName    string `json:"user_name,omitempty"`
+	UserEmail   string `json:"user_email,omitempty"`
+	ActionCount int    `json:"action_count"`
+}
+
+// IsActive reports whether the session can still be used at the given time
+func (s *ImpersonationSession) IsActive(now time.Time) bool {
+	return s.EndedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// ImpersonationAction is one request made during an impersonation session
+type ImpersonationAction struct {
+	ID        int64     `json:"id"`
+	SessionID uuid.UUID `json:"session_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	Blocked   bool      `json:"blocked"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// impersonationSessionColumns is the column list scanned by scanImpersonationSession
+const impersonationSessionColumns = `s.id, s.admin_user_id, s.user_id, s.reason, s.created_at, s.expires_at, s.ended_at,
+			a.first_name || ' ' || a.last_name, u.first_name || ' ' || u.last_name, u.email,
+			(SELECT COUNT(*) FROM impersonation_actions ia WHERE ia.session_id = s.id)`
+
+// scanImpersonationSession scans a row selected with impersonationSessionColumns
+func scanImpersonationSession(row rowScanner) (*ImpersonationSession, error) {
+	var s ImpersonationSession
+	err := row.Scan(
+		&s.ID, &s.AdminUserID, &s.UserID, &s.Reason, &s.CreatedAt, &s.ExpiresAt, &s.EndedAt,
+		&s.AdminName, &s.UserName, &s.UserEmail, &s.ActionCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateImpersonationSession starts a session in which adminUserID acts as userID
+func (db *DB) CreateImpersonationSession(adminUserID, userID uuid.UUID, reason string) (*ImpersonationSession, error) {
+	s := &ImpersonationSession{AdminUserID: adminUserID, UserID: userID, Reason: reason}
+	err := db.QueryRow(`
+		INSERT INTO impersonation_sessions (admin_user_id, user_id, reason, expires_at)
+		VALUES ($1, $2, $3, NOW() + $4 * INTERVAL '1 second')
+		RETURNING id, created_at, expires_at
+	`, adminUserID, userID, reason, int(ImpersonationTTL.Seconds())).Scan(&s.ID, &s.CreatedAt, &s.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create impersonation session: %w", err)
+	}
+	return s, nil
+}
+
+// GetImpersonationSession retrieves an impersonation session by ID
+func (db *DB) GetImpersonationSession(id uuid.UUID) (*ImpersonationSession, error) {
+	s, err := scanImpersonationSession(db.QueryRow(`
+		SELECT `+impersonationSessionColumns+`
+		FROM impersonation_sessions s
+		JOIN users a ON a.id = s.admin_user_id
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id = $1
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation session: %w", err)
+	}
+	return s, nil
+}
+
+// GetImpersonationSessions lists impersonation sessions, newest first
+func (db *DB) GetImpersonationSessions(limit int) ([]ImpersonationSession, error) {
+	rows, err := db.Query(`
+		SELECT `+impersonationSessionColumns+`
+		FROM impersonation_sessions s
+		JOIN users a ON a.id = s.admin_user_id
+		JOIN users u ON u.id = s.user_id
+		ORDER BY s.created_at DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []ImpersonationSession{}
+	for rows.Next() {
+		s, err := scanImpersonationSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan impersonation session: %w", err)
+		}
+		sessions = append(sessions, *s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get impersonation sessions: %w", err)
+	}
+	return sessions, nil
+}
+
+// EndImpersonationSession ends a session before it expires. Ending a session that has
+// already ended or expired is not an error.
+func (db *DB) EndImpersonationSession(id uuid.UUID) error {
+	_, err := db.Exec(`
+		UPDATE impersonation_sessions SET ended_at = NOW()
+		WHERE id = $1 AND ended_at IS NULL AND expires_at > NOW()
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to end impersonation session: %w", err)
+	}
+	return nil
+}
+
+// RecordImpersonationAction adds a request to a session's audit log
+func (db *DB) RecordImpersonationAction(a ImpersonationAction) error {
+	_, err := db.Exec(`
+		INSERT INTO impersonation_actions (session_id, method, path, status, blocked)
+		VALUES ($1, $2, $3, $4, $5)
+	`, a.SessionID, a.Method, a.Path, a.Status, a.Blocked)
+	if err != nil {
+		return fmt.Errorf("failed to record impersonation action: %w", err)
+	}
+	return nil
+}
+
+// GetImpersonationActions retrieves a session's audit log, oldest first
+func (db *DB) GetImpersonationActions(sessionID uuid.UUID) ([]ImpersonationAction, error) {
+	rows, err := db.Query(`
+		SELECT id, session_id, method, path, status, blocked, created_at
+		FROM impersonation_actions
+		WHERE session_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get impersonation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []ImpersonationAction{}
+	for rows.Next() {
+		var a ImpersonationAction
+		if err := rows.Scan(&a.ID, &a.SessionID, &a.Method, &a.Path, &a.Status, &a.Blocked, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan impersonation action: %w", err)
+		}
+		actions = append(actions, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get impersonation actions: %w", err)
+	}
+	return actions, nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestImpersonationSessionIsActive checks sessions end when they expire or are ended
+func TestImpersonationSessionIsActive(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	ended := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session ImpersonationSession
+		want    bool
+	}{
+		{"before expiry", ImpersonationSession{ExpiresAt: now.Add(time.Minute)}, true},
+		{"at expiry", ImpersonationSession{ExpiresAt: now}, false},
+		{"ended early", ImpersonationSession{ExpiresAt: now.Add(time.Minute), EndedAt: &ended}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.session.IsActive(now); got != tt.want {
+			t.Errorf("%s: IsActive = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestImpersonationAuditLog tests that a session records its actions and can be ended
+func TestImpersonationAuditLog(t *testing.T) {
+	db := setupTestDB(t)
+
+	createUser := func(firstName string) uuid.UUID {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO users (email, password_hash, first_name, last_name)
+			VALUES ($1, 'not-a-real-hash', $2, 'Test')
+			RETURNING id
+		`, "test-"+uuid.New().String()+"@example.com", firstName).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test user: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Exec(`DELETE FROM users WHERE id = $1`, id)
+		})
+		return id
+	}
+	adminID, userID := createUser("Admin"), createUser("Parent")
+
+	session, err := db.CreateImpersonationSession(adminID, userID, "Cannot register for swim")
+	if err != nil {
+		t.Fatalf("CreateImpersonationSession: %v", err)
+	}
+	if !session.IsActive(time.Now()) {
+		t.Errorf("new session expires at %v, want active", session.ExpiresAt)
+	}
+
+	actions := []ImpersonationAction{
+		{SessionID: session.ID, Method: "GET", Path: "/api/me", Status: 200},
+		{SessionID: session.ID, Method: "POST", Path: "/api/registrations/cancel", Status: 403, Blocked: true},
+	}
+	for _, a := range actions {
+		if err := db.RecordImpersonationAction(a); err != nil {
+			t.Fatalf("RecordImpersonationAction: %v", err)
+		}
+	}
+
+	got, err := db.GetImpersonationSession(session.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetImpersonationSession: %v, %v", got, err)
+	}
+	if got.ActionCount != 2 || got.AdminName != "Admin Test" || got.UserName != "Parent Test" {
+		t.Errorf("session = %+v, want 2 actions by Admin Test as Parent Test", got)
+	}
+
+	logged, err := db.GetImpersonationActions(session.ID)
+	if err != nil {
+		t.Fatalf("GetImpersonationActions: %v", err)
+	}
+	if len(logged) != 2 || logged[0].Path != "/api/me" || !logged[1].Blocked {
+		t.Errorf("actions = %+v, want /api/me then the blocked cancel", logged)
+	}
+
+	if err := db.EndImpersonationSession(session.ID); err != nil {
+		t.Fatalf("EndImpersonationSession: %v", err)
+	}
+	got, err = db.GetImpersonationSession(session.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetImpersonationSession: %v, %v", got, err)
+	}
+	if got.IsActive(time.Now()) {
+		t.Error("session is still active after being ended")
+	}
+}
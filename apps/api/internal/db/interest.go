@@ -0,0 +1,120 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InterestEntry is a user waiting to hear when a program's registration opens
+type InterestEntry struct {
+	ID         uuid.UUID  `json:"id"`
+	ProgramID  uuid.UUID  `json:"program_id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Position   int        `json:"position"` // 1-based order of joining the list
+	CreatedAt  time.Time  `json:"created_at"`
+	NotifiedAt *time.Time `json:"notified_at,omitempty"`
+
+	// Set on admin listings
+	UserName  string `json:"user_name,omitempty"`
+	UserEmail string `json:"user_email,omitempty"`
+}
+
+// AddProgramInterest puts the user on the program's interest list. Joining twice keeps
+// the original place; created reports whether a new entry was added.
+func (db *DB) AddProgramInterest(programID, userID uuid.UUID) (entry *InterestEntry, created bool, err error) {
+	result, err := db.Exec(`
+		INSERT INTO interest_list (program_id, user_id)
+		VALUES ($1, $2)
+		ON CONFLICT (program_id, user_id) DO NOTHING
+	`, programID, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to add interest: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	var e InterestEntry
+	err = db.QueryRow(`
+		SELECT id, program_id, user_id, position, created_at, notified_at
+		FROM (
+			SELECT i.*, ROW_NUMBER() OVER (ORDER BY created_at, id) AS position
+			FROM interest_list i
+			WHERE program_id = $1
+		) ranked
+		WHERE user_id = $2
+	`, programID, userID).Scan(&e.ID, &e.ProgramID, &e.UserID, &e.Position, &e.CreatedAt, &e.NotifiedAt)
+	if err == sql.ErrNoRows {
+		return nil, false, fmt.Errorf("interest entry not found")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get interest entry: %w", err)
+	}
+
+	return &e, rows > 0, nil
+}
+
+// GetProgramInterest returns a program's interest list in the order users joined
+func (db *DB) GetProgramInterest(programID uuid.UUID) ([]InterestEntry, error) {
+	rows, err := db.Query(`
+		SELECT i.id, i.program_id, i.user_id,
+			ROW_NUMBER() OVER (ORDER BY i.created_at, i.id),
+			i.created_at, i.notified_at, u.first_name || ' ' || u.last_name, u.email
+		FROM interest_list i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.program_id = $1
+		ORDER BY i.created_at, i.id
+	`, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interest list: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []InterestEntry{}
+	for rows.Next() {
+		var e InterestEntry
+		err := rows.Scan(&e.ID, &e.ProgramID, &e.UserID, &e.Position, &e.CreatedAt, &e.NotifiedAt, &e.UserName, &e.UserEmail)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interest entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get interest list: %w", err)
+	}
+	return entries, nil
+}
+
+// QueueRegistrationOpenNotifications queues a REGISTRATION_OPEN email for everyone on the
+// interest list of an active program whose registration has opened, in the order they
+// joined, and marks them notified. Returns how many were queued.
+func (db *DB) QueueRegistrationOpenNotifications() (int, error) {
+	result, err := db.Exec(`
+		WITH due AS (
+			UPDATE interest_list i SET notified_at = now()
+			FROM programs p
+			WHERE p.id = i.program_id
+				AND i.notified_at IS NULL
+				AND p.is_active
+				AND p.registration_opens_at <= now()
+			RETURNING i.id, i.created_at
+		)
+		INSERT INTO notification_queue (type, payload)
+		SELECT 'REGISTRATION_OPEN', jsonb_build_object('interest_id', id)
+		FROM due
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue registration open notifications: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestProgramInterest tests joining an interest list and notifying it when registration opens
+func TestProgramInterest(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+
+	var userIDs []uuid.UUID
+	for i := 0; i < 2; i++ {
+		var userID uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO users (email, password_hash, first_name, last_name)
+			VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+			RETURNING id
+		`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+		if err != nil {
+			t.Fatalf("failed to create test user: %v", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'interest_id' IN (SELECT id::text FROM interest_list WHERE program_id = $1)`, programID)
+		for _, userID := range userIDs {
+			db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+		}
+	})
+
+	_, err := db.Exec(`UPDATE programs SET registration_opens_at = now() + interval '1 day' WHERE id = $1`, programID)
+	if err != nil {
+		t.Fatalf("failed to set registration opening: %v", err)
+	}
+
+	first, created, err := db.AddProgramInterest(programID, userIDs[0])
+	if err != nil || !created || first.Position != 1 {
+		t.Fatalf("AddProgramInterest = %+v, %v, %v; want new entry at position 1", first, created, err)
+	}
+	second, _, err := db.AddProgramInterest(programID, userIDs[1])
+	if err != nil || second.Position != 2 {
+		t.Fatalf("second user position = %+v, %v; want 2", second, err)
+	}
+	again, created, err := db.AddProgramInterest(programID, userIDs[0])
+	if err != nil || created || again.ID != first.ID || again.Position != 1 {
+		t.Errorf("joining twice = %+v, created %v, %v; want the original entry", again, created, err)
+	}
+
+	countQueued := func() int {
+		return countRows(t, db, `
+			SELECT COUNT(*) FROM notification_queue
+			WHERE type = 'REGISTRATION_OPEN'
+				AND payload->>'interest_id' IN (SELECT id::text FROM interest_list WHERE program_id = $1)
+		`, programID)
+	}
+
+	if _, err := db.QueueRegistrationOpenNotifications(); err != nil {
+		t.Fatalf("QueueRegistrationOpenNotifications: %v", err)
+	}
+	if n := countQueued(); n != 0 {
+		t.Errorf("queued %d notifications before registration opened, want 0", n)
+	}
+
+	_, err = db.Exec(`UPDATE programs SET registration_opens_at = now() - interval '1 minute' WHERE id = $1`, programID)
+	if err != nil {
+		t.Fatalf("failed to open registration: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := db.QueueRegistrationOpenNotifications(); err != nil {
+			t.Fatalf("QueueRegistrationOpenNotifications: %v", err)
+		}
+	}
+	if n := countQueued(); n != 2 {
+		t.Errorf("queued %d notifications after registration opened, want 2 (once per user)", n)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -9,14 +10,16 @@ import (
 
 // User represents a public user account
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	FirstName    string    `json:"first_name"`
-	LastName     string    `json:"last_name"`
-	Phone        *string   `json:"phone,omitempty"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
+	ID                   uuid.UUID `json:"id"`
+	Email                string    `json:"email"`
+	PasswordHash         string    `json:"-"`
+	FirstName            string    `json:"first_name"`
+	LastName             string    `json:"last_name"`
+	Phone                *string   `json:"phone,omitempty"`
+	Role                 string    `json:"role"`
+	IsMember             bool      `json:"is_member"`
+	AdvanceBookingExempt bool      `json:"advance_booking_exempt"`
+	CreatedAt            time.Time `json:"created_at"`
 }
 
 // Household represents a family/household
@@ -31,6 +34,10 @@ type Household struct {
 	State        *string   `json:"state,omitempty"`
 	Zip          *string   `json:"zip,omitempty"`
 	CreatedAt    time.Time `json:"created_at"`
+
+	// Inherited by new participants that are created without an emergency contact
+	DefaultEmergencyContactName  *string `json:"default_emergency_contact_name,omitempty"`
+	DefaultEmergencyContactPhone *string `json:"default_emergency_contact_phone,omitempty"`
 }
 
 // Participant represents a person who can be registered
@@ -47,6 +54,9 @@ type Participant struct {
 	IsFavorite             bool       `json:"is_favorite"`
 	Gender                 *string    `json:"gender,omitempty"`
 	ShirtSize              *string    `json:"shirt_size,omitempty"`
+	PhotoURL               *string    `json:"photo_url,omitempty"`
+	DietaryRestrictions    []string   `json:"dietary_restrictions,omitempty"`
+	AccessibilityNeeds     []string   `json:"accessibility_needs,omitempty"`
 	CreatedAt              time.Time  `json:"created_at"`
 }
 
@@ -75,6 +85,33 @@ type Program struct {
 	CreatedAt     time.Time  `json:"created_at"`
 	UpdatedAt     time.Time  `json:"updated_at"`
 
+	// Admin-only fields (left nil on public queries)
+	OverbookPct       *int `json:"overbook_pct,omitempty"`
+	EffectiveCapacity *int `json:"effective_capacity,omitempty"`
+
+	// RegistrationQuestions is a form schema answered on each registration
+	RegistrationQuestions json.RawMessage `json:"registration_questions,omitempty"`
+
+	// RequiresApproval makes new registrations pending until an admin approves them
+	RequiresApproval bool `json:"requires_approval"`
+
+	// RegistrationOpensAt is when registration opens; before then families can only join
+	// the interest list. Nil means registration is open.
+	RegistrationOpensAt *time.Time `json:"registration_opens_at,omitempty"`
+
+	// PublishedAt and UnpublishedAt bound when the program is listed publicly; nil means
+	// no bound
+	PublishedAt   *time.Time `json:"published_at,omitempty"`
+	UnpublishedAt *time.Time `json:"unpublished_at,omitempty"`
+
+	// Category groups programs in listings and participation reports, e.g. "Aquatics"
+	Category *string `json:"category,omitempty"`
+
+	// MinorEmergencyContactRequired blocks registering participants younger than
+	// MinorAgeThreshold who have no emergency contact phone
+	MinorEmergencyContactRequired bool `json:"minor_emergency_contact_required"`
+	MinorAgeThreshold             int  `json:"minor_age_threshold"`
+
 	// Computed fields
 	Sessions      []Session `json:"sessions,omitempty"`
 	SpotsLeft     *int      `json:"spots_left,omitempty"`
@@ -96,11 +133,12 @@ type Event struct {
 	UpdatedAt   time.Time  `json:"updated_at"`
 
 	// Computed fields
-	SpotsLeft     *int `json:"spots_left,omitempty"`
-	WaitlistCount *int `json:"waitlist_count,omitempty"`
+	SpotsLeft     *int      `json:"spots_left,omitempty"`
+	WaitlistCount *int      `json:"waitlist_count,omitempty"`
+	Sessions      []Session `json:"sessions,omitempty"`
 }
 
-// Session represents a specific occurrence of a program
+// Session represents a specific occurrence of a program or event
 type Session struct {
 	ID               uuid.UUID  `json:"id"`
 	ParentType       string     `json:"parent_type"`
@@ -125,11 +163,61 @@ type Registration struct {
 	Status        string     `json:"status"`
 	CreatedAt     time.Time  `json:"created_at"`
 
+	// Answers to the program's registration questions, keyed by question id
+	Answers json.RawMessage `json:"answers,omitempty"`
+
 	// Joined fields
 	Participant *Participant `json:"participant,omitempty"`
 	ProgramInfo *Program     `json:"program,omitempty"`
 	EventInfo   *Event       `json:"event,omitempty"`
 	SessionInfo *Session     `json:"session,omitempty"`
+
+	// WaitlistPosition is set on waitlisted registrations by GetUserRegistrationDetails
+	WaitlistPosition *int `json:"waitlist_position,omitempty"`
+
+	// OfferExpiresAt is the deadline to accept an offered waitlist spot, set by
+	// GetUserRegistrationDetails
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+
+	// CompletionState is what the family still has to do, set by SetCompletionStates
+	CompletionState string `json:"completion_state,omitempty"`
+}
+
+// RegistrationStatusChange records a single status transition of a registration
+type RegistrationStatusChange struct {
+	ID             int64      `json:"id"`
+	RegistrationID uuid.UUID  `json:"registration_id"`
+	OldStatus      *string    `json:"old_status,omitempty"`
+	NewStatus      string     `json:"new_status"`
+	ChangedBy      *uuid.UUID `json:"changed_by,omitempty"`
+	ReasonCode     *string    `json:"reason_code,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// RegistrationWaiverStatus describes whether a participant has accepted a program waiver
+type RegistrationWaiverStatus struct {
+	WaiverID   uuid.UUID `json:"waiver_id"`
+	Title      string    `json:"title"`
+	Version    int       `json:"version"`
+	IsRequired bool      `json:"is_required"`
+	Accepted   bool      `json:"accepted"`
+}
+
+// RegistrationDetail is a registration with its full admin context
+type RegistrationDetail struct {
+	Registration
+	Guardian *User                      `json:"guardian,omitempty"`
+	Waivers  []RegistrationWaiverStatus `json:"waivers"`
+	History  []RegistrationStatusChange `json:"history"`
+	Pauses   []RegistrationPause        `json:"pauses"`
+}
+
+// RegistrationHistory is a registration with its status history, for a participant's
+// registration timeline
+type RegistrationHistory struct {
+	Registration
+	History []RegistrationStatusChange `json:"history"`
 }
 
 // WaitlistPosition represents a position on a waitlist
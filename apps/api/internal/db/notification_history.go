@@ -0,0 +1,76 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// NotificationHistoryEntry is an email sent to a user for a processed notification, with
+// its latest delivery status
+type NotificationHistoryEntry struct {
+	NotificationID  int64      `json:"notification_id"`
+	Type            string     `json:"type"`
+	Subject         string     `json:"subject"`
+	Recipient       string     `json:"recipient"`
+	SentAt          time.Time  `json:"sent_at"`
+	Status          string     `json:"status"` // sent, delivered, bounced or complained
+	StatusUpdatedAt *time.Time `json:"status_updated_at,omitempty"`
+}
+
+// ArchiveNotification moves a successfully processed notification from the queue to
+// sent_notifications, counting the attempt that succeeded
+func (db *DB) ArchiveNotification(id int64) error {
+	return db.archiveNotification(id, nil)
+}
+
+// ArchiveSkippedNotification moves a notification that was dropped without sending from
+// the queue to sent_notifications, recording why it was skipped so it is not taken for
+// one that was sent
+func (db *DB) ArchiveSkippedNotification(id int64, reason string) error {
+	return db.archiveNotification(id, &reason)
+}
+
+func (db *DB) archiveNotification(id int64, skippedReason *string) error {
+	_, err := db.Exec(`
+		WITH sent AS (
+			DELETE FROM notification_queue WHERE id = $1
+			RETURNING id, type, payload, attempts, created_at
+		)
+		INSERT INTO sent_notifications (id, type, payload, attempts, queued_at, skipped_reason)
+		SELECT id, type, payload, attempts + 1, created_at, $2 FROM sent
+		ON CONFLICT (id) DO NOTHING
+	`, id, skippedReason)
+	if err != nil {
+		return fmt.Errorf("failed to archive notification: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationHistory returns the emails sent to a user for notifications, newest
+// first. Emails are matched by the user they were sent to rather than the user's current
+// address, so changing an address keeps its history. It is empty for an unknown user.
+func (db *DB) GetNotificationHistory(userID uuid.UUID, limit int) ([]NotificationHistoryEntry, error) {
+	rows, err := db.Query(`
+		SELECT m.notification_id, m.notification_type, m.subject, m.recipient, m.sent_at, m.status, m.status_updated_at
+		FROM email_messages m
+		WHERE m.user_id = $1 AND m.notification_id IS NOT NULL
+		ORDER BY m.sent_at DESC, m.notification_id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []NotificationHistoryEntry{}
+	for rows.Next() {
+		var e NotificationHistoryEntry
+		if err := rows.Scan(&e.NotificationID, &e.Type, &e.Subject, &e.Recipient, &e.SentAt, &e.Status, &e.StatusUpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification history: %w", err)
+		}
+		history = append(history, e)
+	}
+	return history, rows.Err()
+}
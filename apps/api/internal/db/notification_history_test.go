@@ -0,0 +1,131 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestNotificationHistory tests a processed notification moves out of the queue and its
+// email shows in the recipient's history with the delivery status
+func TestNotificationHistory(t *testing.T) {
+	db := setupTestDB(t)
+
+	email := "test-" + uuid.New().String() + "@example.com"
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, email).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+
+	var notifID int64
+	err = db.QueryRow(`
+		INSERT INTO notification_queue (type, payload, attempts)
+		VALUES ('BOOKING_CONFIRMED', $1, 1)
+		RETURNING id
+	`, `{"booking_id": "`+uuid.New().String()+`"}`).Scan(&notifID)
+	if err != nil {
+		t.Fatalf("failed to queue test notification: %v", err)
+	}
+
+	notifType := "BOOKING_CONFIRMED"
+	sent := &EmailMessage{
+		MessageID:        "<" + uuid.New().String() + "@example.com>",
+		NotificationID:   &notifID,
+		NotificationType: &notifType,
+		Recipient:        email,
+		Subject:          "Booking Confirmed",
+	}
+	if err := db.RecordEmailSent(sent); err != nil {
+		t.Fatalf("RecordEmailSent: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM email_events WHERE message_id = $1`, sent.MessageID)
+		db.Exec(`DELETE FROM email_messages WHERE id = $1`, sent.ID)
+		db.Exec(`DELETE FROM notification_queue WHERE id = $1`, notifID)
+		db.Exec(`DELETE FROM sent_notifications WHERE id = $1`, notifID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	if err := db.ArchiveNotification(notifID); err != nil {
+		t.Fatalf("ArchiveNotification: %v", err)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM notification_queue WHERE id = $1`, notifID); n != 0 {
+		t.Errorf("archived notification still queued %d times", n)
+	}
+	var attempts int
+	db.QueryRow(`SELECT attempts FROM sent_notifications WHERE id = $1`, notifID).Scan(&attempts)
+	if attempts != 2 {
+		t.Errorf("archived attempts = %d, want 2 including the one that succeeded", attempts)
+	}
+
+	// A notification dropped without sending is archived with why it was skipped
+	var skippedID int64
+	err = db.QueryRow(`
+		INSERT INTO notification_queue (type, payload)
+		VALUES ('BOOKING_CONFIRMED', $1)
+		RETURNING id
+	`, `{"booking_id": "`+uuid.New().String()+`"}`).Scan(&skippedID)
+	if err != nil {
+		t.Fatalf("failed to queue test notification: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE id = $1`, skippedID)
+		db.Exec(`DELETE FROM sent_notifications WHERE id = $1`, skippedID)
+	})
+	if err := db.ArchiveSkippedNotification(skippedID, "booking was cancelled"); err != nil {
+		t.Fatalf("ArchiveSkippedNotification: %v", err)
+	}
+	var skippedReason, sentReason *string
+	db.QueryRow(`SELECT skipped_reason FROM sent_notifications WHERE id = $1`, skippedID).Scan(&skippedReason)
+	db.QueryRow(`SELECT skipped_reason FROM sent_notifications WHERE id = $1`, notifID).Scan(&sentReason)
+	if skippedReason == nil || *skippedReason != "booking was cancelled" || sentReason != nil {
+		t.Errorf("skipped reasons = %v and %v, want the reason on the skipped notification only", skippedReason, sentReason)
+	}
+
+	if _, err := db.RecordEmailEvent(EmailEvent{MessageID: sent.MessageID, EventType: EmailEventDelivered}); err != nil {
+		t.Fatalf("RecordEmailEvent: %v", err)
+	}
+
+	history, err := db.GetNotificationHistory(userID, 10)
+	if err != nil {
+		t.Fatalf("GetNotificationHistory: %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("history has %d entries, want 1", len(history))
+	}
+	if h := history[0]; h.NotificationID != notifID || h.Type != notifType || h.Subject != sent.Subject || h.Status != "delivered" {
+		t.Errorf("history entry = %+v, want the delivered %s email", h, notifType)
+	}
+
+	if other, err := db.GetNotificationHistory(uuid.New(), 10); err != nil || len(other) != 0 {
+		t.Errorf("history for an unknown user = %v, %v; want empty", other, err)
+	}
+
+	// The history follows the user, not the address: after a change of address it is
+	// kept, and whoever signs up with the old address does not see it
+	if _, err := db.Exec(`UPDATE users SET email = $2 WHERE id = $1`, userID, "test-"+uuid.New().String()+"@example.com"); err != nil {
+		t.Fatalf("failed to change email: %v", err)
+	}
+	var newcomerID uuid.UUID
+	err = db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Newcomer')
+		RETURNING id
+	`, email).Scan(&newcomerID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	t.Cleanup(func() { db.Exec(`DELETE FROM users WHERE id = $1`, newcomerID) })
+
+	if history, err := db.GetNotificationHistory(userID, 10); err != nil || len(history) != 1 {
+		t.Errorf("history after an address change = %v, %v; want the 1 email kept", history, err)
+	}
+	if history, err := db.GetNotificationHistory(newcomerID, 10); err != nil || len(history) != 0 {
+		t.Errorf("history for the address's new owner = %v, %v; want empty", history, err)
+	}
+}
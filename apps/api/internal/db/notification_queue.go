@@ -0,0 +1,83 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// FailedNotification is a dead-lettered notification: one that used up its attempts and
+// is no longer picked up by the email worker
+type FailedNotification struct {
+	ID          int64           `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   *string         `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	FailedAt    time.Time       `json:"failed_at"`
+}
+
+// RecordNotificationFailure counts a failed attempt at a queued notification and
+// dead-letters it once its attempts are used up, reporting whether it was
+func (db *DB) RecordNotificationFailure(id int64, lastError string) (bool, error) {
+	var deadLettered bool
+	err := db.QueryRow(`
+		UPDATE notification_queue SET
+			attempts = attempts + 1,
+			last_error = $2,
+			failed_at = CASE WHEN attempts + 1 >= max_attempts THEN NOW() END
+		WHERE id = $1
+		RETURNING failed_at IS NOT NULL
+	`, id, lastError).Scan(&deadLettered)
+	if err != nil {
+		return false, fmt.Errorf("failed to record notification failure: %w", err)
+	}
+	return deadLettered, nil
+}
+
+// GetFailedNotifications lists dead-lettered notifications, most recently failed first
+func (db *DB) GetFailedNotifications(limit int) ([]FailedNotification, error) {
+	rows, err := db.Query(`
+		SELECT id, type, payload, attempts, max_attempts, last_error, created_at, failed_at
+		FROM notification_queue
+		WHERE failed_at IS NOT NULL
+		ORDER BY failed_at DESC, id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query failed notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []FailedNotification{}
+	for rows.Next() {
+		var n FailedNotification
+		err := rows.Scan(&n.ID, &n.Type, (*[]byte)(&n.Payload), &n.Attempts, &n.MaxAttempts, &n.LastError, &n.CreatedAt, &n.FailedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan failed notification: %w", err)
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, rows.Err()
+}
+
+// RetryNotification returns a dead-lettered notification to the queue with its attempts
+// reset, keeping its last error until the next attempt. It reports whether a
+// dead-lettered notification with that id was found.
+func (db *DB) RetryNotification(id int64) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE notification_queue
+		SET attempts = 0, failed_at = NULL, not_before_ts = NULL
+		WHERE id = $1 AND failed_at IS NOT NULL
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to retry notification: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,96 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestNotificationDeadLetter tests a notification is dead-lettered on its last failed
+// attempt and can be queued again
+func TestNotificationDeadLetter(t *testing.T) {
+	db := setupTestDB(t)
+
+	var id int64
+	err := db.QueryRow(`
+		INSERT INTO notification_queue (type, payload, max_attempts)
+		VALUES ('CONFIRMATION', $1, 2)
+		RETURNING id
+	`, `{"participant_id": "`+uuid.New().String()+`"}`).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to queue test notification: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE id = $1`, id)
+	})
+
+	failed := func() *FailedNotification {
+		t.Helper()
+		notifications, err := db.GetFailedNotifications(500)
+		if err != nil {
+			t.Fatalf("GetFailedNotifications: %v", err)
+		}
+		for i := range notifications {
+			if notifications[i].ID == id {
+				return &notifications[i]
+			}
+		}
+		return nil
+	}
+
+	if dead, err := db.RecordNotificationFailure(id, "smtp timeout"); err != nil || dead {
+		t.Fatalf("first failure dead-lettered = %v, %v; want false", dead, err)
+	}
+	if failed() != nil {
+		t.Error("notification with attempts left listed as failed")
+	}
+	if found, _ := db.RetryNotification(id); found {
+		t.Error("retried a notification that had not failed")
+	}
+
+	if dead, err := db.RecordNotificationFailure(id, "mailbox unavailable"); err != nil || !dead {
+		t.Fatalf("last failure dead-lettered = %v, %v; want true", dead, err)
+	}
+	n := failed()
+	if n == nil {
+		t.Fatal("dead-lettered notification not listed")
+	}
+	if n.Type != "CONFIRMATION" || n.Attempts != 2 || n.LastError == nil || *n.LastError != "mailbox unavailable" || len(n.Payload) == 0 {
+		t.Errorf("failed notification = %+v, want CONFIRMATION after 2 attempts with the last error and payload", n)
+	}
+
+	found, err := db.RetryNotification(id)
+	if err != nil || !found {
+		t.Fatalf("RetryNotification = %v, %v; want found", found, err)
+	}
+	if failed() != nil {
+		t.Error("retried notification still listed as failed")
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM notification_queue WHERE id = $1 AND attempts = 0 AND failed_at IS NULL`, id); n != 1 {
+		t.Error("retried notification not pending with its attempts reset")
+	}
+}
+
+// TestReminderNotificationTypes tests both reminders can be queued under their own type,
+// which names the email template they are sent with
+func TestReminderNotificationTypes(t *testing.T) {
+	db := setupTestDB(t)
+
+	for _, reminderType := range []string{"REMINDER_72H", "REMINDER_24H"} {
+		var id int64
+		err := db.QueryRow(`
+			INSERT INTO notification_queue (type, payload)
+			VALUES ($1, $2)
+			RETURNING id
+		`, reminderType, `{"participant_id": "`+uuid.New().String()+`"}`).Scan(&id)
+		if err != nil {
+			t.Errorf("failed to queue %s: %v", reminderType, err)
+			continue
+		}
+		db.Exec(`DELETE FROM notification_queue WHERE id = $1`, id)
+
+		if n := countRows(t, db, `SELECT COUNT(*) FROM email_templates WHERE template_key = $1`, reminderType); n != 1 {
+			t.Errorf("%s has %d email templates, want 1", reminderType, n)
+		}
+	}
+}
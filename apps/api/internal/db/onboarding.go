@@ -0,0 +1,26 @@
+package db
+
+import "fmt"
+
+// OnboardingProgress records which first setup steps have been taken
+type OnboardingProgress struct {
+	FirstProgram  bool
+	FirstFacility bool
+	FirstBooking  bool
+}
+
+// GetOnboardingProgress reports whether any program, facility and facility booking has
+// been created yet
+func (db *DB) GetOnboardingProgress() (*OnboardingProgress, error) {
+	var p OnboardingProgress
+	err := db.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM programs),
+			EXISTS (SELECT 1 FROM facilities),
+			EXISTS (SELECT 1 FROM facility_bookings)
+	`).Scan(&p.FirstProgram, &p.FirstFacility, &p.FirstBooking)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get onboarding progress: %w", err)
+	}
+	return &p, nil
+}
@@ -0,0 +1,54 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestGetOnboardingProgress tests the facility and booking steps are done once one of
+// each exists
+func TestGetOnboardingProgress(t *testing.T) {
+	db := setupTestDB(t)
+
+	var userID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type)
+		VALUES ($1, 'Test Court', 'court')
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	start := time.Now().AddDate(0, 0, 7).Truncate(time.Hour)
+	_, err = db.Exec(`
+		INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time)
+		VALUES ($1, $2, $3, $4)
+	`, facilityID, userID, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to create test booking: %v", err)
+	}
+
+	progress, err := db.GetOnboardingProgress()
+	if err != nil {
+		t.Fatalf("GetOnboardingProgress: %v", err)
+	}
+	if !progress.FirstFacility || !progress.FirstBooking {
+		t.Errorf("progress = %+v, want FirstFacility and FirstBooking", *progress)
+	}
+}
@@ -0,0 +1,133 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// DietaryRestrictions are the codes a participant's dietary_restrictions may contain
+var DietaryRestrictions = []string{
+	"vegetarian", "vegan", "gluten_free", "dairy_free", "halal", "kosher",
+	"nut_allergy", "peanut_allergy", "shellfish_allergy", "egg_allergy", "soy_allergy", "other",
+}
+
+// AccessibilityNeeds are the codes a participant's accessibility_needs may contain
+var AccessibilityNeeds = []string{
+	"wheelchair_access", "mobility_support", "visual_support", "hearing_support",
+	"sensory_support", "communication_support", "personal_care_aide", "other",
+}
+
+// ValidateParticipantNeeds checks dietary and accessibility codes against the known lists
+// and rejects duplicates
+func ValidateParticipantNeeds(dietary, accessibility []string) error {
+	if err := validateCodes("dietary_restrictions", dietary, DietaryRestrictions); err != nil {
+		return err
+	}
+	return validateCodes("accessibility_needs", accessibility, AccessibilityNeeds)
+}
+
+func validateCodes(field string, codes, allowed []string) error {
+	seen := map[string]bool{}
+	for _, code := range codes {
+		if !containsString(allowed, code) {
+			return fmt.Errorf("%s: unknown value %q", field, code)
+		}
+		if seen[code] {
+			return fmt.Errorf("%s: duplicate value %q", field, code)
+		}
+		seen[code] = true
+	}
+	return nil
+}
+
+// ProgramNeedsReport aggregates the dietary and accessibility needs of a program's
+// confirmed participants
+type ProgramNeedsReport struct {
+	ProgramID        uuid.UUID   `json:"program_id"`
+	ParticipantCount int         `json:"participant_count"`
+	Dietary          []NeedCount `json:"dietary"`
+	Accessibility    []NeedCount `json:"accessibility"`
+}
+
+// NeedCount is how many participants have one need, and who they are
+type NeedCount struct {
+	Code         string            `json:"code"`
+	Count        int               `json:"count"`
+	Participants []NeedParticipant `json:"participants"`
+}
+
+// NeedParticipant identifies a participant in a needs report
+type NeedParticipant struct {
+	ID   uuid.UUID `json:"id"`
+	Name string    `json:"name"`
+}
+
+// GetProgramNeedsReport counts the needs of participants with a confirmed registration for
+// the program or any of its sessions. Each participant counts once per need. Needs are
+// ordered by count, then code; needs nobody has are left out.
+func (db *DB) GetProgramNeedsReport(programID uuid.UUID) (*ProgramNeedsReport, error) {
+	rows, err := db.Query(`
+		SELECT DISTINCT p.id, p.first_name || ' ' || p.last_name, p.dietary_restrictions, p.accessibility_needs
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		WHERE r.parent_type = 'program' AND r.parent_id = $1 AND r.status = 'confirmed'
+	`, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program participants: %w", err)
+	}
+	defer rows.Close()
+
+	report := &ProgramNeedsReport{ProgramID: programID}
+	dietary := map[string]*NeedCount{}
+	accessibility := map[string]*NeedCount{}
+	for rows.Next() {
+		var participant NeedParticipant
+		var dietaryCodes, accessibilityCodes []string
+		err := rows.Scan(&participant.ID, &participant.Name, pq.Array(&dietaryCodes), pq.Array(&accessibilityCodes))
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan participant needs: %w", err)
+		}
+		report.ParticipantCount++
+		addNeeds(dietary, dietaryCodes, participant)
+		addNeeds(accessibility, accessibilityCodes, participant)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get program participants: %w", err)
+	}
+
+	report.Dietary = sortedNeeds(dietary)
+	report.Accessibility = sortedNeeds(accessibility)
+	return report, nil
+}
+
+func addNeeds(counts map[string]*NeedCount, codes []string, participant NeedParticipant) {
+	for _, code := range codes {
+		need, ok := counts[code]
+		if !ok {
+			need = &NeedCount{Code: code}
+			counts[code] = need
+		}
+		need.Count++
+		need.Participants = append(need.Participants, participant)
+	}
+}
+
+func sortedNeeds(counts map[string]*NeedCount) []NeedCount {
+	needs := make([]NeedCount, 0, len(counts))
+	for _, need := range counts {
+		sort.Slice(need.Participants, func(i, j int) bool {
+			return need.Participants[i].Name < need.Participants[j].Name
+		})
+		needs = append(needs, *need)
+	}
+	sort.Slice(needs, func(i, j int) bool {
+		if needs[i].Count != needs[j].Count {
+			return needs[i].Count > needs[j].Count
+		}
+		return needs[i].Code < needs[j].Code
+	})
+	return needs
+}
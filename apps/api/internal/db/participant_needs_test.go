@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestValidateParticipantNeeds checks needs codes are limited to the known lists
+func TestValidateParticipantNeeds(t *testing.T) {
+	if err := ValidateParticipantNeeds([]string{"vegetarian", "nut_allergy"}, []string{"wheelchair_access"}); err != nil {
+		t.Errorf("valid needs rejected: %v", err)
+	}
+	if err := ValidateParticipantNeeds(nil, nil); err != nil {
+		t.Errorf("empty needs rejected: %v", err)
+	}
+
+	invalid := map[string][2][]string{
+		"unknown dietary":       {{"paleo"}, nil},
+		"unknown accessibility": {nil, {"jetpack"}},
+		"duplicate":             {{"vegan", "vegan"}, nil},
+		"wrong list":            {{"wheelchair_access"}, nil},
+	}
+	for name, needs := range invalid {
+		if err := ValidateParticipantNeeds(needs[0], needs[1]); err == nil {
+			t.Errorf("%s: ValidateParticipantNeeds succeeded, want error", name)
+		}
+	}
+}
+
+// TestSortedNeeds checks needs are ordered by count, then code
+func TestSortedNeeds(t *testing.T) {
+	counts := map[string]*NeedCount{}
+	ana := NeedParticipant{ID: uuid.New(), Name: "Ana Diaz"}
+	ben := NeedParticipant{ID: uuid.New(), Name: "Ben Ng"}
+	addNeeds(counts, []string{"vegan", "nut_allergy"}, ben)
+	addNeeds(counts, []string{"nut_allergy", "halal"}, ana)
+
+	needs := sortedNeeds(counts)
+	if len(needs) != 3 {
+		t.Fatalf("got %d needs, want 3", len(needs))
+	}
+	if needs[0].Code != "nut_allergy" || needs[0].Count != 2 {
+		t.Errorf("first need = %s x%d, want nut_allergy x2", needs[0].Code, needs[0].Count)
+	}
+	if needs[0].Participants[0] != ana {
+		t.Errorf("participants not sorted by name: %v", needs[0].Participants)
+	}
+	if needs[1].Code != "halal" || needs[2].Code != "vegan" {
+		t.Errorf("ties not ordered by code: %s, %s", needs[1].Code, needs[2].Code)
+	}
+}
@@ -0,0 +1,152 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ParticipantProfile is everything staff need about a participant in one place: who they
+// are, who to contact, what the family has filled in and signed, and what they are
+// signed up for. Medical notes and medical forms are only filled in when asked for.
+type ParticipantProfile struct {
+	Participant     *Participant                `json:"participant"`
+	Household       *Household                  `json:"household"`
+	Guardians       []ParticipantGuardian       `json:"guardians"` // household owner first, then members
+	Forms           []ParticipantFormSubmission `json:"forms"`
+	Waivers         []ProfileWaiverAcceptance   `json:"waivers"`
+	Registrations   []Registration              `json:"registrations"`
+	Bookings        []FacilityBooking           `json:"bookings"`
+	MedicalIncluded bool                        `json:"medical_included"`
+}
+
+// ProfileWaiverAcceptance is a waiver acceptance with whether the version accepted is
+// still the waiver's current version
+type ProfileWaiverAcceptance struct {
+	ParticipantWaiverAcceptance
+	IsCurrentVersion bool `json:"is_current_version"`
+}
+
+// GetParticipantProfile composes a participant's staff profile. Returns nil when the
+// participant does not exist. includeMedical adds the participant's medical notes and
+// their medical form submissions.
+func (db *DB) GetParticipantProfile(participantID uuid.UUID, includeMedical bool) (*ParticipantProfile, error) {
+	participant, err := db.GetParticipantByID(participantID)
+	if err != nil || participant == nil {
+		return nil, err
+	}
+
+	profile := &ParticipantProfile{Participant: participant, MedicalIncluded: includeMedical}
+	if !includeMedical {
+		participant.MedicalNotes = nil
+	}
+
+	if profile.Household, err = db.GetHouseholdByID(participant.HouseholdID); err != nil {
+		return nil, err
+	}
+	if profile.Guardians, err = db.getHouseholdGuardians(participant.HouseholdID); err != nil {
+		return nil, err
+	}
+
+	forms, err := db.GetParticipantForms(participantID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Forms = []ParticipantFormSubmission{}
+	for _, f := range forms {
+		if !includeMedical && f.FormTemplate != nil && f.FormTemplate.Type == "medical" {
+			continue
+		}
+		profile.Forms = append(profile.Forms, f)
+	}
+
+	acceptances, err := db.GetParticipantWaiverAcceptances(participantID)
+	if err != nil {
+		return nil, err
+	}
+	profile.Waivers = []ProfileWaiverAcceptance{}
+	for _, a := range acceptances {
+		profile.Waivers = append(profile.Waivers, ProfileWaiverAcceptance{
+			ParticipantWaiverAcceptance: a,
+			IsCurrentVersion:            a.Waiver != nil && a.WaiverVersion == a.Waiver.Version,
+		})
+	}
+
+	if profile.Registrations, err = db.GetParticipantRegistrationDetails(participantID); err != nil {
+		return nil, err
+	}
+	if profile.Bookings, err = db.getParticipantBookings(participantID); err != nil {
+		return nil, err
+	}
+
+	return profile, nil
+}
+
+// getHouseholdGuardians returns the household's owner followed by the users who share it
+func (db *DB) getHouseholdGuardians(householdID uuid.UUID) ([]ParticipantGuardian, error) {
+	rows, err := db.Query(`
+		SELECT u.id, u.first_name, u.last_name, u.email, u.phone
+		FROM (
+			SELECT owner_user_id AS user_id, 0 AS rank, created_at FROM households WHERE id = $1
+			UNION ALL
+			SELECT user_id, 1, created_at FROM household_members WHERE household_id = $1
+		) g
+		JOIN users u ON u.id = g.user_id
+		ORDER BY g.rank, g.created_at
+	`, householdID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get guardians: %w", err)
+	}
+	defer rows.Close()
+
+	guardians := []ParticipantGuardian{}
+	for rows.Next() {
+		var g ParticipantGuardian
+		if err := rows.Scan(&g.UserID, &g.FirstName, &g.LastName, &g.Email, &g.Phone); err != nil {
+			return nil, fmt.Errorf("failed to scan guardian: %w", err)
+		}
+		guardians = append(guardians, g)
+	}
+	return guardians, rows.Err()
+}
+
+// getParticipantBookings retrieves the facility bookings a participant is booked on, with
+// the facility's name, latest first
+func (db *DB) getParticipantBookings(participantID uuid.UUID) ([]FacilityBooking, error) {
+	rows, err := db.Query(`
+		SELECT b.id, b.facility_id, b.user_id, b.household_id, b.participant_ids,
+			b.start_time, b.end_time, b.status, b.notes,
+			b.cancelled_at, b.cancelled_by, b.cancellation_reason, b.cancellation_reason_code,
+			b.late_cancellation, b.late_cancellation_fee_cents, b.advance_limit_waived, b.program_id, b.session_id, b.price_cents, b.series_id, b.unit_id, b.created_at, b.updated_at,
+			f.name
+		FROM facility_bookings b
+		JOIN facilities f ON f.id = b.facility_id
+		WHERE $1 = ANY(b.participant_ids)
+		ORDER BY b.start_time DESC
+	`, participantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query participant bookings: %w", err)
+	}
+	defer rows.Close()
+
+	bookings := []FacilityBooking{}
+	for rows.Next() {
+		var b FacilityBooking
+		var facilityName string
+		err := rows.Scan(
+			&b.ID, &b.FacilityID, &b.UserID, &b.HouseholdID, pq.Array(&b.ParticipantIDs),
+			&b.StartTime, &b.EndTime, &b.Status, &b.Notes,
+			&b.CancelledAt, &b.CancelledBy, &b.CancellationReason, &b.CancellationReasonCode,
+			&b.LateCancellation, &b.LateCancellationFeeCents,
+			&b.AdvanceLimitWaived, &b.ProgramID, &b.SessionID, &b.PriceCents, &b.SeriesID, &b.UnitID, &b.CreatedAt, &b.UpdatedAt,
+			&facilityName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan booking: %w", err)
+		}
+		b.Facility = &Facility{ID: b.FacilityID, Name: facilityName}
+		bookings = append(bookings, b)
+	}
+	return bookings, rows.Err()
+}
@@ -0,0 +1,79 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestGetParticipantProfile tests the profile gathers the participant's household,
+// guardian, forms and registrations, and leaves medical details out unless asked
+func TestGetParticipantProfile(t *testing.T) {
+	db := setupTestDB(t)
+
+	programID := createTestProgram(t, db, 5)
+	reg := registerTestParticipant(t, db, programID, nil)
+	participantID := reg.Registration.ParticipantID
+	if _, err := db.Exec(`UPDATE participants SET medical_notes = 'Peanut allergy' WHERE id = $1`, participantID); err != nil {
+		t.Fatalf("failed to set medical notes: %v", err)
+	}
+
+	var guardianID uuid.UUID
+	if err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id WHERE p.id = $1
+	`, participantID).Scan(&guardianID); err != nil {
+		t.Fatalf("failed to get test guardian: %v", err)
+	}
+
+	var templateIDs []uuid.UUID
+	for _, formType := range []string{"medical", "emergency"} {
+		ft, err := db.CreateFormTemplate(&FormTemplate{Type: formType, Title: "Test " + formType, SchemaJSON: json.RawMessage(`{"fields": []}`), Version: 1, IsActive: true})
+		if err != nil {
+			t.Fatalf("CreateFormTemplate: %v", err)
+		}
+		templateIDs = append(templateIDs, ft.ID)
+		_, err = db.SaveParticipantForm(&ParticipantFormSubmission{
+			ParticipantID: participantID, FormTemplateID: ft.ID, FormVersion: 1,
+			DataJSON: json.RawMessage(`{}`), SubmittedByUserID: guardianID,
+		})
+		if err != nil {
+			t.Fatalf("SaveParticipantForm: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		for _, id := range templateIDs {
+			db.Exec(`DELETE FROM participant_form_submissions WHERE form_template_id = $1`, id)
+			db.Exec(`DELETE FROM form_templates WHERE id = $1`, id)
+		}
+	})
+
+	profile, err := db.GetParticipantProfile(participantID, false)
+	if err != nil {
+		t.Fatalf("GetParticipantProfile: %v", err)
+	}
+	if profile.Household == nil || profile.Household.ID != profile.Participant.HouseholdID {
+		t.Errorf("household = %+v, want the participant's", profile.Household)
+	}
+	if len(profile.Guardians) != 1 || profile.Guardians[0].UserID != guardianID {
+		t.Errorf("guardians = %+v, want the household owner", profile.Guardians)
+	}
+	if len(profile.Registrations) != 1 || profile.Registrations[0].ID != reg.Registration.ID || profile.Registrations[0].ProgramInfo == nil {
+		t.Errorf("registrations = %+v, want the program registration", profile.Registrations)
+	}
+	if profile.Participant.MedicalNotes != nil || len(profile.Forms) != 1 || profile.Forms[0].FormTemplate.Type != "emergency" {
+		t.Errorf("profile without medical has medical notes %v and forms %+v, want neither medical", profile.Participant.MedicalNotes, profile.Forms)
+	}
+
+	profile, err = db.GetParticipantProfile(participantID, true)
+	if err != nil {
+		t.Fatalf("GetParticipantProfile with medical: %v", err)
+	}
+	if !profile.MedicalIncluded || profile.Participant.MedicalNotes == nil || len(profile.Forms) != 2 {
+		t.Errorf("profile with medical has medical notes %v and %d forms, want both forms and the notes", profile.Participant.MedicalNotes, len(profile.Forms))
+	}
+
+	if profile, err := db.GetParticipantProfile(uuid.New(), false); err != nil || profile != nil {
+		t.Errorf("unknown participant profile = %+v, %v; want nil", profile, err)
+	}
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// PII access log actions
+const (
+	PIIAccessParticipantSearch  = "participant_search"
+	PIIAccessParticipantProfile = "participant_profile"
+	PIIAccessRosterExport       = "roster_export"
+)
+
+// ParticipantSearch filters the front-desk participant search. Every word of Query must
+// start the participant's first or last name; DOB, when set, must match exactly.
+type ParticipantSearch struct {
+	Query  string
+	DOB    *time.Time
+	Limit  int
+	Offset int
+}
+
+// ParticipantGuardian is the household owner to contact about a participant
+type ParticipantGuardian struct {
+	UserID    uuid.UUID `json:"user_id"`
+	FirstName string    `json:"first_name"`
+	LastName  string    `json:"last_name"`
+	Email     string    `json:"email"`
+	Phone     *string   `json:"phone,omitempty"`
+}
+
+// ParticipantSearchResult is a participant found by the search, with their household and
+// guardian contact
+type ParticipantSearchResult struct {
+	ParticipantID  uuid.UUID            `json:"participant_id"`
+	FirstName      string               `json:"first_name"`
+	LastName       string               `json:"last_name"`
+	DOB            *time.Time           `json:"dob,omitempty"`
+	HouseholdID    uuid.UUID            `json:"household_id"`
+	HouseholdName  *string              `json:"household_name,omitempty"`
+	HouseholdPhone *string              `json:"household_phone,omitempty"`
+	HouseholdEmail *string              `json:"household_email,omitempty"`
+	Guardian       *ParticipantGuardian `json:"guardian,omitempty"`
+}
+
+// participantSearchPatterns turns a search query into one lowercase LIKE prefix pattern
+// per word, with LIKE wildcards in the words escaped
+func participantSearchPatterns(query string) []string {
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	var patterns []string
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		patterns = append(patterns, escaper.Replace(word)+"%")
+	}
+	return patterns
+}
+
+// SearchParticipants finds participants in active households across the whole system,
+// ordered by last then first name. It returns one page of results and the total number
+// of matches.
+func (db *DB) SearchParticipants(s ParticipantSearch) ([]ParticipantSearchResult, int, error) {
+	patterns := participantSearchPatterns(s.Query)
+	if len(patterns) == 0 {
+		return nil, 0, fmt.Errorf("search query is required")
+	}
+
+	where := `
+		FROM participants p
+		JOIN households h ON h.id = p.household_id AND h.deleted_at IS NULL
+		LEFT JOIN users u ON u.id = h.owner_user_id
+		WHERE NOT EXISTS (
+				SELECT 1 FROM unnest($1::text[]) AS term
+				WHERE lower(p.first_name) NOT LIKE term AND lower(p.last_name) NOT LIKE term
+			)
+			AND ($2::date IS NULL OR p.dob = $2::date)
+	`
+
+	var dob *string
+	if s.DOB != nil {
+		d := s.DOB.Format("2006-01-02")
+		dob = &d
+	}
+
+	var total int
+	if err := db.QueryRow(`SELECT COUNT(*) `+where, pq.Array(patterns), dob).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count participants: %w", err)
+	}
+
+	rows, err := db.Query(`
+		SELECT p.id, p.first_name, p.last_name, p.dob,
+		       h.id, h.name, h.phone, h.email,
+		       u.id, u.first_name, u.last_name, u.email, u.phone
+		`+where+`
+		ORDER BY lower(p.last_name), lower(p.first_name), p.id
+		LIMIT $3 OFFSET $4
+	`, pq.Array(patterns), dob, s.Limit, s.Offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to search participants: %w", err)
+	}
+	defer rows.Close()
+
+	results := []ParticipantSearchResult{}
+	for rows.Next() {
+		var r ParticipantSearchResult
+		var guardianID *uuid.UUID
+		var guardianFirst, guardianLast, guardianEmail *string
+		var guardianPhone *string
+		err := rows.Scan(
+			&r.ParticipantID, &r.FirstName, &r.LastName, &r.DOB,
+			&r.HouseholdID, &r.HouseholdName, &r.HouseholdPhone, &r.HouseholdEmail,
+			&guardianID, &guardianFirst, &guardianLast, &guardianEmail, &guardianPhone,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to scan participant: %w", err)
+		}
+		if guardianID != nil {
+			r.Guardian = &ParticipantGuardian{
+				UserID:    *guardianID,
+				FirstName: *guardianFirst,
+				LastName:  *guardianLast,
+				Email:     *guardianEmail,
+				Phone:     guardianPhone,
+			}
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to search participants: %w", err)
+	}
+
+	return results, total, nil
+}
+
+// RecordPIIAccess adds a lookup that exposed personal details to the access log
+func (db *DB) RecordPIIAccess(userID uuid.UUID, action string, details map[string]string, resultCount int) error {
+	detailsJSON, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode access details: %w", err)
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO pii_access_log (user_id, action, details, result_count)
+		VALUES ($1, $2, $3, $4)
+	`, userID, action, detailsJSON, resultCount)
+	if err != nil {
+		return fmt.Errorf("failed to record PII access: %w", err)
+	}
+	return nil
+}
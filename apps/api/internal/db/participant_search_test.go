@@ -0,0 +1,76 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestParticipantSearchPatterns checks each word becomes an escaped lowercase prefix
+func TestParticipantSearchPatterns(t *testing.T) {
+	got := participantSearchPatterns("  Mc_Donald  SAM%  ")
+	want := []string{`mc\_donald%`, `sam\%%`}
+	if len(got) != len(want) {
+		t.Fatalf("patterns = %q, want %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("patterns[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if got := participantSearchPatterns("   "); len(got) != 0 {
+		t.Errorf("blank query patterns = %q, want none", got)
+	}
+}
+
+// TestSearchParticipants tests participants are found by name prefix and date of birth
+// with their guardian, and that searches are logged
+func TestSearchParticipants(t *testing.T) {
+	db := setupTestDB(t)
+
+	participantID := createTestParticipant(t, db)
+	lastName := "Zzsearch" + uuid.New().String()[:8]
+	dob := time.Date(2015, time.April, 2, 0, 0, 0, 0, time.UTC)
+	if _, err := db.Exec(`UPDATE participants SET first_name = 'Samantha', last_name = $2, dob = $3 WHERE id = $1`,
+		participantID, lastName, dob); err != nil {
+		t.Fatalf("failed to update test participant: %v", err)
+	}
+
+	results, total, err := db.SearchParticipants(ParticipantSearch{Query: "sam " + lastName, Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchParticipants: %v", err)
+	}
+	if total != 1 || len(results) != 1 || results[0].ParticipantID != participantID {
+		t.Fatalf("results = %+v (total %d), want only %s", results, total, participantID)
+	}
+	if results[0].Guardian == nil || results[0].Guardian.LastName != "Parent" {
+		t.Errorf("guardian = %+v, want the household owner", results[0].Guardian)
+	}
+
+	otherDOB := dob.AddDate(1, 0, 0)
+	results, total, err = db.SearchParticipants(ParticipantSearch{Query: lastName, DOB: &otherDOB, Limit: 10})
+	if err != nil || total != 0 || len(results) != 0 {
+		t.Errorf("search with another dob = %+v (total %d), %v; want none", results, total, err)
+	}
+
+	results, total, err = db.SearchParticipants(ParticipantSearch{Query: "amantha " + lastName, Limit: 10})
+	if err != nil || total != 0 {
+		t.Errorf("search by a word inside the name = %+v (total %d), %v; want none", results, total, err)
+	}
+
+	var userID uuid.UUID
+	if err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id WHERE p.id = $1
+	`, participantID).Scan(&userID); err != nil {
+		t.Fatalf("failed to get test user: %v", err)
+	}
+	if err := db.RecordPIIAccess(userID, PIIAccessParticipantSearch, map[string]string{"q": lastName}, 1); err != nil {
+		t.Fatalf("RecordPIIAccess: %v", err)
+	}
+	var logged int
+	db.QueryRow(`SELECT COUNT(*) FROM pii_access_log WHERE user_id = $1 AND details->>'q' = $2`, userID, lastName).Scan(&logged)
+	if logged != 1 {
+		t.Errorf("logged searches = %d, want 1", logged)
+	}
+}
@@ -0,0 +1,284 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PricingRule charges its own hourly rate for a weekly time range, e.g. weekday evenings
+// at the peak rate. Time outside every rule is charged at the facility's base rate.
+type PricingRule struct {
+	ID              uuid.UUID `json:"id"`
+	FacilityID      uuid.UUID `json:"facility_id"`
+	DayOfWeek       int       `json:"day_of_week"` // 0=Sunday, 1=Monday, ..., 6=Saturday
+	StartTime       string    `json:"start_time"`  // HH:MM:SS format
+	EndTime         string    `json:"end_time"`    // HH:MM:SS format
+	HourlyRateCents int       `json:"hourly_rate_cents"`
+	Label           *string   `json:"label,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// Bounds returns the rule's start and end as timestamps on the given date
+func (r PricingRule) Bounds(date time.Time) (time.Time, time.Time, error) {
+	start, err := ParseWindowTime(r.StartTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid rule start time: %w", err)
+	}
+	end, err := ParseWindowTime(r.EndTime)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid rule end time: %w", err)
+	}
+	return timeOnDate(date, start), timeOnDate(date, end), nil
+}
+
+// PriceSegment is a stretch of a booking charged at one rate
+type PriceSegment struct {
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	HourlyRateCents int       `json:"hourly_rate_cents"`
+	Label           *string   `json:"label,omitempty"` // the pricing rule's label; nil at the base rate
+	AmountCents     int       `json:"amount_cents"`
+}
+
+// BookingPrice is the price of a booking with the segments it was charged in
+type BookingPrice struct {
+	TotalCents int            `json:"total_cents"`
+	Segments   []PriceSegment `json:"segments"`
+}
+
+// ComputeBookingPrice prices a booking against the facility's base hourly rate and its
+// pricing rules. Each part of the booking is charged at the rate of the rule covering it,
+// or the base rate outside every rule, so a booking crossing from off-peak into peak is
+// split between the two. Rules are evaluated in the location of startTime. Returns nil
+// when the facility has neither a base rate nor any rules; without a base rate, time
+// outside the rules is free.
+func ComputeBookingPrice(baseRate *int, rules []PricingRule, startTime, endTime time.Time) *BookingPrice {
+	if baseRate == nil && len(rules) == 0 {
+		return nil
+	}
+	base := 0
+	if baseRate != nil {
+		base = *baseRate
+	}
+
+	type ruleSpan struct {
+		start, end time.Time
+		rule       PricingRule
+	}
+
+	price := &BookingPrice{Segments: []PriceSegment{}}
+	currentDate := startTime
+	for currentDate.Before(endTime) {
+		dayStart := time.Date(currentDate.Year(), currentDate.Month(), currentDate.Day(), 0, 0, 0, 0, currentDate.Location())
+		dayEnd := dayStart.AddDate(0, 0, 1)
+
+		from := startTime
+		if from.Before(dayStart) {
+			from = dayStart
+		}
+		to := endTime
+		if to.After(dayEnd) {
+			to = dayEnd
+		}
+
+		// Cut the day's part of the booking wherever a rule starts or ends
+		var spans []ruleSpan
+		cuts := []time.Time{from, to}
+		for _, rule := range rules {
+			if rule.DayOfWeek != int(dayStart.Weekday()) {
+				continue
+			}
+			start, end, err := rule.Bounds(dayStart)
+			if err != nil {
+				continue
+			}
+			spans = append(spans, ruleSpan{start, end, rule})
+			for _, t := range []time.Time{start, end} {
+				if t.After(from) && t.Before(to) {
+					cuts = append(cuts, t)
+				}
+			}
+		}
+		sort.Slice(cuts, func(i, j int) bool { return cuts[i].Before(cuts[j]) })
+
+		for i := 0; i+1 < len(cuts); i++ {
+			segStart, segEnd := cuts[i], cuts[i+1]
+			if !segEnd.After(segStart) {
+				continue
+			}
+			rate, label := base, (*string)(nil)
+			for _, span := range spans {
+				if !segStart.Before(span.start) && !segEnd.After(span.end) {
+					rate, label = span.rule.HourlyRateCents, span.rule.Label
+					break
+				}
+			}
+			price.addSegment(segStart, segEnd, rate, label)
+		}
+
+		currentDate = dayEnd
+	}
+
+	for i := range price.Segments {
+		seg := &price.Segments[i]
+		seconds := int64(seg.EndTime.Sub(seg.StartTime) / time.Second)
+		seg.AmountCents = int((int64(seg.HourlyRateCents)*seconds + 1800) / 3600)
+		price.TotalCents += seg.AmountCents
+	}
+	return price
+}
+
+// addSegment appends a stretch to the price, extending the last segment when it runs on
+// at the same rate
+func (p *BookingPrice) addSegment(start, end time.Time, rate int, label *string) {
+	if n := len(p.Segments); n > 0 {
+		last := &p.Segments[n-1]
+		if last.EndTime.Equal(start) && last.HourlyRateCents == rate && sameLabel(last.Label, label) {
+			last.EndTime = end
+			return
+		}
+	}
+	p.Segments = append(p.Segments, PriceSegment{StartTime: start, EndTime: end, HourlyRateCents: rate, Label: label})
+}
+
+func sameLabel(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// ValidatePricingRules normalizes the times of a complete set of pricing rules to
+// HH:MM:SS and checks each rule is well formed and that no two rules overlap
+func ValidatePricingRules(rules []PricingRule) error {
+	for i := range rules {
+		r := &rules[i]
+		if r.DayOfWeek < 0 || r.DayOfWeek > 6 {
+			return fmt.Errorf("rule %d: day_of_week must be between 0 and 6", i)
+		}
+		start, err := NormalizeWindowTime(r.StartTime)
+		if err != nil {
+			return fmt.Errorf("rule %d: invalid start_time: %w", i, err)
+		}
+		end, err := NormalizeWindowTime(r.EndTime)
+		if err != nil {
+			return fmt.Errorf("rule %d: invalid end_time: %w", i, err)
+		}
+		if end <= start {
+			return fmt.Errorf("rule %d: end_time must be after start_time", i)
+		}
+		if r.HourlyRateCents < 0 {
+			return fmt.Errorf("rule %d: hourly_rate_cents cannot be negative", i)
+		}
+		r.StartTime, r.EndTime = start, end
+	}
+
+	for i := range rules {
+		for j := i + 1; j < len(rules); j++ {
+			a, b := rules[i], rules[j]
+			// Canonical HH:MM:SS strings compare in time order
+			if a.DayOfWeek == b.DayOfWeek && a.StartTime < b.EndTime && b.StartTime < a.EndTime {
+				return fmt.Errorf("rule %d overlaps rule %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// GetPricingRules retrieves a facility's pricing rules in weekly order
+func (db *DB) GetPricingRules(facilityID uuid.UUID) ([]PricingRule, error) {
+	rows, err := db.Query(`
+		SELECT id, facility_id, day_of_week, start_time::text, end_time::text,
+			hourly_rate_cents, label, created_at
+		FROM facility_pricing_rules
+		WHERE facility_id = $1
+		ORDER BY day_of_week, start_time
+	`, facilityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pricing rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []PricingRule{}
+	for rows.Next() {
+		var r PricingRule
+		err := rows.Scan(
+			&r.ID, &r.FacilityID, &r.DayOfWeek, &r.StartTime, &r.EndTime,
+			&r.HourlyRateCents, &r.Label, &r.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pricing rule: %w", err)
+		}
+		if start, err := NormalizeWindowTime(r.StartTime); err == nil {
+			r.StartTime = start
+		}
+		if end, err := NormalizeWindowTime(r.EndTime); err == nil {
+			r.EndTime = end
+		}
+		rules = append(rules, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query pricing rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// ReplacePricingRules makes the facility's pricing rules exactly the given set in one
+// transaction. The set must already have passed ValidatePricingRules.
+func (db *DB) ReplacePricingRules(facilityID uuid.UUID, rules []PricingRule) ([]PricingRule, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the facility so concurrent replacements apply one after the other
+	var locked uuid.UUID
+	err = tx.QueryRow("SELECT id FROM facilities WHERE id = $1 FOR UPDATE", facilityID).Scan(&locked)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("facility not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock facility: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM facility_pricing_rules WHERE facility_id = $1", facilityID); err != nil {
+		return nil, fmt.Errorf("failed to delete pricing rules: %w", err)
+	}
+
+	created := make([]PricingRule, 0, len(rules))
+	for _, r := range rules {
+		r.FacilityID = facilityID
+		err := tx.QueryRow(`
+			INSERT INTO facility_pricing_rules (facility_id, day_of_week, start_time, end_time, hourly_rate_cents, label)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			RETURNING id, created_at
+		`, r.FacilityID, r.DayOfWeek, r.StartTime, r.EndTime, r.HourlyRateCents, r.Label).Scan(&r.ID, &r.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pricing rule: %w", err)
+		}
+		created = append(created, r)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// PriceBooking prices a proposed booking of the facility, reading its pricing rules in the
+// facility's time zone; see ComputeBookingPrice
+func (db *DB) PriceBooking(facility *Facility, startTime, endTime time.Time) (*BookingPrice, error) {
+	rules, err := db.GetPricingRules(facility.ID)
+	if err != nil {
+		return nil, err
+	}
+	loc := facility.TimeLocation()
+	return ComputeBookingPrice(facility.HourlyRateCents, rules, startTime.In(loc), endTime.In(loc)), nil
+}
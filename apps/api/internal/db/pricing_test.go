@@ -0,0 +1,91 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// TestComputeBookingPrice checks bookings are charged at the rate of the rule covering
+// each part of them, splitting across peak and off-peak
+func TestComputeBookingPrice(t *testing.T) {
+	peak := "Peak"
+	base := 2000
+	rules := []PricingRule{
+		// Monday evenings and all of Saturday are peak
+		{DayOfWeek: 1, StartTime: "17:00:00", EndTime: "22:00:00", HourlyRateCents: 3000, Label: &peak},
+		{DayOfWeek: 6, StartTime: "00:00:00", EndTime: "24:00:00", HourlyRateCents: 3000, Label: &peak},
+	}
+	monday := func(hour, minute int) time.Time {
+		return time.Date(2024, 6, 3, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name       string
+		start, end time.Time
+		wantTotal  int
+		wantRates  []int
+	}{
+		{"off-peak", monday(9, 0), monday(10, 30), 3000, []int{2000}},
+		{"peak", monday(18, 0), monday(19, 0), 3000, []int{3000}},
+		{"split into peak", monday(16, 0), monday(18, 0), 5000, []int{2000, 3000}},
+		{"split out of peak", monday(21, 30), monday(23, 0), 1500 + 2000, []int{3000, 2000}},
+		{"across midnight into peak", time.Date(2024, 6, 7, 23, 0, 0, 0, time.UTC), time.Date(2024, 6, 8, 1, 0, 0, 0, time.UTC), 5000, []int{2000, 3000}},
+		{"partial hour rounds", monday(9, 0), monday(9, 20), 667, []int{2000}},
+	}
+	for _, tt := range tests {
+		price := ComputeBookingPrice(&base, rules, tt.start, tt.end)
+		if price == nil {
+			t.Fatalf("%s: price = nil", tt.name)
+		}
+		if price.TotalCents != tt.wantTotal {
+			t.Errorf("%s: total = %d, want %d", tt.name, price.TotalCents, tt.wantTotal)
+		}
+		var rates []int
+		for _, seg := range price.Segments {
+			rates = append(rates, seg.HourlyRateCents)
+		}
+		if len(rates) != len(tt.wantRates) {
+			t.Errorf("%s: segment rates = %v, want %v", tt.name, rates, tt.wantRates)
+			continue
+		}
+		for i := range rates {
+			if rates[i] != tt.wantRates[i] {
+				t.Errorf("%s: segment rates = %v, want %v", tt.name, rates, tt.wantRates)
+				break
+			}
+		}
+	}
+
+	if price := ComputeBookingPrice(nil, nil, monday(9, 0), monday(10, 0)); price != nil {
+		t.Errorf("unpriced facility price = %+v, want nil", price)
+	}
+	if price := ComputeBookingPrice(nil, rules, monday(16, 0), monday(18, 0)); price == nil || price.TotalCents != 3000 {
+		t.Errorf("price without a base rate = %+v, want only the peak hour charged", price)
+	}
+}
+
+// TestValidatePricingRules checks rules are normalized and rejected when they overlap
+func TestValidatePricingRules(t *testing.T) {
+	rules := []PricingRule{
+		{DayOfWeek: 1, StartTime: "17:00", EndTime: "22:00", HourlyRateCents: 3000},
+		{DayOfWeek: 1, StartTime: "22:00", EndTime: "24:00", HourlyRateCents: 2500},
+	}
+	if err := ValidatePricingRules(rules); err != nil {
+		t.Fatalf("ValidatePricingRules failed: %v", err)
+	}
+	if rules[0].StartTime != "17:00:00" {
+		t.Errorf("start time not normalized: %s", rules[0].StartTime)
+	}
+
+	invalid := map[string][]PricingRule{
+		"overlapping":   {{DayOfWeek: 2, StartTime: "17:00", EndTime: "20:00"}, {DayOfWeek: 2, StartTime: "19:00", EndTime: "21:00"}},
+		"negative rate": {{DayOfWeek: 2, StartTime: "17:00", EndTime: "20:00", HourlyRateCents: -1}},
+		"backwards":     {{DayOfWeek: 2, StartTime: "20:00", EndTime: "17:00"}},
+		"bad day":       {{DayOfWeek: 7, StartTime: "17:00", EndTime: "20:00"}},
+	}
+	for name, set := range invalid {
+		if err := ValidatePricingRules(set); err == nil {
+			t.Errorf("%s: ValidatePricingRules succeeded, want error", name)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ProgramRegistrationFilter narrows the admin list of program registrations; nil fields
+// and an empty status match everything
+type ProgramRegistrationFilter struct {
+	ProgramID   *uuid.UUID
+	Status      string
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// ProgramRegistrationRow is a program registration with the participant and account
+// details staff need for a roster
+type ProgramRegistrationRow struct {
+	ID                    uuid.UUID
+	ProgramID             uuid.UUID
+	ProgramTitle          string
+	ParticipantID         uuid.UUID
+	Status                string
+	CreatedAt             time.Time
+	FirstName             string
+	LastName              string
+	DOB                   *time.Time
+	EmergencyContactName  *string
+	EmergencyContactPhone *string
+	Notes                 *string
+	MedicalNotes          *string
+	PhotoURL              *string
+	UserID                uuid.UUID
+	Email                 string
+	Answers               json.RawMessage
+	DietaryRestrictions   []string
+	AccessibilityNeeds    []string
+}
+
+// EachProgramRegistration calls fn with each program registration matching the filter,
+// newest first, reading rows as they arrive so exports need not hold them all. A limit
+// of 0 returns every match. An error from fn stops the scan and is returned.
+func (db *DB) EachProgramRegistration(filter ProgramRegistrationFilter, limit int, fn func(*ProgramRegistrationRow) error) error {
+	var limitArg *int
+	if limit > 0 {
+		limitArg = &limit
+	}
+
+	rows, err := db.Query(`
+		SELECT r.id, r.parent_id, prog.title, r.participant_id, r.status, r.created_at,
+		       p.first_name, p.last_name, p.dob, p.emergency_contact_name, p.emergency_contact_phone,
+		       p.notes, p.medical_notes, p.photo_url,
+		       u.id, u.email, r.answers_json,
+		       p.dietary_restrictions, p.accessibility_needs
+		FROM registrations r
+		JOIN participants p ON r.participant_id = p.id
+		JOIN households h ON p.household_id = h.id
+		JOIN users u ON h.owner_user_id = u.id
+		JOIN programs prog ON r.parent_id = prog.id
+		WHERE r.parent_type = 'program'
+			AND ($1::uuid IS NULL OR r.parent_id = $1)
+			AND ($2 = '' OR r.status::text = $2)
+			AND ($3::timestamptz IS NULL OR r.created_at >= $3)
+			AND ($4::timestamptz IS NULL OR r.created_at < $4)
+		ORDER BY r.created_at DESC
+		LIMIT $5
+	`, filter.ProgramID, filter.Status, filter.CreatedFrom, filter.CreatedTo, limitArg)
+	if err != nil {
+		return fmt.Errorf("failed to query program registrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r ProgramRegistrationRow
+		var answers []byte
+		err := rows.Scan(
+			&r.ID, &r.ProgramID, &r.ProgramTitle, &r.ParticipantID, &r.Status, &r.CreatedAt,
+			&r.FirstName, &r.LastName, &r.DOB, &r.EmergencyContactName, &r.EmergencyContactPhone,
+			&r.Notes, &r.MedicalNotes, &r.PhotoURL,
+			&r.UserID, &r.Email, &answers,
+			pq.Array(&r.DietaryRestrictions), pq.Array(&r.AccessibilityNeeds),
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan program registration: %w", err)
+		}
+		r.Answers = answers
+
+		if err := fn(&r); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
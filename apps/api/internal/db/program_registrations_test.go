@@ -0,0 +1,51 @@
+package db
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestEachProgramRegistration tests the program and status filters and that an error
+// from the callback stops the scan
+func TestEachProgramRegistration(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	otherProgramID := createTestProgram(t, db, 5)
+	results := registerTestParticipants(t, db, programID, nil, 2)
+	registerTestParticipant(t, db, otherProgramID, nil)
+
+	collect := func(filter ProgramRegistrationFilter) []uuid.UUID {
+		t.Helper()
+		var ids []uuid.UUID
+		err := db.EachProgramRegistration(filter, 0, func(r *ProgramRegistrationRow) error {
+			if r.ProgramTitle != "Test Program" || r.Email == "" || r.FirstName != "Test" {
+				t.Errorf("row = %+v, want the program and participant details", r)
+			}
+			ids = append(ids, r.ID)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("EachProgramRegistration: %v", err)
+		}
+		return ids
+	}
+
+	if ids := collect(ProgramRegistrationFilter{ProgramID: &programID}); len(ids) != 2 || ids[0] != results[1].Registration.ID {
+		t.Errorf("program registrations = %v, want both, newest first", ids)
+	}
+	if ids := collect(ProgramRegistrationFilter{ProgramID: &programID, Status: "waitlisted"}); len(ids) != 1 || ids[0] != results[1].Registration.ID {
+		t.Errorf("waitlisted registrations = %v, want the second", ids)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := db.EachProgramRegistration(ProgramRegistrationFilter{ProgramID: &programID}, 0, func(*ProgramRegistrationRow) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("callback error: err = %v after %d calls, want stop after 1", err, calls)
+	}
+}
@@ -0,0 +1,193 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionReservationSkip explains why a session got no facility reservation
+type SessionReservationSkip struct {
+	SessionID uuid.UUID  `json:"session_id"`
+	StartTime *time.Time `json:"start_time,omitempty"`
+	Reason    string     `json:"reason"`
+}
+
+// ProgramReservationResult is the outcome of reserving a facility for a program's sessions
+type ProgramReservationResult struct {
+	Created []FacilityBooking        `json:"created"`
+	Skipped []SessionReservationSkip `json:"skipped"`
+}
+
+// ReserveFacilityForProgram books the facility for each active session of the program,
+// or only the given sessions when sessionIDs is non-empty. Reservations are made by
+// reservedBy and skip the public booking rules (windows, durations, advance limit), but
+// sessions that overlap a closure or another confirmed booking, including the facility's
+// buffer, are skipped and reported. Sessions that already reserve the facility are
+// skipped too, so running it again after a schedule change only books the new sessions.
+func (db *DB) ReserveFacilityForProgram(facilityID, programID, reservedBy uuid.UUID, sessionIDs []uuid.UUID) (*ProgramReservationResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the facility so concurrent reservation runs see each other's bookings
+	var bufferMinutes int
+	err = tx.QueryRow(`SELECT buffer_minutes FROM facilities WHERE id = $1 FOR UPDATE`, facilityID).Scan(&bufferMinutes)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("facility not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock facility: %w", err)
+	}
+
+	var programTitle string
+	err = tx.QueryRow(`SELECT title FROM programs WHERE id = $1`, programID).Scan(&programTitle)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("program not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program: %w", err)
+	}
+
+	type session struct {
+		id               uuid.UUID
+		startsAt, endsAt *time.Time
+	}
+	rows, err := tx.Query(`
+		SELECT id, starts_at, ends_at
+		FROM sessions
+		WHERE parent_type = 'program' AND parent_id = $1 AND is_active = true
+		ORDER BY starts_at ASC NULLS LAST
+	`, programID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	var sessions []session
+	for rows.Next() {
+		var s session
+		if err := rows.Scan(&s.id, &s.startsAt, &s.endsAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+
+	wanted := map[uuid.UUID]bool{}
+	for _, id := range sessionIDs {
+		wanted[id] = true
+	}
+	for _, s := range sessions {
+		delete(wanted, s.id)
+	}
+	if len(wanted) > 0 {
+		return nil, fmt.Errorf("session not found for program")
+	}
+
+	result := &ProgramReservationResult{Created: []FacilityBooking{}, Skipped: []SessionReservationSkip{}}
+	notes := "Reserved for " + programTitle
+	for _, s := range sessions {
+		if len(sessionIDs) > 0 && !containsUUID(sessionIDs, s.id) {
+			continue
+		}
+		skip := func(reason string) {
+			result.Skipped = append(result.Skipped, SessionReservationSkip{SessionID: s.id, StartTime: s.startsAt, Reason: reason})
+		}
+
+		if s.startsAt == nil || s.endsAt == nil || !s.endsAt.After(*s.startsAt) {
+			skip("session has no valid start and end time")
+			continue
+		}
+
+		var reserved bool
+		err := tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM facility_bookings
+				WHERE facility_id = $1 AND session_id = $2 AND status = 'confirmed'
+			)
+		`, facilityID, s.id).Scan(&reserved)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check existing reservation: %w", err)
+		}
+		if reserved {
+			skip("facility is already reserved for this session")
+			continue
+		}
+
+		var closed bool
+		err = tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM facility_closures
+				WHERE facility_id = $1 AND start_time < $3 AND end_time > $2
+			)
+		`, facilityID, *s.startsAt, *s.endsAt).Scan(&closed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check closures: %w", err)
+		}
+		if closed {
+			skip("facility is closed during this session")
+			continue
+		}
+
+		buffer := time.Duration(bufferMinutes) * time.Minute
+		var conflicts bool
+		err = tx.QueryRow(`
+			SELECT EXISTS(
+				SELECT 1 FROM facility_bookings
+				WHERE facility_id = $1 AND `+holdingSlotSQL+` AND start_time < $3 AND end_time > $2
+			)
+		`, facilityID, s.startsAt.Add(-buffer), s.endsAt.Add(buffer)).Scan(&conflicts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check for conflicts: %w", err)
+		}
+		if conflicts {
+			skip("session conflicts with an existing booking")
+			continue
+		}
+
+		programID, sessionID := programID, s.id
+		b := FacilityBooking{
+			FacilityID: facilityID,
+			UserID:     reservedBy,
+			StartTime:  *s.startsAt,
+			EndTime:    *s.endsAt,
+			Status:     "confirmed",
+			Notes:      &notes,
+			ProgramID:  &programID,
+			SessionID:  &sessionID,
+		}
+		err = tx.QueryRow(`
+			INSERT INTO facility_bookings (
+				facility_id, user_id, start_time, end_time, status, notes, program_id, session_id
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at, updated_at
+		`, b.FacilityID, b.UserID, b.StartTime, b.EndTime, b.Status, b.Notes, b.ProgramID, b.SessionID,
+		).Scan(&b.ID, &b.CreatedAt, &b.UpdatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create reservation: %w", err)
+		}
+		result.Created = append(result.Created, b)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+func containsUUID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestReserveFacilityForProgram tests reserving a facility for a program's sessions
+func TestReserveFacilityForProgram(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+
+	var adminID, facilityID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name, role)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Admin', 'admin')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&adminID)
+	if err != nil {
+		t.Fatalf("failed to create test admin: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, buffer_minutes)
+		VALUES ($1, 'Test Gym', 'room', 15)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, adminID)
+	})
+
+	start := time.Now().Add(48 * time.Hour).Truncate(time.Hour)
+	createSession := func(startsAt time.Time) uuid.UUID {
+		var id uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO sessions (parent_type, parent_id, starts_at, ends_at)
+			VALUES ('program', $1, $2, $3)
+			RETURNING id
+		`, programID, startsAt, startsAt.Add(time.Hour)).Scan(&id)
+		if err != nil {
+			t.Fatalf("failed to create test session: %v", err)
+		}
+		return id
+	}
+	free := createSession(start)
+	clashing := createSession(start.Add(24 * time.Hour))
+
+	// A public booking ending 10 minutes before the second session clashes with the buffer
+	_, err = db.Exec(`
+		INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time)
+		VALUES ($1, $2, $3, $4)
+	`, facilityID, adminID, start.Add(23*time.Hour), start.Add(24*time.Hour-10*time.Minute))
+	if err != nil {
+		t.Fatalf("failed to create conflicting booking: %v", err)
+	}
+
+	result, err := db.ReserveFacilityForProgram(facilityID, programID, adminID, nil)
+	if err != nil {
+		t.Fatalf("ReserveFacilityForProgram: %v", err)
+	}
+	if len(result.Created) != 1 || *result.Created[0].SessionID != free || *result.Created[0].ProgramID != programID {
+		t.Errorf("created = %+v, want one reservation for session %s", result.Created, free)
+	}
+	if len(result.Skipped) != 1 || result.Skipped[0].SessionID != clashing {
+		t.Errorf("skipped = %+v, want the clashing session %s", result.Skipped, clashing)
+	}
+
+	if err := db.checkNoConflictingBookings(facilityID, start, start.Add(time.Hour), 0, 1, nil); err == nil {
+		t.Error("reserved session time is still available to the public")
+	}
+
+	again, err := db.ReserveFacilityForProgram(facilityID, programID, adminID, []uuid.UUID{free})
+	if err != nil {
+		t.Fatalf("ReserveFacilityForProgram again: %v", err)
+	}
+	if len(again.Created) != 0 || len(again.Skipped) != 1 {
+		t.Errorf("second run = %+v, want the session skipped as already reserved", again)
+	}
+}
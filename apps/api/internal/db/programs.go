@@ -2,23 +2,258 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-// GetActivePrograms retrieves all active programs with capacity info
+// ProgramUpdate holds the fields of a partial program update; nil fields are left unchanged
+type ProgramUpdate struct {
+	Title         *string
+	Description   *string
+	AgeMin        *int
+	AgeMax        *int
+	Location      *string
+	Capacity      *int
+	StartDate     *time.Time
+	EndDate       *time.Time
+	ScheduleNotes *string
+	IsActive      *bool
+	OverbookPct   *int
+
+	RegistrationQuestions json.RawMessage
+	RequiresApproval      *bool
+	RegistrationOpensAt   *time.Time
+	PublishedAt           *time.Time
+	UnpublishedAt         *time.Time
+	Category              *string
+
+	MinorEmergencyContactRequired *bool
+	MinorAgeThreshold             *int
+
+	// Clear flags set a field back to NULL, ignoring the matching value above
+	ClearRegistrationQuestions bool
+	ClearPublishedAt           bool
+	ClearUnpublishedAt         bool
+}
+
+// EventUpdate holds the fields of a partial event update; nil fields are left unchanged
+type EventUpdate struct {
+	Title       *string
+	Description *string
+	Location    *string
+	Capacity    *int
+	StartsAt    *time.Time
+	EndsAt      *time.Time
+	IsActive    *bool
+}
+
+// CreateProgram creates a new program and returns it with generated fields populated
+func (db *DB) CreateProgram(p *Program) (*Program, error) {
+	err := db.QueryRow(`
+		INSERT INTO programs (
+			slug, title, description, age_min, age_max, location, capacity,
+			start_date, end_date, schedule_notes, is_active, overbook_pct, registration_questions,
+			requires_approval, registration_opens_at, published_at, unpublished_at, category,
+			minor_emergency_contact_required, minor_age_threshold
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, COALESCE($12, 0), $13, $14, $15, $16, $17, $18, $19, $20)
+		RETURNING
+			id, slug, title, description, age_min, age_max,
+			location, capacity, start_date, end_date, schedule_notes,
+			is_active, created_at, updated_at, overbook_pct, registration_questions, requires_approval,
+			registration_opens_at, published_at, unpublished_at, category,
+			minor_emergency_contact_required, minor_age_threshold
+	`,
+		p.Slug, p.Title, p.Description, p.AgeMin, p.AgeMax, p.Location, p.Capacity,
+		p.StartDate, p.EndDate, p.ScheduleNotes, p.IsActive, p.OverbookPct,
+		nullableJSON(p.RegistrationQuestions), p.RequiresApproval, p.RegistrationOpensAt,
+		p.PublishedAt, p.UnpublishedAt, p.Category,
+		p.MinorEmergencyContactRequired, p.MinorAgeThreshold,
+	).Scan(
+		&p.ID, &p.Slug, &p.Title, &p.Description, &p.AgeMin, &p.AgeMax,
+		&p.Location, &p.Capacity, &p.StartDate, &p.EndDate, &p.ScheduleNotes,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.OverbookPct, (*[]byte)(&p.RegistrationQuestions),
+		&p.RequiresApproval, &p.RegistrationOpensAt, &p.PublishedAt, &p.UnpublishedAt, &p.Category,
+		&p.MinorEmergencyContactRequired, &p.MinorAgeThreshold,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create program: %w", err)
+	}
+
+	effective := EffectiveCapacity(p.Capacity, *p.OverbookPct)
+	p.EffectiveCapacity = &effective
+
+	return p, nil
+}
+
+// CreateEvent creates a new event and returns it with generated fields populated
+func (db *DB) CreateEvent(e *Event) (*Event, error) {
+	err := db.QueryRow(`
+		INSERT INTO events (
+			slug, title, description, location, capacity, starts_at, ends_at, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING
+			id, slug, title, description, location, capacity,
+			starts_at, ends_at, is_active, created_at, updated_at
+	`,
+		e.Slug, e.Title, e.Description, e.Location, e.Capacity, e.StartsAt, e.EndsAt, e.IsActive,
+	).Scan(
+		&e.ID, &e.Slug, &e.Title, &e.Description, &e.Location, &e.Capacity,
+		&e.StartsAt, &e.EndsAt, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	return e, nil
+}
+
+// UpdateProgram applies a partial update to a program
+func (db *DB) UpdateProgram(id uuid.UUID, u *ProgramUpdate) error {
+	result, err := db.Exec(`
+		UPDATE programs SET
+			title = COALESCE($2, title),
+			description = COALESCE($3, description),
+			age_min = COALESCE($4, age_min),
+			age_max = COALESCE($5, age_max),
+			location = COALESCE($6, location),
+			capacity = COALESCE($7, capacity),
+			start_date = COALESCE($8, start_date),
+			end_date = COALESCE($9, end_date),
+			schedule_notes = COALESCE($10, schedule_notes),
+			is_active = COALESCE($11, is_active),
+			overbook_pct = COALESCE($12, overbook_pct),
+			registration_questions = CASE WHEN $21 THEN NULL ELSE COALESCE($13, registration_questions) END,
+			requires_approval = COALESCE($14, requires_approval),
+			registration_opens_at = COALESCE($15, registration_opens_at),
+			published_at = CASE WHEN $22 THEN NULL ELSE COALESCE($16, published_at) END,
+			unpublished_at = CASE WHEN $23 THEN NULL ELSE COALESCE($17, unpublished_at) END,
+			category = COALESCE($18, category),
+			minor_emergency_contact_required = COALESCE($19, minor_emergency_contact_required),
+			minor_age_threshold = COALESCE($20, minor_age_threshold),
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, u.Title, u.Description, u.AgeMin, u.AgeMax, u.Location, u.Capacity,
+		u.StartDate, u.EndDate, u.ScheduleNotes, u.IsActive, u.OverbookPct,
+		nullableJSON(u.RegistrationQuestions), u.RequiresApproval, u.RegistrationOpensAt,
+		u.PublishedAt, u.UnpublishedAt, u.Category,
+		u.MinorEmergencyContactRequired, u.MinorAgeThreshold,
+		u.ClearRegistrationQuestions, u.ClearPublishedAt, u.ClearUnpublishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update program: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("program not found")
+	}
+
+	return nil
+}
+
+// DeleteProgram permanently deletes a program
+func (db *DB) DeleteProgram(id uuid.UUID) error {
+	result, err := db.Exec("DELETE FROM programs WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete program: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("program not found")
+	}
+
+	return nil
+}
+
+// UpdateEvent applies a partial update to an event
+func (db *DB) UpdateEvent(id uuid.UUID, u *EventUpdate) error {
+	result, err := db.Exec(`
+		UPDATE events SET
+			title = COALESCE($2, title),
+			description = COALESCE($3, description),
+			location = COALESCE($4, location),
+			capacity = COALESCE($5, capacity),
+			starts_at = COALESCE($6, starts_at),
+			ends_at = COALESCE($7, ends_at),
+			is_active = COALESCE($8, is_active),
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, u.Title, u.Description, u.Location, u.Capacity, u.StartsAt, u.EndsAt, u.IsActive)
+	if err != nil {
+		return fmt.Errorf("failed to update event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("event not found")
+	}
+
+	return nil
+}
+
+// DeleteEvent permanently deletes an event
+func (db *DB) DeleteEvent(id uuid.UUID) error {
+	result, err := db.Exec("DELETE FROM events WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("event not found")
+	}
+
+	return nil
+}
+
+// publishedProgramsCondition limits a programs query to programs inside their publish window
+const publishedProgramsCondition = `(published_at IS NULL OR published_at <= NOW()) AND (unpublished_at IS NULL OR unpublished_at > NOW())`
+
+// GetActivePrograms retrieves all active, currently published programs with capacity info
 func (db *DB) GetActivePrograms() ([]Program, error) {
+	return db.listPrograms(`p.is_active = true AND ` + publishedProgramsCondition)
+}
+
+// GetAllPrograms retrieves every program with capacity info, including inactive and
+// unpublished ones, for admin listings
+func (db *DB) GetAllPrograms() ([]Program, error) {
+	return db.listPrograms(`true`)
+}
+
+// listPrograms retrieves programs matching a fixed WHERE condition with capacity info
+func (db *DB) listPrograms(condition string) ([]Program, error) {
 	rows, err := db.Query(`
 		SELECT
 			p.id, p.slug, p.title, p.description, p.age_min, p.age_max,
 			p.location, p.capacity, p.start_date, p.end_date, p.schedule_notes,
-			p.is_active, p.created_at, p.updated_at,
-			COALESCE(p.capacity - COUNT(DISTINCT CASE WHEN r.status = 'confirmed' THEN r.id END), 0) as spots_left,
+			p.is_active, p.created_at, p.updated_at, p.requires_approval, p.registration_opens_at,
+			p.published_at, p.unpublished_at, p.category,
+			COALESCE(p.capacity * (100 + p.overbook_pct) / 100 - COALESCE(SUM(r.seats) FILTER (WHERE r.status IN ('confirmed', 'paused', 'offered')), 0), 0) as spots_left,
 			COUNT(DISTINCT CASE WHEN r.status = 'waitlisted' THEN r.id END) as waitlist_count
 		FROM programs p
 		LEFT JOIN registrations r ON r.parent_type = 'program' AND r.parent_id = p.id AND r.session_id IS NULL
-		WHERE p.is_active = true
+		WHERE ` + condition + `
 		GROUP BY p.id
 		ORDER BY p.start_date ASC NULLS LAST, p.title ASC
 	`)
@@ -34,7 +269,8 @@ func (db *DB) GetActivePrograms() ([]Program, error) {
 		err := rows.Scan(
 			&p.ID, &p.Slug, &p.Title, &p.Description, &p.AgeMin, &p.AgeMax,
 			&p.Location, &p.Capacity, &p.StartDate, &p.EndDate, &p.ScheduleNotes,
-			&p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+			&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.RequiresApproval, &p.RegistrationOpensAt,
+			&p.PublishedAt, &p.UnpublishedAt, &p.Category,
 			&spotsLeft, &waitlistCount,
 		)
 		if err != nil {
@@ -51,17 +287,22 @@ func (db *DB) GetActivePrograms() ([]Program, error) {
 // GetProgramBySlug retrieves a program by slug with sessions
 func (db *DB) GetProgramBySlug(slug string) (*Program, error) {
 	var p Program
+	var overbookPct int
 	err := db.QueryRow(`
 		SELECT
 			id, slug, title, description, age_min, age_max,
 			location, capacity, start_date, end_date, schedule_notes,
-			is_active, created_at, updated_at
+			is_active, created_at, updated_at, overbook_pct, registration_questions, requires_approval,
+			registration_opens_at, published_at, unpublished_at, category,
+			minor_emergency_contact_required, minor_age_threshold
 		FROM programs
-		WHERE slug = $1 AND is_active = true
+		WHERE slug = $1 AND is_active = true AND `+publishedProgramsCondition+`
 	`, slug).Scan(
 		&p.ID, &p.Slug, &p.Title, &p.Description, &p.AgeMin, &p.AgeMax,
 		&p.Location, &p.Capacity, &p.StartDate, &p.EndDate, &p.ScheduleNotes,
-		&p.IsActive, &p.CreatedAt, &p.UpdatedAt,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &overbookPct, (*[]byte)(&p.RegistrationQuestions),
+		&p.RequiresApproval, &p.RegistrationOpensAt, &p.PublishedAt, &p.UnpublishedAt, &p.Category,
+		&p.MinorEmergencyContactRequired, &p.MinorAgeThreshold,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -70,10 +311,20 @@ func (db *DB) GetProgramBySlug(slug string) (*Program, error) {
 		return nil, fmt.Errorf("failed to get program: %w", err)
 	}
 
+	if err := db.loadProgramCapacity(&p, overbookPct); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// loadProgramCapacity fills in a program's sessions with their capacity info, or its
+// spots left and waitlist count when it has no sessions
+func (db *DB) loadProgramCapacity(p *Program, overbookPct int) error {
 	// Get sessions with capacity info
-	sessions, err := db.GetProgramSessions(p.ID, p.Capacity)
+	sessions, err := db.GetProgramSessions(p.ID, p.Capacity, overbookPct)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	p.Sessions = sessions
 
@@ -83,36 +334,271 @@ func (db *DB) GetProgramBySlug(slug string) (*Program, error) {
 		var spotsLeft, waitlistCount int
 		err = db.QueryRow(`
 			SELECT
-				COALESCE($1 - COUNT(DISTINCT CASE WHEN status = 'confirmed' THEN id END), 0),
+				COALESCE($1 - COALESCE(SUM(seats) FILTER (WHERE status IN ('confirmed', 'paused', 'offered')), 0), 0),
 				COUNT(DISTINCT CASE WHEN status = 'waitlisted' THEN id END)
 			FROM registrations
 			WHERE parent_type = 'program' AND parent_id = $2 AND session_id IS NULL
-		`, p.Capacity, p.ID).Scan(&spotsLeft, &waitlistCount)
+		`, EffectiveCapacity(p.Capacity, overbookPct), p.ID).Scan(&spotsLeft, &waitlistCount)
 		if err != nil {
-			return nil, fmt.Errorf("failed to calculate capacity: %w", err)
+			return fmt.Errorf("failed to calculate capacity: %w", err)
 		}
 		p.SpotsLeft = &spotsLeft
 		p.WaitlistCount = &waitlistCount
 	}
 
+	return nil
+}
+
+// GetProgramDetail retrieves a program by ID regardless of active state, with its
+// sessions and capacity info as GetProgramBySlug reports them
+func (db *DB) GetProgramDetail(id uuid.UUID) (*Program, error) {
+	p, err := db.GetProgramByID(id)
+	if err != nil || p == nil {
+		return p, err
+	}
+
+	if err := db.loadProgramCapacity(p, *p.OverbookPct); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetProgramByID retrieves a program by ID regardless of active state
+func (db *DB) GetProgramByID(id uuid.UUID) (*Program, error) {
+	var p Program
+	err := db.QueryRow(`
+		SELECT
+			id, slug, title, description, age_min, age_max,
+			location, capacity, start_date, end_date, schedule_notes,
+			is_active, created_at, updated_at, overbook_pct, registration_questions, requires_approval,
+			registration_opens_at, published_at, unpublished_at, category,
+			minor_emergency_contact_required, minor_age_threshold
+		FROM programs
+		WHERE id = $1
+	`, id).Scan(
+		&p.ID, &p.Slug, &p.Title, &p.Description, &p.AgeMin, &p.AgeMax,
+		&p.Location, &p.Capacity, &p.StartDate, &p.EndDate, &p.ScheduleNotes,
+		&p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.OverbookPct, (*[]byte)(&p.RegistrationQuestions),
+		&p.RequiresApproval, &p.RegistrationOpensAt, &p.PublishedAt, &p.UnpublishedAt, &p.Category,
+		&p.MinorEmergencyContactRequired, &p.MinorAgeThreshold,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program: %w", err)
+	}
+	effective := EffectiveCapacity(p.Capacity, *p.OverbookPct)
+	p.EffectiveCapacity = &effective
 	return &p, nil
 }
 
+// IsPublished reports whether now falls inside a publish window; nil bounds are open
+func IsPublished(publishedAt, unpublishedAt *time.Time, now time.Time) bool {
+	if publishedAt != nil && publishedAt.After(now) {
+		return false
+	}
+	return unpublishedAt == nil || unpublishedAt.After(now)
+}
+
+// EffectiveCapacity returns the number of registrations that may be confirmed
+// for a nominal capacity once the overbooking percentage is applied
+func EffectiveCapacity(capacity, overbookPct int) int {
+	return capacity * (100 + overbookPct) / 100
+}
+
+// AgeOn returns a person's age in whole years on the given date
+func AgeOn(dob, on time.Time) int {
+	age := on.Year() - dob.Year()
+	if on.Month() < dob.Month() || (on.Month() == dob.Month() && on.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
+
+// ProgramAgeReferenceDate returns the date a participant's age is measured on for a
+// program: its start date, so age cutoffs apply to the season rather than the day of
+// registration, or today when the program has no start date
+func ProgramAgeReferenceDate(p *Program) time.Time {
+	if p.StartDate != nil {
+		return *p.StartDate
+	}
+	return time.Now()
+}
+
+// DefaultMinorAgeThreshold is the age under which participants are treated as minors
+// when a program does not set its own
+const DefaultMinorAgeThreshold = 18
+
+// NeedsEmergencyContact reports whether the program requires an emergency contact phone
+// the participant is missing. Participants without a date of birth are not treated as
+// minors, as with the age range check.
+func NeedsEmergencyContact(p *Program, participant *Participant) bool {
+	if !p.MinorEmergencyContactRequired || participant.DOB == nil {
+		return false
+	}
+	if participant.EmergencyContactPhone != nil && strings.TrimSpace(*participant.EmergencyContactPhone) != "" {
+		return false
+	}
+	return AgeOn(*participant.DOB, ProgramAgeReferenceDate(p)) < p.MinorAgeThreshold
+}
+
+// MeetsAgeRequirements reports whether a participant born on dob fits the program's age range
+func MeetsAgeRequirements(p *Program, dob time.Time) bool {
+	return AgeInRange(p, AgeOn(dob, ProgramAgeReferenceDate(p)))
+}
+
+// AgeInRange reports whether an age fits the program's age range
+func AgeInRange(p *Program, age int) bool {
+	return AgeRequirementReason(p, age) == ""
+}
+
+// AgeRequirementReason explains why an age falls outside the program's age range, or
+// returns "" when it fits
+func AgeRequirementReason(p *Program, age int) string {
+	if p.AgeMin != nil && age < *p.AgeMin {
+		return "Participant is too young for this program"
+	}
+	if p.AgeMax != nil && age > *p.AgeMax {
+		return "Participant is too old for this program"
+	}
+	return ""
+}
+
+// GetEligiblePrograms retrieves active programs a participant meets the age criteria
+// for and is not already registered for. Participants without a DOB match every
+// program. When excludeFull is set, programs with no open spots are left out.
+func (db *DB) GetEligiblePrograms(participant *Participant, excludeFull bool) ([]Program, error) {
+	programs, err := db.GetActivePrograms()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT DISTINCT parent_id
+		FROM registrations
+		WHERE participant_id = $1 AND parent_type = 'program' AND status != 'cancelled'
+	`, participant.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get participant registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registered := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var programID uuid.UUID
+		if err := rows.Scan(&programID); err != nil {
+			return nil, fmt.Errorf("failed to scan registration: %w", err)
+		}
+		registered[programID] = true
+	}
+
+	eligible := []Program{}
+	for i := range programs {
+		p := &programs[i]
+		if registered[p.ID] {
+			continue
+		}
+		if participant.DOB != nil && !MeetsAgeRequirements(p, *participant.DOB) {
+			continue
+		}
+		eligible = append(eligible, *p)
+	}
+
+	if !excludeFull || len(eligible) == 0 {
+		return eligible, nil
+	}
+
+	ids := make([]uuid.UUID, len(eligible))
+	for i, p := range eligible {
+		ids[i] = p.ID
+	}
+	open, err := db.programsWithOpenSpots(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	withSpots := []Program{}
+	for _, p := range eligible {
+		if open[p.ID] {
+			withSpots = append(withSpots, p)
+		}
+	}
+	return withSpots, nil
+}
+
+// programsWithOpenSpots reports which of the given programs have a spot left: in any
+// active session for a program with sessions, otherwise in the program itself
+func (db *DB) programsWithOpenSpots(ids []uuid.UUID) (map[uuid.UUID]bool, error) {
+	rows, err := db.Query(`
+		WITH session_spots AS (
+			SELECT s.parent_id AS program_id,
+				COALESCE(s.capacity_override, p.capacity) * (100 + p.overbook_pct) / 100
+					- COALESCE(SUM(r.seats) FILTER (WHERE r.status IN ('confirmed', 'paused', 'offered')), 0) AS spots_left
+			FROM sessions s
+			JOIN programs p ON p.id = s.parent_id
+			LEFT JOIN registrations r ON r.session_id = s.id
+			WHERE s.parent_type = 'program' AND s.is_active = true AND s.parent_id = ANY($1::uuid[])
+			GROUP BY s.id, p.id
+		),
+		program_spots AS (
+			SELECT p.id AS program_id,
+				p.capacity * (100 + p.overbook_pct) / 100
+					- COALESCE(SUM(r.seats) FILTER (WHERE r.status IN ('confirmed', 'paused', 'offered')), 0) AS spots_left
+			FROM programs p
+			LEFT JOIN registrations r ON r.parent_type = 'program' AND r.parent_id = p.id AND r.session_id IS NULL
+			WHERE p.id = ANY($1::uuid[])
+				AND NOT EXISTS (SELECT 1 FROM session_spots ss WHERE ss.program_id = p.id)
+			GROUP BY p.id
+		)
+		SELECT program_id FROM session_spots WHERE spots_left > 0
+		UNION
+		SELECT program_id FROM program_spots WHERE spots_left > 0
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to check open spots: %w", err)
+	}
+	defer rows.Close()
+
+	open := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan program: %w", err)
+		}
+		open[id] = true
+	}
+	return open, rows.Err()
+}
+
 // GetProgramSessions retrieves sessions for a program
-func (db *DB) GetProgramSessions(programID uuid.UUID, defaultCapacity int) ([]Session, error) {
+func (db *DB) GetProgramSessions(programID uuid.UUID, defaultCapacity int, overbookPct int) ([]Session, error) {
+	return db.getParentSessions("program", programID, defaultCapacity, overbookPct)
+}
+
+// GetEventSessions retrieves the occurrences of an event, each taking the event's
+// capacity unless it overrides it
+func (db *DB) GetEventSessions(eventID uuid.UUID, capacity int) ([]Session, error) {
+	return db.getParentSessions("event", eventID, capacity, 0)
+}
+
+// getParentSessions retrieves the active sessions of a program or event with their
+// capacity info
+func (db *DB) getParentSessions(parentType string, parentID uuid.UUID, defaultCapacity int, overbookPct int) ([]Session, error) {
 	rows, err := db.Query(`
 		SELECT
 			s.id, s.parent_type, s.parent_id, s.starts_at, s.ends_at,
 			s.capacity_override, s.is_active,
-			COALESCE(s.capacity_override, $1) as effective_capacity,
-			COALESCE(COALESCE(s.capacity_override, $1) - COUNT(DISTINCT CASE WHEN r.status = 'confirmed' THEN r.id END), 0) as spots_left,
+			COALESCE(s.capacity_override, $1) * (100 + $3) / 100 as effective_capacity,
+			COALESCE(COALESCE(s.capacity_override, $1) * (100 + $3) / 100 - COALESCE(SUM(r.seats) FILTER (WHERE r.status IN ('confirmed', 'paused', 'offered')), 0), 0) as spots_left,
 			COUNT(DISTINCT CASE WHEN r.status = 'waitlisted' THEN r.id END) as waitlist_count
 		FROM sessions s
 		LEFT JOIN registrations r ON r.session_id = s.id
-		WHERE s.parent_type = 'program' AND s.parent_id = $2 AND s.is_active = true
+		WHERE s.parent_type = $4 AND s.parent_id = $2 AND s.is_active = true
 		GROUP BY s.id
 		ORDER BY s.starts_at ASC NULLS LAST
-	`, defaultCapacity, programID)
+	`, defaultCapacity, parentID, overbookPct, parentType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sessions: %w", err)
 	}
@@ -144,10 +630,10 @@ func (db *DB) GetActiveEvents() ([]Event, error) {
 		SELECT
 			e.id, e.slug, e.title, e.description, e.location, e.capacity,
 			e.starts_at, e.ends_at, e.is_active, e.created_at, e.updated_at,
-			COALESCE(e.capacity - COUNT(DISTINCT CASE WHEN r.status = 'confirmed' THEN r.id END), 0) as spots_left,
+			COALESCE(e.capacity - COALESCE(SUM(r.seats) FILTER (WHERE r.status IN ('confirmed', 'offered')), 0), 0) as spots_left,
 			COUNT(DISTINCT CASE WHEN r.status = 'waitlisted' THEN r.id END) as waitlist_count
 		FROM events e
-		LEFT JOIN registrations r ON r.parent_type = 'event' AND r.parent_id = e.id
+		LEFT JOIN registrations r ON r.parent_type = 'event' AND r.parent_id = e.id AND r.session_id IS NULL
 		WHERE e.is_active = true
 		GROUP BY e.id
 		ORDER BY e.starts_at ASC NULLS LAST, e.title ASC
@@ -177,19 +663,56 @@ func (db *DB) GetActiveEvents() ([]Event, error) {
 	return events, nil
 }
 
-// GetEventBySlug retrieves an event by slug
+// GetEventByID retrieves an event by ID regardless of active state
+func (db *DB) GetEventByID(id uuid.UUID) (*Event, error) {
+	var e Event
+	err := db.QueryRow(`
+		SELECT
+			id, slug, title, description, location, capacity,
+			starts_at, ends_at, is_active, created_at, updated_at
+		FROM events
+		WHERE id = $1
+	`, id).Scan(
+		&e.ID, &e.Slug, &e.Title, &e.Description, &e.Location, &e.Capacity,
+		&e.StartsAt, &e.EndsAt, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get event: %w", err)
+	}
+	return &e, nil
+}
+
+// GetSessionByID retrieves a session by ID
+func (db *DB) GetSessionByID(id uuid.UUID) (*Session, error) {
+	var s Session
+	err := db.QueryRow(`
+		SELECT id, parent_type, parent_id, starts_at, ends_at, capacity_override, is_active
+		FROM sessions
+		WHERE id = $1
+	`, id).Scan(
+		&s.ID, &s.ParentType, &s.ParentID, &s.StartsAt, &s.EndsAt, &s.CapacityOverride, &s.IsActive,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	return &s, nil
+}
+
+// GetEventBySlug retrieves an event by slug with sessions
 func (db *DB) GetEventBySlug(slug string) (*Event, error) {
 	var e Event
 	err := db.QueryRow(`
 		SELECT
-			e.id, e.slug, e.title, e.description, e.location, e.capacity,
-			e.starts_at, e.ends_at, e.is_active, e.created_at, e.updated_at,
-			COALESCE(e.capacity - COUNT(DISTINCT CASE WHEN r.status = 'confirmed' THEN r.id END), 0) as spots_left,
-			COUNT(DISTINCT CASE WHEN r.status = 'waitlisted' THEN r.id END) as waitlist_count
-		FROM events e
-		LEFT JOIN registrations r ON r.parent_type = 'event' AND r.parent_id = e.id
-		WHERE e.slug = $1 AND e.is_active = true
-		GROUP BY e.id
+			id, slug, title, description, location, capacity,
+			starts_at, ends_at, is_active, created_at, updated_at
+		FROM events
+		WHERE slug = $1 AND is_active = true
 	`, slug).Scan(
 		&e.ID, &e.Slug, &e.Title, &e.Description, &e.Location, &e.Capacity,
 		&e.StartsAt, &e.EndsAt, &e.IsActive, &e.CreatedAt, &e.UpdatedAt,
@@ -201,20 +724,51 @@ func (db *DB) GetEventBySlug(slug string) (*Event, error) {
 		return nil, fmt.Errorf("failed to get event: %w", err)
 	}
 
-	// Calculate capacity
+	if err := db.loadEventCapacity(&e); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// GetEventDetail retrieves an event by ID regardless of active state, with its capacity
+// info as GetEventBySlug reports it
+func (db *DB) GetEventDetail(id uuid.UUID) (*Event, error) {
+	e, err := db.GetEventByID(id)
+	if err != nil || e == nil {
+		return e, err
+	}
+
+	if err := db.loadEventCapacity(e); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// loadEventCapacity fills in an event's sessions with their capacity info, and its own
+// spots left and waitlist count, which count the registrations for the event as a whole
+// rather than one of its sessions
+func (db *DB) loadEventCapacity(e *Event) error {
+	sessions, err := db.GetEventSessions(e.ID, e.Capacity)
+	if err != nil {
+		return err
+	}
+	e.Sessions = sessions
+
 	var spotsLeft, waitlistCount int
 	err = db.QueryRow(`
 		SELECT
-			COALESCE($1 - COUNT(DISTINCT CASE WHEN status = 'confirmed' THEN id END), 0),
+			COALESCE($1 - COALESCE(SUM(seats) FILTER (WHERE status IN ('confirmed', 'offered')), 0), 0),
 			COUNT(DISTINCT CASE WHEN status = 'waitlisted' THEN id END)
 		FROM registrations
-		WHERE parent_type = 'event' AND parent_id = $2
+		WHERE parent_type = 'event' AND parent_id = $2 AND session_id IS NULL
 	`, e.Capacity, e.ID).Scan(&spotsLeft, &waitlistCount)
 	if err != nil {
-		return nil, fmt.Errorf("failed to calculate capacity: %w", err)
+		return fmt.Errorf("failed to calculate capacity: %w", err)
 	}
 	e.SpotsLeft = &spotsLeft
 	e.WaitlistCount = &waitlistCount
 
-	return &e, nil
+	return nil
 }
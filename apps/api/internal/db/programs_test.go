@@ -0,0 +1,199 @@
+package db
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestIsPublished checks publish window bounds; a nil bound leaves that side open
+func TestIsPublished(t *testing.T) {
+	now := time.Date(2024, 6, 3, 12, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name                     string
+		publishedAt, unpublished *time.Time
+		want                     bool
+	}{
+		{"no window", nil, nil, true},
+		{"published earlier", &before, nil, true},
+		{"published exactly now", &now, nil, true},
+		{"not yet published", &after, nil, false},
+		{"unpublished later", nil, &after, true},
+		{"unpublished exactly now", nil, &now, false},
+		{"already unpublished", &before, &before, false},
+		{"inside window", &before, &after, true},
+	}
+	for _, tt := range tests {
+		if got := IsPublished(tt.publishedAt, tt.unpublished, now); got != tt.want {
+			t.Errorf("%s: IsPublished = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestProgramPublishWindow tests that programs outside their publish window are left out
+// of public listings but still listed for admins
+func TestProgramPublishWindow(t *testing.T) {
+	db := setupTestDB(t)
+
+	scheduled := createTestProgram(t, db, 10)
+	expired := createTestProgram(t, db, 10)
+	live := createTestProgram(t, db, 10)
+	_, err := db.Exec(`UPDATE programs SET published_at = NOW() + INTERVAL '1 day' WHERE id = $1`, scheduled)
+	if err != nil {
+		t.Fatalf("failed to schedule program: %v", err)
+	}
+	_, err = db.Exec(`UPDATE programs SET unpublished_at = NOW() - INTERVAL '1 day' WHERE id = $1`, expired)
+	if err != nil {
+		t.Fatalf("failed to unpublish program: %v", err)
+	}
+	_, err = db.Exec(`
+		UPDATE programs SET published_at = NOW() - INTERVAL '1 day', unpublished_at = NOW() + INTERVAL '1 day'
+		WHERE id = $1
+	`, live)
+	if err != nil {
+		t.Fatalf("failed to publish program: %v", err)
+	}
+
+	listed := func(programs []Program) map[uuid.UUID]bool {
+		ids := map[uuid.UUID]bool{}
+		for _, p := range programs {
+			ids[p.ID] = true
+		}
+		return ids
+	}
+
+	active, err := db.GetActivePrograms()
+	if err != nil {
+		t.Fatalf("GetActivePrograms: %v", err)
+	}
+	public := listed(active)
+	if public[scheduled] || public[expired] || !public[live] {
+		t.Errorf("public listing: scheduled=%v expired=%v live=%v; want only live", public[scheduled], public[expired], public[live])
+	}
+
+	all, err := db.GetAllPrograms()
+	if err != nil {
+		t.Fatalf("GetAllPrograms: %v", err)
+	}
+	admin := listed(all)
+	if !admin[scheduled] || !admin[expired] || !admin[live] {
+		t.Errorf("admin listing: scheduled=%v expired=%v live=%v; want all", admin[scheduled], admin[expired], admin[live])
+	}
+
+	program, err := db.GetProgramByID(scheduled)
+	if err != nil || program == nil {
+		t.Fatalf("GetProgramByID: %v, %v", program, err)
+	}
+	if bySlug, err := db.GetProgramBySlug(program.Slug); err != nil || bySlug != nil {
+		t.Errorf("GetProgramBySlug(scheduled) = %v, %v; want nil", bySlug, err)
+	}
+}
+
+// TestGetProgramDetail tests an inactive program, hidden from the public detail, is
+// returned for admins with its capacity
+func TestGetProgramDetail(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	registerTestParticipants(t, db, programID, nil, 3)
+	if _, err := db.Exec(`UPDATE programs SET is_active = false WHERE id = $1`, programID); err != nil {
+		t.Fatalf("failed to deactivate program: %v", err)
+	}
+
+	program, err := db.GetProgramDetail(programID)
+	if err != nil || program == nil {
+		t.Fatalf("GetProgramDetail: %v, %v", program, err)
+	}
+	if program.IsActive || program.SpotsLeft == nil || *program.SpotsLeft != 0 || program.WaitlistCount == nil || *program.WaitlistCount != 2 {
+		t.Errorf("program = %+v, want inactive with no spots left and 2 waitlisted", program)
+	}
+	if bySlug, err := db.GetProgramBySlug(program.Slug); err != nil || bySlug != nil {
+		t.Errorf("GetProgramBySlug(inactive) = %v, %v; want nil", bySlug, err)
+	}
+
+	if missing, err := db.GetProgramDetail(uuid.New()); err != nil || missing != nil {
+		t.Errorf("GetProgramDetail(unknown) = %v, %v; want nil", missing, err)
+	}
+}
+
+// TestProgramsWithOpenSpots tests programs are checked for a spot left in one query,
+// in the program itself or, for a program with sessions, in any of its sessions
+func TestProgramsWithOpenSpots(t *testing.T) {
+	db := setupTestDB(t)
+
+	open := createTestProgram(t, db, 2)
+	registerTestParticipants(t, db, open, nil, 1)
+	full := createTestProgram(t, db, 1)
+	registerTestParticipants(t, db, full, nil, 1)
+
+	one := 1
+	withSessions := createTestProgram(t, db, 5)
+	fullSession := createTestSession(t, db, withSessions, &one)
+	registerTestParticipants(t, db, withSessions, &fullSession, 1)
+	createTestSession(t, db, withSessions, &one)
+
+	allSessionsFull := createTestProgram(t, db, 5)
+	onlySession := createTestSession(t, db, allSessionsFull, &one)
+	registerTestParticipants(t, db, allSessionsFull, &onlySession, 1)
+
+	got, err := db.programsWithOpenSpots([]uuid.UUID{open, full, withSessions, allSessionsFull})
+	if err != nil {
+		t.Fatalf("programsWithOpenSpots: %v", err)
+	}
+
+	want := map[uuid.UUID]bool{open: true, withSessions: true}
+	for _, id := range []uuid.UUID{open, full, withSessions, allSessionsFull} {
+		if got[id] != want[id] {
+			t.Errorf("program %s open = %v, want %v", id, got[id], want[id])
+		}
+	}
+}
+
+// TestUpdateProgramClearsFields tests a partial update leaves fields it is not given
+// alone and clears nullable ones it is told to
+func TestUpdateProgramClearsFields(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+
+	questions := json.RawMessage(`[{"key": "shirt", "label": "Shirt size", "type": "text"}]`)
+	if err := db.UpdateProgram(programID, &ProgramUpdate{RegistrationQuestions: questions}); err != nil {
+		t.Fatalf("UpdateProgram(set questions): %v", err)
+	}
+	hasQuestions := func() bool {
+		return countRows(t, db, `SELECT COUNT(*) FROM programs WHERE id = $1 AND registration_questions IS NOT NULL`, programID) == 1
+	}
+
+	title := "Renamed"
+	if err := db.UpdateProgram(programID, &ProgramUpdate{Title: &title}); err != nil {
+		t.Fatalf("UpdateProgram(title): %v", err)
+	}
+	if !hasQuestions() {
+		t.Error("questions cleared by an update that did not mention them")
+	}
+
+	if err := db.UpdateProgram(programID, &ProgramUpdate{ClearRegistrationQuestions: true}); err != nil {
+		t.Fatalf("UpdateProgram(clear questions): %v", err)
+	}
+	if hasQuestions() {
+		t.Error("questions still set after clearing them")
+	}
+
+	publishAt := time.Now().Add(24 * time.Hour)
+	unpublishAt := publishAt.Add(24 * time.Hour)
+	if err := db.UpdateProgram(programID, &ProgramUpdate{PublishedAt: &publishAt, UnpublishedAt: &unpublishAt}); err != nil {
+		t.Fatalf("UpdateProgram(schedule): %v", err)
+	}
+	if err := db.UpdateProgram(programID, &ProgramUpdate{ClearPublishedAt: true, ClearUnpublishedAt: true}); err != nil {
+		t.Fatalf("UpdateProgram(clear schedule): %v", err)
+	}
+	program, err := db.GetProgramByID(programID)
+	if err != nil || program == nil {
+		t.Fatalf("GetProgramByID: %v, %v", program, err)
+	}
+	if program.PublishedAt != nil || program.UnpublishedAt != nil {
+		t.Errorf("publish window = %v to %v after clearing, want none", program.PublishedAt, program.UnpublishedAt)
+	}
+}
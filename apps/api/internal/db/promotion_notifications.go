@@ -0,0 +1,97 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionNotifyConfig schedules waitlist promotion emails. The promotions made by one
+// operation are queued together: the first BatchSize are sent after Delay and each
+// following batch BatchInterval later, so a burst of openings does not send a burst of
+// email.
+type PromotionNotifyConfig struct {
+	Delay         time.Duration
+	BatchSize     int
+	BatchInterval time.Duration
+}
+
+// DefaultPromotionNotifyConfig sends promotion emails straight away, 25 a minute
+var DefaultPromotionNotifyConfig = PromotionNotifyConfig{BatchSize: 25, BatchInterval: time.Minute}
+
+// promotionNotifyConfigFromEnv reads WAITLIST_PROMOTION_NOTIFY_DELAY_SECONDS,
+// WAITLIST_PROMOTION_BATCH_SIZE and WAITLIST_PROMOTION_BATCH_INTERVAL_SECONDS. Unset or
+// invalid values keep the defaults.
+func promotionNotifyConfigFromEnv() PromotionNotifyConfig {
+	cfg := DefaultPromotionNotifyConfig
+	if n, err := strconv.Atoi(os.Getenv("WAITLIST_PROMOTION_NOTIFY_DELAY_SECONDS")); err == nil && n >= 0 {
+		cfg.Delay = time.Duration(n) * time.Second
+	}
+	if n, err := strconv.Atoi(os.Getenv("WAITLIST_PROMOTION_BATCH_SIZE")); err == nil && n > 0 {
+		cfg.BatchSize = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("WAITLIST_PROMOTION_BATCH_INTERVAL_SECONDS")); err == nil && n >= 0 {
+		cfg.BatchInterval = time.Duration(n) * time.Second
+	}
+	return cfg
+}
+
+// sendAfter returns when the i-th promotion email of an operation may be sent, or nil
+// when it can go out straight away
+func (c PromotionNotifyConfig) sendAfter(now time.Time, i int) *time.Time {
+	wait := c.Delay
+	if c.BatchSize > 0 {
+		wait += time.Duration(i/c.BatchSize) * c.BatchInterval
+	}
+	if wait <= 0 {
+		return nil
+	}
+	t := now.Add(wait)
+	return &t
+}
+
+// queuePromotionNotificationsInTx queues a WAITLIST_PROMOTED email for each promotion of
+// one operation, or a WAITLIST_OFFER email giving the deadline for an offered spot. Each
+// payload names the registration and the batch it belongs to, so the email worker needs
+// nothing from the transaction.
+func (db *DB) queuePromotionNotificationsInTx(tx *sql.Tx, promotions []WaitlistPromotion) error {
+	batchID := uuid.New()
+	now := time.Now()
+	for i, p := range promotions {
+		payload := map[string]interface{}{
+			"parent_type":     p.ParentType,
+			"parent_id":       p.ParentID,
+			"participant_id":  p.ParticipantID,
+			"registration_id": p.RegistrationID,
+			"promoted_at":     now,
+			"batch_id":        batchID,
+			"batch_size":      len(promotions),
+		}
+		if p.SessionID != nil {
+			payload["session_id"] = p.SessionID
+		}
+		notifType := "WAITLIST_PROMOTED"
+		if p.Status == "offered" {
+			notifType = "WAITLIST_OFFER"
+			payload["offer_expires_at"] = p.OfferExpiresAt
+		}
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal payload: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO notification_queue (type, payload, not_before_ts)
+			VALUES ($1, $2, $3)
+		`, notifType, payloadJSON, db.promotionNotify.sendAfter(now, i))
+		if err != nil {
+			return fmt.Errorf("failed to queue promotion notification: %w", err)
+		}
+	}
+	return nil
+}
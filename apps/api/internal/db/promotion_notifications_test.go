@@ -0,0 +1,27 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPromotionNotifySendAfter checks promotion emails wait for the lead time and each
+// batch after the first waits one batch interval more
+func TestPromotionNotifySendAfter(t *testing.T) {
+	now := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+
+	if got := DefaultPromotionNotifyConfig.sendAfter(now, 0); got != nil {
+		t.Errorf("first default email sends after %v, want straight away", got)
+	}
+	if got := DefaultPromotionNotifyConfig.sendAfter(now, 25); got == nil || !got.Equal(now.Add(time.Minute)) {
+		t.Errorf("26th default email sends after %v, want a minute later", got)
+	}
+
+	cfg := PromotionNotifyConfig{Delay: 10 * time.Minute, BatchSize: 2, BatchInterval: 5 * time.Minute}
+	want := []time.Duration{10 * time.Minute, 10 * time.Minute, 15 * time.Minute, 15 * time.Minute, 20 * time.Minute}
+	for i, w := range want {
+		if got := cfg.sendAfter(now, i); got == nil || !got.Equal(now.Add(w)) {
+			t.Errorf("email %d sends after %v, want %v", i, got, now.Add(w))
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package db
+
+import (
+	"database/sql"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// slowQueryLogger writes slow query entries as JSON lines to stderr
+var slowQueryLogger = slog.New(slog.NewJSONHandler(os.Stderr, nil))
+
+// slowQueryThresholdFromEnv reads DB_SLOW_QUERY_MS. Unset, zero or invalid
+// values disable slow query logging.
+func slowQueryThresholdFromEnv() time.Duration {
+	ms, err := strconv.Atoi(os.Getenv("DB_SLOW_QUERY_MS"))
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
+// Query runs a query that returns rows, logging it if it exceeds the slow query threshold
+func (db *DB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.DB.Query(query, args...)
+	db.logIfSlow(query, start, err)
+	return rows, err
+}
+
+// QueryRow runs a query that returns at most one row, logging it if it exceeds the
+// slow query threshold. Only the time until the row is available is measured.
+func (db *DB) QueryRow(query string, args ...interface{}) *sql.Row {
+	start := time.Now()
+	row := db.DB.QueryRow(query, args...)
+	db.logIfSlow(query, start, row.Err())
+	return row
+}
+
+// Exec runs a statement without returning rows, logging it if it exceeds the slow query threshold
+func (db *DB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DB.Exec(query, args...)
+	db.logIfSlow(query, start, err)
+	return result, err
+}
+
+// logIfSlow logs the query and its duration when it took longer than the threshold
+func (db *DB) logIfSlow(query string, start time.Time, err error) {
+	if db.slowQueryThreshold <= 0 {
+		return
+	}
+
+	duration := time.Since(start)
+	if duration < db.slowQueryThreshold {
+		return
+	}
+
+	attrs := []interface{}{
+		slog.String("sql", strings.Join(strings.Fields(query), " ")),
+		slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+		slog.Int64("threshold_ms", db.slowQueryThreshold.Milliseconds()),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	slowQueryLogger.Warn("slow query", attrs...)
+}
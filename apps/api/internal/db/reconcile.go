@@ -0,0 +1,505 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// CapacityReconciliation checks one capacity scope of a program: the program itself
+// (SessionID nil) or one of its sessions
+type CapacityReconciliation struct {
+	SessionID           *uuid.UUID `json:"session_id,omitempty"`
+	Capacity            int        `json:"capacity"`        // effective capacity, including overbooking
+	ConfirmedSeats      int        `json:"confirmed_seats"` // capacity taken by confirmed, paused and offered registrations
+	WaitlistedCount     int        `json:"waitlisted_count"`
+	OversoldBy          int        `json:"oversold_by"`
+	PositionCount       int        `json:"position_count"`
+	PositionsContiguous bool       `json:"positions_contiguous"` // positions are exactly 1..position_count
+	OrphanPositions     int        `json:"orphan_positions"`     // positions without a waitlisted registration
+	MissingPositions    int        `json:"missing_positions"`    // waitlisted registrations without a position
+	Issues              []string   `json:"issues"`
+}
+
+// ProgramReconciliation is the reconciliation report for a program and its sessions
+type ProgramReconciliation struct {
+	ProgramID        uuid.UUID                `json:"program_id"`
+	Scopes           []CapacityReconciliation `json:"scopes"`
+	HasDiscrepancies bool                     `json:"has_discrepancies"`
+}
+
+// queryer is satisfied by both *DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// scopeFilter restricts waitlist_positions or registrations to one program scope
+const scopeFilter = `parent_type = 'program' AND parent_id = $1 AND session_id IS NOT DISTINCT FROM $2`
+
+// ReconcileProgram recomputes confirmed seats against capacity and checks waitlist
+// positions for the program and each of its sessions. Nothing is changed.
+func (db *DB) ReconcileProgram(programID uuid.UUID) (*ProgramReconciliation, error) {
+	return reconcileProgram(db, programID)
+}
+
+// FixProgramReconciliation repairs waitlist positions for the program and its sessions
+// in one transaction: positions without a waitlisted registration are removed, waitlisted
+// registrations without a position are appended in registration order, and positions are
+// re-sequenced to 1..n. Oversold scopes are reported but no registration is cancelled.
+// Returns the report after the fix.
+func (db *DB) FixProgramReconciliation(programID uuid.UUID) (*ProgramReconciliation, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sessionIDs, err := parentScopes(tx, "program", programID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sessionID := range sessionIDs {
+		// Lock the scope's positions so concurrent promotions wait for the fix
+		if _, err := tx.Exec(`SELECT id FROM waitlist_positions WHERE `+scopeFilter+` FOR UPDATE`, programID, sessionID); err != nil {
+			return nil, fmt.Errorf("failed to lock waitlist positions: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			DELETE FROM waitlist_positions wp
+			WHERE wp.`+scopeFilter+`
+				AND NOT EXISTS (
+					SELECT 1 FROM registrations r
+					WHERE r.parent_type = wp.parent_type AND r.parent_id = wp.parent_id
+						AND r.session_id IS NOT DISTINCT FROM wp.session_id
+						AND r.participant_id = wp.participant_id AND r.status = 'waitlisted'
+				)
+		`, programID, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove orphan waitlist positions: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO waitlist_positions (parent_type, parent_id, session_id, participant_id, position, notify_opt_in)
+			SELECT r.parent_type, r.parent_id, r.session_id, r.participant_id,
+				(SELECT COALESCE(MAX(position), 0) FROM waitlist_positions WHERE `+scopeFilter+`)
+					+ ROW_NUMBER() OVER (ORDER BY r.created_at),
+				true
+			FROM registrations r
+			WHERE r.`+scopeFilter+` AND r.status = 'waitlisted'
+				AND NOT EXISTS (
+					SELECT 1 FROM waitlist_positions wp
+					WHERE wp.parent_type = r.parent_type AND wp.parent_id = r.parent_id
+						AND wp.session_id IS NOT DISTINCT FROM r.session_id
+						AND wp.participant_id = r.participant_id
+				)
+		`, programID, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to add missing waitlist positions: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			UPDATE waitlist_positions wp
+			SET position = seq.new_position
+			FROM (
+				SELECT id, ROW_NUMBER() OVER (ORDER BY position, created_at) AS new_position
+				FROM waitlist_positions
+				WHERE `+scopeFilter+`
+			) seq
+			WHERE wp.id = seq.id AND wp.position <> seq.new_position
+		`, programID, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to re-sequence waitlist positions: %w", err)
+		}
+	}
+
+	report, err := reconcileProgram(tx, programID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return report, nil
+}
+
+// reconcileProgram builds the reconciliation report using q
+func reconcileProgram(q queryer, programID uuid.UUID) (*ProgramReconciliation, error) {
+	var capacity, overbookPct int
+	err := q.QueryRow(`SELECT capacity, overbook_pct FROM programs WHERE id = $1`, programID).Scan(&capacity, &overbookPct)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program capacity: %w", err)
+	}
+
+	sessionIDs, err := parentScopes(q, "program", programID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &ProgramReconciliation{ProgramID: programID, Scopes: []CapacityReconciliation{}}
+	for _, sessionID := range sessionIDs {
+		scopeCapacity := capacity
+		if sessionID != nil {
+			var override *int
+			if err := q.QueryRow(`SELECT capacity_override FROM sessions WHERE id = $1`, sessionID).Scan(&override); err != nil {
+				return nil, fmt.Errorf("failed to get session capacity: %w", err)
+			}
+			if override != nil {
+				scopeCapacity = *override
+			}
+		}
+
+		scope, err := reconcileScope(q, programID, sessionID, EffectiveCapacity(scopeCapacity, overbookPct))
+		if err != nil {
+			return nil, err
+		}
+		if len(scope.Issues) > 0 {
+			report.HasDiscrepancies = true
+		}
+		report.Scopes = append(report.Scopes, *scope)
+	}
+
+	return report, nil
+}
+
+// parentScopes lists the parent-level scope (nil) of a program or event followed by each
+// of its sessions
+func parentScopes(q queryer, parentType string, parentID uuid.UUID) ([]*uuid.UUID, error) {
+	rows, err := q.Query(`
+		SELECT id FROM sessions
+		WHERE parent_type = $1 AND parent_id = $2
+		ORDER BY starts_at ASC NULLS LAST
+	`, parentType, parentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	scopes := []*uuid.UUID{nil}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		scopes = append(scopes, &id)
+	}
+	return scopes, rows.Err()
+}
+
+// reconcileScope checks taken seats and waitlist positions for one scope
+func reconcileScope(q queryer, programID uuid.UUID, sessionID *uuid.UUID, capacity int) (*CapacityReconciliation, error) {
+	s := &CapacityReconciliation{SessionID: sessionID, Capacity: capacity, Issues: []string{}}
+
+	err := q.QueryRow(`
+		SELECT
+			COALESCE(SUM(seats) FILTER (WHERE status IN ('confirmed', 'paused', 'offered')), 0),
+			COUNT(*) FILTER (WHERE status = 'waitlisted')
+		FROM registrations
+		WHERE `+scopeFilter, programID, sessionID).Scan(&s.ConfirmedSeats, &s.WaitlistedCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count registrations: %w", err)
+	}
+
+	var distinctPositions, minPosition, maxPosition int
+	err = q.QueryRow(`
+		SELECT COUNT(*), COUNT(DISTINCT position), COALESCE(MIN(position), 0), COALESCE(MAX(position), 0)
+		FROM waitlist_positions
+		WHERE `+scopeFilter, programID, sessionID).Scan(&s.PositionCount, &distinctPositions, &minPosition, &maxPosition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check waitlist positions: %w", err)
+	}
+	s.PositionsContiguous = s.PositionCount == 0 ||
+		(distinctPositions == s.PositionCount && minPosition == 1 && maxPosition == s.PositionCount)
+
+	err = q.QueryRow(`
+		SELECT COUNT(*) FROM waitlist_positions wp
+		WHERE wp.`+scopeFilter+`
+			AND NOT EXISTS (
+				SELECT 1 FROM registrations r
+				WHERE r.parent_type = wp.parent_type AND r.parent_id = wp.parent_id
+					AND r.session_id IS NOT DISTINCT FROM wp.session_id
+					AND r.participant_id = wp.participant_id AND r.status = 'waitlisted'
+			)
+	`, programID, sessionID).Scan(&s.OrphanPositions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count orphan waitlist positions: %w", err)
+	}
+
+	err = q.QueryRow(`
+		SELECT COUNT(*) FROM registrations r
+		WHERE r.`+scopeFilter+` AND r.status = 'waitlisted'
+			AND NOT EXISTS (
+				SELECT 1 FROM waitlist_positions wp
+				WHERE wp.parent_type = r.parent_type AND wp.parent_id = r.parent_id
+					AND wp.session_id IS NOT DISTINCT FROM r.session_id
+					AND wp.participant_id = r.participant_id
+			)
+	`, programID, sessionID).Scan(&s.MissingPositions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count missing waitlist positions: %w", err)
+	}
+
+	if s.ConfirmedSeats > s.Capacity {
+		s.OversoldBy = s.ConfirmedSeats - s.Capacity
+		s.Issues = append(s.Issues, fmt.Sprintf("oversold by %d (%d seats confirmed, capacity %d)", s.OversoldBy, s.ConfirmedSeats, s.Capacity))
+	}
+	if !s.PositionsContiguous {
+		s.Issues = append(s.Issues, "waitlist positions are not contiguous from 1")
+	}
+	if s.OrphanPositions > 0 {
+		s.Issues = append(s.Issues, fmt.Sprintf("%d waitlist positions have no waitlisted registration", s.OrphanPositions))
+	}
+	if s.MissingPositions > 0 {
+		s.Issues = append(s.Issues, fmt.Sprintf("%d waitlisted registrations have no waitlist position", s.MissingPositions))
+	}
+	if s.ConfirmedSeats < s.Capacity && s.WaitlistedCount > 0 {
+		s.Issues = append(s.Issues, fmt.Sprintf("%d open spots while %d are waitlisted", s.Capacity-s.ConfirmedSeats, s.WaitlistedCount))
+	}
+
+	return s, nil
+}
+
+// DemotedRegistration is a confirmed registration moved to the waitlist because its
+// program's capacity was lowered
+type DemotedRegistration struct {
+	RegistrationID uuid.UUID  `json:"registration_id"`
+	ParticipantID  uuid.UUID  `json:"participant_id"`
+	SessionID      *uuid.UUID `json:"session_id,omitempty"`
+	Position       int        `json:"position"`
+}
+
+// ReconcileCapacity brings the program and each of its sessions back within capacity
+// after it was lowered, in one transaction. In each oversold scope the most recently
+// confirmed registrations are moved to the waitlist until the rest fit; they go to the
+// top of the waitlist, keeping the order in which they were confirmed, ahead of everyone
+// already waiting. Each demoted family is notified. Returns the demoted registrations.
+func (db *DB) ReconcileCapacity(programID uuid.UUID, changedBy *uuid.UUID) ([]DemotedRegistration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sessionIDs, err := parentScopes(tx, "program", programID)
+	if err != nil {
+		return nil, err
+	}
+
+	demoted := []DemotedRegistration{}
+	for _, sessionID := range sessionIDs {
+		scopeDemoted, err := db.demoteOverflowInTx(tx, "program", programID, sessionID, changedBy, "Program capacity was lowered")
+		if err != nil {
+			return nil, err
+		}
+		demoted = append(demoted, scopeDemoted...)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return demoted, nil
+}
+
+// FillFromWaitlist promotes waitlisted registrations into the spots opened when a program's
+// or event's capacity was raised, in one transaction across the parent and each of its
+// sessions. The promotion emails of the whole fill are queued as one batch. Returns the
+// promoted registrations.
+func (db *DB) FillFromWaitlist(parentType string, parentID uuid.UUID) ([]WaitlistPromotion, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	sessionIDs, err := parentScopes(tx, parentType, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	promoted := []WaitlistPromotion{}
+	for _, sessionID := range sessionIDs {
+		scopePromoted, err := db.promoteFromWaitlistInTx(tx, parentType, parentID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, scopePromoted...)
+	}
+
+	if err := db.queuePromotionNotificationsInTx(tx, promoted); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return promoted, nil
+}
+
+// ReconcileSessionCapacity brings a session's registrations in line with a changed
+// capacity_override: with demote, the overflow of a lowered capacity is first moved to
+// the top of the waitlist, then waitlisted registrations are promoted into any spots
+// left open. Both happen in one transaction. Returns the promoted and demoted
+// registrations.
+func (db *DB) ReconcileSessionCapacity(session *Session, demote bool, changedBy *uuid.UUID) ([]WaitlistPromotion, []DemotedRegistration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	demoted := []DemotedRegistration{}
+	if demote {
+		demoted, err = db.demoteOverflowInTx(tx, session.ParentType, session.ParentID, &session.ID, changedBy, "Session capacity was lowered")
+		if err != nil {
+			return nil, nil, err
+		}
+		if demoted == nil {
+			demoted = []DemotedRegistration{}
+		}
+	}
+
+	promoted, err := db.promoteFromWaitlistInTx(tx, session.ParentType, session.ParentID, &session.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if promoted == nil {
+		promoted = []WaitlistPromotion{}
+	}
+	if err := db.queuePromotionNotificationsInTx(tx, promoted); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return promoted, demoted, nil
+}
+
+// parentScopeFilter restricts waitlist_positions or registrations to one scope of a
+// program or event
+const parentScopeFilter = `parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3`
+
+// demoteOverflowInTx moves the most recently confirmed registrations of one scope of a
+// program or event to the top of its waitlist until the confirmed and paused seats fit
+// its capacity, recording reason in their status history and demotion emails
+func (db *DB) demoteOverflowInTx(tx *sql.Tx, parentType string, parentID uuid.UUID, sessionID *uuid.UUID, changedBy *uuid.UUID, reason string) ([]DemotedRegistration, error) {
+	capacity, err := db.getCapacityInTx(tx, parentType, parentID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Lock confirmed registrations, most recently confirmed first
+	rows, err := tx.Query(`
+		SELECT r.id, r.participant_id, r.seats
+		FROM registrations r
+		WHERE r.`+parentScopeFilter+` AND r.status = 'confirmed'
+		ORDER BY COALESCE(
+			(SELECT MAX(h.created_at) FROM registration_status_history h
+			 WHERE h.registration_id = r.id AND h.new_status = 'confirmed'),
+			r.created_at
+		) DESC, r.id DESC
+		FOR UPDATE OF r
+	`, parentType, parentID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock confirmed registrations: %w", err)
+	}
+
+	type confirmedRegistration struct {
+		id, participantID uuid.UUID
+		seats             int
+	}
+	var confirmed []confirmedRegistration
+	confirmedSeats := 0
+	for rows.Next() {
+		var r confirmedRegistration
+		if err := rows.Scan(&r.id, &r.participantID, &r.seats); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan registration: %w", err)
+		}
+		confirmed = append(confirmed, r)
+		confirmedSeats += r.seats
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to lock confirmed registrations: %w", err)
+	}
+
+	// Paused and offered registrations keep their seats and are never demoted
+	var pausedSeats int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(seats), 0) FROM registrations
+		WHERE `+parentScopeFilter+` AND status IN ('paused', 'offered')
+	`, parentType, parentID, sessionID).Scan(&pausedSeats)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count paused registrations: %w", err)
+	}
+	confirmedSeats += pausedSeats
+
+	var overflow []confirmedRegistration
+	for _, r := range confirmed {
+		if confirmedSeats <= capacity {
+			break
+		}
+		overflow = append(overflow, r)
+		confirmedSeats -= r.seats
+	}
+	if len(overflow) == 0 {
+		return nil, nil
+	}
+
+	// Make room at the top of the waitlist
+	if _, err := tx.Exec(`SELECT id FROM waitlist_positions WHERE `+parentScopeFilter+` FOR UPDATE`, parentType, parentID, sessionID); err != nil {
+		return nil, fmt.Errorf("failed to lock waitlist positions: %w", err)
+	}
+	_, err = tx.Exec(`UPDATE waitlist_positions SET position = position + $4 WHERE `+parentScopeFilter, parentType, parentID, sessionID, len(overflow))
+	if err != nil {
+		return nil, fmt.Errorf("failed to shift waitlist positions: %w", err)
+	}
+
+	confirmedStatus := "confirmed"
+	demoted := make([]DemotedRegistration, 0, len(overflow))
+	for i, r := range overflow {
+		// The overflow is newest first; the earliest confirmed of it goes first in line
+		position := len(overflow) - i
+
+		if _, err := tx.Exec(`UPDATE registrations SET status = 'waitlisted' WHERE id = $1`, r.id); err != nil {
+			return nil, fmt.Errorf("failed to demote registration: %w", err)
+		}
+		_, err = tx.Exec(`
+			INSERT INTO waitlist_positions (parent_type, parent_id, session_id, participant_id, position, notify_opt_in)
+			VALUES ($1, $2, $3, $4, $5, true)
+			ON CONFLICT (parent_type, parent_id, session_id, participant_id) DO UPDATE SET position = EXCLUDED.position
+		`, parentType, parentID, sessionID, r.participantID, position)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create waitlist position: %w", err)
+		}
+		if err := recordStatusChangeInTx(tx, r.id, &confirmedStatus, "waitlisted", changedBy, nil, &reason); err != nil {
+			return nil, err
+		}
+		err = db.queueNotificationInTx(tx, "demoted", RegistrationRequest{
+			ParentType:    parentType,
+			ParentID:      parentID,
+			SessionID:     sessionID,
+			ParticipantID: r.participantID,
+		}, &position, &reason)
+		if err != nil {
+			return nil, err
+		}
+
+		demoted = append(demoted, DemotedRegistration{
+			RegistrationID: r.id,
+			ParticipantID:  r.participantID,
+			SessionID:      sessionID,
+			Position:       position,
+		})
+	}
+
+	return demoted, nil
+}
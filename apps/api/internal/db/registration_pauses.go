@@ -0,0 +1,169 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RegistrationPause is a period a registration was paused while keeping its spot
+type RegistrationPause struct {
+	ID             uuid.UUID  `json:"id"`
+	RegistrationID uuid.UUID  `json:"registration_id"`
+	PausedAt       time.Time  `json:"paused_at"`
+	PausedBy       *uuid.UUID `json:"paused_by,omitempty"`
+	ResumeOn       *time.Time `json:"resume_on,omitempty"`
+	Reason         *string    `json:"reason,omitempty"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+	EndedBy        *uuid.UUID `json:"ended_by,omitempty"`
+}
+
+const registrationPauseColumns = `id, registration_id, paused_at, paused_by, resume_on, reason, ended_at, ended_by`
+
+func scanRegistrationPause(row rowScanner) (*RegistrationPause, error) {
+	var p RegistrationPause
+	err := row.Scan(&p.ID, &p.RegistrationID, &p.PausedAt, &p.PausedBy, &p.ResumeOn, &p.Reason, &p.EndedAt, &p.EndedBy)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// lockRegistrationStatusInTx locks a participant's registration and returns its parent
+// type and status
+func lockRegistrationStatusInTx(tx *sql.Tx, registrationID, participantID uuid.UUID) (string, string, error) {
+	var parentType, status string
+	err := tx.QueryRow(`
+		SELECT parent_type, status
+		FROM registrations
+		WHERE id = $1 AND participant_id = $2
+		FOR UPDATE
+	`, registrationID, participantID).Scan(&parentType, &status)
+	if err == sql.ErrNoRows {
+		return "", "", fmt.Errorf("registration not found")
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get registration: %w", err)
+	}
+	return parentType, status, nil
+}
+
+// PauseRegistration pauses a confirmed program registration. The participant keeps their
+// spot, so nobody is promoted from the waitlist, but is left off rosters and reminders
+// until the registration is resumed. resumeOn and reason are optional.
+func (db *DB) PauseRegistration(registrationID, participantID uuid.UUID, pausedBy *uuid.UUID, resumeOn *time.Time, reason *string) (*RegistrationPause, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	parentType, status, err := lockRegistrationStatusInTx(tx, registrationID, participantID)
+	if err != nil {
+		return nil, err
+	}
+	if parentType != "program" || status != "confirmed" {
+		return nil, fmt.Errorf("only confirmed program registrations can be paused")
+	}
+
+	if _, err := tx.Exec(`UPDATE registrations SET status = 'paused' WHERE id = $1`, registrationID); err != nil {
+		return nil, fmt.Errorf("failed to pause registration: %w", err)
+	}
+	if err := recordStatusChangeInTx(tx, registrationID, &status, "paused", pausedBy, nil, reason); err != nil {
+		return nil, err
+	}
+
+	pause, err := scanRegistrationPause(tx.QueryRow(`
+		INSERT INTO registration_pauses (registration_id, paused_by, resume_on, reason)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+registrationPauseColumns,
+		registrationID, pausedBy, resumeOn, reason))
+	if err != nil {
+		return nil, fmt.Errorf("failed to record pause: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pause, nil
+}
+
+// ResumeRegistration returns a paused registration to confirmed and closes its pause
+func (db *DB) ResumeRegistration(registrationID, participantID uuid.UUID, resumedBy *uuid.UUID) (*RegistrationPause, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, status, err := lockRegistrationStatusInTx(tx, registrationID, participantID)
+	if err != nil {
+		return nil, err
+	}
+	if status != "paused" {
+		return nil, fmt.Errorf("registration is not paused")
+	}
+
+	if _, err := tx.Exec(`UPDATE registrations SET status = 'confirmed' WHERE id = $1`, registrationID); err != nil {
+		return nil, fmt.Errorf("failed to resume registration: %w", err)
+	}
+	if err := recordStatusChangeInTx(tx, registrationID, &status, "confirmed", resumedBy, nil, nil); err != nil {
+		return nil, err
+	}
+
+	pause, err := endRegistrationPauseInTx(tx, registrationID, resumedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return pause, nil
+}
+
+// endRegistrationPauseInTx closes a registration's open pause and returns it, or nil
+// when it has none
+func endRegistrationPauseInTx(tx *sql.Tx, registrationID uuid.UUID, endedBy *uuid.UUID) (*RegistrationPause, error) {
+	pause, err := scanRegistrationPause(tx.QueryRow(`
+		UPDATE registration_pauses
+		SET ended_at = NOW(), ended_by = $2
+		WHERE registration_id = $1 AND ended_at IS NULL
+		RETURNING `+registrationPauseColumns,
+		registrationID, endedBy))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to end pause: %w", err)
+	}
+	return pause, nil
+}
+
+// GetRegistrationPauses returns a registration's pauses, most recent first
+func (db *DB) GetRegistrationPauses(registrationID uuid.UUID) ([]RegistrationPause, error) {
+	rows, err := db.Query(`
+		SELECT `+registrationPauseColumns+`
+		FROM registration_pauses
+		WHERE registration_id = $1
+		ORDER BY paused_at DESC
+	`, registrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pauses: %w", err)
+	}
+	defer rows.Close()
+
+	pauses := []RegistrationPause{}
+	for rows.Next() {
+		p, err := scanRegistrationPause(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pause: %w", err)
+		}
+		pauses = append(pauses, *p)
+	}
+	return pauses, rows.Err()
+}
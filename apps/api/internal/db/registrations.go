@@ -3,7 +3,9 @@ package db
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,83 +16,1070 @@ type RegistrationRequest struct {
 	ParentID      uuid.UUID
 	SessionID     *uuid.UUID
 	ParticipantID uuid.UUID
+	ActorUserID   *uuid.UUID      // user performing the registration, recorded in status history
+	Answers       json.RawMessage // answers to the program's registration questions
+	// AllowAgeOverride skips the program's age range check; callers only set it for admins
+	AllowAgeOverride bool
+	// SkipApproval places the registration even when the program requires approval
+	SkipApproval bool
+	// IdempotencyKey, when set, stores the result so a retry replays it; see idempotency_keys
+	IdempotencyKey *string
+	// Seats is how many capacity spots the registration takes, such as 3 for a group of
+	// three; zero means one
+	Seats int
+}
+
+// seats returns how many capacity spots the request takes
+func (r RegistrationRequest) seats() int {
+	if r.Seats < 1 {
+		return 1
+	}
+	return r.Seats
+}
+
+// AgeEligibilityError is returned when a participant's age falls outside the program's range
+type AgeEligibilityError struct {
+	Age    int
+	Reason string
+}
+
+func (e *AgeEligibilityError) Error() string {
+	return fmt.Sprintf("%s (age %d)", e.Reason, e.Age)
 }
 
 // RegistrationResult contains the outcome of a registration
 type RegistrationResult struct {
 	Registration *Registration
 	IsWaitlisted bool
+	IsPending    bool // awaiting admin approval
 	Position     *int
+	// AlreadyRegistered is set when the participant already held an active registration,
+	// which is returned unchanged
+	AlreadyRegistered bool
+}
+
+// CreateRegistration creates a new registration with capacity management
+// This MUST be called within the context of a capacity lock (see core/registration.go)
+func (db *DB) CreateRegistration(req RegistrationRequest) (*RegistrationResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := db.createRegistrationInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
+// BatchRegistrationItem is the outcome of a batch registration for one participant
+type BatchRegistrationItem struct {
+	ParticipantID uuid.UUID           `json:"participant_id"`
+	Status        string              `json:"status"` // confirmed, waitlisted, pending or error
+	Result        *RegistrationResult `json:"-"`
+	Err           error               `json:"-"`
+}
+
+// CreateRegistrations registers several participants for the same parent and session in
+// one transaction, in order, each against the capacity left by those before it. A
+// participant that fails is rolled back on its own and reported with its error. When
+// atomic is set, nothing is kept unless every participant is confirmed; the items still
+// report what each participant would have got. Returns whether the batch was committed.
+// This MUST be called within the context of a capacity lock (see core/registration.go)
+func (db *DB) CreateRegistrations(reqs []RegistrationRequest, atomic bool) ([]BatchRegistrationItem, bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	items := make([]BatchRegistrationItem, len(reqs))
+	allConfirmed := true
+	for i, req := range reqs {
+		items[i] = BatchRegistrationItem{ParticipantID: req.ParticipantID}
+
+		// A savepoint per participant keeps a failure from aborting the others
+		if _, err := tx.Exec(`SAVEPOINT batch_registration`); err != nil {
+			return nil, false, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		result, err := db.createRegistrationInTx(tx, req)
+		if err != nil {
+			if _, rbErr := tx.Exec(`ROLLBACK TO SAVEPOINT batch_registration`); rbErr != nil {
+				return nil, false, fmt.Errorf("failed to roll back to savepoint: %w", rbErr)
+			}
+			items[i].Status = "error"
+			items[i].Err = err
+			allConfirmed = false
+			continue
+		}
+		if _, err := tx.Exec(`RELEASE SAVEPOINT batch_registration`); err != nil {
+			return nil, false, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		items[i].Result = result
+		items[i].Status = result.Registration.Status
+		if result.Registration.Status != "confirmed" {
+			allConfirmed = false
+		}
+	}
+
+	if atomic && !allConfirmed {
+		return items, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return items, true, nil
+}
+
+// ErrSessionNotFound is returned when registering into, or transferring to, a session
+// that does not exist, is inactive or belongs to another program or event
+var ErrSessionNotFound = errors.New("session not found")
+
+// createRegistrationInTx places and records one registration
+func (db *DB) createRegistrationInTx(tx *sql.Tx, req RegistrationRequest) (*RegistrationResult, error) {
+	if req.SessionID != nil {
+		var parentType string
+		var parentID uuid.UUID
+		var isActive bool
+		err := tx.QueryRow(`
+			SELECT parent_type, parent_id, is_active FROM sessions WHERE id = $1
+		`, *req.SessionID).Scan(&parentType, &parentID, &isActive)
+		if err == sql.ErrNoRows || (err == nil && (parentType != req.ParentType || parentID != req.ParentID || !isActive)) {
+			return nil, ErrSessionNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session: %w", err)
+		}
+	}
+
+	// Registering again while confirmed, waitlisted or pending returns the existing
+	// registration instead of re-running placement, which could count the spot twice
+	existing, err := getActiveRegistrationInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return existing, nil
+	}
+
+	// Programs that require approval start pending and hold no spot until approved
+	requiresApproval := false
+	if req.ParentType == "program" {
+		var program Program
+		err = tx.QueryRow(`
+			SELECT requires_approval, age_min, age_max, start_date FROM programs WHERE id = $1
+		`, req.ParentID).Scan(&requiresApproval, &program.AgeMin, &program.AgeMax, &program.StartDate)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get program: %w", err)
+		}
+
+		// Participants without a DOB can't be checked and are let through
+		if !req.AllowAgeOverride && (program.AgeMin != nil || program.AgeMax != nil) {
+			var dob *time.Time
+			err = tx.QueryRow(`SELECT dob FROM participants WHERE id = $1`, req.ParticipantID).Scan(&dob)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get participant: %w", err)
+			}
+			if dob != nil {
+				age := AgeOn(*dob, ProgramAgeReferenceDate(&program))
+				if reason := AgeRequirementReason(&program, age); reason != "" {
+					return nil, &AgeEligibilityError{Age: age, Reason: reason}
+				}
+			}
+		}
+	}
+
+	var result RegistrationResult
+	var status string
+	var position *int
+
+	if requiresApproval && !req.SkipApproval {
+		status = "pending"
+	} else {
+		status, position, err = db.placeRegistrationInTx(tx, req)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Capture any previous status so re-registrations are recorded as transitions
+	var previousID *uuid.UUID
+	var previousStatus *string
+	err = tx.QueryRow(`
+		SELECT id, status FROM registrations
+		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID).Scan(&previousID, &previousStatus)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to check existing registration: %w", err)
+	}
+
+	// Create registration
+	var reg Registration
+	err = tx.QueryRow(`
+		INSERT INTO registrations (parent_type, parent_id, session_id, participant_id, status, answers_json, seats)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (parent_type, parent_id, session_id, participant_id)
+		DO UPDATE SET status = EXCLUDED.status, answers_json = EXCLUDED.answers_json, seats = EXCLUDED.seats
+		RETURNING id, parent_type, parent_id, session_id, participant_id, status, created_at, answers_json
+	`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID, status, nullableJSON(req.Answers), req.seats()).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
+		(*[]byte)(&reg.Answers),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registration: %w", err)
+	}
+
+	if previousID == nil || *previousID != reg.ID {
+		previousStatus = nil
+	}
+	if err := recordStatusChangeInTx(tx, reg.ID, previousStatus, status, req.ActorUserID, nil, nil); err != nil {
+		return nil, err
+	}
+
+	// Queue notification
+	err = db.queueNotificationInTx(tx, status, req, position, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Registration = &reg
+	result.IsWaitlisted = (status == "waitlisted")
+	result.IsPending = (status == "pending")
+	result.Position = position
+
+	if req.IdempotencyKey != nil && *req.IdempotencyKey != "" {
+		if err := saveIdempotentResponseInTx(tx, IdempotencyScopeRegistration, *req.IdempotencyKey, &result); err != nil {
+			return nil, err
+		}
+	}
+
+	return &result, nil
 }
 
-// CreateRegistration creates a new registration with capacity management
-// This MUST be called within the context of a capacity lock (see core/registration.go)
-func (db *DB) CreateRegistration(req RegistrationRequest) (*RegistrationResult, error) {
-	tx, err := db.Begin()
+// getActiveRegistrationInTx locks and returns the participant's confirmed, waitlisted,
+// pending or paused registration for the same parent and session, or nil when there is none
+func getActiveRegistrationInTx(tx *sql.Tx, req RegistrationRequest) (*RegistrationResult, error) {
+	var reg Registration
+	err := tx.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status, created_at, answers_json
+		FROM registrations
+		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+			AND status IN ('confirmed', 'waitlisted', 'pending', 'paused', 'offered')
+		FOR UPDATE
+	`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
+		(*[]byte)(&reg.Answers),
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check existing registration: %w", err)
+	}
+
+	result := &RegistrationResult{
+		Registration:      &reg,
+		IsWaitlisted:      reg.Status == "waitlisted",
+		IsPending:         reg.Status == "pending",
+		AlreadyRegistered: true,
+	}
+	if result.IsWaitlisted {
+		var position int
+		err := tx.QueryRow(`
+			SELECT position FROM waitlist_positions
+			WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+		`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID).Scan(&position)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+		}
+		if err == nil {
+			result.Position = &position
+		}
+	}
+	return result, nil
+}
+
+// CancelRegistration cancels a registration and promotes from waitlist if needed.
+// cancelledBy is recorded in the status history and may be nil for system cancellations;
+// reasonCode and reason are optional.
+func (db *DB) CancelRegistration(registrationID uuid.UUID, participantID uuid.UUID, cancelledBy *uuid.UUID, reasonCode, reason *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Get registration details
+	var reg Registration
+	err = tx.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status
+		FROM registrations
+		WHERE id = $1 AND participant_id = $2
+		FOR UPDATE
+	`, registrationID, participantID).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status,
+	)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("registration not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get registration: %w", err)
+	}
+
+	if err := db.cancelRegistrationInTx(tx, &reg, cancelledBy, reasonCode, reason); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// cancelRegistrationInTx cancels a locked registration, recording the change, and
+// promotes from the waitlist into the seats it held
+func (db *DB) cancelRegistrationInTx(tx *sql.Tx, reg *Registration, cancelledBy *uuid.UUID, reasonCode, reason *string) error {
+	_, err := tx.Exec(`
+		UPDATE registrations
+		SET status = 'cancelled', offer_expires_at = NULL
+		WHERE id = $1
+	`, reg.ID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel registration: %w", err)
+	}
+
+	if err := recordStatusChangeInTx(tx, reg.ID, &reg.Status, "cancelled", cancelledBy, reasonCode, reason); err != nil {
+		return err
+	}
+
+	// If it held a spot, promote from waitlist
+	if reg.Status == "confirmed" || reg.Status == "paused" || reg.Status == "offered" {
+		promotions, err := db.promoteFromWaitlistInTx(tx, reg.ParentType, reg.ParentID, reg.SessionID)
+		if err != nil {
+			return err
+		}
+		if err := db.queuePromotionNotificationsInTx(tx, promotions); err != nil {
+			return err
+		}
+	}
+
+	if reg.Status == "paused" {
+		if _, err := endRegistrationPauseInTx(tx, reg.ID, cancelledBy); err != nil {
+			return err
+		}
+	}
+
+	// A waitlist entry left behind would let a later promotion revive the registration
+	if reg.Status == "waitlisted" {
+		_, err = tx.Exec(`
+			DELETE FROM waitlist_positions
+			WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+		`, reg.ParentType, reg.ParentID, reg.SessionID, reg.ParticipantID)
+		if err != nil {
+			return fmt.Errorf("failed to delete waitlist position: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// TransferResult is the outcome of moving a registration to another session
+type TransferResult struct {
+	// NeedsWaitlistConfirmation is set when the target session is full and the transfer
+	// was not confirmed onto its waitlist; nothing was changed
+	NeedsWaitlistConfirmation bool
+	WaitlistPosition          *int
+	CancelledRegistrationID   uuid.UUID
+	Result                    *RegistrationResult
+}
+
+// TransferRegistration moves a confirmed or waitlisted program registration to another
+// session of the same program in one transaction: the old registration is cancelled,
+// promoting from its waitlist, and the participant is placed in the target session with
+// the same answers. When the target session is full and confirmWaitlist is not set,
+// nothing is changed and the waitlist position the participant would get is returned.
+// This MUST be called within the context of both sessions' capacity locks (see core/registration.go)
+func (db *DB) TransferRegistration(registrationID, participantID, targetSessionID uuid.UUID, actor *uuid.UUID, confirmWaitlist bool) (*TransferResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reg Registration
+	var seats int
+	err = tx.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status, created_at, answers_json, seats
+		FROM registrations
+		WHERE id = $1 AND participant_id = $2
+		FOR UPDATE
+	`, registrationID, participantID).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
+		(*[]byte)(&reg.Answers), &seats,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("registration not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration: %w", err)
+	}
+	if reg.ParentType != "program" || reg.SessionID == nil {
+		return nil, fmt.Errorf("only session registrations can be transferred")
+	}
+	if reg.Status != "confirmed" && reg.Status != "waitlisted" {
+		return nil, fmt.Errorf("only confirmed or waitlisted registrations can be transferred")
+	}
+	if *reg.SessionID == targetSessionID {
+		return nil, fmt.Errorf("registration is already in this session")
+	}
+
+	var targetProgramID uuid.UUID
+	var targetActive bool
+	err = tx.QueryRow(`
+		SELECT parent_id, is_active FROM sessions WHERE id = $1 AND parent_type = 'program'
+	`, targetSessionID).Scan(&targetProgramID, &targetActive)
+	if err == sql.ErrNoRows || (err == nil && (targetProgramID != reg.ParentID || !targetActive)) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	req := RegistrationRequest{
+		ParentType:    reg.ParentType,
+		ParentID:      reg.ParentID,
+		SessionID:     &targetSessionID,
+		ParticipantID: participantID,
+		ActorUserID:   actor,
+		Answers:       reg.Answers,
+		Seats:         seats,
+		// The participant was already accepted into the program
+		AllowAgeOverride: true,
+		SkipApproval:     true,
+	}
+
+	existing, err := getActiveRegistrationInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("participant is already registered for this session")
+	}
+
+	hasRoom, err := db.hasRoomInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+	if !hasRoom && !confirmWaitlist {
+		position, err := nextWaitlistPositionInTx(tx, req)
+		if err != nil {
+			return nil, err
+		}
+		return &TransferResult{NeedsWaitlistConfirmation: true, WaitlistPosition: &position}, nil
+	}
+
+	reason := "Transferred to another session"
+	if err := db.cancelRegistrationInTx(tx, &reg, actor, nil, &reason); err != nil {
+		return nil, err
+	}
+
+	result, err := db.createRegistrationInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &TransferResult{CancelledRegistrationID: reg.ID, Result: result}, nil
+}
+
+// ErrRegistrationStatusUnchanged is returned when setting a registration to the status it
+// already has
+var ErrRegistrationStatusUnchanged = errors.New("registration already has this status")
+
+// UpdateRegistrationStatus sets a registration's status directly (admin override)
+// and records the change in the status history. Setting the status it already has is
+// rejected with ErrRegistrationStatusUnchanged. The waitlist follows the status: a
+// registration taken off the waitlist loses its position and one put on it is added at
+// the end, and leaving paused ends the pause. A registration giving up its seat promotes
+// from the waitlist into it. Seats are not checked, so confirming over capacity is
+// allowed.
+// This MUST be called within the context of a capacity lock (see core/registration.go)
+func (db *DB) UpdateRegistrationStatus(id uuid.UUID, status string, changedBy uuid.UUID, reason *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var req RegistrationRequest
+	var oldStatus string
+	err = tx.QueryRow(`
+		SELECT parent_type, parent_id, session_id, participant_id, status
+		FROM registrations
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(&req.ParentType, &req.ParentID, &req.SessionID, &req.ParticipantID, &oldStatus)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("registration not found")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get registration: %w", err)
+	}
+	if oldStatus == status {
+		return ErrRegistrationStatusUnchanged
+	}
+
+	_, err = tx.Exec("UPDATE registrations SET status = $1, offer_expires_at = NULL WHERE id = $2", status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update registration status: %w", err)
+	}
+
+	if err := recordStatusChangeInTx(tx, id, &oldStatus, status, &changedBy, nil, reason); err != nil {
+		return err
+	}
+
+	if oldStatus == "waitlisted" {
+		_, err = tx.Exec(`
+			DELETE FROM waitlist_positions
+			WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+		`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID)
+		if err != nil {
+			return fmt.Errorf("failed to delete waitlist position: %w", err)
+		}
+	}
+
+	// The seat is filled before a registration moved to the waitlist joins it, so it is
+	// not promoted straight back into the seat it gave up
+	heldSeat := oldStatus == "confirmed" || oldStatus == "paused" || oldStatus == "offered"
+	if heldSeat && status != "confirmed" {
+		promotions, err := db.promoteFromWaitlistInTx(tx, req.ParentType, req.ParentID, req.SessionID)
+		if err != nil {
+			return err
+		}
+		if err := db.queuePromotionNotificationsInTx(tx, promotions); err != nil {
+			return err
+		}
+	}
+
+	if status == "waitlisted" {
+		position, err := nextWaitlistPositionInTx(tx, req)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			INSERT INTO waitlist_positions (parent_type, parent_id, session_id, participant_id, position, notify_opt_in)
+			VALUES ($1, $2, $3, $4, $5, true)
+			ON CONFLICT (parent_type, parent_id, session_id, participant_id) DO NOTHING
+		`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID, position)
+		if err != nil {
+			return fmt.Errorf("failed to create waitlist position: %w", err)
+		}
+	}
+
+	if oldStatus == "paused" {
+		if _, err := endRegistrationPauseInTx(tx, id, &changedBy); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ApproveRegistration moves a pending registration into the program: confirmed if there
+// is room, otherwise onto the waitlist. The family is notified either way.
+// This MUST be called within the context of a capacity lock (see core/registration.go)
+func (db *DB) ApproveRegistration(id, approvedBy uuid.UUID) (*RegistrationResult, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reg, err := getPendingRegistrationInTx(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	req := RegistrationRequest{
+		ParentType:    reg.ParentType,
+		ParentID:      reg.ParentID,
+		SessionID:     reg.SessionID,
+		ParticipantID: reg.ParticipantID,
+		ActorUserID:   &approvedBy,
+	}
+	if err := tx.QueryRow(`SELECT seats FROM registrations WHERE id = $1`, id).Scan(&req.Seats); err != nil {
+		return nil, fmt.Errorf("failed to get registration seats: %w", err)
+	}
+	status, position, err := db.placeRegistrationInTx(tx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec("UPDATE registrations SET status = $1 WHERE id = $2", status, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve registration: %w", err)
+	}
+
+	reason := "Approved"
+	if err := recordStatusChangeInTx(tx, id, &reg.Status, status, &approvedBy, nil, &reason); err != nil {
+		return nil, err
+	}
+
+	if err := db.queueNotificationInTx(tx, status, req, position, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	reg.Status = status
+	return &RegistrationResult{
+		Registration: reg,
+		IsWaitlisted: status == "waitlisted",
+		Position:     position,
+	}, nil
+}
+
+// RejectRegistration cancels a pending registration and notifies the family. Pending
+// registrations hold no spot, so nobody is promoted from the waitlist.
+func (db *DB) RejectRegistration(id, rejectedBy uuid.UUID, reason *string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	reg, err := getPendingRegistrationInTx(tx, id)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE registrations SET status = 'cancelled' WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to reject registration: %w", err)
+	}
+
+	if err := recordStatusChangeInTx(tx, id, &reg.Status, "cancelled", &rejectedBy, nil, reason); err != nil {
+		return err
+	}
+
+	err = db.queueNotificationInTx(tx, "rejected", RegistrationRequest{
+		ParentType:    reg.ParentType,
+		ParentID:      reg.ParentID,
+		SessionID:     reg.SessionID,
+		ParticipantID: reg.ParticipantID,
+	}, nil, reason)
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// getPendingRegistrationInTx locks a registration that is awaiting approval
+func getPendingRegistrationInTx(tx *sql.Tx, id uuid.UUID) (*Registration, error) {
+	var reg Registration
+	err := tx.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status, created_at, answers_json
+		FROM registrations
+		WHERE id = $1
+		FOR UPDATE
+	`, id).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
+		(*[]byte)(&reg.Answers),
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("registration not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration: %w", err)
+	}
+	if reg.Status != "pending" {
+		return nil, fmt.Errorf("registration is not pending approval")
+	}
+	return &reg, nil
+}
+
+// recordStatusChangeInTx appends a row to the registration status history
+func recordStatusChangeInTx(tx *sql.Tx, registrationID uuid.UUID, oldStatus *string, newStatus string, changedBy *uuid.UUID, reasonCode, reason *string) error {
+	_, err := tx.Exec(`
+		INSERT INTO registration_status_history (registration_id, old_status, new_status, changed_by, reason_code, reason)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`, registrationID, oldStatus, newStatus, changedBy, reasonCode, reason)
+	if err != nil {
+		return fmt.Errorf("failed to record status change: %w", err)
+	}
+	// Every status change can add or remove the registration from connected calendars
+	return queueCalendarSync(tx, "registration", registrationID)
+}
+
+// GetRegistrationStatusHistory retrieves the status history of a registration, oldest first
+func (db *DB) GetRegistrationStatusHistory(registrationID uuid.UUID) ([]RegistrationStatusChange, error) {
+	rows, err := db.Query(`
+		SELECT id, registration_id, old_status, new_status, changed_by, reason_code, reason, created_at
+		FROM registration_status_history
+		WHERE registration_id = $1
+		ORDER BY created_at ASC, id ASC
+	`, registrationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer rows.Close()
+
+	history := []RegistrationStatusChange{}
+	for rows.Next() {
+		var h RegistrationStatusChange
+		err := rows.Scan(&h.ID, &h.RegistrationID, &h.OldStatus, &h.NewStatus, &h.ChangedBy, &h.ReasonCode, &h.Reason, &h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		history = append(history, h)
+	}
+
+	return history, nil
+}
+
+// GetParticipantRegistrationHistory retrieves every registration of a participant,
+// cancelled ones included, oldest first, each with its program or event and its status
+// history
+func (db *DB) GetParticipantRegistrationHistory(participantID uuid.UUID) ([]RegistrationHistory, error) {
+	registrations, err := db.GetParticipantRegistrationDetails(participantID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.Query(`
+		SELECT h.id, h.registration_id, h.old_status, h.new_status, h.changed_by, h.reason_code, h.reason, h.created_at
+		FROM registration_status_history h
+		JOIN registrations r ON r.id = h.registration_id
+		WHERE r.participant_id = $1
+		ORDER BY h.created_at ASC, h.id ASC
+	`, participantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make(map[uuid.UUID][]RegistrationStatusChange)
+	for rows.Next() {
+		var h RegistrationStatusChange
+		err := rows.Scan(&h.ID, &h.RegistrationID, &h.OldStatus, &h.NewStatus, &h.ChangedBy, &h.ReasonCode, &h.Reason, &h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status change: %w", err)
+		}
+		changes[h.RegistrationID] = append(changes[h.RegistrationID], h)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+
+	// The details come newest first
+	history := make([]RegistrationHistory, 0, len(registrations))
+	for i := len(registrations) - 1; i >= 0; i-- {
+		r := RegistrationHistory{Registration: registrations[i], History: changes[registrations[i].ID]}
+		if r.History == nil {
+			r.History = []RegistrationStatusChange{}
+		}
+		history = append(history, r)
+	}
+
+	return history, nil
+}
+
+// GetRegistrationDetail retrieves a registration with its participant, guardian,
+// program/event, session, waiver status, status history and pauses
+func (db *DB) GetRegistrationDetail(id uuid.UUID) (*RegistrationDetail, error) {
+	var d RegistrationDetail
+	err := db.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status, created_at
+		FROM registrations
+		WHERE id = $1
+	`, id).Scan(
+		&d.ID, &d.ParentType, &d.ParentID, &d.SessionID, &d.ParticipantID, &d.Status, &d.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration: %w", err)
+	}
+
+	participant, err := db.GetParticipantByID(d.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+	d.Participant = participant
+
+	if participant != nil {
+		household, err := db.GetHouseholdByID(participant.HouseholdID)
+		if err != nil {
+			return nil, err
+		}
+		if household != nil {
+			guardian, err := db.GetUserByID(household.OwnerUserID)
+			if err != nil {
+				return nil, err
+			}
+			d.Guardian = guardian
+		}
+	}
+
+	d.Waivers = []RegistrationWaiverStatus{}
+	if d.ParentType == "program" {
+		program, err := db.GetProgramByID(d.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		d.ProgramInfo = program
+
+		programWaivers, err := db.GetProgramWaivers(d.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		for _, pw := range programWaivers {
+			accepted, err := db.CheckParticipantWaiverStatus(d.ParticipantID, pw.WaiverID, pw.Waiver.Version, nil)
+			if err != nil {
+				return nil, err
+			}
+			d.Waivers = append(d.Waivers, RegistrationWaiverStatus{
+				WaiverID:   pw.WaiverID,
+				Title:      pw.Waiver.Title,
+				Version:    pw.Waiver.Version,
+				IsRequired: pw.IsRequired,
+				Accepted:   accepted,
+			})
+		}
+	} else {
+		event, err := db.GetEventByID(d.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		d.EventInfo = event
+	}
+
+	if d.SessionID != nil {
+		session, err := db.GetSessionByID(*d.SessionID)
+		if err != nil {
+			return nil, err
+		}
+		d.SessionInfo = session
+	}
+
+	history, err := db.GetRegistrationStatusHistory(d.ID)
+	if err != nil {
+		return nil, err
+	}
+	d.History = history
+
+	pauses, err := db.GetRegistrationPauses(d.ID)
+	if err != nil {
+		return nil, err
+	}
+	d.Pauses = pauses
+
+	return &d, nil
+}
+
+// WaitlistPromotion is a waitlisted registration moved into an open spot
+type WaitlistPromotion struct {
+	RegistrationID uuid.UUID  `json:"registration_id"`
+	ParticipantID  uuid.UUID  `json:"participant_id"`
+	ParentType     string     `json:"parent_type"`
+	ParentID       uuid.UUID  `json:"parent_id"`
+	SessionID      *uuid.UUID `json:"session_id,omitempty"`
+	Position       int        `json:"position"` // waitlist position it was promoted from
+
+	// Status is 'offered' when the spot is held until OfferExpiresAt for the family to
+	// accept, or 'confirmed' when offers are disabled
+	Status         string     `json:"status"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+}
+
+// promoteFromWaitlistInTx promotes from the front of the waitlist while the parent/session
+// has room for the next in line, so freeing several seats at once fills all of them.
+// Offered spots count as taken, so each goes to one person at a time. Notifications are
+// left to the caller, which queues every promotion of its operation together.
+func (db *DB) promoteFromWaitlistInTx(tx *sql.Tx, parentType string, parentID uuid.UUID, sessionID *uuid.UUID) ([]WaitlistPromotion, error) {
+	scope := RegistrationRequest{ParentType: parentType, ParentID: parentID, SessionID: sessionID}
+
+	var promotions []WaitlistPromotion
+	for {
+		hasRoom, err := db.hasRoomInTx(tx, scope)
+		if err != nil {
+			return nil, err
+		}
+		if !hasRoom {
+			return promotions, nil
+		}
+
+		promotion, err := db.promoteNextInTx(tx, parentType, parentID, sessionID)
+		if err != nil {
+			return nil, err
+		}
+		if promotion == nil {
+			return promotions, nil // No one left on the waitlist, or the next needs more seats
+		}
+		promotions = append(promotions, *promotion)
+	}
+}
+
+// promoteNextInTx offers the spot to the first registration on the waitlist that has not
+// opted out of promotion, or confirms it when offers are disabled. It returns nil when no
+// one is waiting, or when the first in line needs more seats than are free: a group
+// keeps its place rather than being passed over. Opted-out entries are passed over and
+// keep their place, or with removeOptedOut are cancelled and taken off the waitlist.
+func (db *DB) promoteNextInTx(tx *sql.Tx, parentType string, parentID uuid.UUID, sessionID *uuid.UUID) (*WaitlistPromotion, error) {
+	// Get the next waitlist position whose registration is still waitlisted; entries left
+	// behind by registrations that have since moved on are skipped
+	var wpID uuid.UUID
+	var seats int
+	promotion := WaitlistPromotion{ParentType: parentType, ParentID: parentID, SessionID: sessionID}
+	for {
+		var optIn bool
+		err := tx.QueryRow(`
+			SELECT wp.id, wp.participant_id, wp.position, wp.notify_opt_in, r.seats
+			FROM waitlist_positions wp
+			JOIN registrations r ON r.parent_type = wp.parent_type AND r.parent_id = wp.parent_id
+				AND r.session_id IS NOT DISTINCT FROM wp.session_id AND r.participant_id = wp.participant_id
+				AND r.status = 'waitlisted'
+			WHERE wp.parent_type = $1 AND wp.parent_id = $2 AND wp.session_id IS NOT DISTINCT FROM $3
+				AND (wp.notify_opt_in OR $4)
+			ORDER BY wp.position ASC
+			LIMIT 1
+			FOR UPDATE OF wp SKIP LOCKED
+		`, parentType, parentID, sessionID, db.removeOptedOut).Scan(&wpID, &promotion.ParticipantID, &promotion.Position, &optIn, &seats)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+		}
+		if optIn {
+			break
+		}
+		if err := db.removeOptedOutInTx(tx, wpID, parentType, parentID, sessionID, promotion.ParticipantID); err != nil {
+			return nil, err
+		}
+	}
+
+	fits, err := db.hasRoomInTx(tx, RegistrationRequest{ParentType: parentType, ParentID: parentID, SessionID: sessionID, Seats: seats})
 	if err != nil {
-		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, err
+	}
+	if !fits {
+		return nil, nil
 	}
-	defer tx.Rollback()
 
-	// Get capacity for this parent/session
-	capacity, err := db.getCapacityInTx(tx, req.ParentType, req.ParentID, req.SessionID)
+	// Hold the spot for the family to accept, or confirm it outright without offers
+	promotion.Status = "confirmed"
+	if db.offerWindow > 0 {
+		expiresAt := time.Now().Add(db.offerWindow)
+		promotion.Status = "offered"
+		promotion.OfferExpiresAt = &expiresAt
+	}
+	err = tx.QueryRow(`
+		UPDATE registrations
+		SET status = $5, offer_expires_at = $6
+		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+			AND status = 'waitlisted'
+		RETURNING id
+	`, parentType, parentID, sessionID, promotion.ParticipantID, promotion.Status, promotion.OfferExpiresAt).Scan(&promotion.RegistrationID)
 	if err != nil {
+		return nil, fmt.Errorf("failed to promote registration: %w", err)
+	}
+
+	waitlisted := "waitlisted"
+	reason := "Promoted from waitlist"
+	if err := recordStatusChangeInTx(tx, promotion.RegistrationID, &waitlisted, promotion.Status, nil, nil, &reason); err != nil {
 		return nil, err
 	}
 
-	// Lock and count confirmed registrations
-	var confirmedCount int
-	if req.SessionID != nil {
-		err = tx.QueryRow(`
-			SELECT COUNT(*) FROM (
-				SELECT id FROM registrations
-				WHERE parent_type = $1 AND parent_id = $2 AND session_id = $3 AND status = 'confirmed'
-				FOR UPDATE
-			) AS locked_rows
-		`, req.ParentType, req.ParentID, req.SessionID).Scan(&confirmedCount)
-	} else {
-		err = tx.QueryRow(`
-			SELECT COUNT(*) FROM (
-				SELECT id FROM registrations
-				WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NULL AND status = 'confirmed'
-				FOR UPDATE
-			) AS locked_rows
-		`, req.ParentType, req.ParentID).Scan(&confirmedCount)
+	// Delete waitlist position
+	_, err = tx.Exec(`DELETE FROM waitlist_positions WHERE id = $1`, wpID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete waitlist position: %w", err)
 	}
+
+	return &promotion, nil
+}
+
+// removeOptedOutInTx cancels a waitlisted registration that opted out of promotion,
+// deletes its waitlist position and emails the family why with WAITLIST_REMOVED
+func (db *DB) removeOptedOutInTx(tx *sql.Tx, wpID uuid.UUID, parentType string, parentID uuid.UUID, sessionID *uuid.UUID, participantID uuid.UUID) error {
+	var registrationID uuid.UUID
+	err := tx.QueryRow(`
+		UPDATE registrations
+		SET status = 'cancelled'
+		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+			AND status = 'waitlisted'
+		RETURNING id
+	`, parentType, parentID, sessionID, participantID).Scan(&registrationID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to count registrations: %w", err)
+		return fmt.Errorf("failed to cancel opted-out registration: %w", err)
+	}
+
+	waitlisted := "waitlisted"
+	reason := "Opted out of waitlist promotion"
+	if err := recordStatusChangeInTx(tx, registrationID, &waitlisted, "cancelled", nil, nil, &reason); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM waitlist_positions WHERE id = $1`, wpID); err != nil {
+		return fmt.Errorf("failed to delete waitlist position: %w", err)
+	}
+
+	req := RegistrationRequest{ParentType: parentType, ParentID: parentID, SessionID: sessionID, ParticipantID: participantID}
+	return db.queueNotificationInTx(tx, "removed", req, nil, &reason)
+}
+
+// placeRegistrationInTx decides whether a registration is confirmed or waitlisted against
+// the current confirmed count, adding a waitlist position when it is full. Pending
+// registrations are not counted.
+func (db *DB) placeRegistrationInTx(tx *sql.Tx, req RegistrationRequest) (string, *int, error) {
+	hasRoom, err := db.hasRoomInTx(tx, req)
+	if err != nil {
+		return "", nil, err
 	}
 
-	var result RegistrationResult
 	var status string
 	var position *int
 
-	if confirmedCount < capacity {
+	if hasRoom {
 		// Space available - confirm registration
 		status = "confirmed"
 	} else {
 		// Full - add to waitlist
 		status = "waitlisted"
 
-		// Get next position
-		var nextPos int
-		if req.SessionID != nil {
-			err = tx.QueryRow(`
-				SELECT COALESCE(MAX(position), 0) + 1
-				FROM waitlist_positions
-				WHERE parent_type = $1 AND parent_id = $2 AND session_id = $3
-			`, req.ParentType, req.ParentID, req.SessionID).Scan(&nextPos)
-		} else {
-			err = tx.QueryRow(`
-				SELECT COALESCE(MAX(position), 0) + 1
-				FROM waitlist_positions
-				WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NULL
-			`, req.ParentType, req.ParentID).Scan(&nextPos)
-		}
+		nextPos, err := nextWaitlistPositionInTx(tx, req)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get next waitlist position: %w", err)
+			return "", nil, err
 		}
-
 		position = &nextPos
 
 		// Insert waitlist position
@@ -100,160 +1089,114 @@ func (db *DB) CreateRegistration(req RegistrationRequest) (*RegistrationResult,
 			ON CONFLICT (parent_type, parent_id, session_id, participant_id) DO NOTHING
 		`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID, nextPos)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create waitlist position: %w", err)
+			return "", nil, fmt.Errorf("failed to create waitlist position: %w", err)
 		}
 	}
 
-	// Create registration
-	var reg Registration
-	err = tx.QueryRow(`
-		INSERT INTO registrations (parent_type, parent_id, session_id, participant_id, status)
-		VALUES ($1, $2, $3, $4, $5)
-		ON CONFLICT (parent_type, parent_id, session_id, participant_id) DO UPDATE SET status = EXCLUDED.status
-		RETURNING id, parent_type, parent_id, session_id, participant_id, status, created_at
-	`, req.ParentType, req.ParentID, req.SessionID, req.ParticipantID, status).Scan(
-		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create registration: %w", err)
-	}
+	return status, position, nil
+}
 
-	// Queue notification
-	err = db.queueNotificationInTx(tx, status, req, position)
+// hasRoomInTx locks the confirmed registrations of a parent/session and reports whether
+// the seats they take leave room for the seats of req
+func (db *DB) hasRoomInTx(tx *sql.Tx, req RegistrationRequest) (bool, error) {
+	// Get capacity for this parent/session
+	capacity, err := db.getCapacityInTx(tx, req.ParentType, req.ParentID, req.SessionID)
 	if err != nil {
-		return nil, err
+		return false, err
 	}
 
-	if err := tx.Commit(); err != nil {
-		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	// Lock confirmed, paused and offered registrations and count the seats they take
+	var confirmedSeats int
+	if req.SessionID != nil {
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(seats), 0) FROM (
+				SELECT seats FROM registrations
+				WHERE parent_type = $1 AND parent_id = $2 AND session_id = $3 AND status IN ('confirmed', 'paused', 'offered')
+				FOR UPDATE
+			) AS locked_rows
+		`, req.ParentType, req.ParentID, req.SessionID).Scan(&confirmedSeats)
+	} else {
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(seats), 0) FROM (
+				SELECT seats FROM registrations
+				WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NULL AND status IN ('confirmed', 'paused', 'offered')
+				FOR UPDATE
+			) AS locked_rows
+		`, req.ParentType, req.ParentID).Scan(&confirmedSeats)
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to count registrations: %w", err)
 	}
 
-	result.Registration = &reg
-	result.IsWaitlisted = (status == "waitlisted")
-	result.Position = position
-
-	return &result, nil
+	return confirmedSeats+req.seats() <= capacity, nil
 }
 
-// CancelRegistration cancels a registration and promotes from waitlist if needed
-func (db *DB) CancelRegistration(registrationID uuid.UUID, participantID uuid.UUID) error {
+// SeatsLeft returns how many seats a parent/session has left as placement counts them,
+// negative when it is overbooked. Nothing is locked, so a registration made afterwards
+// may still find it full.
+func (db *DB) SeatsLeft(parentType string, parentID uuid.UUID, sessionID *uuid.UUID) (int, error) {
 	tx, err := db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Get registration details
-	var reg Registration
-	err = tx.QueryRow(`
-		SELECT id, parent_type, parent_id, session_id, participant_id, status
-		FROM registrations
-		WHERE id = $1 AND participant_id = $2
-		FOR UPDATE
-	`, registrationID, participantID).Scan(
-		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status,
-	)
-	if err == sql.ErrNoRows {
-		return fmt.Errorf("registration not found")
-	}
+	capacity, err := db.getCapacityInTx(tx, parentType, parentID, sessionID)
 	if err != nil {
-		return fmt.Errorf("failed to get registration: %w", err)
+		return 0, err
 	}
 
-	// Update to cancelled
-	_, err = tx.Exec(`
-		UPDATE registrations
-		SET status = 'cancelled'
-		WHERE id = $1
-	`, registrationID)
+	var takenSeats int
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(seats), 0) FROM registrations
+		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3
+			AND status IN ('confirmed', 'paused', 'offered')
+	`, parentType, parentID, sessionID).Scan(&takenSeats)
 	if err != nil {
-		return fmt.Errorf("failed to cancel registration: %w", err)
+		return 0, fmt.Errorf("failed to count registrations: %w", err)
 	}
 
-	// If was confirmed, promote from waitlist
-	if reg.Status == "confirmed" {
-		err = db.promoteFromWaitlistInTx(tx, reg.ParentType, reg.ParentID, reg.SessionID)
-		if err != nil {
-			return err
-		}
-	}
+	return capacity - takenSeats, nil
+}
 
-	if err := tx.Commit(); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+// HasActiveRegistration reports whether the participant already has a confirmed,
+// waitlisted, pending, paused or offered registration for the parent and session
+func (db *DB) HasActiveRegistration(parentType string, parentID uuid.UUID, sessionID *uuid.UUID, participantID uuid.UUID) (bool, error) {
+	var exists bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM registrations
+			WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NOT DISTINCT FROM $3 AND participant_id = $4
+				AND status IN ('confirmed', 'waitlisted', 'pending', 'paused', 'offered')
+		)
+	`, parentType, parentID, sessionID, participantID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing registration: %w", err)
 	}
-
-	return nil
+	return exists, nil
 }
 
-// promoteFromWaitlistInTx promotes the next person from the waitlist
-func (db *DB) promoteFromWaitlistInTx(tx *sql.Tx, parentType string, parentID uuid.UUID, sessionID *uuid.UUID) error {
-	// Get next waitlist position
-	var wpID uuid.UUID
-	var participantID uuid.UUID
-	var query string
-
-	if sessionID != nil {
-		query = `
-			SELECT id, participant_id
+// nextWaitlistPositionInTx returns the position after the last on a parent/session's waitlist
+func nextWaitlistPositionInTx(tx *sql.Tx, req RegistrationRequest) (int, error) {
+	var nextPos int
+	var err error
+	if req.SessionID != nil {
+		err = tx.QueryRow(`
+			SELECT COALESCE(MAX(position), 0) + 1
 			FROM waitlist_positions
 			WHERE parent_type = $1 AND parent_id = $2 AND session_id = $3
-			ORDER BY position ASC
-			LIMIT 1
-			FOR UPDATE SKIP LOCKED
-		`
-		err := tx.QueryRow(query, parentType, parentID, sessionID).Scan(&wpID, &participantID)
-		if err == sql.ErrNoRows {
-			return nil // No one on waitlist
-		}
-		if err != nil {
-			return fmt.Errorf("failed to get waitlist position: %w", err)
-		}
+		`, req.ParentType, req.ParentID, req.SessionID).Scan(&nextPos)
 	} else {
-		query = `
-			SELECT id, participant_id
+		err = tx.QueryRow(`
+			SELECT COALESCE(MAX(position), 0) + 1
 			FROM waitlist_positions
 			WHERE parent_type = $1 AND parent_id = $2 AND session_id IS NULL
-			ORDER BY position ASC
-			LIMIT 1
-			FOR UPDATE SKIP LOCKED
-		`
-		err := tx.QueryRow(query, parentType, parentID).Scan(&wpID, &participantID)
-		if err == sql.ErrNoRows {
-			return nil // No one on waitlist
-		}
-		if err != nil {
-			return fmt.Errorf("failed to get waitlist position: %w", err)
-		}
-	}
-
-	// Update registration to confirmed
-	_, err := tx.Exec(`
-		UPDATE registrations
-		SET status = 'confirmed'
-		WHERE parent_type = $1 AND parent_id = $2 AND session_id IS DISTINCT FROM $3 AND participant_id = $4
-	`, parentType, parentID, sessionID, participantID)
-	if err != nil {
-		return fmt.Errorf("failed to promote registration: %w", err)
+		`, req.ParentType, req.ParentID).Scan(&nextPos)
 	}
-
-	// Delete waitlist position
-	_, err = tx.Exec(`DELETE FROM waitlist_positions WHERE id = $1`, wpID)
-	if err != nil {
-		return fmt.Errorf("failed to delete waitlist position: %w", err)
-	}
-
-	// Queue promotion notification
-	err = db.queueNotificationInTx(tx, "promoted", RegistrationRequest{
-		ParentType:    parentType,
-		ParentID:      parentID,
-		SessionID:     sessionID,
-		ParticipantID: participantID,
-	}, nil)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("failed to get next waitlist position: %w", err)
 	}
-
-	return nil
+	return nextPos, nil
 }
 
 // getCapacityInTx gets the effective capacity for a parent/session
@@ -261,30 +1204,32 @@ func (db *DB) getCapacityInTx(tx *sql.Tx, parentType string, parentID uuid.UUID,
 	if sessionID != nil {
 		// Session-specific capacity
 		var capacityOverride *int
-		var defaultCapacity int
+		var defaultCapacity, overbookPct int
 		err := tx.QueryRow(`
-			SELECT s.capacity_override, p.capacity
+			SELECT s.capacity_override, COALESCE(p.capacity, e.capacity), COALESCE(p.overbook_pct, 0)
 			FROM sessions s
 			LEFT JOIN programs p ON p.id = s.parent_id AND s.parent_type = 'program'
 			LEFT JOIN events e ON e.id = s.parent_id AND s.parent_type = 'event'
 			WHERE s.id = $1
-		`, sessionID).Scan(&capacityOverride, &defaultCapacity)
+		`, sessionID).Scan(&capacityOverride, &defaultCapacity, &overbookPct)
 		if err != nil {
 			return 0, fmt.Errorf("failed to get session capacity: %w", err)
 		}
 		if capacityOverride != nil {
-			return *capacityOverride, nil
+			return EffectiveCapacity(*capacityOverride, overbookPct), nil
 		}
-		return defaultCapacity, nil
+		return EffectiveCapacity(defaultCapacity, overbookPct), nil
 	}
 
 	// Parent-level capacity
 	var capacity int
 	if parentType == "program" {
-		err := tx.QueryRow(`SELECT capacity FROM programs WHERE id = $1`, parentID).Scan(&capacity)
+		var overbookPct int
+		err := tx.QueryRow(`SELECT capacity, overbook_pct FROM programs WHERE id = $1`, parentID).Scan(&capacity, &overbookPct)
 		if err != nil {
 			return 0, fmt.Errorf("failed to get program capacity: %w", err)
 		}
+		capacity = EffectiveCapacity(capacity, overbookPct)
 	} else {
 		err := tx.QueryRow(`SELECT capacity FROM events WHERE id = $1`, parentID).Scan(&capacity)
 		if err != nil {
@@ -296,7 +1241,7 @@ func (db *DB) getCapacityInTx(tx *sql.Tx, parentType string, parentID uuid.UUID,
 }
 
 // queueNotificationInTx queues an email notification
-func (db *DB) queueNotificationInTx(tx *sql.Tx, notifType string, req RegistrationRequest, position *int) error {
+func (db *DB) queueNotificationInTx(tx *sql.Tx, notifType string, req RegistrationRequest, position *int, reason *string) error {
 	payload := map[string]interface{}{
 		"parent_type":    req.ParentType,
 		"parent_id":      req.ParentID,
@@ -308,6 +1253,9 @@ func (db *DB) queueNotificationInTx(tx *sql.Tx, notifType string, req Registrati
 	if position != nil {
 		payload["position"] = *position
 	}
+	if reason != nil {
+		payload["reason"] = *reason
+	}
 
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
@@ -320,8 +1268,14 @@ func (db *DB) queueNotificationInTx(tx *sql.Tx, notifType string, req Registrati
 		emailType = "CONFIRMATION"
 	case "waitlisted":
 		emailType = "WAITLIST_SPOT"
-	case "promoted":
-		emailType = "WAITLIST_PROMOTED"
+	case "demoted":
+		emailType = "WAITLIST_DEMOTED"
+	case "pending":
+		emailType = "REGISTRATION_PENDING_REVIEW"
+	case "rejected":
+		emailType = "REGISTRATION_REJECTED"
+	case "removed":
+		emailType = "WAITLIST_REMOVED"
 	default:
 		return fmt.Errorf("unknown notification type: %s", notifType)
 	}
@@ -337,6 +1291,216 @@ func (db *DB) queueNotificationInTx(tx *sql.Tx, notifType string, req Registrati
 	return nil
 }
 
+// WaitlistStanding describes where a waitlisted registration stands. Raw positions can
+// include entries whose registration is no longer waitlisted; only live entries ahead
+// count towards the effective position.
+type WaitlistStanding struct {
+	RegistrationID    uuid.UUID `json:"registration_id"`
+	Position          int       `json:"position"`
+	AheadWaitlisted   int       `json:"ahead_waitlisted"` // entries ahead that are still waitlisted
+	AheadInactive     int       `json:"ahead_inactive"`   // entries ahead whose registration is no longer waitlisted
+	EffectivePosition int       `json:"effective_position"`
+	TotalWaitlisted   int       `json:"total_waitlisted"`
+}
+
+// GetWaitlistStanding returns the waitlist standing of a registration, or nil if it is
+// not on a waitlist
+func (db *DB) GetWaitlistStanding(registrationID uuid.UUID) (*WaitlistStanding, error) {
+	var parentType string
+	var parentID uuid.UUID
+	var sessionID *uuid.UUID
+	var position int
+	err := db.QueryRow(`
+		SELECT r.parent_type, r.parent_id, r.session_id, wp.position
+		FROM registrations r
+		JOIN waitlist_positions wp ON wp.parent_type = r.parent_type AND wp.parent_id = r.parent_id
+			AND wp.session_id IS NOT DISTINCT FROM r.session_id AND wp.participant_id = r.participant_id
+		WHERE r.id = $1 AND r.status = 'waitlisted'
+	`, registrationID).Scan(&parentType, &parentID, &sessionID, &position)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waitlist position: %w", err)
+	}
+
+	standing := &WaitlistStanding{RegistrationID: registrationID, Position: position}
+	err = db.QueryRow(`
+		SELECT
+			COUNT(*) FILTER (WHERE wp.position < $4 AND r.status = 'waitlisted'),
+			COUNT(*) FILTER (WHERE wp.position < $4 AND r.status IS DISTINCT FROM 'waitlisted'),
+			COUNT(*) FILTER (WHERE r.status = 'waitlisted')
+		FROM waitlist_positions wp
+		LEFT JOIN registrations r ON r.parent_type = wp.parent_type AND r.parent_id = wp.parent_id
+			AND r.session_id IS NOT DISTINCT FROM wp.session_id AND r.participant_id = wp.participant_id
+		WHERE wp.parent_type = $1 AND wp.parent_id = $2 AND wp.session_id IS NOT DISTINCT FROM $3
+	`, parentType, parentID, sessionID, position).Scan(
+		&standing.AheadWaitlisted, &standing.AheadInactive, &standing.TotalWaitlisted,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count waitlist entries ahead: %w", err)
+	}
+	standing.EffectivePosition = standing.AheadWaitlisted + 1
+
+	return standing, nil
+}
+
+// SetWaitlistOptIn sets whether a waitlisted registration wants to be promoted when a
+// spot opens. It reports false if the registration is not on a waitlist.
+func (db *DB) SetWaitlistOptIn(registrationID uuid.UUID, optIn bool) (bool, error) {
+	result, err := db.Exec(`
+		UPDATE waitlist_positions wp
+		SET notify_opt_in = $2
+		FROM registrations r
+		WHERE r.id = $1 AND r.status = 'waitlisted'
+			AND wp.parent_type = r.parent_type AND wp.parent_id = r.parent_id
+			AND wp.session_id IS NOT DISTINCT FROM r.session_id AND wp.participant_id = r.participant_id
+	`, registrationID, optIn)
+	if err != nil {
+		return false, fmt.Errorf("failed to update waitlist preferences: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// SessionWaitlistEntry is a registration waiting on a session's waitlist
+type SessionWaitlistEntry struct {
+	Position        int       `json:"position"`     // live position, counting only registrations still waitlisted
+	RawPosition     int       `json:"raw_position"` // stored position, which may have gaps
+	RegistrationID  uuid.UUID `json:"registration_id"`
+	ParticipantID   uuid.UUID `json:"participant_id"`
+	ParticipantName string    `json:"participant_name"`
+	GuardianEmail   *string   `json:"guardian_email,omitempty"`
+	NotifyOptIn     bool      `json:"notify_opt_in"`
+	WaitlistedAt    time.Time `json:"waitlisted_at"`
+}
+
+// GetSessionWaitlist retrieves the registrations waiting on a session's waitlist, in the
+// order they will be promoted
+func (db *DB) GetSessionWaitlist(sessionID uuid.UUID) ([]SessionWaitlistEntry, error) {
+	rows, err := db.Query(`
+		SELECT
+			ROW_NUMBER() OVER (ORDER BY wp.position ASC), wp.position,
+			r.id, p.id, p.first_name || ' ' || p.last_name, u.email,
+			wp.notify_opt_in, wp.created_at
+		FROM waitlist_positions wp
+		JOIN sessions s ON s.id = wp.session_id
+		JOIN registrations r ON r.parent_type = wp.parent_type AND r.parent_id = wp.parent_id
+			AND r.session_id IS NOT DISTINCT FROM wp.session_id AND r.participant_id = wp.participant_id
+			AND r.status = 'waitlisted'
+		JOIN participants p ON p.id = wp.participant_id
+		LEFT JOIN households h ON h.id = p.household_id
+		LEFT JOIN users u ON u.id = h.owner_user_id
+		WHERE wp.session_id = $1 AND wp.parent_type = s.parent_type AND wp.parent_id = s.parent_id
+		ORDER BY wp.position ASC
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session waitlist: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []SessionWaitlistEntry{}
+	for rows.Next() {
+		var e SessionWaitlistEntry
+		err := rows.Scan(
+			&e.Position, &e.RawPosition,
+			&e.RegistrationID, &e.ParticipantID, &e.ParticipantName, &e.GuardianEmail,
+			&e.NotifyOptIn, &e.WaitlistedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan waitlist entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get session waitlist: %w", err)
+	}
+	return entries, nil
+}
+
+// WasPromotedFromWaitlist reports whether a registration is confirmed after having moved
+// off the waitlist
+func (db *DB) WasPromotedFromWaitlist(registrationID uuid.UUID) (bool, error) {
+	var promoted bool
+	err := db.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1
+			FROM registrations r
+			JOIN registration_status_history h ON h.registration_id = r.id
+			WHERE r.id = $1 AND r.status IN ('confirmed', 'offered')
+				AND h.old_status = 'waitlisted' AND h.new_status IN ('confirmed', 'offered')
+		)
+	`, registrationID).Scan(&promoted)
+	if err != nil {
+		return false, fmt.Errorf("failed to check waitlist promotion: %w", err)
+	}
+	return promoted, nil
+}
+
+// EventCheckIn is the outcome of scanning a registration in at an event
+type EventCheckIn struct {
+	RegistrationID   uuid.UUID `json:"registration_id"`
+	ParticipantName  string    `json:"participant_name"`
+	Status           string    `json:"status"`
+	CheckedInAt      time.Time `json:"checked_in_at"`
+	AlreadyCheckedIn bool      `json:"already_checked_in"`
+}
+
+// CheckInEventRegistration marks a confirmed event registration as attended. Returns nil if
+// the registration does not belong to the event. A registration that was already checked in
+// is left unchanged and reported with AlreadyCheckedIn and the original check-in time.
+func (db *DB) CheckInEventRegistration(eventID, registrationID, checkedInBy uuid.UUID) (*EventCheckIn, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &EventCheckIn{RegistrationID: registrationID}
+	var checkedInAt *time.Time
+	err = tx.QueryRow(`
+		SELECT r.status, r.checked_in_at, p.first_name || ' ' || p.last_name
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		WHERE r.id = $1 AND r.parent_type = 'event' AND r.parent_id = $2
+		FOR UPDATE OF r
+	`, registrationID, eventID).Scan(&result.Status, &checkedInAt, &result.ParticipantName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registration: %w", err)
+	}
+
+	if checkedInAt != nil {
+		result.CheckedInAt = *checkedInAt
+		result.AlreadyCheckedIn = true
+		return result, nil
+	}
+	if result.Status != "confirmed" {
+		return result, nil
+	}
+
+	err = tx.QueryRow(`
+		UPDATE registrations
+		SET checked_in_at = now(), checked_in_by = $2
+		WHERE id = $1
+		RETURNING checked_in_at
+	`, registrationID, checkedInBy).Scan(&result.CheckedInAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check in registration: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return result, nil
+}
+
 // GetUserRegistrations retrieves all registrations for a user's participants
 func (db *DB) GetUserRegistrations(userID uuid.UUID) ([]Registration, error) {
 	rows, err := db.Query(`
@@ -345,7 +1509,8 @@ func (db *DB) GetUserRegistrations(userID uuid.UUID) ([]Registration, error) {
 		FROM registrations r
 		JOIN participants p ON p.id = r.participant_id
 		JOIN households h ON h.id = p.household_id
-		WHERE h.owner_user_id = $1 AND r.status != 'cancelled'
+		WHERE (h.owner_user_id = $1 OR h.id IN (SELECT household_id FROM household_members WHERE user_id = $1))
+			AND h.deleted_at IS NULL AND r.status != 'cancelled'
 		ORDER BY r.created_at DESC
 	`, userID)
 	if err != nil {
@@ -367,3 +1532,88 @@ func (db *DB) GetUserRegistrations(userID uuid.UUID) ([]Registration, error) {
 
 	return registrations, nil
 }
+
+// GetUserRegistrationDetails retrieves registrations for a user's participants with the
+// participant's name, the program or event, the session and the waitlist position filled
+// in. status limits the result to one status; cancelled registrations are left out unless
+// includeCancelled is set or status asks for them.
+func (db *DB) GetUserRegistrationDetails(userID uuid.UUID, status string, includeCancelled bool) ([]Registration, error) {
+	return db.registrationDetails(`
+		(h.owner_user_id = $1 OR h.id IN (SELECT household_id FROM household_members WHERE user_id = $1))
+			AND h.deleted_at IS NULL
+			AND ($2 = '' OR r.status::text = $2)
+			AND ($3 OR $2 = 'cancelled' OR r.status != 'cancelled')
+	`, userID, status, includeCancelled)
+}
+
+// GetParticipantRegistrationDetails retrieves all of a participant's registrations, with
+// the same details as GetUserRegistrationDetails, newest first
+func (db *DB) GetParticipantRegistrationDetails(participantID uuid.UUID) ([]Registration, error) {
+	return db.registrationDetails(`r.participant_id = $1`, participantID)
+}
+
+// registrationDetails retrieves the registrations matching condition with their details
+// filled in, newest first
+func (db *DB) registrationDetails(condition string, args ...interface{}) ([]Registration, error) {
+	rows, err := db.Query(`
+		SELECT
+			r.id, r.parent_type, r.parent_id, r.session_id, r.participant_id, r.status, r.created_at,
+			r.offer_expires_at, p.first_name, p.last_name,
+			COALESCE(prog.title, ev.title), COALESCE(prog.slug, ev.slug), COALESCE(prog.location, ev.location),
+			prog.start_date, prog.end_date, ev.starts_at, ev.ends_at,
+			s.starts_at, s.ends_at,
+			wp.position
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		JOIN households h ON h.id = p.household_id
+		LEFT JOIN programs prog ON r.parent_type = 'program' AND prog.id = r.parent_id
+		LEFT JOIN events ev ON r.parent_type = 'event' AND ev.id = r.parent_id
+		LEFT JOIN sessions s ON s.id = r.session_id
+		LEFT JOIN waitlist_positions wp ON r.status = 'waitlisted'
+			AND wp.parent_type = r.parent_type AND wp.parent_id = r.parent_id
+			AND wp.session_id IS NOT DISTINCT FROM r.session_id AND wp.participant_id = r.participant_id
+		WHERE `+condition+`
+		ORDER BY r.created_at DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registrations: %w", err)
+	}
+	defer rows.Close()
+
+	registrations := []Registration{}
+	for rows.Next() {
+		var r Registration
+		var participant Participant
+		var title, slug string
+		var location *string
+		var programStart, programEnd, eventStart, eventEnd, sessionStart, sessionEnd *time.Time
+		err := rows.Scan(
+			&r.ID, &r.ParentType, &r.ParentID, &r.SessionID, &r.ParticipantID, &r.Status, &r.CreatedAt,
+			&r.OfferExpiresAt, &participant.FirstName, &participant.LastName,
+			&title, &slug, &location,
+			&programStart, &programEnd, &eventStart, &eventEnd,
+			&sessionStart, &sessionEnd,
+			&r.WaitlistPosition,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan registration: %w", err)
+		}
+
+		participant.ID = r.ParticipantID
+		r.Participant = &participant
+		if r.ParentType == "program" {
+			r.ProgramInfo = &Program{ID: r.ParentID, Slug: slug, Title: title, Location: location, StartDate: programStart, EndDate: programEnd}
+		} else {
+			r.EventInfo = &Event{ID: r.ParentID, Slug: slug, Title: title, Location: location, StartsAt: eventStart, EndsAt: eventEnd}
+		}
+		if r.SessionID != nil {
+			r.SessionInfo = &Session{ID: *r.SessionID, ParentType: r.ParentType, ParentID: r.ParentID, StartsAt: sessionStart, EndsAt: sessionEnd}
+		}
+		registrations = append(registrations, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get registrations: %w", err)
+	}
+
+	return registrations, nil
+}
@@ -1,113 +1,1238 @@
 package db
 
 import (
+	"errors"
+	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+// Database tests run against the Postgres instance in TEST_DATABASE_URL and are
+// skipped when it is not set. Every test creates its own programs and participants
+// and removes them afterwards, so a shared database can be reused between runs.
+
 // TestCapacityEnforcement tests that registrations respect capacity limits
 func TestCapacityEnforcement(t *testing.T) {
-	// This is a demonstration test structure
-	// In a real implementation, you would:
-	// 1. Set up a test database
-	// 2. Create test programs/events with known capacity
-	// 3. Attempt registrations up to and beyond capacity
-	// 4. Verify that excess registrations are waitlisted
-
 	t.Run("should confirm registration when capacity available", func(t *testing.T) {
-		// Setup: Create program with capacity 5, 2 existing registrations
-		// Action: Register new participant
-		// Assert: Status should be 'confirmed', no waitlist position
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		registerTestParticipants(t, db, programID, nil, 2)
+
+		result := registerTestParticipant(t, db, programID, nil)
+		if result.Registration.Status != "confirmed" {
+			t.Errorf("status = %q, want confirmed", result.Registration.Status)
+		}
+		if result.IsWaitlisted || result.Position != nil {
+			t.Errorf("expected no waitlist position, got waitlisted=%v position=%v", result.IsWaitlisted, result.Position)
+		}
 	})
 
 	t.Run("should waitlist registration when at capacity", func(t *testing.T) {
-		// Setup: Create program with capacity 5, 5 existing confirmed registrations
-		// Action: Register new participant
-		// Assert: Status should be 'waitlisted', position should be 1
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		registerTestParticipants(t, db, programID, nil, 5)
+
+		result := registerTestParticipant(t, db, programID, nil)
+		if result.Registration.Status != "waitlisted" {
+			t.Errorf("status = %q, want waitlisted", result.Registration.Status)
+		}
+		if result.Position == nil || *result.Position != 1 {
+			t.Errorf("position = %v, want 1", result.Position)
+		}
 	})
 
 	t.Run("should maintain waitlist order", func(t *testing.T) {
-		// Setup: Create program at capacity with 2 on waitlist
-		// Action: Register new participant
-		// Assert: Position should be 3
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		registerTestParticipants(t, db, programID, nil, 7)
+
+		result := registerTestParticipant(t, db, programID, nil)
+		if result.Position == nil || *result.Position != 3 {
+			t.Errorf("position = %v, want 3", result.Position)
+		}
 	})
 }
 
 // TestWaitlistPromotion tests that cancellations promote from waitlist
 func TestWaitlistPromotion(t *testing.T) {
 	t.Run("should promote first waitlist participant on cancellation", func(t *testing.T) {
-		// Setup: Program at capacity (5/5) with 2 on waitlist
-		// Action: Cancel one confirmed registration
-		// Assert:
-		//   - First waitlist participant should be promoted to confirmed
-		//   - Waitlist position should be deleted
-		//   - Notification should be queued
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		confirmed := registerTestParticipants(t, db, programID, nil, 5)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		first := waitlisted[0].Registration
+		if status := registrationStatus(t, db, first.ID); status != "confirmed" {
+			t.Errorf("first waitlisted status = %q, want confirmed", status)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE participant_id = $1`, first.ParticipantID); n != 0 {
+			t.Errorf("first waitlisted still has %d waitlist positions, want 0", n)
+		}
+		if n := countNotifications(t, db, "WAITLIST_PROMOTED", first.ParticipantID); n != 1 {
+			t.Errorf("WAITLIST_PROMOTED notifications = %d, want 1", n)
+		}
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "waitlisted" {
+			t.Errorf("second waitlisted status = %q, want waitlisted", status)
+		}
+
+		if promoted, err := db.WasPromotedFromWaitlist(first.ID); err != nil || !promoted {
+			t.Errorf("WasPromotedFromWaitlist(promoted) = %v, %v; want true", promoted, err)
+		}
+		if promoted, err := db.WasPromotedFromWaitlist(confirmed[1].Registration.ID); err != nil || promoted {
+			t.Errorf("WasPromotedFromWaitlist(confirmed directly) = %v, %v; want false", promoted, err)
+		}
+		standing, err := db.GetWaitlistStanding(waitlisted[1].Registration.ID)
+		if err != nil || standing == nil {
+			t.Fatalf("GetWaitlistStanding: %v, %v", standing, err)
+		}
+		if standing.EffectivePosition != 1 || standing.TotalWaitlisted != 1 {
+			t.Errorf("second waitlisted position = %d of %d, want 1 of 1", standing.EffectivePosition, standing.TotalWaitlisted)
+		}
 	})
 
 	t.Run("should not promote if no waitlist", func(t *testing.T) {
-		// Setup: Program with capacity (3/5), no waitlist
-		// Action: Cancel one confirmed registration
-		// Assert: Capacity should be 2/5, no promotions
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		confirmed := registerTestParticipants(t, db, programID, nil, 3)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if n := countConfirmed(t, db, programID, nil); n != 2 {
+			t.Errorf("confirmed = %d, want 2", n)
+		}
 	})
 
 	t.Run("should promote multiple participants in order", func(t *testing.T) {
-		// Setup: Program at capacity with 3 on waitlist
-		// Action: Cancel 2 confirmed registrations
-		// Assert: First 2 waitlist participants should be promoted in order
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 3)
+		confirmed := registerTestParticipants(t, db, programID, nil, 3)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 3)
+
+		cancelTestRegistration(t, db, confirmed[0])
+		cancelTestRegistration(t, db, confirmed[1])
+
+		want := []string{"confirmed", "confirmed", "waitlisted"}
+		for i, w := range waitlisted {
+			if status := registrationStatus(t, db, w.Registration.ID); status != want[i] {
+				t.Errorf("waitlisted[%d] status = %q, want %q", i, status, want[i])
+			}
+		}
+		if n := countConfirmed(t, db, programID, nil); n != 3 {
+			t.Errorf("confirmed = %d, want 3", n)
+		}
 	})
-}
 
-// TestConcurrentRegistrations tests race condition handling
-func TestConcurrentRegistrations(t *testing.T) {
-	t.Run("should handle concurrent registrations at capacity limit", func(t *testing.T) {
-		// This test would verify that with distributed locking (Redis):
-		// Setup: Program with 1 spot left
-		// Action: Simulate 10 concurrent registration requests
-		// Assert: Exactly 1 should be confirmed, 9 should be waitlisted
-		// This ensures no over-booking occurs
+	t.Run("should promote within the cancelled session only", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		sessionA := createTestSession(t, db, programID, nil)
+		sessionB := createTestSession(t, db, programID, nil)
+		confirmedA := registerTestParticipants(t, db, programID, &sessionA, 1)
+		waitlistedA := registerTestParticipants(t, db, programID, &sessionA, 1)
+		registerTestParticipants(t, db, programID, &sessionB, 1)
+		waitlistedB := registerTestParticipants(t, db, programID, &sessionB, 1)
+
+		cancelTestRegistration(t, db, confirmedA[0])
+
+		if status := registrationStatus(t, db, waitlistedA[0].Registration.ID); status != "confirmed" {
+			t.Errorf("session A waitlisted status = %q, want confirmed", status)
+		}
+		if status := registrationStatus(t, db, waitlistedB[0].Registration.ID); status != "waitlisted" {
+			t.Errorf("session B waitlisted status = %q, want waitlisted", status)
+		}
+	})
+
+	t.Run("should not revive a cancelled waitlisted registration", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		cancelTestRegistration(t, db, waitlisted[0])
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE participant_id = $1`, waitlisted[0].Registration.ParticipantID); n != 0 {
+			t.Errorf("cancelled waitlisted still has %d waitlist positions, want 0", n)
+		}
+
+		// A stale entry left from before cancellations removed them is skipped too
+		_, err := db.Exec(`
+			INSERT INTO waitlist_positions (parent_type, parent_id, session_id, participant_id, position)
+			VALUES ('program', $1, NULL, $2, 0)
+		`, programID, waitlisted[0].Registration.ParticipantID)
+		if err != nil {
+			t.Fatalf("failed to create stale waitlist position: %v", err)
+		}
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if status := registrationStatus(t, db, waitlisted[0].Registration.ID); status != "cancelled" {
+			t.Errorf("cancelled waitlisted status = %q, want cancelled", status)
+		}
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "confirmed" {
+			t.Errorf("next waitlisted status = %q, want confirmed", status)
+		}
+	})
+
+	t.Run("should fill every seat one cancellation frees and notify them as a batch", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 2)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		if _, err := db.Exec(`UPDATE registrations SET seats = 2 WHERE id = $1`, confirmed[0].Registration.ID); err != nil {
+			t.Fatalf("failed to set seats: %v", err)
+		}
+		waitlisted := registerTestParticipants(t, db, programID, nil, 3)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		want := []string{"confirmed", "confirmed", "waitlisted"}
+		for i, w := range waitlisted {
+			if status := registrationStatus(t, db, w.Registration.ID); status != want[i] {
+				t.Errorf("waitlisted[%d] status = %q, want %q", i, status, want[i])
+			}
+		}
+
+		var batches, withRegistration int
+		err := db.QueryRow(`
+			SELECT COUNT(DISTINCT payload->>'batch_id'), COUNT(*) FILTER (WHERE payload->>'registration_id' IS NOT NULL)
+			FROM notification_queue
+			WHERE type = 'WAITLIST_PROMOTED' AND payload->>'participant_id' IN ($1, $2)
+		`, waitlisted[0].Registration.ParticipantID.String(), waitlisted[1].Registration.ParticipantID.String()).Scan(&batches, &withRegistration)
+		if err != nil {
+			t.Fatalf("failed to count promotion notifications: %v", err)
+		}
+		if batches != 1 || withRegistration != 2 {
+			t.Errorf("promotion notifications in %d batches, %d naming the registration; want 1 batch of 2", batches, withRegistration)
+		}
 	})
+
+	t.Run("should pass over a waitlisted participant who opted out", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		if updated, err := db.SetWaitlistOptIn(waitlisted[0].Registration.ID, false); err != nil || !updated {
+			t.Fatalf("SetWaitlistOptIn = %v, %v; want true", updated, err)
+		}
+		if updated, err := db.SetWaitlistOptIn(confirmed[0].Registration.ID, false); err != nil || updated {
+			t.Errorf("SetWaitlistOptIn(confirmed) = %v, %v; want false", updated, err)
+		}
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "confirmed" {
+			t.Errorf("second waitlisted status = %q, want confirmed", status)
+		}
+		if status := registrationStatus(t, db, waitlisted[0].Registration.ID); status != "waitlisted" {
+			t.Errorf("opted-out status = %q, want waitlisted", status)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE participant_id = $1`, waitlisted[0].Registration.ParticipantID); n != 1 {
+			t.Errorf("opted-out has %d waitlist positions, want to keep 1", n)
+		}
+		if n := countNotifications(t, db, "WAITLIST_PROMOTED", waitlisted[0].Registration.ParticipantID); n != 0 {
+			t.Errorf("opted-out WAITLIST_PROMOTED notifications = %d, want 0", n)
+		}
+	})
+
+	t.Run("should remove an opted-out participant when configured to", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.removeOptedOut = true
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		if _, err := db.SetWaitlistOptIn(waitlisted[0].Registration.ID, false); err != nil {
+			t.Fatalf("SetWaitlistOptIn: %v", err)
+		}
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "confirmed" {
+			t.Errorf("second waitlisted status = %q, want confirmed", status)
+		}
+		if status := registrationStatus(t, db, waitlisted[0].Registration.ID); status != "cancelled" {
+			t.Errorf("opted-out status = %q, want cancelled", status)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE participant_id = $1`, waitlisted[0].Registration.ParticipantID); n != 0 {
+			t.Errorf("opted-out still has %d waitlist positions, want 0", n)
+		}
+		if n := countRows(t, db, `
+			SELECT COUNT(*) FROM notification_queue
+			WHERE type = 'WAITLIST_REMOVED' AND payload->>'participant_id' = $1 AND payload->>'reason' <> ''
+		`, waitlisted[0].Registration.ParticipantID.String()); n != 1 {
+			t.Errorf("opted-out WAITLIST_REMOVED notifications with a reason = %d, want 1", n)
+		}
+	})
+}
+
+// TestFillFromWaitlist tests raising a program's capacity promotes into the new spots
+func TestFillFromWaitlist(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	registerTestParticipants(t, db, programID, nil, 1)
+	waitlisted := registerTestParticipants(t, db, programID, nil, 3)
+
+	if _, err := db.Exec(`UPDATE programs SET capacity = 3 WHERE id = $1`, programID); err != nil {
+		t.Fatalf("failed to raise capacity: %v", err)
+	}
+
+	promoted, err := db.FillFromWaitlist("program", programID)
+	if err != nil {
+		t.Fatalf("FillFromWaitlist: %v", err)
+	}
+	if len(promoted) != 2 || promoted[0].RegistrationID != waitlisted[0].Registration.ID || promoted[1].RegistrationID != waitlisted[1].Registration.ID {
+		t.Fatalf("promoted = %+v, want the first two waitlisted", promoted)
+	}
+	if status := registrationStatus(t, db, waitlisted[2].Registration.ID); status != "waitlisted" {
+		t.Errorf("third waitlisted status = %q, want waitlisted", status)
+	}
+	for _, p := range promoted {
+		if n := countNotifications(t, db, "WAITLIST_PROMOTED", p.ParticipantID); n != 1 {
+			t.Errorf("WAITLIST_PROMOTED notifications = %d, want 1", n)
+		}
+	}
+
+	// Filling again changes nothing
+	if promoted, err := db.FillFromWaitlist("program", programID); err != nil || len(promoted) != 0 {
+		t.Errorf("second FillFromWaitlist = %v, %v; want nothing promoted", promoted, err)
+	}
 }
 
+// TestGetSessionWaitlist tests a session's waitlist lists only its own live entries in
+// promotion order
+func TestGetSessionWaitlist(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	sessionA := createTestSession(t, db, programID, nil)
+	sessionB := createTestSession(t, db, programID, nil)
+	registerTestParticipants(t, db, programID, &sessionA, 1)
+	waitlistedA := registerTestParticipants(t, db, programID, &sessionA, 3)
+	registerTestParticipants(t, db, programID, &sessionB, 2)
+
+	cancelTestRegistration(t, db, waitlistedA[1])
+
+	waitlist, err := db.GetSessionWaitlist(sessionA)
+	if err != nil {
+		t.Fatalf("GetSessionWaitlist: %v", err)
+	}
+	want := []*RegistrationResult{waitlistedA[0], waitlistedA[2]}
+	if len(waitlist) != len(want) {
+		t.Fatalf("waitlist = %+v, want %d entries", waitlist, len(want))
+	}
+	for i, w := range want {
+		if waitlist[i].RegistrationID != w.Registration.ID || waitlist[i].Position != i+1 {
+			t.Errorf("waitlist[%d] = %s at %d, want %s at %d", i, waitlist[i].RegistrationID, waitlist[i].Position, w.Registration.ID, i+1)
+		}
+	}
+	if waitlist[1].RawPosition != 3 {
+		t.Errorf("last raw position = %d, want 3", waitlist[1].RawPosition)
+	}
+}
+
+// Concurrent registrations rely on the Redis capacity lock and are tested in
+// internal/core (TestConcurrentRegistrations).
+
 // TestSessionCapacityOverride tests session-specific capacity
 func TestSessionCapacityOverride(t *testing.T) {
 	t.Run("should use session capacity override when set", func(t *testing.T) {
-		// Setup: Program with capacity 10, session with override capacity 5
-		// Action: Register 6 participants for this session
-		// Assert: 5 confirmed, 1 waitlisted (using session capacity, not program)
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 10)
+		override := 5
+		sessionID := createTestSession(t, db, programID, &override)
+
+		results := registerTestParticipants(t, db, programID, &sessionID, 6)
+
+		if n := countConfirmed(t, db, programID, &sessionID); n != 5 {
+			t.Errorf("confirmed = %d, want 5", n)
+		}
+		if last := results[5]; !last.IsWaitlisted {
+			t.Errorf("6th registration status = %q, want waitlisted", last.Registration.Status)
+		}
 	})
 
 	t.Run("should use program capacity when no override", func(t *testing.T) {
-		// Setup: Program with capacity 10, session with no override
-		// Action: Register participants
-		// Assert: Should use program capacity of 10
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 10)
+		sessionID := createTestSession(t, db, programID, nil)
+
+		results := registerTestParticipants(t, db, programID, &sessionID, 11)
+
+		if n := countConfirmed(t, db, programID, &sessionID); n != 10 {
+			t.Errorf("confirmed = %d, want 10", n)
+		}
+		if last := results[10]; !last.IsWaitlisted {
+			t.Errorf("11th registration status = %q, want waitlisted", last.Registration.Status)
+		}
+	})
+}
+
+// TestCapacityCountsSeats tests that capacity is taken by seats rather than registration rows
+func TestCapacityCountsSeats(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 4)
+
+	// A group registration takes three of the four seats
+	group := registerTestParticipant(t, db, programID, nil)
+	if _, err := db.Exec(`UPDATE registrations SET seats = 3 WHERE id = $1`, group.Registration.ID); err != nil {
+		t.Fatalf("failed to set seats: %v", err)
+	}
+
+	program, err := db.GetProgramByID(programID)
+	if err != nil || program == nil {
+		t.Fatalf("GetProgramByID: %v, %v", program, err)
+	}
+	bySlug, err := db.GetProgramBySlug(program.Slug)
+	if err != nil || bySlug == nil || bySlug.SpotsLeft == nil {
+		t.Fatalf("GetProgramBySlug: %v, %v", bySlug, err)
+	}
+	if *bySlug.SpotsLeft != 1 {
+		t.Errorf("spots left = %d, want 1", *bySlug.SpotsLeft)
+	}
+
+	results := registerTestParticipants(t, db, programID, nil, 2)
+	if results[0].IsWaitlisted {
+		t.Error("registration for the last seat was waitlisted, want confirmed")
+	}
+	if !results[1].IsWaitlisted {
+		t.Errorf("registration past the last seat status = %q, want waitlisted", results[1].Registration.Status)
+	}
+
+	report, err := db.ReconcileProgram(programID)
+	if err != nil {
+		t.Fatalf("ReconcileProgram: %v", err)
+	}
+	if len(report.Scopes) != 1 || report.Scopes[0].ConfirmedSeats != 4 || report.HasDiscrepancies {
+		t.Errorf("reconciliation = %+v, want 4 confirmed seats and no discrepancies", report.Scopes)
+	}
+}
+
+// TestGroupRegistrationNeedsAllSeats tests a registration taking several seats is only
+// confirmed when all of them are free, and waits at the head of the waitlist until they
+// are rather than being passed over
+func TestGroupRegistrationNeedsAllSeats(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 3)
+	first := registerTestParticipants(t, db, programID, nil, 2)
+
+	// One seat is left, so a group of three is waitlisted
+	group, err := db.CreateRegistration(RegistrationRequest{
+		ParentType:    "program",
+		ParentID:      programID,
+		ParticipantID: createTestParticipant(t, db),
+		Seats:         3,
+	})
+	if err != nil {
+		t.Fatalf("CreateRegistration: %v", err)
+	}
+	if !group.IsWaitlisted {
+		t.Fatalf("group of three with one seat left status = %q, want waitlisted", group.Registration.Status)
+	}
+	behind := registerTestParticipant(t, db, programID, nil)
+	if behind.IsWaitlisted {
+		t.Errorf("single registration for the last seat was waitlisted, want confirmed")
+	}
+
+	// Freeing one seat is not enough for the group, and nobody behind it jumps ahead
+	last := registerTestParticipant(t, db, programID, nil)
+	cancelTestRegistration(t, db, first[0])
+	if status := registrationStatus(t, db, group.Registration.ID); status != "waitlisted" {
+		t.Errorf("group status after one seat freed = %q, want waitlisted", status)
+	}
+	if status := registrationStatus(t, db, last.Registration.ID); status != "waitlisted" {
+		t.Errorf("registration behind the group status = %q, want waitlisted", status)
+	}
+
+	// With all three seats free the group is promoted
+	cancelTestRegistration(t, db, first[1])
+	cancelTestRegistration(t, db, behind)
+	if status := registrationStatus(t, db, group.Registration.ID); status != "confirmed" {
+		t.Errorf("group status after three seats freed = %q, want confirmed", status)
+	}
+	if n := countConfirmed(t, db, programID, nil); n != 1 {
+		t.Errorf("confirmed registrations = %d, want the group alone", n)
+	}
+}
+
+// TestTransferRegistration tests moving a registration between sessions of a program
+func TestTransferRegistration(t *testing.T) {
+	t.Run("should move the registration and promote from the old session's waitlist", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		from := createTestSession(t, db, programID, nil)
+		to := createTestSession(t, db, programID, nil)
+
+		moving := registerTestParticipant(t, db, programID, &from)
+		waiting := registerTestParticipant(t, db, programID, &from)
+
+		reg := moving.Registration
+		transfer, err := db.TransferRegistration(reg.ID, reg.ParticipantID, to, nil, false)
+		if err != nil {
+			t.Fatalf("TransferRegistration: %v", err)
+		}
+		if transfer.NeedsWaitlistConfirmation || transfer.Result.IsWaitlisted {
+			t.Fatalf("transfer = %+v, want confirmed in the target session", transfer)
+		}
+		if got := transfer.Result.Registration; *got.SessionID != to || got.Status != "confirmed" {
+			t.Errorf("new registration = %+v, want confirmed in session %s", got, to)
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "cancelled" {
+			t.Errorf("old registration status = %q, want cancelled", status)
+		}
+		if status := registrationStatus(t, db, waiting.Registration.ID); status != "confirmed" {
+			t.Errorf("waitlisted registration status = %q, want confirmed", status)
+		}
+	})
+
+	t.Run("should ask for confirmation before waitlisting", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		from := createTestSession(t, db, programID, nil)
+		to := createTestSession(t, db, programID, nil)
+
+		registerTestParticipant(t, db, programID, &to)
+		moving := registerTestParticipant(t, db, programID, &from)
+		reg := moving.Registration
+
+		transfer, err := db.TransferRegistration(reg.ID, reg.ParticipantID, to, nil, false)
+		if err != nil {
+			t.Fatalf("TransferRegistration: %v", err)
+		}
+		if !transfer.NeedsWaitlistConfirmation || transfer.WaitlistPosition == nil || *transfer.WaitlistPosition != 1 {
+			t.Fatalf("transfer = %+v, want confirmation needed for position 1", transfer)
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "confirmed" {
+			t.Errorf("old registration status = %q, want it left confirmed", status)
+		}
+
+		transfer, err = db.TransferRegistration(reg.ID, reg.ParticipantID, to, nil, true)
+		if err != nil {
+			t.Fatalf("TransferRegistration: %v", err)
+		}
+		if !transfer.Result.IsWaitlisted || transfer.Result.Position == nil || *transfer.Result.Position != 1 {
+			t.Errorf("transfer result = %+v, want waitlisted at position 1", transfer.Result)
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "cancelled" {
+			t.Errorf("old registration status = %q, want cancelled", status)
+		}
+	})
+
+	t.Run("should refuse a session of another program", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		from := createTestSession(t, db, programID, nil)
+		other := createTestSession(t, db, createTestProgram(t, db, 1), nil)
+
+		reg := registerTestParticipant(t, db, programID, &from).Registration
+		if _, err := db.TransferRegistration(reg.ID, reg.ParticipantID, other, nil, false); err == nil {
+			t.Error("transfer to another program's session succeeded, want error")
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "confirmed" {
+			t.Errorf("old registration status = %q, want confirmed", status)
+		}
+	})
+}
+
+// TestPauseRegistration tests that a paused registration keeps its spot until it is
+// resumed or cancelled
+func TestPauseRegistration(t *testing.T) {
+	t.Run("should hold the spot while paused", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		sessionID := createTestSession(t, db, programID, nil)
+
+		reg := registerTestParticipant(t, db, programID, &sessionID).Registration
+		resumeOn := time.Now().AddDate(0, 1, 0).Truncate(24 * time.Hour)
+		pause, err := db.PauseRegistration(reg.ID, reg.ParticipantID, nil, &resumeOn, nil)
+		if err != nil {
+			t.Fatalf("PauseRegistration: %v", err)
+		}
+		if pause.EndedAt != nil || pause.ResumeOn == nil {
+			t.Errorf("pause = %+v, want open with a resume date", pause)
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "paused" {
+			t.Errorf("status = %q, want paused", status)
+		}
+
+		if next := registerTestParticipant(t, db, programID, &sessionID); !next.IsWaitlisted {
+			t.Error("registration while the only spot is paused was confirmed, want waitlisted")
+		}
+		if _, err := db.PauseRegistration(reg.ID, reg.ParticipantID, nil, nil, nil); err == nil {
+			t.Error("pausing a paused registration succeeded, want error")
+		}
+
+		pause, err = db.ResumeRegistration(reg.ID, reg.ParticipantID, nil)
+		if err != nil {
+			t.Fatalf("ResumeRegistration: %v", err)
+		}
+		if pause == nil || pause.EndedAt == nil {
+			t.Errorf("resumed pause = %+v, want it ended", pause)
+		}
+		if status := registrationStatus(t, db, reg.ID); status != "confirmed" {
+			t.Errorf("status after resume = %q, want confirmed", status)
+		}
+	})
+
+	t.Run("should promote from the waitlist when a paused registration is cancelled", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		sessionID := createTestSession(t, db, programID, nil)
+
+		paused := registerTestParticipant(t, db, programID, &sessionID)
+		waiting := registerTestParticipant(t, db, programID, &sessionID)
+		reg := paused.Registration
+		if _, err := db.PauseRegistration(reg.ID, reg.ParticipantID, nil, nil, nil); err != nil {
+			t.Fatalf("PauseRegistration: %v", err)
+		}
+
+		cancelTestRegistration(t, db, paused)
+		if status := registrationStatus(t, db, waiting.Registration.ID); status != "confirmed" {
+			t.Errorf("waitlisted registration status = %q, want confirmed", status)
+		}
+		if open := countRows(t, db, `SELECT COUNT(*) FROM registration_pauses WHERE registration_id = $1 AND ended_at IS NULL`, reg.ID); open != 0 {
+			t.Errorf("open pauses after cancel = %d, want 0", open)
+		}
+	})
+
+	t.Run("should only pause confirmed registrations", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+
+		registerTestParticipant(t, db, programID, nil)
+		reg := registerTestParticipant(t, db, programID, nil).Registration
+		if _, err := db.PauseRegistration(reg.ID, reg.ParticipantID, nil, nil, nil); err == nil {
+			t.Error("pausing a waitlisted registration succeeded, want error")
+		}
+		if _, err := db.ResumeRegistration(reg.ID, reg.ParticipantID, nil); err == nil {
+			t.Error("resuming a registration that is not paused succeeded, want error")
+		}
+	})
+}
+
+// TestReconcileCapacity tests that lowering capacity moves the most recently confirmed
+// registrations to the top of the waitlist
+func TestReconcileCapacity(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 3)
+	results := registerTestParticipants(t, db, programID, nil, 4)
+
+	if _, err := db.Exec(`UPDATE programs SET capacity = 1 WHERE id = $1`, programID); err != nil {
+		t.Fatalf("failed to lower capacity: %v", err)
+	}
+
+	demoted, err := db.ReconcileCapacity(programID, nil)
+	if err != nil {
+		t.Fatalf("ReconcileCapacity: %v", err)
+	}
+	if len(demoted) != 2 {
+		t.Fatalf("demoted %d registrations, want 2", len(demoted))
+	}
+	if n := countConfirmed(t, db, programID, nil); n != 1 {
+		t.Errorf("confirmed = %d, want 1", n)
+	}
+
+	// The earlier of the two demoted goes first, ahead of who was already waiting
+	wantPositions := map[uuid.UUID]int{
+		results[1].Registration.ParticipantID: 1,
+		results[2].Registration.ParticipantID: 2,
+		results[3].Registration.ParticipantID: 3,
+	}
+	for participantID, want := range wantPositions {
+		var position int
+		err := db.QueryRow(`
+			SELECT position FROM waitlist_positions
+			WHERE parent_id = $1 AND session_id IS NULL AND participant_id = $2
+		`, programID, participantID).Scan(&position)
+		if err != nil {
+			t.Fatalf("failed to get waitlist position: %v", err)
+		}
+		if position != want {
+			t.Errorf("participant %s position = %d, want %d", participantID, position, want)
+		}
+	}
+	for _, r := range results[1:3] {
+		if status := registrationStatus(t, db, r.Registration.ID); status != "waitlisted" {
+			t.Errorf("registration status = %q, want waitlisted", status)
+		}
+		if n := countNotifications(t, db, "WAITLIST_DEMOTED", r.Registration.ParticipantID); n != 1 {
+			t.Errorf("WAITLIST_DEMOTED notifications = %d, want 1", n)
+		}
+	}
+
+	// Reconciling again changes nothing
+	demoted, err = db.ReconcileCapacity(programID, nil)
+	if err != nil || len(demoted) != 0 {
+		t.Errorf("second ReconcileCapacity = %v, %v, want nothing demoted", demoted, err)
+	}
+}
+
+// TestEffectiveCapacity tests overbooking capacity math
+func TestEffectiveCapacity(t *testing.T) {
+	tests := []struct {
+		capacity, overbookPct, want int
+	}{
+		{20, 0, 20},
+		{20, 10, 22},
+		{20, 12, 22}, // 22.4 floors to 22
+		{15, 10, 16}, // 16.5 floors to 16
+		{0, 50, 0},
+	}
+
+	for _, tt := range tests {
+		if got := EffectiveCapacity(tt.capacity, tt.overbookPct); got != tt.want {
+			t.Errorf("EffectiveCapacity(%d, %d) = %d, want %d", tt.capacity, tt.overbookPct, got, tt.want)
+		}
+	}
+}
+
+// TestAgeOn tests whole-year age calculation around birthdays
+func TestAgeOn(t *testing.T) {
+	dob := time.Date(2018, time.June, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		on   time.Time
+		want int
+	}{
+		{time.Date(2025, time.June, 14, 0, 0, 0, 0, time.UTC), 6},
+		{time.Date(2025, time.June, 15, 0, 0, 0, 0, time.UTC), 7},
+		{time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC), 6},
+		{time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC), 7},
+	}
+
+	for _, tt := range tests {
+		if got := AgeOn(dob, tt.on); got != tt.want {
+			t.Errorf("AgeOn(%s, %s) = %d, want %d", dob.Format("2006-01-02"), tt.on.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+// TestAgeOnLeapDay tests that a Feb 29 birthday is reached on Mar 1 in common years
+func TestAgeOnLeapDay(t *testing.T) {
+	dob := time.Date(2016, time.February, 29, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		on   time.Time
+		want int
+	}{
+		{time.Date(2025, time.February, 28, 0, 0, 0, 0, time.UTC), 8},
+		{time.Date(2025, time.March, 1, 0, 0, 0, 0, time.UTC), 9},
+		{time.Date(2024, time.February, 28, 0, 0, 0, 0, time.UTC), 7},
+		{time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC), 8},
+	}
+
+	for _, tt := range tests {
+		if got := AgeOn(dob, tt.on); got != tt.want {
+			t.Errorf("AgeOn(%s, %s) = %d, want %d", dob.Format("2006-01-02"), tt.on.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+// TestMeetsAgeRequirements tests the age range boundaries on the program start date
+func TestMeetsAgeRequirements(t *testing.T) {
+	now := time.Now()
+	start := time.Date(now.Year()+1, time.June, 15, 0, 0, 0, 0, time.UTC)
+	ageMin, ageMax := 5, 7
+	program := &Program{StartDate: &start, AgeMin: &ageMin, AgeMax: &ageMax}
+
+	tests := []struct {
+		name string
+		dob  time.Time
+		want bool
+	}{
+		{"turns min age on start date", start.AddDate(-5, 0, 0), true},
+		{"turns min age the day after start", start.AddDate(-5, 0, 1), false},
+		{"still max age on start date", start.AddDate(-8, 0, 1), true},
+		{"turns max age + 1 on start date", start.AddDate(-8, 0, 0), false},
+	}
+
+	for _, tt := range tests {
+		if got := MeetsAgeRequirements(program, tt.dob); got != tt.want {
+			t.Errorf("%s: MeetsAgeRequirements(dob %s) = %v, want %v", tt.name, tt.dob.Format("2006-01-02"), got, tt.want)
+		}
+	}
+}
+
+// TestProgramAgeReferenceDate tests ages are measured on the start date, even once started
+func TestProgramAgeReferenceDate(t *testing.T) {
+	started := time.Date(2020, time.September, 1, 0, 0, 0, 0, time.UTC)
+	if got := ProgramAgeReferenceDate(&Program{StartDate: &started}); !got.Equal(started) {
+		t.Errorf("reference date = %s, want start date %s", got, started)
+	}
+	if got := ProgramAgeReferenceDate(&Program{}); time.Since(got) > time.Minute {
+		t.Errorf("reference date without start date = %s, want today", got)
+	}
+}
+
+// TestNeedsEmergencyContact tests minors need an emergency contact phone as of the program
+// start date, and only when the program requires one
+func TestNeedsEmergencyContact(t *testing.T) {
+	start := time.Date(2030, time.June, 15, 0, 0, 0, 0, time.UTC)
+	program := &Program{StartDate: &start, MinorEmergencyContactRequired: true, MinorAgeThreshold: 18}
+	minor := start.AddDate(-18, 0, 1)
+	adult := start.AddDate(-18, 0, 0)
+	phone, blank := "555-0100", "  "
+
+	tests := []struct {
+		name    string
+		program *Program
+		dob     *time.Time
+		phone   *string
+		want    bool
+	}{
+		{"minor without phone", program, &minor, nil, true},
+		{"minor with blank phone", program, &minor, &blank, true},
+		{"minor with phone", program, &minor, &phone, false},
+		{"turns 18 on start date", program, &adult, nil, false},
+		{"no date of birth", program, nil, nil, false},
+		{"not required", &Program{StartDate: &start, MinorAgeThreshold: 18}, &minor, nil, false},
+		{"lower threshold", &Program{StartDate: &start, MinorEmergencyContactRequired: true, MinorAgeThreshold: 16}, &minor, nil, false},
+	}
+
+	for _, tt := range tests {
+		participant := &Participant{DOB: tt.dob, EmergencyContactPhone: tt.phone}
+		if got := NeedsEmergencyContact(tt.program, participant); got != tt.want {
+			t.Errorf("%s: NeedsEmergencyContact = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestCreateRegistrations tests registering several participants in one transaction
+func TestCreateRegistrations(t *testing.T) {
+	batch := func(t *testing.T, db *DB, programID uuid.UUID, n int) []RegistrationRequest {
+		reqs := make([]RegistrationRequest, n)
+		for i := range reqs {
+			reqs[i] = RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: createTestParticipant(t, db)}
+		}
+		return reqs
+	}
+
+	t.Run("should place each participant against the capacity left", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 2)
+		reqs := batch(t, db, programID, 3)
+
+		// The second participant is too young; its failure must not affect the others
+		if _, err := db.Exec(`UPDATE programs SET age_min = 10 WHERE id = $1`, programID); err != nil {
+			t.Fatalf("failed to set program age range: %v", err)
+		}
+		if _, err := db.Exec(`UPDATE participants SET dob = NOW() - INTERVAL '5 years' WHERE id = $1`, reqs[1].ParticipantID); err != nil {
+			t.Fatalf("failed to set participant dob: %v", err)
+		}
+		reqs = append(reqs, batch(t, db, programID, 1)...)
+
+		items, committed, err := db.CreateRegistrations(reqs, false)
+		if err != nil {
+			t.Fatalf("CreateRegistrations: %v", err)
+		}
+		if !committed {
+			t.Error("batch was not committed")
+		}
+		want := []string{"confirmed", "error", "confirmed", "waitlisted"}
+		for i, item := range items {
+			if item.Status != want[i] {
+				t.Errorf("items[%d] status = %q, want %q (err %v)", i, item.Status, want[i], item.Err)
+			}
+		}
+		var ageErr *AgeEligibilityError
+		if !errors.As(items[1].Err, &ageErr) {
+			t.Errorf("items[1] error = %v, want AgeEligibilityError", items[1].Err)
+		}
+		if n := countConfirmed(t, db, programID, nil); n != 2 {
+			t.Errorf("confirmed = %d, want 2", n)
+		}
 	})
+
+	t.Run("should keep nothing from an atomic batch that does not fit", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 2)
+		reqs := batch(t, db, programID, 3)
+
+		items, committed, err := db.CreateRegistrations(reqs, true)
+		if err != nil {
+			t.Fatalf("CreateRegistrations: %v", err)
+		}
+		if committed {
+			t.Error("atomic batch with a waitlisted participant was committed")
+		}
+		if items[2].Status != "waitlisted" {
+			t.Errorf("items[2] status = %q, want waitlisted", items[2].Status)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM registrations WHERE parent_id = $1`, programID); n != 0 {
+			t.Errorf("registrations = %d, want 0", n)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE parent_id = $1`, programID); n != 0 {
+			t.Errorf("waitlist positions = %d, want 0", n)
+		}
+
+		if _, committed, err := db.CreateRegistrations(reqs[:2], true); err != nil || !committed {
+			t.Errorf("atomic batch that fits: committed = %v, %v; want true", committed, err)
+		}
+		if n := countConfirmed(t, db, programID, nil); n != 2 {
+			t.Errorf("confirmed = %d, want 2", n)
+		}
+	})
+}
+
+// TestRegistrationAgeEnforcement tests registrations are rejected outside the program's
+// age range as of its start date, unless overridden
+func TestRegistrationAgeEnforcement(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+
+	start := time.Date(time.Now().Year()+1, time.June, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := db.Exec(`UPDATE programs SET age_min = 13, age_max = 18, start_date = $2 WHERE id = $1`, programID, start); err != nil {
+		t.Fatalf("failed to set program age range: %v", err)
+	}
+
+	register := func(dob *time.Time, override bool) error {
+		participantID := createTestParticipant(t, db)
+		if _, err := db.Exec(`UPDATE participants SET dob = $2 WHERE id = $1`, participantID, dob); err != nil {
+			t.Fatalf("failed to set participant dob: %v", err)
+		}
+		_, err := db.CreateRegistration(RegistrationRequest{
+			ParentType:       "program",
+			ParentID:         programID,
+			ParticipantID:    participantID,
+			AllowAgeOverride: override,
+		})
+		return err
+	}
+
+	date := func(years, days int) *time.Time {
+		d := start.AddDate(-years, 0, days)
+		return &d
+	}
+
+	tests := []struct {
+		name     string
+		dob      *time.Time
+		override bool
+		wantErr  bool
+	}{
+		{"turns 13 on start date", date(13, 0), false, false},
+		{"turns 13 the day after start", date(13, 1), false, true},
+		{"still 18 on start date", date(19, 1), false, false},
+		{"turns 19 on start date", date(19, 0), false, true},
+		{"5-year-old", date(5, 0), false, true},
+		{"5-year-old with override", date(5, 0), true, false},
+		{"no dob", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		err := register(tt.dob, tt.override)
+		var ageErr *AgeEligibilityError
+		if tt.wantErr && !errors.As(err, &ageErr) {
+			t.Errorf("%s: err = %v, want AgeEligibilityError", tt.name, err)
+		}
+		if !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+	}
 }
 
 // TestDuplicateRegistration tests uniqueness constraints
 func TestDuplicateRegistration(t *testing.T) {
 	t.Run("should prevent duplicate registration for same participant", func(t *testing.T) {
-		// Setup: Participant already registered for program
-		// Action: Attempt to register same participant again
-		// Assert: Should return error or update existing registration
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+		participantID := createTestParticipant(t, db)
+
+		first, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: participantID})
+		if err != nil {
+			t.Fatalf("first registration: %v", err)
+		}
+		second, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: participantID})
+		if err != nil {
+			t.Fatalf("second registration: %v", err)
+		}
+
+		if first.Registration.ID != second.Registration.ID {
+			t.Errorf("second registration created a new row %s, want existing %s", second.Registration.ID, first.Registration.ID)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM registrations WHERE participant_id = $1`, participantID); n != 1 {
+			t.Errorf("registrations for participant = %d, want 1", n)
+		}
+		if !second.AlreadyRegistered || second.Registration.Status != "confirmed" {
+			t.Errorf("second registration = %+v, want the existing confirmed registration", second)
+		}
+	})
+
+	t.Run("should not consume capacity twice when registering twice in a row", func(t *testing.T) {
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		participantID := createTestParticipant(t, db)
+
+		for i := 0; i < 2; i++ {
+			if _, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: participantID}); err != nil {
+				t.Fatalf("registration %d: %v", i+1, err)
+			}
+		}
+
+		if n := countRows(t, db, `SELECT COUNT(*) FROM registrations WHERE parent_id = $1 AND status = 'confirmed'`, programID); n != 1 {
+			t.Errorf("confirmed registrations = %d, want 1", n)
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM waitlist_positions WHERE parent_id = $1`, programID); n != 0 {
+			t.Errorf("waitlist positions = %d, want 0", n)
+		}
+		if n := countNotifications(t, db, "CONFIRMATION", participantID); n != 1 {
+			t.Errorf("CONFIRMATION notifications = %d, want 1", n)
+		}
+
+		// The only spot is still taken by the first registration
+		other := registerTestParticipant(t, db, programID, nil)
+		if !other.IsWaitlisted {
+			t.Errorf("next participant = %+v, want waitlisted", other)
+		}
 	})
 }
 
+// TestGetUserRegistrationDetails tests a household's registrations come back with their
+// program, session and waitlist position, leaving out cancelled ones unless asked
+func TestGetUserRegistrationDetails(t *testing.T) {
+	db := setupTestDB(t)
+	openProgramID := createTestProgram(t, db, 5)
+	sessionID := createTestSession(t, db, openProgramID, nil)
+	fullProgramID := createTestProgram(t, db, 1)
+	registerTestParticipants(t, db, fullProgramID, nil, 1)
+	cancelledProgramID := createTestProgram(t, db, 5)
+
+	participantID := createTestParticipant(t, db)
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id WHERE p.id = $1
+	`, participantID).Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to get participant owner: %v", err)
+	}
+
+	register := func(programID uuid.UUID, sessionID *uuid.UUID) *RegistrationResult {
+		result, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, SessionID: sessionID, ParticipantID: participantID})
+		if err != nil {
+			t.Fatalf("failed to register: %v", err)
+		}
+		return result
+	}
+	register(openProgramID, &sessionID)
+	register(fullProgramID, nil)
+	cancelled := register(cancelledProgramID, nil)
+	if err := db.CancelRegistration(cancelled.Registration.ID, participantID, nil, nil, nil); err != nil {
+		t.Fatalf("failed to cancel registration: %v", err)
+	}
+
+	registrations, err := db.GetUserRegistrationDetails(userID, "", false)
+	if err != nil {
+		t.Fatalf("GetUserRegistrationDetails: %v", err)
+	}
+	if len(registrations) != 2 {
+		t.Fatalf("registrations = %d, want 2 without the cancelled one", len(registrations))
+	}
+	for _, r := range registrations {
+		if r.ProgramInfo == nil || r.ProgramInfo.Title != "Test Program" || r.Participant == nil || r.Participant.FirstName != "Test" {
+			t.Errorf("registration %s missing program or participant details: %+v", r.ID, r)
+		}
+		switch r.ParentID {
+		case openProgramID:
+			if r.SessionInfo == nil || r.SessionInfo.ID != sessionID || r.WaitlistPosition != nil {
+				t.Errorf("confirmed registration = %+v, want its session and no waitlist position", r)
+			}
+		case fullProgramID:
+			if r.Status != "waitlisted" || r.WaitlistPosition == nil || *r.WaitlistPosition != 1 {
+				t.Errorf("waitlisted registration = %+v, want waitlist position 1", r)
+			}
+		}
+	}
+
+	waitlisted, err := db.GetUserRegistrationDetails(userID, "waitlisted", false)
+	if err != nil || len(waitlisted) != 1 || waitlisted[0].ParentID != fullProgramID {
+		t.Errorf("waitlisted registrations = %+v, %v; want only the full program", waitlisted, err)
+	}
+	all, err := db.GetUserRegistrationDetails(userID, "", true)
+	if err != nil || len(all) != 3 {
+		t.Errorf("registrations including cancelled = %d, %v; want 3", len(all), err)
+	}
+}
+
+// TestGetParticipantRegistrationHistory tests a participant's timeline lists cancelled
+// registrations and promotions off the waitlist, oldest first
+func TestGetParticipantRegistrationHistory(t *testing.T) {
+	db := setupTestDB(t)
+	fullProgramID := createTestProgram(t, db, 1)
+	ahead := registerTestParticipant(t, db, fullProgramID, nil)
+	cancelledProgramID := createTestProgram(t, db, 5)
+
+	participantID := createTestParticipant(t, db)
+	register := func(programID uuid.UUID) *RegistrationResult {
+		t.Helper()
+		result, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: participantID})
+		if err != nil {
+			t.Fatalf("failed to register: %v", err)
+		}
+		return result
+	}
+	cancelled := register(cancelledProgramID)
+	cancelTestRegistration(t, db, cancelled)
+	promoted := register(fullProgramID)
+	cancelTestRegistration(t, db, ahead)
+
+	timeline, err := db.GetParticipantRegistrationHistory(participantID)
+	if err != nil {
+		t.Fatalf("GetParticipantRegistrationHistory: %v", err)
+	}
+	if len(timeline) != 2 {
+		t.Fatalf("timeline has %d registrations, want 2", len(timeline))
+	}
+
+	statuses := func(r RegistrationHistory) []string {
+		var s []string
+		for _, h := range r.History {
+			s = append(s, h.NewStatus)
+		}
+		return s
+	}
+	first, second := timeline[0], timeline[1]
+	if first.ID != cancelled.Registration.ID || first.Status != "cancelled" || first.ProgramInfo == nil || first.ProgramInfo.Title != "Test Program" {
+		t.Errorf("first registration = %+v, want the cancelled one with its program", first.Registration)
+	}
+	if got := statuses(first); len(got) != 2 || got[0] != "confirmed" || got[1] != "cancelled" {
+		t.Errorf("cancelled registration history = %v, want confirmed then cancelled", got)
+	}
+	if second.ID != promoted.Registration.ID || second.Status != "confirmed" {
+		t.Errorf("second registration = %+v, want the promoted one", second.Registration)
+	}
+	if got := statuses(second); len(got) != 2 || got[0] != "waitlisted" || got[1] != "confirmed" {
+		t.Errorf("promoted registration history = %v, want waitlisted then confirmed", got)
+	}
+
+	if other, err := db.GetParticipantRegistrationHistory(uuid.New()); err != nil || len(other) != 0 {
+		t.Errorf("timeline for an unknown participant = %v, %v; want empty", other, err)
+	}
+}
+
+// TestAdminUpdateRegistrationStatus tests an admin confirming a waitlisted registration
+// takes it off the waitlist and records who made the change, a no-op change is rejected,
+// and giving up a seat promotes from the waitlist
+func TestAdminUpdateRegistrationStatus(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	results := registerTestParticipants(t, db, programID, nil, 3)
+	waitlisted := results[1].Registration
+
+	var adminID uuid.UUID
+	err := db.QueryRow(`
+		SELECT h.owner_user_id FROM participants p
+		JOIN households h ON h.id = p.household_id
+		WHERE p.id = $1
+	`, results[0].Registration.ParticipantID).Scan(&adminID)
+	if err != nil {
+		t.Fatalf("failed to get a user to act as admin: %v", err)
+	}
+
+	waitlistPosition := func(participantID uuid.UUID) int {
+		t.Helper()
+		var position int
+		err := db.QueryRow(`
+			SELECT COALESCE(MAX(position), 0) FROM waitlist_positions
+			WHERE parent_type = 'program' AND parent_id = $1 AND participant_id = $2
+		`, programID, participantID).Scan(&position)
+		if err != nil {
+			t.Fatalf("failed to get waitlist position: %v", err)
+		}
+		return position
+	}
+
+	reason := "Approved by the program director"
+	if err := db.UpdateRegistrationStatus(waitlisted.ID, "confirmed", adminID, &reason); err != nil {
+		t.Fatalf("UpdateRegistrationStatus: %v", err)
+	}
+	if status := registrationStatus(t, db, waitlisted.ID); status != "confirmed" {
+		t.Errorf("status = %q, want confirmed", status)
+	}
+	if position := waitlistPosition(waitlisted.ParticipantID); position != 0 {
+		t.Errorf("confirmed registration still on the waitlist at position %d", position)
+	}
+
+	history, err := db.GetRegistrationStatusHistory(waitlisted.ID)
+	if err != nil {
+		t.Fatalf("GetRegistrationStatusHistory: %v", err)
+	}
+	last := history[len(history)-1]
+	if last.OldStatus == nil || *last.OldStatus != "waitlisted" || last.NewStatus != "confirmed" ||
+		last.ChangedBy == nil || *last.ChangedBy != adminID || last.Reason == nil || *last.Reason != reason {
+		t.Errorf("history entry = %+v, want waitlisted to confirmed by the admin with the reason", last)
+	}
+
+	if err := db.UpdateRegistrationStatus(waitlisted.ID, "confirmed", adminID, nil); !errors.Is(err, ErrRegistrationStatusUnchanged) {
+		t.Errorf("no-op status change = %v, want ErrRegistrationStatusUnchanged", err)
+	}
+	if after, _ := db.GetRegistrationStatusHistory(waitlisted.ID); len(after) != len(history) {
+		t.Errorf("no-op status change recorded history: %d entries, want %d", len(after), len(history))
+	}
+
+	// Moving it back puts it at the end of the waitlist
+	if err := db.UpdateRegistrationStatus(waitlisted.ID, "waitlisted", adminID, nil); err != nil {
+		t.Fatalf("UpdateRegistrationStatus: %v", err)
+	}
+	if position, behind := waitlistPosition(waitlisted.ParticipantID), waitlistPosition(results[2].Registration.ParticipantID); position <= behind {
+		t.Errorf("waitlist position = %d, want after the remaining entry at %d", position, behind)
+	}
+
+	// Cancelling the confirmed registration promotes the head of the waitlist into its seat
+	if err := db.UpdateRegistrationStatus(results[0].Registration.ID, "cancelled", adminID, nil); err != nil {
+		t.Fatalf("UpdateRegistrationStatus: %v", err)
+	}
+	if status := registrationStatus(t, db, results[2].Registration.ID); status != "confirmed" {
+		t.Errorf("head of the waitlist status = %q, want confirmed", status)
+	}
+	if status := registrationStatus(t, db, waitlisted.ID); status != "waitlisted" {
+		t.Errorf("second on the waitlist status = %q, want waitlisted", status)
+	}
+}
+
 // TestEmailNotifications tests notification queue
 func TestEmailNotifications(t *testing.T) {
 	t.Run("should queue confirmation email on confirmed registration", func(t *testing.T) {
-		// Setup: Register participant with available capacity
-		// Assert: notification_queue should have CONFIRMATION entry
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 5)
+
+		result := registerTestParticipant(t, db, programID, nil)
+
+		if n := countNotifications(t, db, "CONFIRMATION", result.Registration.ParticipantID); n != 1 {
+			t.Errorf("CONFIRMATION notifications = %d, want 1", n)
+		}
 	})
 
 	t.Run("should queue waitlist email when waitlisted", func(t *testing.T) {
-		// Setup: Register participant when at capacity
-		// Assert: notification_queue should have WAITLIST_SPOT entry with position
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		registerTestParticipants(t, db, programID, nil, 1)
+
+		result := registerTestParticipant(t, db, programID, nil)
+
+		var position int
+		err := db.QueryRow(`
+			SELECT (payload->>'position')::int FROM notification_queue
+			WHERE type = 'WAITLIST_SPOT' AND payload->>'participant_id' = $1
+		`, result.Registration.ParticipantID.String()).Scan(&position)
+		if err != nil {
+			t.Fatalf("failed to get WAITLIST_SPOT notification: %v", err)
+		}
+		if position != 1 {
+			t.Errorf("notification position = %d, want 1", position)
+		}
 	})
 
 	t.Run("should queue promotion email when promoted from waitlist", func(t *testing.T) {
-		// Setup: Cancel confirmed registration with waitlist
-		// Assert: notification_queue should have WAITLIST_PROMOTED entry
+		db := setupTestDB(t)
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipant(t, db, programID, nil)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if n := countNotifications(t, db, "WAITLIST_PROMOTED", waitlisted.Registration.ParticipantID); n != 1 {
+			t.Errorf("WAITLIST_PROMOTED notifications = %d, want 1", n)
+		}
 	})
 }
 
@@ -115,29 +1240,226 @@ func TestEmailNotifications(t *testing.T) {
 func BenchmarkCreateRegistration(b *testing.B) {
 	// Benchmark registration creation performance
 	// Useful for ensuring capacity checks don't significantly impact performance
+	db := setupTestDB(b)
+	programID := createTestProgram(b, db, b.N)
+	participants := make([]uuid.UUID, b.N)
+	for i := range participants {
+		participants[i] = createTestParticipant(b, db)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := db.CreateRegistration(RegistrationRequest{ParentType: "program", ParentID: programID, ParticipantID: participants[i]}); err != nil {
+			b.Fatalf("registration failed: %v", err)
+		}
+	}
 }
 
 func BenchmarkWaitlistPromotion(b *testing.B) {
 	// Benchmark waitlist promotion performance
 	// Ensures cancellation + promotion is fast even with long waitlists
+	db := setupTestDB(b)
+	programID := createTestProgram(b, db, 1)
+	results := registerTestParticipants(b, db, programID, nil, b.N+1)
+
+	// Each cancellation promotes the next participant, who is cancelled in the next iteration
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		cancelTestRegistration(b, db, results[i])
+	}
+}
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// setupTestDB connects to TEST_DATABASE_URL and applies migrations once per run,
+// skipping the test when no test database is configured
+func setupTestDB(t testing.TB) *DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping database test")
+	}
+
+	db, err := Open(url)
+	if err != nil {
+		t.Fatalf("failed to connect to test database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrateOnce.Do(func() {
+		migrateErr = db.RunMigrations("../../migrations")
+	})
+	if migrateErr != nil {
+		t.Fatalf("failed to run migrations: %v", migrateErr)
+	}
+
+	// Promotions confirm straight away unless a test turns waitlist offers on
+	db.offerWindow = 0
+
+	return db
+}
+
+// createTestProgram creates an active program with the given capacity and removes it,
+// its sessions, registrations and queued notifications when the test ends
+func createTestProgram(t testing.TB, db *DB, capacity int) uuid.UUID {
+	t.Helper()
+
+	slug := "test-program-" + uuid.New().String()
+	var id uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO programs (slug, title, capacity)
+		VALUES ($1, 'Test Program', $2)
+		RETURNING id
+	`, slug, capacity).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create test program: %v", err)
+	}
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'parent_id' = $1`, id.String())
+		db.Exec(`DELETE FROM waitlist_positions WHERE parent_id = $1`, id)
+		db.Exec(`DELETE FROM registrations WHERE parent_id = $1`, id)
+		db.Exec(`DELETE FROM sessions WHERE parent_id = $1`, id)
+		db.Exec(`DELETE FROM programs WHERE id = $1`, id)
+	})
+
+	return id
 }
 
-// Example helper function for test setup
-func setupTestDB(t *testing.T) *DB {
-	// In real implementation:
-	// 1. Create test database connection
-	// 2. Run migrations
-	// 3. Return DB instance
-	// 4. Register cleanup with t.Cleanup()
-	return nil
+// createTestSession creates a session for a program; it is removed with the program
+func createTestSession(t testing.TB, db *DB, programID uuid.UUID, capacityOverride *int) uuid.UUID {
+	t.Helper()
+
+	var id uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO sessions (parent_type, parent_id, starts_at, ends_at, capacity_override)
+		VALUES ('program', $1, now() + interval '7 days', now() + interval '7 days 1 hour', $2)
+		RETURNING id
+	`, programID, capacityOverride).Scan(&id)
+	if err != nil {
+		t.Fatalf("failed to create test session: %v", err)
+	}
+	return id
+}
+
+// createTestParticipant creates a user, household and participant, removing them
+// (and through cascades, their registrations) when the test ends
+func createTestParticipant(t testing.TB, db *DB) uuid.UUID {
+	t.Helper()
+
+	var userID uuid.UUID
+	err := db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	var householdID uuid.UUID
+	err = db.QueryRow(`
+		INSERT INTO households (owner_user_id) VALUES ($1) RETURNING id
+	`, userID).Scan(&householdID)
+	if err != nil {
+		t.Fatalf("failed to create test household: %v", err)
+	}
+
+	var participantID uuid.UUID
+	err = db.QueryRow(`
+		INSERT INTO participants (household_id, first_name, last_name)
+		VALUES ($1, 'Test', 'Participant')
+		RETURNING id
+	`, householdID).Scan(&participantID)
+	if err != nil {
+		t.Fatalf("failed to create test participant: %v", err)
+	}
+
+	return participantID
+}
+
+// registerTestParticipant registers a new participant for a program or program session
+func registerTestParticipant(t testing.TB, db *DB, programID uuid.UUID, sessionID *uuid.UUID) *RegistrationResult {
+	t.Helper()
+
+	result, err := db.CreateRegistration(RegistrationRequest{
+		ParentType:    "program",
+		ParentID:      programID,
+		SessionID:     sessionID,
+		ParticipantID: createTestParticipant(t, db),
+	})
+	if err != nil {
+		t.Fatalf("failed to register participant: %v", err)
+	}
+	return result
+}
+
+// registerTestParticipants registers n new participants in order
+func registerTestParticipants(t testing.TB, db *DB, programID uuid.UUID, sessionID *uuid.UUID, n int) []*RegistrationResult {
+	t.Helper()
+
+	results := make([]*RegistrationResult, n)
+	for i := range results {
+		results[i] = registerTestParticipant(t, db, programID, sessionID)
+	}
+	return results
 }
 
-func createTestProgram(t *testing.T, db *DB, capacity int) uuid.UUID {
-	// Helper to create a test program with specific capacity
-	return uuid.New()
+// cancelTestRegistration cancels a registration created by registerTestParticipant
+func cancelTestRegistration(t testing.TB, db *DB, result *RegistrationResult) {
+	t.Helper()
+
+	reg := result.Registration
+	if err := db.CancelRegistration(reg.ID, reg.ParticipantID, nil, nil, nil); err != nil {
+		t.Fatalf("failed to cancel registration: %v", err)
+	}
 }
 
-func createTestParticipant(t *testing.T, db *DB) uuid.UUID {
-	// Helper to create a test participant
-	return uuid.New()
+// registrationStatus returns the current status of a registration
+func registrationStatus(t testing.TB, db *DB, id uuid.UUID) string {
+	t.Helper()
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM registrations WHERE id = $1`, id).Scan(&status); err != nil {
+		t.Fatalf("failed to get registration status: %v", err)
+	}
+	return status
+}
+
+// countConfirmed counts confirmed registrations for a program, or one of its sessions
+func countConfirmed(t testing.TB, db *DB, programID uuid.UUID, sessionID *uuid.UUID) int {
+	t.Helper()
+
+	return countRows(t, db, `
+		SELECT COUNT(*) FROM registrations
+		WHERE parent_id = $1 AND session_id IS NOT DISTINCT FROM $2 AND status = 'confirmed'
+	`, programID, sessionID)
+}
+
+// countNotifications counts queued notifications of a type for a participant
+func countNotifications(t testing.TB, db *DB, notifType string, participantID uuid.UUID) int {
+	t.Helper()
+
+	return countRows(t, db, `
+		SELECT COUNT(*) FROM notification_queue
+		WHERE type = $1 AND payload->>'participant_id' = $2
+	`, notifType, participantID.String())
+}
+
+// countRows runs a COUNT(*) query
+func countRows(t testing.TB, db *DB, query string, args ...interface{}) int {
+	t.Helper()
+
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatalf("failed to count rows: %v", err)
+	}
+	return n
 }
@@ -0,0 +1,145 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ParticipantPIIPurge records the sensitive fields scrubbed from one participant
+type ParticipantPIIPurge struct {
+	ParticipantID  uuid.UUID `json:"participant_id"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	Fields         []string  `json:"fields"`
+}
+
+// PurgeInactiveParticipantPII scrubs medical notes, emergency contacts and dietary and
+// accessibility needs from participants whose last activity is before cutoff and who are
+// not under a legal hold. Activity is the participant's creation and last edit, their
+// registrations and status changes, check-ins, the end of the sessions and events they
+// registered for, the bookings that name them, and their form submissions and waiver
+// acceptances. Registrations are left in place for statistics. Each purge is logged and
+// returned.
+func (db *DB) PurgeInactiveParticipantPII(cutoff time.Time) ([]ParticipantPIIPurge, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		WITH activity AS (
+			SELECT participant_id, MAX(at) AS last_at
+			FROM (
+				SELECT r.participant_id, GREATEST(
+					MAX(r.created_at), MAX(r.checked_in_at), MAX(h.created_at),
+					MAX(s.ends_at), MAX(e.ends_at), MAX(e.starts_at)
+				) AS at
+				FROM registrations r
+				LEFT JOIN registration_status_history h ON h.registration_id = r.id
+				LEFT JOIN sessions s ON s.id = r.session_id
+					OR (r.session_id IS NULL AND s.parent_type = r.parent_type AND s.parent_id = r.parent_id)
+				LEFT JOIN events e ON r.parent_type = 'event' AND e.id = r.parent_id
+				GROUP BY r.participant_id
+				UNION ALL
+				SELECT unnest(b.participant_ids), GREATEST(b.created_at, b.end_time)
+				FROM facility_bookings b
+				UNION ALL
+				SELECT participant_id, updated_at FROM participant_form_submissions
+				UNION ALL
+				SELECT participant_id, accepted_at FROM participant_waiver_acceptances
+				UNION ALL
+				SELECT participant_id, accepted_at FROM participant_waivers
+			) sources
+			GROUP BY participant_id
+		)
+		SELECT p.id, GREATEST(p.created_at, p.updated_at, a.last_at),
+			ARRAY_REMOVE(ARRAY[
+				CASE WHEN p.medical_notes IS NOT NULL THEN 'medical_notes' END,
+				CASE WHEN p.emergency_contact_name IS NOT NULL THEN 'emergency_contact_name' END,
+				CASE WHEN p.emergency_contact_phone IS NOT NULL THEN 'emergency_contact_phone' END,
+				CASE WHEN cardinality(p.dietary_restrictions) > 0 THEN 'dietary_restrictions' END,
+				CASE WHEN cardinality(p.accessibility_needs) > 0 THEN 'accessibility_needs' END
+			], NULL)
+		FROM participants p
+		LEFT JOIN activity a ON a.participant_id = p.id
+		WHERE NOT p.legal_hold
+			AND GREATEST(p.created_at, p.updated_at, a.last_at) < $1
+			AND (p.medical_notes IS NOT NULL
+				OR p.emergency_contact_name IS NOT NULL
+				OR p.emergency_contact_phone IS NOT NULL
+				OR cardinality(p.dietary_restrictions) > 0
+				OR cardinality(p.accessibility_needs) > 0)
+		ORDER BY p.id
+		FOR UPDATE OF p
+	`, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find participants to purge: %w", err)
+	}
+
+	purges := []ParticipantPIIPurge{}
+	for rows.Next() {
+		var p ParticipantPIIPurge
+		if err := rows.Scan(&p.ParticipantID, &p.LastActivityAt, pq.Array(&p.Fields)); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan participant to purge: %w", err)
+		}
+		purges = append(purges, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to find participants to purge: %w", err)
+	}
+
+	for _, p := range purges {
+		_, err := tx.Exec(`
+			UPDATE participants SET
+				medical_notes = NULL,
+				emergency_contact_name = NULL,
+				emergency_contact_phone = NULL,
+				dietary_restrictions = '{}',
+				accessibility_needs = '{}',
+				pii_purged_at = now()
+			WHERE id = $1
+		`, p.ParticipantID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge participant: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO participant_pii_purges (participant_id, last_activity_at, fields)
+			VALUES ($1, $2, $3)
+		`, p.ParticipantID, p.LastActivityAt, pq.Array(p.Fields))
+		if err != nil {
+			return nil, fmt.Errorf("failed to log participant purge: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return purges, nil
+}
+
+// SetParticipantLegalHold places or lifts a legal hold exempting a participant from the
+// retention purge. It reports false if the participant does not exist.
+func (db *DB) SetParticipantLegalHold(participantID uuid.UUID, hold bool, reason *string) (bool, error) {
+	if !hold {
+		reason = nil
+	}
+	result, err := db.Exec(`
+		UPDATE participants SET legal_hold = $2, legal_hold_reason = $3
+		WHERE id = $1
+	`, participantID, hold, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to set legal hold: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rowsAffected > 0, nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TestPurgeInactiveParticipantPII tests sensitive fields are scrubbed from inactive
+// participants, but not from active ones or those under a legal hold, and that the
+// purge is logged while registrations are kept
+func TestPurgeInactiveParticipantPII(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 5)
+	inactive := registerTestParticipant(t, db, programID, nil).Registration
+	held := createTestParticipant(t, db)
+	active := createTestParticipant(t, db)
+	edited := createTestParticipant(t, db)
+
+	longAgo := time.Now().AddDate(-3, 0, 0)
+	for _, id := range []interface{}{inactive.ParticipantID, held, active, edited} {
+		_, err := db.Exec(`
+			UPDATE participants SET medical_notes = 'Asthma', emergency_contact_phone = '555-0100',
+				dietary_restrictions = '{nut_allergy}'
+			WHERE id = $1
+		`, id)
+		if err != nil {
+			t.Fatalf("failed to set participant details: %v", err)
+		}
+	}
+	db.Exec(`UPDATE participants SET created_at = $2, updated_at = $2 WHERE id = ANY($1)`, pq.Array([]string{inactive.ParticipantID.String(), held.String()}), longAgo)
+	// Created long ago but edited by the family recently
+	db.Exec(`UPDATE participants SET created_at = $2 WHERE id = $1`, edited, longAgo)
+	db.Exec(`UPDATE registrations SET created_at = $2 WHERE id = $1`, inactive.ID, longAgo)
+	db.Exec(`UPDATE registration_status_history SET created_at = $2 WHERE registration_id = $1`, inactive.ID, longAgo)
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM participant_pii_purges WHERE participant_id = $1`, inactive.ParticipantID)
+	})
+
+	if found, err := db.SetParticipantLegalHold(held, true, nil); err != nil || !found {
+		t.Fatalf("SetParticipantLegalHold = %v, %v; want true", found, err)
+	}
+
+	purges, err := db.PurgeInactiveParticipantPII(time.Now().AddDate(-1, 0, 0))
+	if err != nil {
+		t.Fatalf("PurgeInactiveParticipantPII: %v", err)
+	}
+
+	var purged []string
+	for _, p := range purges {
+		switch p.ParticipantID {
+		case inactive.ParticipantID:
+			purged = p.Fields
+		case held, active, edited:
+			t.Errorf("participant %s purged despite a legal hold or recent activity", p.ParticipantID)
+		}
+	}
+	want := []string{"medical_notes", "emergency_contact_phone", "dietary_restrictions"}
+	if len(purged) != len(want) {
+		t.Fatalf("purged fields = %v, want %v", purged, want)
+	}
+	for i := range want {
+		if purged[i] != want[i] {
+			t.Errorf("purged fields = %v, want %v", purged, want)
+		}
+	}
+
+	if n := countRows(t, db, `SELECT COUNT(*) FROM participants WHERE id = $1 AND medical_notes IS NULL AND pii_purged_at IS NOT NULL`, inactive.ParticipantID); n != 1 {
+		t.Error("inactive participant's medical notes not scrubbed")
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM participants WHERE id = ANY($1) AND medical_notes IS NOT NULL`, pq.Array([]string{held.String(), active.String(), edited.String()})); n != 3 {
+		t.Errorf("%d of the held, active and recently edited participants kept their medical notes, want 3", n)
+	}
+	if n := countRows(t, db, `SELECT COUNT(*) FROM participant_pii_purges WHERE participant_id = $1`, inactive.ParticipantID); n != 1 {
+		t.Errorf("purge log entries = %d, want 1", n)
+	}
+	if status := registrationStatus(t, db, inactive.ID); status != "confirmed" {
+		t.Errorf("registration status = %q, want it kept as confirmed", status)
+	}
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ScheduleItem is one dated occurrence on a household's schedule: a program or event
+// session someone in the household is confirmed for, or a confirmed facility booking.
+// Household members attending the same occurrence share one item.
+type ScheduleItem struct {
+	Kind             string      `json:"kind"`      // 'program', 'event' or 'booking'
+	ParentID         uuid.UUID   `json:"parent_id"` // program, event or facility ID
+	SessionID        *uuid.UUID  `json:"session_id,omitempty"`
+	BookingID        *uuid.UUID  `json:"booking_id,omitempty"`
+	Title            string      `json:"title"`
+	Location         *string     `json:"location,omitempty"`
+	StartsAt         time.Time   `json:"starts_at"`
+	EndsAt           *time.Time  `json:"ends_at,omitempty"`
+	ParticipantIDs   []uuid.UUID `json:"participant_ids"`
+	ParticipantNames []string    `json:"participant_names"`
+}
+
+// ScheduleDay groups the schedule items starting on one calendar day
+type ScheduleDay struct {
+	Date  string         `json:"date"` // YYYY-MM-DD
+	Items []ScheduleItem `json:"items"`
+}
+
+// GetHouseholdSchedule returns the household's confirmed registrations and bookings that
+// start in [from, to), in chronological order. A registration for a specific session
+// covers that session; a program- or event-level registration covers every active
+// session of its parent, or the event's own times when it has no sessions. Programs
+// without dated sessions have nothing to put on a calendar and are left out.
+func (db *DB) GetHouseholdSchedule(householdID uuid.UUID, from, to time.Time) ([]ScheduleItem, error) {
+	rows, err := db.Query(`
+		WITH regs AS (
+			SELECT r.parent_type, r.parent_id, r.session_id, p.id AS participant_id,
+				p.first_name || ' ' || p.last_name AS participant_name
+			FROM registrations r
+			JOIN participants p ON p.id = r.participant_id
+			WHERE p.household_id = $1 AND r.status = 'confirmed'
+		), occurrences AS (
+			SELECT regs.*, s.id AS occurrence_session_id, s.starts_at, s.ends_at
+			FROM regs
+			JOIN sessions s ON s.id = regs.session_id
+			UNION ALL
+			SELECT regs.*, s.id, s.starts_at, s.ends_at
+			FROM regs
+			JOIN sessions s ON s.parent_type = regs.parent_type AND s.parent_id = regs.parent_id
+			WHERE regs.session_id IS NULL AND s.is_active = true
+			UNION ALL
+			SELECT regs.*, NULL::uuid, e.starts_at, e.ends_at
+			FROM regs
+			JOIN events e ON e.id = regs.parent_id
+			WHERE regs.parent_type = 'event' AND regs.session_id IS NULL
+				AND NOT EXISTS (
+					SELECT 1 FROM sessions s
+					WHERE s.parent_type = 'event' AND s.parent_id = e.id AND s.is_active = true
+				)
+		)
+		SELECT o.parent_type::text, o.parent_id, o.occurrence_session_id, NULL::uuid,
+			COALESCE(prog.title, ev.title), COALESCE(prog.location, ev.location),
+			o.starts_at, o.ends_at,
+			array_agg(o.participant_id ORDER BY o.participant_name),
+			array_agg(o.participant_name ORDER BY o.participant_name)
+		FROM occurrences o
+		LEFT JOIN programs prog ON o.parent_type = 'program' AND prog.id = o.parent_id
+		LEFT JOIN events ev ON o.parent_type = 'event' AND ev.id = o.parent_id
+		WHERE o.starts_at >= $2 AND o.starts_at < $3
+		GROUP BY o.parent_type, o.parent_id, o.occurrence_session_id,
+			prog.title, ev.title, prog.location, ev.location, o.starts_at, o.ends_at
+
+		UNION ALL
+
+		SELECT 'booking', f.id, NULL::uuid, b.id, f.name, f.location,
+			b.start_time, b.end_time,
+			ARRAY(
+				SELECT p.id FROM participants p
+				WHERE p.id = ANY(b.participant_ids)
+				ORDER BY p.first_name || ' ' || p.last_name
+			),
+			ARRAY(
+				SELECT p.first_name || ' ' || p.last_name FROM participants p
+				WHERE p.id = ANY(b.participant_ids)
+				ORDER BY p.first_name || ' ' || p.last_name
+			)
+		FROM facility_bookings b
+		JOIN facilities f ON f.id = b.facility_id
+		WHERE b.household_id = $1 AND b.status = 'confirmed'
+			AND b.start_time >= $2 AND b.start_time < $3
+
+		ORDER BY 7, 5
+	`, householdID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get household schedule: %w", err)
+	}
+	defer rows.Close()
+
+	items := []ScheduleItem{}
+	for rows.Next() {
+		var item ScheduleItem
+		err := rows.Scan(
+			&item.Kind, &item.ParentID, &item.SessionID, &item.BookingID, &item.Title, &item.Location,
+			&item.StartsAt, &item.EndsAt, pq.Array(&item.ParticipantIDs), pq.Array(&item.ParticipantNames),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan schedule item: %w", err)
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// GroupScheduleByDay groups chronologically ordered items by the day they start in loc
+func GroupScheduleByDay(items []ScheduleItem, loc *time.Location) []ScheduleDay {
+	days := []ScheduleDay{}
+	for _, item := range items {
+		date := item.StartsAt.In(loc).Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Date != date {
+			days = append(days, ScheduleDay{Date: date, Items: []ScheduleItem{}})
+		}
+		days[len(days)-1].Items = append(days[len(days)-1].Items, item)
+	}
+	return days
+}
+
+// GetProgramScheduleSessions returns a program's active, dated sessions in start order.
+// A sessionID narrows them to that session, for a registration for one session only.
+func (db *DB) GetProgramScheduleSessions(programID uuid.UUID, sessionID *uuid.UUID) ([]Session, error) {
+	rows, err := db.Query(`
+		SELECT id, parent_type, parent_id, starts_at, ends_at, capacity_override, is_active
+		FROM sessions
+		WHERE parent_type = 'program' AND parent_id = $1 AND is_active = true
+			AND starts_at IS NOT NULL
+			AND ($2::uuid IS NULL OR id = $2)
+		ORDER BY starts_at
+	`, programID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get program schedule: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []Session{}
+	for rows.Next() {
+		var s Session
+		err := rows.Scan(&s.ID, &s.ParentType, &s.ParentID, &s.StartsAt, &s.EndsAt, &s.CapacityOverride, &s.IsActive)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// QueueProgramSchedules queues a PROGRAM_SCHEDULE email for each confirmed registration
+// of a program and returns how many were queued. With dryRun nothing is queued and the
+// count is of the registrations that would be emailed.
+func (db *DB) QueueProgramSchedules(programID uuid.UUID, dryRun bool) (int, error) {
+	if dryRun {
+		var count int
+		err := db.QueryRow(`
+			SELECT COUNT(*)
+			FROM registrations
+			WHERE parent_type = 'program' AND parent_id = $1 AND status = 'confirmed'
+		`, programID).Scan(&count)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count schedule recipients: %w", err)
+		}
+		return count, nil
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO notification_queue (type, payload)
+		SELECT 'PROGRAM_SCHEDULE', jsonb_strip_nulls(jsonb_build_object(
+			'registration_id', id,
+			'parent_type', parent_type,
+			'parent_id', parent_id,
+			'session_id', session_id,
+			'participant_id', participant_id
+		))
+		FROM registrations
+		WHERE parent_type = 'program' AND parent_id = $1 AND status = 'confirmed'
+		ORDER BY created_at
+	`, programID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue program schedules: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return int(rows), nil
+}
@@ -0,0 +1,47 @@
+package db
+
+import (
+	"testing"
+)
+
+// TestQueueProgramSchedules tests a dry run only counts confirmed registrants and a real
+// run queues one schedule per confirmed registration, naming its session
+func TestQueueProgramSchedules(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 1)
+	sessionID := createTestSession(t, db, programID, nil)
+	createTestSession(t, db, programID, nil)
+	registerTestParticipants(t, db, programID, &sessionID, 2) // the second is waitlisted
+
+	sessions, err := db.GetProgramScheduleSessions(programID, nil)
+	if err != nil || len(sessions) != 2 {
+		t.Fatalf("program sessions = %d (%v), want 2", len(sessions), err)
+	}
+	sessions, err = db.GetProgramScheduleSessions(programID, &sessionID)
+	if err != nil || len(sessions) != 1 || sessions[0].ID != sessionID {
+		t.Fatalf("registered session = %+v (%v), want only %s", sessions, err, sessionID)
+	}
+
+	count, err := db.QueueProgramSchedules(programID, true)
+	if err != nil || count != 1 {
+		t.Fatalf("dry run = %d (%v), want 1", count, err)
+	}
+	var queued int
+	db.QueryRow(`SELECT COUNT(*) FROM notification_queue WHERE type = 'PROGRAM_SCHEDULE' AND payload->>'parent_id' = $1`, programID.String()).Scan(&queued)
+	if queued != 0 {
+		t.Fatalf("dry run queued %d schedules", queued)
+	}
+
+	count, err = db.QueueProgramSchedules(programID, false)
+	if err != nil || count != 1 {
+		t.Fatalf("queued = %d (%v), want 1", count, err)
+	}
+	var payloadSession string
+	err = db.QueryRow(`
+		SELECT payload->>'session_id' FROM notification_queue
+		WHERE type = 'PROGRAM_SCHEDULE' AND payload->>'parent_id' = $1
+	`, programID.String()).Scan(&payloadSession)
+	if err != nil || payloadSession != sessionID.String() {
+		t.Errorf("queued session_id = %q (%v), want %s", payloadSession, err, sessionID)
+	}
+}
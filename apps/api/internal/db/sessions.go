@@ -0,0 +1,83 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SessionUpdate holds the fields of a partial session update; nil fields are left unchanged
+type SessionUpdate struct {
+	StartsAt         *time.Time
+	EndsAt           *time.Time
+	CapacityOverride *int
+	IsActive         *bool
+
+	// ClearCapacityOverride sets capacity_override back to NULL, so the session uses its
+	// parent's capacity again
+	ClearCapacityOverride bool
+}
+
+// CreateSession creates a session of a program or event and returns it with generated
+// fields populated
+func (db *DB) CreateSession(s *Session) (*Session, error) {
+	err := db.QueryRow(`
+		INSERT INTO sessions (parent_type, parent_id, starts_at, ends_at, capacity_override, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, parent_type, parent_id, starts_at, ends_at, capacity_override, is_active
+	`, s.ParentType, s.ParentID, s.StartsAt, s.EndsAt, s.CapacityOverride, s.IsActive).Scan(
+		&s.ID, &s.ParentType, &s.ParentID, &s.StartsAt, &s.EndsAt, &s.CapacityOverride, &s.IsActive,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	return s, nil
+}
+
+// UpdateSession applies a partial update to a session
+func (db *DB) UpdateSession(id uuid.UUID, u *SessionUpdate) error {
+	result, err := db.Exec(`
+		UPDATE sessions SET
+			starts_at = COALESCE($2, starts_at),
+			ends_at = COALESCE($3, ends_at),
+			capacity_override = CASE WHEN $6 THEN NULL ELSE COALESCE($4, capacity_override) END,
+			is_active = COALESCE($5, is_active)
+		WHERE id = $1
+	`, id, u.StartsAt, u.EndsAt, u.CapacityOverride, u.IsActive, u.ClearCapacityOverride)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("session not found")
+	}
+
+	return nil
+}
+
+// DeactivateSession hides a session from registration. Its registrations are kept, so
+// the session can be reactivated.
+func (db *DB) DeactivateSession(id uuid.UUID) error {
+	inactive := false
+	return db.UpdateSession(id, &SessionUpdate{IsActive: &inactive})
+}
+
+// CountSessionConfirmedRegistrations counts the registrations holding a spot in a
+// session: confirmed, paused and offered
+func (db *DB) CountSessionConfirmedRegistrations(id uuid.UUID) (int, error) {
+	var count int
+	err := db.QueryRow(`
+		SELECT COUNT(*) FROM registrations
+		WHERE session_id = $1 AND status IN ('confirmed', 'paused', 'offered')
+	`, id).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count session registrations: %w", err)
+	}
+	return count, nil
+}
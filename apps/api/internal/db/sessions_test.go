@@ -0,0 +1,212 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestSessionCRUD tests sessions can be created, partially updated and deactivated,
+// and that the registrations holding a spot in one are counted
+func TestSessionCRUD(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 5)
+
+	startsAt := time.Now().Add(7 * 24 * time.Hour).Truncate(time.Second)
+	endsAt := startsAt.Add(time.Hour)
+	capacity := 3
+	session, err := db.CreateSession(&Session{
+		ParentType:       "program",
+		ParentID:         programID,
+		StartsAt:         &startsAt,
+		EndsAt:           &endsAt,
+		CapacityOverride: &capacity,
+		IsActive:         true,
+	})
+	if err != nil {
+		t.Fatalf("CreateSession: %v", err)
+	}
+
+	later := endsAt.Add(30 * time.Minute)
+	if err := db.UpdateSession(session.ID, &SessionUpdate{EndsAt: &later}); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+	got, err := db.GetSessionByID(session.ID)
+	if err != nil || got == nil {
+		t.Fatalf("GetSessionByID = %v, %v", got, err)
+	}
+	if !got.EndsAt.Equal(later) || !got.StartsAt.Equal(startsAt) || got.CapacityOverride == nil || *got.CapacityOverride != 3 {
+		t.Errorf("updated session = %+v, want only ends_at changed", got)
+	}
+
+	registerTestParticipants(t, db, programID, &session.ID, 4) // the fourth is waitlisted
+	if count, err := db.CountSessionConfirmedRegistrations(session.ID); err != nil || count != 3 {
+		t.Errorf("CountSessionConfirmedRegistrations = %d, %v; want 3", count, err)
+	}
+
+	if err := db.DeactivateSession(session.ID); err != nil {
+		t.Fatalf("DeactivateSession: %v", err)
+	}
+	if got, err := db.GetSessionByID(session.ID); err != nil || got == nil || got.IsActive {
+		t.Errorf("deactivated session = %+v, %v; want inactive", got, err)
+	}
+	if count, err := db.CountSessionConfirmedRegistrations(session.ID); err != nil || count != 3 {
+		t.Errorf("CountSessionConfirmedRegistrations after deactivation = %d, %v; want 3 kept", count, err)
+	}
+}
+
+// TestEventSessions tests an event's sessions are returned with their own capacity, and
+// registering for one counts against that session only, not the event's own spots
+func TestEventSessions(t *testing.T) {
+	db := setupTestDB(t)
+
+	slug := "test-event-" + uuid.New().String()
+	event, err := db.CreateEvent(&Event{Slug: slug, Title: "Test Market", Capacity: 2, IsActive: true})
+	if err != nil {
+		t.Fatalf("CreateEvent: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM notification_queue WHERE payload->>'parent_id' = $1`, event.ID.String())
+		db.Exec(`DELETE FROM waitlist_positions WHERE parent_id = $1`, event.ID)
+		db.Exec(`DELETE FROM registrations WHERE parent_id = $1`, event.ID)
+		db.Exec(`DELETE FROM sessions WHERE parent_id = $1`, event.ID)
+		db.Exec(`DELETE FROM events WHERE id = $1`, event.ID)
+	})
+
+	week := 7 * 24 * time.Hour
+	var sessionIDs []uuid.UUID
+	for i := 1; i <= 2; i++ {
+		startsAt := time.Now().Add(time.Duration(i) * week)
+		endsAt := startsAt.Add(3 * time.Hour)
+		session, err := db.CreateSession(&Session{ParentType: "event", ParentID: event.ID, StartsAt: &startsAt, EndsAt: &endsAt, IsActive: true})
+		if err != nil {
+			t.Fatalf("CreateSession: %v", err)
+		}
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err := db.CreateRegistration(RegistrationRequest{
+			ParentType:    "event",
+			ParentID:      event.ID,
+			SessionID:     &sessionIDs[0],
+			ParticipantID: createTestParticipant(t, db),
+		})
+		if err != nil {
+			t.Fatalf("failed to register for event session: %v", err)
+		}
+	}
+
+	// A registration for the event as a whole counts against the event's own spots
+	_, err = db.CreateRegistration(RegistrationRequest{
+		ParentType:    "event",
+		ParentID:      event.ID,
+		ParticipantID: createTestParticipant(t, db),
+	})
+	if err != nil {
+		t.Fatalf("failed to register for event: %v", err)
+	}
+
+	got, err := db.GetEventBySlug(slug)
+	if err != nil || got == nil {
+		t.Fatalf("GetEventBySlug = %v, %v", got, err)
+	}
+	if got.SpotsLeft == nil || *got.SpotsLeft != 1 || got.WaitlistCount == nil || *got.WaitlistCount != 0 {
+		t.Errorf("event spots left = %v, waitlist = %v; want 1 and 0", got.SpotsLeft, got.WaitlistCount)
+	}
+	if len(got.Sessions) != 2 || got.Sessions[0].ID != sessionIDs[0] {
+		t.Fatalf("sessions = %+v, want both in start order", got.Sessions)
+	}
+	if left := got.Sessions[0].SpotsLeft; left == nil || *left != 0 || *got.Sessions[0].WaitlistCount != 1 {
+		t.Errorf("first session spots left = %v, waitlist = %v; want 0 and 1", left, got.Sessions[0].WaitlistCount)
+	}
+	if left := got.Sessions[1].SpotsLeft; left == nil || *left != 2 {
+		t.Errorf("second session spots left = %v, want 2", left)
+	}
+}
+
+// TestReconcileSessionCapacity tests raising a session's capacity_override promotes from
+// its waitlist, and lowering it with demotion moves the overflow back onto the waitlist
+func TestReconcileSessionCapacity(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 10)
+	override := 2
+	sessionID := createTestSession(t, db, programID, &override)
+	results := registerTestParticipants(t, db, programID, &sessionID, 4)
+
+	raised := 3
+	if err := db.UpdateSession(sessionID, &SessionUpdate{CapacityOverride: &raised}); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+	session, err := db.GetSessionByID(sessionID)
+	if err != nil || session == nil {
+		t.Fatalf("GetSessionByID = %v, %v", session, err)
+	}
+	promoted, demoted, err := db.ReconcileSessionCapacity(session, false, nil)
+	if err != nil {
+		t.Fatalf("ReconcileSessionCapacity: %v", err)
+	}
+	if len(promoted) != 1 || promoted[0].ParticipantID != results[2].Registration.ParticipantID || len(demoted) != 0 {
+		t.Errorf("promoted %+v, demoted %+v; want the first waitlisted promoted", promoted, demoted)
+	}
+
+	lowered := 1
+	if err := db.UpdateSession(sessionID, &SessionUpdate{CapacityOverride: &lowered}); err != nil {
+		t.Fatalf("UpdateSession: %v", err)
+	}
+	promoted, demoted, err = db.ReconcileSessionCapacity(session, true, nil)
+	if err != nil {
+		t.Fatalf("ReconcileSessionCapacity with demotion: %v", err)
+	}
+	if len(demoted) != 2 || len(promoted) != 0 {
+		t.Errorf("demoted %d, promoted %d; want 2 and 0", len(demoted), len(promoted))
+	}
+	if n := countConfirmed(t, db, programID, &sessionID); n != 1 {
+		t.Errorf("confirmed = %d, want 1", n)
+	}
+
+	// Clearing the override falls back to the program's capacity of 10
+	if err := db.UpdateSession(sessionID, &SessionUpdate{ClearCapacityOverride: true}); err != nil {
+		t.Fatalf("UpdateSession clearing the override: %v", err)
+	}
+	if got, err := db.GetSessionByID(sessionID); err != nil || got == nil || got.CapacityOverride != nil {
+		t.Fatalf("session after clearing = %+v, %v; want no capacity_override", got, err)
+	}
+	promoted, _, err = db.ReconcileSessionCapacity(session, false, nil)
+	if err != nil {
+		t.Fatalf("ReconcileSessionCapacity after clearing: %v", err)
+	}
+	if len(promoted) != 3 {
+		t.Errorf("promoted %d after clearing the override, want 3", len(promoted))
+	}
+	if n := countConfirmed(t, db, programID, &sessionID); n != 4 {
+		t.Errorf("confirmed = %d, want 4", n)
+	}
+}
+
+// TestRegisterUnavailableSession tests registering into an inactive session or one of
+// another program is refused
+func TestRegisterUnavailableSession(t *testing.T) {
+	db := setupTestDB(t)
+	programID := createTestProgram(t, db, 5)
+	otherProgramID := createTestProgram(t, db, 5)
+	inactiveID := createTestSession(t, db, programID, nil)
+	if err := db.DeactivateSession(inactiveID); err != nil {
+		t.Fatalf("DeactivateSession: %v", err)
+	}
+	otherID := createTestSession(t, db, otherProgramID, nil)
+
+	for name, sessionID := range map[string]uuid.UUID{"inactive": inactiveID, "other program": otherID, "missing": uuid.New()} {
+		_, err := db.CreateRegistration(RegistrationRequest{
+			ParentType:    "program",
+			ParentID:      programID,
+			SessionID:     &sessionID,
+			ParticipantID: createTestParticipant(t, db),
+		})
+		if !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("%s session: err = %v, want ErrSessionNotFound", name, err)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"sort"
+	"time"
+)
+
+// busyPeriod is a closure, or a booking widened by the facility's buffer, that slots
+// may not overlap
+type busyPeriod struct {
+	start, end time.Time
+	booking    *FacilityBooking // nil for a closure
+}
+
+// busyIndex keeps busy periods sorted by start so the ones overlapping a slot are found
+// by binary search rather than by scanning every period for every slot
+type busyIndex struct {
+	periods []busyPeriod
+	longest time.Duration
+}
+
+func newBusyIndex(periods []busyPeriod) *busyIndex {
+	sort.Slice(periods, func(i, j int) bool {
+		return periods[i].start.Before(periods[j].start)
+	})
+	idx := &busyIndex{periods: periods}
+	for _, p := range periods {
+		if d := p.end.Sub(p.start); d > idx.longest {
+			idx.longest = d
+		}
+	}
+	return idx
+}
+
+// each calls fn with every period overlapping the time range, in start order, until fn
+// returns false
+func (idx *busyIndex) each(start, end time.Time, fn func(busyPeriod) bool) {
+	// A period starting more than the longest period before the range ends before it
+	earliest := start.Add(-idx.longest)
+	first := sort.Search(len(idx.periods), func(i int) bool {
+		return !idx.periods[i].start.Before(earliest)
+	})
+	for _, p := range idx.periods[first:] {
+		if !p.start.Before(end) {
+			return
+		}
+		if p.end.After(start) && !fn(p) {
+			return
+		}
+	}
+}
+
+// overlaps reports whether any period overlaps the time range
+func (idx *busyIndex) overlaps(start, end time.Time) bool {
+	found := false
+	idx.each(start, end, func(busyPeriod) bool {
+		found = true
+		return false
+	})
+	return found
+}
+
+// bookings returns the bookings whose periods overlap the time range
+func (idx *busyIndex) bookings(start, end time.Time) []FacilityBooking {
+	var bookings []FacilityBooking
+	idx.each(start, end, func(p busyPeriod) bool {
+		bookings = append(bookings, *p.booking)
+		return true
+	})
+	return bookings
+}
+
+// slotChecker decides which of a facility's candidate slots can be booked, given its
+// closures, bookings holding their slot and units over the range the slots cover
+type slotChecker struct {
+	closures      *busyIndex
+	bookings      *busyIndex
+	units         []FacilityUnit
+	bufferMinutes int
+	limit         int
+}
+
+func newSlotChecker(facility *Facility, closures []FacilityClosure, bookings []FacilityBooking, units []FacilityUnit) *slotChecker {
+	closurePeriods := make([]busyPeriod, 0, len(closures))
+	for _, c := range closures {
+		closurePeriods = append(closurePeriods, busyPeriod{start: c.StartTime, end: c.EndTime})
+	}
+
+	buffer := time.Duration(facility.BufferMinutes) * time.Minute
+	bookingPeriods := make([]busyPeriod, 0, len(bookings))
+	for i := range bookings {
+		b := &bookings[i]
+		bookingPeriods = append(bookingPeriods, busyPeriod{start: b.StartTime.Add(-buffer), end: b.EndTime.Add(buffer), booking: b})
+	}
+
+	return &slotChecker{
+		closures:      newBusyIndex(closurePeriods),
+		bookings:      newBusyIndex(bookingPeriods),
+		units:         units,
+		bufferMinutes: facility.BufferMinutes,
+		limit:         facility.ConcurrentBookingLimit(),
+	}
+}
+
+// open reports whether a slot can be booked. For a facility split into units it sets
+// the slot's FreeUnits and is open while at least one unit is free; a shared facility is
+// open while it has fewer concurrent bookings than its capacity.
+func (sc *slotChecker) open(slot *AvailabilitySlot) bool {
+	if sc.closures.overlaps(slot.StartTime, slot.EndTime) {
+		return false
+	}
+	if len(sc.units) == 0 && sc.limit <= 1 {
+		return !sc.bookings.overlaps(slot.StartTime, slot.EndTime)
+	}
+
+	overlapping := sc.bookings.bookings(slot.StartTime, slot.EndTime)
+	if len(sc.units) == 0 {
+		return peakConcurrentBookings(overlapping, slot.StartTime, slot.EndTime, sc.bufferMinutes) < sc.limit
+	}
+	free := len(FreeUnits(sc.units, overlapping, slot.StartTime, slot.EndTime, sc.bufferMinutes))
+	slot.FreeUnits = &free
+	return free > 0
+}
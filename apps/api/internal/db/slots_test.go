@@ -0,0 +1,140 @@
+package db
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// scanOpenSlot is the straightforward check the slot checker replaces: every closure and
+// booking is compared with the slot. It is kept as the reference the checker must agree
+// with and as the baseline for BenchmarkOpenSlots.
+func scanOpenSlot(slot *AvailabilitySlot, facility *Facility, closures []FacilityClosure, bookings []FacilityBooking, units []FacilityUnit) bool {
+	for _, closure := range closures {
+		if slot.StartTime.Before(closure.EndTime) && slot.EndTime.After(closure.StartTime) {
+			return false
+		}
+	}
+
+	buffer := time.Duration(facility.BufferMinutes) * time.Minute
+	if len(units) == 0 && facility.ConcurrentBookingLimit() <= 1 {
+		for _, b := range bookings {
+			if slot.StartTime.Before(b.EndTime.Add(buffer)) && slot.EndTime.After(b.StartTime.Add(-buffer)) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(units) == 0 {
+		return peakConcurrentBookings(bookings, slot.StartTime, slot.EndTime, facility.BufferMinutes) < facility.ConcurrentBookingLimit()
+	}
+	free := len(FreeUnits(units, bookings, slot.StartTime, slot.EndTime, facility.BufferMinutes))
+	slot.FreeUnits = &free
+	return free > 0
+}
+
+// slotTestCalendar builds a month of 15-minute-increment slots from 6am to 10pm every
+// day, with the given number of random bookings and a few closures across it
+func slotTestCalendar(facility *Facility, bookingCount int, units []FacilityUnit, seed int64) ([]AvailabilitySlot, []FacilityClosure, []FacilityBooking) {
+	var windows []AvailabilityWindow
+	for day := 0; day < 7; day++ {
+		windows = append(windows, AvailabilityWindow{DayOfWeek: day, StartTime: "06:00:00", EndTime: "22:00:00", Audience: AudiencePublic})
+	}
+
+	first := time.Now().AddDate(0, 0, 1).Truncate(24 * time.Hour)
+	var slots []AvailabilitySlot
+	for day := 0; day < 30; day++ {
+		slots = append(slots, windowSlotsForDay(facility, windows, first.AddDate(0, 0, day), 60)...)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	bookings := make([]FacilityBooking, 0, bookingCount)
+	for i := 0; i < bookingCount; i++ {
+		start := first.AddDate(0, 0, rng.Intn(30)).Add(time.Duration(6*60+15*rng.Intn(60)) * time.Minute)
+		b := FacilityBooking{StartTime: start, EndTime: start.Add(time.Duration(30+15*rng.Intn(8)) * time.Minute)}
+		if len(units) > 0 && rng.Intn(10) > 0 {
+			b.UnitID = &units[rng.Intn(len(units))].ID
+		}
+		bookings = append(bookings, b)
+	}
+
+	closures := []FacilityClosure{
+		{StartTime: first.AddDate(0, 0, 3), EndTime: first.AddDate(0, 0, 4)},
+		{StartTime: first.AddDate(0, 0, 10).Add(12 * time.Hour), EndTime: first.AddDate(0, 0, 10).Add(14 * time.Hour)},
+		{StartTime: first.AddDate(0, 0, 20), EndTime: first.AddDate(0, 0, 27)},
+	}
+	return slots, closures, bookings
+}
+
+// TestSlotCheckerMatchesScan checks the slot checker opens exactly the slots a full scan
+// of closures and bookings would, including buffers, shared capacity and units
+func TestSlotCheckerMatchesScan(t *testing.T) {
+	courts := []FacilityUnit{{ID: uuid.New(), Name: "Court 1"}, {ID: uuid.New(), Name: "Court 2"}}
+	tests := []struct {
+		name     string
+		facility Facility
+		units    []FacilityUnit
+	}{
+		{"single booking", Facility{MinBookingDurationMinutes: 15, AdvanceBookingDays: 60}, nil},
+		{"with buffer", Facility{MinBookingDurationMinutes: 15, AdvanceBookingDays: 60, BufferMinutes: 15}, nil},
+		{"shared capacity", Facility{MinBookingDurationMinutes: 15, AdvanceBookingDays: 60, MaxConcurrentBookings: 3}, nil},
+		{"units", Facility{MinBookingDurationMinutes: 15, AdvanceBookingDays: 60, BufferMinutes: 10}, courts},
+	}
+	for i, tt := range tests {
+		slots, closures, bookings := slotTestCalendar(&tt.facility, 200, tt.units, int64(i))
+		checker := newSlotChecker(&tt.facility, closures, bookings, tt.units)
+
+		open := 0
+		for _, slot := range slots {
+			indexed, scanned := slot, slot
+			got := checker.open(&indexed)
+			want := scanOpenSlot(&scanned, &tt.facility, closures, bookings, tt.units)
+			if got != want {
+				t.Fatalf("%s: slot %v-%v open = %v, want %v", tt.name, slot.StartTime, slot.EndTime, got, want)
+			}
+			if (indexed.FreeUnits == nil) != (scanned.FreeUnits == nil) || (indexed.FreeUnits != nil && *indexed.FreeUnits != *scanned.FreeUnits) {
+				t.Fatalf("%s: slot %v free units = %v, want %v", tt.name, slot.StartTime, indexed.FreeUnits, scanned.FreeUnits)
+			}
+			if got {
+				open++
+			}
+		}
+		if open == 0 || open == len(slots) {
+			t.Errorf("%s: %d of %d slots open, want a mix", tt.name, open, len(slots))
+		}
+	}
+}
+
+// BenchmarkOpenSlots compares filtering a month of 15-minute slots on a facility with 200
+// bookings through the slot checker and by scanning every booking for every slot
+func BenchmarkOpenSlots(b *testing.B) {
+	facility := &Facility{MinBookingDurationMinutes: 15, AdvanceBookingDays: 60, BufferMinutes: 15}
+	slots, closures, bookings := slotTestCalendar(facility, 200, nil, 1)
+
+	b.Run("checker", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			checker := newSlotChecker(facility, closures, bookings, nil)
+			var open []AvailabilitySlot
+			for _, slot := range slots {
+				if checker.open(&slot) {
+					open = append(open, slot)
+				}
+			}
+		}
+	})
+
+	b.Run("scan", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var open []AvailabilitySlot
+			for _, slot := range slots {
+				if scanOpenSlot(&slot, facility, closures, bookings, nil) {
+					open = append(open, slot)
+				}
+			}
+		}
+	})
+}
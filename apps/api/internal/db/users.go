@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -19,9 +20,9 @@ func (db *DB) CreateUser(email, password, firstName, lastName string, phone *str
 	err = db.QueryRow(`
 		INSERT INTO users (email, password_hash, first_name, last_name, phone)
 		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, email, first_name, last_name, phone, role, created_at
+		RETURNING id, email, first_name, last_name, phone, role, is_member, advance_booking_exempt, created_at
 	`, email, string(hash), firstName, lastName, phone).Scan(
-		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.CreatedAt,
+		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.IsMember, &user.AdvanceBookingExempt, &user.CreatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
@@ -43,11 +44,11 @@ func (db *DB) CreateUser(email, password, firstName, lastName string, phone *str
 func (db *DB) GetUserByEmail(email string) (*User, error) {
 	var user User
 	err := db.QueryRow(`
-		SELECT id, email, password_hash, first_name, last_name, phone, role, created_at
+		SELECT id, email, password_hash, first_name, last_name, phone, role, is_member, advance_booking_exempt, created_at
 		FROM users
 		WHERE email = $1
 	`, email).Scan(
-		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.CreatedAt,
+		&user.ID, &user.Email, &user.PasswordHash, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.IsMember, &user.AdvanceBookingExempt, &user.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -62,11 +63,11 @@ func (db *DB) GetUserByEmail(email string) (*User, error) {
 func (db *DB) GetUserByID(id uuid.UUID) (*User, error) {
 	var user User
 	err := db.QueryRow(`
-		SELECT id, email, first_name, last_name, phone, role, created_at
+		SELECT id, email, first_name, last_name, phone, role, is_member, advance_booking_exempt, created_at
 		FROM users
 		WHERE id = $1
 	`, id).Scan(
-		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.CreatedAt,
+		&user.ID, &user.Email, &user.FirstName, &user.LastName, &user.Phone, &user.Role, &user.IsMember, &user.AdvanceBookingExempt, &user.CreatedAt,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -77,21 +78,94 @@ func (db *DB) GetUserByID(id uuid.UUID) (*User, error) {
 	return &user, nil
 }
 
+// SetUserMembership sets whether a user may book members-only availability windows
+func (db *DB) SetUserMembership(id uuid.UUID, isMember bool) error {
+	result, err := db.Exec(`UPDATE users SET is_member = $2 WHERE id = $1`, id, isMember)
+	if err != nil {
+		return fmt.Errorf("failed to update membership: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+// GetUserAudience returns the booking audience of a user: staff for admins, members for
+// members, otherwise public
+func (db *DB) GetUserAudience(id uuid.UUID) (string, error) {
+	user, err := db.GetUserByID(id)
+	if err != nil {
+		return "", err
+	}
+	if user == nil {
+		return "", fmt.Errorf("user not found")
+	}
+	return user.Audience(), nil
+}
+
+// Audience returns the user's booking audience: staff for admins, members for members,
+// otherwise public
+func (u *User) Audience() string {
+	switch {
+	case u.Role == "admin":
+		return AudienceStaff
+	case u.IsMember:
+		return AudienceMembers
+	default:
+		return AudiencePublic
+	}
+}
+
+// ExemptFromAdvanceLimit reports whether the user may book beyond a facility's advance
+// booking limit. Admins always may.
+func (u *User) ExemptFromAdvanceLimit() bool {
+	return u.Role == "admin" || u.AdvanceBookingExempt
+}
+
+// SetUserAdvanceBookingExempt sets whether a user may book beyond advance booking limits
+func (db *DB) SetUserAdvanceBookingExempt(id uuid.UUID, exempt bool) error {
+	result, err := db.Exec(`UPDATE users SET advance_booking_exempt = $2 WHERE id = $1`, id, exempt)
+	if err != nil {
+		return fmt.Errorf("failed to update advance booking exemption: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
 // CheckPassword verifies a password against the stored hash
 func (db *DB) CheckPassword(user *User, password string) bool {
 	err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
 	return err == nil
 }
 
-// GetUserHousehold retrieves the user's household
+// GetUserHousehold retrieves the household the user owns or, failing that, is a member of
 func (db *DB) GetUserHousehold(userID uuid.UUID) (*Household, error) {
 	var h Household
 	err := db.QueryRow(`
-		SELECT id, owner_user_id, name, phone, email, address_line1, city, state, zip, created_at
+		SELECT id, owner_user_id, name, phone, email, address_line1, city, state, zip, created_at,
+		       default_emergency_contact_name, default_emergency_contact_phone
 		FROM households
-		WHERE owner_user_id = $1
+		WHERE deleted_at IS NULL
+			AND (owner_user_id = $1 OR id IN (SELECT household_id FROM household_members WHERE user_id = $1))
+		ORDER BY owner_user_id = $1 DESC
+		LIMIT 1
 	`, userID).Scan(
 		&h.ID, &h.OwnerUserID, &h.Name, &h.Phone, &h.Email, &h.AddressLine1, &h.City, &h.State, &h.Zip, &h.CreatedAt,
+		&h.DefaultEmergencyContactName, &h.DefaultEmergencyContactPhone,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -102,15 +176,71 @@ func (db *DB) GetUserHousehold(userID uuid.UUID) (*Household, error) {
 	return &h, nil
 }
 
+// EnsureUserHousehold returns the user's household, creating one owned by the user if they
+// have none (e.g. it was never created or has been deleted). The user row is locked while
+// checking so concurrent requests create at most one household.
+func (db *DB) EnsureUserHousehold(userID uuid.UUID) (*Household, error) {
+	household, err := db.GetUserHousehold(userID)
+	if err != nil || household != nil {
+		return household, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var email string
+	err = tx.QueryRow("SELECT email FROM users WHERE id = $1 FOR UPDATE", userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	// Another request may have created it while we waited for the lock
+	var exists bool
+	err = tx.QueryRow(`
+		SELECT EXISTS(
+			SELECT 1 FROM households
+			WHERE deleted_at IS NULL
+				AND (owner_user_id = $1 OR id IN (SELECT household_id FROM household_members WHERE user_id = $1))
+		)
+	`, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check household: %w", err)
+	}
+
+	if !exists {
+		_, err = tx.Exec(`
+			INSERT INTO households (owner_user_id, email)
+			VALUES ($1, $2)
+		`, userID, email)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create household: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return db.GetUserHousehold(userID)
+}
+
 // GetHouseholdByID retrieves a household by ID
 func (db *DB) GetHouseholdByID(householdID uuid.UUID) (*Household, error) {
 	var h Household
 	err := db.QueryRow(`
-		SELECT id, owner_user_id, name, phone, email, address_line1, city, state, zip, created_at
+		SELECT id, owner_user_id, name, phone, email, address_line1, city, state, zip, created_at,
+		       default_emergency_contact_name, default_emergency_contact_phone
 		FROM households
 		WHERE id = $1
 	`, householdID).Scan(
 		&h.ID, &h.OwnerUserID, &h.Name, &h.Phone, &h.Email, &h.AddressLine1, &h.City, &h.State, &h.Zip, &h.CreatedAt,
+		&h.DefaultEmergencyContactName, &h.DefaultEmergencyContactPhone,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -125,7 +255,8 @@ func (db *DB) GetHouseholdByID(householdID uuid.UUID) (*Household, error) {
 func (db *DB) GetHouseholdParticipants(householdID uuid.UUID) ([]Participant, error) {
 	rows, err := db.Query(`
 		SELECT id, household_id, first_name, last_name, dob, notes, medical_notes,
-		       emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, created_at
+		       emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, photo_url, created_at,
+		       dietary_restrictions, accessibility_needs
 		FROM participants
 		WHERE household_id = $1
 		ORDER BY is_favorite DESC, created_at ASC
@@ -140,7 +271,8 @@ func (db *DB) GetHouseholdParticipants(householdID uuid.UUID) ([]Participant, er
 		var p Participant
 		err := rows.Scan(
 			&p.ID, &p.HouseholdID, &p.FirstName, &p.LastName, &p.DOB, &p.Notes, &p.MedicalNotes,
-			&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.CreatedAt,
+			&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.PhotoURL, &p.CreatedAt,
+			pq.Array(&p.DietaryRestrictions), pq.Array(&p.AccessibilityNeeds),
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan participant: %w", err)
@@ -167,17 +299,45 @@ func (db *DB) CreateParticipant(householdID uuid.UUID, firstName, lastName strin
 	return &p, nil
 }
 
+// CreateParticipantFull creates a participant with all profile fields and
+// returns the stored record
+func (db *DB) CreateParticipantFull(p *Participant) (*Participant, error) {
+	err := db.QueryRow(`
+		INSERT INTO participants (
+			household_id, first_name, last_name, dob, notes, medical_notes,
+			emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, photo_url,
+			dietary_restrictions, accessibility_needs
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, COALESCE($13::text[], '{}'), COALESCE($14::text[], '{}'))
+		RETURNING id, household_id, first_name, last_name, dob, notes, medical_notes,
+		          emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, photo_url, created_at,
+		          dietary_restrictions, accessibility_needs
+	`, p.HouseholdID, p.FirstName, p.LastName, p.DOB, p.Notes, p.MedicalNotes,
+		p.EmergencyContactName, p.EmergencyContactPhone, p.IsFavorite, p.Gender, p.ShirtSize, p.PhotoURL,
+		pq.Array(p.DietaryRestrictions), pq.Array(p.AccessibilityNeeds)).Scan(
+		&p.ID, &p.HouseholdID, &p.FirstName, &p.LastName, &p.DOB, &p.Notes, &p.MedicalNotes,
+		&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.PhotoURL, &p.CreatedAt,
+		pq.Array(&p.DietaryRestrictions), pq.Array(&p.AccessibilityNeeds),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create participant: %w", err)
+	}
+	return p, nil
+}
+
 // GetParticipantByID retrieves a participant by ID
 func (db *DB) GetParticipantByID(id uuid.UUID) (*Participant, error) {
 	var p Participant
 	err := db.QueryRow(`
 		SELECT id, household_id, first_name, last_name, dob, notes, medical_notes,
-		       emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, created_at
+		       emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, photo_url, created_at,
+		       dietary_restrictions, accessibility_needs
 		FROM participants
 		WHERE id = $1
 	`, id).Scan(
 		&p.ID, &p.HouseholdID, &p.FirstName, &p.LastName, &p.DOB, &p.Notes, &p.MedicalNotes,
-		&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.CreatedAt,
+		&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.PhotoURL, &p.CreatedAt,
+		pq.Array(&p.DietaryRestrictions), pq.Array(&p.AccessibilityNeeds),
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
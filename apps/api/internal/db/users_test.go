@@ -0,0 +1,75 @@
+package db
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// TestEnsureUserHousehold tests household auto-creation for users without one
+func TestEnsureUserHousehold(t *testing.T) {
+	t.Run("should create exactly one household under concurrent requests", func(t *testing.T) {
+		db := setupTestDB(t)
+
+		var userID uuid.UUID
+		err := db.QueryRow(`
+			INSERT INTO users (email, password_hash, first_name, last_name)
+			VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+			RETURNING id
+		`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+		if err != nil {
+			t.Fatalf("failed to create test user: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+		})
+
+		const concurrent = 8
+		households := make([]*Household, concurrent)
+		errs := make([]error, concurrent)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrent; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				households[i], errs[i] = db.EnsureUserHousehold(userID)
+			}(i)
+		}
+		wg.Wait()
+
+		for i := range households {
+			if errs[i] != nil {
+				t.Fatalf("EnsureUserHousehold: %v", errs[i])
+			}
+			if households[i] == nil || households[i].ID != households[0].ID {
+				t.Fatalf("request %d returned household %v, want %s", i, households[i], households[0].ID)
+			}
+		}
+		if n := countRows(t, db, `SELECT COUNT(*) FROM households WHERE owner_user_id = $1`, userID); n != 1 {
+			t.Errorf("households for user = %d, want 1", n)
+		}
+	})
+
+	t.Run("should return the existing household", func(t *testing.T) {
+		db := setupTestDB(t)
+		participantID := createTestParticipant(t, db)
+
+		var householdID, userID uuid.UUID
+		err := db.QueryRow(`
+			SELECT h.id, h.owner_user_id FROM participants p JOIN households h ON h.id = p.household_id
+			WHERE p.id = $1
+		`, participantID).Scan(&householdID, &userID)
+		if err != nil {
+			t.Fatalf("failed to get test household: %v", err)
+		}
+
+		household, err := db.EnsureUserHousehold(userID)
+		if err != nil {
+			t.Fatalf("EnsureUserHousehold: %v", err)
+		}
+		if household.ID != householdID {
+			t.Errorf("household = %s, want existing %s", household.ID, householdID)
+		}
+	})
+}
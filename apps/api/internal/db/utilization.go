@@ -0,0 +1,147 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UtilizationPeriod is how much of the bookable time across active facilities was booked
+// between Start and End
+type UtilizationPeriod struct {
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end"`
+	BookedMinutes    float64   `json:"booked_minutes"`
+	AvailableMinutes float64   `json:"available_minutes"`
+}
+
+// Pct returns the booked share of the available minutes as a percentage rounded to one
+// decimal place, or 0 when nothing was available
+func (p UtilizationPeriod) Pct() float64 {
+	if p.AvailableMinutes <= 0 {
+		return 0
+	}
+	return math.Round(p.BookedMinutes/p.AvailableMinutes*1000) / 10
+}
+
+// GetFacilityUtilization measures facility utilization over the periods between
+// consecutive bounds, which must be in ascending order. Available minutes are the
+// availability window minutes of active, bookable facilities, counted once per active unit
+// or, without units, once per max_concurrent_bookings (never by capacity, which is a
+// headcount); booked minutes are confirmed bookings and no-shows.
+func (db *DB) GetFacilityUtilization(bounds ...time.Time) ([]UtilizationPeriod, error) {
+	if len(bounds) < 2 {
+		return []UtilizationPeriod{}, nil
+	}
+	periods := make([]UtilizationPeriod, len(bounds)-1)
+	for i := range periods {
+		periods[i] = UtilizationPeriod{Start: bounds[i], End: bounds[i+1]}
+	}
+	from, to := bounds[0], bounds[len(bounds)-1]
+
+	facilities, err := db.GetAllFacilities(true)
+	if err != nil {
+		return nil, err
+	}
+
+	bookings, err := db.getBookedIntervals(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, facility := range facilities {
+		if !facility.Bookable {
+			continue
+		}
+		windows, err := db.GetAvailabilityWindows(facility.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get availability windows: %w", err)
+		}
+		units, err := db.GetFacilityUnits(facility.ID, true)
+		if err != nil {
+			return nil, err
+		}
+		concurrent := facility.ConcurrentBookingLimit()
+		if len(units) > 0 {
+			concurrent = len(units)
+		}
+
+		loc := facility.TimeLocation()
+		for i := range periods {
+			p := &periods[i]
+			p.AvailableMinutes += windowMinutes(windows, p.Start, p.End, loc) * float64(concurrent)
+			p.BookedMinutes += bookedMinutes(bookings[facility.ID], p.Start, p.End)
+		}
+	}
+
+	return periods, nil
+}
+
+// getBookedIntervals returns the confirmed and no-show bookings overlapping from and to,
+// keyed by facility. Only the times are filled in.
+func (db *DB) getBookedIntervals(from, to time.Time) (map[uuid.UUID][]FacilityBooking, error) {
+	rows, err := db.Query(`
+		SELECT facility_id, start_time, end_time
+		FROM facility_bookings
+		WHERE status IN ('confirmed', 'no_show') AND start_time < $2 AND end_time > $1
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query booked intervals: %w", err)
+	}
+	defer rows.Close()
+
+	bookings := map[uuid.UUID][]FacilityBooking{}
+	for rows.Next() {
+		var b FacilityBooking
+		if err := rows.Scan(&b.FacilityID, &b.StartTime, &b.EndTime); err != nil {
+			return nil, fmt.Errorf("failed to scan booked interval: %w", err)
+		}
+		bookings[b.FacilityID] = append(bookings[b.FacilityID], b)
+	}
+	return bookings, rows.Err()
+}
+
+// windowMinutes returns the minutes between from and to covered by the windows in effect,
+// reading window times as wall-clock times in loc. Windows are assumed not to overlap,
+// as ValidateAvailabilityWindows ensures.
+func windowMinutes(windows []AvailabilityWindow, from, to time.Time, loc *time.Location) float64 {
+	var total time.Duration
+	for day := facilityDay(from.In(loc), loc); day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, window := range windows {
+			if window.DayOfWeek != int(day.Weekday()) || !windowInEffect(window, day) {
+				continue
+			}
+			start, end, err := window.Bounds(day)
+			if err != nil {
+				continue
+			}
+			total += overlap(start, end, from, to)
+		}
+	}
+	return total.Minutes()
+}
+
+// bookedMinutes returns the minutes of the bookings that fall between from and to
+func bookedMinutes(bookings []FacilityBooking, from, to time.Time) float64 {
+	var total time.Duration
+	for _, b := range bookings {
+		total += overlap(b.StartTime, b.EndTime, from, to)
+	}
+	return total.Minutes()
+}
+
+// overlap returns how long the ranges start-end and from-to have in common
+func overlap(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
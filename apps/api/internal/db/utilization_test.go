@@ -0,0 +1,144 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TestWindowMinutes tests window minutes are clipped to the range and read in the
+// facility's time zone
+func TestWindowMinutes(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	weekdays := []AvailabilityWindow{}
+	for day := 1; day <= 5; day++ {
+		weekdays = append(weekdays, AvailabilityWindow{DayOfWeek: day, StartTime: "09:00:00", EndTime: "17:00:00"})
+	}
+	monday := time.Date(2030, time.January, 7, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		loc      *time.Location
+		want     float64
+	}{
+		{"whole week", monday, monday.AddDate(0, 0, 7), time.UTC, 5 * 480},
+		{"part of a window", monday.Add(16 * time.Hour), monday.Add(20 * time.Hour), time.UTC, 60},
+		{"weekend", monday.AddDate(0, 0, 5), monday.AddDate(0, 0, 7), time.UTC, 0},
+		// Monday 09:00-17:00 in New York is 14:00-22:00 UTC
+		{"facility time zone", monday, monday.Add(15 * time.Hour), ny, 60},
+	}
+
+	for _, tt := range tests {
+		if got := windowMinutes(weekdays, tt.from, tt.to, tt.loc); got != tt.want {
+			t.Errorf("%s: windowMinutes = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestUtilizationPeriodPct tests the percentage is rounded and zero without availability
+func TestUtilizationPeriodPct(t *testing.T) {
+	if got := (UtilizationPeriod{BookedMinutes: 300, AvailableMinutes: 2880}).Pct(); got != 10.4 {
+		t.Errorf("Pct = %v, want 10.4", got)
+	}
+	if got := (UtilizationPeriod{BookedMinutes: 60}).Pct(); got != 0 {
+		t.Errorf("Pct without availability = %v, want 0", got)
+	}
+}
+
+// TestGetFacilityUtilization tests seeded bookings against a shared facility's windows.
+// Other facilities in the database count too, so only what this facility adds is checked.
+func TestGetFacilityUtilization(t *testing.T) {
+	db := setupTestDB(t)
+
+	monday := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+	thursday := monday.AddDate(0, 0, 3)
+	nextMonday := monday.AddDate(0, 0, 7)
+
+	before, err := db.GetFacilityUtilization(monday, thursday, nextMonday)
+	if err != nil {
+		t.Fatalf("GetFacilityUtilization: %v", err)
+	}
+
+	var userID, facilityID uuid.UUID
+	err = db.QueryRow(`
+		INSERT INTO users (email, password_hash, first_name, last_name)
+		VALUES ($1, 'not-a-real-hash', 'Test', 'Parent')
+		RETURNING id
+	`, "test-"+uuid.New().String()+"@example.com").Scan(&userID)
+	if err != nil {
+		t.Fatalf("failed to create test user: %v", err)
+	}
+	err = db.QueryRow(`
+		INSERT INTO facilities (slug, name, facility_type, capacity, max_concurrent_bookings)
+		VALUES ($1, 'Test Pavilion', 'pavilion', 40, 2)
+		RETURNING id
+	`, "test-facility-"+uuid.New().String()).Scan(&facilityID)
+	if err != nil {
+		t.Fatalf("failed to create test facility: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM facility_bookings WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM availability_windows WHERE facility_id = $1`, facilityID)
+		db.Exec(`DELETE FROM facilities WHERE id = $1`, facilityID)
+		db.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	})
+
+	// Open 09:00-17:00 on weekdays, holding two bookings at a time; the capacity of 40
+	// people does not multiply the available minutes
+	for day := 1; day <= 5; day++ {
+		_, err := db.Exec(`
+			INSERT INTO availability_windows (facility_id, day_of_week, start_time, end_time)
+			VALUES ($1, $2, '09:00', '17:00')
+		`, facilityID, day)
+		if err != nil {
+			t.Fatalf("failed to create availability window: %v", err)
+		}
+	}
+
+	book := func(start time.Time, hours int, status string) {
+		_, err := db.Exec(`
+			INSERT INTO facility_bookings (facility_id, user_id, start_time, end_time, status)
+			VALUES ($1, $2, $3, $4, $5)
+		`, facilityID, userID, start, start.Add(time.Duration(hours)*time.Hour), status)
+		if err != nil {
+			t.Fatalf("failed to create test booking: %v", err)
+		}
+	}
+	book(monday.Add(9*time.Hour), 2, "confirmed")
+	book(monday.Add(9*time.Hour), 2, "confirmed")
+	book(monday.AddDate(0, 0, 2).Add(13*time.Hour), 1, "no_show")
+	book(thursday.Add(9*time.Hour), 8, "cancelled")
+	book(thursday.Add(9*time.Hour), 8, "pending")
+	// Only the hour before midnight falls inside the week
+	book(nextMonday.Add(-time.Hour), 2, "confirmed")
+
+	after, err := db.GetFacilityUtilization(monday, thursday, nextMonday)
+	if err != nil {
+		t.Fatalf("GetFacilityUtilization: %v", err)
+	}
+
+	want := []struct {
+		booked, available, pct float64
+	}{
+		{300, 3 * 480 * 2, 10.4}, // Monday to Wednesday
+		{60, 2 * 480 * 2, 3.1},   // Thursday to Sunday
+	}
+	for i, w := range want {
+		added := UtilizationPeriod{
+			BookedMinutes:    after[i].BookedMinutes - before[i].BookedMinutes,
+			AvailableMinutes: after[i].AvailableMinutes - before[i].AvailableMinutes,
+		}
+		if added.BookedMinutes != w.booked || added.AvailableMinutes != w.available {
+			t.Errorf("period %d: added %v booked of %v available minutes, want %v of %v",
+				i, added.BookedMinutes, added.AvailableMinutes, w.booked, w.available)
+		}
+		if got := added.Pct(); got != w.pct {
+			t.Errorf("period %d: Pct = %v, want %v", i, got, w.pct)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultWaitlistOfferWindow is how long a family has to accept a spot offered from the
+// waitlist before it goes to the next person
+const DefaultWaitlistOfferWindow = 48 * time.Hour
+
+// waitlistOfferWindowFromEnv reads WAITLIST_OFFER_HOURS. 0 turns offers off, so a spot
+// opening up confirms the next waitlisted registration straight away; unset or invalid
+// values keep the default.
+func waitlistOfferWindowFromEnv() time.Duration {
+	if n, err := strconv.Atoi(os.Getenv("WAITLIST_OFFER_HOURS")); err == nil && n >= 0 {
+		return time.Duration(n) * time.Hour
+	}
+	return DefaultWaitlistOfferWindow
+}
+
+// WaitlistOfferWindow returns how long a family has to accept a spot offered from the
+// waitlist, or 0 when promotions are confirmed straight away
+func (db *DB) WaitlistOfferWindow() time.Duration {
+	return db.offerWindow
+}
+
+// AcceptWaitlistOffer confirms a registration that was offered a spot from the waitlist
+// and queues its confirmation email. It reports false if the registration has no open
+// offer, including one whose deadline has passed.
+func (db *DB) AcceptWaitlistOffer(registrationID uuid.UUID, acceptedBy *uuid.UUID) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var req RegistrationRequest
+	err = tx.QueryRow(`
+		UPDATE registrations
+		SET status = 'confirmed', offer_expires_at = NULL
+		WHERE id = $1 AND status = 'offered' AND offer_expires_at > NOW()
+		RETURNING parent_type, parent_id, session_id, participant_id
+	`, registrationID).Scan(&req.ParentType, &req.ParentID, &req.SessionID, &req.ParticipantID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to accept waitlist offer: %w", err)
+	}
+
+	offered := "offered"
+	reason := "Accepted waitlist offer"
+	if err := recordStatusChangeInTx(tx, registrationID, &offered, "confirmed", acceptedBy, nil, &reason); err != nil {
+		return false, err
+	}
+	if err := db.queueNotificationInTx(tx, "confirmed", req, nil, nil); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// DeclineWaitlistOffer cancels a registration that was offered a spot from the waitlist
+// and offers the spot to the next person. It reports false if the registration has no
+// open offer.
+func (db *DB) DeclineWaitlistOffer(registrationID uuid.UUID, declinedBy *uuid.UUID) (bool, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var reg Registration
+	err = tx.QueryRow(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status
+		FROM registrations
+		WHERE id = $1 AND status = 'offered'
+		FOR UPDATE
+	`, registrationID).Scan(
+		&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status,
+	)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to get registration: %w", err)
+	}
+
+	reason := "Declined waitlist offer"
+	if err := db.cancelRegistrationInTx(tx, &reg, declinedBy, nil, &reason); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return true, nil
+}
+
+// ExpireWaitlistOffers cancels offered registrations whose deadline has passed, offering
+// each spot to the next person on the waitlist, and returns how many it cancelled
+func (db *DB) ExpireWaitlistOffers() (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`
+		SELECT id, parent_type, parent_id, session_id, participant_id, status
+		FROM registrations
+		WHERE status = 'offered' AND offer_expires_at <= NOW()
+		ORDER BY offer_expires_at
+		FOR UPDATE SKIP LOCKED
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired waitlist offers: %w", err)
+	}
+	var expired []Registration
+	for rows.Next() {
+		var reg Registration
+		if err := rows.Scan(&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan waitlist offer: %w", err)
+		}
+		expired = append(expired, reg)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read waitlist offers: %w", err)
+	}
+
+	reason := "Waitlist offer expired"
+	for i := range expired {
+		if err := db.cancelRegistrationInTx(tx, &expired[i], nil, nil, &reason); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return len(expired), nil
+}
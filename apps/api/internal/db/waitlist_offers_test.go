@@ -0,0 +1,102 @@
+package db
+
+import (
+	"testing"
+	"time"
+)
+
+// TestWaitlistOffers tests a spot opening up is offered to the next waitlisted
+// registration, which holds it until it is accepted, declined or expires
+func TestWaitlistOffers(t *testing.T) {
+	t.Run("should offer the spot and confirm it when accepted", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.offerWindow = 48 * time.Hour
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		offered := waitlisted[0].Registration
+		if status := registrationStatus(t, db, offered.ID); status != "offered" {
+			t.Fatalf("first waitlisted status = %q, want offered", status)
+		}
+		if n := countNotifications(t, db, "WAITLIST_OFFER", offered.ParticipantID); n != 1 {
+			t.Errorf("WAITLIST_OFFER notifications = %d, want 1", n)
+		}
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "waitlisted" {
+			t.Errorf("second waitlisted status = %q, want waitlisted while the spot is offered", status)
+		}
+		if result := registerTestParticipant(t, db, programID, nil); result.Registration.Status != "waitlisted" {
+			t.Errorf("new registration status = %q, want waitlisted while the spot is offered", result.Registration.Status)
+		}
+
+		if accepted, err := db.AcceptWaitlistOffer(offered.ID, nil); err != nil || !accepted {
+			t.Fatalf("AcceptWaitlistOffer = %v, %v; want true", accepted, err)
+		}
+		if status := registrationStatus(t, db, offered.ID); status != "confirmed" {
+			t.Errorf("accepted status = %q, want confirmed", status)
+		}
+		if n := countNotifications(t, db, "CONFIRMATION", offered.ParticipantID); n != 1 {
+			t.Errorf("CONFIRMATION notifications = %d, want 1", n)
+		}
+		if accepted, err := db.AcceptWaitlistOffer(offered.ID, nil); err != nil || accepted {
+			t.Errorf("second AcceptWaitlistOffer = %v, %v; want false", accepted, err)
+		}
+	})
+
+	t.Run("should offer the spot to the next person when declined", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.offerWindow = 48 * time.Hour
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		if declined, err := db.DeclineWaitlistOffer(waitlisted[0].Registration.ID, nil); err != nil || !declined {
+			t.Fatalf("DeclineWaitlistOffer = %v, %v; want true", declined, err)
+		}
+		if status := registrationStatus(t, db, waitlisted[0].Registration.ID); status != "cancelled" {
+			t.Errorf("declined status = %q, want cancelled", status)
+		}
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "offered" {
+			t.Errorf("second waitlisted status = %q, want offered", status)
+		}
+		if declined, err := db.DeclineWaitlistOffer(confirmed[0].Registration.ID, nil); err != nil || declined {
+			t.Errorf("DeclineWaitlistOffer(cancelled) = %v, %v; want false", declined, err)
+		}
+	})
+
+	t.Run("should roll an expired offer to the next person", func(t *testing.T) {
+		db := setupTestDB(t)
+		db.offerWindow = 48 * time.Hour
+		programID := createTestProgram(t, db, 1)
+		confirmed := registerTestParticipants(t, db, programID, nil, 1)
+		waitlisted := registerTestParticipants(t, db, programID, nil, 2)
+
+		cancelTestRegistration(t, db, confirmed[0])
+
+		expired := waitlisted[0].Registration
+		if _, err := db.Exec(`UPDATE registrations SET offer_expires_at = NOW() - INTERVAL '1 minute' WHERE id = $1`, expired.ID); err != nil {
+			t.Fatalf("failed to expire offer: %v", err)
+		}
+		if accepted, err := db.AcceptWaitlistOffer(expired.ID, nil); err != nil || accepted {
+			t.Errorf("AcceptWaitlistOffer(expired) = %v, %v; want false", accepted, err)
+		}
+
+		count, err := db.ExpireWaitlistOffers()
+		if err != nil {
+			t.Fatalf("ExpireWaitlistOffers: %v", err)
+		}
+		if count < 1 {
+			t.Errorf("ExpireWaitlistOffers = %d, want at least 1", count)
+		}
+		if status := registrationStatus(t, db, expired.ID); status != "cancelled" {
+			t.Errorf("expired status = %q, want cancelled", status)
+		}
+		if status := registrationStatus(t, db, waitlisted[1].Registration.ID); status != "offered" {
+			t.Errorf("second waitlisted status = %q, want offered", status)
+		}
+	})
+}
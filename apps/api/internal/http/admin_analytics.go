@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"sterling-rec/api/internal/db"
+)
+
+// AdminGetCancellationAnalytics aggregates cancellations by reason code over a date range.
+// Defaults to the last 30 days.
+func (h *Handler) AdminGetCancellationAnalytics(c *gin.Context) {
+	to := time.Now()
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format (use RFC3339)"})
+			return
+		}
+		to = parsed
+	}
+
+	from := to.AddDate(0, 0, -30)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format (use RFC3339)"})
+			return
+		}
+		from = parsed
+	}
+
+	if !to.After(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+		return
+	}
+
+	report, err := h.db.GetCancellationReport(from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get cancellation analytics"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"report": report})
+}
+
+// AdminGetParticipationReport reports unique participants for a calendar year (default
+// the current year) by program category, age band and residency, as JSON or, with
+// ?format=csv, as a CSV download. Residents are households whose zip code is listed in
+// RESIDENT_ZIP_CODES (comma-separated).
+func (h *Handler) AdminGetParticipationReport(c *gin.Context) {
+	year := time.Now().Year()
+	if yearStr := c.Query("year"); yearStr != "" {
+		parsed, err := strconv.Atoi(yearStr)
+		if err != nil || parsed < 2000 || parsed > time.Now().Year()+1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		year = parsed
+	}
+
+	var residentZips []string
+	if zips := os.Getenv("RESIDENT_ZIP_CODES"); zips != "" {
+		residentZips = strings.Split(zips, ",")
+	}
+
+	report, err := h.db.GetParticipationReport(year, residentZips)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get participation report"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, gin.H{"report": report})
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=participation_%d.csv", year))
+
+	writer := csv.NewWriter(c.Writer)
+	defer writer.Flush()
+
+	writer.Write([]string{"Section", "Group", "Participants", "Registrations"})
+	writer.Write([]string{"Total", "All", strconv.Itoa(report.UniqueParticipants), strconv.Itoa(report.TotalRegistrations)})
+	sections := []struct {
+		name   string
+		counts []db.ParticipationCount
+	}{
+		{"Category", report.ByCategory},
+		{"Age band", report.ByAgeBand},
+		{"Residency", report.ByResidency},
+	}
+	for _, section := range sections {
+		for _, count := range section.counts {
+			writer.Write([]string{section.name, count.Group, strconv.Itoa(count.Participants), strconv.Itoa(count.Registrations)})
+		}
+	}
+}
@@ -2,6 +2,7 @@ package http
 
 import (
 	"encoding/csv"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"sterling-rec/api/internal/core"
 	"sterling-rec/api/internal/db"
 )
 
@@ -44,13 +46,23 @@ func (h *Handler) AdminCreateFacility(c *gin.Context) {
 		FacilityType              string  `json:"facility_type" binding:"required"`
 		Location                  *string `json:"location"`
 		Capacity                  *int    `json:"capacity"`
+		MaxConcurrentBookings     *int    `json:"max_concurrent_bookings" binding:"omitempty,min=1"`
 		MinBookingDurationMinutes int     `json:"min_booking_duration_minutes" binding:"required"`
 		MaxBookingDurationMinutes int     `json:"max_booking_duration_minutes" binding:"required"`
 		BufferMinutes             int     `json:"buffer_minutes"`
 		AdvanceBookingDays        int     `json:"advance_booking_days" binding:"required"`
 		CancellationCutoffHours   int     `json:"cancellation_cutoff_hours" binding:"required"`
 		IsActive                  bool    `json:"is_active"`
+		Bookable                  *bool   `json:"bookable"`
 		RequiresApproval          bool    `json:"requires_approval"`
+		RequiresConfirmation      bool    `json:"requires_confirmation"`
+		PublishedAt               *string `json:"published_at"`
+		UnpublishedAt             *string `json:"unpublished_at"`
+		HourlyRateCents           *int    `json:"hourly_rate_cents" binding:"omitempty,min=0"`
+		AllowLateCancellation     *bool   `json:"allow_late_cancellation"`
+		LateCancellationFeePct    *int    `json:"late_cancellation_fee_pct" binding:"omitempty,min=0,max=100"`
+		Timezone                  *string `json:"timezone"`
+		SameDayCutoffTime         *string `json:"same_day_cutoff_time"` // HH:MM or HH:MM:SS; empty clears it
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -80,6 +92,43 @@ func (h *Handler) AdminCreateFacility(c *gin.Context) {
 		return
 	}
 
+	publishedAt, unpublishedAt, ok := parsePublishWindow(c, req.PublishedAt, req.UnpublishedAt)
+	if !ok {
+		return
+	}
+
+	bookable := true
+	if req.Bookable != nil {
+		bookable = *req.Bookable
+	}
+
+	maxConcurrentBookings := 1
+	if req.MaxConcurrentBookings != nil {
+		maxConcurrentBookings = *req.MaxConcurrentBookings
+	}
+
+	allowLateCancellation := false
+	if req.AllowLateCancellation != nil {
+		allowLateCancellation = *req.AllowLateCancellation
+	}
+	lateCancellationFeePct := 0
+	if req.LateCancellationFeePct != nil {
+		lateCancellationFeePct = *req.LateCancellationFeePct
+	}
+
+	timezone := ""
+	if req.Timezone != nil {
+		if !checkFacilityTimezone(c, *req.Timezone) {
+			return
+		}
+		timezone = *req.Timezone
+	}
+
+	sameDayCutoffTime, ok := parseSameDayCutoff(c, req.SameDayCutoffTime, nil)
+	if !ok {
+		return
+	}
+
 	facility := &db.Facility{
 		Slug:                      req.Slug,
 		Name:                      req.Name,
@@ -87,13 +136,23 @@ func (h *Handler) AdminCreateFacility(c *gin.Context) {
 		FacilityType:              req.FacilityType,
 		Location:                  req.Location,
 		Capacity:                  req.Capacity,
+		MaxConcurrentBookings:     maxConcurrentBookings,
 		MinBookingDurationMinutes: req.MinBookingDurationMinutes,
 		MaxBookingDurationMinutes: req.MaxBookingDurationMinutes,
 		BufferMinutes:             req.BufferMinutes,
 		AdvanceBookingDays:        req.AdvanceBookingDays,
 		CancellationCutoffHours:   req.CancellationCutoffHours,
 		IsActive:                  req.IsActive,
+		Bookable:                  bookable,
 		RequiresApproval:          req.RequiresApproval,
+		RequiresConfirmation:      req.RequiresConfirmation,
+		PublishedAt:               publishedAt,
+		UnpublishedAt:             unpublishedAt,
+		HourlyRateCents:           req.HourlyRateCents,
+		AllowLateCancellation:     allowLateCancellation,
+		LateCancellationFeePct:    lateCancellationFeePct,
+		Timezone:                  timezone,
+		SameDayCutoffTime:         sameDayCutoffTime,
 	}
 
 	created, err := h.db.CreateFacility(facility)
@@ -120,13 +179,23 @@ func (h *Handler) AdminUpdateFacility(c *gin.Context) {
 		FacilityType              string  `json:"facility_type" binding:"required"`
 		Location                  *string `json:"location"`
 		Capacity                  *int    `json:"capacity"`
+		MaxConcurrentBookings     *int    `json:"max_concurrent_bookings" binding:"omitempty,min=1"`
 		MinBookingDurationMinutes int     `json:"min_booking_duration_minutes" binding:"required"`
 		MaxBookingDurationMinutes int     `json:"max_booking_duration_minutes" binding:"required"`
 		BufferMinutes             int     `json:"buffer_minutes"`
 		AdvanceBookingDays        int     `json:"advance_booking_days" binding:"required"`
 		CancellationCutoffHours   int     `json:"cancellation_cutoff_hours" binding:"required"`
 		IsActive                  bool    `json:"is_active"`
+		Bookable                  *bool   `json:"bookable"`
 		RequiresApproval          bool    `json:"requires_approval"`
+		RequiresConfirmation      bool    `json:"requires_confirmation"`
+		PublishedAt               *string `json:"published_at"`
+		UnpublishedAt             *string `json:"unpublished_at"`
+		HourlyRateCents           *int    `json:"hourly_rate_cents" binding:"omitempty,min=0"`
+		AllowLateCancellation     *bool   `json:"allow_late_cancellation"`
+		LateCancellationFeePct    *int    `json:"late_cancellation_fee_pct" binding:"omitempty,min=0,max=100"`
+		Timezone                  *string `json:"timezone"`
+		SameDayCutoffTime         *string `json:"same_day_cutoff_time"` // HH:MM or HH:MM:SS; empty clears it
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -144,6 +213,58 @@ func (h *Handler) AdminUpdateFacility(c *gin.Context) {
 		return
 	}
 
+	currentFacility, err := h.db.GetFacilityByID(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+	if currentFacility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	publishedAt, unpublishedAt, ok := parsePublishWindow(c, req.PublishedAt, req.UnpublishedAt)
+	if !ok {
+		return
+	}
+
+	bookable := currentFacility.Bookable
+	if req.Bookable != nil {
+		bookable = *req.Bookable
+	}
+
+	maxConcurrentBookings := currentFacility.MaxConcurrentBookings
+	if req.MaxConcurrentBookings != nil {
+		maxConcurrentBookings = *req.MaxConcurrentBookings
+	}
+
+	hourlyRateCents := currentFacility.HourlyRateCents
+	if req.HourlyRateCents != nil {
+		hourlyRateCents = req.HourlyRateCents
+	}
+
+	allowLateCancellation := currentFacility.AllowLateCancellation
+	if req.AllowLateCancellation != nil {
+		allowLateCancellation = *req.AllowLateCancellation
+	}
+	lateCancellationFeePct := currentFacility.LateCancellationFeePct
+	if req.LateCancellationFeePct != nil {
+		lateCancellationFeePct = *req.LateCancellationFeePct
+	}
+
+	timezone := currentFacility.Timezone
+	if req.Timezone != nil {
+		if !checkFacilityTimezone(c, *req.Timezone) {
+			return
+		}
+		timezone = *req.Timezone
+	}
+
+	sameDayCutoffTime, ok := parseSameDayCutoff(c, req.SameDayCutoffTime, currentFacility.SameDayCutoffTime)
+	if !ok {
+		return
+	}
+
 	facility := &db.Facility{
 		Slug:                      req.Slug,
 		Name:                      req.Name,
@@ -151,13 +272,23 @@ func (h *Handler) AdminUpdateFacility(c *gin.Context) {
 		FacilityType:              req.FacilityType,
 		Location:                  req.Location,
 		Capacity:                  req.Capacity,
+		MaxConcurrentBookings:     maxConcurrentBookings,
 		MinBookingDurationMinutes: req.MinBookingDurationMinutes,
 		MaxBookingDurationMinutes: req.MaxBookingDurationMinutes,
 		BufferMinutes:             req.BufferMinutes,
 		AdvanceBookingDays:        req.AdvanceBookingDays,
 		CancellationCutoffHours:   req.CancellationCutoffHours,
 		IsActive:                  req.IsActive,
+		Bookable:                  bookable,
 		RequiresApproval:          req.RequiresApproval,
+		RequiresConfirmation:      req.RequiresConfirmation,
+		PublishedAt:               publishedAt,
+		UnpublishedAt:             unpublishedAt,
+		HourlyRateCents:           hourlyRateCents,
+		AllowLateCancellation:     allowLateCancellation,
+		LateCancellationFeePct:    lateCancellationFeePct,
+		Timezone:                  timezone,
+		SameDayCutoffTime:         sameDayCutoffTime,
 	}
 
 	err = h.db.UpdateFacility(facilityID, facility)
@@ -169,6 +300,37 @@ func (h *Handler) AdminUpdateFacility(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Facility updated"})
 }
 
+// checkFacilityTimezone responds with 400 and returns false unless tz is empty (UTC) or an
+// IANA time zone name such as America/New_York
+func checkFacilityTimezone(c *gin.Context, tz string) bool {
+	if tz == "" {
+		return true
+	}
+	if _, err := time.LoadLocation(tz); err != nil || tz == "Local" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone (use an IANA name such as America/New_York)"})
+		return false
+	}
+	return true
+}
+
+// parseSameDayCutoff reads a same-day cutoff time from a request, normalized to HH:MM:SS.
+// A missing value keeps current and an empty one clears it. Responds with 400 and
+// returns false if the time is invalid.
+func parseSameDayCutoff(c *gin.Context, value, current *string) (*string, bool) {
+	if value == nil {
+		return current, true
+	}
+	if *value == "" {
+		return nil, true
+	}
+	cutoff, err := db.NormalizeWindowTime(*value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid same_day_cutoff_time format (use HH:MM or HH:MM:SS)"})
+		return nil, false
+	}
+	return &cutoff, true
+}
+
 // AdminDeleteFacility soft deletes a facility
 func (h *Handler) AdminDeleteFacility(c *gin.Context) {
 	facilityID, err := uuid.Parse(c.Param("id"))
@@ -186,6 +348,78 @@ func (h *Handler) AdminDeleteFacility(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Facility deleted"})
 }
 
+// AdminExportFacilityConfig returns a facility's settings, availability windows, pricing
+// rules, upcoming closures and units as a self-contained config for
+// AdminImportFacilityConfig
+func (h *Handler) AdminExportFacilityConfig(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	cfg, err := h.db.ExportFacilityConfig(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export facility"})
+		return
+	}
+	if cfg == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=facility-%s.json", facilityID))
+	c.JSON(http.StatusOK, cfg)
+}
+
+// AdminImportFacilityConfig creates a new facility under the given slug from an exported
+// config, optionally renamed
+func (h *Handler) AdminImportFacilityConfig(c *gin.Context) {
+	var req struct {
+		Slug   string             `json:"slug" binding:"required"`
+		Name   *string            `json:"name"`
+		Config *db.FacilityConfig `json:"config" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Name != nil {
+		req.Config.Facility.Name = *req.Name
+	}
+	if err := db.ValidateFacilityConfig(req.Config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, err := h.db.GetFacilityBySlug(req.Slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check slug"})
+		return
+	}
+	if existing != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "A facility with this slug already exists"})
+		return
+	}
+
+	var createdBy *uuid.UUID
+	if adminID, ok := GetUserID(c); ok {
+		createdBy = &adminID
+	}
+	facility, err := h.db.ImportFacilityConfig(req.Config, req.Slug, createdBy)
+	if errors.Is(err, db.ErrFacilitySlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A facility with this slug already exists"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import facility"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"facility": facility})
+}
+
 // AdminCreateAvailabilityWindow creates a new availability window
 func (h *Handler) AdminCreateAvailabilityWindow(c *gin.Context) {
 	facilityID, err := uuid.Parse(c.Param("id"))
@@ -200,6 +434,7 @@ func (h *Handler) AdminCreateAvailabilityWindow(c *gin.Context) {
 		EndTime        string  `json:"end_time" binding:"required"`
 		EffectiveFrom  *string `json:"effective_from"`
 		EffectiveUntil *string `json:"effective_until"`
+		Audience       string  `json:"audience" binding:"omitempty,oneof=public members staff"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -207,25 +442,20 @@ func (h *Handler) AdminCreateAvailabilityWindow(c *gin.Context) {
 		return
 	}
 
-	// Validate time format (HH:MM or HH:MM:SS)
-	_, err = time.Parse("15:04:05", req.StartTime)
+	// Validate time format (HH:MM or HH:MM:SS) and store canonical HH:MM:SS
+	startTime, err := db.NormalizeWindowTime(req.StartTime)
 	if err != nil {
-		_, err = time.Parse("15:04", req.StartTime)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use HH:MM or HH:MM:SS)"})
-			return
-		}
-		req.StartTime = req.StartTime + ":00"
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use HH:MM or HH:MM:SS)"})
+		return
 	}
-
-	_, err = time.Parse("15:04:05", req.EndTime)
+	endTime, err := db.NormalizeWindowTime(req.EndTime)
 	if err != nil {
-		_, err = time.Parse("15:04", req.EndTime)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use HH:MM or HH:MM:SS)"})
-			return
-		}
-		req.EndTime = req.EndTime + ":00"
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use HH:MM or HH:MM:SS)"})
+		return
+	}
+	if endTime <= startTime {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
 	}
 
 	var effectiveFrom *time.Time
@@ -251,10 +481,11 @@ func (h *Handler) AdminCreateAvailabilityWindow(c *gin.Context) {
 	window := &db.AvailabilityWindow{
 		FacilityID:     facilityID,
 		DayOfWeek:      req.DayOfWeek,
-		StartTime:      req.StartTime,
-		EndTime:        req.EndTime,
+		StartTime:      startTime,
+		EndTime:        endTime,
 		EffectiveFrom:  effectiveFrom,
 		EffectiveUntil: effectiveUntil,
+		Audience:       req.Audience,
 	}
 
 	created, err := h.db.CreateAvailabilityWindow(window)
@@ -266,179 +497,904 @@ func (h *Handler) AdminCreateAvailabilityWindow(c *gin.Context) {
 	c.JSON(http.StatusCreated, gin.H{"window": created})
 }
 
-// AdminDeleteAvailabilityWindow deletes an availability window
-func (h *Handler) AdminDeleteAvailabilityWindow(c *gin.Context) {
-	windowID, err := uuid.Parse(c.Param("window_id"))
+// AdminReplaceAvailabilityWindows replaces a facility's weekly schedule with the given
+// complete set of windows in a single transaction
+func (h *Handler) AdminReplaceAvailabilityWindows(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
 		return
 	}
 
-	err = h.db.DeleteAvailabilityWindow(windowID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete availability window"})
-		return
+	var req struct {
+		Windows []struct {
+			DayOfWeek      *int    `json:"day_of_week" binding:"required,min=0,max=6"`
+			StartTime      string  `json:"start_time" binding:"required"`
+			EndTime        string  `json:"end_time" binding:"required"`
+			EffectiveFrom  *string `json:"effective_from"`
+			EffectiveUntil *string `json:"effective_until"`
+			Audience       string  `json:"audience" binding:"omitempty,oneof=public members staff"`
+		} `json:"windows" binding:"required,dive"`
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Availability window deleted"})
-}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-// AdminCreateClosure creates a new closure
-func (h *Handler) AdminCreateClosure(c *gin.Context) {
-	facilityID, err := uuid.Parse(c.Param("id"))
+	facility, err := h.db.GetFacilityByID(facilityID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
 		return
 	}
-
-	userID, exists := GetUserID(c)
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
 		return
 	}
 
-	var req struct {
-		StartTime string  `json:"start_time" binding:"required"`
-		EndTime   string  `json:"end_time" binding:"required"`
-		Reason    *string `json:"reason"`
+	windows := make([]db.AvailabilityWindow, 0, len(req.Windows))
+	for i, w := range req.Windows {
+		effectiveFrom, err := parseOptionalTime(w.EffectiveFrom, "2006-01-02")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("window %d: invalid effective_from format (use YYYY-MM-DD)", i)})
+			return
+		}
+		effectiveUntil, err := parseOptionalTime(w.EffectiveUntil, "2006-01-02")
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("window %d: invalid effective_until format (use YYYY-MM-DD)", i)})
+			return
+		}
+		windows = append(windows, db.AvailabilityWindow{
+			FacilityID:     facilityID,
+			DayOfWeek:      *w.DayOfWeek,
+			StartTime:      w.StartTime,
+			EndTime:        w.EndTime,
+			EffectiveFrom:  effectiveFrom,
+			EffectiveUntil: effectiveUntil,
+			Audience:       w.Audience,
+		})
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
+	// Check the whole set before touching the existing schedule
+	if err := db.ValidateAvailabilityWindows(windows); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	result, err := h.db.ReplaceAvailabilityWindows(facilityID, windows)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace availability windows"})
 		return
 	}
 
-	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	c.JSON(http.StatusOK, result)
+}
+
+// AdminGetPricingRules returns a facility's base rate and pricing rules
+func (h *Handler) AdminGetPricingRules(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
 		return
 	}
 
-	if !endTime.After(startTime) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+	facility, err := h.db.GetFacilityByID(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
 		return
 	}
-
-	closure := &db.FacilityClosure{
-		FacilityID: facilityID,
-		StartTime:  startTime,
-		EndTime:    endTime,
-		Reason:     req.Reason,
-		CreatedBy:  &userID,
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
 	}
 
-	created, err := h.db.CreateClosure(closure)
+	rules, err := h.db.GetPricingRules(facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create closure"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pricing rules"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"closure": created})
+	c.JSON(http.StatusOK, gin.H{
+		"hourly_rate_cents": facility.HourlyRateCents,
+		"rules":             rules,
+	})
 }
 
-// AdminGetClosures gets closures for a facility
-func (h *Handler) AdminGetClosures(c *gin.Context) {
+// AdminReplacePricingRules replaces a facility's pricing rules with the given complete set
+// in a single transaction
+func (h *Handler) AdminReplacePricingRules(c *gin.Context) {
 	facilityID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
 		return
 	}
 
-	// Parse date range
-	startTimeStr := c.Query("start_time")
-	endTimeStr := c.Query("end_time")
+	var req struct {
+		Rules []struct {
+			DayOfWeek       *int    `json:"day_of_week" binding:"required,min=0,max=6"`
+			StartTime       string  `json:"start_time" binding:"required"`
+			EndTime         string  `json:"end_time" binding:"required"`
+			HourlyRateCents *int    `json:"hourly_rate_cents" binding:"required,min=0"`
+			Label           *string `json:"label"`
+		} `json:"rules" binding:"required,dive"`
+	}
 
-	var startTime, endTime time.Time
-	if startTimeStr != "" {
-		startTime, err = time.Parse(time.RFC3339, startTimeStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
-			return
-		}
-	} else {
-		startTime = time.Now()
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if endTimeStr != "" {
-		endTime, err = time.Parse(time.RFC3339, endTimeStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
-			return
-		}
-	} else {
-		endTime = startTime.AddDate(1, 0, 0) // Default to 1 year
+	facility, err := h.db.GetFacilityByID(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
 	}
 
-	closures, err := h.db.GetClosures(facilityID, startTime, endTime)
+	rules := make([]db.PricingRule, 0, len(req.Rules))
+	for _, r := range req.Rules {
+		rules = append(rules, db.PricingRule{
+			FacilityID:      facilityID,
+			DayOfWeek:       *r.DayOfWeek,
+			StartTime:       r.StartTime,
+			EndTime:         r.EndTime,
+			HourlyRateCents: *r.HourlyRateCents,
+			Label:           r.Label,
+		})
+	}
+
+	// Check the whole set before touching the existing rules
+	if err := db.ValidatePricingRules(rules); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.db.ReplacePricingRules(facilityID, rules)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get closures"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to replace pricing rules"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"closures": closures})
+	c.JSON(http.StatusOK, gin.H{"rules": created})
 }
 
-// AdminDeleteClosure deletes a closure
-func (h *Handler) AdminDeleteClosure(c *gin.Context) {
-	closureID, err := uuid.Parse(c.Param("closure_id"))
+// AdminGetFacilityUnits lists a facility's units, including inactive ones
+func (h *Handler) AdminGetFacilityUnits(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
 		return
 	}
 
-	err = h.db.DeleteClosure(closureID)
+	facility, err := h.db.GetFacilityByID(facilityID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete closure"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Closure deleted"})
+	units, err := h.db.GetFacilityUnits(facilityID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility units"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"units": units})
 }
 
-// AdminGetFacilityBookings gets all bookings for a facility
-func (h *Handler) AdminGetFacilityBookings(c *gin.Context) {
+// AdminCreateFacilityUnit splits a facility into separately bookable units, one at a time
+func (h *Handler) AdminCreateFacilityUnit(c *gin.Context) {
 	facilityID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
 		return
 	}
 
-	// Parse optional date range
-	var startTime, endTime *time.Time
-	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
-		parsed, err := time.Parse(time.RFC3339, startTimeStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
-			return
-		}
-		startTime = &parsed
+	var req struct {
+		Name      string `json:"name" binding:"required"`
+		SortOrder int    `json:"sort_order"`
+		IsActive  *bool  `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
-	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
-		parsed, err := time.Parse(time.RFC3339, endTimeStr)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
-			return
-		}
-		endTime = &parsed
+	facility, err := h.db.GetFacilityByID(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
 	}
 
-	bookings, err := h.facilitiesService.GetFacilityBookings(c.Request.Context(), facilityID, startTime, endTime)
+	if !h.unitNameFree(c, facilityID, nil, req.Name) {
+		return
+	}
+
+	unit := &db.FacilityUnit{FacilityID: facilityID, Name: req.Name, SortOrder: req.SortOrder, IsActive: true}
+	if req.IsActive != nil {
+		unit.IsActive = *req.IsActive
+	}
+
+	unit, err = h.db.CreateFacilityUnit(unit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookings"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create facility unit"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"bookings": bookings})
+	c.JSON(http.StatusCreated, gin.H{"unit": unit})
 }
 
-// AdminExportBookings exports bookings as CSV
+// AdminUpdateFacilityUnit renames, reorders or (de)activates a unit. Deactivated units
+// keep their bookings but take no new ones.
+func (h *Handler) AdminUpdateFacilityUnit(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+	unitID, err := uuid.Parse(c.Param("unit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit ID"})
+		return
+	}
+
+	var req struct {
+		Name      *string `json:"name"`
+		SortOrder *int    `json:"sort_order"`
+		IsActive  *bool   `json:"is_active"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	unit, err := h.db.GetFacilityUnit(facilityID, unitID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility unit"})
+		return
+	}
+	if unit == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unit not found"})
+		return
+	}
+
+	if req.Name != nil && *req.Name != unit.Name {
+		if *req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name cannot be empty"})
+			return
+		}
+		if !h.unitNameFree(c, facilityID, &unitID, *req.Name) {
+			return
+		}
+		unit.Name = *req.Name
+	}
+	if req.SortOrder != nil {
+		unit.SortOrder = *req.SortOrder
+	}
+	if req.IsActive != nil {
+		unit.IsActive = *req.IsActive
+	}
+
+	if err := h.db.UpdateFacilityUnit(unit); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update facility unit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unit": unit})
+}
+
+// AdminDeleteFacilityUnit deletes a unit that has never been booked
+func (h *Handler) AdminDeleteFacilityUnit(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+	unitID, err := uuid.Parse(c.Param("unit_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit ID"})
+		return
+	}
+
+	unit, err := h.db.GetFacilityUnit(facilityID, unitID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility unit"})
+		return
+	}
+	if unit == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unit not found"})
+		return
+	}
+
+	booked, err := h.db.FacilityUnitHasBookings(unitID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check unit bookings"})
+		return
+	}
+	if booked {
+		c.JSON(http.StatusConflict, gin.H{"error": "Unit has bookings; deactivate it instead"})
+		return
+	}
+
+	if err := h.db.DeleteFacilityUnit(facilityID, unitID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete facility unit"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Unit deleted"})
+}
+
+// unitNameFree reports whether no other unit of the facility has the name. It responds
+// with 409 when one does, or 500 when the units cannot be read, and returns false.
+func (h *Handler) unitNameFree(c *gin.Context, facilityID uuid.UUID, exceptID *uuid.UUID, name string) bool {
+	units, err := h.db.GetFacilityUnits(facilityID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility units"})
+		return false
+	}
+	for _, u := range units {
+		if u.Name == name && (exceptID == nil || u.ID != *exceptID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "A unit with this name already exists"})
+			return false
+		}
+	}
+	return true
+}
+
+// AdminDeleteAvailabilityWindow deletes an availability window
+func (h *Handler) AdminDeleteAvailabilityWindow(c *gin.Context) {
+	windowID, err := uuid.Parse(c.Param("window_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid window ID"})
+		return
+	}
+
+	err = h.db.DeleteAvailabilityWindow(windowID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete availability window"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Availability window deleted"})
+}
+
+// AdminCreateClosure creates a new closure
+func (h *Handler) AdminCreateClosure(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req struct {
+		StartTime string  `json:"start_time" binding:"required"`
+		EndTime   string  `json:"end_time" binding:"required"`
+		Reason    *string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
+		return
+	}
+
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
+		return
+	}
+
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
+	}
+
+	closure := &db.FacilityClosure{
+		FacilityID: facilityID,
+		StartTime:  startTime,
+		EndTime:    endTime,
+		Reason:     req.Reason,
+		CreatedBy:  &userID,
+	}
+
+	created, err := h.db.CreateClosure(closure)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create closure"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"closure": created})
+}
+
+// AdminGetClosures gets closures for a facility
+func (h *Handler) AdminGetClosures(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	// Parse date range
+	startTimeStr := c.Query("start_time")
+	endTimeStr := c.Query("end_time")
+
+	var startTime, endTime time.Time
+	if startTimeStr != "" {
+		startTime, err = time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
+			return
+		}
+	} else {
+		startTime = time.Now()
+	}
+
+	if endTimeStr != "" {
+		endTime, err = time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
+			return
+		}
+	} else {
+		endTime = startTime.AddDate(1, 0, 0) // Default to 1 year
+	}
+
+	closures, err := h.db.GetClosures(facilityID, startTime, endTime)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get closures"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"closures": closures})
+}
+
+// AdminDeleteClosure deletes a closure
+func (h *Handler) AdminDeleteClosure(c *gin.Context) {
+	closureID, err := uuid.Parse(c.Param("closure_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+		return
+	}
+
+	err = h.db.DeleteClosure(closureID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete closure"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Closure deleted"})
+}
+
+// AdminProposeClosureReschedule suggests alternative slots for bookings affected by a closure
+func (h *Handler) AdminProposeClosureReschedule(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	closureID, err := uuid.Parse(c.Param("closure_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+		return
+	}
+
+	proposals, err := h.facilitiesService.ProposeClosureReschedule(c.Request.Context(), facilityID, closureID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"proposals": proposals})
+}
+
+// AdminConfirmClosureReschedule applies reschedule moves for bookings affected by a closure
+func (h *Handler) AdminConfirmClosureReschedule(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	closureID, err := uuid.Parse(c.Param("closure_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid closure ID"})
+		return
+	}
+
+	var req struct {
+		Moves []struct {
+			BookingID string `json:"booking_id" binding:"required"`
+			StartTime string `json:"start_time" binding:"required"`
+			EndTime   string `json:"end_time" binding:"required"`
+		} `json:"moves" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	moves := make([]core.RescheduleMove, 0, len(req.Moves))
+	for _, m := range req.Moves {
+		bookingID, err := uuid.Parse(m.BookingID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+			return
+		}
+
+		startTime, err := time.Parse(time.RFC3339, m.StartTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
+			return
+		}
+
+		endTime, err := time.Parse(time.RFC3339, m.EndTime)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
+			return
+		}
+
+		if !endTime.After(startTime) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+			return
+		}
+
+		moves = append(moves, core.RescheduleMove{
+			BookingID: bookingID,
+			StartTime: startTime,
+			EndTime:   endTime,
+		})
+	}
+
+	results, err := h.facilitiesService.ApplyClosureReschedule(c.Request.Context(), facilityID, closureID, moves)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// AdminGetFacilityBookings gets all bookings for a facility
+func (h *Handler) AdminGetFacilityBookings(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	// Parse optional date range
+	var startTime, endTime *time.Time
+	if startTimeStr := c.Query("start_time"); startTimeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, startTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format"})
+			return
+		}
+		startTime = &parsed
+	}
+
+	if endTimeStr := c.Query("end_time"); endTimeStr != "" {
+		parsed, err := time.Parse(time.RFC3339, endTimeStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format"})
+			return
+		}
+		endTime = &parsed
+	}
+
+	createdFrom, createdTo, ok := parseCreatedWindow(c)
+	if !ok {
+		return
+	}
+
+	status := c.DefaultQuery("status", "confirmed")
+	switch status {
+	case "pending", "confirmed", "rejected", "cancelled", "no_show":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status (use pending, confirmed, rejected, cancelled or no_show)"})
+		return
+	}
+
+	bookings, err := h.facilitiesService.GetFacilityBookings(c.Request.Context(), facilityID, status, startTime, endTime, createdFrom, createdTo)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"bookings": bookings})
+}
+
+// AdminReserveFacilityForProgram books the facility for a program's sessions so the
+// public cannot book over them
+func (h *Handler) AdminReserveFacilityForProgram(c *gin.Context) {
+	facilityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility ID"})
+		return
+	}
+
+	var req struct {
+		ProgramID  string   `json:"program_id" binding:"required,uuid"`
+		SessionIDs []string `json:"session_ids"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	programID, err := uuid.Parse(req.ProgramID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program_id"})
+		return
+	}
+
+	facility, err := h.db.GetFacilityByID(facilityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+	if facility == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	sessions, err := h.db.GetProgramSessions(programID, program.Capacity, *program.OverbookPct)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program sessions"})
+		return
+	}
+	programSessions := map[uuid.UUID]bool{}
+	for _, session := range sessions {
+		programSessions[session.ID] = true
+	}
+
+	var sessionIDs []uuid.UUID
+	for _, idStr := range req.SessionIDs {
+		id, err := uuid.Parse(idStr)
+		if err != nil || !programSessions[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Session " + idStr + " is not an active session of this program"})
+			return
+		}
+		sessionIDs = append(sessionIDs, id)
+	}
+
+	userID, _ := GetUserID(c)
+	result, err := h.db.ReserveFacilityForProgram(facilityID, programID, userID, sessionIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve facility"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, result)
+}
+
+// AdminSetUserMembership sets whether a user may book members-only availability windows
+func (h *Handler) AdminSetUserMembership(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		IsMember *bool `json:"is_member" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.SetUserMembership(userID, *req.IsMember); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update membership"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Membership updated"})
+}
+
+// AdminSetUserAdvanceBookingExempt sets whether a user may book beyond advance booking limits
+func (h *Handler) AdminSetUserAdvanceBookingExempt(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		AdvanceBookingExempt *bool `json:"advance_booking_exempt" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.db.SetUserAdvanceBookingExempt(userID, *req.AdvanceBookingExempt); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update advance booking exemption"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Advance booking exemption updated"})
+}
+
+// AdminGetUserBookingStats returns aggregate booking statistics for a user
+func (h *Handler) AdminGetUserBookingStats(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var from, to *time.Time
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format"})
+			return
+		}
+		from = &parsed
+	}
+
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format"})
+			return
+		}
+		to = &parsed
+	}
+
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	stats, err := h.db.GetUserBookingStats(userID, from, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get booking stats"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"stats": stats})
+}
+
+// AdminApproveBooking confirms a booking awaiting approval
+func (h *Handler) AdminApproveBooking(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	if !h.checkPendingBooking(c, bookingID) {
+		return
+	}
+
+	booking, err := h.facilitiesService.ApproveBooking(c.Request.Context(), bookingID)
+	if err != nil {
+		var unavailable *db.AvailabilityError
+		if errors.As(err, &unavailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": unavailable.Code, "message": unavailable.Message})
+			return
+		}
+		if errors.Is(err, db.ErrBookingNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking is not awaiting approval"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve booking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking": booking})
+}
+
+// AdminRejectBooking rejects a booking awaiting approval
+func (h *Handler) AdminRejectBooking(c *gin.Context) {
+	adminID, _ := GetUserID(c)
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	var req struct {
+		Reason *string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkPendingBooking(c, bookingID) {
+		return
+	}
+
+	if err := h.db.RejectBooking(bookingID, adminID, req.Reason); err != nil {
+		if errors.Is(err, db.ErrBookingNotPending) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Booking is not awaiting approval"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject booking"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rejected"})
+}
+
+// checkPendingBooking responds with 404 or 409 and returns false unless the booking
+// exists and is awaiting approval
+func (h *Handler) checkPendingBooking(c *gin.Context, bookingID uuid.UUID) bool {
+	booking, err := h.db.GetBooking(bookingID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve booking"})
+		return false
+	}
+	if booking == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return false
+	}
+	if booking.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Booking is not awaiting approval"})
+		return false
+	}
+	return true
+}
+
+// AdminMarkBookingNoShow marks a booking as a no-show
+func (h *Handler) AdminMarkBookingNoShow(c *gin.Context) {
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	if err := h.db.MarkBookingNoShow(bookingID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking marked as no-show"})
+}
+
+// AdminExportBookings exports bookings as CSV
 func (h *Handler) AdminExportBookings(c *gin.Context) {
 	// Parse optional filters
 	var facilityID *uuid.UUID
@@ -472,7 +1428,12 @@ func (h *Handler) AdminExportBookings(c *gin.Context) {
 
 	status := c.Query("status") // "" for all, "confirmed", "cancelled"
 
-	bookings, err := h.db.GetBookings(facilityID, nil, startTime, endTime, status)
+	createdFrom, createdTo, ok := parseCreatedWindow(c)
+	if !ok {
+		return
+	}
+
+	bookings, err := h.db.GetBookings(facilityID, nil, startTime, endTime, status, createdFrom, createdTo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get bookings"})
 		return
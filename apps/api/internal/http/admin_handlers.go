@@ -1,12 +1,20 @@
 package http
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
-"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/core"
+	"sterling-rec/api/internal/db"
 )
 
 // Admin middleware - check if user is admin
@@ -18,6 +26,11 @@ func (h *Handler) AdminOnly() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+		if _, ok := GetImpersonation(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access is not available while impersonating a user"})
+			c.Abort()
+			return
+		}
 
 		var role string
 		err := h.db.QueryRow("SELECT role FROM users WHERE id = $1", userID).Scan(&role)
@@ -37,6 +50,61 @@ func (h *Handler) AdminOnly() gin.HandlerFunc {
 	}
 }
 
+// Get all programs, including inactive and unpublished ones (Admin only)
+func (h *Handler) AdminGetPrograms(c *gin.Context) {
+	programs, err := h.db.GetAllPrograms()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve programs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"programs": programs})
+}
+
+// Get a program by ID, including inactive and unpublished ones, with its sessions,
+// capacity and assigned waivers and forms (Admin only)
+func (h *Handler) AdminGetProgram(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramDetail(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	waivers, err := h.db.GetProgramWaivers(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve program waivers"})
+		return
+	}
+	if waivers == nil {
+		waivers = []db.ProgramWaiver{}
+	}
+
+	forms, err := h.db.GetProgramForms(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve program forms"})
+		return
+	}
+	if forms == nil {
+		forms = []db.ProgramForm{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"program": program,
+		"waivers": waivers,
+		"forms":   forms,
+	})
+}
+
 // Create Program (Admin only)
 func (h *Handler) AdminCreateProgram(c *gin.Context) {
 	var req struct {
@@ -50,6 +118,17 @@ func (h *Handler) AdminCreateProgram(c *gin.Context) {
 		StartDate     *string `json:"start_date"`
 		EndDate       *string `json:"end_date"`
 		ScheduleNotes *string `json:"schedule_notes"`
+		OverbookPct   *int    `json:"overbook_pct" binding:"omitempty,min=0,max=100"`
+
+		RegistrationQuestions json.RawMessage `json:"registration_questions"`
+		RequiresApproval      bool            `json:"requires_approval"`
+		RegistrationOpensAt   *string         `json:"registration_opens_at"`
+		PublishedAt           *string         `json:"published_at"`
+		UnpublishedAt         *string         `json:"unpublished_at"`
+		Category              *string         `json:"category"`
+
+		MinorEmergencyContactRequired *bool `json:"minor_emergency_contact_required"`
+		MinorAgeThreshold             *int  `json:"minor_age_threshold" binding:"omitempty,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -57,25 +136,103 @@ func (h *Handler) AdminCreateProgram(c *gin.Context) {
 		return
 	}
 
-	// Insert program
-	var programID uuid.UUID
-	err := h.db.QueryRow(`
-		INSERT INTO programs (slug, title, description, age_min, age_max, location, capacity, start_date, end_date, schedule_notes, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true)
-		RETURNING id
-	`, req.Slug, req.Title, req.Description, req.AgeMin, req.AgeMax, req.Location, req.Capacity, req.StartDate, req.EndDate, req.ScheduleNotes).Scan(&programID)
+	if len(req.RegistrationQuestions) > 0 {
+		if _, err := db.ParseFormSchema(req.RegistrationQuestions); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	startDate, err := parseOptionalTime(req.StartDate, "2006-01-02")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format (use YYYY-MM-DD)"})
+		return
+	}
+	endDate, err := parseOptionalTime(req.EndDate, "2006-01-02")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format (use YYYY-MM-DD)"})
+		return
+	}
+	registrationOpensAt, err := parseOptionalTime(req.RegistrationOpensAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration_opens_at format (use RFC3339)"})
+		return
+	}
+	publishedAt, unpublishedAt, ok := parsePublishWindow(c, req.PublishedAt, req.UnpublishedAt)
+	if !ok {
+		return
+	}
+
+	if c.Query("force") != "true" {
+		duplicates, err := h.db.FindSimilarPrograms(req.Title, startDate, endDate)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate programs"})
+			return
+		}
+		if len(duplicates) > 0 {
+			possibleDuplicates(c, "program", duplicates)
+			return
+		}
+	}
+
+	minorAgeThreshold := db.DefaultMinorAgeThreshold
+	if req.MinorAgeThreshold != nil {
+		minorAgeThreshold = *req.MinorAgeThreshold
+	}
+
+	program := &db.Program{
+		Slug:          req.Slug,
+		Title:         req.Title,
+		Description:   req.Description,
+		AgeMin:        req.AgeMin,
+		AgeMax:        req.AgeMax,
+		Location:      req.Location,
+		Capacity:      req.Capacity,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		ScheduleNotes: req.ScheduleNotes,
+		IsActive:      true,
+		OverbookPct:   req.OverbookPct,
+
+		RegistrationQuestions: req.RegistrationQuestions,
+		RequiresApproval:      req.RequiresApproval,
+		RegistrationOpensAt:   registrationOpensAt,
+		PublishedAt:           publishedAt,
+		UnpublishedAt:         unpublishedAt,
+		Category:              req.Category,
 
+		MinorEmergencyContactRequired: req.MinorEmergencyContactRequired == nil || *req.MinorEmergencyContactRequired,
+		MinorAgeThreshold:             minorAgeThreshold,
+	}
+
+	created, err := h.db.CreateProgram(program)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create program"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"program_id": programID})
+	c.JSON(http.StatusCreated, gin.H{"program": created})
+}
+
+// possibleDuplicates holds back creating a program or event that looks like one already
+// in the catalog. It is a warning rather than a refusal: repeating the request with
+// ?force=true creates it anyway.
+func possibleDuplicates(c *gin.Context, kind string, duplicates []db.PossibleDuplicate) {
+	c.JSON(http.StatusConflict, gin.H{
+		"error":               "A similar " + kind + " already exists for these dates",
+		"warning":             "possible_duplicate",
+		"possible_duplicates": duplicates,
+		"message":             "Check the listed " + kind + "s and repeat the request with ?force=true if this is not a duplicate",
+	})
 }
 
 // Update Program (Admin only)
 func (h *Handler) AdminUpdateProgram(c *gin.Context) {
-	programID := c.Param("id")
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
 
 	var req struct {
 		Title         *string `json:"title"`
@@ -88,6 +245,17 @@ func (h *Handler) AdminUpdateProgram(c *gin.Context) {
 		EndDate       *string `json:"end_date"`
 		ScheduleNotes *string `json:"schedule_notes"`
 		IsActive      *bool   `json:"is_active"`
+		OverbookPct   *int    `json:"overbook_pct" binding:"omitempty,min=0,max=100"`
+
+		RegistrationQuestions json.RawMessage `json:"registration_questions"`
+		RequiresApproval      *bool           `json:"requires_approval"`
+		RegistrationOpensAt   *string         `json:"registration_opens_at"`
+		PublishedAt           *string         `json:"published_at"`
+		UnpublishedAt         *string         `json:"unpublished_at"`
+		Category              *string         `json:"category"`
+
+		MinorEmergencyContactRequired *bool `json:"minor_emergency_contact_required"`
+		MinorAgeThreshold             *int  `json:"minor_age_threshold" binding:"omitempty,min=1"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -95,37 +263,153 @@ func (h *Handler) AdminUpdateProgram(c *gin.Context) {
 		return
 	}
 
-	// Build dynamic update query
-	_, err := h.db.Exec(`
-		UPDATE programs SET
-			title = COALESCE($1, title),
-			description = COALESCE($2, description),
-			age_min = COALESCE($3, age_min),
-			age_max = COALESCE($4, age_max),
-			location = COALESCE($5, location),
-			capacity = COALESCE($6, capacity),
-			start_date = COALESCE($7, start_date),
-			end_date = COALESCE($8, end_date),
-			schedule_notes = COALESCE($9, schedule_notes),
-			is_active = COALESCE($10, is_active),
-			updated_at = NOW()
-		WHERE id = $11
-	`, req.Title, req.Description, req.AgeMin, req.AgeMax, req.Location, req.Capacity, req.StartDate, req.EndDate, req.ScheduleNotes, req.IsActive, programID)
+	// registration_questions: null removes the questions
+	clearQuestions := string(req.RegistrationQuestions) == "null"
+	if clearQuestions {
+		req.RegistrationQuestions = nil
+	}
+	if len(req.RegistrationQuestions) > 0 {
+		if _, err := db.ParseFormSchema(req.RegistrationQuestions); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	startDate, err := parseOptionalTime(req.StartDate, "2006-01-02")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format (use YYYY-MM-DD)"})
+		return
+	}
+	endDate, err := parseOptionalTime(req.EndDate, "2006-01-02")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format (use YYYY-MM-DD)"})
+		return
+	}
+	registrationOpensAt, err := parseOptionalTime(req.RegistrationOpensAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration_opens_at format (use RFC3339)"})
+		return
+	}
+	// An empty published_at or unpublished_at removes that end of the publish window
+	clearPublishedAt := req.PublishedAt != nil && *req.PublishedAt == ""
+	if clearPublishedAt {
+		req.PublishedAt = nil
+	}
+	clearUnpublishedAt := req.UnpublishedAt != nil && *req.UnpublishedAt == ""
+	if clearUnpublishedAt {
+		req.UnpublishedAt = nil
+	}
+	publishedAt, unpublishedAt, ok := parsePublishWindow(c, req.PublishedAt, req.UnpublishedAt)
+	if !ok {
+		return
+	}
+
+	// Raising capacity or overbooking promotes from the waitlist into the new spots. With
+	// ?reconcile=true, lowering capacity below the confirmed registrations also moves the
+	// most recently confirmed to the top of the waitlist instead of leaving it over-booked.
+	reconcile := c.Query("reconcile") == "true"
+	var oldCapacity, oldEffective, newEffective int
+	if req.Capacity != nil || req.OverbookPct != nil {
+		program, err := h.db.GetProgramByID(programID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+			return
+		}
+		if program == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+			return
+		}
+		oldCapacity = program.Capacity
+		oldEffective = db.EffectiveCapacity(program.Capacity, *program.OverbookPct)
+
+		newCapacity, newOverbookPct := program.Capacity, *program.OverbookPct
+		if req.Capacity != nil {
+			newCapacity = *req.Capacity
+		}
+		if req.OverbookPct != nil {
+			newOverbookPct = *req.OverbookPct
+		}
+		newEffective = db.EffectiveCapacity(newCapacity, newOverbookPct)
+	}
+
+	err = h.db.UpdateProgram(programID, &db.ProgramUpdate{
+		Title:         req.Title,
+		Description:   req.Description,
+		AgeMin:        req.AgeMin,
+		AgeMax:        req.AgeMax,
+		Location:      req.Location,
+		Capacity:      req.Capacity,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		ScheduleNotes: req.ScheduleNotes,
+		IsActive:      req.IsActive,
+		OverbookPct:   req.OverbookPct,
 
+		RegistrationQuestions: req.RegistrationQuestions,
+		RequiresApproval:      req.RequiresApproval,
+		RegistrationOpensAt:   registrationOpensAt,
+		PublishedAt:           publishedAt,
+		UnpublishedAt:         unpublishedAt,
+		Category:              req.Category,
+
+		MinorEmergencyContactRequired: req.MinorEmergencyContactRequired,
+		MinorAgeThreshold:             req.MinorAgeThreshold,
+
+		ClearRegistrationQuestions: clearQuestions,
+		ClearPublishedAt:           clearPublishedAt,
+		ClearUnpublishedAt:         clearUnpublishedAt,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update program"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Program updated"})
+	promoted := []db.WaitlistPromotion{}
+	if newEffective > oldEffective {
+		promoted, err = h.db.FillFromWaitlist("program", programID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Program updated but failed to promote from the waitlist"})
+			return
+		}
+	}
+
+	if !reconcile {
+		c.JSON(http.StatusOK, gin.H{
+			"message":                "Program updated",
+			"promoted":               len(promoted),
+			"promoted_registrations": promoted,
+		})
+		return
+	}
+
+	demoted := []db.DemotedRegistration{}
+	if req.Capacity != nil && *req.Capacity < oldCapacity {
+		adminID, _ := GetUserID(c)
+		demoted, err = h.db.ReconcileCapacity(programID, &adminID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Program updated but failed to reconcile capacity"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "Program updated",
+		"demoted":                len(demoted),
+		"demoted_registrations":  demoted,
+		"promoted":               len(promoted),
+		"promoted_registrations": promoted,
+	})
 }
 
 // Delete Program (Admin only)
 func (h *Handler) AdminDeleteProgram(c *gin.Context) {
-	programID := c.Param("id")
-
-	_, err := h.db.Exec("DELETE FROM programs WHERE id = $1", programID)
+	programID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	if err := h.db.DeleteProgram(programID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete program"})
 		return
 	}
@@ -133,6 +417,181 @@ func (h *Handler) AdminDeleteProgram(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Program deleted"})
 }
 
+// Get program capacity reconciliation (Admin only)
+func (h *Handler) AdminGetProgramReconciliation(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	report, err := h.db.ReconcileProgram(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile program"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Get dietary and accessibility needs of a program's confirmed participants (Admin only)
+func (h *Handler) AdminGetProgramNeeds(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	report, err := h.db.GetProgramNeedsReport(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program needs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Get a program's interest list in the order users joined (Admin only)
+func (h *Handler) AdminGetProgramInterest(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	entries, err := h.db.GetProgramInterest(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get interest list"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration_opens_at": program.RegistrationOpensAt,
+		"interest":              entries,
+	})
+}
+
+// Email each confirmed registrant their schedule of the program's sessions (Admin only).
+// With ?dry_run=true nothing is sent and the response only counts the recipients.
+func (h *Handler) AdminSendProgramSchedule(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	sessions, err := h.db.GetProgramScheduleSessions(programID, nil)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program sessions"})
+		return
+	}
+	if len(sessions) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Program has no scheduled sessions"})
+		return
+	}
+
+	dryRun := c.Query("dry_run") == "true"
+	recipients, err := h.db.QueueProgramSchedules(programID, dryRun)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to queue schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run":    dryRun,
+		"recipients": recipients,
+		"sessions":   len(sessions),
+	})
+}
+
+// Fix program capacity discrepancies (Admin only)
+func (h *Handler) AdminFixProgramReconciliation(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	report, err := h.regService.FixProgramReconciliation(c.Request.Context(), programID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// Get an event by ID, including inactive ones, with its capacity (Admin only)
+func (h *Handler) AdminGetEvent(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.db.GetEventDetail(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"event": event})
+}
+
 // Create Event (Admin only)
 func (h *Handler) AdminCreateEvent(c *gin.Context) {
 	var req struct {
@@ -150,24 +609,56 @@ func (h *Handler) AdminCreateEvent(c *gin.Context) {
 		return
 	}
 
-	var eventID uuid.UUID
-	err := h.db.QueryRow(`
-		INSERT INTO events (slug, title, description, location, capacity, starts_at, ends_at, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, true)
-		RETURNING id
-	`, req.Slug, req.Title, req.Description, req.Location, req.Capacity, req.StartsAt, req.EndsAt).Scan(&eventID)
+	startsAt, err := parseOptionalTime(req.StartsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid starts_at format (use RFC3339)"})
+		return
+	}
+	endsAt, err := parseOptionalTime(req.EndsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ends_at format (use RFC3339)"})
+		return
+	}
+
+	if c.Query("force") != "true" {
+		duplicates, err := h.db.FindSimilarEvents(req.Title, startsAt, endsAt)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check for duplicate events"})
+			return
+		}
+		if len(duplicates) > 0 {
+			possibleDuplicates(c, "event", duplicates)
+			return
+		}
+	}
+
+	event := &db.Event{
+		Slug:        req.Slug,
+		Title:       req.Title,
+		Description: req.Description,
+		Location:    req.Location,
+		Capacity:    req.Capacity,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		IsActive:    true,
+	}
 
+	created, err := h.db.CreateEvent(event)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"event_id": eventID})
+	c.JSON(http.StatusCreated, gin.H{"event": created})
 }
 
 // Update Event (Admin only)
 func (h *Handler) AdminUpdateEvent(c *gin.Context) {
-	eventID := c.Param("id")
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
 
 	var req struct {
 		Title       *string `json:"title"`
@@ -184,33 +675,71 @@ func (h *Handler) AdminUpdateEvent(c *gin.Context) {
 		return
 	}
 
-	_, err := h.db.Exec(`
-		UPDATE events SET
-			title = COALESCE($1, title),
-			description = COALESCE($2, description),
-			location = COALESCE($3, location),
-			capacity = COALESCE($4, capacity),
-			starts_at = COALESCE($5, starts_at),
-			ends_at = COALESCE($6, ends_at),
-			is_active = COALESCE($7, is_active),
-			updated_at = NOW()
-		WHERE id = $8
-	`, req.Title, req.Description, req.Location, req.Capacity, req.StartsAt, req.EndsAt, req.IsActive, eventID)
+	startsAt, err := parseOptionalTime(req.StartsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid starts_at format (use RFC3339)"})
+		return
+	}
+	endsAt, err := parseOptionalTime(req.EndsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ends_at format (use RFC3339)"})
+		return
+	}
+
+	var oldCapacity int
+	if req.Capacity != nil {
+		event, err := h.db.GetEventByID(eventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+			return
+		}
+		if event == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		oldCapacity = event.Capacity
+	}
 
+	err = h.db.UpdateEvent(eventID, &db.EventUpdate{
+		Title:       req.Title,
+		Description: req.Description,
+		Location:    req.Location,
+		Capacity:    req.Capacity,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		IsActive:    req.IsActive,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Event updated"})
+	// Raising capacity promotes from the waitlist into the new spots
+	promoted := []db.WaitlistPromotion{}
+	if req.Capacity != nil && *req.Capacity > oldCapacity {
+		promoted, err = h.db.FillFromWaitlist("event", eventID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Event updated but failed to promote from the waitlist"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                "Event updated",
+		"promoted":               len(promoted),
+		"promoted_registrations": promoted,
+	})
 }
 
 // Delete Event (Admin only)
 func (h *Handler) AdminDeleteEvent(c *gin.Context) {
-	eventID := c.Param("id")
-
-	_, err := h.db.Exec("DELETE FROM events WHERE id = $1", eventID)
+	eventID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	if err := h.db.DeleteEvent(eventID); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 		return
 	}
@@ -218,19 +747,200 @@ func (h *Handler) AdminDeleteEvent(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted"})
 }
 
+// Check in an event attendee by scanned token (Admin only)
+func (h *Handler) AdminEventCheckIn(c *gin.Context) {
+	adminID, _ := GetUserID(c)
+
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	var req struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	registrationID, err := core.ParseCheckInToken(req.Token)
+	if errors.Is(err, core.ErrCheckInDisabled) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Event check-in is not configured"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid check-in token"})
+		return
+	}
+
+	checkIn, err := h.db.CheckInEventRegistration(eventID, registrationID, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check in"})
+		return
+	}
+	if checkIn == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Check-in token is not for this event"})
+		return
+	}
+	if checkIn.AlreadyCheckedIn {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":            "Already checked in",
+			"participant_name": checkIn.ParticipantName,
+			"checked_in_at":    checkIn.CheckedInAt,
+		})
+		return
+	}
+	if checkIn.Status != "confirmed" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":            "Registration is not confirmed",
+			"participant_name": checkIn.ParticipantName,
+			"status":           checkIn.Status,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, checkIn)
+}
+
+// parseOptionalTime parses s with layout, returning nil when s is nil or empty
+func parseOptionalTime(s *string, layout string) (*time.Time, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	parsed, err := time.Parse(layout, *s)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parseOptionalUUID parses s as a UUID, returning nil when s is nil or empty
+func parseOptionalUUID(s *string) (*uuid.UUID, error) {
+	if s == nil || *s == "" {
+		return nil, nil
+	}
+	parsed, err := uuid.Parse(*s)
+	if err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// parseCreatedWindow reads the created_from and created_to (RFC3339) query parameters that
+// limit admin lists and exports to records made in a window. It responds with 400 and
+// returns false when either is invalid.
+func parseCreatedWindow(c *gin.Context) (createdFrom, createdTo *time.Time, ok bool) {
+	from := c.Query("created_from")
+	createdFrom, err := parseOptionalTime(&from, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_from format (use RFC3339)"})
+		return nil, nil, false
+	}
+	to := c.Query("created_to")
+	createdTo, err = parseOptionalTime(&to, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid created_to format (use RFC3339)"})
+		return nil, nil, false
+	}
+	return createdFrom, createdTo, true
+}
+
+// parsePublishWindow parses the published_at and unpublished_at (RFC3339) fields that bound
+// when a program or facility is listed publicly. It responds with 400 and returns false when
+// either is invalid or the window ends before it starts.
+func parsePublishWindow(c *gin.Context, published, unpublished *string) (publishedAt, unpublishedAt *time.Time, ok bool) {
+	publishedAt, err := parseOptionalTime(published, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid published_at format (use RFC3339)"})
+		return nil, nil, false
+	}
+	unpublishedAt, err = parseOptionalTime(unpublished, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unpublished_at format (use RFC3339)"})
+		return nil, nil, false
+	}
+	if publishedAt != nil && unpublishedAt != nil && !unpublishedAt.After(*publishedAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unpublished_at must be after published_at"})
+		return nil, nil, false
+	}
+	return publishedAt, unpublishedAt, true
+}
+
+// Merge one household into another (Admin only)
+func (h *Handler) AdminMergeHouseholds(c *gin.Context) {
+	var req struct {
+		SourceHouseholdID string `json:"source_household_id" binding:"required,uuid"`
+		TargetHouseholdID string `json:"target_household_id" binding:"required,uuid"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sourceID, _ := uuid.Parse(req.SourceHouseholdID)
+	targetID, _ := uuid.Parse(req.TargetHouseholdID)
+
+	result, err := h.db.MergeHouseholds(sourceID, targetID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"merge": result})
+}
+
+// AdminGetSessionWaitlist returns a session's waitlist in promotion order
+func (h *Handler) AdminGetSessionWaitlist(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	session, err := h.db.GetSessionByID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	waitlist, err := h.db.GetSessionWaitlist(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session waitlist"})
+		return
+	}
+	count := len(waitlist)
+	session.WaitlistCount = &count
+
+	c.JSON(http.StatusOK, gin.H{"session": session, "waitlist": waitlist})
+}
+
 // Get all registrations (Admin only)
 func (h *Handler) AdminGetRegistrations(c *gin.Context) {
+	createdFrom, createdTo, ok := parseCreatedWindow(c)
+	if !ok {
+		return
+	}
+
 	rows, err := h.db.Query(`
 		SELECT r.id, r.parent_type, r.parent_id, r.session_id, r.participant_id, r.status, r.created_at,
-		       p.first_name, p.last_name, p.dob,
+		       p.first_name, p.last_name, p.dob, p.photo_url,
 		       u.email, u.first_name as user_first_name, u.last_name as user_last_name
 		FROM registrations r
 		JOIN participants p ON r.participant_id = p.id
 		JOIN households h ON p.household_id = h.id
 		JOIN users u ON h.owner_user_id = u.id
+		WHERE ($1::timestamptz IS NULL OR r.created_at >= $1)
+			AND ($2::timestamptz IS NULL OR r.created_at < $2)
 		ORDER BY r.created_at DESC
 		LIMIT 100
-	`)
+	`, createdFrom, createdTo)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
 		return
@@ -250,13 +960,14 @@ func (h *Handler) AdminGetRegistrations(c *gin.Context) {
 			FirstName     string
 			LastName      string
 			Dob           *string
+			PhotoURL      *string
 			Email         string
 			UserFirstName string
 			UserLastName  string
 		}
 
 		if err := rows.Scan(&reg.ID, &reg.ParentType, &reg.ParentID, &reg.SessionID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
-			&reg.FirstName, &reg.LastName, &reg.Dob, &reg.Email, &reg.UserFirstName, &reg.UserLastName); err != nil {
+			&reg.FirstName, &reg.LastName, &reg.Dob, &reg.PhotoURL, &reg.Email, &reg.UserFirstName, &reg.UserLastName); err != nil {
 			continue
 		}
 
@@ -272,6 +983,7 @@ func (h *Handler) AdminGetRegistrations(c *gin.Context) {
 				"first_name": reg.FirstName,
 				"last_name":  reg.LastName,
 				"dob":        reg.Dob,
+				"photo_url":  reg.PhotoURL,
 			},
 			"user": map[string]interface{}{
 				"email":      reg.Email,
@@ -285,68 +997,13 @@ func (h *Handler) AdminGetRegistrations(c *gin.Context) {
 }
 // Get all program registrations (Admin only)
 func (h *Handler) AdminGetProgramRegistrations(c *gin.Context) {
-	rows, err := h.db.Query(`
-		SELECT r.id, r.parent_id as program_id, r.participant_id, r.status, r.created_at,
-		       prog.title as program_title,
-		       p.first_name, p.last_name, p.dob, p.emergency_contact_name, p.emergency_contact_phone, 
-		       p.notes, p.medical_notes,
-		       u.id as user_id, u.email
-		FROM registrations r
-		JOIN participants p ON r.participant_id = p.id
-		JOIN households h ON p.household_id = h.id
-		JOIN users u ON h.owner_user_id = u.id
-		JOIN programs prog ON r.parent_id = prog.id
-		WHERE r.parent_type = 'program'
-		ORDER BY r.created_at DESC
-		LIMIT 500
-	`)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+	filter, ok := parseProgramRegistrationFilter(c)
+	if !ok {
 		return
 	}
-	defer rows.Close()
 
 	registrations := []map[string]interface{}{}
-	for rows.Next() {
-		var reg struct {
-			ID                     uuid.UUID
-			ProgramID              uuid.UUID
-			ParticipantID          uuid.UUID
-			Status                 string
-			CreatedAt              string
-			ProgramTitle           string
-			FirstName              string
-			LastName               string
-			Dob                    *string
-			EmergencyContactName   *string
-			EmergencyContactPhone  *string
-			Notes                  *string
-			MedicalNotes           *string
-			UserID                 uuid.UUID
-			Email                  string
-		}
-
-		if err := rows.Scan(&reg.ID, &reg.ProgramID, &reg.ParticipantID, &reg.Status, &reg.CreatedAt,
-			&reg.ProgramTitle, &reg.FirstName, &reg.LastName, &reg.Dob, 
-			&reg.EmergencyContactName, &reg.EmergencyContactPhone, &reg.Notes, &reg.MedicalNotes,
-			&reg.UserID, &reg.Email); err != nil {
-			continue
-		}
-
-		// Calculate participant age if DOB is available
-		var participantAge *int
-		if reg.Dob != nil {
-			// Simple age calculation
-			dobYear := (*reg.Dob)[:4]
-			if len(dobYear) == 4 {
-				var year int
-				fmt.Sscanf(dobYear, "%d", &year)
-				age := time.Now().Year() - year
-				participantAge = &age
-			}
-		}
-
-		participantName := reg.FirstName + " " + reg.LastName
+	err := h.db.EachProgramRegistration(filter, 500, func(reg *db.ProgramRegistrationRow) error {
 		emergencyContactName := ""
 		if reg.EmergencyContactName != nil {
 			emergencyContactName = *reg.EmergencyContactName
@@ -367,42 +1024,306 @@ func (h *Handler) AdminGetProgramRegistrations(c *gin.Context) {
 		}
 
 		registrations = append(registrations, map[string]interface{}{
-			"id":                       reg.ID,
-			"program_id":               reg.ProgramID,
-			"program_title":            reg.ProgramTitle,
-			"user_id":                  reg.UserID,
-			"user_email":               reg.Email,
-			"participant_name":         participantName,
-			"participant_age":          participantAge,
-			"emergency_contact_name":   emergencyContactName,
-			"emergency_contact_phone":  emergencyContactPhone,
-			"notes":                    notes,
-			"status":                   reg.Status,
-			"registered_at":            reg.CreatedAt,
+			"id":                      reg.ID,
+			"program_id":              reg.ProgramID,
+			"program_title":           reg.ProgramTitle,
+			"user_id":                 reg.UserID,
+			"user_email":              reg.Email,
+			"participant_name":        reg.FirstName + " " + reg.LastName,
+			"participant_age":         participantAge(reg.DOB),
+			"participant_photo_url":   reg.PhotoURL,
+			"emergency_contact_name":  emergencyContactName,
+			"emergency_contact_phone": emergencyContactPhone,
+			"notes":                   notes,
+			"status":                  reg.Status,
+			"registered_at":           reg.CreatedAt,
+			"answers":                 reg.Answers,
+			"dietary_restrictions":    reg.DietaryRestrictions,
+			"accessibility_needs":     reg.AccessibilityNeeds,
 		})
+		return nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"registrations": registrations})
 }
 
+// AdminExportProgramRegistrations streams program registrations as a printable roster
+// CSV, with the same filters as AdminGetProgramRegistrations but no row limit. Medical
+// notes are only included with include_medical=true, and such an export is written to
+// the PII access log before any row is sent.
+func (h *Handler) AdminExportProgramRegistrations(c *gin.Context) {
+	filter, ok := parseProgramRegistrationFilter(c)
+	if !ok {
+		return
+	}
+	includeMedical := c.Query("include_medical") == "true"
+
+	filename := "roster"
+	if filter.ProgramID != nil {
+		program, err := h.db.GetProgramByID(*filter.ProgramID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+			return
+		}
+		if program == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+			return
+		}
+		filename += "_" + program.Slug
+	}
+
+	if includeMedical {
+		userID, _ := GetUserID(c)
+		details := map[string]string{
+			"program_id":   c.Query("program_id"),
+			"status":       filter.Status,
+			"created_from": c.Query("created_from"),
+			"created_to":   c.Query("created_to"),
+		}
+		// The rows are streamed after the access is logged, so their count is not known
+		if err := h.db.RecordPIIAccess(userID, db.PIIAccessRosterExport, details, 0); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log roster export"})
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s_%s.csv", filename, time.Now().Format("2006-01-02")))
+
+	writer := csv.NewWriter(c.Writer)
+	defer writer.Flush()
+
+	header := []string{
+		"Registration ID", "Program", "Participant", "Age", "Date of Birth",
+		"Emergency Contact", "Emergency Phone", "User Email",
+		"Status", "Registered At",
+	}
+	if includeMedical {
+		header = append(header, "Medical Notes")
+	}
+	writer.Write(header)
+
+	optional := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	err := h.db.EachProgramRegistration(filter, 0, func(reg *db.ProgramRegistrationRow) error {
+		age, dob := "", ""
+		if reg.DOB != nil {
+			age = strconv.Itoa(*participantAge(reg.DOB))
+			dob = reg.DOB.Format("2006-01-02")
+		}
+		record := []string{
+			reg.ID.String(),
+			reg.ProgramTitle,
+			reg.FirstName + " " + reg.LastName,
+			age,
+			dob,
+			optional(reg.EmergencyContactName),
+			optional(reg.EmergencyContactPhone),
+			reg.Email,
+			reg.Status,
+			reg.CreatedAt.Format(time.RFC3339),
+		}
+		if includeMedical {
+			record = append(record, optional(reg.MedicalNotes))
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		// The header and some rows may already be sent, so the download is cut short
+		log.Printf("Failed to export program registrations: %v", err)
+	}
+}
+
+// parseProgramRegistrationFilter reads the program_id, status and created_from/created_to
+// filters of the program registration list. It responds with 400 and returns false when
+// one is invalid.
+func parseProgramRegistrationFilter(c *gin.Context) (db.ProgramRegistrationFilter, bool) {
+	var filter db.ProgramRegistrationFilter
+	if programIDStr := c.Query("program_id"); programIDStr != "" {
+		programID, err := uuid.Parse(programIDStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program_id"})
+			return filter, false
+		}
+		filter.ProgramID = &programID
+	}
+
+	switch status := c.Query("status"); status {
+	case "", "pending", "confirmed", "waitlisted", "offered", "paused", "cancelled":
+		filter.Status = status
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return filter, false
+	}
+
+	createdFrom, createdTo, ok := parseCreatedWindow(c)
+	if !ok {
+		return filter, false
+	}
+	filter.CreatedFrom, filter.CreatedTo = createdFrom, createdTo
+	return filter, true
+}
+
+// participantAge returns a participant's age today, or nil without a date of birth
+func participantAge(dob *time.Time) *int {
+	if dob == nil {
+		return nil
+	}
+	age := db.AgeOn(*dob, time.Now())
+	return &age
+}
+
+// Get a single registration with full detail and status history (Admin only)
+func (h *Handler) AdminGetRegistration(c *gin.Context) {
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	detail, err := h.db.GetRegistrationDetail(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration"})
+		return
+	}
+	if detail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+	if err := h.db.SetCompletionStates(&detail.Registration); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registration": detail})
+}
+
 // Update registration status (Admin only)
 func (h *Handler) AdminUpdateRegistrationStatus(c *gin.Context) {
-	registrationID := c.Param("id")
-	
+	adminID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
 	var req struct {
-		Status string `json:"status" binding:"required,oneof=pending approved waitlisted cancelled completed confirmed"`
+		Status string  `json:"status" binding:"required,oneof=pending confirmed waitlisted cancelled"`
+		Reason *string `json:"reason"`
 	}
-	
+
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	
-	_, err := h.db.Exec("UPDATE registrations SET status = $1 WHERE id = $2", req.Status, registrationID)
+
+	detail, err := h.db.GetRegistrationDetail(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration"})
+		return
+	}
+	if detail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+
+	// The status is compared under the row lock, so two admins making the same change
+	// at once get one update and one 409
+	err = h.regService.UpdateRegistrationStatus(c.Request.Context(), registrationID, req.Status, adminID, req.Reason)
+	if errors.Is(err, db.ErrRegistrationStatusUnchanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration already has status " + req.Status})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
 		return
 	}
-	
+
 	c.JSON(http.StatusOK, gin.H{"message": "Status updated"})
 }
+
+// Approve a registration awaiting approval (Admin only)
+func (h *Handler) AdminApproveRegistration(c *gin.Context) {
+	adminID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkPendingRegistration(c, registrationID) {
+		return
+	}
+
+	result, err := h.regService.ApproveRegistration(c.Request.Context(), registrationID, adminID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to approve registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration": result.Registration,
+		"waitlisted":   result.IsWaitlisted,
+		"position":     result.Position,
+	})
+}
+
+// Reject a registration awaiting approval (Admin only)
+func (h *Handler) AdminRejectRegistration(c *gin.Context) {
+	adminID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req struct {
+		Reason *string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkPendingRegistration(c, registrationID) {
+		return
+	}
+
+	if err := h.db.RejectRegistration(registrationID, adminID, req.Reason); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reject registration"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration rejected"})
+}
+
+// checkPendingRegistration responds with 404 or 409 and returns false unless the
+// registration exists and is awaiting approval
+func (h *Handler) checkPendingRegistration(c *gin.Context, registrationID uuid.UUID) bool {
+	detail, err := h.db.GetRegistrationDetail(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registration"})
+		return false
+	}
+	if detail == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return false
+	}
+	if detail.Status != "pending" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration is not pending approval"})
+		return false
+	}
+	return true
+}
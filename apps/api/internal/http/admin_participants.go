@@ -0,0 +1,178 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+// AdminSearchParticipants finds participants across all households by name and optional
+// date of birth for front-desk lookups. Every search is written to the PII access log.
+func (h *Handler) AdminSearchParticipants(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if len(query) < 2 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "q must be at least 2 characters"})
+		return
+	}
+
+	search := db.ParticipantSearch{Query: query, Limit: 25}
+	if d := c.Query("dob"); d != "" {
+		dob, err := time.Parse("2006-01-02", d)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "dob must be YYYY-MM-DD"})
+			return
+		}
+		search.DOB = &dob
+	}
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 100"})
+			return
+		}
+		search.Limit = parsed
+	}
+	if o := c.Query("offset"); o != "" {
+		parsed, err := strconv.Atoi(o)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must not be negative"})
+			return
+		}
+		search.Offset = parsed
+	}
+
+	participants, total, err := h.db.SearchParticipants(search)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search participants"})
+		return
+	}
+
+	// Results are only returned once the search has been logged
+	details := map[string]string{"q": query}
+	if search.DOB != nil {
+		details["dob"] = search.DOB.Format("2006-01-02")
+	}
+	if err := h.db.RecordPIIAccess(userID, db.PIIAccessParticipantSearch, details, len(participants)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log participant search"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"participants": participants,
+		"total":        total,
+		"limit":        search.Limit,
+		"offset":       search.Offset,
+	})
+}
+
+// AdminGetParticipantProfile returns a participant's household, guardians, forms, waivers,
+// registrations and bookings in one response. Medical notes and medical forms are only
+// included with include_medical=true. Every view is written to the PII access log.
+func (h *Handler) AdminGetParticipantProfile(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	participantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant ID"})
+		return
+	}
+	includeMedical := c.Query("include_medical") == "true"
+
+	profile, err := h.db.GetParticipantProfile(participantID, includeMedical)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get participant profile"})
+		return
+	}
+	if profile == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+		return
+	}
+
+	// The profile is only returned once the view has been logged
+	details := map[string]string{
+		"participant_id":  participantID.String(),
+		"include_medical": strconv.FormatBool(includeMedical),
+	}
+	if err := h.db.RecordPIIAccess(userID, db.PIIAccessParticipantProfile, details, 1); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log participant profile access"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"profile": profile})
+}
+
+// AdminGetParticipantRegistrations returns a participant's full registration timeline
+// across programs and events, cancelled registrations included, oldest first with each
+// registration's status changes
+func (h *Handler) AdminGetParticipantRegistrations(c *gin.Context) {
+	participantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant ID"})
+		return
+	}
+
+	participant, err := h.db.GetParticipantByID(participantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get participant"})
+		return
+	}
+	if participant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+		return
+	}
+
+	registrations, err := h.db.GetParticipantRegistrationHistory(participantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get registrations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registrations": registrations})
+}
+
+// AdminSetParticipantLegalHold places or lifts a legal hold that exempts a participant
+// from the retention purge of sensitive fields
+func (h *Handler) AdminSetParticipantLegalHold(c *gin.Context) {
+	participantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant ID"})
+		return
+	}
+
+	var req struct {
+		LegalHold *bool   `json:"legal_hold" binding:"required"`
+		Reason    *string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	found, err := h.db.SetParticipantLegalHold(participantID, *req.LegalHold, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to set legal hold"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"participant_id": participantID, "legal_hold": *req.LegalHold})
+}
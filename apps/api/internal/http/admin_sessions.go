@@ -0,0 +1,321 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+// AdminCreateProgramSession adds a session to a program, such as one night of a
+// Tuesday/Thursday split
+func (h *Handler) AdminCreateProgramSession(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	h.createSession(c, "program", programID)
+}
+
+// AdminCreateEventSession adds an occurrence to an event, such as one week of a weekly
+// market
+func (h *Handler) AdminCreateEventSession(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	event, err := h.db.GetEventByID(eventID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get event"})
+		return
+	}
+	if event == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		return
+	}
+
+	h.createSession(c, "event", eventID)
+}
+
+// createSession creates a session of a program or event from the request body, which
+// needs starts_at before ends_at and a positive capacity_override when one is set
+func (h *Handler) createSession(c *gin.Context, parentType string, parentID uuid.UUID) {
+	var req struct {
+		StartsAt         string `json:"starts_at" binding:"required"`
+		EndsAt           string `json:"ends_at" binding:"required"`
+		CapacityOverride *int   `json:"capacity_override" binding:"omitempty,min=1"`
+		IsActive         *bool  `json:"is_active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startsAt, endsAt, ok := parseSessionTimes(c, &req.StartsAt, &req.EndsAt)
+	if !ok {
+		return
+	}
+	if !startsAt.Before(*endsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at must be before ends_at"})
+		return
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	session, err := h.db.CreateSession(&db.Session{
+		ParentType:       parentType,
+		ParentID:         parentID,
+		StartsAt:         startsAt,
+		EndsAt:           endsAt,
+		CapacityOverride: req.CapacityOverride,
+		IsActive:         isActive,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create session"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, session)
+}
+
+// AdminUpdateSession applies a partial update to a program's session. Deactivating a
+// session that still has confirmed registrations needs ?force=true. A null
+// capacity_override clears it, so the session uses its parent's capacity again.
+// Changing capacity_override promotes from the session's waitlist into any new spots;
+// with ?reconcile=true, a lowered capacity also moves the most recently confirmed
+// registrations to the top of the waitlist instead of leaving the session over-booked.
+func (h *Handler) AdminUpdateSession(c *gin.Context) {
+	session, ok := h.loadAdminSession(c, "", uuid.Nil)
+	if !ok {
+		return
+	}
+	h.updateSession(c, session)
+}
+
+// AdminUpdateEventSession applies a partial update to one of an event's sessions, as
+// AdminUpdateSession does
+func (h *Handler) AdminUpdateEventSession(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	session, ok := h.loadAdminSession(c, "event", eventID)
+	if !ok {
+		return
+	}
+	h.updateSession(c, session)
+}
+
+// AdminDeleteSession deactivates a program's session, keeping its registrations. A session that
+// still has confirmed registrations needs ?force=true.
+func (h *Handler) AdminDeleteSession(c *gin.Context) {
+	session, ok := h.loadAdminSession(c, "", uuid.Nil)
+	if !ok {
+		return
+	}
+	h.deactivateSession(c, session)
+}
+
+// AdminDeleteEventSession deactivates one of an event's sessions, as AdminDeleteSession does
+func (h *Handler) AdminDeleteEventSession(c *gin.Context) {
+	eventID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+	session, ok := h.loadAdminSession(c, "event", eventID)
+	if !ok {
+		return
+	}
+	h.deactivateSession(c, session)
+}
+
+// loadAdminSession loads the session named by the session_id parameter, or id when there
+// is none, responding with 400 or 404 and returning false when it is missing. With a
+// parentType it must belong to that parent; without one it must be a program's, since
+// an event's sessions are only changed through the event's routes. An API key changing
+// an event's session needs the events:write scope as well as the route's own.
+func (h *Handler) loadAdminSession(c *gin.Context, parentType string, parentID uuid.UUID) (*db.Session, bool) {
+	param := c.Param("session_id")
+	if param == "" {
+		param = c.Param("id")
+	}
+	sessionID, err := uuid.Parse(param)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return nil, false
+	}
+
+	session, err := h.db.GetSessionByID(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return nil, false
+	}
+	if session == nil || (parentType == "" && session.ParentType != "program") ||
+		(parentType != "" && (session.ParentType != parentType || session.ParentID != parentID)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return nil, false
+	}
+
+	if apiKey, ok := GetAPIKey(c); ok && session.ParentType == "event" && !apiKey.HasScope(db.ScopeEventsWrite) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":         "API key does not have the required scope",
+			"missing_scope": db.ScopeEventsWrite,
+		})
+		return nil, false
+	}
+
+	return session, true
+}
+
+// updateSession applies the partial update in the request body to a session, then
+// reconciles the session's registrations with a changed capacity_override
+func (h *Handler) updateSession(c *gin.Context, session *db.Session) {
+	var req struct {
+		StartsAt         *string         `json:"starts_at"`
+		EndsAt           *string         `json:"ends_at"`
+		CapacityOverride json.RawMessage `json:"capacity_override"`
+		IsActive         *bool           `json:"is_active"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// A null capacity_override clears it; any other value must be a positive number
+	clearCapacityOverride := string(req.CapacityOverride) == "null"
+	var capacityOverride *int
+	if len(req.CapacityOverride) > 0 && !clearCapacityOverride {
+		if err := json.Unmarshal(req.CapacityOverride, &capacityOverride); err != nil || *capacityOverride < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "capacity_override must be a positive number or null"})
+			return
+		}
+	}
+
+	startsAt, endsAt, ok := parseSessionTimes(c, req.StartsAt, req.EndsAt)
+	if !ok {
+		return
+	}
+
+	// The times are checked as they will be after the update
+	if startsAt == nil {
+		startsAt = session.StartsAt
+	}
+	if endsAt == nil {
+		endsAt = session.EndsAt
+	}
+	if startsAt != nil && endsAt != nil && !startsAt.Before(*endsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "starts_at must be before ends_at"})
+		return
+	}
+
+	if req.IsActive != nil && !*req.IsActive && session.IsActive && !h.checkSessionDeactivation(c, session.ID) {
+		return
+	}
+
+	err := h.db.UpdateSession(session.ID, &db.SessionUpdate{
+		StartsAt:              startsAt,
+		EndsAt:                endsAt,
+		CapacityOverride:      capacityOverride,
+		IsActive:              req.IsActive,
+		ClearCapacityOverride: clearCapacityOverride,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update session"})
+		return
+	}
+
+	if capacityOverride != nil || clearCapacityOverride {
+		adminID, _ := GetUserID(c)
+		_, _, err := h.db.ReconcileSessionCapacity(session, c.Query("reconcile") == "true", &adminID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Session updated but failed to reconcile its capacity"})
+			return
+		}
+	}
+
+	updated, err := h.db.GetSessionByID(session.ID)
+	if err != nil || updated == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// deactivateSession hides a session from registration unless it still has confirmed
+// registrations and the request does not pass ?force=true
+func (h *Handler) deactivateSession(c *gin.Context, session *db.Session) {
+	if !h.checkSessionDeactivation(c, session.ID) {
+		return
+	}
+
+	if err := h.db.DeactivateSession(session.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session deactivated"})
+}
+
+// checkSessionDeactivation responds with 409 and returns false when the session still
+// has confirmed registrations, unless the request passes ?force=true
+func (h *Handler) checkSessionDeactivation(c *gin.Context, sessionID uuid.UUID) bool {
+	if c.Query("force") == "true" {
+		return true
+	}
+
+	confirmed, err := h.db.CountSessionConfirmedRegistrations(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check session registrations"})
+		return false
+	}
+	if confirmed > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":                   "Session has confirmed registrations; pass force=true to deactivate it anyway",
+			"confirmed_registrations": confirmed,
+		})
+		return false
+	}
+	return true
+}
+
+// parseSessionTimes parses optional RFC3339 session times, responding with 400 and
+// returning false when one is malformed
+func parseSessionTimes(c *gin.Context, startsAt, endsAt *string) (*time.Time, *time.Time, bool) {
+	starts, err := parseOptionalTime(startsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid starts_at format (use RFC3339)"})
+		return nil, nil, false
+	}
+	ends, err := parseOptionalTime(endsAt, time.RFC3339)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ends_at format (use RFC3339)"})
+		return nil, nil, false
+	}
+	return starts, ends, true
+}
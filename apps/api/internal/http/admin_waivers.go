@@ -1,8 +1,11 @@
 package http
 
 import (
+	"encoding/csv"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -241,6 +244,143 @@ func (h *Handler) AdminRemoveWaiverFromProgram(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Waiver removed from program successfully"})
 }
 
+// AdminAssignFormToProgram assigns a form template to a program
+func (h *Handler) AdminAssignFormToProgram(c *gin.Context) {
+	var req struct {
+		ProgramID      string `json:"program_id" binding:"required"`
+		FormTemplateID string `json:"form_template_id" binding:"required"`
+		IsRequired     *bool  `json:"is_required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	programID, err := uuid.Parse(req.ProgramID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	formTemplateID, err := uuid.Parse(req.FormTemplateID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form template ID"})
+		return
+	}
+
+	isRequired := true
+	if req.IsRequired != nil {
+		isRequired = *req.IsRequired
+	}
+
+	created, err := h.db.AssignFormToProgram(&db.ProgramForm{
+		ProgramID:      programID,
+		FormTemplateID: formTemplateID,
+		IsRequired:     isRequired,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to assign form to program"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"program_form": created})
+}
+
+// AdminRemoveFormFromProgram removes a form template from a program
+func (h *Handler) AdminRemoveFormFromProgram(c *gin.Context) {
+	programIDStr := c.Query("program_id")
+	formTemplateIDStr := c.Query("form_template_id")
+
+	if programIDStr == "" || formTemplateIDStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "program_id and form_template_id are required"})
+		return
+	}
+
+	programID, err := uuid.Parse(programIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	formTemplateID, err := uuid.Parse(formTemplateIDStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form template ID"})
+		return
+	}
+
+	err = h.db.RemoveFormFromProgram(programID, formTemplateID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to remove form from program"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Form removed from program successfully"})
+}
+
+// AdminGetProgramCompliance reports which confirmed participants have completed the
+// program's required waivers and forms, as JSON or, with ?format=csv, as a CSV download
+func (h *Handler) AdminGetProgramCompliance(c *gin.Context) {
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+
+	report, err := h.db.GetProgramCompliance(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program compliance"})
+		return
+	}
+
+	if c.Query("format") != "csv" {
+		c.JSON(http.StatusOK, report)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=compliance_%s_%s.csv", program.Slug, time.Now().Format("2006-01-02")))
+
+	writer := csv.NewWriter(c.Writer)
+	defer writer.Flush()
+
+	header := []string{"Participant ID", "Participant"}
+	for _, waiver := range report.Waivers {
+		header = append(header, "Waiver: "+waiver.Title)
+	}
+	for _, form := range report.Forms {
+		header = append(header, "Form: "+form.Title)
+	}
+	writer.Write(append(header, "Compliant"))
+
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+	for _, participant := range report.Participants {
+		row := []string{participant.ParticipantID.String(), participant.Name}
+		for _, waiver := range participant.Waivers {
+			row = append(row, yesNo(waiver.Complete))
+		}
+		for _, form := range participant.Forms {
+			row = append(row, yesNo(form.Complete))
+		}
+		writer.Write(append(row, yesNo(participant.Compliant)))
+	}
+}
+
 // AdminGetAllFormTemplates retrieves all form templates
 func (h *Handler) AdminGetAllFormTemplates(c *gin.Context) {
 	activeOnly := c.Query("active_only") == "true"
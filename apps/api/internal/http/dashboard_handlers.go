@@ -96,8 +96,15 @@ func (h *Handler) GetDashboardSummary(c *gin.Context) {
 		summary.UpcomingEvents7d = upcomingEvents
 	}
 
-	// Pending bookings (Sterling doesn't have bookings table yet, return 0 for now)
-	summary.PendingBookings = 0
+	// Upcoming bookings, awaiting approval or confirmed, that have not yet started
+	var pendingBookings int
+	err = h.db.QueryRow(
+		`SELECT COUNT(*) FROM facility_bookings WHERE status IN ('pending', 'confirmed') AND start_time > $1`,
+		now,
+	).Scan(&pendingBookings)
+	if err == nil {
+		summary.PendingBookings = pendingBookings
+	}
 
 	// Registrations MTD (month-to-date)
 	var registrationsMTD int
@@ -109,8 +116,11 @@ func (h *Handler) GetDashboardSummary(c *gin.Context) {
 		summary.RegistrationsMTD = registrationsMTD
 	}
 
-	// Facility utilization (7 days) - Sterling doesn't have facilities yet, return 0 for now
-	summary.Utilization7dPct = 0.0
+	// Facility utilization over the last 7 days
+	utilization, err := h.db.GetFacilityUtilization(now.AddDate(0, 0, -7), now)
+	if err == nil && len(utilization) == 1 {
+		summary.Utilization7dPct = utilization[0].Pct()
+	}
 
 	c.JSON(http.StatusOK, summary)
 }
@@ -162,7 +172,7 @@ func (h *Handler) GetDashboardUpcomingEvents(c *gin.Context) {
 		// Count registered participants for this event
 		var registered int
 		h.db.QueryRow(
-			`SELECT COUNT(*) FROM registrations WHERE parent_type = 'event' AND parent_id = $1 AND status = 'confirmed'`,
+			`SELECT COALESCE(SUM(seats), 0) FROM registrations WHERE parent_type = 'event' AND parent_id = $1 AND status = 'confirmed'`,
 			e.ID,
 		).Scan(&registered)
 		e.Registered = registered
@@ -175,23 +185,59 @@ func (h *Handler) GetDashboardUpcomingEvents(c *gin.Context) {
 
 // GetRecentBookings returns the most recent booking requests
 func (h *Handler) GetRecentBookings(c *gin.Context) {
-	// Sterling doesn't have bookings table yet, return empty array
+	rows, err := h.db.Query(
+		`SELECT
+			b.id, b.created_at, f.name, b.start_time, b.end_time,
+			u.first_name || ' ' || u.last_name, u.email, b.status
+		FROM facility_bookings b
+		JOIN facilities f ON f.id = b.facility_id
+		JOIN users u ON u.id = b.user_id
+		ORDER BY b.created_at DESC
+		LIMIT 10`,
+	)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch bookings"})
+		return
+	}
+	defer rows.Close()
+
 	bookings := []RecentBooking{}
+	for rows.Next() {
+		var b RecentBooking
+		err := rows.Scan(&b.ID, &b.CreatedAt, &b.FacilityName, &b.SlotStartsAt, &b.SlotEndsAt,
+			&b.RequesterName, &b.RequesterEmail, &b.Status)
+		if err != nil {
+			continue
+		}
+		bookings = append(bookings, b)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"bookings": bookings})
 }
 
-// GetUtilizationSeries returns facility utilization for the past 8 weeks
+// GetUtilizationSeries returns facility utilization for each of the past 8 complete
+// weeks, Monday to Sunday, oldest first
 func (h *Handler) GetUtilizationSeries(c *gin.Context) {
-	// Sterling doesn't have facilities yet, return empty series
-	series := []UtilizationPoint{}
-
-	// Generate 8 weeks of zero data
 	now := time.Now()
-	for i := 7; i >= 0; i-- {
-		weekStart := now.AddDate(0, 0, -7*(i+1))
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	thisWeek := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, now.Location())
+
+	bounds := make([]time.Time, 9)
+	for i := range bounds {
+		bounds[i] = thisWeek.AddDate(0, 0, -7*(8-i))
+	}
+
+	periods, err := h.db.GetFacilityUtilization(bounds...)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to calculate utilization"})
+		return
+	}
+
+	series := []UtilizationPoint{}
+	for _, p := range periods {
 		series = append(series, UtilizationPoint{
-			WeekStart: weekStart.Format("2006-01-02"),
-			Pct:       0.0,
+			WeekStart: p.Start.Format("2006-01-02"),
+			Pct:       p.Pct(),
 		})
 	}
 
@@ -204,18 +250,12 @@ func (h *Handler) GetOnboarding(c *gin.Context) {
 		UpdatedAt: time.Now(),
 	}
 
-	// Check first program
-	var programCount int
-	h.db.QueryRow(
-		`SELECT COUNT(*) FROM programs`,
-	).Scan(&programCount)
-	checklist.FirstProgram = programCount > 0
-
-	// Check first facility - Sterling doesn't have facilities yet
-	checklist.FirstFacility = false
-
-	// Check first booking - Sterling doesn't have bookings yet
-	checklist.FirstBooking = false
+	progress, err := h.db.GetOnboardingProgress()
+	if err == nil {
+		checklist.FirstProgram = progress.FirstProgram
+		checklist.FirstFacility = progress.FirstFacility
+		checklist.FirstBooking = progress.FirstBooking
+	}
 
 	// Logo and homepage - not implemented yet
 	checklist.LogoUploaded = false
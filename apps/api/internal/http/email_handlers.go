@@ -0,0 +1,255 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/core"
+	"sterling-rec/api/internal/db"
+)
+
+// EmailWebhook receives delivery, bounce and complaint events from the email provider.
+// The body must be signed with EMAIL_WEBHOOK_SECRET in the X-Email-Signature header.
+// Malformed events are skipped rather than failing the batch, so the provider does not
+// retry it forever.
+func (h *Handler) EmailWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+		return
+	}
+
+	if err := core.VerifyEmailWebhookSignature(body, c.GetHeader("X-Email-Signature")); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Events []struct {
+			Type       string  `json:"type"`
+			MessageID  string  `json:"message_id"`
+			Recipient  *string `json:"recipient"`
+			BounceType *string `json:"bounce_type"`
+			Reason     *string `json:"reason"`
+			Timestamp  *string `json:"timestamp"`
+		} `json:"events"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid webhook payload"})
+		return
+	}
+
+	var processed, unmatched, ignored int
+	for _, e := range req.Events {
+		switch e.Type {
+		case db.EmailEventDelivered, db.EmailEventBounce, db.EmailEventComplaint:
+		default:
+			ignored++
+			continue
+		}
+		if strings.TrimSpace(e.MessageID) == "" {
+			ignored++
+			continue
+		}
+		if e.BounceType != nil && *e.BounceType != "hard" && *e.BounceType != "soft" {
+			e.BounceType = nil
+		}
+		occurredAt, err := parseOptionalTime(e.Timestamp, time.RFC3339)
+		if err != nil {
+			ignored++
+			continue
+		}
+
+		matched, err := h.db.RecordEmailEvent(db.EmailEvent{
+			MessageID:  strings.TrimSpace(e.MessageID),
+			EventType:  e.Type,
+			BounceType: e.BounceType,
+			Recipient:  e.Recipient,
+			Detail:     e.Reason,
+			OccurredAt: occurredAt,
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record email event"})
+			return
+		}
+		processed++
+		if !matched {
+			unmatched++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"processed": processed,
+		"unmatched": unmatched,
+		"ignored":   ignored,
+	})
+}
+
+// AdminGetEmailSuppressions lists addresses that hard-bounced or complained and are no
+// longer emailed
+func (h *Handler) AdminGetEmailSuppressions(c *gin.Context) {
+	suppressions, err := h.db.GetEmailSuppressions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email suppressions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"suppressions": suppressions})
+}
+
+// AdminDeleteEmailSuppression lets a suppressed address be emailed again
+func (h *Handler) AdminDeleteEmailSuppression(c *gin.Context) {
+	email := c.Param("email")
+
+	suppressed, err := h.db.IsEmailSuppressed(email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get email suppression"})
+		return
+	}
+	if !suppressed {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email suppression not found"})
+		return
+	}
+
+	if err := h.db.DeleteEmailSuppression(email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete email suppression"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Email suppression removed"})
+}
+
+// GetMyNotificationHistory lists the emails sent to the current user, so they can check
+// a confirmation went out and arrived
+func (h *Handler) GetMyNotificationHistory(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	h.notificationHistory(c, userID)
+}
+
+// AdminGetUserNotificationHistory lists the emails sent to a user, for support
+func (h *Handler) AdminGetUserNotificationHistory(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	h.notificationHistory(c, userID)
+}
+
+// notificationHistory responds with a user's notification history, newest first
+func (h *Handler) notificationHistory(c *gin.Context, userID uuid.UUID) {
+	limit, ok := notificationListLimit(c)
+	if !ok {
+		return
+	}
+
+	history, err := h.db.GetNotificationHistory(userID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get notification history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": history})
+}
+
+// AdminGetFailedNotifications lists dead-lettered notifications, most recently failed
+// first, with their payload and last error
+func (h *Handler) AdminGetFailedNotifications(c *gin.Context) {
+	limit, ok := notificationListLimit(c)
+	if !ok {
+		return
+	}
+
+	notifications, err := h.db.GetFailedNotifications(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get failed notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notifications": notifications})
+}
+
+// AdminRetryNotification returns a dead-lettered notification to the queue with its
+// attempts reset
+func (h *Handler) AdminRetryNotification(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid notification ID"})
+		return
+	}
+
+	found, err := h.db.RetryNotification(id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry notification"})
+		return
+	}
+	if !found {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Failed notification not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Notification queued for retry"})
+}
+
+// AdminRenderEmailTemplate renders an email template with a registration's data, as the
+// notification queue would assemble it, without sending it
+func (h *Handler) AdminRenderEmailTemplate(c *gin.Context) {
+	registrationID, err := uuid.Parse(c.Query("registration_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A valid registration_id is required"})
+		return
+	}
+
+	email, err := h.emailService.PreviewRegistrationEmail(c.Param("key"), registrationID)
+	if errors.Is(err, core.ErrTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Email template not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to render email template"})
+		return
+	}
+	if email == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, email)
+}
+
+// notificationListLimit reads ?limit= for notification lists (default 100, at most 500)
+func notificationListLimit(c *gin.Context) (int, bool) {
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return 0, false
+		}
+		limit = parsed
+	}
+	return limit, true
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,11 +10,12 @@ import (
 	"github.com/google/uuid"
 
 	"sterling-rec/api/internal/core"
+	"sterling-rec/api/internal/db"
 )
 
 // GetFacilities retrieves all active facilities (public)
 func (h *Handler) GetFacilities(c *gin.Context) {
-	facilities, err := h.db.GetAllFacilities(true) // active only
+	facilities, err := h.db.GetPublishedFacilities()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facilities"})
 		return
@@ -47,7 +49,7 @@ func (h *Handler) GetFacilityBySlug(c *gin.Context) {
 		return
 	}
 
-	if !facility.IsActive {
+	if !facility.IsPublic(time.Now()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
 		return
 	}
@@ -60,10 +62,26 @@ func (h *Handler) GetFacilityBySlug(c *gin.Context) {
 	}
 	facility.AvailabilityWindows = windows
 
+	rules, err := h.db.GetPricingRules(facility.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get pricing rules"})
+		return
+	}
+	facility.PricingRules = rules
+
+	units, err := h.db.GetFacilityUnits(facility.ID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility units"})
+		return
+	}
+	facility.Units = units
+
 	c.JSON(http.StatusOK, gin.H{"facility": facility})
 }
 
-// GetAvailability checks availability for a facility (public)
+// GetAvailability checks availability for a facility (public). A participant_id, which
+// must be in the signed-in user's household, also leaves out slots clashing with that
+// participant's bookings elsewhere.
 func (h *Handler) GetAvailability(c *gin.Context) {
 	slug := c.Param("slug")
 
@@ -105,11 +123,27 @@ func (h *Handler) GetAvailability(c *gin.Context) {
 		return
 	}
 
-	if facility == nil || !facility.IsActive {
+	if facility == nil || !facility.IsPublic(time.Now()) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
 		return
 	}
 
+	audience, err := h.requestAudience(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	// Optionally leave out slots clashing with one of the user's participants' bookings
+	var participantID *uuid.UUID
+	if participantIDStr := c.Query("participant_id"); participantIDStr != "" {
+		id, ok := h.householdParticipantID(c, participantIDStr)
+		if !ok {
+			return
+		}
+		participantID = &id
+	}
+
 	// Get available slots
 	slots, err := h.facilitiesService.GetAvailableSlots(
 		c.Request.Context(),
@@ -117,6 +151,8 @@ func (h *Handler) GetAvailability(c *gin.Context) {
 		startDate,
 		endDate.AddDate(0, 0, 1), // Include end date
 		duration,
+		audience,
+		participantID,
 	)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -126,6 +162,236 @@ func (h *Handler) GetAvailability(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"slots": slots})
 }
 
+// householdParticipantID parses a participant ID that must belong to the signed-in user's
+// household, responding with an error when it does not
+func (h *Handler) householdParticipantID(c *gin.Context, idStr string) (uuid.UUID, bool) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sign in to check availability for a participant"})
+		return uuid.Nil, false
+	}
+
+	participantID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
+		return uuid.Nil, false
+	}
+
+	participant, err := h.db.GetParticipantByID(participantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return uuid.Nil, false
+	}
+	if participant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+		return uuid.Nil, false
+	}
+
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil || household == nil || participant.HouseholdID != household.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view this participant"})
+		return uuid.Nil, false
+	}
+
+	return participantID, true
+}
+
+// GetNextAvailable returns the earliest available slot of a duration (public)
+func (h *Handler) GetNextAvailable(c *gin.Context) {
+	slug := c.Param("slug")
+
+	durationStr := c.Query("duration")
+	if durationStr == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "duration is required"})
+		return
+	}
+
+	var duration int
+	_, err := fmt.Sscanf(durationStr, "%d", &duration)
+	if err != nil || duration <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration (must be positive integer minutes)"})
+		return
+	}
+
+	after := time.Now()
+	if afterStr := c.Query("after"); afterStr != "" {
+		after, err = time.Parse(time.RFC3339, afterStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid after format (use RFC3339)"})
+			return
+		}
+	}
+
+	// Get facility
+	facility, err := h.db.GetFacilityBySlug(slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+
+	if facility == nil || !facility.IsPublic(time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	if duration < facility.MinBookingDurationMinutes || duration > facility.MaxBookingDurationMinutes {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("duration must be between %d and %d minutes",
+			facility.MinBookingDurationMinutes, facility.MaxBookingDurationMinutes)})
+		return
+	}
+
+	audience, err := h.requestAudience(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	slot, err := h.facilitiesService.GetNextAvailableSlot(c.Request.Context(), facility.ID, after, duration, audience)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// slot is null when nothing is available within the advance booking limit
+	c.JSON(http.StatusOK, gin.H{"slot": slot})
+}
+
+// GetFacilityHours returns the facility's opening hours for each weekday of the coming
+// week, optionally with the closures falling in that week (public)
+func (h *Handler) GetFacilityHours(c *gin.Context) {
+	slug := c.Param("slug")
+
+	facility, err := h.db.GetFacilityBySlug(slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+
+	if facility == nil || !facility.IsPublic(time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	// Days are the facility's own, in its time zone
+	loc := facility.TimeLocation()
+	now := time.Now().In(loc)
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	if dateStr := c.Query("date"); dateStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateStr, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format (use YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+
+	audience, err := h.requestAudience(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	windows, err := h.db.GetAvailabilityWindows(facility.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get availability windows"})
+		return
+	}
+
+	hours := db.WeeklyHours(windows, audience, from)
+
+	if c.Query("include_closures") == "true" {
+		closures, err := h.db.GetClosures(facility.ID, from, from.AddDate(0, 0, 7))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get closures"})
+			return
+		}
+		db.OverlayClosures(hours, closures, loc)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"facility_id": facility.ID,
+		"from":        from.Format("2006-01-02"),
+		"hours":       hours,
+	})
+}
+
+// GetBookingQuote checks a proposed booking and returns its availability, price and cancellation deadline (public)
+func (h *Handler) GetBookingQuote(c *gin.Context) {
+	slug := c.Param("slug")
+
+	var req struct {
+		StartTime        string `json:"start_time" binding:"required"`
+		EndTime          string `json:"end_time" binding:"required"`
+		ParticipantCount int    `json:"participant_count" binding:"min=0"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
+		return
+	}
+
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
+		return
+	}
+
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
+	}
+
+	facility, err := h.db.GetFacilityBySlug(slug)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get facility"})
+		return
+	}
+
+	if facility == nil || !facility.IsPublic(time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Facility not found"})
+		return
+	}
+
+	user, err := h.requestUser(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+
+	quote, err := h.facilitiesService.QuoteBooking(c.Request.Context(), facility, startTime, endTime, req.ParticipantCount, user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"quote": quote})
+}
+
+// requestUser returns the signed-in user, or nil for anonymous requests
+func (h *Handler) requestUser(c *gin.Context) (*db.User, error) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		return nil, nil
+	}
+	return h.db.GetUserByID(userID)
+}
+
+// requestAudience returns the booking audience of the signed-in user, or public for
+// anonymous requests
+func (h *Handler) requestAudience(c *gin.Context) (string, error) {
+	user, err := h.requestUser(c)
+	if err != nil || user == nil {
+		return db.AudiencePublic, err
+	}
+	return user.Audience(), nil
+}
+
 // CreateBooking creates a new facility booking (authenticated)
 func (h *Handler) CreateBooking(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -134,102 +400,292 @@ func (h *Handler) CreateBooking(c *gin.Context) {
 		return
 	}
 
+	bookingReq, ok := h.bindBookingRequest(c, userID)
+	if !ok {
+		return
+	}
+
+	// Create booking using service (with locking)
+	booking, err := h.facilitiesService.CreateBooking(c.Request.Context(), bookingReq)
+	if err != nil {
+		bookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"booking": booking})
+}
+
+// ReserveBooking holds a slot at a facility that requires confirmation (authenticated).
+// The hold must be confirmed through ConfirmBooking before hold_expires_at.
+func (h *Handler) ReserveBooking(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	bookingReq, ok := h.bindBookingRequest(c, userID)
+	if !ok {
+		return
+	}
+
+	booking, err := h.facilitiesService.ReserveBooking(c.Request.Context(), bookingReq)
+	if err != nil {
+		bookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"booking": booking})
+}
+
+// ConfirmBooking finalizes the user's booking hold (authenticated)
+func (h *Handler) ConfirmBooking(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	bookingID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return
+	}
+
+	booking, err := h.facilitiesService.ConfirmBooking(c.Request.Context(), bookingID, userID)
+	if err != nil {
+		c.JSON(bookingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"booking": booking})
+}
+
+// bookingErrorStatus maps an error confirming or cancelling a user's booking to 404 when
+// it does not exist, 403 when it is someone else's and 400 otherwise
+func bookingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, core.ErrBookingNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, core.ErrBookingForbidden):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// bindBookingRequest parses the body of a booking or reservation request, responding
+// with 400 when it is invalid
+func (h *Handler) bindBookingRequest(c *gin.Context, userID uuid.UUID) (core.BookingRequest, bool) {
 	var req struct {
 		FacilityID     string   `json:"facility_id" binding:"required"`
 		ParticipantIDs []string `json:"participant_ids"`
 		StartTime      string   `json:"start_time" binding:"required"`
 		EndTime        string   `json:"end_time" binding:"required"`
+		UnitID         *string  `json:"unit_id"`
 		Notes          *string  `json:"notes"`
 		IdempotencyKey *string  `json:"idempotency_key"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		return core.BookingRequest{}, false
 	}
 
 	// Parse facility ID
 	facilityID, err := uuid.Parse(req.FacilityID)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility_id"})
-		return
+		return core.BookingRequest{}, false
 	}
 
 	// Parse times
 	startTime, err := time.Parse(time.RFC3339, req.StartTime)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
-		return
+		return core.BookingRequest{}, false
 	}
 
 	endTime, err := time.Parse(time.RFC3339, req.EndTime)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
-		return
+		return core.BookingRequest{}, false
 	}
 
 	if !endTime.After(startTime) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return core.BookingRequest{}, false
+	}
+
+	unitID, err := parseOptionalUUID(req.UnitID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit_id"})
+		return core.BookingRequest{}, false
+	}
+
+	householdID, participantIDs, ok := h.bookingParticipants(userID, req.ParticipantIDs)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
+		return core.BookingRequest{}, false
+	}
+
+	return core.BookingRequest{
+		FacilityID:     facilityID,
+		UserID:         userID,
+		HouseholdID:    householdID,
+		ParticipantIDs: participantIDs,
+		StartTime:      startTime,
+		EndTime:        endTime,
+		UnitID:         unitID,
+		Notes:          req.Notes,
+		IdempotencyKey: req.IdempotencyKey,
+	}, true
+}
+
+// bookingError responds to a failed booking or reservation, with the reason code when
+// the slot was unavailable or the facility books in two steps
+func bookingError(c *gin.Context, err error) {
+	var unavailable *db.AvailabilityError
+	if errors.As(err, &unavailable) {
+		body := gin.H{"error": err.Error(), "code": unavailable.Code, "message": unavailable.Message}
+		if unavailable.Closure != nil {
+			body["closure"] = unavailable.Closure
+		}
+		c.JSON(http.StatusBadRequest, body)
+		return
+	}
+	if errors.Is(err, core.ErrConfirmationRequired) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "CONFIRMATION_REQUIRED"})
 		return
 	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+}
 
+// bookingParticipants resolves the booking user's household and parses the participants
+// booked for, which must belong to it. Users without a household may still book.
+func (h *Handler) bookingParticipants(userID uuid.UUID, ids []string) (*uuid.UUID, []uuid.UUID, bool) {
 	// Parse participant IDs
 	var participantIDs []uuid.UUID
-	for _, pidStr := range req.ParticipantIDs {
+	for _, pidStr := range ids {
 		pid, err := uuid.Parse(pidStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
-			return
+			return nil, nil, false
 		}
 		participantIDs = append(participantIDs, pid)
 	}
 
-	// Get user's household
+	// Get user's household (the user may not have one yet, that's okay)
 	var householdID *uuid.UUID
-	err = h.db.QueryRow(`
-		SELECT id FROM households WHERE owner_user_id = $1
-	`, userID).Scan(&householdID)
-	if err != nil {
-		// User may not have a household yet, that's okay
-		householdID = nil
+	if household, err := h.db.GetUserHousehold(userID); err == nil && household != nil {
+		householdID = &household.ID
 	}
 
 	// Verify all participants belong to the user's household
 	if len(participantIDs) > 0 && householdID != nil {
 		for _, pid := range participantIDs {
 			var count int
-			err = h.db.QueryRow(`
+			err := h.db.QueryRow(`
 				SELECT COUNT(*) FROM participants
 				WHERE id = $1 AND household_id = $2
 			`, pid, householdID).Scan(&count)
 			if err != nil || count == 0 {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
-				return
+				return nil, nil, false
 			}
 		}
 	}
 
-	// Create booking using service (with locking)
-	bookingReq := core.BookingRequest{
-		FacilityID:     facilityID,
-		UserID:         userID,
-		HouseholdID:    householdID,
-		ParticipantIDs: participantIDs,
-		StartTime:      startTime,
-		EndTime:        endTime,
-		Notes:          req.Notes,
-		IdempotencyKey: req.IdempotencyKey,
+	return householdID, participantIDs, true
+}
+
+// CreateRecurringBooking books the same slot every week or every few weeks until a date
+// (authenticated). Each occurrence is booked on its own; the response lists which were
+// booked and which were skipped for a conflict or closure.
+func (h *Handler) CreateRecurringBooking(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
 
-	booking, err := h.facilitiesService.CreateBooking(c.Request.Context(), bookingReq)
-	if err != nil {
+	var req struct {
+		FacilityID     string   `json:"facility_id" binding:"required"`
+		ParticipantIDs []string `json:"participant_ids"`
+		StartTime      string   `json:"start_time" binding:"required"`
+		EndTime        string   `json:"end_time" binding:"required"`
+		IntervalWeeks  int      `json:"interval_weeks"`
+		Until          string   `json:"until" binding:"required"`
+		UnitID         *string  `json:"unit_id"`
+		Notes          *string  `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// TODO: Send confirmation email with ICS attachment
+	facilityID, err := uuid.Parse(req.FacilityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid facility_id"})
+		return
+	}
 
-	c.JSON(http.StatusCreated, gin.H{"booking": booking})
+	startTime, err := time.Parse(time.RFC3339, req.StartTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format (use RFC3339)"})
+		return
+	}
+	endTime, err := time.Parse(time.RFC3339, req.EndTime)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format (use RFC3339)"})
+		return
+	}
+	if !endTime.After(startTime) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "end_time must be after start_time"})
+		return
+	}
+
+	until, err := time.Parse("2006-01-02", req.Until)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid until format (use YYYY-MM-DD)"})
+		return
+	}
+
+	if req.IntervalWeeks == 0 {
+		req.IntervalWeeks = 1
+	}
+
+	unitID, err := parseOptionalUUID(req.UnitID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid unit_id"})
+		return
+	}
+
+	householdID, participantIDs, ok := h.bookingParticipants(userID, req.ParticipantIDs)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
+		return
+	}
+
+	result, err := h.facilitiesService.CreateBookingSeries(c.Request.Context(), core.RecurringBookingRequest{
+		BookingRequest: core.BookingRequest{
+			FacilityID:     facilityID,
+			UserID:         userID,
+			HouseholdID:    householdID,
+			ParticipantIDs: participantIDs,
+			StartTime:      startTime,
+			EndTime:        endTime,
+			UnitID:         unitID,
+			Notes:          req.Notes,
+		},
+		IntervalWeeks: req.IntervalWeeks,
+		Until:         until,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"series": result})
 }
 
 // GetMyBookings retrieves the current user's bookings (authenticated)
@@ -266,7 +722,8 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 	}
 
 	var req struct {
-		Reason *string `json:"reason"`
+		ReasonCode *string `json:"reason_code" binding:"omitempty,oneof=schedule_conflict illness cost dissatisfied moved other"`
+		Reason     *string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -274,13 +731,54 @@ func (h *Handler) CancelBooking(c *gin.Context) {
 		return
 	}
 
-	err = h.facilitiesService.CancelBooking(c.Request.Context(), bookingID, userID, req.Reason)
+	result, err := h.facilitiesService.CancelBooking(c.Request.Context(), bookingID, userID, req.ReasonCode, req.Reason)
 	if err != nil {
+		c.JSON(bookingErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":                     "Booking cancelled",
+		"late_cancellation":           result.Late,
+		"late_cancellation_fee_cents": result.FeeCents,
+	})
+}
+
+// CancelBookingSeries cancels the remaining bookings of a recurring series (authenticated).
+// Owners may cancel their own series; signed-in admins may cancel any. AuthMiddleware
+// refuses API keys, so an API key's admin service user can't cancel a family's series.
+func (h *Handler) CancelBookingSeries(c *gin.Context) {
+	user, err := h.requestUser(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	seriesID, err := uuid.Parse(c.Param("series_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid series ID"})
+		return
+	}
+
+	var req struct {
+		ReasonCode *string `json:"reason_code" binding:"omitempty,oneof=schedule_conflict illness cost dissatisfied moved other"`
+		Reason     *string `json:"reason"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	// TODO: Send cancellation email
+	result, err := h.facilitiesService.CancelBookingSeries(c.Request.Context(), seriesID, user.ID, user.Role == "admin", req.ReasonCode, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled"})
+	c.JSON(http.StatusOK, gin.H{"series": result})
 }
@@ -0,0 +1,27 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"sterling-rec/api/internal/core"
+)
+
+func TestBookingErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{core.ErrBookingNotFound, http.StatusNotFound},
+		{core.ErrBookingForbidden, http.StatusForbidden},
+		{fmt.Errorf("confirm: %w", core.ErrBookingForbidden), http.StatusForbidden},
+		{errors.New("booking hold has expired; reserve the slot again"), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if got := bookingErrorStatus(tt.err); got != tt.want {
+			t.Errorf("bookingErrorStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
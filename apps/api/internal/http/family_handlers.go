@@ -2,11 +2,19 @@ package http
 
 import (
 	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"sterling-rec/api/internal/db"
 )
 
 // GetHousehold returns the user's household, creating one if it doesn't exist
@@ -17,38 +25,13 @@ func (h *Handler) GetHousehold(c *gin.Context) {
 		return
 	}
 
-	household, err := h.db.GetUserHousehold(userID)
+	// Auto-create household if it doesn't exist
+	household, err := h.db.EnsureUserHousehold(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve household"})
 		return
 	}
 
-	// Auto-create household if it doesn't exist
-	if household == nil {
-		user, err := h.db.GetUserByID(userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user"})
-			return
-		}
-
-		// Create household
-		_, err = h.db.Exec(`
-			INSERT INTO households (owner_user_id, email)
-			VALUES ($1, $2)
-		`, userID, user.Email)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create household"})
-			return
-		}
-
-		// Retrieve the newly created household
-		household, err = h.db.GetUserHousehold(userID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve household"})
-			return
-		}
-	}
-
 	c.JSON(http.StatusOK, gin.H{"household": household})
 }
 
@@ -68,6 +51,9 @@ func (h *Handler) UpdateHousehold(c *gin.Context) {
 		City         *string `json:"city"`
 		State        *string `json:"state"`
 		Zip          *string `json:"zip"`
+
+		DefaultEmergencyContactName  *string `json:"default_emergency_contact_name"`
+		DefaultEmergencyContactPhone *string `json:"default_emergency_contact_phone"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -91,9 +77,12 @@ func (h *Handler) UpdateHousehold(c *gin.Context) {
 		    address_line1 = COALESCE($4, address_line1),
 		    city = COALESCE($5, city),
 		    state = COALESCE($6, state),
-		    zip = COALESCE($7, zip)
-		WHERE id = $8
-	`, req.Name, req.Phone, req.Email, req.AddressLine1, req.City, req.State, req.Zip, household.ID)
+		    zip = COALESCE($7, zip),
+		    default_emergency_contact_name = COALESCE($8, default_emergency_contact_name),
+		    default_emergency_contact_phone = COALESCE($9, default_emergency_contact_phone)
+		WHERE id = $10
+	`, req.Name, req.Phone, req.Email, req.AddressLine1, req.City, req.State, req.Zip,
+		req.DefaultEmergencyContactName, req.DefaultEmergencyContactPhone, household.ID)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update household"})
@@ -105,6 +94,67 @@ func (h *Handler) UpdateHousehold(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"household": updatedHousehold})
 }
 
+// GetFamilySchedule returns the household's confirmed registrations and bookings between
+// from and to (inclusive, YYYY-MM-DD), grouped by day. Defaults to the next 14 days.
+func (h *Handler) GetFamilySchedule(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	if fromStr := c.Query("from"); fromStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", fromStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format (use YYYY-MM-DD)"})
+			return
+		}
+		from = parsed
+	}
+
+	to := from.AddDate(0, 0, 13)
+	if toStr := c.Query("to"); toStr != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", toStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format (use YYYY-MM-DD)"})
+			return
+		}
+		to = parsed
+	}
+
+	if to.Before(from) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "to must not be before from"})
+		return
+	}
+	if to.Sub(from) > 366*24*time.Hour {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Date range cannot exceed one year"})
+		return
+	}
+
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve household"})
+		return
+	}
+
+	items := []db.ScheduleItem{}
+	if household != nil {
+		items, err = h.db.GetHouseholdSchedule(household.ID, from, to.AddDate(0, 0, 1))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve schedule"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from": from.Format("2006-01-02"),
+		"to":   to.Format("2006-01-02"),
+		"days": db.GroupScheduleByDay(items, time.Local),
+	})
+}
+
 // GetParticipants returns all participants for the user's household
 func (h *Handler) GetParticipants(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -147,6 +197,10 @@ func (h *Handler) CreateParticipantEnhanced(c *gin.Context) {
 		IsFavorite            *bool   `json:"is_favorite"`
 		Gender                *string `json:"gender"`
 		ShirtSize             *string `json:"shirt_size"`
+		PhotoURL              *string `json:"photo_url"`
+
+		DietaryRestrictions []string `json:"dietary_restrictions"`
+		AccessibilityNeeds  []string `json:"accessibility_needs"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -154,6 +208,21 @@ func (h *Handler) CreateParticipantEnhanced(c *gin.Context) {
 		return
 	}
 
+	if err := db.ValidateParticipantNeeds(req.DietaryRestrictions, req.AccessibilityNeeds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.PhotoURL != nil && *req.PhotoURL == "" {
+		req.PhotoURL = nil
+	}
+	if req.PhotoURL != nil {
+		if err := validatePhotoURL(*req.PhotoURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Get household
 	household, err := h.db.GetUserHousehold(userID)
 	if err != nil || household == nil {
@@ -173,37 +242,39 @@ func (h *Handler) CreateParticipantEnhanced(c *gin.Context) {
 		isFavorite = *req.IsFavorite
 	}
 
-	// Create participant
-	var p struct {
-		ID                    uuid.UUID  `json:"id"`
-		HouseholdID           uuid.UUID  `json:"household_id"`
-		FirstName             string     `json:"first_name"`
-		LastName              string     `json:"last_name"`
-		DOB                   *time.Time `json:"dob"`
-		Notes                 *string    `json:"notes"`
-		MedicalNotes          *string    `json:"medical_notes"`
-		EmergencyContactName  *string    `json:"emergency_contact_name"`
-		EmergencyContactPhone *string    `json:"emergency_contact_phone"`
-		IsFavorite            bool       `json:"is_favorite"`
-		Gender                *string    `json:"gender"`
-		ShirtSize             *string    `json:"shirt_size"`
-		CreatedAt             time.Time  `json:"created_at"`
+	// Inherit the household's default emergency contact when none is given
+	if req.EmergencyContactName == nil && req.EmergencyContactPhone == nil {
+		req.EmergencyContactName = household.DefaultEmergencyContactName
+		req.EmergencyContactPhone = household.DefaultEmergencyContactPhone
 	}
 
-	err = h.db.QueryRow(`
-		INSERT INTO participants (
-			household_id, first_name, last_name, dob, notes, medical_notes,
-			emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id, household_id, first_name, last_name, dob, notes, medical_notes,
-		          emergency_contact_name, emergency_contact_phone, is_favorite, gender, shirt_size, created_at
-	`, household.ID, req.FirstName, req.LastName, req.DOB, req.Notes, req.MedicalNotes,
-		req.EmergencyContactName, req.EmergencyContactPhone, isFavorite, req.Gender, req.ShirtSize).Scan(
-		&p.ID, &p.HouseholdID, &p.FirstName, &p.LastName, &p.DOB, &p.Notes, &p.MedicalNotes,
-		&p.EmergencyContactName, &p.EmergencyContactPhone, &p.IsFavorite, &p.Gender, &p.ShirtSize, &p.CreatedAt,
-	)
+	var dob *time.Time
+	if req.DOB != nil && *req.DOB != "" {
+		parsed, err := time.Parse("2006-01-02", *req.DOB)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid dob format (use YYYY-MM-DD)"})
+			return
+		}
+		dob = &parsed
+	}
 
+	// Create participant
+	p, err := h.db.CreateParticipantFull(&db.Participant{
+		HouseholdID:           household.ID,
+		FirstName:             req.FirstName,
+		LastName:              req.LastName,
+		DOB:                   dob,
+		Notes:                 req.Notes,
+		MedicalNotes:          req.MedicalNotes,
+		EmergencyContactName:  req.EmergencyContactName,
+		EmergencyContactPhone: req.EmergencyContactPhone,
+		IsFavorite:            isFavorite,
+		Gender:                req.Gender,
+		ShirtSize:             req.ShirtSize,
+		PhotoURL:              req.PhotoURL,
+		DietaryRestrictions:   req.DietaryRestrictions,
+		AccessibilityNeeds:    req.AccessibilityNeeds,
+	})
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create participant"})
 		return
@@ -238,6 +309,11 @@ func (h *Handler) UpdateParticipantEnhanced(c *gin.Context) {
 		IsFavorite            *bool   `json:"is_favorite"`
 		Gender                *string `json:"gender"`
 		ShirtSize             *string `json:"shirt_size"`
+		PhotoURL              *string `json:"photo_url"` // empty string removes the photo
+
+		// Replace the whole list when given; an empty list clears it
+		DietaryRestrictions []string `json:"dietary_restrictions"`
+		AccessibilityNeeds  []string `json:"accessibility_needs"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -245,6 +321,18 @@ func (h *Handler) UpdateParticipantEnhanced(c *gin.Context) {
 		return
 	}
 
+	if err := db.ValidateParticipantNeeds(req.DietaryRestrictions, req.AccessibilityNeeds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.PhotoURL != nil && *req.PhotoURL != "" {
+		if err := validatePhotoURL(*req.PhotoURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Verify ownership
 	household, err := h.db.GetUserHousehold(userID)
 	if err != nil || household == nil {
@@ -283,10 +371,15 @@ func (h *Handler) UpdateParticipantEnhanced(c *gin.Context) {
 		    emergency_contact_phone = COALESCE($7, emergency_contact_phone),
 		    is_favorite = COALESCE($8, is_favorite),
 		    gender = COALESCE($9, gender),
-		    shirt_size = COALESCE($10, shirt_size)
-		WHERE id = $11
+		    shirt_size = COALESCE($10, shirt_size),
+		    photo_url = NULLIF(COALESCE($11, photo_url), ''),
+		    dietary_restrictions = COALESCE($13::text[], dietary_restrictions),
+		    accessibility_needs = COALESCE($14::text[], accessibility_needs),
+		    updated_at = now()
+		WHERE id = $12
 	`, req.FirstName, req.LastName, req.DOB, req.Notes, req.MedicalNotes,
-		req.EmergencyContactName, req.EmergencyContactPhone, req.IsFavorite, req.Gender, req.ShirtSize, participantID)
+		req.EmergencyContactName, req.EmergencyContactPhone, req.IsFavorite, req.Gender, req.ShirtSize, req.PhotoURL, participantID,
+		pq.Array(req.DietaryRestrictions), pq.Array(req.AccessibilityNeeds))
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update participant"})
@@ -298,6 +391,85 @@ func (h *Handler) UpdateParticipantEnhanced(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"participant": participant})
 }
 
+// photoURLClient is used to check participant photo URLs before saving them. It only
+// connects to public addresses, checked as each connection is dialled, so a host that
+// resolves to an internal address or a redirect to one is refused too.
+var photoURLClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+					return fmt.Errorf("photo_url must not point to an internal address")
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("photo_url redirected too many times")
+		}
+		return checkPhotoURLTarget(req.URL)
+	},
+}
+
+// isPublicIP reports whether ip is a public unicast address, rather than a loopback,
+// link-local, private (RFC 1918 or IPv6 unique local) or otherwise special one
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// checkPhotoURLTarget checks that a photo URL, or a URL it redirects to, is an absolute
+// http(s) URL and not an internal address given as an IP literal
+func checkPhotoURLTarget(u *url.URL) error {
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("photo_url must be an absolute http or https URL")
+	}
+	if ip := net.ParseIP(u.Hostname()); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("photo_url must not point to an internal address")
+	}
+	return nil
+}
+
+// validatePhotoURL checks that a photo URL is an absolute http(s) URL on a public address
+// serving an image
+func validatePhotoURL(raw string) error {
+	if len(raw) > 2048 {
+		return fmt.Errorf("photo_url is too long")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("photo_url must be an absolute http or https URL")
+	}
+	if err := checkPhotoURLTarget(u); err != nil {
+		return err
+	}
+
+	resp, err := photoURLClient.Head(raw)
+	if err != nil {
+		return fmt.Errorf("photo_url could not be reached")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("photo_url returned status %d", resp.StatusCode)
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "image/") {
+		return fmt.Errorf("photo_url must point to an image")
+	}
+
+	return nil
+}
+
 // DeleteParticipantEnhanced deletes a participant with ownership check
 func (h *Handler) DeleteParticipantEnhanced(c *gin.Context) {
 	userID, exists := GetUserID(c)
@@ -395,12 +567,7 @@ func (h *Handler) GetParticipantEligibility(c *gin.Context) {
 	}
 
 	// Get program/event to check age restrictions
-	var ageMin, ageMax *int
-	if parentType == "program" {
-		err = h.db.QueryRow(`
-			SELECT age_min, age_max FROM programs WHERE id = $1
-		`, parentID).Scan(&ageMin, &ageMax)
-	} else if parentType == "event" {
+	if parentType != "program" {
 		// Events don't have age restrictions in current schema
 		c.JSON(http.StatusOK, gin.H{
 			"eligible": true,
@@ -409,7 +576,8 @@ func (h *Handler) GetParticipantEligibility(c *gin.Context) {
 		return
 	}
 
-	if err != nil {
+	program, err := h.db.GetProgramByID(parentID)
+	if err != nil || program == nil {
 		c.JSON(http.StatusOK, gin.H{
 			"eligible": true,
 			"reason":   "",
@@ -417,30 +585,72 @@ func (h *Handler) GetParticipantEligibility(c *gin.Context) {
 		return
 	}
 
-	// Calculate age (simplified - using today's date)
-	age := time.Now().Year() - participant.DOB.Year()
+	// Age is measured on the program's start date, or on ?asOf= to preview a session date
+	referenceDate := db.ProgramAgeReferenceDate(program)
+	if asOf := c.Query("asOf"); asOf != "" {
+		parsed, err := time.Parse("2006-01-02", asOf)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid asOf date (use YYYY-MM-DD)"})
+			return
+		}
+		referenceDate = parsed
+	}
+	age := db.AgeOn(*participant.DOB, referenceDate)
 
-	// Check age eligibility
-	if ageMin != nil && age < *ageMin {
-		c.JSON(http.StatusOK, gin.H{
-			"eligible": false,
-			"reason":   "Participant is too young for this program",
-		})
+	reason := db.AgeRequirementReason(program, age)
+
+	c.JSON(http.StatusOK, gin.H{
+		"eligible":       reason == "",
+		"reason":         reason,
+		"age":            age,
+		"reference_date": referenceDate.Format("2006-01-02"),
+	})
+}
+
+// GetParticipantEligiblePrograms returns active programs a participant can join
+func (h *Handler) GetParticipantEligiblePrograms(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
 		return
 	}
 
-	if ageMax != nil && age > *ageMax {
-		c.JSON(http.StatusOK, gin.H{
-			"eligible": false,
-			"reason":   "Participant is too old for this program",
-		})
+	participantID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant ID"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"eligible": true,
-		"reason":   "",
-	})
+	// Verify ownership
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil || household == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Household not found"})
+		return
+	}
+
+	participant, err := h.db.GetParticipantByID(participantID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if participant == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found"})
+		return
+	}
+	if participant.HouseholdID != household.ID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to view this participant"})
+		return
+	}
+
+	excludeFull := c.Query("exclude_full") == "true"
+
+	programs, err := h.db.GetEligiblePrograms(participant, excludeFull)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve eligible programs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"programs": programs})
 }
 
 // AcceptWaiver records a waiver acceptance for a participant
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestValidatePhotoURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{"loopback server", server.URL + "/photo.png"},
+		{"localhost name", "http://localhost/photo.png"},
+		{"cloud metadata", "http://169.254.169.254/latest/meta-data"},
+		{"private network", "http://10.0.0.5/photo.png"},
+		{"ipv6 unique local", "http://[fd00::1]/photo.png"},
+		{"not http", "file:///etc/passwd"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validatePhotoURL(tt.url); err == nil {
+				t.Errorf("validatePhotoURL(%q) = nil, want an error", tt.url)
+			}
+		})
+	}
+}
+
+func TestPhotoURLRedirectChecked(t *testing.T) {
+	for _, target := range []string{"http://127.0.0.1/photo.png", "http://192.168.1.1/photo.png", "ftp://example.com/photo.png"} {
+		u, _ := url.Parse(target)
+		if err := photoURLClient.CheckRedirect(&http.Request{URL: u}, nil); err == nil {
+			t.Errorf("redirect to %s allowed, want it refused", target)
+		}
+	}
+
+	u, _ := url.Parse("https://example.com/photo.png")
+	if err := photoURLClient.CheckRedirect(&http.Request{URL: u}, nil); err != nil {
+		t.Errorf("redirect to a public URL refused: %v", err)
+	}
+}
@@ -2,7 +2,11 @@ package http
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -15,13 +19,19 @@ type Handler struct {
 	db                *db.DB
 	regService        *core.RegistrationService
 	facilitiesService *core.FacilitiesService
+	googleCalendar    *core.GoogleCalendarService
+	syncClient        *core.SyncClient
+	emailService      *core.EmailService
 }
 
-func NewHandler(database *db.DB, regService *core.RegistrationService, facilitiesService *core.FacilitiesService) *Handler {
+func NewHandler(database *db.DB, regService *core.RegistrationService, facilitiesService *core.FacilitiesService, googleCalendar *core.GoogleCalendarService, syncClient *core.SyncClient, emailService *core.EmailService) *Handler {
 	return &Handler{
 		db:                database,
 		regService:        regService,
 		facilitiesService: facilitiesService,
+		googleCalendar:    googleCalendar,
+		syncClient:        syncClient,
+		emailService:      emailService,
 	}
 }
 
@@ -116,6 +126,13 @@ func (h *Handler) Login(c *gin.Context) {
 }
 
 func (h *Handler) Logout(c *gin.Context) {
+	// Logging out of an impersonation ends it and drops the admin's kept token too
+	if session, ok := GetImpersonation(c); ok {
+		if err := h.db.EndImpersonationSession(session.ID); err != nil {
+			log.Printf("Failed to end impersonation session %s: %v", session.ID, err)
+		}
+		setTokenCookie(c, impersonatorCookie, "", -1)
+	}
 	ClearAuthCookie(c)
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
@@ -191,20 +208,20 @@ func (h *Handler) GetMe(c *gin.Context) {
 		return
 	}
 
-	household, err := h.db.GetUserHousehold(userID)
+	// Auto-create household if it doesn't exist, same as GetHousehold
+	household, err := h.db.EnsureUserHousehold(userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve household"})
 		return
 	}
 
-	participants := []db.Participant{}
-	if household != nil {
-		var err error
-		participants, err = h.db.GetHouseholdParticipants(household.ID)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve participants"})
-			return
-		}
+	participants, err := h.db.GetHouseholdParticipants(household.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve participants"})
+		return
+	}
+	if participants == nil {
+		participants = []db.Participant{}
 	}
 
 	registrations, err := h.db.GetUserRegistrations(userID)
@@ -213,12 +230,23 @@ func (h *Handler) GetMe(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"user":          user,
 		"household":     household,
 		"participants":  participants,
 		"registrations": registrations,
-	})
+	}
+	// Lets the app show a banner while an admin is signed in as this user
+	if session, ok := GetImpersonation(c); ok {
+		response["impersonation"] = gin.H{
+			"session_id":      session.ID,
+			"impersonated_by": session.AdminUserID,
+			"admin_name":      session.AdminName,
+			"expires_at":      session.ExpiresAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, response)
 }
 
 func (h *Handler) CreateParticipant(c *gin.Context) {
@@ -259,10 +287,14 @@ func (h *Handler) CreateRegistration(c *gin.Context) {
 	userID, _ := GetUserID(c)
 
 	var req struct {
-		ParentType    string     `json:"parent_type" binding:"required,oneof=program event"`
-		ParentID      string     `json:"parent_id" binding:"required,uuid"`
-		SessionID     *string    `json:"session_id"`
-		ParticipantID string     `json:"participant_id" binding:"required,uuid"`
+		ParentType    string          `json:"parent_type" binding:"required,oneof=program event"`
+		ParentID      string          `json:"parent_id" binding:"required,uuid"`
+		SessionID     *string         `json:"session_id"`
+		ParticipantID string          `json:"participant_id" binding:"required,uuid"`
+		Answers       json.RawMessage `json:"answers"`
+		// AllowAgeOverride registers outside the program's age range; admins only
+		AllowAgeOverride bool    `json:"allow_age_override"`
+		IdempotencyKey   *string `json:"idempotency_key"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -306,22 +338,426 @@ func (h *Handler) CreateRegistration(c *gin.Context) {
 		return
 	}
 
+	if req.AllowAgeOverride && !h.checkAgeOverride(c, userID) {
+		return
+	}
+
+	// Answers are only kept for programs that ask registration questions
+	var answers json.RawMessage
+	if req.ParentType == "program" {
+		schema, ok := h.checkRegistrableProgram(c, parentID)
+		if !ok {
+			return
+		}
+		if schema != nil {
+			if err := schema.ValidateAnswers(req.Answers); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			answers = req.Answers
+		}
+	}
+
 	// Create registration
 	result, err := h.regService.Register(c.Request.Context(), db.RegistrationRequest{
 		ParentType:    req.ParentType,
 		ParentID:      parentID,
 		SessionID:     sessionID,
 		ParticipantID: participantID,
+		ActorUserID:   &userID,
+		Answers:       answers,
+
+		AllowAgeOverride: req.AllowAgeOverride,
+		IdempotencyKey:   req.IdempotencyKey,
 	})
 	if err != nil {
+		if errors.Is(err, db.ErrSessionNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+			return
+		}
+		var ageErr *db.AgeEligibilityError
+		if errors.As(err, &ageErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": ageErr.Reason, "age": ageErr.Age})
+			return
+		}
+		var waiversErr *core.MissingWaiversError
+		if errors.As(err, &waiversErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": waiversErr.Error(), "missing_waivers": waiversErr.Waivers})
+			return
+		}
+		var contactErr *core.MissingEmergencyContactError
+		if errors.As(err, &contactErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": contactErr.Error(), "age": contactErr.Age, "missing_emergency_contact": true})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"registration": result.Registration,
-		"waitlisted":   result.IsWaitlisted,
-		"position":     result.Position,
+	// The registration is already made, so a failed check only leaves the state out
+	if err := h.db.SetCompletionStates(result.Registration); err != nil {
+		log.Printf("Failed to get completion state of registration %s: %v", result.Registration.ID, err)
+	}
+
+	status := http.StatusCreated
+	if result.AlreadyRegistered {
+		status = http.StatusOK
+	}
+	c.JSON(status, gin.H{
+		"registration":       result.Registration,
+		"waitlisted":         result.IsWaitlisted,
+		"pending":            result.IsPending,
+		"position":           result.Position,
+		"already_registered": result.AlreadyRegistered,
+	})
+}
+
+// CreateRegistrationBatch registers several participants of the household for the same
+// program or event session in one call. Each participant gets its own result; with
+// atomic set, nothing is kept unless every participant is confirmed.
+func (h *Handler) CreateRegistrationBatch(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	var req struct {
+		ParentType     string   `json:"parent_type" binding:"required,oneof=program event"`
+		ParentID       string   `json:"parent_id" binding:"required,uuid"`
+		SessionID      *string  `json:"session_id"`
+		ParticipantIDs []string `json:"participant_ids" binding:"required,min=1,max=20,dive,uuid"`
+		// Answers to the program's registration questions, keyed by participant id
+		Answers          map[string]json.RawMessage `json:"answers"`
+		Atomic           bool                       `json:"atomic"`
+		AllowAgeOverride bool                       `json:"allow_age_override"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	parentID, err := uuid.Parse(req.ParentID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id"})
+		return
+	}
+
+	var sessionID *uuid.UUID
+	if req.SessionID != nil && *req.SessionID != "" {
+		sid, err := uuid.Parse(*req.SessionID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id"})
+			return
+		}
+		sessionID = &sid
+	}
+
+	// Every participant must belong to the caller's household
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil || household == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to register these participants"})
+		return
+	}
+	participantIDs := make([]uuid.UUID, len(req.ParticipantIDs))
+	seen := map[uuid.UUID]bool{}
+	for i, id := range req.ParticipantIDs {
+		participantID, err := uuid.Parse(id)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id"})
+			return
+		}
+		if seen[participantID] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Each participant may only be listed once"})
+			return
+		}
+		seen[participantID] = true
+
+		participant, err := h.db.GetParticipantByID(participantID)
+		if err != nil || participant == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found", "participant_id": participantID})
+			return
+		}
+		if participant.HouseholdID != household.ID {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to register this participant", "participant_id": participantID})
+			return
+		}
+		participantIDs[i] = participantID
+	}
+
+	if req.AllowAgeOverride && !h.checkAgeOverride(c, userID) {
+		return
+	}
+
+	var schema *db.FormSchema
+	if req.ParentType == "program" {
+		var ok bool
+		if schema, ok = h.checkRegistrableProgram(c, parentID); !ok {
+			return
+		}
+	}
+
+	reqs := make([]db.RegistrationRequest, len(participantIDs))
+	for i, participantID := range participantIDs {
+		var answers json.RawMessage
+		if schema != nil {
+			answers = req.Answers[participantID.String()]
+			if err := schema.ValidateAnswers(answers); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "participant_id": participantID})
+				return
+			}
+		}
+		reqs[i] = db.RegistrationRequest{
+			ParentType:    req.ParentType,
+			ParentID:      parentID,
+			SessionID:     sessionID,
+			ParticipantID: participantID,
+			ActorUserID:   &userID,
+			Answers:       answers,
+
+			AllowAgeOverride: req.AllowAgeOverride,
+		}
+	}
+
+	items, committed, err := h.regService.RegisterBatch(c.Request.Context(), reqs, req.Atomic)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	results := make([]gin.H, len(items))
+	for i, item := range items {
+		result := gin.H{"participant_id": item.ParticipantID, "status": item.Status}
+		if item.Err != nil {
+			result["error"] = item.Err.Error()
+			var ageErr *db.AgeEligibilityError
+			if errors.As(item.Err, &ageErr) {
+				result["error"] = ageErr.Reason
+				result["age"] = ageErr.Age
+			}
+			var waiversErr *core.MissingWaiversError
+			if errors.As(item.Err, &waiversErr) {
+				result["missing_waivers"] = waiversErr.Waivers
+			}
+			var contactErr *core.MissingEmergencyContactError
+			if errors.As(item.Err, &contactErr) {
+				result["age"] = contactErr.Age
+				result["missing_emergency_contact"] = true
+			}
+		} else if item.Result != nil {
+			// A rolled-back atomic batch only reports what each participant would have got
+			if committed {
+				result["registration"] = item.Result.Registration
+				result["position"] = item.Result.Position
+			}
+			result["already_registered"] = item.Result.AlreadyRegistered
+		}
+		results[i] = result
+	}
+
+	status := http.StatusOK
+	if req.Atomic && !committed {
+		status = http.StatusConflict
+	}
+	c.JSON(status, gin.H{
+		"results":   results,
+		"committed": committed,
+	})
+}
+
+// ValidateRegistrationCart checks a cart of intended registrations for the household's
+// participants before checkout and reports every blocking issue per item, with a summary
+// for the cart. Nothing is registered.
+func (h *Handler) ValidateRegistrationCart(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	var req struct {
+		Items []struct {
+			ParentType    string  `json:"parent_type" binding:"required,oneof=program event"`
+			ParentID      string  `json:"parent_id" binding:"required,uuid"`
+			SessionID     *string `json:"session_id"`
+			ParticipantID string  `json:"participant_id" binding:"required,uuid"`
+		} `json:"items" binding:"required,min=1,max=50,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Every participant must belong to the caller's household
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil || household == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to register these participants"})
+		return
+	}
+	owned := map[uuid.UUID]bool{}
+
+	items := make([]core.CartItem, len(req.Items))
+	for i, item := range req.Items {
+		parentID, err := uuid.Parse(item.ParentID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parent_id", "index": i})
+			return
+		}
+		participantID, err := uuid.Parse(item.ParticipantID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid participant_id", "index": i})
+			return
+		}
+		var sessionID *uuid.UUID
+		if item.SessionID != nil && *item.SessionID != "" {
+			sid, err := uuid.Parse(*item.SessionID)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session_id", "index": i})
+				return
+			}
+			sessionID = &sid
+		}
+
+		if !owned[participantID] {
+			participant, err := h.db.GetParticipantByID(participantID)
+			if err != nil || participant == nil {
+				c.JSON(http.StatusNotFound, gin.H{"error": "Participant not found", "participant_id": participantID})
+				return
+			}
+			if participant.HouseholdID != household.ID {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized to register this participant", "participant_id": participantID})
+				return
+			}
+			owned[participantID] = true
+		}
+
+		items[i] = core.CartItem{
+			ParentType:    item.ParentType,
+			ParentID:      parentID,
+			SessionID:     sessionID,
+			ParticipantID: participantID,
+		}
+	}
+
+	validation, err := h.regService.ValidateCart(items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate cart"})
+		return
+	}
+
+	c.JSON(http.StatusOK, validation)
+}
+
+// checkAgeOverride responds with 403 and returns false unless the user is an admin, who
+// alone may register participants outside a program's age range
+func (h *Handler) checkAgeOverride(c *gin.Context, userID uuid.UUID) bool {
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check admin status"})
+		return false
+	}
+	if user == nil || user.Role != "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only admins can override age requirements"})
+		return false
+	}
+	return true
+}
+
+// checkRegistrableProgram responds with 404 or 409 and returns false unless the program
+// is published and open for registration. It returns the program's registration questions,
+// or nil when it asks none.
+func (h *Handler) checkRegistrableProgram(c *gin.Context, programID uuid.UUID) (*db.FormSchema, bool) {
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return nil, false
+	}
+	// Programs outside their publish window are hidden from the public
+	if program == nil || !db.IsPublished(program.PublishedAt, program.UnpublishedAt, time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return nil, false
+	}
+	if program.RegistrationOpensAt != nil && time.Now().Before(*program.RegistrationOpensAt) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":                 "Registration has not opened yet; join the interest list to be notified",
+			"registration_opens_at": program.RegistrationOpensAt,
+		})
+		return nil, false
+	}
+	if len(program.RegistrationQuestions) == 0 {
+		return nil, true
+	}
+	schema, err := db.ParseFormSchema(program.RegistrationQuestions)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Program registration questions are invalid"})
+		return nil, false
+	}
+	return schema, true
+}
+
+// GetMyRegistrations lists the registrations of the user's household with their program or
+// event, session and waitlist position
+func (h *Handler) GetMyRegistrations(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	status := c.Query("status")
+	switch status {
+	case "", "confirmed", "waitlisted", "pending", "paused", "cancelled":
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status (use confirmed, waitlisted, pending, paused or cancelled)"})
+		return
+	}
+
+	registrations, err := h.db.GetUserRegistrationDetails(userID, status, c.Query("include_cancelled") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+		return
+	}
+
+	regs := make([]*db.Registration, len(registrations))
+	for i := range registrations {
+		regs[i] = &registrations[i]
+	}
+	if err := h.db.SetCompletionStates(regs...); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve registrations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registrations": registrations})
+}
+
+// AddProgramInterest puts the user on the interest list of a program whose registration
+// has not opened yet
+func (h *Handler) AddProgramInterest(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	programID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid program ID"})
+		return
+	}
+
+	program, err := h.db.GetProgramByID(programID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get program"})
+		return
+	}
+	if program == nil || !program.IsActive || !db.IsPublished(program.PublishedAt, program.UnpublishedAt, time.Now()) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Program not found"})
+		return
+	}
+	if program.RegistrationOpensAt == nil || !time.Now().Before(*program.RegistrationOpensAt) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration is already open"})
+		return
+	}
+
+	entry, created, err := h.db.AddProgramInterest(programID, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to join interest list"})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, gin.H{
+		"interest":              entry,
+		"registration_opens_at": program.RegistrationOpensAt,
 	})
 }
 
@@ -329,7 +765,9 @@ func (h *Handler) CancelRegistration(c *gin.Context) {
 	userID, _ := GetUserID(c)
 
 	var req struct {
-		RegistrationID string `json:"registration_id" binding:"required,uuid"`
+		RegistrationID string  `json:"registration_id" binding:"required,uuid"`
+		ReasonCode     *string `json:"reason_code" binding:"omitempty,oneof=schedule_conflict illness cost dissatisfied moved other"`
+		Reason         *string `json:"reason"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -369,7 +807,7 @@ func (h *Handler) CancelRegistration(c *gin.Context) {
 	}
 
 	// Cancel registration
-	err = h.regService.CancelRegistration(c.Request.Context(), registrationID, participantID)
+	err = h.regService.CancelRegistration(c.Request.Context(), registrationID, participantID, &userID, req.ReasonCode, req.Reason)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -378,6 +816,404 @@ func (h *Handler) CancelRegistration(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Registration cancelled successfully"})
 }
 
+// GetRegistrationWaitlist returns where a waitlisted registration stands
+func (h *Handler) GetRegistrationWaitlist(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	standing, err := h.db.GetWaitlistStanding(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get waitlist standing"})
+		return
+	}
+	if standing == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registration is not waitlisted"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"waitlist": standing})
+}
+
+// GetRegistrationWaitlistPosition returns the live position of a waitlisted registration,
+// counting only entries ahead that are still waitlisted, or reports that it has been
+// promoted. Registrations that were never on the waitlist are not found.
+func (h *Handler) GetRegistrationWaitlistPosition(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	standing, err := h.db.GetWaitlistStanding(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get waitlist standing"})
+		return
+	}
+	if standing != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"registration_id": registrationID,
+			"position":        standing.EffectivePosition,
+			"waitlist_length": standing.TotalWaitlisted,
+			"promoted":        false,
+		})
+		return
+	}
+
+	promoted, err := h.db.WasPromotedFromWaitlist(registrationID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get registration status"})
+		return
+	}
+	if !promoted {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration is not on a waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registration_id": registrationID, "promoted": true})
+}
+
+// UpdateWaitlistPreferences sets whether the user's waitlisted registration should be
+// promoted when a spot opens. An opted-out registration is passed over for the next
+// person on the waitlist.
+func (h *Handler) UpdateWaitlistPreferences(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req struct {
+		NotifyOptIn *bool `json:"notify_opt_in" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	updated, err := h.db.SetWaitlistOptIn(registrationID, *req.NotifyOptIn)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update waitlist preferences"})
+		return
+	}
+	if !updated {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration is not on a waitlist"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registration_id": registrationID, "notify_opt_in": *req.NotifyOptIn})
+}
+
+// AcceptWaitlistOffer confirms the user's registration that was offered a spot from the
+// waitlist, if it is accepted before the offer's deadline
+func (h *Handler) AcceptWaitlistOffer(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	accepted, err := h.db.AcceptWaitlistOffer(registrationID, &userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to accept offer"})
+		return
+	}
+	if !accepted {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration has no open offer, or the offer has expired"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registration_id": registrationID, "status": "confirmed"})
+}
+
+// DeclineWaitlistOffer cancels the user's registration that was offered a spot from the
+// waitlist, passing the spot to the next person
+func (h *Handler) DeclineWaitlistOffer(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	declined, err := h.db.DeclineWaitlistOffer(registrationID, &userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to decline offer"})
+		return
+	}
+	if !declined {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration has no open offer"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"registration_id": registrationID, "status": "cancelled"})
+}
+
+// TransferRegistration moves the user's registration to another session of the same
+// program. When the target session is full the transfer is refused with the waitlist
+// position the participant would get, until it is repeated with confirm_waitlist.
+func (h *Handler) TransferRegistration(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req struct {
+		SessionID       string `json:"session_id" binding:"required,uuid"`
+		ConfirmWaitlist bool   `json:"confirm_waitlist"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	targetSessionID, _ := uuid.Parse(req.SessionID)
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	var participantID, programID uuid.UUID
+	var parentType, status string
+	var sessionID *uuid.UUID
+	err = h.db.QueryRow(`
+		SELECT participant_id, parent_type, parent_id, session_id, status
+		FROM registrations
+		WHERE id = $1
+	`, registrationID).Scan(&participantID, &parentType, &programID, &sessionID, &status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if parentType != "program" || sessionID == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only session registrations can be transferred"})
+		return
+	}
+	if status != "confirmed" && status != "waitlisted" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only confirmed or waitlisted registrations can be transferred"})
+		return
+	}
+	if *sessionID == targetSessionID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Registration is already in this session"})
+		return
+	}
+
+	session, err := h.db.GetSessionByID(targetSessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get session"})
+		return
+	}
+	if session == nil || !session.IsActive {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	if session.ParentType != "program" || session.ParentID != programID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Session belongs to a different program"})
+		return
+	}
+
+	var alreadyRegistered bool
+	err = h.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM registrations
+			WHERE parent_type = 'program' AND parent_id = $1 AND session_id = $2 AND participant_id = $3
+				AND status IN ('confirmed', 'waitlisted', 'pending', 'paused', 'offered')
+		)
+	`, programID, targetSessionID, participantID).Scan(&alreadyRegistered)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if alreadyRegistered {
+		c.JSON(http.StatusConflict, gin.H{"error": "Participant is already registered for this session"})
+		return
+	}
+
+	result, err := h.regService.TransferRegistration(c.Request.Context(), registrationID, participantID, targetSessionID, &userID, req.ConfirmWaitlist)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	if result.NeedsWaitlistConfirmation {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":                     "Session is full",
+			"waitlist_position":         result.WaitlistPosition,
+			"confirm_waitlist_required": true,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"registration":              result.Result.Registration,
+		"waitlisted":                result.Result.IsWaitlisted,
+		"position":                  result.Result.Position,
+		"cancelled_registration_id": result.CancelledRegistrationID,
+	})
+}
+
+// PauseRegistration pauses a confirmed program registration. The participant keeps their
+// spot but is left off rosters and reminders until the registration is resumed.
+func (h *Handler) PauseRegistration(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	var req struct {
+		ResumeOn *string `json:"resume_on"`
+		Reason   *string `json:"reason"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var resumeOn *time.Time
+	if req.ResumeOn != nil {
+		d, err := time.Parse("2006-01-02", *req.ResumeOn)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "resume_on must be YYYY-MM-DD"})
+			return
+		}
+		if d.Before(time.Now().Truncate(24 * time.Hour)) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "resume_on must not be in the past"})
+			return
+		}
+		resumeOn = &d
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	var participantID uuid.UUID
+	var parentType, status string
+	err = h.db.QueryRow(`
+		SELECT participant_id, parent_type, status
+		FROM registrations
+		WHERE id = $1
+	`, registrationID).Scan(&participantID, &parentType, &status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if parentType != "program" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only program registrations can be paused"})
+		return
+	}
+	if status != "confirmed" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Only confirmed registrations can be paused"})
+		return
+	}
+
+	pause, err := h.db.PauseRegistration(registrationID, participantID, &userID, resumeOn, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration paused", "pause": pause})
+}
+
+// ResumeRegistration returns a paused registration to confirmed
+func (h *Handler) ResumeRegistration(c *gin.Context) {
+	userID, _ := GetUserID(c)
+
+	registrationID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid registration ID"})
+		return
+	}
+
+	if !h.checkRegistrationOwner(c, registrationID, userID) {
+		return
+	}
+
+	var participantID uuid.UUID
+	var status string
+	err = h.db.QueryRow(`SELECT participant_id, status FROM registrations WHERE id = $1`, registrationID).Scan(&participantID, &status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return
+	}
+	if status != "paused" {
+		c.JSON(http.StatusConflict, gin.H{"error": "Registration is not paused"})
+		return
+	}
+
+	pause, err := h.db.ResumeRegistration(registrationID, participantID, &userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Registration resumed", "pause": pause})
+}
+
+// checkRegistrationOwner responds with 404 or 403 and returns false unless the
+// registration exists and belongs to the user's household
+func (h *Handler) checkRegistrationOwner(c *gin.Context, registrationID, userID uuid.UUID) bool {
+	var householdID uuid.UUID
+	err := h.db.QueryRow(`
+		SELECT p.household_id
+		FROM registrations r
+		JOIN participants p ON p.id = r.participant_id
+		WHERE r.id = $1
+	`, registrationID).Scan(&householdID)
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Registration not found"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		return false
+	}
+
+	household, err := h.db.GetUserHousehold(userID)
+	if err != nil || household == nil || household.ID != householdID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Not authorized"})
+		return false
+	}
+	return true
+}
+
 func (h *Handler) Health(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
 		"status": "healthy",
@@ -0,0 +1,260 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/db"
+)
+
+// impersonatorCookie keeps the admin's own token while they impersonate a user, so
+// ending the session signs them back in as themselves
+const impersonatorCookie = "impersonator_token"
+
+// impersonationAllowedWrites are the only routes other than GET that may be used while
+// impersonating: support staff look around as the family but leave every change to
+// them. Any other write, including one on a route added later, is refused.
+var impersonationAllowedWrites = map[string]bool{
+	"POST /api/logout":                      true,
+	"POST /api/registrations/cart/validate": true,
+}
+
+// impersonationBlockedReads are GET routes that still change something for the family,
+// and are refused while impersonating
+var impersonationBlockedReads = map[string]bool{
+	"GET /api/me/integrations/google/connect": true,
+}
+
+// impersonationBlocked reports whether a route may not be used while impersonating
+func impersonationBlocked(method, route string) bool {
+	key := method + " " + route
+	if method == http.MethodGet || method == http.MethodHead {
+		return impersonationBlockedReads[key]
+	}
+	return !impersonationAllowedWrites[key]
+}
+
+// GenerateImpersonationToken creates a JWT that acts as the session's user until the
+// session expires
+func GenerateImpersonationToken(session *db.ImpersonationSession, email string) (string, error) {
+	claims := &Claims{
+		UserID:                 session.UserID,
+		Email:                  email,
+		ImpersonatedBy:         &session.AdminUserID,
+		ImpersonationSessionID: &session.ID,
+		Impersonating:          true,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(session.ExpiresAt),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(jwtSecret)
+}
+
+// authenticateImpersonation sets the impersonated user as the request's user while the
+// session is active, refuses blocked actions and records every request in the session's
+// audit log
+func authenticateImpersonation(c *gin.Context, database *db.DB, claims *Claims) {
+	session, err := database.GetImpersonationSession(*claims.ImpersonationSessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check impersonation session"})
+		c.Abort()
+		return
+	}
+	if session == nil || session.UserID != claims.UserID || !session.IsActive(time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Impersonation session has ended"})
+		c.Abort()
+		return
+	}
+
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("impersonation", session)
+
+	blocked := impersonationBlocked(c.Request.Method, c.FullPath())
+	if blocked {
+		c.JSON(http.StatusForbidden, gin.H{"error": "This action is not available while impersonating a user"})
+		c.Abort()
+	} else {
+		c.Next()
+	}
+
+	err = database.RecordImpersonationAction(db.ImpersonationAction{
+		SessionID: session.ID,
+		Method:    c.Request.Method,
+		Path:      c.Request.URL.Path,
+		Status:    c.Writer.Status(),
+		Blocked:   blocked,
+	})
+	if err != nil {
+		log.Printf("Failed to record impersonation action: %v", err)
+	}
+}
+
+// GetImpersonation returns the impersonation session the request is made in, if any
+func GetImpersonation(c *gin.Context) (*db.ImpersonationSession, bool) {
+	session, exists := c.Get("impersonation")
+	if !exists {
+		return nil, false
+	}
+	return session.(*db.ImpersonationSession), true
+}
+
+// AdminImpersonateUser signs the admin in as a user for a short, audited support
+// session. The admin's own token is kept aside until the session is ended.
+func (h *Handler) AdminImpersonateUser(c *gin.Context) {
+	adminID, _ := GetUserID(c)
+
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := h.db.GetUserByID(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user"})
+		return
+	}
+	if user == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.ID == adminID {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "You cannot impersonate yourself"})
+		return
+	}
+	if user.Role == "admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Admin accounts cannot be impersonated"})
+		return
+	}
+
+	session, err := h.db.CreateImpersonationSession(adminID, userID, req.Reason)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start impersonation"})
+		return
+	}
+
+	token, err := GenerateImpersonationToken(session, user.Email)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		return
+	}
+
+	if adminToken, err := c.Cookie("auth_token"); err == nil {
+		setTokenCookie(c, impersonatorCookie, adminToken, 60*60*24*7) // 7 days, as the admin's own cookie
+	}
+	setTokenCookie(c, "auth_token", token, int(time.Until(session.ExpiresAt).Seconds()))
+
+	log.Printf("Admin %s started impersonating user %s (session %s): %s", adminID, userID, session.ID, req.Reason)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"impersonation": session,
+		"user":          user,
+	})
+}
+
+// EndImpersonation ends the current impersonation session and signs the admin back in
+// as themselves. It is reachable without a valid session so an expired impersonation
+// can still be left.
+func (h *Handler) EndImpersonation(c *gin.Context) {
+	ended := false
+	if tokenString, err := c.Cookie("auth_token"); err == nil {
+		// Claims are not validated so an expired impersonation token can still be ended
+		claims := &Claims{}
+		parser := jwt.NewParser(jwt.WithoutClaimsValidation())
+		token, err := parser.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+			return jwtSecret, nil
+		})
+		if err == nil && token.Valid && claims.ImpersonationSessionID != nil {
+			if err := h.db.EndImpersonationSession(*claims.ImpersonationSessionID); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to end impersonation"})
+				return
+			}
+			log.Printf("Impersonation session %s ended", *claims.ImpersonationSessionID)
+			ended = true
+		}
+	}
+
+	adminToken, err := c.Cookie(impersonatorCookie)
+	if !ended && err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Not impersonating a user"})
+		return
+	}
+
+	setTokenCookie(c, impersonatorCookie, "", -1)
+	if err == nil {
+		SetAuthCookie(c, adminToken)
+	} else {
+		ClearAuthCookie(c)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Impersonation ended"})
+}
+
+// AdminGetImpersonationSessions lists recent impersonation sessions
+func (h *Handler) AdminGetImpersonationSessions(c *gin.Context) {
+	limit := 100
+	if l := c.Query("limit"); l != "" {
+		parsed, err := strconv.Atoi(l)
+		if err != nil || parsed < 1 || parsed > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+			return
+		}
+		limit = parsed
+	}
+
+	sessions, err := h.db.GetImpersonationSessions(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get impersonation sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": sessions})
+}
+
+// AdminGetImpersonationSession returns an impersonation session with its audit log
+func (h *Handler) AdminGetImpersonationSession(c *gin.Context) {
+	sessionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return
+	}
+
+	session, err := h.db.GetImpersonationSession(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get impersonation session"})
+		return
+	}
+	if session == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Impersonation session not found"})
+		return
+	}
+
+	actions, err := h.db.GetImpersonationActions(sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get impersonation actions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"session": session,
+		"actions": actions,
+	})
+}
@@ -0,0 +1,53 @@
+package http
+
+import (
+	"net/http"
+	"os"
+	"regexp"
+	"testing"
+)
+
+// TestImpersonationBlocksWrites goes through every protected and admin route registered
+// in main.go and checks that only GETs and the allowed writes are usable while
+// impersonating
+func TestImpersonationBlocksWrites(t *testing.T) {
+	source, err := os.ReadFile("../../cmd/api/main.go")
+	if err != nil {
+		t.Fatalf("failed to read routes: %v", err)
+	}
+
+	prefixes := map[string]string{"protected": "/api", "admin": "/api/admin"}
+	routePattern := regexp.MustCompile(`(protected|admin)\.(GET|POST|PUT|PATCH|DELETE)\("([^"]+)"`)
+	routes := routePattern.FindAllStringSubmatch(string(source), -1)
+	if len(routes) == 0 {
+		t.Fatal("no routes found in main.go")
+	}
+
+	writes := 0
+	for _, m := range routes {
+		method, route := m[2], prefixes[m[1]]+m[3]
+		blocked := impersonationBlocked(method, route)
+		switch {
+		case method == http.MethodGet:
+			if blocked != impersonationBlockedReads[method+" "+route] {
+				t.Errorf("%s %s blocked = %v while impersonating", method, route, blocked)
+			}
+		case impersonationAllowedWrites[method+" "+route]:
+			writes++
+			if blocked {
+				t.Errorf("%s %s is blocked while impersonating, want allowed", method, route)
+			}
+		default:
+			if !blocked {
+				t.Errorf("%s %s is allowed while impersonating, want blocked", method, route)
+			}
+		}
+	}
+	if writes != len(impersonationAllowedWrites) {
+		t.Errorf("found %d of the %d allowed writes in main.go", writes, len(impersonationAllowedWrites))
+	}
+
+	if !impersonationBlocked(http.MethodPost, "/api/route-added-later") {
+		t.Error("an unlisted write is allowed while impersonating, want blocked")
+	}
+}
@@ -0,0 +1,140 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/core"
+)
+
+// googleOAuthStateCookie holds the OAuth state of a Google connection in progress
+const googleOAuthStateCookie = "google_oauth_state"
+
+// GetGoogleCalendarIntegration reports whether the user has connected Google Calendar
+func (h *Handler) GetGoogleCalendarIntegration(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	conn, err := h.db.GetGoogleCalendarConnection(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get integration"})
+		return
+	}
+
+	connected := conn != nil && conn.RevokedAt == nil
+	response := gin.H{
+		"available": h.googleCalendar.Configured(),
+		"connected": connected,
+	}
+	if connected {
+		response["connected_at"] = conn.ConnectedAt
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// ConnectGoogleCalendar returns the Google consent URL the browser should be sent to
+func (h *Handler) ConnectGoogleCalendar(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if !h.googleCalendar.Configured() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Google Calendar integration is not configured"})
+		return
+	}
+
+	// The state is also kept in a cookie, so the callback only completes in the browser
+	// that started the connection
+	authURL, state := h.googleCalendar.AuthURL(userID)
+	setTokenCookie(c, googleOAuthStateCookie, state, int(core.OAuthStateTTL.Seconds()))
+
+	c.JSON(http.StatusOK, gin.H{"url": authURL})
+}
+
+// GoogleCalendarCallback completes the Google consent flow. Google redirects the browser
+// here without our auth header, so the user is identified by the signed state instead,
+// which must match the state cookie set when the flow started. The browser is sent back
+// to the family page with the outcome.
+func (h *Handler) GoogleCalendarCallback(c *gin.Context) {
+	redirect := os.Getenv("APP_ORIGIN") + "/account/family?google_calendar="
+
+	state := c.Query("state")
+	cookieState, cookieErr := c.Cookie(googleOAuthStateCookie)
+	setTokenCookie(c, googleOAuthStateCookie, "", -1)
+
+	if c.Query("error") != "" {
+		c.Redirect(http.StatusFound, redirect+"cancelled")
+		return
+	}
+
+	if cookieErr != nil || state == "" || subtle.ConstantTimeCompare([]byte(cookieState), []byte(state)) != 1 {
+		c.Redirect(http.StatusFound, redirect+"error")
+		return
+	}
+
+	userID, err := core.ParseOAuthState(state)
+	if err != nil {
+		c.Redirect(http.StatusFound, redirect+"error")
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" || !h.googleCalendar.Configured() {
+		c.Redirect(http.StatusFound, redirect+"error")
+		return
+	}
+
+	if err := h.googleCalendar.Connect(c.Request.Context(), userID, code); err != nil {
+		c.Redirect(http.StatusFound, redirect+"error")
+		return
+	}
+
+	c.Redirect(http.StatusFound, redirect+"connected")
+}
+
+// DisconnectGoogleCalendar revokes the user's Google Calendar connection
+func (h *Handler) DisconnectGoogleCalendar(c *gin.Context) {
+	userID, exists := GetUserID(c)
+	if !exists || userID == uuid.Nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	conn, err := h.db.GetGoogleCalendarConnection(userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get integration"})
+		return
+	}
+	if conn == nil || conn.RevokedAt != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Google Calendar is not connected"})
+		return
+	}
+
+	if err := h.googleCalendar.Disconnect(c.Request.Context(), userID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disconnect Google Calendar"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Google Calendar disconnected"})
+}
+
+// AdminGetSyncStatus reports the central platform sync: whether it is enabled, the
+// request timeout, the circuit breaker state and the sync queue by status
+func (h *Handler) AdminGetSyncStatus(c *gin.Context) {
+	status, err := h.syncClient.Status(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get sync status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sync": status})
+}
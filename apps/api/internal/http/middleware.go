@@ -1,6 +1,7 @@
 package http
 
 import (
+	"log"
 	"net/http"
 	"os"
 	"strings"
@@ -9,6 +10,9 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+
+	"sterling-rec/api/internal/core"
+	"sterling-rec/api/internal/db"
 )
 
 var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
@@ -16,33 +20,125 @@ var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
+
+	// Set on impersonation tokens only. Impersonating is there for clients to show a
+	// banner; the session is checked on every request.
+	ImpersonatedBy         *uuid.UUID `json:"impersonated_by,omitempty"`
+	ImpersonationSessionID *uuid.UUID `json:"impersonation_session_id,omitempty"`
+	Impersonating          bool       `json:"impersonating,omitempty"`
+
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware validates JWT from cookie
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware validates the JWT from the auth cookie. API keys act as an admin
+// service user, so they are refused here and only accepted by AdminAuthMiddleware.
+func AuthMiddleware(database *db.DB) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := c.Cookie("auth_token")
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		if _, ok := bearerToken(c); ok {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API keys are only accepted on admin routes"})
 			c.Abort()
 			return
 		}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
+		authenticateCookie(c, database)
+	}
+}
 
-		if err != nil || !token.Valid {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
-			c.Abort()
+// AdminAuthMiddleware validates the JWT from the auth cookie, or an API key sent as
+// "Authorization: Bearer <key>" for server-to-server calls to admin routes
+func AdminAuthMiddleware(database *db.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if key, ok := bearerToken(c); ok {
+			authenticateAPIKey(c, database, key)
 			return
 		}
 
-		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
+		authenticateCookie(c, database)
+	}
+}
+
+// authenticateCookie sets the user from the JWT in the auth cookie, checking
+// impersonation sessions
+func authenticateCookie(c *gin.Context, database *db.DB) {
+	tokenString, err := c.Cookie("auth_token")
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		c.Abort()
+		return
+	}
+
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return jwtSecret, nil
+	})
+
+	if err != nil || !token.Valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		c.Abort()
+		return
+	}
+
+	if claims.ImpersonationSessionID != nil {
+		authenticateImpersonation(c, database, claims)
+		return
+	}
+
+	// Set user info in context
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Next()
+}
+
+// bearerToken returns the token from an "Authorization: Bearer" header
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateAPIKey sets the key's service user as the request's user. The key itself
+// is kept in the context so AdminOnly can check its scopes.
+func authenticateAPIKey(c *gin.Context, database *db.DB, key string) {
+	apiKey, err := database.GetActiveAPIKeyByHash(core.HashAPIKey(key))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check API key"})
+		c.Abort()
+		return
+	}
+	if apiKey == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+		c.Abort()
+		return
+	}
+
+	if err := database.TouchAPIKey(apiKey.ID); err != nil {
+		log.Printf("Failed to record API key use: %v", err)
+	}
+
+	c.Set("user_id", apiKey.ServiceUserID)
+	c.Set("user_email", apiKey.ServiceEmail)
+	c.Set("api_key", apiKey)
+	c.Next()
+}
+
+// OptionalAuthMiddleware sets user info from a valid JWT cookie when present, but
+// lets anonymous requests through
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, err := c.Cookie("auth_token")
+		if err == nil {
+			claims := &Claims{}
+			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+				return jwtSecret, nil
+			})
+			// Impersonation sessions are only checked and audited on authenticated routes
+			if err == nil && token.Valid && claims.ImpersonationSessionID == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+			}
+		}
 		c.Next()
 	}
 }
@@ -64,13 +160,18 @@ func GenerateToken(userID uuid.UUID, email string) (string, error) {
 
 // SetAuthCookie sets the authentication cookie
 func SetAuthCookie(c *gin.Context, token string) {
+	setTokenCookie(c, "auth_token", token, 60*60*24*7) // 7 days
+}
+
+// setTokenCookie sets an httpOnly cookie holding a token
+func setTokenCookie(c *gin.Context, name, token string, maxAge int) {
 	cookieDomain := os.Getenv("COOKIE_DOMAIN")
 	cookieSecure := os.Getenv("COOKIE_SECURE") == "true"
 
 	c.SetCookie(
-		"auth_token",
+		name,
 		token,
-		60*60*24*7, // 7 days
+		maxAge,
 		"/",
 		cookieDomain,
 		cookieSecure,
@@ -163,6 +264,43 @@ func GetUserID(c *gin.Context) (uuid.UUID, bool) {
 	return userID.(uuid.UUID), true
 }
 
+// GetAPIKey returns the API key the request authenticated with, if any
+func GetAPIKey(c *gin.Context) (*db.APIKey, bool) {
+	apiKey, exists := c.Get("api_key")
+	if !exists {
+		return nil, false
+	}
+	return apiKey.(*db.APIKey), true
+}
+
+// RequireScope limits requests made with an API key to keys granted the scope. Signed-in
+// users pass; their access is checked by AdminOnly.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if apiKey, ok := GetAPIKey(c); ok && !apiKey.HasScope(scope) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error":         "API key does not have the required scope",
+				"missing_scope": scope,
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SessionOnly rejects requests made with an API key
+func SessionOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKey(c); ok {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This endpoint requires a signed-in user"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
 // ValidateContentType ensures JSON content type for POST/PUT
 func ValidateContentType() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
 	"time"
 
 	"sterling-rec/api/internal/core"
@@ -12,22 +14,39 @@ import (
 )
 
 type JobManager struct {
-	db           *db.DB
-	emailService *core.EmailService
-	ctx          context.Context
-	cancel       context.CancelFunc
+	db             *db.DB
+	emailService   *core.EmailService
+	googleCalendar *core.GoogleCalendarService
+	ctx            context.Context
+	cancel         context.CancelFunc
+
+	// piiRetention is how long after their last activity participants' sensitive fields
+	// are kept; 0 keeps them indefinitely
+	piiRetention time.Duration
 }
 
-func NewJobManager(database *db.DB, emailService *core.EmailService) *JobManager {
+func NewJobManager(database *db.DB, emailService *core.EmailService, googleCalendar *core.GoogleCalendarService) *JobManager {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &JobManager{
-		db:           database,
-		emailService: emailService,
-		ctx:          ctx,
-		cancel:       cancel,
+		db:             database,
+		emailService:   emailService,
+		googleCalendar: googleCalendar,
+		ctx:            ctx,
+		cancel:         cancel,
+		piiRetention:   piiRetentionFromEnv(),
 	}
 }
 
+// piiRetentionFromEnv reads PARTICIPANT_PII_RETENTION_DAYS. Unset or invalid values
+// disable the retention purge.
+func piiRetentionFromEnv() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("PARTICIPANT_PII_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 func (jm *JobManager) Start() {
 	log.Println("Starting job manager...")
 
@@ -37,6 +56,21 @@ func (jm *JobManager) Start() {
 	// Reminder worker - check every hour
 	go jm.runPeriodic("reminder-worker", 1*time.Hour, jm.scheduleReminders)
 
+	// Google Calendar worker - push queued changes every minute
+	go jm.runPeriodic("calendar-worker", 1*time.Minute, jm.googleCalendar.ProcessSyncQueue)
+
+	// Interest list worker - email interested families once registration opens
+	go jm.runPeriodic("interest-worker", 1*time.Minute, jm.notifyRegistrationOpen)
+
+	// Maintenance worker - purge expired idempotency keys and stale participant PII every hour
+	go jm.runPeriodic("maintenance-worker", 1*time.Hour, jm.runMaintenance)
+
+	// Booking hold sweeper - mark unconfirmed booking holds expired every minute
+	go jm.runPeriodic("booking-hold-sweeper", 1*time.Minute, jm.expireBookingHolds)
+
+	// Waitlist offer sweeper - pass spots whose offers ran out to the next person every minute
+	go jm.runPeriodic("waitlist-offer-sweeper", 1*time.Minute, jm.expireWaitlistOffers)
+
 	log.Println("Job manager started")
 }
 
@@ -71,55 +105,119 @@ func (jm *JobManager) processEmailQueue() error {
 	return jm.emailService.ProcessNotificationQueue()
 }
 
-func (jm *JobManager) scheduleReminders() error {
-	now := time.Now()
-	_ = now.Add(72 * time.Hour) // window72h
-	_ = now.Add(24 * time.Hour) // window24h
-
-	// Find sessions starting in ~72 hours (between 71-73 hours from now)
-	err := jm.scheduleRemindersForWindow(
-		now.Add(71*time.Hour),
-		now.Add(73*time.Hour),
-		"REMINDER_72H",
-	)
+func (jm *JobManager) notifyRegistrationOpen() error {
+	count, err := jm.db.QueueRegistrationOpenNotifications()
 	if err != nil {
-		log.Printf("Failed to schedule 72h reminders: %v", err)
+		return err
+	}
+	if count > 0 {
+		log.Printf("Queued %d registration open notifications", count)
 	}
+	return nil
+}
 
-	// Find sessions starting in ~24 hours (between 23-25 hours from now)
-	err = jm.scheduleRemindersForWindow(
-		now.Add(23*time.Hour),
-		now.Add(25*time.Hour),
-		"REMINDER_24H",
-	)
+func (jm *JobManager) expireBookingHolds() error {
+	count, err := jm.db.ExpireBookingHolds()
 	if err != nil {
-		log.Printf("Failed to schedule 24h reminders: %v", err)
+		return err
 	}
+	if count > 0 {
+		log.Printf("Expired %d unconfirmed booking holds", count)
+	}
+	return nil
+}
+
+func (jm *JobManager) expireWaitlistOffers() error {
+	count, err := jm.db.ExpireWaitlistOffers()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		log.Printf("Expired %d unaccepted waitlist offers", count)
+	}
+	return nil
+}
 
-	// Find events starting in ~72 hours
-	err = jm.scheduleEventRemindersForWindow(
-		now.Add(71*time.Hour),
-		now.Add(73*time.Hour),
-		"REMINDER_72H",
-	)
+func (jm *JobManager) runMaintenance() error {
+	count, err := jm.db.DeleteExpiredIdempotencyKeys()
 	if err != nil {
-		log.Printf("Failed to schedule event 72h reminders: %v", err)
+		return err
+	}
+	if count > 0 {
+		log.Printf("Deleted %d expired idempotency keys", count)
+	}
+
+	return jm.purgeParticipantPII()
+}
+
+// purgeParticipantPII scrubs sensitive fields from participants inactive for longer than
+// the retention window, logging each participant and the fields cleared
+func (jm *JobManager) purgeParticipantPII() error {
+	if jm.piiRetention <= 0 {
+		return nil
 	}
 
-	// Find events starting in ~24 hours
-	err = jm.scheduleEventRemindersForWindow(
-		now.Add(23*time.Hour),
-		now.Add(25*time.Hour),
-		"REMINDER_24H",
-	)
+	purges, err := jm.db.PurgeInactiveParticipantPII(time.Now().Add(-jm.piiRetention))
 	if err != nil {
-		log.Printf("Failed to schedule event 24h reminders: %v", err)
+		return err
+	}
+	for _, p := range purges {
+		log.Printf("Purged %v from participant %s, last active %s", p.Fields, p.ParticipantID, p.LastActivityAt.Format(time.RFC3339))
+	}
+	if len(purges) > 0 {
+		log.Printf("Purged sensitive fields from %d inactive participants", len(purges))
+	}
+	return nil
+}
+
+// reminderSchedule is a reminder email sent a fixed time before a session or event starts
+type reminderSchedule struct {
+	Type   string
+	Offset time.Duration
+}
+
+// reminderSchedules are the reminders sent before each session and event
+var reminderSchedules = []reminderSchedule{
+	{Type: "REMINDER_72H", Offset: 72 * time.Hour},
+	{Type: "REMINDER_24H", Offset: 24 * time.Hour},
+}
+
+// reminderLeadTime is how far ahead of its send time a reminder is queued, held back by
+// its not_before_ts until then
+const reminderLeadTime = 12 * time.Hour
+
+// window returns the start times of the sessions and events whose reminder is queued on
+// a run at now: from an hour past the send time, so an hourly run never misses one, to
+// reminderLeadTime ahead of it
+func (r reminderSchedule) window(now time.Time) (time.Time, time.Time) {
+	return now.Add(r.Offset - time.Hour), now.Add(r.Offset + reminderLeadTime)
+}
+
+// notBefore returns when the reminder for a session or event starting at startsAt is sent
+func (r reminderSchedule) notBefore(startsAt time.Time) time.Time {
+	return startsAt.Add(-r.Offset)
+}
+
+func (jm *JobManager) scheduleReminders() error {
+	now := time.Now()
+
+	for _, r := range reminderSchedules {
+		from, to := r.window(now)
+
+		if err := jm.scheduleRemindersForWindow(from, to, r); err != nil {
+			log.Printf("Failed to schedule %s reminders: %v", r.Type, err)
+		}
+		if err := jm.scheduleEventRemindersForWindow(from, to, r); err != nil {
+			log.Printf("Failed to schedule event %s reminders: %v", r.Type, err)
+		}
 	}
 
 	return nil
 }
 
-func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, reminderType string) error {
+// scheduleRemindersForWindow queues the reminder for confirmed registrations in sessions
+// of programs and events starting in the window, once per type, participant and session
+func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, reminder reminderSchedule) error {
 	// Find sessions in time window
 	rows, err := jm.db.Query(`
 		SELECT s.id, s.parent_type, s.parent_id, s.starts_at
@@ -163,7 +261,8 @@ func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, r
 				continue
 			}
 
-			// Check if reminder already queued
+			// Check if this reminder was already queued or sent; each session is in the
+			// window for several hourly runs
 			var exists bool
 			err = jm.db.QueryRow(`
 				SELECT EXISTS(
@@ -171,8 +270,14 @@ func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, r
 					WHERE type = $1
 						AND payload->>'participant_id' = $2
 						AND payload->>'session_id' = $3
+					UNION ALL
+					SELECT 1 FROM sent_notifications
+					WHERE type = $1
+						AND payload->>'participant_id' = $2
+						AND payload->>'session_id' = $3
+						AND skipped_reason IS NULL
 				)
-			`, reminderType, participantID, sessionID).Scan(&exists)
+			`, reminder.Type, participantID, sessionID).Scan(&exists)
 			if err != nil || exists {
 				continue
 			}
@@ -189,7 +294,7 @@ func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, r
 			_, err = jm.db.Exec(`
 				INSERT INTO notification_queue (type, payload, not_before_ts)
 				VALUES ($1, $2, $3)
-			`, reminderType, payloadJSON, startsAt.Add(-72*time.Hour))
+			`, reminder.Type, payloadJSON, reminder.notBefore(startsAt))
 			if err != nil {
 				log.Printf("Failed to queue reminder: %v", err)
 				continue
@@ -201,20 +306,24 @@ func (jm *JobManager) scheduleRemindersForWindow(startTime, endTime time.Time, r
 	}
 
 	if count > 0 {
-		log.Printf("Scheduled %d %s session reminders", count, reminderType)
+		log.Printf("Scheduled %d %s session reminders", count, reminder.Type)
 	}
 
 	return nil
 }
 
-func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Time, reminderType string) error {
+// scheduleEventRemindersForWindow queues the reminder for confirmed registrations in
+// events starting in the window, once per type, participant and event. Only
+// registrations for the event as a whole are reminded of here; one for an occurrence of
+// an event with sessions is reminded of as a session.
+func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Time, reminder reminderSchedule) error {
 	// Find events in time window
 	rows, err := jm.db.Query(`
-		SELECT id, starts_at
-		FROM events
-		WHERE is_active = true
-			AND starts_at >= $1
-			AND starts_at < $2
+		SELECT e.id, e.starts_at
+		FROM events e
+		WHERE e.is_active = true
+			AND e.starts_at >= $1
+			AND e.starts_at < $2
 	`, startTime, endTime)
 	if err != nil {
 		return fmt.Errorf("failed to query events: %w", err)
@@ -236,7 +345,7 @@ func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Ti
 		regRows, err := jm.db.Query(`
 			SELECT participant_id
 			FROM registrations
-			WHERE parent_type = 'event' AND parent_id = $1 AND status = 'confirmed'
+			WHERE parent_type = 'event' AND parent_id = $1 AND session_id IS NULL AND status = 'confirmed'
 		`, eventID)
 		if err != nil {
 			log.Printf("Failed to query registrations: %v", err)
@@ -250,7 +359,8 @@ func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Ti
 				continue
 			}
 
-			// Check if reminder already queued
+			// Check if this reminder was already queued or sent; each event is in the window
+			// for several hourly runs
 			var exists bool
 			err = jm.db.QueryRow(`
 				SELECT EXISTS(
@@ -258,8 +368,16 @@ func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Ti
 					WHERE type = $1
 						AND payload->>'participant_id' = $2
 						AND payload->>'parent_id' = $3
+						AND payload->>'session_id' IS NULL
+					UNION ALL
+					SELECT 1 FROM sent_notifications
+					WHERE type = $1
+						AND payload->>'participant_id' = $2
+						AND payload->>'parent_id' = $3
+						AND payload->>'session_id' IS NULL
+						AND skipped_reason IS NULL
 				)
-			`, reminderType, participantID, eventID).Scan(&exists)
+			`, reminder.Type, participantID, eventID).Scan(&exists)
 			if err != nil || exists {
 				continue
 			}
@@ -275,7 +393,7 @@ func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Ti
 			_, err = jm.db.Exec(`
 				INSERT INTO notification_queue (type, payload, not_before_ts)
 				VALUES ($1, $2, $3)
-			`, reminderType, payloadJSON, startsAt.Add(-72*time.Hour))
+			`, reminder.Type, payloadJSON, reminder.notBefore(startsAt))
 			if err != nil {
 				log.Printf("Failed to queue reminder: %v", err)
 				continue
@@ -287,7 +405,7 @@ func (jm *JobManager) scheduleEventRemindersForWindow(startTime, endTime time.Ti
 	}
 
 	if count > 0 {
-		log.Printf("Scheduled %d %s event reminders", count, reminderType)
+		log.Printf("Scheduled %d %s event reminders", count, reminder.Type)
 	}
 
 	return nil
@@ -0,0 +1,54 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+// queuedReminders returns the types of the reminders a run at now queues for a session
+// starting at startsAt, with when each is sent
+func queuedReminders(startsAt, now time.Time) map[string]time.Time {
+	queued := make(map[string]time.Time)
+	for _, r := range reminderSchedules {
+		from, to := r.window(now)
+		if !startsAt.Before(from) && startsAt.Before(to) {
+			queued[r.Type] = r.notBefore(startsAt)
+		}
+	}
+	return queued
+}
+
+// TestReminderSchedules tests each reminder is queued only ahead of its own send time
+// and held back until then
+func TestReminderSchedules(t *testing.T) {
+	now := time.Date(2030, time.March, 4, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		until time.Duration
+		want  map[string]time.Duration
+	}{
+		{"session 30 hours out", 30 * time.Hour, map[string]time.Duration{"REMINDER_24H": 6 * time.Hour}},
+		{"session 80 hours out", 80 * time.Hour, map[string]time.Duration{"REMINDER_72H": 8 * time.Hour}},
+		{"24h reminder just due", 24*time.Hour - 30*time.Minute, map[string]time.Duration{"REMINDER_24H": -30 * time.Minute}},
+		{"between reminders", 50 * time.Hour, map[string]time.Duration{}},
+		{"too far out", 100 * time.Hour, map[string]time.Duration{}},
+		{"starting soon", 2 * time.Hour, map[string]time.Duration{}},
+	}
+
+	for _, tt := range tests {
+		queued := queuedReminders(now.Add(tt.until), now)
+		if len(queued) != len(tt.want) {
+			t.Errorf("%s: queued %v, want %v", tt.name, queued, tt.want)
+			continue
+		}
+		for reminderType, after := range tt.want {
+			notBefore, ok := queued[reminderType]
+			if !ok {
+				t.Errorf("%s: %s not queued, got %v", tt.name, reminderType, queued)
+			} else if !notBefore.Equal(now.Add(after)) {
+				t.Errorf("%s: %s sent at %v, want %v", tt.name, reminderType, notBefore, now.Add(after))
+			}
+		}
+	}
+}
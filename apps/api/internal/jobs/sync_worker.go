@@ -3,6 +3,7 @@ package jobs
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -57,6 +58,13 @@ func (sw *SyncWorker) run() {
 func (sw *SyncWorker) processSyncQueue() {
 	ctx := context.Background()
 
+	// While the central platform is down the breaker is open; wait for it to probe again
+	// rather than fail every queued event
+	if !sw.syncClient.Available() {
+		log.Println("Central platform circuit breaker open, skipping sync run")
+		return
+	}
+
 	// Get pending sync events that are ready to be retried
 	rows, err := sw.db.Query(`
 		SELECT id, event_type, entity_type, entity_id, payload, attempts, max_attempts
@@ -92,12 +100,16 @@ func (sw *SyncWorker) processSyncQueue() {
 			continue
 		}
 
-		// Process the event
-		sw.processSyncEvent(ctx, id, eventType, payload, attempts, maxAttempts)
+		// Process the event, stopping if the breaker opens
+		if !sw.processSyncEvent(ctx, id, eventType, payload, attempts, maxAttempts) {
+			return
+		}
 	}
 }
 
-func (sw *SyncWorker) processSyncEvent(ctx context.Context, id int64, eventType string, payload map[string]interface{}, attempts, maxAttempts int) {
+// processSyncEvent sends one event to the central platform. It returns false, leaving the
+// event untouched, when the circuit breaker refused the request.
+func (sw *SyncWorker) processSyncEvent(ctx context.Context, id int64, eventType string, payload map[string]interface{}, attempts, maxAttempts int) bool {
 	log.Printf("Processing sync event %d (type: %s, attempt: %d/%d)", id, eventType, attempts+1, maxAttempts)
 
 	// Increment attempts
@@ -111,7 +123,12 @@ func (sw *SyncWorker) processSyncEvent(ctx context.Context, id int64, eventType
 		err = sw.syncClient.SyncRegistrationCancelled(ctx, payload)
 	default:
 		sw.markFailed(id, fmt.Sprintf("Unknown event type: %s", eventType))
-		return
+		return true
+	}
+
+	if errors.Is(err, core.ErrCircuitOpen) {
+		log.Printf("Sync event %d deferred: %v", id, err)
+		return false
 	}
 
 	if err != nil {
@@ -133,6 +150,7 @@ func (sw *SyncWorker) processSyncEvent(ctx context.Context, id int64, eventType
 		sw.logSyncEvent(id, "INFO", "Successfully synced to central platform", nil)
 		log.Printf("Sync event %d completed successfully", id)
 	}
+	return true
 }
 
 func (sw *SyncWorker) calculateNextRetry(attempts int) time.Time {
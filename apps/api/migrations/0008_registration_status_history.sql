@@ -0,0 +1,16 @@
+-- Migration 0008: Registration status history
+-- Records every status transition of a registration for auditing and dispute resolution
+
+CREATE TABLE IF NOT EXISTS registration_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    registration_id UUID NOT NULL REFERENCES registrations(id) ON DELETE CASCADE,
+    old_status TEXT, -- NULL for the initial status
+    new_status TEXT NOT NULL,
+    changed_by UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL when changed by the system
+    reason TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_reg_status_history_registration ON registration_status_history(registration_id, created_at);
+
+COMMENT ON TABLE registration_status_history IS 'Audit trail of registration status changes';
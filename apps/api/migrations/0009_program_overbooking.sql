@@ -0,0 +1,9 @@
+-- Migration 0009: Program overbooking
+-- Lets admins confirm more registrations than nominal capacity for programs with high no-show rates.
+-- Effective capacity = floor(capacity * (1 + overbook_pct / 100))
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS overbook_pct INT NOT NULL DEFAULT 0;
+
+ALTER TABLE programs ADD CONSTRAINT programs_overbook_pct_check CHECK (overbook_pct >= 0 AND overbook_pct <= 100);
+
+COMMENT ON COLUMN programs.overbook_pct IS 'Percentage above capacity that may be confirmed (0 = no overbooking)';
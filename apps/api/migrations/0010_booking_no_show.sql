@@ -0,0 +1,8 @@
+-- Migration 0010: Booking no-shows
+-- Allows staff to mark a confirmed booking as a no-show so usage statistics can report it
+
+ALTER TABLE facility_bookings DROP CONSTRAINT IF EXISTS facility_bookings_status_check;
+ALTER TABLE facility_bookings ADD CONSTRAINT facility_bookings_status_check
+    CHECK (status IN ('confirmed', 'cancelled', 'no_show'));
+
+CREATE INDEX IF NOT EXISTS idx_bookings_user_start ON facility_bookings(user_id, start_time);
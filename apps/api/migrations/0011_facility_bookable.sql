@@ -0,0 +1,7 @@
+-- Migration 0011: Facility bookable flag
+-- Separates "closed to new bookings" from soft-deletion (is_active = false).
+-- A non-bookable facility stays listed and keeps its existing bookings.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS bookable BOOLEAN NOT NULL DEFAULT true;
+
+COMMENT ON COLUMN facilities.bookable IS 'When false the facility is visible but rejects new bookings';
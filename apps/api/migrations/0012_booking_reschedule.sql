@@ -0,0 +1,39 @@
+-- Migration 0012: Booking reschedule notifications
+-- Lets staff move bookings out of a closure and notify the affected users
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'BOOKING_RESCHEDULED';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'BOOKING_RESCHEDULED',
+    'Booking Rescheduled: {{.FacilityName}} - {{.BookingDate}}',
+    '<h2>Booking Rescheduled</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>Due to a facility closure, your booking has been moved to a new time:</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <h3>{{.FacilityName}}</h3>
+        <p><strong>New date:</strong> {{.BookingDate}}</p>
+        <p><strong>New time:</strong> {{.StartTime}} - {{.EndTime}}</p>
+        <p><strong>Location:</strong> {{.Location}}</p>
+        {{if .PreviousDate}}<p><strong>Originally:</strong> {{.PreviousDate}}, {{.PreviousStartTime}} - {{.PreviousEndTime}}</p>{{end}}
+    </div>
+    <p>If the new time does not work for you, please cancel or contact us.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Booking Rescheduled
+
+Hi {{.UserFirstName}},
+
+Due to a facility closure, your booking has been moved to a new time:
+
+Facility: {{.FacilityName}}
+New date: {{.BookingDate}}
+New time: {{.StartTime}} - {{.EndTime}}
+Location: {{.Location}}
+{{if .PreviousDate}}Originally: {{.PreviousDate}}, {{.PreviousStartTime}} - {{.PreviousEndTime}}{{end}}
+
+If the new time does not work for you, please cancel or contact us.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
@@ -0,0 +1,6 @@
+-- Migration 0013: Participant photos
+-- Optional headshot URL so staff can identify participants at check-in and pickup
+
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS photo_url TEXT;
+
+COMMENT ON COLUMN participants.photo_url IS 'URL of an image identifying the participant; shown to staff on rosters';
@@ -0,0 +1,14 @@
+-- Migration 0014: Structured cancellation reasons
+-- A reason code alongside the free-text reason so cancellations can be reported on
+
+ALTER TABLE registration_status_history ADD COLUMN IF NOT EXISTS reason_code TEXT
+    CHECK (reason_code IN ('schedule_conflict', 'illness', 'cost', 'dissatisfied', 'moved', 'other'));
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS cancellation_reason_code TEXT
+    CHECK (cancellation_reason_code IN ('schedule_conflict', 'illness', 'cost', 'dissatisfied', 'moved', 'other'));
+
+CREATE INDEX IF NOT EXISTS idx_reg_status_history_new_status ON registration_status_history(new_status, created_at);
+CREATE INDEX IF NOT EXISTS idx_bookings_cancelled_at ON facility_bookings(cancelled_at) WHERE status = 'cancelled';
+
+COMMENT ON COLUMN registration_status_history.reason_code IS 'Structured cancellation reason; NULL for other transitions or when not given';
+COMMENT ON COLUMN facility_bookings.cancellation_reason_code IS 'Structured cancellation reason; free text stays in cancellation_reason';
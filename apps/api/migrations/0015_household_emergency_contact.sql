@@ -0,0 +1,5 @@
+-- Migration 0015: Household default emergency contact
+-- New participants inherit these when no emergency contact is given
+
+ALTER TABLE households ADD COLUMN IF NOT EXISTS default_emergency_contact_name TEXT;
+ALTER TABLE households ADD COLUMN IF NOT EXISTS default_emergency_contact_phone TEXT;
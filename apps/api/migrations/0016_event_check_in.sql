@@ -0,0 +1,22 @@
+-- Migration 0016: Event check-in
+-- Door staff scan a signed token from the confirmation email to record attendance
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMPTZ;
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS checked_in_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN registrations.checked_in_at IS 'When the participant was scanned in at the event door; NULL until checked in';
+
+-- Show the check-in code in event confirmations (CheckInToken is only set for events)
+UPDATE email_templates
+SET body_html = replace(body_html, '<p>We look forward to seeing you!</p>',
+        '{{if .CheckInToken}}<p><strong>Check-in code:</strong> {{.CheckInToken}}</p>
+<p>Show this code (or its QR code) at the door.</p>{{end}}
+<p>We look forward to seeing you!</p>'),
+    body_text = replace(body_text, 'We look forward to seeing you!',
+        '{{if .CheckInToken}}Check-in code: {{.CheckInToken}}
+Show this code (or its QR code) at the door.
+
+{{end}}We look forward to seeing you!'),
+    updated_at = now()
+WHERE template_key IN ('CONFIRMATION', 'WAITLIST_PROMOTED')
+  AND body_html NOT LIKE '%CheckInToken%';
@@ -0,0 +1,11 @@
+-- Migration 0017: Availability window audiences
+-- Lets one facility express e.g. "public swim 6-8am, members 8-10am, staff any time"
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_member BOOL NOT NULL DEFAULT false;
+
+ALTER TABLE availability_windows ADD COLUMN IF NOT EXISTS audience TEXT NOT NULL DEFAULT 'public';
+ALTER TABLE availability_windows ADD CONSTRAINT availability_windows_audience_check
+    CHECK (audience IN ('public', 'members', 'staff'));
+
+COMMENT ON COLUMN users.is_member IS 'Whether the user may book windows reserved for members';
+COMMENT ON COLUMN availability_windows.audience IS 'Who may book this window: public (everyone), members (members and staff) or staff (staff only)';
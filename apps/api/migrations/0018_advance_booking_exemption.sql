@@ -0,0 +1,9 @@
+-- Migration 0018: Advance booking exemptions
+-- Trusted users and staff may book beyond a facility's advance booking window
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS advance_booking_exempt BOOL NOT NULL DEFAULT false;
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS advance_limit_waived BOOL NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN users.advance_booking_exempt IS 'Whether the user may book beyond advance_booking_days (admins always may)';
+COMMENT ON COLUMN facility_bookings.advance_limit_waived IS 'True when the booking was made beyond the advance booking limit using an exemption';
@@ -0,0 +1,20 @@
+-- Migration 0019: Household merging
+-- Two households can be combined; the emptied one is soft-deleted and its owner
+-- becomes a member of the surviving household
+
+ALTER TABLE households ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE households ADD COLUMN IF NOT EXISTS merged_into_id UUID REFERENCES households(id) ON DELETE SET NULL;
+
+-- Users other than the owner who share a household
+CREATE TABLE IF NOT EXISTS household_members (
+    household_id UUID NOT NULL REFERENCES households(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (household_id, user_id)
+);
+
+-- A user belongs to at most one household as a member
+CREATE UNIQUE INDEX IF NOT EXISTS idx_household_members_user ON household_members(user_id);
+
+COMMENT ON TABLE household_members IS 'Users who share a household they do not own, e.g. after a merge';
+COMMENT ON COLUMN households.merged_into_id IS 'Household this one was merged into; set together with deleted_at';
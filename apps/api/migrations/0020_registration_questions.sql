@@ -0,0 +1,9 @@
+-- Migration 0020: Per-program registration questions
+-- One-off questions (e.g. t-shirt size, carpool interest) answered on each registration.
+-- registration_questions uses the same {"fields": [...]} format as form_templates.schema_json
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS registration_questions JSONB;
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS answers_json JSONB;
+
+COMMENT ON COLUMN programs.registration_questions IS 'Questions asked at registration, as {"fields": [{"id", "type", "label", "required", "options"}]}';
+COMMENT ON COLUMN registrations.answers_json IS 'Answers to the program registration questions, keyed by field id';
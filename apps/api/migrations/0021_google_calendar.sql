@@ -0,0 +1,47 @@
+-- Migration 0021: Google Calendar push
+-- Users can connect a Google account; their household's confirmed registrations and
+-- their bookings are pushed to Google Calendar and kept in step as they change
+
+-- One connection per user. The refresh token is encrypted by the API before storage
+-- and cleared when the user disconnects.
+CREATE TABLE IF NOT EXISTS google_calendar_connections (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    refresh_token_encrypted TEXT,
+    calendar_id TEXT NOT NULL DEFAULT 'primary',
+    connected_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    revoked_at TIMESTAMPTZ
+);
+
+-- Google events created for a registration or booking. A program registration can map
+-- to one event per session; occurrence_key tells them apart ('' for single events).
+CREATE TABLE IF NOT EXISTS google_calendar_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('registration', 'booking')),
+    entity_id UUID NOT NULL,
+    occurrence_key TEXT NOT NULL DEFAULT '',
+    google_event_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, entity_type, entity_id, occurrence_key)
+);
+
+-- Registrations and bookings whose Google events need to be brought up to date.
+-- Queued in the same transaction as the change; one pending row per user and entity.
+CREATE TABLE IF NOT EXISTS calendar_sync_queue (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    entity_type TEXT NOT NULL CHECK (entity_type IN ('registration', 'booking')),
+    entity_id UUID NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    last_error TEXT,
+    not_before_ts TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (user_id, entity_type, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_calendar_sync_queue_pending ON calendar_sync_queue(created_at) WHERE attempts < max_attempts;
+
+COMMENT ON TABLE google_calendar_connections IS 'Opt-in Google Calendar connections; revoked_at is set when the user disconnects';
+COMMENT ON TABLE calendar_sync_queue IS 'Registrations and bookings to push to connected Google calendars';
@@ -0,0 +1,58 @@
+-- Migration 0022: Registration approval
+-- Programs with eligibility review (scholarships, inclusion support) can require staff to
+-- approve each registration. Those registrations start as 'pending', do not hold a spot,
+-- and are confirmed (or waitlisted when full) or cancelled by an admin
+
+ALTER TYPE reg_status ADD VALUE IF NOT EXISTS 'pending';
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS requires_approval BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'REGISTRATION_PENDING_REVIEW';
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'REGISTRATION_REJECTED';
+
+COMMENT ON COLUMN programs.requires_approval IS 'New registrations are pending until an admin approves or rejects them';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'REGISTRATION_PENDING_REVIEW',
+    'Registration Awaiting Approval - {{.ProgramTitle}}',
+    '<h2>Registration Awaiting Approval</h2>
+    <p>A new registration needs review:</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <h3>{{.ProgramTitle}}</h3>
+        <p><strong>Participant:</strong> {{.ParticipantName}}</p>
+        {{if .SessionDate}}<p><strong>Date:</strong> {{.SessionDate}}</p>{{end}}
+    </div>
+    <p>Approve or reject it from the admin registrations page.</p>',
+    'Registration Awaiting Approval
+
+A new registration needs review:
+
+Program: {{.ProgramTitle}}
+Participant: {{.ParticipantName}}
+{{if .SessionDate}}Date: {{.SessionDate}}{{end}}
+
+Approve or reject it from the admin registrations page.'
+),
+(
+    'REGISTRATION_REJECTED',
+    'Registration Not Approved - {{.ProgramTitle}}',
+    '<h2>Registration Not Approved</h2>
+    <p>Hi {{.ParticipantName}},</p>
+    <p>Unfortunately your registration for <strong>{{.ProgramTitle}}</strong> was not approved.</p>
+    {{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
+    <p>If you have questions, please contact us.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Registration Not Approved
+
+Hi {{.ParticipantName}},
+
+Unfortunately your registration for {{.ProgramTitle}} was not approved.
+{{if .Reason}}Reason: {{.Reason}}{{end}}
+
+If you have questions, please contact us.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
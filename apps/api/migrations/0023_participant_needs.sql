@@ -0,0 +1,13 @@
+-- Migration 0023: Structured dietary and accessibility needs
+-- Lets staff count and plan for allergies, diets and accommodations instead of reading
+-- free-text medical notes. Codes are validated by the API; free-text detail stays in
+-- medical_notes.
+
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS dietary_restrictions TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS accessibility_needs TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_participants_dietary ON participants USING GIN (dietary_restrictions);
+CREATE INDEX IF NOT EXISTS idx_participants_accessibility ON participants USING GIN (accessibility_needs);
+
+COMMENT ON COLUMN participants.dietary_restrictions IS 'Dietary restriction and allergy codes, e.g. nut_allergy, vegetarian';
+COMMENT ON COLUMN participants.accessibility_needs IS 'Accessibility accommodation codes, e.g. wheelchair_access, sensory_support';
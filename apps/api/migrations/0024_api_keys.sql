@@ -0,0 +1,27 @@
+-- Migration 0024: API keys
+-- Scripts and the central platform call the API with an Authorization: Bearer key
+-- instead of a browser session. Each key acts as its own service user, so actor columns
+-- (created_by, changed_by, ...) keep pointing at users. Only a SHA-256 hash of the key
+-- is stored; the plaintext is shown once when the key is issued.
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS is_service BOOLEAN NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN users.is_service IS 'Service identity behind an API key; cannot log in and receives no email';
+
+CREATE TABLE IF NOT EXISTS api_keys (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  name TEXT NOT NULL,
+  key_prefix TEXT NOT NULL,
+  key_hash TEXT UNIQUE NOT NULL,
+  service_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  scopes TEXT[] NOT NULL DEFAULT '{}',
+  created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  last_used_at TIMESTAMPTZ,
+  revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_service_user ON api_keys(service_user_id);
+
+COMMENT ON COLUMN api_keys.key_prefix IS 'First characters of the key, to recognise it without the secret';
+COMMENT ON COLUMN api_keys.scopes IS 'Admin API access granted to the key: admin:read, admin:write';
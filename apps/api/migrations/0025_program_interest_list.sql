@@ -0,0 +1,42 @@
+-- Migration 0025: Registration opening time and interest list
+-- Programs can set when registration opens. Until then families can join an interest
+-- list; everyone on it is emailed, in the order they joined, once registration opens.
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS registration_opens_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN programs.registration_opens_at IS 'Registrations are refused before this time; NULL means open';
+
+CREATE TABLE IF NOT EXISTS interest_list (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  notified_at TIMESTAMPTZ,
+  UNIQUE (program_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_list_pending ON interest_list(program_id, created_at) WHERE notified_at IS NULL;
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'REGISTRATION_OPEN';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'REGISTRATION_OPEN',
+    'Registration Is Open - {{.ProgramTitle}}',
+    '<h2>Registration Is Open</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>Registration for <strong>{{.ProgramTitle}}</strong> is now open. You asked us to let you know.</p>
+    <p>Spots are limited, so register soon.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Registration Is Open
+
+Hi {{.UserFirstName}},
+
+Registration for {{.ProgramTitle}} is now open. You asked us to let you know.
+
+Spots are limited, so register soon.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
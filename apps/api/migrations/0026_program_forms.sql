@@ -0,0 +1,14 @@
+-- Migration 0026: Program forms
+-- Assigns form templates to programs the way program_waivers assigns waivers, so staff
+-- can see which participants still owe a required form before a program starts
+
+CREATE TABLE IF NOT EXISTS program_forms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    program_id UUID NOT NULL REFERENCES programs(id) ON DELETE CASCADE,
+    form_template_id UUID NOT NULL REFERENCES form_templates(id) ON DELETE CASCADE,
+    is_required BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE(program_id, form_template_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_program_forms_program ON program_forms(program_id);
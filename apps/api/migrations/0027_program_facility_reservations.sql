@@ -0,0 +1,15 @@
+-- Migration 0027: Program facility reservations
+-- Facility bookings can be tied to the program session they reserve the facility for.
+-- They are ordinary confirmed bookings, so availability checks treat them like any other.
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS program_id UUID REFERENCES programs(id) ON DELETE SET NULL;
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS session_id UUID REFERENCES sessions(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_bookings_program ON facility_bookings(program_id) WHERE program_id IS NOT NULL;
+
+-- A session reserves a facility at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_session_facility ON facility_bookings(session_id, facility_id)
+    WHERE session_id IS NOT NULL AND status = 'confirmed';
+
+COMMENT ON COLUMN facility_bookings.program_id IS 'Program the facility is reserved for; NULL for ordinary bookings';
+COMMENT ON COLUMN facility_bookings.session_id IS 'Program session the facility is reserved for';
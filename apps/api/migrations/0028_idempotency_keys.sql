@@ -0,0 +1,20 @@
+-- Migration 0028: Idempotency key store
+-- Idempotency keys used to live on facility_bookings forever. They now go in their own
+-- table with a snapshot of the original response, are honoured for a limited time and are
+-- purged by the maintenance job; a key older than that is treated as new.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  scope TEXT NOT NULL,
+  key TEXT NOT NULL,
+  response JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created ON idempotency_keys(created_at);
+
+COMMENT ON COLUMN idempotency_keys.scope IS 'Operation the key applies to, e.g. booking or registration';
+COMMENT ON COLUMN idempotency_keys.response IS 'Snapshot of the result returned when the key was first used';
+
+DROP INDEX IF EXISTS idx_bookings_idempotency;
+ALTER TABLE facility_bookings DROP COLUMN IF EXISTS idempotency_key;
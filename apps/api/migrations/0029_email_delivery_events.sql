@@ -0,0 +1,45 @@
+-- Migration 0029: Email delivery tracking
+-- Every sent email gets a Message-ID recorded against the notification that produced it.
+-- The email provider reports deliveries, bounces and complaints to a webhook; hard-bounced
+-- and complaining addresses are suppressed so they are not emailed again.
+
+CREATE TABLE IF NOT EXISTS email_messages (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  message_id TEXT NOT NULL UNIQUE,
+  notification_id BIGINT,
+  notification_type TEXT,
+  notification_payload JSONB,
+  recipient TEXT NOT NULL,
+  subject TEXT NOT NULL,
+  status TEXT NOT NULL DEFAULT 'sent' CHECK (status IN ('sent', 'delivered', 'bounced', 'complained')),
+  sent_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  status_updated_at TIMESTAMPTZ
+);
+
+COMMENT ON COLUMN email_messages.notification_id IS 'notification_queue row that produced the email; the row itself is deleted once sent';
+
+CREATE INDEX IF NOT EXISTS idx_email_messages_recipient ON email_messages(lower(recipient));
+
+CREATE TABLE IF NOT EXISTS email_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  email_message_id UUID REFERENCES email_messages(id) ON DELETE CASCADE,
+  message_id TEXT NOT NULL,
+  event_type TEXT NOT NULL CHECK (event_type IN ('delivered', 'bounce', 'complaint')),
+  bounce_type TEXT CHECK (bounce_type IN ('hard', 'soft')),
+  recipient TEXT,
+  detail TEXT,
+  occurred_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_events_message ON email_events(email_message_id);
+
+CREATE TABLE IF NOT EXISTS email_suppressions (
+  email TEXT PRIMARY KEY,
+  reason TEXT NOT NULL CHECK (reason IN ('hard_bounce', 'complaint')),
+  detail TEXT,
+  email_message_id UUID REFERENCES email_messages(id) ON DELETE SET NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE email_suppressions IS 'Lower-cased addresses that are no longer emailed';
@@ -0,0 +1,15 @@
+-- Migration 0030: Publish windows for programs and facilities
+-- Programs and facilities can be staged ahead of time: they appear in public listings only
+-- from published_at and are hidden again from unpublished_at. Admins always see them.
+-- This is separate from is_active, which still switches an item off entirely.
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS unpublished_at TIMESTAMPTZ;
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS unpublished_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN programs.published_at IS 'Hidden from the public before this time; NULL means published';
+COMMENT ON COLUMN programs.unpublished_at IS 'Hidden from the public from this time; NULL means never';
+COMMENT ON COLUMN facilities.published_at IS 'Hidden from the public before this time; NULL means published';
+COMMENT ON COLUMN facilities.unpublished_at IS 'Hidden from the public from this time; NULL means never';
@@ -0,0 +1,9 @@
+-- Migration 0031: Seats per registration
+-- Capacity is counted in seats rather than registration rows, so a registration that
+-- covers a group can take more than one spot. Every existing registration is one seat.
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS seats INT NOT NULL DEFAULT 1;
+
+ALTER TABLE registrations ADD CONSTRAINT registrations_seats_check CHECK (seats > 0);
+
+COMMENT ON COLUMN registrations.seats IS 'Number of capacity spots this registration takes (default 1)';
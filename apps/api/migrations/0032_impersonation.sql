@@ -0,0 +1,34 @@
+-- Migration 0032: Support impersonation
+-- Admins can sign in as a family for a short, audited session to see exactly what the
+-- family sees. Every request made during the session is logged, including requests that
+-- were refused because impersonation does not allow them.
+
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  admin_user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reason TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  expires_at TIMESTAMPTZ NOT NULL,
+  ended_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_created ON impersonation_sessions(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_user ON impersonation_sessions(user_id);
+
+CREATE TABLE IF NOT EXISTS impersonation_actions (
+  id BIGSERIAL PRIMARY KEY,
+  session_id UUID NOT NULL REFERENCES impersonation_sessions(id) ON DELETE CASCADE,
+  method TEXT NOT NULL,
+  path TEXT NOT NULL,
+  status INT NOT NULL,
+  blocked BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_actions_session ON impersonation_actions(session_id, created_at);
+
+COMMENT ON TABLE impersonation_sessions IS 'Admin support sessions acting as another user';
+COMMENT ON COLUMN impersonation_sessions.ended_at IS 'Set when the admin ends the session before it expires';
+COMMENT ON TABLE impersonation_actions IS 'Audit log of every request made during an impersonation session';
+COMMENT ON COLUMN impersonation_actions.blocked IS 'Refused because the action is not allowed while impersonating';
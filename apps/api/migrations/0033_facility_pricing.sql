@@ -0,0 +1,32 @@
+-- Migration 0033: Facility pricing
+-- A facility has a base hourly rate, and pricing rules charge a different rate for part of
+-- the week (e.g. evenings and weekends at a peak rate). A booking is priced minute by
+-- minute against the rule covering each part of it, so it can span peak and off-peak.
+-- The price is stored on the booking when it is made.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS hourly_rate_cents INT;
+ALTER TABLE facilities ADD CONSTRAINT facilities_hourly_rate_cents_check
+    CHECK (hourly_rate_cents IS NULL OR hourly_rate_cents >= 0);
+
+CREATE TABLE IF NOT EXISTS facility_pricing_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    facility_id UUID NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+
+    -- Day of week (0=Sunday, 1=Monday, ..., 6=Saturday)
+    day_of_week INT NOT NULL CHECK (day_of_week >= 0 AND day_of_week <= 6),
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL CHECK (end_time > start_time),
+
+    hourly_rate_cents INT NOT NULL CHECK (hourly_rate_cents >= 0),
+    label TEXT, -- e.g. 'Peak', shown in price breakdowns
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pricing_rules_facility ON facility_pricing_rules(facility_id);
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS price_cents INT;
+
+COMMENT ON COLUMN facilities.hourly_rate_cents IS 'Rate outside any pricing rule, in cents per hour; NULL means free';
+COMMENT ON TABLE facility_pricing_rules IS 'Weekly time ranges charged at their own hourly rate instead of the facility base rate';
+COMMENT ON COLUMN facility_bookings.price_cents IS 'Price computed when the booking was made; NULL for bookings made before pricing';
@@ -0,0 +1,9 @@
+-- Migration 0034: Program categories
+-- Groups programs (e.g. Aquatics, Youth Sports, Arts) for listings and the annual
+-- participation report.
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS category TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_programs_category ON programs(category) WHERE category IS NOT NULL;
+
+COMMENT ON COLUMN programs.category IS 'Reporting category; NULL is reported as Uncategorized';
@@ -0,0 +1,32 @@
+-- Migration 0035: Capacity demotion
+-- When an admin lowers a program's capacity below its confirmed registrations and asks to
+-- reconcile, the most recently confirmed registrations move to the top of the waitlist
+-- and their families are told
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'WAITLIST_DEMOTED';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'WAITLIST_DEMOTED',
+    'Moved to Waitlist - {{.ProgramTitle}}',
+    '<h2>You''ve Been Moved to the Waitlist</h2>
+    <p>Hi {{.ParticipantName}},</p>
+    <p>The number of spots in <strong>{{.ProgramTitle}}</strong> has been reduced, and your registration has been moved to the waitlist.</p>
+    <p><strong>Your position:</strong> #{{.Position}}</p>
+    {{if .SessionDate}}<p><strong>Date:</strong> {{.SessionDate}}</p>{{end}}
+    <p>You are at the front of the waitlist and will be confirmed automatically if a spot opens. We''re sorry for the inconvenience.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'You''ve Been Moved to the Waitlist
+
+Hi {{.ParticipantName}},
+
+The number of spots in {{.ProgramTitle}} has been reduced, and your registration has been moved to the waitlist.
+Your position: #{{.Position}}
+{{if .SessionDate}}Date: {{.SessionDate}}{{end}}
+
+You are at the front of the waitlist and will be confirmed automatically if a spot opens. We''re sorry for the inconvenience.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
@@ -0,0 +1,10 @@
+-- Migration 0036: Recurring booking series
+-- A recurring booking (e.g. every Tuesday 6-8pm for a season) is made as one booking per
+-- occurrence; the bookings of a series share a series_id so they can be listed and
+-- cancelled together
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS series_id UUID;
+
+CREATE INDEX IF NOT EXISTS idx_facility_bookings_series ON facility_bookings(series_id) WHERE series_id IS NOT NULL;
+
+COMMENT ON COLUMN facility_bookings.series_id IS 'Shared by the bookings of a recurring series; NULL for single bookings';
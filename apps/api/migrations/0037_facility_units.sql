@@ -0,0 +1,25 @@
+-- Migration 0037: Facility units
+-- A facility with several identical spaces (e.g. four tennis courts or six pool lanes) is
+-- split into units instead of being modelled as separate facilities. Each booking of such
+-- a facility holds one unit, and bookings only conflict with others on the same unit. A
+-- booking without a unit, such as a program reservation, holds the whole facility.
+
+CREATE TABLE IF NOT EXISTS facility_units (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    facility_id UUID NOT NULL REFERENCES facilities(id) ON DELETE CASCADE,
+    name TEXT NOT NULL, -- e.g. 'Court 1', 'Lane 4'
+    sort_order INT NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (facility_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_facility_units_facility ON facility_units(facility_id, sort_order);
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS unit_id UUID REFERENCES facility_units(id);
+
+CREATE INDEX IF NOT EXISTS idx_facility_bookings_unit ON facility_bookings(unit_id, start_time) WHERE unit_id IS NOT NULL;
+
+COMMENT ON TABLE facility_units IS 'Separately bookable spaces within a facility, such as courts or lanes';
+COMMENT ON COLUMN facility_units.is_active IS 'Inactive units keep their bookings but take no new ones';
+COMMENT ON COLUMN facility_bookings.unit_id IS 'Unit held by the booking; NULL holds the whole facility';
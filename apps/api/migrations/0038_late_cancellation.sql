@@ -0,0 +1,18 @@
+-- Migration 0038: Late cancellations
+-- A facility can let bookings be cancelled inside its cancellation cutoff instead of
+-- refusing them. Such cancellations are flagged as late for no-show and penalty
+-- tracking, with the share of the booking's price they forfeit recorded for when
+-- payments are taken.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS allow_late_cancellation BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS late_cancellation_fee_pct INT NOT NULL DEFAULT 0;
+ALTER TABLE facilities ADD CONSTRAINT facilities_late_cancellation_fee_pct_check
+    CHECK (late_cancellation_fee_pct >= 0 AND late_cancellation_fee_pct <= 100);
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS late_cancellation BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS late_cancellation_fee_cents INT;
+
+COMMENT ON COLUMN facilities.allow_late_cancellation IS 'Cancellations inside the cutoff are allowed and flagged as late rather than refused';
+COMMENT ON COLUMN facilities.late_cancellation_fee_pct IS 'Percentage of the booking price forfeited by a late cancellation';
+COMMENT ON COLUMN facility_bookings.late_cancellation IS 'Cancelled inside the facility cancellation cutoff';
+COMMENT ON COLUMN facility_bookings.late_cancellation_fee_cents IS 'Fee forfeited by a late cancellation; NULL when none applies';
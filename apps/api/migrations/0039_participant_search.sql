@@ -0,0 +1,24 @@
+-- Migration 0039: Participant search
+-- Front-desk staff look participants up by name and date of birth across all
+-- households. Results expose personal details, so every search is written to an access
+-- log recording who searched, for what, and how many participants it returned.
+
+CREATE INDEX IF NOT EXISTS idx_participants_last_name ON participants(lower(last_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_participants_first_name ON participants(lower(first_name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_participants_dob ON participants(dob);
+
+CREATE TABLE IF NOT EXISTS pii_access_log (
+  id BIGSERIAL PRIMARY KEY,
+  user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+  action TEXT NOT NULL,
+  details JSONB NOT NULL DEFAULT '{}',
+  result_count INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_pii_access_log_user ON pii_access_log(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_pii_access_log_created ON pii_access_log(created_at DESC);
+
+COMMENT ON TABLE pii_access_log IS 'Audit log of staff lookups that expose personal details';
+COMMENT ON COLUMN pii_access_log.user_id IS 'Staff member or API key service user who made the lookup';
+COMMENT ON COLUMN pii_access_log.details IS 'Search terms used, e.g. {"q": "smith", "dob": "2015-04-02"}';
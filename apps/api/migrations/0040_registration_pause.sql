@@ -0,0 +1,25 @@
+-- Migration 0040: Registration pause
+-- Multi-month programs let a family step away for a while (vacation, injury) without
+-- losing their spot. A paused registration still counts against capacity but the
+-- participant is left off rosters and reminders until it is resumed. Each pause is
+-- recorded with when it started and ended.
+
+ALTER TYPE reg_status ADD VALUE IF NOT EXISTS 'paused';
+
+CREATE TABLE IF NOT EXISTS registration_pauses (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  registration_id UUID NOT NULL REFERENCES registrations(id) ON DELETE CASCADE,
+  paused_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  paused_by UUID REFERENCES users(id) ON DELETE SET NULL,
+  resume_on DATE,
+  reason TEXT,
+  ended_at TIMESTAMPTZ,
+  ended_by UUID REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_registration_pauses_registration ON registration_pauses(registration_id, paused_at DESC);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_registration_pauses_open ON registration_pauses(registration_id) WHERE ended_at IS NULL;
+
+COMMENT ON TABLE registration_pauses IS 'Periods a registration was paused while keeping its spot';
+COMMENT ON COLUMN registration_pauses.resume_on IS 'Date the family expects to return, if given';
+COMMENT ON COLUMN registration_pauses.ended_at IS 'When the registration was resumed or cancelled; NULL while still paused';
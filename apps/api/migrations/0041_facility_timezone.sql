@@ -0,0 +1,9 @@
+-- Migration 0041: Facility time zone
+-- Availability windows and pricing rules are wall-clock times. They were read in the
+-- server's time zone, which gave a facility elsewhere the wrong hours, especially around
+-- daylight saving changes and midnight. Each facility now names the IANA time zone its
+-- windows are in; facilities without one keep using UTC.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN facilities.timezone IS 'IANA time zone, e.g. America/New_York, that availability windows and pricing rules are read in; empty means UTC';
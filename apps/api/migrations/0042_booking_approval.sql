@@ -0,0 +1,66 @@
+-- Migration 0042: Booking approval
+-- Facilities flagged requires_approval (the main gym) take booking requests rather than
+-- bookings. A request starts as 'pending' and does not hold the slot until a facility
+-- manager approves it; a rejected request is kept as 'rejected' with the reason given.
+
+ALTER TABLE facility_bookings DROP CONSTRAINT IF EXISTS facility_bookings_status_check;
+ALTER TABLE facility_bookings ADD CONSTRAINT facility_bookings_status_check
+    CHECK (status IN ('pending', 'confirmed', 'rejected', 'cancelled', 'no_show'));
+
+CREATE INDEX IF NOT EXISTS idx_bookings_pending ON facility_bookings(start_time) WHERE status = 'pending';
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'BOOKING_APPROVED';
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'BOOKING_REJECTED';
+
+COMMENT ON COLUMN facilities.requires_approval IS 'New bookings are pending until an admin approves or rejects them';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'BOOKING_APPROVED',
+    'Booking Approved: {{.FacilityName}} - {{.BookingDate}}',
+    '<h2>Booking Approved</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>Your booking request has been approved and is now confirmed:</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <h3>{{.FacilityName}}</h3>
+        <p><strong>Date:</strong> {{.BookingDate}}</p>
+        <p><strong>Time:</strong> {{.StartTime}} - {{.EndTime}}</p>
+        <p><strong>Location:</strong> {{.Location}}</p>
+    </div>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Booking Approved
+
+Hi {{.UserFirstName}},
+
+Your booking request has been approved and is now confirmed:
+
+Facility: {{.FacilityName}}
+Date: {{.BookingDate}}
+Time: {{.StartTime}} - {{.EndTime}}
+Location: {{.Location}}
+
+Best regards,
+Sterling Recreation'
+),
+(
+    'BOOKING_REJECTED',
+    'Booking Not Approved: {{.FacilityName}} - {{.BookingDate}}',
+    '<h2>Booking Not Approved</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>Unfortunately your booking request for <strong>{{.FacilityName}}</strong> on {{.BookingDate}}, {{.StartTime}} - {{.EndTime}} was not approved.</p>
+    {{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
+    <p>If you have questions, please contact us.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Booking Not Approved
+
+Hi {{.UserFirstName}},
+
+Unfortunately your booking request for {{.FacilityName}} on {{.BookingDate}}, {{.StartTime}} - {{.EndTime}} was not approved.
+{{if .Reason}}Reason: {{.Reason}}{{end}}
+
+If you have questions, please contact us.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
@@ -0,0 +1,13 @@
+-- Migration 0043: Emergency contact for minors
+-- Staff need someone to call when a child is hurt or not picked up. Programs can now
+-- require an emergency contact phone on file before a participant under an age
+-- threshold is registered. The check is on by default for everyone under 18 and can be
+-- turned off or given a different threshold per program, e.g. for adult programs that
+-- admit older teens.
+
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS minor_emergency_contact_required BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE programs ADD COLUMN IF NOT EXISTS minor_age_threshold INT NOT NULL DEFAULT 18
+  CHECK (minor_age_threshold > 0);
+
+COMMENT ON COLUMN programs.minor_emergency_contact_required IS 'Whether participants under minor_age_threshold need an emergency contact phone to register';
+COMMENT ON COLUMN programs.minor_age_threshold IS 'Participants younger than this, as of the program start date, are treated as minors';
@@ -0,0 +1,39 @@
+-- Migration 0044: Booking confirmation emails
+-- A confirmed booking now emails its owner a confirmation with the booking attached as a
+-- calendar invite (booking.ics), queued in the same transaction as the booking. Series
+-- occurrences and program reservations are not emailed one by one.
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'BOOKING_CONFIRMED';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'BOOKING_CONFIRMED',
+    'Booking Confirmed: {{.FacilityName}} - {{.BookingDate}}',
+    '<h2>Booking Confirmed</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>Your booking is confirmed:</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <h3>{{.FacilityName}}</h3>
+        <p><strong>Date:</strong> {{.BookingDate}}</p>
+        <p><strong>Time:</strong> {{.StartTime}} - {{.EndTime}}</p>
+        <p><strong>Location:</strong> {{.Location}}</p>
+    </div>
+    <p>A calendar invite is attached so you can add the booking to your calendar.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Booking Confirmed
+
+Hi {{.UserFirstName}},
+
+Your booking is confirmed:
+
+Facility: {{.FacilityName}}
+Date: {{.BookingDate}}
+Time: {{.StartTime}} - {{.EndTime}}
+Location: {{.Location}}
+
+A calendar invite is attached so you can add the booking to your calendar.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
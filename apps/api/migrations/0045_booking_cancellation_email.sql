@@ -0,0 +1,45 @@
+-- Migration 0045: Booking cancellation emails
+-- Cancelling a booking emails its owner, with a calendar cancellation (booking.ics) that
+-- removes the event sent with the confirmation. The payload records whether the owner or
+-- an administrator cancelled it, so the email can say which.
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'BOOKING_CANCELLED';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'BOOKING_CANCELLED',
+    'Booking Cancelled: {{.FacilityName}} - {{.BookingDate}}',
+    '<h2>Booking Cancelled</h2>
+    <p>Hi {{.UserFirstName}},</p>
+    <p>{{if .CancelledByAdmin}}An administrator cancelled your booking:{{else}}Your booking has been cancelled as requested:{{end}}</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <h3>{{.FacilityName}}</h3>
+        <p><strong>Date:</strong> {{.BookingDate}}</p>
+        <p><strong>Time:</strong> {{.StartTime}} - {{.EndTime}}</p>
+        <p><strong>Location:</strong> {{.Location}}</p>
+    </div>
+    {{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
+    <p>The attached calendar update removes the booking from your calendar.</p>
+    {{if .CancelledByAdmin}}<p>If you have questions, please contact us.</p>{{end}}
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Booking Cancelled
+
+Hi {{.UserFirstName}},
+
+{{if .CancelledByAdmin}}An administrator cancelled your booking:{{else}}Your booking has been cancelled as requested:{{end}}
+
+Facility: {{.FacilityName}}
+Date: {{.BookingDate}}
+Time: {{.StartTime}} - {{.EndTime}}
+Location: {{.Location}}
+{{if .Reason}}
+Reason: {{.Reason}}
+{{end}}
+The attached calendar update removes the booking from your calendar.
+{{if .CancelledByAdmin}}
+If you have questions, please contact us.
+{{end}}
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
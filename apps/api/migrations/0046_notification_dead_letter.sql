@@ -0,0 +1,15 @@
+-- Migration 0046: Notification dead letters
+-- A notification that used up its attempts was left in the queue with nothing to show it
+-- had given up. It is now dead-lettered with failed_at, like failed sync_events, so
+-- admins can list what failed and send it again.
+
+ALTER TABLE notification_queue ADD COLUMN IF NOT EXISTS failed_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN notification_queue.failed_at IS 'When the notification used up its attempts and stopped being sent; NULL while pending';
+
+-- Notifications that already gave up are dead-lettered as of now
+UPDATE notification_queue SET failed_at = now() WHERE attempts >= max_attempts AND failed_at IS NULL;
+
+DROP INDEX IF EXISTS idx_notification_queue_pending;
+CREATE INDEX IF NOT EXISTS idx_notification_queue_pending ON notification_queue(not_before_ts) WHERE failed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_notification_queue_failed ON notification_queue(failed_at) WHERE failed_at IS NOT NULL;
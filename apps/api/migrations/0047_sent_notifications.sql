@@ -0,0 +1,25 @@
+-- Migration 0047: Sent notification archive
+-- A notification the email worker has processed used to be deleted from the queue, leaving
+-- only its emails in email_messages. It now moves to sent_notifications under the same id,
+-- so there is a record of what was sent and when.
+
+CREATE TABLE IF NOT EXISTS sent_notifications (
+  id BIGINT PRIMARY KEY,
+  type notif_type NOT NULL,
+  payload JSONB NOT NULL,
+  attempts INT NOT NULL,
+  queued_at TIMESTAMPTZ NOT NULL,
+  processed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+COMMENT ON TABLE sent_notifications IS 'notification_queue rows processed successfully, keeping their queue id';
+COMMENT ON COLUMN sent_notifications.attempts IS 'Attempts taken, including the successful one';
+COMMENT ON COLUMN email_messages.notification_id IS 'notification that produced the email; in notification_queue until sent, then in sent_notifications';
+
+CREATE INDEX IF NOT EXISTS idx_email_messages_notification ON email_messages(notification_id);
+
+-- The reminder scheduler checks sent_notifications as well as the queue before queueing a
+-- reminder, since each session falls in its window for two hourly runs. The archive only
+-- grows, so the check looks reminders up by participant.
+CREATE INDEX IF NOT EXISTS idx_sent_notifications_participant
+  ON sent_notifications(type, (payload->>'participant_id'));
@@ -0,0 +1,19 @@
+-- Migration 0048: Booking holds
+-- Facilities flagged requires_confirmation book in two steps. Reserving a slot creates a
+-- 'held' booking that blocks the slot for a few minutes while the user reviews it;
+-- confirming turns the hold into a confirmed (or, at facilities that require approval,
+-- pending) booking. A hold not confirmed in time stops blocking the slot once
+-- hold_expires_at passes and is later marked 'expired' by the hold sweeper.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS requires_confirmation BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE facility_bookings ADD COLUMN IF NOT EXISTS hold_expires_at TIMESTAMPTZ;
+
+ALTER TABLE facility_bookings DROP CONSTRAINT IF EXISTS facility_bookings_status_check;
+ALTER TABLE facility_bookings ADD CONSTRAINT facility_bookings_status_check
+    CHECK (status IN ('held', 'pending', 'confirmed', 'rejected', 'cancelled', 'no_show', 'expired'));
+
+CREATE INDEX IF NOT EXISTS idx_bookings_held ON facility_bookings(hold_expires_at) WHERE status = 'held';
+
+COMMENT ON COLUMN facilities.requires_confirmation IS 'Bookings are reserved as a short hold and must be confirmed before it expires';
+COMMENT ON COLUMN facility_bookings.hold_expires_at IS 'When a held booking stops blocking its slot unless confirmed';
@@ -0,0 +1,7 @@
+-- Migration 0049: Reminder notification types
+-- The reminder scheduler queues REMINDER_72H and REMINDER_24H notifications, named after
+-- their email templates, but notif_type only had a generic 'REMINDER', so every reminder
+-- insert was rejected. 'REMINDER' is kept for any rows already using it.
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'REMINDER_72H';
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'REMINDER_24H';
@@ -0,0 +1,9 @@
+-- Migration 0050: Same-day booking cutoff
+-- Some facilities need their bookings in by a set time the day before, e.g. so the pool
+-- can schedule lifeguards. When same_day_cutoff_time is set, a day's slots can no longer
+-- be booked once that time has passed on the previous day in the facility's time zone.
+-- This is separate from advance_booking_days, which limits how far ahead booking opens.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS same_day_cutoff_time TIME;
+
+COMMENT ON COLUMN facilities.same_day_cutoff_time IS 'Time on the day before after which a day can no longer be booked; NULL for no cutoff';
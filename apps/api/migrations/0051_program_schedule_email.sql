@@ -0,0 +1,38 @@
+-- Migration 0051: Season schedule emails
+-- Staff can email every confirmed registrant of a program the sessions they are registered
+-- for, with the sessions attached as calendar events (schedule.ics). Each registration is
+-- queued as its own PROGRAM_SCHEDULE notification.
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'PROGRAM_SCHEDULE';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+    'PROGRAM_SCHEDULE',
+    'Your Schedule: {{.ProgramTitle}}',
+    '<h2>Your Season Schedule</h2>
+    <p>Hi {{.ParticipantName}},</p>
+    <p>Here is your schedule for <strong>{{.ProgramTitle}}</strong>:</p>
+    <div style="border: 1px solid #ddd; padding: 16px; margin: 16px 0; border-radius: 4px;">
+        <p><strong>Location:</strong> {{.Location}}</p>
+        <ul>
+        {{range .Sessions}}<li>{{.Date}}, {{.StartTime}}{{if .EndTime}} - {{.EndTime}}{{end}}</li>
+        {{end}}</ul>
+    </div>
+    <p>The sessions are attached as a calendar file so you can add them to your calendar.</p>
+    <p>Best regards,<br>Sterling Recreation</p>',
+    'Your Season Schedule
+
+Hi {{.ParticipantName}},
+
+Here is your schedule for {{.ProgramTitle}}:
+
+Location: {{.Location}}
+{{range .Sessions}}
+- {{.Date}}, {{.StartTime}}{{if .EndTime}} - {{.EndTime}}{{end}}{{end}}
+
+The sessions are attached as a calendar file so you can add them to your calendar.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
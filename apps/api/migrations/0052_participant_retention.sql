@@ -0,0 +1,24 @@
+-- Migration 0052: Participant PII retention
+-- Medical notes, emergency contacts and dietary/accessibility needs are scrubbed from
+-- participants with no activity within the retention window, unless they are under a
+-- legal hold. Registrations are kept for statistics. Each purge is logged with the
+-- fields it cleared.
+
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS legal_hold_reason TEXT;
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS pii_purged_at TIMESTAMPTZ;
+
+COMMENT ON COLUMN participants.legal_hold IS 'Exempts the participant from the PII retention purge';
+COMMENT ON COLUMN participants.pii_purged_at IS 'When sensitive fields were last scrubbed by the retention purge';
+
+CREATE TABLE IF NOT EXISTS participant_pii_purges (
+  id BIGSERIAL PRIMARY KEY,
+  participant_id UUID NOT NULL, -- kept without a foreign key so the log outlives the participant
+  last_activity_at TIMESTAMPTZ NOT NULL,
+  fields TEXT[] NOT NULL,
+  purged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_participant_pii_purges_participant ON participant_pii_purges(participant_id, purged_at);
+
+COMMENT ON TABLE participant_pii_purges IS 'Log of sensitive participant fields scrubbed by the retention purge';
@@ -0,0 +1,44 @@
+-- Migration 0053: Time-boxed waitlist offers
+-- A spot opening up no longer confirms the next waitlisted registration outright. It is
+-- 'offered' the spot, which it holds until offer_expires_at; the family accepts to be
+-- confirmed or declines, and an offer left to expire is cancelled and the spot offered to
+-- the next person. The offer email (WAITLIST_OFFER) gives the deadline.
+
+ALTER TYPE reg_status ADD VALUE IF NOT EXISTS 'offered';
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'WAITLIST_OFFER';
+
+ALTER TABLE registrations ADD COLUMN IF NOT EXISTS offer_expires_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_registrations_offer_expires ON registrations(offer_expires_at)
+  WHERE offer_expires_at IS NOT NULL;
+
+COMMENT ON COLUMN registrations.offer_expires_at IS 'Deadline to accept an offered waitlist spot; NULL unless the registration is offered';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+  'WAITLIST_OFFER',
+  'Spot Available - {{.ProgramTitle}}',
+  '<h1>A Spot is Available!</h1>
+<p>Hi {{.ParticipantName}},</p>
+<p>Great news! A spot has opened up for <strong>{{.ProgramTitle}}</strong> and it is being held for you.</p>
+{{if .SessionDate}}<p><strong>Date:</strong> {{.SessionDate}}</p>{{end}}
+{{if .Location}}<p><strong>Location:</strong> {{.Location}}</p>{{end}}
+<p><strong>Please log in to accept the spot by {{.OfferExpiresAt}}.</strong> If you do not accept it by then, it will be offered to the next person on the waitlist.</p>
+<p>If you no longer want the spot, you can decline it so the next family can have it sooner.</p>
+<p>Best regards,<br>Sterling Recreation</p>',
+  'A Spot is Available!
+
+Hi {{.ParticipantName}},
+
+Great news! A spot has opened up for {{.ProgramTitle}} and it is being held for you.
+{{if .SessionDate}}Date: {{.SessionDate}}{{end}}
+{{if .Location}}Location: {{.Location}}{{end}}
+
+Please log in to accept the spot by {{.OfferExpiresAt}}. If you do not accept it by then, it will be offered to the next person on the waitlist.
+
+If you no longer want the spot, you can decline it so the next family can have it sooner.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
@@ -0,0 +1,16 @@
+-- Migration 0054: Unit-aware double-booking index
+-- idx_no_overlapping_bookings predates facility units and keys on the facility alone, so
+-- two different courts of the same facility could not be booked for the same slot. It is
+-- rebuilt with the unit; a booking without a unit holds the whole facility and is keyed
+-- on the nil UUID, so two whole-facility bookings of the same slot still collide.
+
+DROP INDEX IF EXISTS idx_no_overlapping_bookings;
+
+CREATE UNIQUE INDEX idx_no_overlapping_bookings ON facility_bookings (
+    facility_id,
+    COALESCE(unit_id, '00000000-0000-0000-0000-000000000000'::uuid),
+    start_time,
+    end_time
+) WHERE status = 'confirmed';
+
+COMMENT ON INDEX idx_no_overlapping_bookings IS 'Prevents double-booking a slot of the same unit, or of the whole facility, at the database level';
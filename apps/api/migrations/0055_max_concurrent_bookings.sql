@@ -0,0 +1,26 @@
+-- Migration 0055: Concurrent booking limit
+-- How many bookings a shared facility such as a pavilion takes at the same time is its
+-- own setting rather than its capacity, which is a headcount (a gym holding 60 people is
+-- still booked by one group at a time). Every facility starts at one.
+--
+-- idx_no_overlapping_bookings rejected a second booking of the exact slot, which would
+-- refuse a shared facility's second booking. It is replaced by an index on unit
+-- bookings only, since a unit always takes one booking at a time; whole-facility
+-- bookings are checked against max_concurrent_bookings under the booking lock.
+
+ALTER TABLE facilities ADD COLUMN IF NOT EXISTS max_concurrent_bookings INT NOT NULL DEFAULT 1;
+
+ALTER TABLE facilities ADD CONSTRAINT facilities_max_concurrent_bookings_check CHECK (max_concurrent_bookings > 0);
+
+COMMENT ON COLUMN facilities.max_concurrent_bookings IS 'Bookings without a unit allowed to overlap (default 1); capacity is a headcount and does not limit bookings';
+
+DROP INDEX IF EXISTS idx_no_overlapping_bookings;
+
+CREATE UNIQUE INDEX idx_no_overlapping_bookings ON facility_bookings (
+    facility_id,
+    unit_id,
+    start_time,
+    end_time
+) WHERE status = 'confirmed' AND unit_id IS NOT NULL;
+
+COMMENT ON INDEX idx_no_overlapping_bookings IS 'Prevents double-booking a slot of the same unit at the database level';
@@ -0,0 +1,14 @@
+-- Migration 0056: Email messages by user
+-- Notification history matched a user's emails by their current address, so a user who
+-- changed their email lost their history and whoever took the old address gained it. Each
+-- email now records the user it was addressed to when it was sent.
+
+ALTER TABLE email_messages ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE SET NULL;
+
+COMMENT ON COLUMN email_messages.user_id IS 'User whose address the email was sent to, when it was sent; NULL for addresses without an account';
+
+UPDATE email_messages m SET user_id = u.id
+FROM users u
+WHERE m.user_id IS NULL AND lower(u.email) = lower(m.recipient);
+
+CREATE INDEX IF NOT EXISTS idx_email_messages_user ON email_messages(user_id, sent_at DESC) WHERE user_id IS NOT NULL;
@@ -0,0 +1,32 @@
+-- Migration 0057: Waitlist removal email
+-- With removal of opted-out entries configured, a waitlisted registration that opted out
+-- of promotion is cancelled when its turn comes. The family is now told why their
+-- registration was cancelled (WAITLIST_REMOVED).
+
+ALTER TYPE notif_type ADD VALUE IF NOT EXISTS 'WAITLIST_REMOVED';
+
+INSERT INTO email_templates (template_key, subject, body_html, body_text) VALUES
+(
+  'WAITLIST_REMOVED',
+  'Removed from Waitlist - {{.ProgramTitle}}',
+  '<h2>You''ve Been Removed from the Waitlist</h2>
+<p>Hi {{.ParticipantName}},</p>
+<p>A spot opened up in <strong>{{.ProgramTitle}}</strong>, but your registration had opted out of being promoted, so it has been cancelled and the spot given to the next person on the waitlist.</p>
+{{if .SessionDate}}<p><strong>Date:</strong> {{.SessionDate}}</p>{{end}}
+{{if .Reason}}<p><strong>Reason:</strong> {{.Reason}}</p>{{end}}
+<p>If you would still like to take part, you are welcome to register again.</p>
+<p>Best regards,<br>Sterling Recreation</p>',
+  'You''ve Been Removed from the Waitlist
+
+Hi {{.ParticipantName}},
+
+A spot opened up in {{.ProgramTitle}}, but your registration had opted out of being promoted, so it has been cancelled and the spot given to the next person on the waitlist.
+{{if .SessionDate}}Date: {{.SessionDate}}{{end}}
+{{if .Reason}}Reason: {{.Reason}}{{end}}
+
+If you would still like to take part, you are welcome to register again.
+
+Best regards,
+Sterling Recreation'
+)
+ON CONFLICT (template_key) DO NOTHING;
@@ -0,0 +1,14 @@
+-- Migration 0058: Participant edit time
+-- The retention purge judged activity by registrations alone, so a participant whose
+-- family had just edited their details could still be purged. Participants now record
+-- when they were last edited, which counts as activity. Existing participants start at
+-- their creation time so the column does not reset every retention window.
+
+ALTER TABLE participants ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+
+UPDATE participants SET updated_at = created_at WHERE updated_at IS NULL;
+
+ALTER TABLE participants ALTER COLUMN updated_at SET DEFAULT now();
+ALTER TABLE participants ALTER COLUMN updated_at SET NOT NULL;
+
+COMMENT ON COLUMN participants.updated_at IS 'When the family last edited the participant; counts as activity for the retention purge';
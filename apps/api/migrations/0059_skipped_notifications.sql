@@ -0,0 +1,9 @@
+-- Migration 0059: Skipped notifications
+-- Offers, promotions, reminders and schedules that no longer applied by the time the
+-- worker reached them were archived to sent_notifications like the ones that went out.
+-- They now keep the reason they were dropped, and only rows without one were sent.
+
+ALTER TABLE sent_notifications ADD COLUMN IF NOT EXISTS skipped_reason TEXT;
+
+COMMENT ON TABLE sent_notifications IS 'notification_queue rows processed successfully, keeping their queue id; skipped_reason is set on those dropped without sending';
+COMMENT ON COLUMN sent_notifications.skipped_reason IS 'Why the notification was dropped rather than sent; NULL when it was sent';
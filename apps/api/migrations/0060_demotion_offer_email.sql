@@ -0,0 +1,16 @@
+-- Migration 0060: Demotion email with waitlist offers
+-- WAITLIST_DEMOTED promised the family they would be confirmed automatically when a spot
+-- opened, but with waitlist offers on (the default) moving up the waitlist produces an
+-- offer that has to be accepted. The email now describes the offer and how long there is
+-- to accept it, and keeps the old wording when WAITLIST_OFFER_HOURS=0 turns offers off.
+
+UPDATE email_templates
+SET body_html = replace(body_html,
+        '<p>You are at the front of the waitlist and will be confirmed automatically if a spot opens. We''re sorry for the inconvenience.</p>',
+        '{{if .OfferHours}}<p>You are at the front of the waitlist. If a spot opens, it will be offered to you first and you will have {{.OfferHours}} hours to accept it before it goes to the next family. We''re sorry for the inconvenience.</p>{{else}}<p>You are at the front of the waitlist and will be confirmed automatically if a spot opens. We''re sorry for the inconvenience.</p>{{end}}'),
+    body_text = replace(body_text,
+        'You are at the front of the waitlist and will be confirmed automatically if a spot opens. We''re sorry for the inconvenience.',
+        '{{if .OfferHours}}You are at the front of the waitlist. If a spot opens, it will be offered to you first and you will have {{.OfferHours}} hours to accept it before it goes to the next family.{{else}}You are at the front of the waitlist and will be confirmed automatically if a spot opens.{{end}} We''re sorry for the inconvenience.'),
+    updated_at = now()
+WHERE template_key = 'WAITLIST_DEMOTED'
+  AND body_html NOT LIKE '%OfferHours%';
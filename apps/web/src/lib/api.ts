@@ -315,7 +315,7 @@ export const programsAPI = {
   getAll: () => api.get<{ programs: Program[] }>('/programs'),
   getBySlug: (slug: string) => api.get<{ program: Program }>(`/programs/${slug}`),
   list: async () => {
-    const { data } = await getAPI().get('/programs')
+    const { data } = await getAPI().get('/admin/programs')
     return data
   },
   create: async (program: any) => {